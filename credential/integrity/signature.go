@@ -7,8 +7,10 @@ import (
 	"reflect"
 
 	"github.com/extrimian/ssi-sdk/credential"
+	"github.com/extrimian/ssi-sdk/crypto"
 	"github.com/extrimian/ssi-sdk/crypto/jwx"
 	"github.com/extrimian/ssi-sdk/did"
+	"github.com/extrimian/ssi-sdk/did/pkh"
 	"github.com/extrimian/ssi-sdk/did/resolution"
 
 	"github.com/pkg/errors"
@@ -81,13 +83,13 @@ func VerifyJWTCredential(ctx context.Context, cred string, r resolution.Resolver
 	if err != nil {
 		return false, errors.Wrapf(err, "error getting issuer DID<%s> to verify credential<%s>", token.Issuer(), token.JwtID())
 	}
-	issuerKey, err := did.GetKeyFromVerificationMethod(issuerDID.Document, issuerKID)
-	if err != nil {
-		return false, errors.Wrapf(err, "error getting key to verify credential<%s>", token.JwtID())
-	}
-
 	// construct a verifier
-	credVerifier, err := jwx.NewJWXVerifier(issuerDID.ID, issuerKID, issuerKey)
+	var credVerifier *jwx.Verifier
+	if headers.Algorithm() == jwx.ES256KRAlg {
+		credVerifier, err = recoveryVerifier(issuerDID.Document, issuerKID)
+	} else {
+		credVerifier, err = verifier(issuerDID.Document, issuerKID)
+	}
 	if err != nil {
 		return false, errors.Wrapf(err, "error constructing verifier for credential<%s>", token.JwtID())
 	}
@@ -98,6 +100,39 @@ func VerifyJWTCredential(ctx context.Context, cred string, r resolution.Resolver
 	return true, nil
 }
 
+func verifier(issuer did.Document, kid string) (*jwx.Verifier, error) {
+	issuerKey, err := did.GetKeyFromVerificationMethod(issuer, kid)
+	if err != nil {
+		return nil, errors.Wrap(err, "getting key to verify with")
+	}
+	return jwx.NewJWXVerifier(issuer.ID, kid, issuerKey)
+}
+
+// recoveryVerifier constructs an ES256K-R verifier, which verifies the key recovered from the signature against the
+// verification method's key, or the address of its blockchainAccountId, such as those of did:pkh documents
+func recoveryVerifier(issuer did.Document, kid string) (*jwx.Verifier, error) {
+	method, err := did.GetVerificationMethodForKID(issuer, kid)
+	if err != nil {
+		return nil, errors.Wrap(err, "getting verification method to verify with")
+	}
+	if method.BlockchainAccountID == "" {
+		issuerKey, err := did.GetKeyFromVerificationMethod(issuer, kid)
+		if err != nil {
+			return nil, errors.Wrap(err, "getting key to verify with")
+		}
+		return jwx.NewJWXRecoveryVerifier(issuer.ID, kid, issuerKey)
+	}
+
+	accountID, err := pkh.ParseAccountID(method.BlockchainAccountID)
+	if err != nil {
+		return nil, errors.Wrap(err, "parsing blockchain account id")
+	}
+	if accountID.Namespace != pkh.EIP155Namespace {
+		return nil, fmt.Errorf("unsupported blockchain account for %s: %s", jwx.ES256KRAlg, method.BlockchainAccountID)
+	}
+	return jwx.NewJWXRecoveryVerifier(issuer.ID, kid, crypto.EthereumAddress(accountID.Address))
+}
+
 // VerifyDataIntegrityCredential verifies the signature of a Data Integrity credential
 // TODO(gabe): https://github.com/extrimian/ssi-sdk/issues/196
 func VerifyDataIntegrityCredential(_ context.Context, cred credential.VerifiableCredential, _ resolution.Resolver) (bool, error) {
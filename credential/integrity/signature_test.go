@@ -13,6 +13,7 @@ import (
 	"github.com/extrimian/ssi-sdk/crypto"
 	"github.com/extrimian/ssi-sdk/crypto/jwx"
 	"github.com/extrimian/ssi-sdk/did/key"
+	"github.com/extrimian/ssi-sdk/did/pkh"
 	"github.com/extrimian/ssi-sdk/did/resolution"
 	"github.com/extrimian/ssi-sdk/did/web"
 
@@ -235,6 +236,59 @@ func TestVerifyJWTCredential(t *testing.T) {
 		assert.NoError(t, err)
 		assert.True(t, verified)
 	})
+
+	t.Run("valid ES256K-R credential issued by a did:pkh", func(tt *testing.T) {
+		resolver, err := resolution.NewResolver([]resolution.Resolver{pkh.Resolver{}}...)
+		assert.NoError(tt, err)
+
+		pubKey, privKey, err := crypto.GenerateSECP256k1Key()
+		assert.NoError(tt, err)
+		didPKH, err := pkh.CreateDIDPKHFromNetwork(pkh.Ethereum, string(crypto.EthereumAddressFromPublicKey(pubKey)))
+		assert.NoError(tt, err)
+		signer, err := jwx.NewJWXRecoverySigner(didPKH.String(), didPKH.String()+"#blockchainAccountId", privKey)
+		assert.NoError(tt, err)
+
+		jwtCred := getTestJWTCredential(tt, *signer)
+		verified, err := VerifyJWTCredential(context.Background(), jwtCred, resolver)
+		assert.NoError(tt, err)
+		assert.True(tt, verified)
+	})
+
+	t.Run("ES256K-R credential signed by another account", func(tt *testing.T) {
+		resolver, err := resolution.NewResolver([]resolution.Resolver{pkh.Resolver{}}...)
+		assert.NoError(tt, err)
+
+		pubKey, _, err := crypto.GenerateSECP256k1Key()
+		assert.NoError(tt, err)
+		_, otherPrivKey, err := crypto.GenerateSECP256k1Key()
+		assert.NoError(tt, err)
+		didPKH, err := pkh.CreateDIDPKHFromNetwork(pkh.Ethereum, string(crypto.EthereumAddressFromPublicKey(pubKey)))
+		assert.NoError(tt, err)
+		signer, err := jwx.NewJWXRecoverySigner(didPKH.String(), didPKH.String()+"#blockchainAccountId", otherPrivKey)
+		assert.NoError(tt, err)
+
+		jwtCred := getTestJWTCredential(tt, *signer)
+		verified, err := VerifyJWTCredential(context.Background(), jwtCred, resolver)
+		assert.Error(tt, err)
+		assert.False(tt, verified)
+	})
+
+	t.Run("valid ES256K-R credential issued by a secp256k1 did:key", func(tt *testing.T) {
+		resolver, err := resolution.NewResolver([]resolution.Resolver{key.Resolver{}}...)
+		assert.NoError(tt, err)
+
+		privKey, didKey, err := key.GenerateDIDKey(crypto.SECP256k1)
+		assert.NoError(tt, err)
+		expanded, err := didKey.Expand()
+		assert.NoError(tt, err)
+		signer, err := jwx.NewJWXRecoverySigner(didKey.String(), expanded.VerificationMethod[0].ID, privKey)
+		assert.NoError(tt, err)
+
+		jwtCred := getTestJWTCredential(tt, *signer)
+		verified, err := VerifyJWTCredential(context.Background(), jwtCred, resolver)
+		assert.NoError(tt, err)
+		assert.True(tt, verified)
+	})
 }
 
 func getTestJWTCredential(t *testing.T, signer jwx.Signer) string {
@@ -1,12 +1,14 @@
 package crypto
 
 import (
+	gocrypto "crypto"
 	"crypto/rand"
 	"crypto/sha256"
 	"fmt"
 	"strings"
 
 	bbsg2 "github.com/hyperledger/aries-framework-go/pkg/crypto/primitive/bbs12381g2pub"
+	"github.com/pkg/errors"
 )
 
 // GenerateBBSKeyPair https://w3c-ccg.github.io/ldp-bbs2020
@@ -23,6 +25,8 @@ type BBSPlusSigner struct {
 	*bbsg2.PrivateKey
 	*bbsg2.PublicKey
 	*BBSPlusVerifier
+	// remoteSigner signs with a key held by a key manager, in which case there is no private key
+	remoteSigner *RemoteSigner
 }
 
 func NewBBSPlusSigner(kid string, privKey *bbsg2.PrivateKey) *BBSPlusSigner {
@@ -38,16 +42,44 @@ func NewBBSPlusSigner(kid string, privKey *bbsg2.PrivateKey) *BBSPlusSigner {
 	}
 }
 
+// NewBBSPlusSignerFromKeyManager creates a signer of a BLS12381G2 key held by a key manager, which signs the
+// statements of a message as BBS+ signers do
+func NewBBSPlusSignerFromKeyManager(kid string, keyManager KeyManager, keyID string) (*BBSPlusSigner, error) {
+	remoteSigner, err := NewRemoteSigner(keyManager, keyID)
+	if err != nil {
+		return nil, errors.Wrap(err, "creating remote signer")
+	}
+	pubKey, ok := remoteSigner.PublicKey().(*bbsg2.PublicKey)
+	if !ok {
+		return nil, fmt.Errorf("key %s is not a BLS12381G2 key", keyID)
+	}
+	return &BBSPlusSigner{
+		kid:       kid,
+		PublicKey: pubKey,
+		BBSPlusVerifier: &BBSPlusVerifier{
+			KID:       kid,
+			PublicKey: pubKey,
+		},
+		remoteSigner: remoteSigner,
+	}, nil
+}
+
 func (s *BBSPlusSigner) GetKeyID() string {
 	return s.kid
 }
 
 func (s *BBSPlusSigner) Sign(message []byte) ([]byte, error) {
+	if s.remoteSigner != nil {
+		return s.remoteSigner.Sign(rand.Reader, message, gocrypto.Hash(0))
+	}
 	bls := bbsg2.New()
 	return bls.SignWithKey(prepareBBSMessage(message), s.PrivateKey)
 }
 
 func (s *BBSPlusSigner) SignMultiple(messages ...[]byte) ([]byte, error) {
+	if s.remoteSigner != nil {
+		return nil, errors.New("signing multiple messages is not supported by key manager signers")
+	}
 	bls := bbsg2.New()
 	return bls.SignWithKey(messages, s.PrivateKey)
 }
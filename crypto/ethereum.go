@@ -0,0 +1,118 @@
+package crypto
+
+import (
+	"encoding/hex"
+	"strings"
+
+	secp "github.com/decred/dcrd/dcrec/secp256k1/v4"
+	secpecdsa "github.com/decred/dcrd/dcrec/secp256k1/v4/ecdsa"
+	"github.com/pkg/errors"
+	"golang.org/x/crypto/sha3"
+)
+
+const (
+	// RecoverableSignatureSize is the size of a recoverable secp256k1 signature: R || S || V
+	RecoverableSignatureSize = 65
+
+	// compactMagicOffset is the offset of the recovery code in the first byte of a compact signature
+	compactMagicOffset = 27
+)
+
+// EthereumAddress is a hex encoded Ethereum account address, the last 20 bytes of the Keccak-256 hash of
+// an uncompressed secp256k1 public key
+type EthereumAddress string
+
+// Keccak256 returns the legacy Keccak-256 hash of the concatenated data, as used by Ethereum
+func Keccak256(data ...[]byte) []byte {
+	hasher := sha3.NewLegacyKeccak256()
+	for _, d := range data {
+		hasher.Write(d)
+	}
+	return hasher.Sum(nil)
+}
+
+// EthereumAddressFromPublicKey returns the EIP-55 checksummed Ethereum address of a secp256k1 public key
+func EthereumAddressFromPublicKey(key secp.PublicKey) EthereumAddress {
+	hashed := Keccak256(key.SerializeUncompressed()[1:])
+	address := hex.EncodeToString(hashed[12:])
+
+	// https://eips.ethereum.org/EIPS/eip-55
+	checksum := hex.EncodeToString(Keccak256([]byte(address)))
+	checksummed := []byte(address)
+	for i, c := range checksummed {
+		if c >= 'a' && checksum[i] >= '8' {
+			checksummed[i] = c - 'a' + 'A'
+		}
+	}
+	return EthereumAddress("0x" + string(checksummed))
+}
+
+// Matches returns true if the address is that of the public key. Addresses are compared case-insensitively,
+// so both checksummed and lowercase addresses match.
+func (a EthereumAddress) Matches(key secp.PublicKey) bool {
+	return strings.EqualFold(string(a), string(EthereumAddressFromPublicKey(key)))
+}
+
+func (a EthereumAddress) IsValid() bool {
+	address, found := strings.CutPrefix(string(a), "0x")
+	if !found || len(address) != 40 {
+		return false
+	}
+	_, err := hex.DecodeString(address)
+	return err == nil
+}
+
+// SignRecoverableSECP256k1 signs a 32-byte hash with a secp256k1 private key, returning the signature as R || S || V
+// where V is the recovery id, 0 or 1
+func SignRecoverableSECP256k1(key secp.PrivateKey, hash []byte) ([]byte, error) {
+	if len(hash) != 32 {
+		return nil, errors.Errorf("hash must be 32 bytes, got %d", len(hash))
+	}
+	compact := secpecdsa.SignCompact(&key, hash, false)
+	signature := make([]byte, 0, RecoverableSignatureSize)
+	signature = append(signature, compact[1:]...)
+	return append(signature, compact[0]-compactMagicOffset), nil
+}
+
+// RecoverableSECP256k1Signature converts an ASN.1 DER encoded secp256k1 ECDSA signature of the hash, such as one made
+// by a key manager, into a recoverable signature of R || S || V, finding the recovery id V of the public key
+func RecoverableSECP256k1Signature(hash, signature []byte, key secp.PublicKey) ([]byte, error) {
+	rs, err := SECP256k1SignatureToRS(signature)
+	if err != nil {
+		return nil, err
+	}
+	recoverable := append(rs, 0)
+	for v := byte(0); v <= 1; v++ {
+		recoverable[64] = v
+		if recovered, err := RecoverSECP256k1PublicKey(hash, recoverable); err == nil && recovered.IsEqual(&key) {
+			return recoverable, nil
+		}
+	}
+	return nil, errors.New("signature does not recover to the public key")
+}
+
+// RecoverSECP256k1PublicKey recovers the secp256k1 public key that produced a R || S || V signature over a 32-byte
+// hash. V may be the recovery id, 0 or 1, or the recovery id offset by 27 as produced by Ethereum wallets.
+func RecoverSECP256k1PublicKey(hash, signature []byte) (*secp.PublicKey, error) {
+	if len(hash) != 32 {
+		return nil, errors.Errorf("hash must be 32 bytes, got %d", len(hash))
+	}
+	if len(signature) != RecoverableSignatureSize {
+		return nil, errors.Errorf("recoverable signature must be %d bytes, got %d", RecoverableSignatureSize, len(signature))
+	}
+	v := signature[64]
+	if v >= compactMagicOffset {
+		v -= compactMagicOffset
+	}
+	if v > 1 {
+		return nil, errors.Errorf("invalid recovery id: %d", signature[64])
+	}
+	compact := make([]byte, 0, RecoverableSignatureSize)
+	compact = append(compact, v+compactMagicOffset)
+	compact = append(compact, signature[:64]...)
+	key, _, err := secpecdsa.RecoverCompact(compact, hash)
+	if err != nil {
+		return nil, errors.Wrap(err, "recovering public key")
+	}
+	return key, nil
+}
@@ -0,0 +1,62 @@
+package crypto
+
+import (
+	"encoding/hex"
+	"testing"
+
+	secp "github.com/decred/dcrd/dcrec/secp256k1/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEthereumAddress(t *testing.T) {
+	t.Run("known vector", func(tt *testing.T) {
+		// the EIP-712 example account, whose private key is keccak256("cow")
+		privateKey := secp.PrivKeyFromBytes(Keccak256([]byte("cow")))
+		address := EthereumAddressFromPublicKey(*privateKey.PubKey())
+		assert.Equal(tt, EthereumAddress("0xCD2a3d9F938E13CD947Ec05AbC7FE734Df8DD826"), address)
+		assert.True(tt, address.IsValid())
+		assert.True(tt, EthereumAddress("0xcd2a3d9f938e13cd947ec05abc7fe734df8dd826").Matches(*privateKey.PubKey()))
+	})
+
+	t.Run("invalid addresses", func(tt *testing.T) {
+		assert.False(tt, EthereumAddress("cd2a3d9f938e13cd947ec05abc7fe734df8dd826").IsValid())
+		assert.False(tt, EthereumAddress("0xcd2a3d").IsValid())
+		assert.False(tt, EthereumAddress("0xzz2a3d9f938e13cd947ec05abc7fe734df8dd826").IsValid())
+	})
+
+	t.Run("keccak256", func(tt *testing.T) {
+		assert.Equal(tt, "c5d2460186f7233c927e7db2dcc703c0e500b653ca82273b7bfad8045d85a470", hex.EncodeToString(Keccak256()))
+	})
+}
+
+func TestRecoverableSECP256k1Signature(t *testing.T) {
+	publicKey, privateKey, err := GenerateSECP256k1Key()
+	require.NoError(t, err)
+	hash := Keccak256([]byte("hello"))
+
+	signature, err := SignRecoverableSECP256k1(privateKey, hash)
+	require.NoError(t, err)
+	assert.Len(t, signature, RecoverableSignatureSize)
+	assert.LessOrEqual(t, signature[64], byte(1))
+
+	recovered, err := RecoverSECP256k1PublicKey(hash, signature)
+	assert.NoError(t, err)
+	assert.True(t, publicKey.IsEqual(recovered))
+
+	// wallets offset the recovery id by 27
+	signature[64] += 27
+	recovered, err = RecoverSECP256k1PublicKey(hash, signature)
+	assert.NoError(t, err)
+	assert.True(t, publicKey.IsEqual(recovered))
+
+	signature[64] = 5
+	_, err = RecoverSECP256k1PublicKey(hash, signature)
+	assert.ErrorContains(t, err, "invalid recovery id")
+
+	_, err = RecoverSECP256k1PublicKey(hash, signature[:64])
+	assert.ErrorContains(t, err, "recoverable signature must be 65 bytes")
+
+	_, err = SignRecoverableSECP256k1(privateKey, []byte("short"))
+	assert.ErrorContains(t, err, "hash must be 32 bytes")
+}
@@ -1,6 +1,8 @@
 package jwx
 
 import (
+	gocrypto "crypto"
+	"crypto/rand"
 	"fmt"
 
 	"github.com/cloudflare/circl/sign/dilithium"
@@ -68,6 +70,9 @@ func (s DilithiumSignerVerifier) Sign(payload []byte, keyif any) ([]byte, error)
 	switch key := keyif.(type) {
 	case dilithium.PrivateKey:
 		return s.m.Sign(key, payload), nil
+	case gocrypto.Signer:
+		// a signer of a key held elsewhere, such as by a crypto.KeyManager
+		return key.Sign(rand.Reader, payload, gocrypto.Hash(0))
 	default:
 		return nil, fmt.Errorf(`invalid key type %T`, keyif)
 	}
@@ -0,0 +1,171 @@
+package jwx
+
+import (
+	gocrypto "crypto"
+	"crypto/ecdsa"
+	"crypto/rand"
+	"crypto/sha256"
+	"fmt"
+
+	"github.com/decred/dcrd/dcrec/secp256k1/v4"
+	"github.com/lestrrat-go/jwx/v2/jwa"
+	"github.com/lestrrat-go/jwx/v2/jws"
+	"github.com/pkg/errors"
+
+	"github.com/extrimian/ssi-sdk/crypto"
+)
+
+// ES256KRAlg is the recoverable secp256k1 signature algorithm, whose signatures are R || S || V over the SHA-256
+// hash of the signing input. The signer's public key is recovered from the signature, so it can be verified against
+// a blockchain account address rather than a public key.
+// https://identity.foundation/EcdsaSecp256k1RecoverySignature2020/
+const ES256KRAlg jwa.SignatureAlgorithm = "ES256K-R"
+
+// secp256k1CRV is the JWK curve name of secp256k1, which the jwx library only defines with the jwx_es256k build tag
+const secp256k1CRV = "secp256k1"
+
+func init() {
+	jws.RegisterSigner(ES256KRAlg, jws.SignerFactoryFn(NewES256KRSigner))
+	jws.RegisterVerifier(ES256KRAlg, jws.VerifierFactoryFn(NewES256KRVerifier))
+}
+
+// ES256KRSignerVerifier implements the jws.Signer and jws.Verifier interfaces for ES256K-R for use with the jwx library
+type ES256KRSignerVerifier struct{}
+
+// NewES256KRSigner returns a new ES256KRSignerVerifier
+func NewES256KRSigner() (jws.Signer, error) {
+	return &ES256KRSignerVerifier{}, nil
+}
+
+// NewES256KRVerifier returns a new ES256KRSignerVerifier
+func NewES256KRVerifier() (jws.Verifier, error) {
+	return &ES256KRSignerVerifier{}, nil
+}
+
+// Algorithm returns ES256KRAlg
+func (ES256KRSignerVerifier) Algorithm() jwa.SignatureAlgorithm {
+	return ES256KRAlg
+}
+
+// Sign signs the payload using the provided secp256k1 private key
+func (ES256KRSignerVerifier) Sign(payload []byte, keyif any) ([]byte, error) {
+	var key secp256k1.PrivateKey
+	switch k := keyif.(type) {
+	case secp256k1.PrivateKey:
+		key = k
+	case *secp256k1.PrivateKey:
+		key = *k
+	case ecdsa.PrivateKey:
+		key = *secp256k1.PrivKeyFromBytes(k.D.Bytes())
+	case *ecdsa.PrivateKey:
+		key = *secp256k1.PrivKeyFromBytes(k.D.Bytes())
+	case gocrypto.Signer:
+		// a signer of a key held elsewhere, such as by a crypto.KeyManager, which does not return the recovery id
+		return signRecoverableWithSigner(k, payload)
+	default:
+		return nil, fmt.Errorf(`invalid key type %T`, keyif)
+	}
+	hash := sha256.Sum256(payload)
+	return crypto.SignRecoverableSECP256k1(key, hash[:])
+}
+
+func signRecoverableWithSigner(signer gocrypto.Signer, payload []byte) ([]byte, error) {
+	publicKey, ok := signer.Public().(*ecdsa.PublicKey)
+	if !ok {
+		return nil, fmt.Errorf(`expected *ecdsa.PublicKey, got %T`, signer.Public())
+	}
+	var x, y secp256k1.FieldVal
+	if x.SetByteSlice(publicKey.X.Bytes()) || y.SetByteSlice(publicKey.Y.Bytes()) {
+		return nil, errors.New("invalid secp256k1 public key")
+	}
+	hash := sha256.Sum256(payload)
+	signature, err := signer.Sign(rand.Reader, hash[:], gocrypto.SHA256)
+	if err != nil {
+		return nil, err
+	}
+	return crypto.RecoverableSECP256k1Signature(hash[:], signature, *secp256k1.NewPublicKey(&x, &y))
+}
+
+// Verify recovers the public key from the signature over the payload and checks it against the provided key, which
+// is either a secp256k1 public key or an Ethereum address
+func (ES256KRSignerVerifier) Verify(payload []byte, signature []byte, keyif any) error {
+	hash := sha256.Sum256(payload)
+	recovered, err := crypto.RecoverSECP256k1PublicKey(hash[:], signature)
+	if err != nil {
+		return err
+	}
+	var matches bool
+	switch key := keyif.(type) {
+	case crypto.EthereumAddress:
+		matches = key.Matches(*recovered)
+	case secp256k1.PublicKey:
+		matches = key.IsEqual(recovered)
+	case *secp256k1.PublicKey:
+		matches = key.IsEqual(recovered)
+	case ecdsa.PublicKey:
+		matches = key.Equal(recovered.ToECDSA())
+	case *ecdsa.PublicKey:
+		matches = key.Equal(recovered.ToECDSA())
+	default:
+		return fmt.Errorf(`invalid key type %T`, keyif)
+	}
+	if !matches {
+		return fmt.Errorf(`failed to verify ES256K-R signature`)
+	}
+	return nil
+}
+
+// NewJWXRecoverySigner creates a new signer from a secp256k1 private key to produce ES256K-R JWTs and JWS values
+func NewJWXRecoverySigner(id, kid string, key gocrypto.PrivateKey) (*Signer, error) {
+	if id == "" {
+		return nil, errors.New("id is required")
+	}
+	_, privateKeyJWK, err := PrivateKeyToPrivateKeyJWK(kid, key)
+	if err != nil {
+		return nil, errors.Wrap(err, "converting private key to JWK")
+	}
+	if privateKeyJWK.CRV != secp256k1CRV {
+		return nil, fmt.Errorf("%s requires a secp256k1 key", ES256KRAlg)
+	}
+	privateKeyJWK.ALG = ES256KRAlg.String()
+	if convertedPrivKey, ok := privKeyForJWX(key); ok {
+		key = convertedPrivKey
+	}
+	return &Signer{ID: id, PrivateKeyJWK: *privateKeyJWK, PrivateKey: key}, nil
+}
+
+// NewJWXRecoverySignerFromKeyManager creates a new signer of a secp256k1 key held by a key manager to produce
+// ES256K-R JWTs and JWS values, recovering the recovery id of the key manager's signatures
+func NewJWXRecoverySignerFromKeyManager(id, kid string, keyManager crypto.KeyManager, keyID string) (*Signer, error) {
+	if id == "" {
+		return nil, errors.New("id is required")
+	}
+	remoteSigner, jwk, err := remoteSignerJWK(kid, keyManager, keyID)
+	if err != nil {
+		return nil, err
+	}
+	if jwk.CRV != secp256k1CRV {
+		return nil, fmt.Errorf("%s requires a secp256k1 key", ES256KRAlg)
+	}
+	jwk.ALG = ES256KRAlg.String()
+	return &Signer{ID: id, PrivateKeyJWK: *jwk, PrivateKey: remoteSigner}, nil
+}
+
+// NewJWXRecoveryVerifier creates a new verifier of ES256K-R JWTs and JWS values signed by the key of a secp256k1
+// public key or an Ethereum address, neither of which need to be known before the signature is recovered
+func NewJWXRecoveryVerifier(id, kid string, key gocrypto.PublicKey) (*Verifier, error) {
+	if id == "" {
+		return nil, errors.New("id is required")
+	}
+	switch k := key.(type) {
+	case crypto.EthereumAddress:
+		if !k.IsValid() {
+			return nil, fmt.Errorf("invalid ethereum address: %s", k)
+		}
+	case secp256k1.PublicKey, *secp256k1.PublicKey, ecdsa.PublicKey, *ecdsa.PublicKey:
+	default:
+		return nil, fmt.Errorf("unsupported key type for %s: %T", ES256KRAlg, key)
+	}
+	jwk := PublicKeyJWK{KTY: "EC", CRV: secp256k1CRV, ALG: ES256KRAlg.String(), KID: kid}
+	return &Verifier{ID: id, PublicKeyJWK: jwk, publicKey: key}, nil
+}
@@ -0,0 +1,68 @@
+package jwx
+
+import (
+	"testing"
+
+	"github.com/extrimian/ssi-sdk/crypto"
+	"github.com/lestrrat-go/jwx/v2/jws"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestES256KR(t *testing.T) {
+	publicKey, privateKey, err := crypto.GenerateSECP256k1Key()
+	require.NoError(t, err)
+	signer, err := NewJWXRecoverySigner("did:example:issuer", "key-1", privateKey)
+	require.NoError(t, err)
+	assert.Equal(t, ES256KRAlg.String(), signer.ALG)
+
+	token, err := signer.SignWithDefaults(map[string]any{"sub": "did:example:subject"})
+	require.NoError(t, err)
+	headers, err := GetJWSHeaders(token)
+	require.NoError(t, err)
+	assert.Equal(t, ES256KRAlg, headers.Algorithm())
+
+	t.Run("verify with the public key", func(tt *testing.T) {
+		verifier, err := NewJWXRecoveryVerifier("did:example:issuer", "key-1", publicKey)
+		require.NoError(tt, err)
+		assert.NoError(tt, verifier.Verify(string(token)))
+	})
+
+	t.Run("verify with the address", func(tt *testing.T) {
+		verifier, err := NewJWXRecoveryVerifier("did:example:issuer", "key-1", crypto.EthereumAddressFromPublicKey(publicKey))
+		require.NoError(tt, err)
+		assert.NoError(tt, verifier.Verify(string(token)))
+
+		_, _, err = verifier.VerifyAndParse(string(token))
+		assert.NoError(tt, err)
+	})
+
+	t.Run("verify with another key", func(tt *testing.T) {
+		otherKey, _, err := crypto.GenerateSECP256k1Key()
+		require.NoError(tt, err)
+		verifier, err := NewJWXRecoveryVerifier("did:example:issuer", "key-1", crypto.EthereumAddressFromPublicKey(otherKey))
+		require.NoError(tt, err)
+		assert.Error(tt, verifier.Verify(string(token)))
+	})
+
+	t.Run("sign and verify a JWS", func(tt *testing.T) {
+		signed, err := jws.Sign([]byte("payload"), jws.WithKey(ES256KRAlg, privateKey))
+		require.NoError(tt, err)
+		payload, err := jws.Verify(signed, jws.WithKey(ES256KRAlg, crypto.EthereumAddressFromPublicKey(publicKey)))
+		assert.NoError(tt, err)
+		assert.Equal(tt, "payload", string(payload))
+	})
+
+	t.Run("unsupported keys", func(tt *testing.T) {
+		_, p256Key, err := crypto.GenerateP256Key()
+		require.NoError(tt, err)
+		_, err = NewJWXRecoverySigner("did:example:issuer", "key-1", p256Key)
+		assert.ErrorContains(tt, err, "requires a secp256k1 key")
+
+		_, err = NewJWXRecoveryVerifier("did:example:issuer", "key-1", crypto.EthereumAddress("0x1234"))
+		assert.ErrorContains(tt, err, "invalid ethereum address")
+
+		_, err = NewJWXRecoveryVerifier("did:example:issuer", "key-1", "not a key")
+		assert.ErrorContains(tt, err, "unsupported key type")
+	})
+}
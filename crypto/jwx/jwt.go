@@ -39,6 +39,39 @@ func NewJWXSignerFromJWK(id string, key PrivateKeyJWK) (*Signer, error) {
 	return jwxSigner(id, key, privateKey)
 }
 
+// NewJWXSignerFromKeyManager creates a new signer of a key held by a key manager, such as a KMS or an HSM, to sign
+// JWTs and produce JWS values without the private key leaving the key manager. The signer's JWK only holds the public
+// members of the key.
+func NewJWXSignerFromKeyManager(id, kid string, keyManager crypto.KeyManager, keyID string) (*Signer, error) {
+	remoteSigner, jwk, err := remoteSignerJWK(kid, keyManager, keyID)
+	if err != nil {
+		return nil, err
+	}
+	return jwxSigner(id, *jwk, remoteSigner)
+}
+
+// remoteSignerJWK creates a signer of the key in the key manager, and a JWK of the key without private members
+func remoteSignerJWK(kid string, keyManager crypto.KeyManager, keyID string) (*crypto.RemoteSigner, *PrivateKeyJWK, error) {
+	remoteSigner, err := crypto.NewRemoteSigner(keyManager, keyID)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "creating remote signer")
+	}
+	publicKeyJWK, err := PublicKeyToPublicKeyJWK(kid, remoteSigner.PublicKey())
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "converting public key to JWK")
+	}
+	return remoteSigner, &PrivateKeyJWK{
+		KTY: publicKeyJWK.KTY,
+		CRV: publicKeyJWK.CRV,
+		X:   publicKeyJWK.X,
+		Y:   publicKeyJWK.Y,
+		N:   publicKeyJWK.N,
+		E:   publicKeyJWK.E,
+		ALG: publicKeyJWK.ALG,
+		KID: publicKeyJWK.KID,
+	}, nil
+}
+
 func jwxSigner(id string, jwk PrivateKeyJWK, key gocrypto.PrivateKey) (*Signer, error) {
 	if id == "" {
 		return nil, errors.New("id is required")
@@ -4,8 +4,10 @@ import (
 	"testing"
 
 	"github.com/extrimian/ssi-sdk/crypto"
+	"github.com/lestrrat-go/jwx/v2/jws"
 	"github.com/lestrrat-go/jwx/v2/jwt"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestJsonWebSignature2020TestVectorJWT(t *testing.T) {
@@ -186,3 +188,62 @@ func getTestVectorKey0Signer(t *testing.T) Signer {
 	assert.NoError(t, err)
 	return *signer
 }
+
+func TestSignVerifyJWTWithKeyManager(t *testing.T) {
+	keyManager := crypto.NewInMemoryKeyManager()
+	for _, kt := range []crypto.KeyType{crypto.Ed25519, crypto.SECP256k1, crypto.P256, crypto.P384, crypto.P521, crypto.RSA} {
+		t.Run(string(kt), func(tt *testing.T) {
+			publicKey, err := keyManager.GenerateKey(string(kt), kt)
+			require.NoError(tt, err)
+
+			signer, err := NewJWXSignerFromKeyManager("test-id", "test-kid", keyManager, string(kt))
+			require.NoError(tt, err)
+			assert.Empty(tt, signer.D)
+
+			token, err := signer.SignWithDefaults(map[string]any{"test": "data"})
+			require.NoError(tt, err)
+
+			verifier, err := NewJWXVerifier("test-id", "test-kid", publicKey)
+			require.NoError(tt, err)
+			assert.NoError(tt, verifier.Verify(string(token)))
+
+			signed, err := signer.SignJWS([]byte("payload"))
+			require.NoError(tt, err)
+			assert.NoError(tt, verifier.VerifyJWS(string(signed)))
+		})
+	}
+
+	t.Run("ES256K-R", func(tt *testing.T) {
+		publicKey, err := keyManager.GenerateKey("recovery", crypto.SECP256k1)
+		require.NoError(tt, err)
+		signer, err := NewJWXRecoverySignerFromKeyManager("test-id", "test-kid", keyManager, "recovery")
+		require.NoError(tt, err)
+
+		token, err := signer.SignWithDefaults(map[string]any{"test": "data"})
+		require.NoError(tt, err)
+		verifier, err := NewJWXRecoveryVerifier("test-id", "test-kid", publicKey)
+		require.NoError(tt, err)
+		assert.NoError(tt, verifier.Verify(string(token)))
+
+		_, err = NewJWXRecoverySignerFromKeyManager("test-id", "test-kid", keyManager, string(crypto.P256))
+		assert.ErrorContains(tt, err, "requires a secp256k1 key")
+	})
+
+	t.Run("Dilithium", func(tt *testing.T) {
+		publicKey, err := keyManager.GenerateKey("dilithium", crypto.Dilithium3)
+		require.NoError(tt, err)
+		signer, err := NewJWXSignerFromKeyManager("test-id", "test-kid", keyManager, "dilithium")
+		require.NoError(tt, err)
+		assert.Equal(tt, DilithiumMode3Alg.String(), signer.ALG)
+
+		signed, err := jws.Sign([]byte("payload"), jws.WithKey(DilithiumMode3Alg, signer.PrivateKey))
+		require.NoError(tt, err)
+		_, err = jws.Verify(signed, jws.WithKey(DilithiumMode3Alg, publicKey))
+		assert.NoError(tt, err)
+	})
+
+	t.Run("unknown key", func(tt *testing.T) {
+		_, err := NewJWXSignerFromKeyManager("test-id", "test-kid", keyManager, "missing")
+		assert.ErrorContains(tt, err, "key not found: missing")
+	})
+}
@@ -16,6 +16,7 @@ import (
 	"github.com/cloudflare/circl/sign/dilithium/mode2"
 	"github.com/cloudflare/circl/sign/dilithium/mode3"
 	"github.com/cloudflare/circl/sign/dilithium/mode5"
+	bbsg2 "github.com/hyperledger/aries-framework-go/pkg/crypto/primitive/bbs12381g2pub"
 	"github.com/pkg/errors"
 
 	secp "github.com/decred/dcrd/dcrec/secp256k1/v4"
@@ -56,6 +57,8 @@ func GenerateKeyByKeyType(kt KeyType) (crypto.PublicKey, crypto.PrivateKey, erro
 		return GenerateDilithiumKeyPair(dilithium.Mode3)
 	case Dilithium5:
 		return GenerateDilithiumKeyPair(dilithium.Mode5)
+	case BLS12381G2:
+		return GenerateBBSKeyPair()
 	}
 	return nil, nil, fmt.Errorf("unsupported key type: %s", kt)
 }
@@ -111,6 +114,8 @@ func PubKeyToBytes(key crypto.PublicKey, opts ...Option) ([]byte, error) {
 		return k.Bytes(), nil
 	case mode5.PublicKey:
 		return k.Bytes(), nil
+	case bbsg2.PublicKey:
+		return k.Marshal()
 	}
 
 	return nil, errors.New("unknown public key type; could not convert to bytes")
@@ -167,16 +172,26 @@ func BytesToPubKey(keyBytes []byte, kt KeyType, opts ...Option) (crypto.PublicKe
 		}
 		return *pubKey, nil
 	case Dilithium2:
-		return dilithium.Mode2.PublicKeyFromBytes(keyBytes), nil
+		return dilithiumPubKeyFromBytes(dilithium.Mode2, keyBytes)
 	case Dilithium3:
-		return dilithium.Mode3.PublicKeyFromBytes(keyBytes), nil
+		return dilithiumPubKeyFromBytes(dilithium.Mode3, keyBytes)
 	case Dilithium5:
-		return dilithium.Mode5.PublicKeyFromBytes(keyBytes), nil
+		return dilithiumPubKeyFromBytes(dilithium.Mode5, keyBytes)
+	case BLS12381G2:
+		return bbsg2.UnmarshalPublicKey(keyBytes)
 	default:
 		return nil, fmt.Errorf("unsupported key type: %s", kt)
 	}
 }
 
+// dilithiumPubKeyFromBytes checks the size of the key before unpacking it, which panics on other sizes
+func dilithiumPubKeyFromBytes(m dilithium.Mode, keyBytes []byte) (crypto.PublicKey, error) {
+	if len(keyBytes) != m.PublicKeySize() {
+		return nil, fmt.Errorf("invalid %s public key size: %d", m.Name(), len(keyBytes))
+	}
+	return m.PublicKeyFromBytes(keyBytes), nil
+}
+
 // GetKeyTypeFromPrivateKey returns the key type for a private key for known key types
 func GetKeyTypeFromPrivateKey(key crypto.PrivateKey) (KeyType, error) {
 	// dereference the ptr
@@ -229,6 +244,8 @@ func GetKeyTypeFromPrivateKey(key crypto.PrivateKey) (KeyType, error) {
 		return Dilithium3, nil
 	case mode5.PrivateKey:
 		return Dilithium5, nil
+	case bbsg2.PrivateKey:
+		return BLS12381G2, nil
 	default:
 		return "", errors.New("unknown private key type")
 	}
@@ -266,6 +283,8 @@ func PrivKeyToBytes(key crypto.PrivateKey) ([]byte, error) {
 		return k.Bytes(), nil
 	case mode5.PrivateKey:
 		return k.Bytes(), nil
+	case bbsg2.PrivateKey:
+		return k.Marshal()
 	default:
 		return nil, errors.New("unknown private key type; could not convert to bytes")
 	}
@@ -302,6 +321,8 @@ func BytesToPrivKey(keyBytes []byte, kt KeyType) (crypto.PrivateKey, error) {
 		return dilithium.Mode3.PrivateKeyFromBytes(keyBytes), nil
 	case Dilithium5:
 		return dilithium.Mode5.PrivateKeyFromBytes(keyBytes), nil
+	case BLS12381G2:
+		return bbsg2.UnmarshalPrivateKey(keyBytes)
 	default:
 		return nil, fmt.Errorf("unsupported key type: %s", kt)
 	}
@@ -0,0 +1,345 @@
+package crypto
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/asn1"
+	"fmt"
+	"io"
+	"math/big"
+	"reflect"
+	"sync"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/cloudflare/circl/sign/dilithium"
+	secp "github.com/decred/dcrd/dcrec/secp256k1/v4"
+	secpecdsa "github.com/decred/dcrd/dcrec/secp256k1/v4/ecdsa"
+	bbsg2 "github.com/hyperledger/aries-framework-go/pkg/crypto/primitive/bbs12381g2pub"
+	"github.com/pkg/errors"
+)
+
+// KeyManager holds private keys, identified by key ids, and signs with them without exposing them, as a KMS or an
+// HSM does. Signing follows the conventions of crypto.Signer: keys sign a digest hashed with opts.HashFunc(), or the
+// message itself when it is zero, ECDSA signatures are ASN.1 DER encoded, and RSA keys sign with PSS when opts are
+// *rsa.PSSOptions and PKCS #1 v1.5 otherwise. Ed25519, Dilithium and BBS+ keys sign messages.
+type KeyManager interface {
+	// Sign signs a digest, or a message, with the key
+	Sign(keyID string, digest []byte, opts crypto.SignerOpts) ([]byte, error)
+	// PublicKey returns the public key of the key, in the form GenerateKeyByKeyType returns it
+	PublicKey(keyID string) (crypto.PublicKey, error)
+	// Algorithms returns the signature algorithms the key signs with
+	Algorithms(keyID string) ([]SignatureAlgorithm, error)
+}
+
+// RemoteSigner is a crypto.Signer of a key held by a key manager, which can be used in place of a private key by
+// signers of JWTs, Data Integrity proofs and sidetree operations
+type RemoteSigner struct {
+	keyManager KeyManager
+	keyID      string
+	publicKey  crypto.PublicKey
+}
+
+var _ crypto.Signer = (*RemoteSigner)(nil)
+
+// NewRemoteSigner creates a signer of the key with the id in the key manager
+func NewRemoteSigner(keyManager KeyManager, keyID string) (*RemoteSigner, error) {
+	if keyManager == nil {
+		return nil, errors.New("key manager is required")
+	}
+	if keyID == "" {
+		return nil, errors.New("key id is required")
+	}
+	publicKey, err := keyManager.PublicKey(keyID)
+	if err != nil {
+		return nil, errors.Wrapf(err, "getting public key of %s", keyID)
+	}
+	return &RemoteSigner{keyManager: keyManager, keyID: keyID, publicKey: publicKey}, nil
+}
+
+// KeyID returns the id of the key in the key manager
+func (s *RemoteSigner) KeyID() string {
+	return s.keyID
+}
+
+// PublicKey returns the public key as the key manager returns it
+func (s *RemoteSigner) PublicKey() crypto.PublicKey {
+	return s.publicKey
+}
+
+// Public returns the public key in the form of the standard library's keys, as crypto.Signer requires, where
+// secp256k1 keys are *ecdsa.PublicKey values
+func (s *RemoteSigner) Public() crypto.PublicKey {
+	switch k := derefPublicKey(s.publicKey).(type) {
+	case ecdsa.PublicKey:
+		return &k
+	case secp.PublicKey:
+		return k.ToECDSA()
+	case rsa.PublicKey:
+		return &k
+	default:
+		return s.publicKey
+	}
+}
+
+// Sign signs a digest, or a message, with the key of the key manager. The source of randomness is that of the key
+// manager.
+func (s *RemoteSigner) Sign(_ io.Reader, digest []byte, opts crypto.SignerOpts) ([]byte, error) {
+	if opts == nil {
+		opts = crypto.Hash(0)
+	}
+	return s.keyManager.Sign(s.keyID, digest, opts)
+}
+
+// Algorithms returns the signature algorithms of the key
+func (s *RemoteSigner) Algorithms() ([]SignatureAlgorithm, error) {
+	return s.keyManager.Algorithms(s.keyID)
+}
+
+// InMemoryKeyManager is a key manager holding private keys in memory, for tests and for applications whose keys are
+// not held elsewhere. It is safe for concurrent use.
+type InMemoryKeyManager struct {
+	mu   sync.RWMutex
+	keys map[string]crypto.PrivateKey
+}
+
+var _ KeyManager = (*InMemoryKeyManager)(nil)
+
+// NewInMemoryKeyManager creates an empty in-memory key manager
+func NewInMemoryKeyManager() *InMemoryKeyManager {
+	return &InMemoryKeyManager{keys: make(map[string]crypto.PrivateKey)}
+}
+
+// AddKey adds a private key with the id, which must not already be used
+func (m *InMemoryKeyManager) AddKey(keyID string, key crypto.PrivateKey) error {
+	if keyID == "" {
+		return errors.New("key id is required")
+	}
+	if key == nil {
+		return errors.New("key is required")
+	}
+	key = derefPrivateKey(key)
+	kt, err := GetKeyTypeFromPrivateKey(key)
+	if err != nil {
+		return err
+	}
+	if _, err = SignatureAlgorithmsForKeyType(kt); err != nil {
+		return err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.keys[keyID]; ok {
+		return fmt.Errorf("key already exists: %s", keyID)
+	}
+	m.keys[keyID] = key
+	return nil
+}
+
+// GenerateKey generates a private key of the key type with the id, returning its public key
+func (m *InMemoryKeyManager) GenerateKey(keyID string, kt KeyType) (crypto.PublicKey, error) {
+	publicKey, privateKey, err := GenerateKeyByKeyType(kt)
+	if err != nil {
+		return nil, err
+	}
+	if err = m.AddKey(keyID, privateKey); err != nil {
+		return nil, err
+	}
+	return publicKey, nil
+}
+
+// Sign signs a digest, or a message, with the key
+func (m *InMemoryKeyManager) Sign(keyID string, digest []byte, opts crypto.SignerOpts) ([]byte, error) {
+	key, err := m.key(keyID)
+	if err != nil {
+		return nil, err
+	}
+	if opts == nil {
+		opts = crypto.Hash(0)
+	}
+	switch k := key.(type) {
+	case ed25519.PrivateKey:
+		if opts.HashFunc() != 0 {
+			return nil, errors.New("ed25519 keys sign messages, not digests")
+		}
+		return ed25519.Sign(k, digest), nil
+	case ecdsa.PrivateKey:
+		if k.Curve == btcec.S256() {
+			secpKey := secp.PrivKeyFromBytes(k.D.FillBytes(make([]byte, 32)))
+			return secpecdsa.Sign(secpKey, digest).Serialize(), nil
+		}
+		return ecdsa.SignASN1(rand.Reader, &k, digest)
+	case secp.PrivateKey:
+		return secpecdsa.Sign(&k, digest).Serialize(), nil
+	case rsa.PrivateKey:
+		return k.Sign(rand.Reader, digest, opts)
+	case dilithium.PrivateKey:
+		if opts.HashFunc() != 0 {
+			return nil, errors.New("dilithium keys sign messages, not digests")
+		}
+		mode, err := GetModeFromDilithiumPrivateKey(k)
+		if err != nil {
+			return nil, err
+		}
+		return mode.Sign(k, digest), nil
+	case bbsg2.PrivateKey:
+		if opts.HashFunc() != 0 {
+			return nil, errors.New("bbs+ keys sign messages, not digests")
+		}
+		return bbsg2.New().SignWithKey(prepareBBSMessage(digest), &k)
+	default:
+		return nil, fmt.Errorf("unsupported key type: %T", key)
+	}
+}
+
+// PublicKey returns the public key of the key
+func (m *InMemoryKeyManager) PublicKey(keyID string) (crypto.PublicKey, error) {
+	key, err := m.key(keyID)
+	if err != nil {
+		return nil, err
+	}
+	switch k := key.(type) {
+	case ed25519.PrivateKey:
+		return k.Public(), nil
+	case ecdsa.PrivateKey:
+		return k.PublicKey, nil
+	case secp.PrivateKey:
+		return *k.PubKey(), nil
+	case rsa.PrivateKey:
+		return k.PublicKey, nil
+	case dilithium.PrivateKey:
+		return k.Public(), nil
+	case bbsg2.PrivateKey:
+		return k.PublicKey(), nil
+	default:
+		return nil, fmt.Errorf("unsupported key type: %T", key)
+	}
+}
+
+// Algorithms returns the signature algorithms of the key
+func (m *InMemoryKeyManager) Algorithms(keyID string) ([]SignatureAlgorithm, error) {
+	key, err := m.key(keyID)
+	if err != nil {
+		return nil, err
+	}
+	kt, err := GetKeyTypeFromPrivateKey(key)
+	if err != nil {
+		return nil, err
+	}
+	return SignatureAlgorithmsForKeyType(kt)
+}
+
+func (m *InMemoryKeyManager) key(keyID string) (crypto.PrivateKey, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	key, ok := m.keys[keyID]
+	if !ok {
+		return nil, fmt.Errorf("key not found: %s", keyID)
+	}
+	return key, nil
+}
+
+// SignatureAlgorithmsForKeyType returns the signature algorithms keys of the key type sign with
+func SignatureAlgorithmsForKeyType(kt KeyType) ([]SignatureAlgorithm, error) {
+	switch kt {
+	case Ed25519:
+		return []SignatureAlgorithm{EdDSA}, nil
+	case SECP256k1, SECP256k1ECDSA:
+		return []SignatureAlgorithm{ES256K}, nil
+	case P256:
+		return []SignatureAlgorithm{ES256}, nil
+	case P384:
+		return []SignatureAlgorithm{ES384}, nil
+	case P521:
+		return []SignatureAlgorithm{ES512}, nil
+	case RSA:
+		return []SignatureAlgorithm{PS256}, nil
+	case Dilithium2:
+		return []SignatureAlgorithm{Dilithium2Sig}, nil
+	case Dilithium3:
+		return []SignatureAlgorithm{Dilithium3Sig}, nil
+	case Dilithium5:
+		return []SignatureAlgorithm{Dilithium5Sig}, nil
+	case BLS12381G2:
+		return []SignatureAlgorithm{BBSPlus}, nil
+	default:
+		return nil, fmt.Errorf("unsupported key type for signing: %s", kt)
+	}
+}
+
+// ECDSASignatureToRS converts an ASN.1 DER encoded ECDSA signature into the concatenation of its R and S values,
+// each of the given size in bytes, as used by JWS
+func ECDSASignatureToRS(signature []byte, size int) ([]byte, error) {
+	var rs struct {
+		R, S *big.Int
+	}
+	rest, err := asn1.Unmarshal(signature, &rs)
+	if err != nil {
+		return nil, errors.Wrap(err, "unmarshalling ASN.1 signature")
+	}
+	if len(rest) > 0 {
+		return nil, errors.New("trailing data after ASN.1 signature")
+	}
+	if rs.R.Sign() <= 0 || rs.S.Sign() <= 0 || len(rs.R.Bytes()) > size || len(rs.S.Bytes()) > size {
+		return nil, errors.New("invalid signature values")
+	}
+	out := make([]byte, 2*size)
+	rs.R.FillBytes(out[:size])
+	rs.S.FillBytes(out[size:])
+	return out, nil
+}
+
+// RSToECDSASignature converts the concatenation of the R and S values of an ECDSA signature into its ASN.1 DER
+// encoding
+func RSToECDSASignature(signature []byte) ([]byte, error) {
+	if len(signature) == 0 || len(signature)%2 != 0 {
+		return nil, fmt.Errorf("invalid signature length: %d", len(signature))
+	}
+	size := len(signature) / 2
+	return asn1.Marshal(struct {
+		R, S *big.Int
+	}{R: new(big.Int).SetBytes(signature[:size]), S: new(big.Int).SetBytes(signature[size:])})
+}
+
+// SECP256k1SignatureToRS converts an ASN.1 DER encoded secp256k1 ECDSA signature into the 64 bytes of its R and S
+// values, with S in the lower half of the curve order as Bitcoin and Ethereum require
+func SECP256k1SignatureToRS(signature []byte) ([]byte, error) {
+	rs, err := ECDSASignatureToRS(signature, 32)
+	if err != nil {
+		return nil, err
+	}
+	var s secp.ModNScalar
+	if overflow := s.SetByteSlice(rs[32:]); overflow {
+		return nil, errors.New("invalid signature values")
+	}
+	if s.IsOverHalfOrder() {
+		s.Negate()
+		s.PutBytesUnchecked(rs[32:])
+	}
+	return rs, nil
+}
+
+func derefPrivateKey(key crypto.PrivateKey) crypto.PrivateKey {
+	// dilithium keys are pointers implementing dilithium.PrivateKey
+	if _, ok := key.(dilithium.PrivateKey); ok {
+		return key
+	}
+	for reflect.ValueOf(key).Kind() == reflect.Ptr {
+		key = reflect.ValueOf(key).Elem().Interface().(crypto.PrivateKey)
+	}
+	return key
+}
+
+func derefPublicKey(key crypto.PublicKey) crypto.PublicKey {
+	if _, ok := key.(dilithium.PublicKey); ok {
+		return key
+	}
+	if _, ok := key.(*bbsg2.PublicKey); ok {
+		return key
+	}
+	for reflect.ValueOf(key).Kind() == reflect.Ptr {
+		key = reflect.ValueOf(key).Elem().Interface().(crypto.PublicKey)
+	}
+	return key
+}
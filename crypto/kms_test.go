@@ -0,0 +1,129 @@
+package crypto
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"testing"
+
+	"github.com/cloudflare/circl/sign/dilithium"
+	secp "github.com/decred/dcrd/dcrec/secp256k1/v4"
+	secpecdsa "github.com/decred/dcrd/dcrec/secp256k1/v4/ecdsa"
+	bbsg2 "github.com/hyperledger/aries-framework-go/pkg/crypto/primitive/bbs12381g2pub"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInMemoryKeyManager(t *testing.T) {
+	message := []byte("hello world")
+	digest := sha256.Sum256(message)
+
+	t.Run("sign with each key type", func(tt *testing.T) {
+		km := NewInMemoryKeyManager()
+		for _, kt := range []KeyType{Ed25519, SECP256k1, P256, P384, P521, RSA, Dilithium2, BLS12381G2} {
+			publicKey, err := km.GenerateKey(string(kt), kt)
+			require.NoError(tt, err, kt)
+
+			kmPublicKey, err := km.PublicKey(string(kt))
+			require.NoError(tt, err, kt)
+
+			algs, err := km.Algorithms(string(kt))
+			require.NoError(tt, err, kt)
+			assert.NotEmpty(tt, algs, kt)
+
+			switch pk := publicKey.(type) {
+			case ed25519.PublicKey:
+				signature, err := km.Sign(string(kt), message, crypto.Hash(0))
+				require.NoError(tt, err)
+				assert.True(tt, ed25519.Verify(pk, message, signature))
+			case secp.PublicKey:
+				assert.Equal(tt, pk, kmPublicKey)
+				signature, err := km.Sign(string(kt), digest[:], crypto.SHA256)
+				require.NoError(tt, err)
+				parsed, err := secpecdsa.ParseDERSignature(signature)
+				require.NoError(tt, err)
+				assert.True(tt, parsed.Verify(digest[:], &pk))
+			case ecdsa.PublicKey:
+				assert.Equal(tt, pk, kmPublicKey)
+				signature, err := km.Sign(string(kt), digest[:], crypto.SHA256)
+				require.NoError(tt, err)
+				assert.True(tt, ecdsa.VerifyASN1(&pk, digest[:], signature))
+			case rsa.PublicKey:
+				signature, err := km.Sign(string(kt), digest[:], &rsa.PSSOptions{SaltLength: rsa.PSSSaltLengthEqualsHash, Hash: crypto.SHA256})
+				require.NoError(tt, err)
+				assert.NoError(tt, rsa.VerifyPSS(&pk, crypto.SHA256, digest[:], signature, nil))
+			case dilithium.PublicKey:
+				signature, err := km.Sign(string(kt), message, crypto.Hash(0))
+				require.NoError(tt, err)
+				assert.True(tt, dilithium.Mode2.Verify(pk, message, signature))
+			case *bbsg2.PublicKey:
+				signature, err := km.Sign(string(kt), message, crypto.Hash(0))
+				require.NoError(tt, err)
+				assert.NoError(tt, VerifyBBSMessage(pk, signature, message))
+			default:
+				tt.Fatalf("unexpected public key type %T", publicKey)
+			}
+		}
+	})
+
+	t.Run("keys must be unique and known", func(tt *testing.T) {
+		km := NewInMemoryKeyManager()
+		_, privateKey, err := GenerateEd25519Key()
+		require.NoError(tt, err)
+		require.NoError(tt, km.AddKey("key-1", privateKey))
+		assert.ErrorContains(tt, km.AddKey("key-1", privateKey), "key already exists: key-1")
+
+		_, err = km.Sign("key-2", message, crypto.Hash(0))
+		assert.ErrorContains(tt, err, "key not found: key-2")
+		_, err = km.Sign("key-1", digest[:], crypto.SHA256)
+		assert.ErrorContains(tt, err, "ed25519 keys sign messages, not digests")
+	})
+}
+
+func TestRemoteSigner(t *testing.T) {
+	km := NewInMemoryKeyManager()
+	_, err := km.GenerateKey("secp", SECP256k1)
+	require.NoError(t, err)
+
+	signer, err := NewRemoteSigner(km, "secp")
+	require.NoError(t, err)
+	assert.Equal(t, "secp", signer.KeyID())
+
+	algs, err := signer.Algorithms()
+	require.NoError(t, err)
+	assert.Equal(t, []SignatureAlgorithm{ES256K}, algs)
+
+	// secp256k1 keys are presented as standard library keys
+	publicKey, ok := signer.Public().(*ecdsa.PublicKey)
+	require.True(t, ok)
+
+	digest := sha256.Sum256([]byte("hello world"))
+	signature, err := signer.Sign(rand.Reader, digest[:], crypto.SHA256)
+	require.NoError(t, err)
+	assert.True(t, ecdsa.VerifyASN1(publicKey, digest[:], signature))
+
+	_, err = NewRemoteSigner(km, "missing")
+	assert.Error(t, err)
+}
+
+func TestECDSASignatureConversion(t *testing.T) {
+	_, privateKey, err := GenerateP256Key()
+	require.NoError(t, err)
+	digest := sha256.Sum256([]byte("hello world"))
+	signature, err := ecdsa.SignASN1(rand.Reader, &privateKey, digest[:])
+	require.NoError(t, err)
+
+	rs, err := ECDSASignatureToRS(signature, 32)
+	require.NoError(t, err)
+	assert.Len(t, rs, 64)
+
+	der, err := RSToECDSASignature(rs)
+	require.NoError(t, err)
+	assert.True(t, ecdsa.VerifyASN1(&privateKey.PublicKey, digest[:], der))
+
+	_, err = RSToECDSASignature(rs[:63])
+	assert.ErrorContains(t, err, "invalid signature length: 63")
+}
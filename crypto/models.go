@@ -19,6 +19,7 @@ const (
 	RSA            KeyType = "RSA"
 	BLS12381G1     KeyType = "BLS12381G1"
 	BLS12381G2     KeyType = "BLS12381G2"
+	BLS12381G1G2   KeyType = "BLS12381G1G2"
 	Dilithium2     KeyType = "Dilithium2"
 	Dilithium3     KeyType = "Dilithium3"
 	Dilithium5     KeyType = "Dilithium5"
@@ -39,8 +40,12 @@ const (
 	ES256 SignatureAlgorithm = "ES256"
 	// ES384 uses a p-384 curve key
 	ES384 SignatureAlgorithm = "ES384"
+	// ES512 uses a p-521 curve key
+	ES512 SignatureAlgorithm = "ES512"
 	// PS256 uses a 2048-bit RSA key
 	PS256 SignatureAlgorithm = "PS256"
+	// BBSPlus uses a BLS12-381 G2 key to sign multiple messages https://w3c-ccg.github.io/ldp-bbs2020
+	BBSPlus SignatureAlgorithm = "BBS+"
 
 	// Experimental
 
@@ -87,7 +92,7 @@ func IsSupportedSignatureAlg(sa SignatureAlgorithm) bool {
 
 // GetSupportedSignatureAlgs returns a list of supported signature algorithms
 func GetSupportedSignatureAlgs() []SignatureAlgorithm {
-	return []SignatureAlgorithm{EdDSA, ES256K, ES256, ES384, PS256}
+	return []SignatureAlgorithm{EdDSA, ES256K, ES256, ES384, ES512, PS256}
 }
 
 // GetExperimentalSignatureAlgs returns a list of experimental signature algorithms
@@ -0,0 +1,410 @@
+//go:build cgo
+
+// Package pkcs11 provides a key manager of keys held by a PKCS #11 token, such as an HSM, so the private keys of
+// JWTs, Data Integrity proofs and sidetree operations never leave the token.
+package pkcs11
+
+import (
+	gocrypto "crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/asn1"
+	"encoding/binary"
+	"fmt"
+	"math/big"
+	"sync"
+
+	secp "github.com/decred/dcrd/dcrec/secp256k1/v4"
+	p11 "github.com/miekg/pkcs11"
+	"github.com/pkg/errors"
+
+	"github.com/extrimian/ssi-sdk/crypto"
+)
+
+// EdDSA constants of PKCS #11 v3.0, which github.com/miekg/pkcs11 does not define
+const (
+	ckkECEdwards            = 0x00000040
+	ckmECEdwardsKeyPairGen  = 0x00001055
+	ckmEdDSA                = 0x00001057
+	rsaModulusBits          = 2048
+	maxObjectsWithSameLabel = 2
+)
+
+var (
+	oidP256      = asn1.ObjectIdentifier{1, 2, 840, 10045, 3, 1, 7}
+	oidP384      = asn1.ObjectIdentifier{1, 3, 132, 0, 34}
+	oidP521      = asn1.ObjectIdentifier{1, 3, 132, 0, 35}
+	oidSECP256k1 = asn1.ObjectIdentifier{1, 3, 132, 0, 10}
+	oidEd25519   = asn1.ObjectIdentifier{1, 3, 101, 112}
+
+	// DER encoded DigestInfo prefixes of PKCS #1 v1.5 signatures, as in crypto/rsa
+	digestInfoPrefixes = map[gocrypto.Hash][]byte{
+		gocrypto.SHA256: {0x30, 0x31, 0x30, 0x0d, 0x06, 0x09, 0x60, 0x86, 0x48, 0x01, 0x65, 0x03, 0x04, 0x02, 0x01, 0x05, 0x00, 0x04, 0x20},
+		gocrypto.SHA384: {0x30, 0x41, 0x30, 0x0d, 0x06, 0x09, 0x60, 0x86, 0x48, 0x01, 0x65, 0x03, 0x04, 0x02, 0x02, 0x05, 0x00, 0x04, 0x30},
+		gocrypto.SHA512: {0x30, 0x51, 0x30, 0x0d, 0x06, 0x09, 0x60, 0x86, 0x48, 0x01, 0x65, 0x03, 0x04, 0x02, 0x03, 0x05, 0x00, 0x04, 0x40},
+	}
+
+	pssHashes = map[gocrypto.Hash]struct{ mechanism, mgf uint }{
+		gocrypto.SHA256: {mechanism: p11.CKM_SHA256, mgf: p11.CKG_MGF1_SHA256},
+		gocrypto.SHA384: {mechanism: p11.CKM_SHA384, mgf: p11.CKG_MGF1_SHA384},
+		gocrypto.SHA512: {mechanism: p11.CKM_SHA512, mgf: p11.CKG_MGF1_SHA512},
+	}
+)
+
+// Config identifies the token of a key manager
+type Config struct {
+	// Module is the path of the PKCS #11 library, such as /usr/lib/softhsm/libsofthsm2.so
+	Module string
+	// TokenLabel is the label of the token holding the keys
+	TokenLabel string
+	// PIN is the user PIN of the token
+	PIN string
+}
+
+// KeyManager is a crypto.KeyManager of the keys of a PKCS #11 token, which are identified by their CKA_LABEL.
+// ECDSA keys of the P-256, P-384, P-521 and secp256k1 curves, RSA keys and Ed25519 keys are supported. It is safe for
+// concurrent use, though operations are serialized over a single session.
+type KeyManager struct {
+	mu      sync.Mutex
+	ctx     *p11.Ctx
+	session p11.SessionHandle
+}
+
+var _ crypto.KeyManager = (*KeyManager)(nil)
+
+// New loads the PKCS #11 module and logs in to the token with the label, which must be closed once it is no longer
+// used
+func New(config Config) (*KeyManager, error) {
+	if config.Module == "" {
+		return nil, errors.New("module is required")
+	}
+	ctx := p11.New(config.Module)
+	if ctx == nil {
+		return nil, fmt.Errorf("loading PKCS #11 module: %s", config.Module)
+	}
+	if err := ctx.Initialize(); err != nil {
+		ctx.Destroy()
+		return nil, errors.Wrap(err, "initializing PKCS #11 module")
+	}
+	km := &KeyManager{ctx: ctx}
+	if err := km.openSession(config); err != nil {
+		_ = ctx.Finalize()
+		ctx.Destroy()
+		return nil, err
+	}
+	return km, nil
+}
+
+func (m *KeyManager) openSession(config Config) error {
+	slots, err := m.ctx.GetSlotList(true)
+	if err != nil {
+		return errors.Wrap(err, "listing slots")
+	}
+	for _, slot := range slots {
+		tokenInfo, err := m.ctx.GetTokenInfo(slot)
+		if err != nil {
+			return errors.Wrap(err, "getting token info")
+		}
+		if tokenInfo.Label != config.TokenLabel {
+			continue
+		}
+		session, err := m.ctx.OpenSession(slot, p11.CKF_SERIAL_SESSION|p11.CKF_RW_SESSION)
+		if err != nil {
+			return errors.Wrap(err, "opening session")
+		}
+		if err = m.ctx.Login(session, p11.CKU_USER, config.PIN); err != nil && !errors.Is(err, p11.Error(p11.CKR_USER_ALREADY_LOGGED_IN)) {
+			_ = m.ctx.CloseSession(session)
+			return errors.Wrap(err, "logging in")
+		}
+		m.session = session
+		return nil
+	}
+	return fmt.Errorf("token not found: %s", config.TokenLabel)
+}
+
+// Close logs out of the token and unloads the module
+func (m *KeyManager) Close() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	_ = m.ctx.Logout(m.session)
+	if err := m.ctx.CloseSession(m.session); err != nil {
+		return errors.Wrap(err, "closing session")
+	}
+	if err := m.ctx.Finalize(); err != nil {
+		return errors.Wrap(err, "finalizing PKCS #11 module")
+	}
+	m.ctx.Destroy()
+	return nil
+}
+
+// GenerateKey generates a key pair of the key type on the token, labelled with the key id, returning its public key.
+// The private key is sensitive, so it cannot be extracted from the token.
+func (m *KeyManager) GenerateKey(keyID string, kt crypto.KeyType) (gocrypto.PublicKey, error) {
+	var mechanism uint
+	publicTemplate := []*p11.Attribute{
+		p11.NewAttribute(p11.CKA_TOKEN, true),
+		p11.NewAttribute(p11.CKA_VERIFY, true),
+		p11.NewAttribute(p11.CKA_LABEL, keyID),
+	}
+	switch kt {
+	case crypto.P256, crypto.P384, crypto.P521, crypto.SECP256k1, crypto.Ed25519:
+		oid := map[crypto.KeyType]asn1.ObjectIdentifier{
+			crypto.P256:      oidP256,
+			crypto.P384:      oidP384,
+			crypto.P521:      oidP521,
+			crypto.SECP256k1: oidSECP256k1,
+			crypto.Ed25519:   oidEd25519,
+		}[kt]
+		params, err := asn1.Marshal(oid)
+		if err != nil {
+			return nil, errors.Wrap(err, "encoding curve parameters")
+		}
+		mechanism = p11.CKM_EC_KEY_PAIR_GEN
+		if kt == crypto.Ed25519 {
+			mechanism = ckmECEdwardsKeyPairGen
+		}
+		publicTemplate = append(publicTemplate, p11.NewAttribute(p11.CKA_EC_PARAMS, params))
+	case crypto.RSA:
+		mechanism = p11.CKM_RSA_PKCS_KEY_PAIR_GEN
+		publicTemplate = append(publicTemplate,
+			p11.NewAttribute(p11.CKA_MODULUS_BITS, rsaModulusBits),
+			p11.NewAttribute(p11.CKA_PUBLIC_EXPONENT, big.NewInt(65537).Bytes()))
+	default:
+		return nil, fmt.Errorf("unsupported key type: %s", kt)
+	}
+	privateTemplate := []*p11.Attribute{
+		p11.NewAttribute(p11.CKA_TOKEN, true),
+		p11.NewAttribute(p11.CKA_SIGN, true),
+		p11.NewAttribute(p11.CKA_PRIVATE, true),
+		p11.NewAttribute(p11.CKA_SENSITIVE, true),
+		p11.NewAttribute(p11.CKA_EXTRACTABLE, false),
+		p11.NewAttribute(p11.CKA_LABEL, keyID),
+	}
+
+	m.mu.Lock()
+	if _, err := m.findObject(p11.CKO_PRIVATE_KEY, keyID); err == nil {
+		m.mu.Unlock()
+		return nil, fmt.Errorf("key already exists: %s", keyID)
+	}
+	_, _, err := m.ctx.GenerateKeyPair(m.session, []*p11.Mechanism{p11.NewMechanism(mechanism, nil)}, publicTemplate, privateTemplate)
+	m.mu.Unlock()
+	if err != nil {
+		return nil, errors.Wrapf(err, "generating %s key", kt)
+	}
+	return m.PublicKey(keyID)
+}
+
+// Sign signs a digest, or a message for Ed25519 keys, with the private key labelled with the key id
+func (m *KeyManager) Sign(keyID string, digest []byte, opts gocrypto.SignerOpts) ([]byte, error) {
+	if opts == nil {
+		opts = gocrypto.Hash(0)
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	key, err := m.findObject(p11.CKO_PRIVATE_KEY, keyID)
+	if err != nil {
+		return nil, err
+	}
+	keyType, err := m.keyType(key)
+	if err != nil {
+		return nil, err
+	}
+
+	var mechanism *p11.Mechanism
+	data := digest
+	switch keyType {
+	case p11.CKK_EC:
+		mechanism = p11.NewMechanism(p11.CKM_ECDSA, nil)
+	case ckkECEdwards:
+		if opts.HashFunc() != 0 {
+			return nil, errors.New("ed25519 keys sign messages, not digests")
+		}
+		mechanism = p11.NewMechanism(ckmEdDSA, nil)
+	case p11.CKK_RSA:
+		if pssOpts, ok := opts.(*rsa.PSSOptions); ok {
+			hash, ok := pssHashes[pssOpts.Hash]
+			if !ok {
+				return nil, fmt.Errorf("unsupported hash for RSA-PSS: %s", pssOpts.Hash)
+			}
+			saltLength := pssOpts.SaltLength
+			if saltLength == rsa.PSSSaltLengthAuto || saltLength == rsa.PSSSaltLengthEqualsHash {
+				saltLength = pssOpts.Hash.Size()
+			}
+			mechanism = p11.NewMechanism(p11.CKM_RSA_PKCS_PSS, p11.NewPSSParams(hash.mechanism, hash.mgf, uint(saltLength)))
+			break
+		}
+		prefix, ok := digestInfoPrefixes[opts.HashFunc()]
+		if !ok {
+			return nil, fmt.Errorf("unsupported hash for RSA: %s", opts.HashFunc())
+		}
+		mechanism = p11.NewMechanism(p11.CKM_RSA_PKCS, nil)
+		data = append(append([]byte{}, prefix...), digest...)
+	default:
+		return nil, fmt.Errorf("unsupported key type: %d", keyType)
+	}
+
+	if err = m.ctx.SignInit(m.session, []*p11.Mechanism{mechanism}, key); err != nil {
+		return nil, errors.Wrap(err, "initializing signature")
+	}
+	signature, err := m.ctx.Sign(m.session, data)
+	if err != nil {
+		return nil, errors.Wrap(err, "signing")
+	}
+	if keyType == p11.CKK_EC {
+		// tokens return R || S, while crypto.Signer returns ASN.1 DER
+		return crypto.RSToECDSASignature(signature)
+	}
+	return signature, nil
+}
+
+// PublicKey returns the public key labelled with the key id, as the in-memory key manager does: ecdsa.PublicKey,
+// secp256k1.PublicKey, rsa.PublicKey or ed25519.PublicKey values
+func (m *KeyManager) PublicKey(keyID string) (gocrypto.PublicKey, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	key, err := m.findObject(p11.CKO_PUBLIC_KEY, keyID)
+	if err != nil {
+		return nil, err
+	}
+	keyType, err := m.keyType(key)
+	if err != nil {
+		return nil, err
+	}
+	switch keyType {
+	case p11.CKK_EC, ckkECEdwards:
+		attributes, err := m.ctx.GetAttributeValue(m.session, key, []*p11.Attribute{
+			p11.NewAttribute(p11.CKA_EC_PARAMS, nil),
+			p11.NewAttribute(p11.CKA_EC_POINT, nil),
+		})
+		if err != nil {
+			return nil, errors.Wrap(err, "getting EC public key")
+		}
+		return ecPublicKey(attributes[0].Value, attributes[1].Value)
+	case p11.CKK_RSA:
+		attributes, err := m.ctx.GetAttributeValue(m.session, key, []*p11.Attribute{
+			p11.NewAttribute(p11.CKA_MODULUS, nil),
+			p11.NewAttribute(p11.CKA_PUBLIC_EXPONENT, nil),
+		})
+		if err != nil {
+			return nil, errors.Wrap(err, "getting RSA public key")
+		}
+		return rsa.PublicKey{
+			N: new(big.Int).SetBytes(attributes[0].Value),
+			E: int(new(big.Int).SetBytes(attributes[1].Value).Int64()),
+		}, nil
+	default:
+		return nil, fmt.Errorf("unsupported key type: %d", keyType)
+	}
+}
+
+// Algorithms returns the signature algorithms of the key labelled with the key id
+func (m *KeyManager) Algorithms(keyID string) ([]crypto.SignatureAlgorithm, error) {
+	publicKey, err := m.PublicKey(keyID)
+	if err != nil {
+		return nil, err
+	}
+	var kt crypto.KeyType
+	switch k := publicKey.(type) {
+	case ed25519.PublicKey:
+		kt = crypto.Ed25519
+	case secp.PublicKey:
+		kt = crypto.SECP256k1
+	case rsa.PublicKey:
+		kt = crypto.RSA
+	case ecdsa.PublicKey:
+		kt = map[elliptic.Curve]crypto.KeyType{
+			elliptic.P256(): crypto.P256,
+			elliptic.P384(): crypto.P384,
+			elliptic.P521(): crypto.P521,
+		}[k.Curve]
+	}
+	return crypto.SignatureAlgorithmsForKeyType(kt)
+}
+
+func (m *KeyManager) findObject(class uint, label string) (p11.ObjectHandle, error) {
+	template := []*p11.Attribute{
+		p11.NewAttribute(p11.CKA_CLASS, class),
+		p11.NewAttribute(p11.CKA_LABEL, label),
+	}
+	if err := m.ctx.FindObjectsInit(m.session, template); err != nil {
+		return 0, errors.Wrap(err, "finding key")
+	}
+	objects, _, err := m.ctx.FindObjects(m.session, maxObjectsWithSameLabel)
+	if finalErr := m.ctx.FindObjectsFinal(m.session); err == nil {
+		err = finalErr
+	}
+	if err != nil {
+		return 0, errors.Wrap(err, "finding key")
+	}
+	switch len(objects) {
+	case 0:
+		return 0, fmt.Errorf("key not found: %s", label)
+	case 1:
+		return objects[0], nil
+	default:
+		return 0, fmt.Errorf("more than one key labelled %s", label)
+	}
+}
+
+func (m *KeyManager) keyType(key p11.ObjectHandle) (uint, error) {
+	attributes, err := m.ctx.GetAttributeValue(m.session, key, []*p11.Attribute{p11.NewAttribute(p11.CKA_KEY_TYPE, nil)})
+	if err != nil {
+		return 0, errors.Wrap(err, "getting key type")
+	}
+	value := attributes[0].Value
+	switch len(value) {
+	case 4:
+		return uint(binary.NativeEndian.Uint32(value)), nil
+	case 8:
+		return uint(binary.NativeEndian.Uint64(value)), nil
+	default:
+		return 0, fmt.Errorf("invalid key type length: %d", len(value))
+	}
+}
+
+// ecPublicKey converts the CKA_EC_PARAMS and CKA_EC_POINT of a public key, an OID and a DER encoded OCTET STRING,
+// into a public key
+func ecPublicKey(params, point []byte) (gocrypto.PublicKey, error) {
+	var oid asn1.ObjectIdentifier
+	if _, err := asn1.Unmarshal(params, &oid); err != nil {
+		// some tokens identify edwards25519 by its name
+		var name string
+		if _, err = asn1.Unmarshal(params, &name); err != nil || name != "edwards25519" {
+			return nil, errors.New("unsupported curve parameters")
+		}
+		oid = oidEd25519
+	}
+	var rawPoint []byte
+	if rest, err := asn1.Unmarshal(point, &rawPoint); err != nil || len(rest) != 0 {
+		// some tokens return the point without its encoding
+		rawPoint = point
+	}
+	switch {
+	case oid.Equal(oidEd25519):
+		if len(rawPoint) != ed25519.PublicKeySize {
+			return nil, fmt.Errorf("invalid ed25519 public key length: %d", len(rawPoint))
+		}
+		return ed25519.PublicKey(rawPoint), nil
+	case oid.Equal(oidSECP256k1):
+		publicKey, err := secp.ParsePubKey(rawPoint)
+		if err != nil {
+			return nil, errors.Wrap(err, "parsing secp256k1 public key")
+		}
+		return *publicKey, nil
+	}
+	curve, ok := map[string]elliptic.Curve{
+		oidP256.String(): elliptic.P256(),
+		oidP384.String(): elliptic.P384(),
+		oidP521.String(): elliptic.P521(),
+	}[oid.String()]
+	if !ok {
+		return nil, fmt.Errorf("unsupported curve: %s", oid)
+	}
+	x, y := elliptic.Unmarshal(curve, rawPoint)
+	if x == nil {
+		return nil, errors.New("invalid EC point")
+	}
+	return ecdsa.PublicKey{Curve: curve, X: x, Y: y}, nil
+}
@@ -0,0 +1,121 @@
+//go:build cgo
+
+package pkcs11
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	p11 "github.com/miekg/pkcs11"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/extrimian/ssi-sdk/crypto"
+	"github.com/extrimian/ssi-sdk/crypto/jwx"
+)
+
+const (
+	testTokenLabel = "ssi-sdk"
+	testSOPIN      = "12345678"
+	testPIN        = "1234"
+)
+
+// softHSMPaths are the paths SoftHSM is installed to by common package managers
+var softHSMPaths = []string{
+	"/usr/lib/softhsm/libsofthsm2.so",
+	"/usr/lib/x86_64-linux-gnu/softhsm/libsofthsm2.so",
+	"/usr/lib64/pkcs11/libsofthsm2.so",
+	"/usr/local/lib/softhsm/libsofthsm2.so",
+	"/opt/homebrew/lib/softhsm/libsofthsm2.so",
+}
+
+func TestKeyManager(t *testing.T) {
+	km := newSoftHSMKeyManager(t)
+
+	for _, kt := range []crypto.KeyType{crypto.Ed25519, crypto.SECP256k1, crypto.P256, crypto.P384, crypto.RSA} {
+		t.Run(string(kt), func(tt *testing.T) {
+			publicKey, err := km.GenerateKey(string(kt), kt)
+			require.NoError(tt, err)
+
+			algs, err := km.Algorithms(string(kt))
+			require.NoError(tt, err)
+			expectedAlgs, err := crypto.SignatureAlgorithmsForKeyType(kt)
+			require.NoError(tt, err)
+			assert.Equal(tt, expectedAlgs, algs)
+
+			// the token's signatures verify as JWTs with the public key
+			signer, err := jwx.NewJWXSignerFromKeyManager("test-id", "test-kid", km, string(kt))
+			require.NoError(tt, err)
+			token, err := signer.SignWithDefaults(map[string]any{"test": "data"})
+			require.NoError(tt, err)
+			verifier, err := jwx.NewJWXVerifier("test-id", "test-kid", publicKey)
+			require.NoError(tt, err)
+			assert.NoError(tt, verifier.Verify(string(token)))
+		})
+	}
+
+	t.Run("keys must be unique and known", func(tt *testing.T) {
+		_, err := km.GenerateKey(string(crypto.P256), crypto.P256)
+		assert.ErrorContains(tt, err, "key already exists: P-256")
+
+		_, err = km.PublicKey("missing")
+		assert.ErrorContains(tt, err, "key not found: missing")
+
+		_, err = km.GenerateKey("bls", crypto.BLS12381G2)
+		assert.ErrorContains(tt, err, "unsupported key type")
+	})
+}
+
+// newSoftHSMKeyManager initializes a token in a temporary SoftHSM token directory, skipping the test when SoftHSM is
+// not installed. SOFTHSM2_LIB overrides the path of the library.
+func newSoftHSMKeyManager(t *testing.T) *KeyManager {
+	t.Helper()
+	module := os.Getenv("SOFTHSM2_LIB")
+	for _, path := range softHSMPaths {
+		if module != "" {
+			break
+		}
+		if _, err := os.Stat(path); err == nil {
+			module = path
+		}
+	}
+	if module == "" {
+		t.Skip("SoftHSM is not installed; set SOFTHSM2_LIB to the path of libsofthsm2.so")
+	}
+
+	dir := t.TempDir()
+	tokenDir := filepath.Join(dir, "tokens")
+	require.NoError(t, os.Mkdir(tokenDir, 0o700))
+	config := filepath.Join(dir, "softhsm2.conf")
+	require.NoError(t, os.WriteFile(config, []byte("directories.tokendir = "+tokenDir+"\nobjectstore.backend = file\nlog.level = ERROR\n"), 0o600))
+	t.Setenv("SOFTHSM2_CONF", config)
+
+	ctx := p11.New(module)
+	require.NotNil(t, ctx)
+	require.NoError(t, ctx.Initialize())
+	slots, err := ctx.GetSlotList(false)
+	require.NoError(t, err)
+	require.NotEmpty(t, slots)
+	require.NoError(t, ctx.InitToken(slots[0], testSOPIN, testTokenLabel))
+
+	// SoftHSM moves an initialized token to a new slot
+	slots, err = ctx.GetSlotList(true)
+	require.NoError(t, err)
+	require.NotEmpty(t, slots)
+	session, err := ctx.OpenSession(slots[0], p11.CKF_SERIAL_SESSION|p11.CKF_RW_SESSION)
+	require.NoError(t, err)
+	require.NoError(t, ctx.Login(session, p11.CKU_SO, testSOPIN))
+	require.NoError(t, ctx.InitPIN(session, testPIN))
+	require.NoError(t, ctx.Logout(session))
+	require.NoError(t, ctx.CloseSession(session))
+	require.NoError(t, ctx.Finalize())
+	ctx.Destroy()
+
+	km, err := New(Config{Module: module, TokenLabel: testTokenLabel, PIN: testPIN})
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		assert.NoError(t, km.Close())
+	})
+	return km
+}
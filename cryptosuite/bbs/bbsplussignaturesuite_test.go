@@ -74,3 +74,27 @@ func TestBBSPlusTestVectors(t *testing.T) {
 	err = suite.Verify(verifier, &cred)
 	assert.NoError(t, err)
 }
+
+func TestBBSPlusSignerFromKeyManager(t *testing.T) {
+	keyManager := crypto.NewInMemoryKeyManager()
+	_, err := keyManager.GenerateKey("bbs-key", crypto.BLS12381G2)
+	assert.NoError(t, err)
+
+	signer, err := NewBBSPlusSignerFromKeyManager("test-key-1", keyManager, "bbs-key", cryptosuite.Authentication)
+	assert.NoError(t, err)
+	assert.Nil(t, signer.PrivateKey)
+
+	// statements are signed as separate messages
+	message := []byte("_:c14n0 <http://purl.org/dc/terms/created> \"2021-02-23T19:31:12Z\" .\n<did:example:abcd> <http://schema.org/name> \"JOHN\" .\n")
+	signature, err := signer.Sign(message)
+	assert.NoError(t, err)
+	assert.NoError(t, signer.Verify(message, signature))
+
+	_, err = signer.SignMultiple([]byte("hello"), []byte("world"))
+	assert.Error(t, err)
+
+	_, err = keyManager.GenerateKey("ed25519-key", crypto.Ed25519)
+	assert.NoError(t, err)
+	_, err = NewBBSPlusSignerFromKeyManager("test-key-2", keyManager, "ed25519-key", cryptosuite.Authentication)
+	assert.ErrorContains(t, err, "is not a BLS12381G2 key")
+}
@@ -87,6 +87,19 @@ func NewBBSPlusSigner(kid string, privKey *bbs.PrivateKey, purpose cryptosuite.P
 	}
 }
 
+// NewBBSPlusSignerFromKeyManager creates a signer of a BLS12381G2 key held by a key manager
+func NewBBSPlusSignerFromKeyManager(kid string, keyManager crypto.KeyManager, keyID string, purpose cryptosuite.ProofPurpose) (*BBSPlusSigner, error) {
+	signer, err := crypto.NewBBSPlusSignerFromKeyManager(kid, keyManager, keyID)
+	if err != nil {
+		return nil, err
+	}
+	return &BBSPlusSigner{
+		BBSPlusSigner:   signer,
+		BBSPlusVerifier: signer.BBSPlusVerifier,
+		purpose:         purpose,
+	}, nil
+}
+
 func (s *BBSPlusSigner) Sign(tbs []byte) ([]byte, error) {
 	return s.BBSPlusSigner.Sign(tbs)
 }
@@ -224,6 +224,19 @@ func NewJSONWebKeySigner(id string, key jwx.PrivateKeyJWK, purpose cryptosuite.P
 	}, nil
 }
 
+// NewJSONWebKeySignerFromKeyManager creates a signer of a key held by a key manager, such as a KMS or an HSM, whose
+// private key never leaves the key manager
+func NewJSONWebKeySignerFromKeyManager(id, kid string, keyManager crypto.KeyManager, keyID string, purpose cryptosuite.ProofPurpose) (*JSONWebKeySigner, error) {
+	signer, err := jwx.NewJWXSignerFromKeyManager(id, kid, keyManager, keyID)
+	if err != nil {
+		return nil, err
+	}
+	return &JSONWebKeySigner{
+		Signer:  *signer,
+		purpose: purpose,
+	}, nil
+}
+
 // JSONWebKeyVerifier constructs a verifier for a JSONWebKey2020 object.
 // Given a signature algorithm (e.g. ES256, PS384) and a JSON Web Key (pub key), the verifier is able to accept
 // a message and signature, and provide a result to whether the signature is valid.
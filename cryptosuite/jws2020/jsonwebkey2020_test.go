@@ -3,8 +3,11 @@ package jws2020
 import (
 	"testing"
 
+	"github.com/extrimian/ssi-sdk/crypto"
+	"github.com/extrimian/ssi-sdk/crypto/jwx"
 	"github.com/extrimian/ssi-sdk/cryptosuite"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestJSONWebKey2020SignerVerifier(t *testing.T) {
@@ -64,3 +67,28 @@ func TestJSONWebKey2020SignerVerifier(t *testing.T) {
 		})
 	}
 }
+
+func TestJSONWebKeySignerFromKeyManager(t *testing.T) {
+	signerID := "signer-id"
+	keyManager := crypto.NewInMemoryKeyManager()
+	for _, kt := range []crypto.KeyType{crypto.RSA, crypto.Ed25519, crypto.SECP256k1, crypto.P256, crypto.P384} {
+		t.Run(string(kt), func(tt *testing.T) {
+			publicKey, err := keyManager.GenerateKey(string(kt), kt)
+			require.NoError(tt, err)
+
+			signer, err := NewJSONWebKeySignerFromKeyManager(signerID, "key-1", keyManager, string(kt), cryptosuite.AssertionMethod)
+			require.NoError(tt, err)
+			assert.Equal(tt, cryptosuite.AssertionMethod, signer.GetProofPurpose())
+
+			testMessage := []byte("my name is satoshi")
+			signature, err := signer.Sign(testMessage)
+			require.NoError(tt, err)
+
+			publicKeyJWK, err := jwx.PublicKeyToPublicKeyJWK("key-1", publicKey)
+			require.NoError(tt, err)
+			verifier, err := NewJSONWebKeyVerifier(signerID, *publicKeyJWK)
+			require.NoError(tt, err)
+			assert.NoError(tt, verifier.Verify(testMessage, signature))
+		})
+	}
+}
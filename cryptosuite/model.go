@@ -18,6 +18,7 @@ const (
 	SECP256k1VerificationKey2019Context string = "https://w3id.org/security/suites/secp256k1-2019/v1"
 	JSONWebKey2020Context               string = "https://w3id.org/security/suites/jws-2020/v1"
 	Multikey2021Context                 string = "https://w3id.org/security/suites/multikey-2021/v1"
+	MultikeyContext                     string = "https://w3id.org/security/multikey/v1"
 	BLS12381G2Key2020Context            string = "https://w3id.org/security/suites/bls12381-2020/v1"
 
 	AssertionMethod ProofPurpose = "assertionMethod"
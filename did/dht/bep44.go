@@ -0,0 +1,96 @@
+package dht
+
+import (
+	"crypto/ed25519"
+	"encoding/binary"
+	"fmt"
+	"strconv"
+
+	"github.com/pkg/errors"
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+// MaxValueSize is the largest value of a BEP44 mutable item https://www.bittorrent.org/beps/bep_0044.html
+const MaxValueSize = 1000
+
+// BEP44Message is a BEP44 signed mutable item, whose value is the DNS packet of a DID Document. The sequence number
+// orders the versions of the item, and is the time of publication in seconds.
+type BEP44Message struct {
+	Key ed25519.PublicKey
+	Seq int64
+	V   []byte
+	Sig []byte
+}
+
+// NewBEP44Message signs a DNS packet as a BEP44 mutable item with the identity key
+func NewBEP44Message(identityKey ed25519.PrivateKey, seq int64, packet *dnsmessage.Message) (*BEP44Message, error) {
+	v, err := packet.Pack()
+	if err != nil {
+		return nil, errors.Wrap(err, "packing dns packet")
+	}
+	if len(v) > MaxValueSize {
+		return nil, fmt.Errorf("dns packet of %d bytes exceeds the limit of %d bytes", len(v), MaxValueSize)
+	}
+	return &BEP44Message{
+		Key: identityKey.Public().(ed25519.PublicKey),
+		Seq: seq,
+		V:   v,
+		Sig: ed25519.Sign(identityKey, signable(seq, v)),
+	}, nil
+}
+
+// Verify checks the signature of the item
+func (m BEP44Message) Verify() error {
+	if len(m.Key) != ed25519.PublicKeySize {
+		return errors.New("invalid key")
+	}
+	if len(m.V) > MaxValueSize {
+		return fmt.Errorf("value of %d bytes exceeds the limit of %d bytes", len(m.V), MaxValueSize)
+	}
+	if !ed25519.Verify(m.Key, signable(m.Seq, m.V), m.Sig) {
+		return errors.New("invalid signature")
+	}
+	return nil
+}
+
+// DNSPacket unpacks the value of the item
+func (m BEP44Message) DNSPacket() (*dnsmessage.Message, error) {
+	var packet dnsmessage.Message
+	if err := packet.Unpack(m.V); err != nil {
+		return nil, errors.Wrap(err, "unpacking dns packet")
+	}
+	return &packet, nil
+}
+
+// MarshalRelay encodes the item as the body of a Pkarr relay request: the signature, the sequence number as 8 big
+// endian bytes, then the value
+// https://github.com/Nuhvi/pkarr/blob/main/design/relays.md
+func (m BEP44Message) MarshalRelay() []byte {
+	body := make([]byte, 0, ed25519.SignatureSize+8+len(m.V))
+	body = append(body, m.Sig...)
+	body = binary.BigEndian.AppendUint64(body, uint64(m.Seq))
+	return append(body, m.V...)
+}
+
+// UnmarshalRelay decodes the body of a Pkarr relay request for the given key, and verifies it
+func UnmarshalRelay(key ed25519.PublicKey, body []byte) (*BEP44Message, error) {
+	if len(body) < ed25519.SignatureSize+8 {
+		return nil, errors.New("relay body is too short")
+	}
+	m := BEP44Message{
+		Key: key,
+		Sig: body[:ed25519.SignatureSize],
+		Seq: int64(binary.BigEndian.Uint64(body[ed25519.SignatureSize : ed25519.SignatureSize+8])),
+		V:   body[ed25519.SignatureSize+8:],
+	}
+	if err := m.Verify(); err != nil {
+		return nil, err
+	}
+	return &m, nil
+}
+
+// signable returns the bencoded sequence number and value that are signed
+func signable(seq int64, v []byte) []byte {
+	s := "3:seqi" + strconv.FormatInt(seq, 10) + "e1:v" + strconv.Itoa(len(v)) + ":"
+	return append([]byte(s), v...)
+}
@@ -0,0 +1,251 @@
+// Package dht implements the did:dht method https://did-dht.com
+// A did:dht DID is the z-base-32 encoding of an Ed25519 identity key. Its DID Document is encoded as a DNS packet,
+// which is signed by the identity key and stored as a BEP44 mutable item in the Mainline DHT, usually through a
+// Pkarr gateway. Neither a blockchain nor a web server is involved.
+package dht
+
+import (
+	"crypto/ed25519"
+	"fmt"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/extrimian/ssi-sdk/crypto"
+	"github.com/extrimian/ssi-sdk/did"
+	"github.com/extrimian/ssi-sdk/util"
+)
+
+type (
+	DIDDHT string
+
+	// PublicKeyPurpose is a verification relationship, named as in the root record of the DNS packet
+	PublicKeyPurpose string
+
+	// TypeIndex is an entry of the did:dht type registry, describing the kind of entity the DID is for
+	// https://did-dht.com/registry/#indexed-types
+	TypeIndex int
+)
+
+const (
+	Prefix = "did:dht"
+
+	// IdentityKeyID is the fragment of the verification method of the identity key
+	IdentityKeyID = "0"
+
+	Authentication       PublicKeyPurpose = "auth"
+	AssertionMethod      PublicKeyPurpose = "asm"
+	KeyAgreement         PublicKeyPurpose = "agm"
+	CapabilityInvocation PublicKeyPurpose = "inv"
+	CapabilityDelegation PublicKeyPurpose = "del"
+
+	Discoverable           TypeIndex = 0
+	Organization           TypeIndex = 1
+	GovernmentOrganization TypeIndex = 2
+	Corporation            TypeIndex = 3
+	LocalBusiness          TypeIndex = 4
+	SoftwarePackage        TypeIndex = 5
+	WebApp                 TypeIndex = 6
+	FinancialInstitution   TypeIndex = 7
+)
+
+// VerificationMethod is an additional verification method of a DID, along with its verification relationships
+type VerificationMethod struct {
+	VerificationMethod did.VerificationMethod
+	Purposes           []PublicKeyPurpose
+}
+
+// CreateDIDDHTOpts are the optional contents of a new DID Document
+type CreateDIDDHTOpts struct {
+	// Controller and AlsoKnownAs may hold comma separated lists
+	Controller          string
+	AlsoKnownAs         string
+	VerificationMethods []VerificationMethod
+	Services            []did.Service
+}
+
+// GenerateDIDDHT generates an identity key and creates the DID Document of its did:dht DID
+func GenerateDIDDHT(opts CreateDIDDHTOpts) (ed25519.PrivateKey, *did.Document, error) {
+	pubKey, privKey, err := crypto.GenerateEd25519Key()
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "generating identity key")
+	}
+	doc, err := CreateDIDDHTDID(pubKey, opts)
+	if err != nil {
+		return nil, nil, err
+	}
+	return privKey, doc, nil
+}
+
+// CreateDIDDHTDID creates the DID Document of the did:dht DID of an identity key. The identity key is the first
+// verification method, with the id 0, and is used for authentication, assertion, capability invocation and
+// capability delegation.
+// https://did-dht.com/#create
+func CreateDIDDHTDID(identityKey ed25519.PublicKey, opts CreateDIDDHTOpts) (*did.Document, error) {
+	id, err := FromIdentityKey(identityKey)
+	if err != nil {
+		return nil, err
+	}
+	identityVM, err := did.ConstructJWKVerificationMethod(id.verificationMethodID(IdentityKeyID), id.String(),
+		identityKey, crypto.Ed25519)
+	if err != nil {
+		return nil, errors.Wrap(err, "constructing identity key verification method")
+	}
+	identityRef := []did.VerificationMethodSet{identityVM.ID}
+	doc := did.Document{
+		Context:              did.KnownDIDContext,
+		ID:                   id.String(),
+		Controller:           opts.Controller,
+		AlsoKnownAs:          opts.AlsoKnownAs,
+		VerificationMethod:   []did.VerificationMethod{*identityVM},
+		Authentication:       identityRef,
+		AssertionMethod:      identityRef,
+		CapabilityInvocation: identityRef,
+		CapabilityDelegation: identityRef,
+	}
+	for _, vm := range opts.VerificationMethods {
+		method := vm.VerificationMethod
+		if method.ID == "" {
+			return nil, errors.New("verification method id is required")
+		}
+		method.ID = id.verificationMethodID(method.ID)
+		if method.ID == identityVM.ID {
+			return nil, fmt.Errorf("verification method id %s is reserved for the identity key", IdentityKeyID)
+		}
+		if method.Controller == "" {
+			method.Controller = id.String()
+		}
+		doc.VerificationMethod = append(doc.VerificationMethod, method)
+		for _, purpose := range vm.Purposes {
+			relationship := relationshipOf(&doc, purpose)
+			if relationship == nil {
+				return nil, fmt.Errorf("unknown purpose: %s", purpose)
+			}
+			*relationship = append(*relationship, method.ID)
+		}
+	}
+	for _, service := range opts.Services {
+		service.ID = id.verificationMethodID(service.ID)
+		doc.Services = append(doc.Services, service)
+	}
+	return &doc, nil
+}
+
+// FromIdentityKey returns the did:dht DID of an identity key
+func FromIdentityKey(identityKey ed25519.PublicKey) (DIDDHT, error) {
+	if len(identityKey) != ed25519.PublicKeySize {
+		return "", fmt.Errorf("identity key must be %d bytes", ed25519.PublicKeySize)
+	}
+	return DIDDHT(Prefix + ":" + zBase32Encode(identityKey)), nil
+}
+
+// IsValid checks that the DID is the z-base-32 encoding of an Ed25519 key
+func (d DIDDHT) IsValid() bool {
+	_, err := d.IdentityKey()
+	return err == nil
+}
+
+func (d DIDDHT) String() string {
+	return string(d)
+}
+
+func (d DIDDHT) Suffix() (string, error) {
+	suffix, ok := strings.CutPrefix(d.String(), Prefix+":")
+	if !ok || suffix == "" {
+		return "", errors.Wrap(util.InvalidFormatError, "did is malformed")
+	}
+	return suffix, nil
+}
+
+func (DIDDHT) Method() did.Method {
+	return did.DHTMethod
+}
+
+// IdentityKey decodes the identity key of the DID
+func (d DIDDHT) IdentityKey() (ed25519.PublicKey, error) {
+	suffix, err := d.Suffix()
+	if err != nil {
+		return nil, err
+	}
+	decoded, err := zBase32Decode(suffix)
+	if err != nil {
+		return nil, errors.Wrap(err, "decoding identity key")
+	}
+	if len(decoded) != ed25519.PublicKeySize {
+		return nil, fmt.Errorf("identity key must be %d bytes", ed25519.PublicKeySize)
+	}
+	return decoded, nil
+}
+
+// verificationMethodID qualifies a fragment, with or without its #, with the DID
+func (d DIDDHT) verificationMethodID(fragment string) string {
+	if strings.HasPrefix(fragment, d.String()+"#") {
+		return fragment
+	}
+	return d.String() + "#" + strings.TrimPrefix(fragment, "#")
+}
+
+// fragment returns the fragment of a DID URL or relative reference, without its #
+func (d DIDDHT) fragment(id string) string {
+	return strings.TrimPrefix(strings.TrimPrefix(id, d.String()), "#")
+}
+
+func relationshipOf(doc *did.Document, purpose PublicKeyPurpose) *did.VerificationRelationship {
+	switch purpose {
+	case Authentication:
+		return &doc.Authentication
+	case AssertionMethod:
+		return &doc.AssertionMethod
+	case KeyAgreement:
+		return &doc.KeyAgreement
+	case CapabilityInvocation:
+		return &doc.CapabilityInvocation
+	case CapabilityDelegation:
+		return &doc.CapabilityDelegation
+	default:
+		return nil
+	}
+}
+
+var purposes = []PublicKeyPurpose{Authentication, AssertionMethod, KeyAgreement, CapabilityInvocation, CapabilityDelegation}
+
+// zBase32Alphabet is the human-oriented base-32 alphabet https://philzimmermann.com/docs/human-oriented-base-32-encoding.txt
+const zBase32Alphabet = "ybndrfg8ejkmcpqxot1uwisza345h769"
+
+func zBase32Encode(data []byte) string {
+	var sb strings.Builder
+	var buffer, bits uint
+	for _, b := range data {
+		buffer = buffer<<8 | uint(b)
+		bits += 8
+		for bits >= 5 {
+			bits -= 5
+			sb.WriteByte(zBase32Alphabet[(buffer>>bits)&31])
+		}
+	}
+	if bits > 0 {
+		sb.WriteByte(zBase32Alphabet[(buffer<<(5-bits))&31])
+	}
+	return sb.String()
+}
+
+func zBase32Decode(s string) ([]byte, error) {
+	var out []byte
+	var buffer, bits uint
+	for _, c := range s {
+		i := strings.IndexRune(zBase32Alphabet, c)
+		if i < 0 {
+			return nil, fmt.Errorf("invalid z-base-32 character: %q", c)
+		}
+		buffer = buffer<<5 | uint(i)
+		bits += 5
+		if bits >= 8 {
+			bits -= 8
+			out = append(out, byte(buffer>>bits))
+		}
+	}
+	if buffer&(1<<bits-1) != 0 {
+		return nil, errors.New("invalid z-base-32 padding")
+	}
+	return out, nil
+}
@@ -0,0 +1,187 @@
+package dht
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/dns/dnsmessage"
+
+	"github.com/extrimian/ssi-sdk/crypto"
+	"github.com/extrimian/ssi-sdk/did"
+)
+
+func TestZBase32(t *testing.T) {
+	t.Run("Known Value", func(tt *testing.T) {
+		// test vector from https://philzimmermann.com/docs/human-oriented-base-32-encoding.txt
+		assert.Equal(tt, "yy", zBase32Encode([]byte{0}))
+		assert.Equal(tt, "6n9hq", zBase32Encode([]byte{0xf0, 0xbf, 0xc7}))
+	})
+
+	t.Run("Round Trip", func(tt *testing.T) {
+		pk, _, err := crypto.GenerateEd25519Key()
+		require.NoError(tt, err)
+		encoded := zBase32Encode(pk)
+		assert.Len(tt, encoded, 52)
+		decoded, err := zBase32Decode(encoded)
+		require.NoError(tt, err)
+		assert.Equal(tt, []byte(pk), decoded)
+	})
+
+	t.Run("Invalid Character", func(tt *testing.T) {
+		_, err := zBase32Decode("yyl")
+		assert.Error(tt, err)
+	})
+}
+
+func TestDIDDHT(t *testing.T) {
+	privKey, doc, err := GenerateDIDDHT(CreateDIDDHTOpts{})
+	require.NoError(t, err)
+	didDHT := DIDDHT(doc.ID)
+	assert.True(t, didDHT.IsValid())
+	assert.Equal(t, did.DHTMethod, didDHT.Method())
+
+	identityKey, err := didDHT.IdentityKey()
+	require.NoError(t, err)
+	assert.Equal(t, privKey.Public(), identityKey)
+	assert.Equal(t, doc.ID+"#0", doc.VerificationMethod[0].ID)
+	assert.Equal(t, did.VerificationRelationship{doc.ID + "#0"}, doc.Authentication)
+	assert.Empty(t, doc.KeyAgreement)
+
+	t.Run("Invalid DIDs", func(tt *testing.T) {
+		assert.False(tt, DIDDHT("did:dht:abc").IsValid())
+		assert.False(tt, DIDDHT("did:dht:"+strings.Repeat("l", 52)).IsValid())
+		assert.False(tt, DIDDHT("did:web:example.com").IsValid())
+	})
+
+	t.Run("Reserved Identity Key ID", func(tt *testing.T) {
+		pk, _, err := crypto.GenerateEd25519Key()
+		require.NoError(tt, err)
+		vm, err := did.ConstructJWKVerificationMethod("#0", "", pk, crypto.Ed25519)
+		require.NoError(tt, err)
+		_, _, err = GenerateDIDDHT(CreateDIDDHTOpts{VerificationMethods: []VerificationMethod{{VerificationMethod: *vm}}})
+		assert.ErrorContains(tt, err, "reserved")
+	})
+}
+
+func TestDNSPacket(t *testing.T) {
+	x25519Key, _, err := crypto.GenerateX25519Key()
+	require.NoError(t, err)
+	agreementVM, err := did.ConstructJWKVerificationMethod("enc", "", x25519Key, crypto.X25519)
+	require.NoError(t, err)
+	secpKey, _, err := crypto.GenerateSECP256k1Key()
+	require.NoError(t, err)
+	secpBytes, err := crypto.PubKeyToBytes(secpKey)
+	require.NoError(t, err)
+	secpVM, err := did.ConstructJWKVerificationMethod("#secp", "did:example:controller", secpBytes, crypto.SECP256k1)
+	require.NoError(t, err)
+	p256Key, _, err := crypto.GenerateP256Key()
+	require.NoError(t, err)
+	p256Bytes, err := crypto.PubKeyToBytes(p256Key, crypto.ECDSAMarshalCompressed)
+	require.NoError(t, err)
+	p256VM, err := did.ConstructJWKVerificationMethod("#p256", "", p256Bytes, crypto.P256)
+	require.NoError(t, err)
+
+	_, doc, err := GenerateDIDDHT(CreateDIDDHTOpts{
+		Controller:  "did:example:controller",
+		AlsoKnownAs: "did:example:alias1,did:example:alias2",
+		VerificationMethods: []VerificationMethod{
+			{VerificationMethod: *agreementVM, Purposes: []PublicKeyPurpose{KeyAgreement}},
+			{VerificationMethod: *secpVM, Purposes: []PublicKeyPurpose{AssertionMethod, Authentication}},
+			{VerificationMethod: *p256VM},
+		},
+		Services: []did.Service{
+			{ID: "dwn", Type: "DecentralizedWebNode", ServiceEndpoint: []string{"https://a.example.com", "https://b.example.com"}},
+			{ID: "#site", Type: "LinkedDomains", ServiceEndpoint: "https://example.com"},
+		},
+	})
+	require.NoError(t, err)
+	didDHT := DIDDHT(doc.ID)
+
+	packet, err := didDHT.ToDNSPacket(*doc, []TypeIndex{Organization, FinancialInstitution})
+	require.NoError(t, err)
+	packed, err := packet.Pack()
+	require.NoError(t, err)
+	assert.LessOrEqual(t, len(packed), MaxValueSize)
+
+	decoded, types, err := didDHT.FromDNSPacket(packet)
+	require.NoError(t, err)
+	assert.Equal(t, []TypeIndex{Organization, FinancialInstitution}, types)
+	assert.Equal(t, doc.Controller, decoded.Controller)
+	assert.Equal(t, doc.AlsoKnownAs, decoded.AlsoKnownAs)
+	require.Len(t, decoded.VerificationMethod, 4)
+	for i, vm := range doc.VerificationMethod {
+		assert.Equal(t, vm.ID, decoded.VerificationMethod[i].ID)
+		assert.Equal(t, vm.Controller, decoded.VerificationMethod[i].Controller)
+		assert.Equal(t, vm.PublicKeyJWK.X, decoded.VerificationMethod[i].PublicKeyJWK.X)
+		assert.Equal(t, vm.PublicKeyJWK.Y, decoded.VerificationMethod[i].PublicKeyJWK.Y)
+	}
+	assert.Equal(t, doc.Authentication, decoded.Authentication)
+	assert.Equal(t, doc.AssertionMethod, decoded.AssertionMethod)
+	assert.Equal(t, doc.KeyAgreement, decoded.KeyAgreement)
+	assert.Equal(t, doc.CapabilityInvocation, decoded.CapabilityInvocation)
+	assert.Equal(t, doc.CapabilityDelegation, decoded.CapabilityDelegation)
+	require.Len(t, decoded.Services, 2)
+	assert.Equal(t, doc.ID+"#dwn", decoded.Services[0].ID)
+	assert.Equal(t, []string{"https://a.example.com", "https://b.example.com"}, decoded.Services[0].ServiceEndpoint)
+	assert.Equal(t, doc.Services[1].ServiceEndpoint, decoded.Services[1].ServiceEndpoint)
+
+	t.Run("Long Values Are Split", func(tt *testing.T) {
+		long := *doc
+		long.VerificationMethod = doc.VerificationMethod[:1]
+		long.Authentication, long.AssertionMethod, long.KeyAgreement = nil, nil, nil
+		long.CapabilityInvocation, long.CapabilityDelegation = nil, nil
+		long.Services = []did.Service{{ID: "#site", Type: "LinkedDomains", ServiceEndpoint: "https://" + strings.Repeat("a", 300) + ".com"}}
+		longPacket, err := didDHT.ToDNSPacket(long, nil)
+		require.NoError(tt, err)
+		packed, err := longPacket.Pack()
+		require.NoError(tt, err)
+		assert.LessOrEqual(tt, len(packed), MaxValueSize)
+		decoded, _, err := didDHT.FromDNSPacket(longPacket)
+		require.NoError(tt, err)
+		assert.Equal(tt, long.Services[0].ServiceEndpoint, decoded.Services[0].ServiceEndpoint)
+	})
+
+	t.Run("Packet Too Large", func(tt *testing.T) {
+		large := *doc
+		large.AlsoKnownAs = strings.Repeat("did:example:alias,", 60)
+		largePacket, err := didDHT.ToDNSPacket(large, nil)
+		require.NoError(tt, err)
+		_, err = NewBEP44Message(nil, 1, largePacket)
+		assert.ErrorContains(tt, err, "exceeds the limit")
+	})
+
+	t.Run("Document Of Another DID", func(tt *testing.T) {
+		_, other, err := GenerateDIDDHT(CreateDIDDHTOpts{})
+		require.NoError(tt, err)
+		_, err = didDHT.ToDNSPacket(*other, nil)
+		assert.Error(tt, err)
+	})
+
+	t.Run("Non JWK Verification Method", func(tt *testing.T) {
+		invalid := *doc
+		invalid.VerificationMethod = append([]did.VerificationMethod{}, doc.VerificationMethod...)
+		invalid.VerificationMethod[3] = did.VerificationMethod{ID: doc.ID + "#p256", Type: "Multikey", PublicKeyMultibase: "z6Mk"}
+		_, err := didDHT.ToDNSPacket(invalid, nil)
+		assert.ErrorContains(tt, err, "must be a JWK")
+	})
+
+	t.Run("First Verification Method Is Not The Identity Key", func(tt *testing.T) {
+		_, other, err := GenerateDIDDHT(CreateDIDDHTOpts{})
+		require.NoError(tt, err)
+		otherPacket, err := DIDDHT(other.ID).ToDNSPacket(*other, nil)
+		require.NoError(tt, err)
+		// present the other DID's packet, and so its identity key, as the packet of this DID
+		suffix, err := didDHT.Suffix()
+		require.NoError(tt, err)
+		otherPacket.Answers[0].Header.Name = dnsmessage.MustNewName(rootRecordPrefix + suffix + ".")
+		_, _, err = didDHT.FromDNSPacket(otherPacket)
+		assert.ErrorContains(tt, err, "not the identity key")
+	})
+
+	t.Run("Packet Without Root Record", func(tt *testing.T) {
+		_, _, err := DIDDHT("did:dht:" + zBase32Encode(make([]byte, 32))).FromDNSPacket(packet)
+		assert.ErrorContains(tt, err, "no root record")
+	})
+}
@@ -0,0 +1,386 @@
+package dht
+
+import (
+	"crypto/ed25519"
+	"encoding/base64"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+	"golang.org/x/net/dns/dnsmessage"
+
+	"github.com/extrimian/ssi-sdk/crypto"
+	"github.com/extrimian/ssi-sdk/crypto/jwx"
+	"github.com/extrimian/ssi-sdk/did"
+)
+
+// The DID Document is represented as TXT records of a DNS packet https://did-dht.com/#dids-as-dns-records
+// The root record _did.<id>. lists the other records and the verification relationships, e.g.
+//
+//	_did.<id>.   TXT "v=0;vm=k0,k1;auth=k0;asm=k0,k1;agm=k1;inv=k0;del=k0;svc=s0"
+//	_k0._did.    TXT "id=0;t=0;k=<base64url public key>"
+//	_s0._did.    TXT "id=dwn;t=DecentralizedWebNode;se=https://example.com/dwn"
+//	_cnt._did.   TXT "did:example:controller"
+//	_aka._did.   TXT "did:example:alias"
+//	_typ._did.   TXT "id=1,7"
+const (
+	// RecordTTL is the time to live of the TXT records, in seconds
+	RecordTTL = 7200
+	// SpecVersion is the version of the DNS representation
+	SpecVersion = "0"
+
+	rootRecordPrefix = "_did."
+	recordSuffix     = "._did."
+	controllerRecord = "_cnt._did."
+	aliasRecord      = "_aka._did."
+	typeRecord       = "_typ._did."
+
+	// maxCharacterString is the longest character string of a TXT record, longer values are split
+	maxCharacterString = 255
+)
+
+// keyTypeIndexes are the key types of the did:dht key type registry https://did-dht.com/registry/#key-type-index
+var keyTypeIndexes = map[crypto.KeyType]int{
+	crypto.Ed25519:   0,
+	crypto.SECP256k1: 1,
+	crypto.P256:      2,
+	crypto.X25519:    3,
+}
+
+// ToDNSPacket encodes the DID Document and types of the DID as a DNS packet. Verification methods must be JWKs
+// of a key type in the registry, and verification relationships must reference them.
+func (d DIDDHT) ToDNSPacket(doc did.Document, types []TypeIndex) (*dnsmessage.Message, error) {
+	if doc.ID != d.String() {
+		return nil, fmt.Errorf("document id<%s> does not match %s", doc.ID, d)
+	}
+	suffix, err := d.Suffix()
+	if err != nil {
+		return nil, err
+	}
+
+	var records []dnsmessage.Resource
+	keyNames := make(map[string]string)
+	var vmNames []string
+	for i, vm := range doc.VerificationMethod {
+		name := fmt.Sprintf("k%d", i)
+		fragment := d.fragment(vm.ID)
+		keyNames[fragment] = name
+		vmNames = append(vmNames, name)
+		value, err := d.encodeVerificationMethod(fragment, vm)
+		if err != nil {
+			return nil, err
+		}
+		records = append(records, txtRecord("_"+name+recordSuffix, value))
+	}
+
+	root := []string{"v=" + SpecVersion}
+	if len(vmNames) > 0 {
+		root = append(root, "vm="+strings.Join(vmNames, ","))
+	}
+	for _, purpose := range purposes {
+		refs, err := d.relationshipKeyNames(*relationshipOf(&doc, purpose), keyNames)
+		if err != nil {
+			return nil, errors.Wrapf(err, "encoding %s", purpose)
+		}
+		if len(refs) > 0 {
+			root = append(root, string(purpose)+"="+strings.Join(refs, ","))
+		}
+	}
+
+	var svcNames []string
+	for i, service := range doc.Services {
+		name := fmt.Sprintf("s%d", i)
+		svcNames = append(svcNames, name)
+		value, err := d.encodeService(service)
+		if err != nil {
+			return nil, err
+		}
+		records = append(records, txtRecord("_"+name+recordSuffix, value))
+	}
+	if len(svcNames) > 0 {
+		root = append(root, "svc="+strings.Join(svcNames, ","))
+	}
+
+	if doc.Controller != "" {
+		records = append(records, txtRecord(controllerRecord, doc.Controller))
+	}
+	if doc.AlsoKnownAs != "" {
+		records = append(records, txtRecord(aliasRecord, doc.AlsoKnownAs))
+	}
+	if len(types) > 0 {
+		typeStrs := make([]string, 0, len(types))
+		for _, t := range types {
+			typeStrs = append(typeStrs, strconv.Itoa(int(t)))
+		}
+		records = append(records, txtRecord(typeRecord, "id="+strings.Join(typeStrs, ",")))
+	}
+
+	answers := append([]dnsmessage.Resource{txtRecord(rootRecordPrefix+suffix+".", strings.Join(root, ";"))}, records...)
+	return &dnsmessage.Message{
+		Header:  dnsmessage.Header{Response: true, Authoritative: true},
+		Answers: answers,
+	}, nil
+}
+
+// FromDNSPacket decodes the DID Document and types of the DID from a DNS packet
+func (d DIDDHT) FromDNSPacket(msg *dnsmessage.Message) (*did.Document, []TypeIndex, error) {
+	suffix, err := d.Suffix()
+	if err != nil {
+		return nil, nil, err
+	}
+	records := make(map[string]string)
+	for _, answer := range msg.Answers {
+		txt, ok := answer.Body.(*dnsmessage.TXTResource)
+		if !ok {
+			continue
+		}
+		records[answer.Header.Name.String()] = strings.Join(txt.TXT, "")
+	}
+	rootValue, ok := records[rootRecordPrefix+suffix+"."]
+	if !ok {
+		return nil, nil, errors.New("dns packet has no root record")
+	}
+	root := parseProperties(rootValue)
+	if root["v"] != SpecVersion {
+		return nil, nil, fmt.Errorf("unsupported version: %s", root["v"])
+	}
+
+	doc := did.Document{
+		Context:     did.KnownDIDContext,
+		ID:          d.String(),
+		Controller:  records[controllerRecord],
+		AlsoKnownAs: records[aliasRecord],
+	}
+	vmIDs := make(map[string]string)
+	for _, name := range splitList(root["vm"]) {
+		value, ok := records["_"+name+recordSuffix]
+		if !ok {
+			return nil, nil, fmt.Errorf("missing verification method record: %s", name)
+		}
+		vm, err := d.decodeVerificationMethod(value)
+		if err != nil {
+			return nil, nil, errors.Wrapf(err, "decoding verification method record %s", name)
+		}
+		vmIDs[name] = vm.ID
+		doc.VerificationMethod = append(doc.VerificationMethod, *vm)
+	}
+	if len(doc.VerificationMethod) == 0 || doc.VerificationMethod[0].ID != d.verificationMethodID(IdentityKeyID) {
+		return nil, nil, errors.New("the identity key must be the first verification method")
+	}
+	identityKey, err := d.IdentityKey()
+	if err != nil {
+		return nil, nil, err
+	}
+	firstKey, err := doc.VerificationMethod[0].PublicKeyJWK.ToPublicKey()
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "decoding the first verification method")
+	}
+	if key, ok := firstKey.(ed25519.PublicKey); !ok || !key.Equal(identityKey) {
+		return nil, nil, errors.New("the first verification method is not the identity key")
+	}
+	for _, purpose := range purposes {
+		for _, name := range splitList(root[string(purpose)]) {
+			id, ok := vmIDs[name]
+			if !ok {
+				return nil, nil, fmt.Errorf("%s references unknown verification method: %s", purpose, name)
+			}
+			relationship := relationshipOf(&doc, purpose)
+			*relationship = append(*relationship, id)
+		}
+	}
+	for _, name := range splitList(root["svc"]) {
+		value, ok := records["_"+name+recordSuffix]
+		if !ok {
+			return nil, nil, fmt.Errorf("missing service record: %s", name)
+		}
+		doc.Services = append(doc.Services, d.decodeService(value))
+	}
+
+	var types []TypeIndex
+	if typeValue, ok := records[typeRecord]; ok {
+		for _, t := range splitList(parseProperties(typeValue)["id"]) {
+			index, err := strconv.Atoi(t)
+			if err != nil {
+				return nil, nil, fmt.Errorf("invalid type index: %s", t)
+			}
+			types = append(types, TypeIndex(index))
+		}
+	}
+	return &doc, types, nil
+}
+
+func (d DIDDHT) encodeVerificationMethod(fragment string, vm did.VerificationMethod) (string, error) {
+	if vm.PublicKeyJWK == nil {
+		return "", fmt.Errorf("verification method %s must be a JWK", vm.ID)
+	}
+	pubKey, err := vm.PublicKeyJWK.ToPublicKey()
+	if err != nil {
+		return "", errors.Wrapf(err, "converting verification method %s to public key", vm.ID)
+	}
+	kt, err := keyTypeOfJWK(*vm.PublicKeyJWK)
+	if err != nil {
+		return "", errors.Wrapf(err, "verification method %s", vm.ID)
+	}
+	keyBytes, err := crypto.PubKeyToBytes(pubKey, crypto.ECDSAMarshalCompressed)
+	if err != nil {
+		return "", errors.Wrapf(err, "converting verification method %s to bytes", vm.ID)
+	}
+	props := []string{
+		"id=" + fragment,
+		"t=" + strconv.Itoa(keyTypeIndexes[kt]),
+		"k=" + base64.RawURLEncoding.EncodeToString(keyBytes),
+	}
+	if vm.PublicKeyJWK.ALG != "" {
+		props = append(props, "a="+vm.PublicKeyJWK.ALG)
+	}
+	if vm.Controller != "" && vm.Controller != d.String() {
+		props = append(props, "c="+vm.Controller)
+	}
+	return strings.Join(props, ";"), nil
+}
+
+func (d DIDDHT) decodeVerificationMethod(value string) (*did.VerificationMethod, error) {
+	props := parseProperties(value)
+	index, err := strconv.Atoi(props["t"])
+	if err != nil {
+		return nil, fmt.Errorf("invalid key type index: %s", props["t"])
+	}
+	var kt crypto.KeyType
+	for keyType, i := range keyTypeIndexes {
+		if i == index {
+			kt = keyType
+		}
+	}
+	if kt == "" {
+		return nil, fmt.Errorf("unsupported key type index: %d", index)
+	}
+	keyBytes, err := base64.RawURLEncoding.DecodeString(props["k"])
+	if err != nil {
+		return nil, errors.Wrap(err, "decoding key")
+	}
+	controller := props["c"]
+	if controller == "" {
+		controller = d.String()
+	}
+	vm, err := did.ConstructJWKVerificationMethod(d.verificationMethodID(props["id"]), controller, keyBytes, kt)
+	if err != nil {
+		return nil, err
+	}
+	if alg := props["a"]; alg != "" {
+		vm.PublicKeyJWK.ALG = alg
+	}
+	return vm, nil
+}
+
+func (d DIDDHT) encodeService(service did.Service) (string, error) {
+	var endpoints []string
+	switch endpoint := service.ServiceEndpoint.(type) {
+	case string:
+		endpoints = []string{endpoint}
+	case []string:
+		endpoints = endpoint
+	case []any:
+		for _, e := range endpoint {
+			s, ok := e.(string)
+			if !ok {
+				return "", fmt.Errorf("service %s endpoints must be strings", service.ID)
+			}
+			endpoints = append(endpoints, s)
+		}
+	default:
+		return "", fmt.Errorf("service %s endpoints must be strings", service.ID)
+	}
+	return strings.Join([]string{
+		"id=" + d.fragment(service.ID),
+		"t=" + service.Type,
+		"se=" + strings.Join(endpoints, ","),
+	}, ";"), nil
+}
+
+func (d DIDDHT) decodeService(value string) did.Service {
+	props := parseProperties(value)
+	var endpoint any = props["se"]
+	if endpoints := splitList(props["se"]); len(endpoints) > 1 {
+		endpoint = endpoints
+	}
+	return did.Service{
+		ID:              d.verificationMethodID(props["id"]),
+		Type:            props["t"],
+		ServiceEndpoint: endpoint,
+	}
+}
+
+// relationshipKeyNames returns the record names of the verification methods referenced by a relationship
+func (d DIDDHT) relationshipKeyNames(relationship []did.VerificationMethodSet, keyNames map[string]string) ([]string, error) {
+	var ids []string
+	for _, ref := range relationship {
+		switch r := ref.(type) {
+		case string:
+			ids = append(ids, r)
+		case []string:
+			ids = append(ids, r...)
+		default:
+			return nil, errors.New("embedded verification methods are not supported")
+		}
+	}
+	var names []string
+	for _, id := range ids {
+		name, ok := keyNames[d.fragment(id)]
+		if !ok {
+			return nil, fmt.Errorf("unknown verification method: %s", id)
+		}
+		names = append(names, name)
+	}
+	return names, nil
+}
+
+func keyTypeOfJWK(jwk jwx.PublicKeyJWK) (crypto.KeyType, error) {
+	switch {
+	case jwk.KTY == "OKP" && jwk.CRV == "Ed25519":
+		return crypto.Ed25519, nil
+	case jwk.KTY == "OKP" && jwk.CRV == "X25519":
+		return crypto.X25519, nil
+	case jwk.KTY == "EC" && jwk.CRV == "secp256k1":
+		return crypto.SECP256k1, nil
+	case jwk.KTY == "EC" && jwk.CRV == "P-256":
+		return crypto.P256, nil
+	default:
+		return "", fmt.Errorf("unsupported key type<%s> and curve<%s>", jwk.KTY, jwk.CRV)
+	}
+}
+
+func txtRecord(name, value string) dnsmessage.Resource {
+	var chunks []string
+	for len(value) > maxCharacterString {
+		chunks = append(chunks, value[:maxCharacterString])
+		value = value[maxCharacterString:]
+	}
+	chunks = append(chunks, value)
+	return dnsmessage.Resource{
+		Header: dnsmessage.ResourceHeader{
+			Name:  dnsmessage.MustNewName(name),
+			Type:  dnsmessage.TypeTXT,
+			Class: dnsmessage.ClassINET,
+			TTL:   RecordTTL,
+		},
+		Body: &dnsmessage.TXTResource{TXT: chunks},
+	}
+}
+
+// parseProperties parses the semicolon separated key=value pairs of a TXT record
+func parseProperties(value string) map[string]string {
+	props := make(map[string]string)
+	for _, pair := range strings.Split(value, ";") {
+		k, v, _ := strings.Cut(pair, "=")
+		props[k] = v
+	}
+	return props
+}
+
+func splitList(value string) []string {
+	if value == "" {
+		return nil
+	}
+	return strings.Split(value, ",")
+}
@@ -0,0 +1,194 @@
+package dht
+
+import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/pkg/errors"
+
+	"github.com/extrimian/ssi-sdk/did/resolution"
+)
+
+// Gateway publishes and retrieves the BEP44 mutable items of did:dht DIDs, keyed by their identity keys
+type Gateway interface {
+	// Put stores a verified item, replacing any item with a lower sequence number. An item with the same sequence
+	// number as the current one must have the same value.
+	Put(ctx context.Context, m BEP44Message) error
+	// Get retrieves the item of an identity key, or returns a notFound resolution error
+	Get(ctx context.Context, key ed25519.PublicKey) (*BEP44Message, error)
+}
+
+// MemoryGateway is a Gateway kept in memory, standing in for the DHT in tests and local setups
+type MemoryGateway struct {
+	mu    sync.RWMutex
+	items map[string]BEP44Message
+}
+
+var _ Gateway = (*MemoryGateway)(nil)
+
+// NewMemoryGateway creates an empty MemoryGateway
+func NewMemoryGateway() *MemoryGateway {
+	return &MemoryGateway{items: make(map[string]BEP44Message)}
+}
+
+func (g *MemoryGateway) Put(_ context.Context, m BEP44Message) error {
+	if err := m.Verify(); err != nil {
+		return errors.Wrap(err, "verifying item")
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if current, ok := g.items[string(m.Key)]; ok {
+		if current.Seq > m.Seq {
+			return fmt.Errorf("sequence number %d is lower than the current %d", m.Seq, current.Seq)
+		}
+		// BEP44 items with the same sequence number must have the same value
+		if current.Seq == m.Seq && !bytes.Equal(current.V, m.V) {
+			return fmt.Errorf("sequence number %d is already used by another value", m.Seq)
+		}
+	}
+	g.items[string(m.Key)] = m
+	return nil
+}
+
+func (g *MemoryGateway) Get(_ context.Context, key ed25519.PublicKey) (*BEP44Message, error) {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	m, ok := g.items[string(key)]
+	if !ok {
+		return nil, resolution.NewResolutionErrorf(resolution.NotFoundErrorCode, "no item for key: %s", zBase32Encode(key))
+	}
+	return &m, nil
+}
+
+// PkarrGateway is a Gateway backed by a Pkarr relay, which publishes to and resolves from the DHT over HTTP
+// https://github.com/Nuhvi/pkarr/blob/main/design/relays.md
+type PkarrGateway struct {
+	client  *http.Client
+	baseURL string
+}
+
+var _ Gateway = (*PkarrGateway)(nil)
+
+// NewPkarrGateway creates a client of the Pkarr relay at the base URL, using the given client or
+// http.DefaultClient if nil
+func NewPkarrGateway(client *http.Client, baseURL string) (*PkarrGateway, error) {
+	if baseURL == "" {
+		return nil, errors.New("relay url is required")
+	}
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return &PkarrGateway{client: client, baseURL: strings.TrimSuffix(baseURL, "/")}, nil
+}
+
+func (g *PkarrGateway) Put(ctx context.Context, m BEP44Message) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, g.itemURL(m.Key), bytes.NewReader(m.MarshalRelay()))
+	if err != nil {
+		return errors.Wrap(err, "constructing put request")
+	}
+	req.Header.Set("Content-Type", "application/octet-stream")
+	resp, err := g.client.Do(req)
+	if err != nil {
+		return errors.Wrap(err, "putting item")
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, MaxValueSize))
+		return fmt.Errorf("putting item: unexpected status code %d: %s", resp.StatusCode, body)
+	}
+	return nil
+}
+
+func (g *PkarrGateway) Get(ctx context.Context, key ed25519.PublicKey) (*BEP44Message, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, g.itemURL(key), nil)
+	if err != nil {
+		return nil, errors.Wrap(err, "constructing get request")
+	}
+	resp, err := g.client.Do(req)
+	if err != nil {
+		return nil, errors.Wrap(err, "getting item")
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		return nil, resolution.NewResolutionErrorf(resolution.NotFoundErrorCode, "no item for key: %s", zBase32Encode(key))
+	case resp.StatusCode < 200 || resp.StatusCode >= 300:
+		return nil, fmt.Errorf("getting item: unexpected status code %d", resp.StatusCode)
+	}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, ed25519.SignatureSize+8+MaxValueSize+1))
+	if err != nil {
+		return nil, errors.Wrap(err, "reading item")
+	}
+	return UnmarshalRelay(key, body)
+}
+
+func (g *PkarrGateway) itemURL(key ed25519.PublicKey) string {
+	return g.baseURL + "/" + zBase32Encode(key)
+}
+
+// RelayHandler is an http.Handler serving the Pkarr relay API on top of a Gateway, such as a MemoryGateway for
+// local testing. Items are addressed by the z-base-32 encoded identity key, e.g. PUT /{key} and GET /{key}.
+type RelayHandler struct {
+	gateway Gateway
+}
+
+var _ http.Handler = (*RelayHandler)(nil)
+
+// NewRelayHandler creates a RelayHandler for the gateway
+func NewRelayHandler(gateway Gateway) (*RelayHandler, error) {
+	if gateway == nil {
+		return nil, errors.New("gateway is required")
+	}
+	return &RelayHandler{gateway: gateway}, nil
+}
+
+func (h *RelayHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	key, err := zBase32Decode(strings.TrimPrefix(r.URL.Path, "/"))
+	if err != nil || len(key) != ed25519.PublicKeySize {
+		http.Error(w, "invalid key", http.StatusBadRequest)
+		return
+	}
+	switch r.Method {
+	case http.MethodPut:
+		body, err := io.ReadAll(io.LimitReader(r.Body, ed25519.SignatureSize+8+MaxValueSize+1))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		m, err := UnmarshalRelay(key, body)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if err = h.gateway.Put(r.Context(), *m); err != nil {
+			http.Error(w, err.Error(), http.StatusConflict)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	case http.MethodGet:
+		m, err := h.gateway.Get(r.Context(), key)
+		if resolution.IsNotFound(err) {
+			http.NotFound(w, r)
+			return
+		}
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/octet-stream")
+		_, _ = w.Write(m.MarshalRelay())
+	default:
+		w.Header().Set("Allow", "GET, PUT")
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+	}
+}
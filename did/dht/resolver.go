@@ -0,0 +1,107 @@
+package dht
+
+import (
+	"context"
+	"crypto/ed25519"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/extrimian/ssi-sdk/did"
+	"github.com/extrimian/ssi-sdk/did/resolution"
+	"github.com/extrimian/ssi-sdk/util"
+)
+
+// Publish signs the DID Document and types with the identity key and puts them to the gateway. The sequence number
+// is the current time, so later publications replace earlier ones.
+// https://did-dht.com/#create
+func Publish(ctx context.Context, gateway Gateway, identityKey ed25519.PrivateKey, doc did.Document, types ...TypeIndex) error {
+	id, err := FromIdentityKey(identityKey.Public().(ed25519.PublicKey))
+	if err != nil {
+		return err
+	}
+	packet, err := id.ToDNSPacket(doc, types)
+	if err != nil {
+		return errors.Wrap(err, "encoding dns packet")
+	}
+	m, err := NewBEP44Message(identityKey, time.Now().Unix(), packet)
+	if err != nil {
+		return err
+	}
+	if err = gateway.Put(ctx, *m); err != nil {
+		return errors.Wrapf(err, "publishing %s", id)
+	}
+	return nil
+}
+
+// Resolver resolves did:dht DIDs by retrieving their signed DNS packets from a Gateway
+type Resolver struct {
+	gateway Gateway
+}
+
+var _ resolution.Resolver = (*Resolver)(nil)
+
+// NewResolver creates a Resolver retrieving DNS packets from the gateway
+func NewResolver(gateway Gateway) (*Resolver, error) {
+	if gateway == nil {
+		return nil, errors.New("gateway is required")
+	}
+	return &Resolver{gateway: gateway}, nil
+}
+
+func (Resolver) Methods() []did.Method {
+	return []did.Method{did.DHTMethod}
+}
+
+// Resolve retrieves the item of the DID's identity key, verifies its signature and decodes the DID Document from
+// its DNS packet. The DHT keeps only the latest version of a DID Document.
+// https://did-dht.com/#read
+func (r Resolver) Resolve(ctx context.Context, id string, opts ...resolution.Option) (*resolution.Result, error) {
+	start := time.Now()
+	if !strings.HasPrefix(id, Prefix+":") {
+		return nil, resolution.NewResolutionErrorf(resolution.MethodNotSupportedErrorCode, "not a did:dht DID: %s", id)
+	}
+	options, err := resolution.ParseOptions(opts...)
+	if err != nil {
+		return nil, err
+	}
+	if err = options.RejectVersioned(did.DHTMethod); err != nil {
+		return nil, err
+	}
+	didDHT := DIDDHT(id)
+	identityKey, err := didDHT.IdentityKey()
+	if err != nil {
+		return nil, resolution.NewResolutionError(resolution.InvalidDIDErrorCode, err)
+	}
+	if r.gateway == nil {
+		return nil, errors.New("resolver has no gateway")
+	}
+	m, err := r.gateway.Get(ctx, identityKey)
+	if err != nil {
+		return nil, err
+	}
+	if !m.Key.Equal(identityKey) {
+		return nil, fmt.Errorf("item is not for %s", id)
+	}
+	if err = m.Verify(); err != nil {
+		return nil, errors.Wrapf(err, "verifying item of %s", id)
+	}
+	packet, err := m.DNSPacket()
+	if err != nil {
+		return nil, err
+	}
+	doc, _, err := didDHT.FromDNSPacket(packet)
+	if err != nil {
+		return nil, errors.Wrapf(err, "decoding dns packet of %s", id)
+	}
+	metadata := resolution.DocumentMetadata{
+		Updated: util.AsRFC3339Timestamp(time.Unix(m.Seq, 0)),
+	}
+	result := resolution.NewResult(*doc, &metadata, start)
+	if err = resolution.ValidateResult(result); err != nil {
+		return nil, err
+	}
+	return options.Apply(result), nil
+}
@@ -0,0 +1,134 @@
+package dht
+
+import (
+	"context"
+	"crypto/ed25519"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/extrimian/ssi-sdk/crypto"
+	"github.com/extrimian/ssi-sdk/did"
+	"github.com/extrimian/ssi-sdk/did/resolution"
+)
+
+func TestResolver(t *testing.T) {
+	gateway := NewMemoryGateway()
+	dhtResolver, err := NewResolver(gateway)
+	require.NoError(t, err)
+	resolver, err := resolution.NewResolver(dhtResolver)
+	require.NoError(t, err)
+
+	privKey, doc, err := GenerateDIDDHT(CreateDIDDHTOpts{
+		Services: []did.Service{{ID: "dwn", Type: "DecentralizedWebNode", ServiceEndpoint: "https://example.com/dwn"}},
+	})
+	require.NoError(t, err)
+
+	t.Run("Not Published", func(tt *testing.T) {
+		_, err := resolver.Resolve(context.Background(), doc.ID)
+		assert.True(tt, resolution.IsNotFound(err))
+	})
+
+	t.Run("Publish And Resolve", func(tt *testing.T) {
+		require.NoError(tt, Publish(context.Background(), gateway, privKey, *doc, SoftwarePackage))
+		result, err := resolver.Resolve(context.Background(), doc.ID)
+		require.NoError(tt, err)
+		assert.Equal(tt, doc.ID, result.ID)
+		assert.Equal(tt, doc.ID+"#dwn", result.Services[0].ID)
+		assert.NotEmpty(tt, result.DocumentMetadata.Updated)
+	})
+
+	t.Run("Only The Identity Key Can Publish", func(tt *testing.T) {
+		_, otherKey, err := crypto.GenerateEd25519Key()
+		require.NoError(tt, err)
+		err = Publish(context.Background(), gateway, otherKey, *doc)
+		assert.ErrorContains(tt, err, "does not match")
+	})
+
+	t.Run("Forged Item", func(tt *testing.T) {
+		m, err := gateway.Get(context.Background(), privKey.Public().(ed25519.PublicKey))
+		require.NoError(tt, err)
+		forged := *m
+		forged.Seq++
+		assert.Error(tt, gateway.Put(context.Background(), forged))
+	})
+
+	t.Run("Older Items Are Rejected", func(tt *testing.T) {
+		id := DIDDHT(doc.ID)
+		packet, err := id.ToDNSPacket(*doc, nil)
+		require.NoError(tt, err)
+		m, err := NewBEP44Message(privKey, time.Now().Add(-time.Hour).Unix(), packet)
+		require.NoError(tt, err)
+		assert.ErrorContains(tt, gateway.Put(context.Background(), *m), "lower than the current")
+	})
+
+	t.Run("Same Sequence Number With Another Value", func(tt *testing.T) {
+		m, err := gateway.Get(context.Background(), privKey.Public().(ed25519.PublicKey))
+		require.NoError(tt, err)
+		assert.NoError(tt, gateway.Put(context.Background(), *m))
+
+		changed := *doc
+		changed.Services = nil
+		packet, err := DIDDHT(doc.ID).ToDNSPacket(changed, nil)
+		require.NoError(tt, err)
+		conflicting, err := NewBEP44Message(privKey, m.Seq, packet)
+		require.NoError(tt, err)
+		assert.ErrorContains(tt, gateway.Put(context.Background(), *conflicting), "already used by another value")
+	})
+
+	t.Run("Versions Are Not Supported", func(tt *testing.T) {
+		_, err := resolver.Resolve(context.Background(), doc.ID, resolution.WithVersionID("1"))
+		assert.Error(tt, err)
+	})
+
+	t.Run("Invalid DID", func(tt *testing.T) {
+		_, err := dhtResolver.Resolve(context.Background(), "did:dht:abc")
+		assert.True(tt, resolution.IsInvalidDID(err))
+	})
+}
+
+func TestPkarrGateway(t *testing.T) {
+	handler, err := NewRelayHandler(NewMemoryGateway())
+	require.NoError(t, err)
+	server := httptest.NewServer(handler)
+	defer server.Close()
+
+	gateway, err := NewPkarrGateway(server.Client(), server.URL)
+	require.NoError(t, err)
+	dhtResolver, err := NewResolver(gateway)
+	require.NoError(t, err)
+
+	privKey, doc, err := GenerateDIDDHT(CreateDIDDHTOpts{AlsoKnownAs: "did:example:alias"})
+	require.NoError(t, err)
+
+	t.Run("Not Found", func(tt *testing.T) {
+		_, err := dhtResolver.Resolve(context.Background(), doc.ID)
+		assert.True(tt, resolution.IsNotFound(err))
+	})
+
+	t.Run("Publish And Resolve Through The Relay", func(tt *testing.T) {
+		require.NoError(tt, Publish(context.Background(), gateway, privKey, *doc))
+		result, err := dhtResolver.Resolve(context.Background(), doc.ID)
+		require.NoError(tt, err)
+		assert.Equal(tt, "did:example:alias", result.AlsoKnownAs)
+	})
+
+	t.Run("Relay Rejects Invalid Signatures", func(tt *testing.T) {
+		m, err := gateway.Get(context.Background(), privKey.Public().(ed25519.PublicKey))
+		require.NoError(tt, err)
+		forged := *m
+		forged.Sig = make([]byte, ed25519.SignatureSize)
+		assert.ErrorContains(tt, gateway.Put(context.Background(), forged), "400")
+	})
+
+	t.Run("Relay Rejects Invalid Keys", func(tt *testing.T) {
+		resp, err := server.Client().Get(server.URL + "/not-a-key")
+		require.NoError(tt, err)
+		defer resp.Body.Close()
+		assert.Equal(tt, http.StatusBadRequest, resp.StatusCode)
+	})
+}
@@ -9,6 +9,8 @@ const (
 	PeerMethod    Method = "peer"
 	PKHMethod     Method = "pkh"
 	WebMethod     Method = "web"
+	WebVHMethod   Method = "webvh"
+	DHTMethod     Method = "dht"
 	IONMethod     Method = "ion"
 	JWKMethod     Method = "jwk"
 	QuarkidMethod Method = "quarkid"
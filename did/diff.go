@@ -0,0 +1,465 @@
+package did
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/goccy/go-json"
+	"github.com/pkg/errors"
+)
+
+// Names of the properties of a DID Document tracked by PropertyChange
+const (
+	ContextProperty     = "@context"
+	ControllerProperty  = "controller"
+	AlsoKnownAsProperty = "alsoKnownAs"
+)
+
+// ChangeSet is the set of changes between two versions of a DID Document, as computed by Diff. The ids of the
+// document's own verification methods and services are relative, e.g. `#key-1`, so that versions of a document
+// with different ids, such as the long and short form of a Sidetree DID, are compared by fragment.
+type ChangeSet struct {
+	// Properties are the changes of the @context, controller and alsoKnownAs properties
+	Properties                 []PropertyChange
+	VerificationMethodsAdded   []VerificationMethod
+	VerificationMethodsRemoved []VerificationMethod
+	VerificationMethodsChanged []VerificationMethodChange
+	RelationshipsAdded         []RelationshipChange
+	RelationshipsRemoved       []RelationshipChange
+	ServicesAdded              []Service
+	ServicesRemoved            []Service
+	ServicesChanged            []ServiceChange
+}
+
+// PropertyChange is the change of a property of a DID Document, whose old or new value is nil when it is added or
+// removed
+type PropertyChange struct {
+	Name string
+	Old  any
+	New  any
+}
+
+// VerificationMethodChange is a verification method whose type, controller or key changed
+type VerificationMethodChange struct {
+	Old VerificationMethod
+	New VerificationMethod
+}
+
+// ServiceChange is a service whose type or endpoint changed
+type ServiceChange struct {
+	Old Service
+	New Service
+}
+
+// RelationshipChange is the addition or removal of a verification method, by id, to a verification relationship
+type RelationshipChange struct {
+	Relationship Relationship
+	ID           string
+}
+
+// IsEmpty returns true if there are no changes
+func (c ChangeSet) IsEmpty() bool {
+	return len(c.Properties) == 0 &&
+		len(c.VerificationMethodsAdded) == 0 &&
+		len(c.VerificationMethodsRemoved) == 0 &&
+		len(c.VerificationMethodsChanged) == 0 &&
+		len(c.RelationshipsAdded) == 0 &&
+		len(c.RelationshipsRemoved) == 0 &&
+		len(c.ServicesAdded) == 0 &&
+		len(c.ServicesRemoved) == 0 &&
+		len(c.ServicesChanged) == 0
+}
+
+// Diff computes the changes from one version of a DID Document to another. Verification methods and services are
+// matched by id. Verification methods embedded in verification relationships are compared like the others.
+// Changes are listed in the order they appear in the documents.
+func Diff(from, to Document) ChangeSet {
+	var changes ChangeSet
+	for _, property := range []struct {
+		name     string
+		from, to any
+	}{
+		{name: ContextProperty, from: from.Context, to: to.Context},
+		{name: ControllerProperty, from: from.Controller, to: to.Controller},
+		{name: AlsoKnownAsProperty, from: from.AlsoKnownAs, to: to.AlsoKnownAs},
+	} {
+		if !jsonEqual(property.from, property.to) {
+			changes.Properties = append(changes.Properties, PropertyChange{
+				Name: property.name,
+				Old:  emptyAsNil(property.from),
+				New:  emptyAsNil(property.to),
+			})
+		}
+	}
+
+	fromVMs, toVMs := localVerificationMethods(from), localVerificationMethods(to)
+	for _, vm := range fromVMs {
+		toVM, ok := findVerificationMethod(toVMs, vm.ID)
+		switch {
+		case !ok:
+			changes.VerificationMethodsRemoved = append(changes.VerificationMethodsRemoved, vm)
+		case !jsonEqual(vm, *toVM):
+			changes.VerificationMethodsChanged = append(changes.VerificationMethodsChanged,
+				VerificationMethodChange{Old: vm, New: *toVM})
+		}
+	}
+	for _, vm := range toVMs {
+		if _, ok := findVerificationMethod(fromVMs, vm.ID); !ok {
+			changes.VerificationMethodsAdded = append(changes.VerificationMethodsAdded, vm)
+		}
+	}
+
+	for _, relationship := range Relationships() {
+		fromIDs, toIDs := localRelationshipIDs(from, relationship), localRelationshipIDs(to, relationship)
+		for _, id := range fromIDs {
+			if !contains(toIDs, id) {
+				changes.RelationshipsRemoved = append(changes.RelationshipsRemoved,
+					RelationshipChange{Relationship: relationship, ID: id})
+			}
+		}
+		for _, id := range toIDs {
+			if !contains(fromIDs, id) {
+				changes.RelationshipsAdded = append(changes.RelationshipsAdded,
+					RelationshipChange{Relationship: relationship, ID: id})
+			}
+		}
+	}
+
+	fromServices, toServices := localServices(from), localServices(to)
+	for _, service := range fromServices {
+		toService, ok := findService(toServices, service.ID)
+		switch {
+		case !ok:
+			changes.ServicesRemoved = append(changes.ServicesRemoved, service)
+		case !jsonEqual(service, *toService):
+			changes.ServicesChanged = append(changes.ServicesChanged, ServiceChange{Old: service, New: *toService})
+		}
+	}
+	for _, service := range toServices {
+		if _, ok := findService(fromServices, service.ID); !ok {
+			changes.ServicesAdded = append(changes.ServicesAdded, service)
+		}
+	}
+	return changes
+}
+
+// Apply applies the changes to a copy of the document. Removing a verification method removes it from all
+// verification relationships, and added verification methods are added to the document's verificationMethod.
+// Changes that do not apply to the document, such as removing a service it does not have, are an error.
+func (c ChangeSet) Apply(doc Document) (*Document, error) {
+	patched, err := copyDocument(doc)
+	if err != nil {
+		return nil, err
+	}
+	for _, property := range c.Properties {
+		if err = patched.setProperty(property); err != nil {
+			return nil, err
+		}
+	}
+
+	removed := make(map[string]bool, len(c.VerificationMethodsRemoved))
+	for _, vm := range c.VerificationMethodsRemoved {
+		if !patched.removeVerificationMethod(vm.ID) {
+			return nil, fmt.Errorf("cannot remove verification method %s: not found", vm.ID)
+		}
+		removed[patched.localID(vm.ID)] = true
+	}
+	for _, change := range c.RelationshipsRemoved {
+		// references to removed verification methods are removed with them
+		if removed[patched.localID(change.ID)] {
+			continue
+		}
+		if !patched.removeFromRelationship(change.Relationship, change.ID) {
+			return nil, fmt.Errorf("cannot remove %s from %s: not found", change.ID, change.Relationship)
+		}
+	}
+	for _, change := range c.VerificationMethodsChanged {
+		if !patched.replaceVerificationMethod(change.New) {
+			return nil, fmt.Errorf("cannot change verification method %s: not found", change.New.ID)
+		}
+	}
+	for _, vm := range c.VerificationMethodsAdded {
+		if _, ok := findVerificationMethod(localVerificationMethods(*patched), patched.localID(vm.ID)); ok {
+			return nil, fmt.Errorf("cannot add verification method %s: already exists", vm.ID)
+		}
+		patched.VerificationMethod = append(patched.VerificationMethod, vm)
+	}
+	for _, change := range c.RelationshipsAdded {
+		if contains(localRelationshipIDs(*patched, change.Relationship), patched.localID(change.ID)) {
+			return nil, fmt.Errorf("cannot add %s to %s: already exists", change.ID, change.Relationship)
+		}
+		relationship := patched.relationship(change.Relationship)
+		if relationship == nil {
+			return nil, fmt.Errorf("unknown verification relationship: %s", change.Relationship)
+		}
+		*relationship = append(*relationship, change.ID)
+	}
+
+	for _, service := range c.ServicesRemoved {
+		i, ok := patched.serviceIndex(service.ID)
+		if !ok {
+			return nil, fmt.Errorf("cannot remove service %s: not found", service.ID)
+		}
+		patched.Services = append(patched.Services[:i], patched.Services[i+1:]...)
+	}
+	for _, change := range c.ServicesChanged {
+		i, ok := patched.serviceIndex(change.New.ID)
+		if !ok {
+			return nil, fmt.Errorf("cannot change service %s: not found", change.New.ID)
+		}
+		patched.Services[i] = change.New
+	}
+	for _, service := range c.ServicesAdded {
+		if _, ok := patched.serviceIndex(service.ID); ok {
+			return nil, fmt.Errorf("cannot add service %s: already exists", service.ID)
+		}
+		patched.Services = append(patched.Services, service)
+	}
+	return patched, nil
+}
+
+// JSONPatch converts the changes into a JSON Patch that applies them to the document
+func (c ChangeSet) JSONPatch(doc Document) (JSONPatch, error) {
+	patched, err := c.Apply(doc)
+	if err != nil {
+		return nil, err
+	}
+	from, err := toJSONValue(doc)
+	if err != nil {
+		return nil, err
+	}
+	to, err := toJSONValue(patched)
+	if err != nil {
+		return nil, err
+	}
+	var patch JSONPatch
+	diffJSON("", from, to, &patch)
+	return patch, nil
+}
+
+func (d *Document) setProperty(property PropertyChange) error {
+	switch property.Name {
+	case ContextProperty:
+		d.Context = property.New
+	case ControllerProperty, AlsoKnownAsProperty:
+		value, ok := property.New.(string)
+		if !ok && property.New != nil {
+			return fmt.Errorf("%s must be a string, not %T", property.Name, property.New)
+		}
+		if property.Name == ControllerProperty {
+			d.Controller = value
+		} else {
+			d.AlsoKnownAs = value
+		}
+	default:
+		return fmt.Errorf("unsupported property: %s", property.Name)
+	}
+	return nil
+}
+
+// removeVerificationMethod removes the verification method with the id, wherever it is declared, and all references
+// to it
+func (d *Document) removeVerificationMethod(id string) bool {
+	id = d.localID(id)
+	found := false
+	for i, vm := range d.VerificationMethod {
+		if d.localID(vm.ID) == id {
+			d.VerificationMethod = append(d.VerificationMethod[:i], d.VerificationMethod[i+1:]...)
+			found = true
+			break
+		}
+	}
+	for _, relationship := range Relationships() {
+		if d.removeFromRelationship(relationship, id) {
+			found = true
+		}
+	}
+	return found
+}
+
+// removeFromRelationship removes references to, and embedded verification methods with, the id from a relationship
+func (d *Document) removeFromRelationship(relationship Relationship, id string) bool {
+	sets := d.relationship(relationship)
+	if sets == nil {
+		return false
+	}
+	id = d.localID(id)
+	found := false
+	kept := make(VerificationRelationship, 0, len(*sets))
+	for _, set := range *sets {
+		if vm, err := embeddedVerificationMethod(set); err == nil {
+			if d.localID(vm.ID) == id {
+				found = true
+				continue
+			}
+			kept = append(kept, set)
+			continue
+		}
+		references := VerificationRelationship{set}.References()
+		var keptReferences []string
+		for _, reference := range references {
+			if d.localID(reference) == id {
+				found = true
+				continue
+			}
+			keptReferences = append(keptReferences, reference)
+		}
+		switch {
+		case len(keptReferences) == len(references):
+			kept = append(kept, set)
+		case len(keptReferences) == 0:
+		case len(keptReferences) == 1:
+			kept = append(kept, keptReferences[0])
+		default:
+			kept = append(kept, keptReferences)
+		}
+	}
+	if len(kept) == 0 {
+		kept = nil
+	}
+	*sets = kept
+	return found
+}
+
+// replaceVerificationMethod replaces the verification method with the id of vm, wherever it is declared
+func (d *Document) replaceVerificationMethod(vm VerificationMethod) bool {
+	id := d.localID(vm.ID)
+	for i := range d.VerificationMethod {
+		if d.localID(d.VerificationMethod[i].ID) == id {
+			d.VerificationMethod[i] = vm
+			return true
+		}
+	}
+	for _, relationship := range Relationships() {
+		sets := d.relationship(relationship)
+		for i, set := range *sets {
+			if embedded, err := embeddedVerificationMethod(set); err == nil && d.localID(embedded.ID) == id {
+				(*sets)[i] = vm
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (d *Document) serviceIndex(id string) (int, bool) {
+	id = d.localID(id)
+	for i, service := range d.Services {
+		if d.localID(service.ID) == id {
+			return i, true
+		}
+	}
+	return 0, false
+}
+
+// relationship returns a pointer to a relationship of the document, so that it may be modified
+func (d *Document) relationship(relationship Relationship) *VerificationRelationship {
+	switch relationship {
+	case Authentication:
+		return &d.Authentication
+	case AssertionMethod:
+		return &d.AssertionMethod
+	case KeyAgreement:
+		return &d.KeyAgreement
+	case CapabilityInvocation:
+		return &d.CapabilityInvocation
+	case CapabilityDelegation:
+		return &d.CapabilityDelegation
+	default:
+		return nil
+	}
+}
+
+// localID returns the id relative to the document when it identifies a resource of the document, e.g. `#key-1`
+func (d *Document) localID(id string) string {
+	if d.ID != "" && strings.HasPrefix(id, d.ID+"#") {
+		return strings.TrimPrefix(id, d.ID)
+	}
+	return id
+}
+
+// localVerificationMethods returns the verification methods of the document, including embedded ones, with relative
+// ids
+func localVerificationMethods(doc Document) []VerificationMethod {
+	var vms []VerificationMethod
+	add := func(vm VerificationMethod) {
+		vm.ID = doc.localID(vm.ID)
+		if _, ok := findVerificationMethod(vms, vm.ID); !ok {
+			vms = append(vms, vm)
+		}
+	}
+	for _, vm := range doc.VerificationMethod {
+		add(vm)
+	}
+	for _, relationship := range Relationships() {
+		for _, vm := range doc.Relationship(relationship).Embedded() {
+			add(vm)
+		}
+	}
+	return vms
+}
+
+func localRelationshipIDs(doc Document, relationship Relationship) []string {
+	var ids []string
+	for _, id := range doc.VerificationMethodIDs(relationship) {
+		ids = append(ids, doc.localID(id))
+	}
+	return ids
+}
+
+func localServices(doc Document) []Service {
+	services := make([]Service, 0, len(doc.Services))
+	for _, service := range doc.Services {
+		service.ID = doc.localID(service.ID)
+		services = append(services, service)
+	}
+	return services
+}
+
+func findVerificationMethod(vms []VerificationMethod, id string) (*VerificationMethod, bool) {
+	for i := range vms {
+		if vms[i].ID == id {
+			return &vms[i], true
+		}
+	}
+	return nil, false
+}
+
+func findService(services []Service, id string) (*Service, bool) {
+	for i := range services {
+		if services[i].ID == id {
+			return &services[i], true
+		}
+	}
+	return nil, false
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// emptyAsNil returns nil for empty strings, so that removed properties have a nil value
+func emptyAsNil(value any) any {
+	if s, ok := value.(string); ok && s == "" {
+		return nil
+	}
+	return value
+}
+
+// copyDocument deep copies a document through its JSON representation
+func copyDocument(doc Document) (*Document, error) {
+	docBytes, err := json.Marshal(doc)
+	if err != nil {
+		return nil, errors.Wrap(err, "marshalling document")
+	}
+	var copied Document
+	if err = json.Unmarshal(docBytes, &copied); err != nil {
+		return nil, errors.Wrap(err, "unmarshalling document")
+	}
+	return &copied, nil
+}
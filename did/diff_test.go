@@ -0,0 +1,160 @@
+package did
+
+import (
+	"testing"
+
+	"github.com/goccy/go-json"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/extrimian/ssi-sdk/crypto/jwx"
+)
+
+func TestDiff(t *testing.T) {
+	t.Run("no changes", func(tt *testing.T) {
+		changes := Diff(validDocument(), validDocument())
+		assert.True(tt, changes.IsEmpty())
+
+		// fully qualified and relative ids of the same resources are equal
+		doc := validDocument()
+		doc.VerificationMethod[0].ID = "did:example:123#key-1"
+		doc.Services[0].ID = "did:example:123#linked"
+		assert.True(tt, Diff(validDocument(), doc).IsEmpty())
+	})
+
+	t.Run("changes", func(tt *testing.T) {
+		from := validDocument()
+		to := updatedDocument()
+
+		changes := Diff(from, to)
+		assert.Equal(tt, []PropertyChange{{Name: ControllerProperty, New: "did:example:456"}}, changes.Properties)
+		require.Len(tt, changes.VerificationMethodsAdded, 1)
+		assert.Equal(tt, "#key-4", changes.VerificationMethodsAdded[0].ID)
+		require.Len(tt, changes.VerificationMethodsRemoved, 1)
+		assert.Equal(tt, "#key-1", changes.VerificationMethodsRemoved[0].ID)
+		require.Len(tt, changes.VerificationMethodsChanged, 1)
+		assert.Equal(tt, "#key-2", changes.VerificationMethodsChanged[0].Old.ID)
+		assert.Equal(tt, "P-256", changes.VerificationMethodsChanged[0].New.PublicKeyJWK.CRV)
+		assert.Equal(tt, []RelationshipChange{
+			{Relationship: Authentication, ID: "#key-1"},
+			{Relationship: AssertionMethod, ID: "#key-1"},
+		}, changes.RelationshipsRemoved)
+		assert.Equal(tt, []RelationshipChange{
+			{Relationship: Authentication, ID: "#key-4"},
+			{Relationship: CapabilityInvocation, ID: "#key-2"},
+		}, changes.RelationshipsAdded)
+		assert.Equal(tt, []Service{from.Services[0]}, changes.ServicesRemoved)
+		assert.Equal(tt, []ServiceChange{{Old: from.Services[1], New: to.Services[0]}}, changes.ServicesChanged)
+		assert.Equal(tt, []Service{to.Services[1]}, changes.ServicesAdded)
+	})
+}
+
+func TestChangeSetApply(t *testing.T) {
+	t.Run("applying a diff results in the new document", func(tt *testing.T) {
+		from, to := validDocument(), updatedDocument()
+		changes := Diff(from, to)
+		patched, err := changes.Apply(from)
+		require.NoError(tt, err)
+		assert.True(tt, Diff(*patched, to).IsEmpty())
+
+		// the document is not modified
+		assert.True(tt, Diff(from, validDocument()).IsEmpty())
+	})
+
+	t.Run("removing a verification method removes it from all relationships", func(tt *testing.T) {
+		doc := validDocument()
+		patched, err := ChangeSet{VerificationMethodsRemoved: []VerificationMethod{{ID: "#key-3"}}}.Apply(doc)
+		require.NoError(tt, err)
+		assert.Empty(tt, patched.KeyAgreement)
+		assert.Equal(tt, VerificationRelationship{"#key-1"}, patched.AssertionMethod)
+	})
+
+	t.Run("changes that do not apply", func(tt *testing.T) {
+		doc := validDocument()
+		for name, test := range map[string]struct {
+			changes ChangeSet
+			err     string
+		}{
+			"unknown property": {
+				changes: ChangeSet{Properties: []PropertyChange{{Name: "id", New: "did:example:456"}}},
+				err:     "unsupported property: id",
+			},
+			"missing verification method": {
+				changes: ChangeSet{VerificationMethodsRemoved: []VerificationMethod{{ID: "#key-9"}}},
+				err:     "cannot remove verification method #key-9: not found",
+			},
+			"existing verification method": {
+				changes: ChangeSet{VerificationMethodsAdded: []VerificationMethod{{ID: "did:example:123#key-1"}}},
+				err:     "cannot add verification method did:example:123#key-1: already exists",
+			},
+			"existing relationship entry": {
+				changes: ChangeSet{RelationshipsAdded: []RelationshipChange{{Relationship: Authentication, ID: "#key-2"}}},
+				err:     "cannot add #key-2 to authentication: already exists",
+			},
+			"missing relationship entry": {
+				changes: ChangeSet{RelationshipsRemoved: []RelationshipChange{{Relationship: KeyAgreement, ID: "#key-1"}}},
+				err:     "cannot remove #key-1 from keyAgreement: not found",
+			},
+			"missing service": {
+				changes: ChangeSet{ServicesChanged: []ServiceChange{{New: Service{ID: "#hub"}}}},
+				err:     "cannot change service #hub: not found",
+			},
+			"existing service": {
+				changes: ChangeSet{ServicesAdded: []Service{{ID: "#linked"}}},
+				err:     "cannot add service #linked: already exists",
+			},
+		} {
+			_, err := test.changes.Apply(doc)
+			assert.ErrorContains(tt, err, test.err, name)
+		}
+	})
+}
+
+func TestChangeSetJSONPatch(t *testing.T) {
+	from, to := validDocument(), updatedDocument()
+	patch, err := Diff(from, to).JSONPatch(from)
+	require.NoError(t, err)
+	assert.NotEmpty(t, patch)
+
+	patched, err := patch.Apply(from)
+	require.NoError(t, err)
+	assert.True(t, Diff(*patched, to).IsEmpty())
+
+	// the patch round trips as JSON
+	patchBytes, err := json.Marshal(patch)
+	require.NoError(t, err)
+	var unmarshalled JSONPatch
+	require.NoError(t, json.Unmarshal(patchBytes, &unmarshalled))
+	patched, err = unmarshalled.Apply(from)
+	require.NoError(t, err)
+	assert.True(t, Diff(*patched, to).IsEmpty())
+}
+
+// updatedDocument is validDocument with a controller, #key-1 and #linked removed, #key-2 and #messaging changed,
+// and #key-4 and #hub added
+func updatedDocument() Document {
+	doc := validDocument()
+	doc.Controller = "did:example:456"
+	doc.VerificationMethod = []VerificationMethod{
+		{
+			ID:           "did:example:123#key-2",
+			Type:         "JsonWebKey2020",
+			Controller:   "did:example:123",
+			PublicKeyJWK: &jwx.PublicKeyJWK{KTY: "EC", CRV: "P-256", X: "igrFmi0whuihKnj9R3Om1SoMph72wUGeFaBbzG2vzns", Y: "efsX5b10x8yjyrj4ny3pGfLcY7Xby1KzgqOdqnsrJIM"},
+		},
+		{
+			ID:                 "#key-4",
+			Type:               "Ed25519VerificationKey2020",
+			Controller:         "did:example:123",
+			PublicKeyMultibase: "z6MkqRYqQiSgvZQdnBytw86Qbs2ZWUkGv22od935YF4s8M7V",
+		},
+	}
+	doc.Authentication = VerificationRelationship{"did:example:123#key-2", "did:example:other#key-1", "#key-4"}
+	doc.AssertionMethod = VerificationRelationship{"#key-3"}
+	doc.CapabilityInvocation = VerificationRelationship{"#key-2"}
+	doc.Services = []Service{
+		{ID: "#messaging", Type: "DIDCommMessaging", ServiceEndpoint: "https://example.com/didcomm"},
+		{ID: "#hub", Type: "IdentityHub", ServiceEndpoint: "https://hub.example.com"},
+	}
+	return doc
+}
@@ -3,10 +3,13 @@ package ion
 import (
 	"context"
 	"net/http"
+	"net/http/httptest"
 	"testing"
 
 	"github.com/extrimian/ssi-sdk/did"
+	"github.com/extrimian/ssi-sdk/did/resolution"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"gopkg.in/h2non/gock.v1"
 )
 
@@ -89,6 +92,37 @@ func TestResolver(t *testing.T) {
 		assert.Equal(tt, "did:ion:test", result.Document.ID)
 	})
 
+	t.Run("resolve a specific version of a DID", func(tt *testing.T) {
+		gock.New("https://test-ion-resolution.com").
+			Get("/identifiers/did:ion:test").
+			MatchParam("versionId", "2").
+			Reply(200).
+			BodyString(`{"didDocument": {"id": "did:ion:test"}, "didDocumentMetadata": {"versionId": "2"}}`)
+		defer gock.Off()
+
+		resolver, err := NewIONResolver(http.DefaultClient, "https://test-ion-resolution.com")
+		assert.NoError(tt, err)
+
+		result, err := resolver.Resolve(context.Background(), "did:ion:test", resolution.WithVersionID("2"))
+		assert.NoError(tt, err)
+		assert.Equal(tt, "2", result.DocumentMetadata.VersionID)
+	})
+
+	t.Run("resolve a deactivated DID", func(tt *testing.T) {
+		gock.New("https://test-ion-resolution.com").
+			Get("/identifiers/did:ion:test").
+			Reply(410).
+			BodyString(`{"didDocument": {"id": "did:ion:test"}, "didDocumentMetadata": {"method": {"published": true}}}`)
+		defer gock.Off()
+
+		resolver, err := NewIONResolver(http.DefaultClient, "https://test-ion-resolution.com")
+		assert.NoError(tt, err)
+
+		result, err := resolver.Resolve(context.Background(), "did:ion:test")
+		assert.NoError(tt, err)
+		assert.True(tt, result.DocumentMetadata.Deactivated)
+	})
+
 	t.Run("resolve a long form DID", func(tt *testing.T) {
 		tt.Run("bad long form DID", func(ttt *testing.T) {
 			gock.New("https://test-ion-resolution.com").
@@ -203,9 +237,9 @@ func TestRequests(t *testing.T) {
 		assert.NotEmpty(tt, ionDID.ID())
 		assert.Contains(tt, ionDID.ID(), "did:ion:")
 		assert.Len(tt, ionDID.Operations(), 1)
-		assert.NotEmpty(tt, ionDID.updatePrivateKey)
-		assert.NotEmpty(tt, ionDID.recoveryPrivateKey)
-		assert.NotEqual(tt, ionDID.updatePrivateKey, ionDID.recoveryPrivateKey)
+		assert.NotEmpty(tt, ionDID.GetUpdatePrivateKey())
+		assert.NotEmpty(tt, ionDID.GetRecoveryPrivateKey())
+		assert.NotEqual(tt, ionDID.GetUpdatePrivateKey(), ionDID.GetRecoveryPrivateKey())
 
 		// try to decode long form DID
 		decoded, initialState, err := DecodeLongFormDID(ionDID.LongForm())
@@ -276,7 +310,7 @@ func TestRequests(t *testing.T) {
 		assert.NotEmpty(tt, updateOp.SignedData)
 
 		// make sure keys are different and op is added
-		assert.NotEqual(tt, ionDID.updatePrivateKey, updatedDID.updatePrivateKey)
+		assert.NotEqual(tt, ionDID.GetUpdatePrivateKey(), updatedDID.GetUpdatePrivateKey())
 		assert.Len(tt, ionDID.Operations(), 1)
 		assert.Len(tt, updatedDID.Operations(), 2)
 	})
@@ -328,8 +362,8 @@ func TestRequests(t *testing.T) {
 		assert.NotEmpty(tt, recoverOp.SignedData)
 
 		// make sure keys are different and op is added
-		assert.NotEqual(tt, ionDID.updatePrivateKey, recoveredDID.updatePrivateKey)
-		assert.NotEqual(tt, ionDID.recoveryPrivateKey, recoveredDID.recoveryPrivateKey)
+		assert.NotEqual(tt, ionDID.GetUpdatePrivateKey(), recoveredDID.GetUpdatePrivateKey())
+		assert.NotEqual(tt, ionDID.GetRecoveryPrivateKey(), recoveredDID.GetRecoveryPrivateKey())
 		assert.Len(tt, ionDID.Operations(), 1)
 		assert.Len(tt, recoveredDID.Operations(), 2)
 	})
@@ -372,3 +406,42 @@ func TestRequests(t *testing.T) {
 		assert.Len(tt, deactivatedDID.Operations(), 2)
 	})
 }
+
+func TestNode(t *testing.T) {
+	node, err := NewNode()
+	require.NoError(t, err)
+	server := httptest.NewTLSServer(node)
+	defer server.Close()
+	resolver, err := NewIONResolver(server.Client(), server.URL)
+	require.NoError(t, err)
+
+	ionDID, createOp, err := NewIONDID(Document{Services: []did.Service{{ID: "serviceID", Type: "serviceType", ServiceEndpoint: "https://example.com"}}})
+	require.NoError(t, err)
+	_, err = resolver.Anchor(context.Background(), createOp)
+	assert.NoError(t, err)
+
+	updatedDID, updateOp, err := ionDID.Update(StateChange{ServiceIDsToRemove: []string{"serviceID"}})
+	require.NoError(t, err)
+	_, err = resolver.Anchor(context.Background(), updateOp)
+	assert.NoError(t, err)
+
+	recoveredDID, recoverOp, err := updatedDID.Recover(Document{Services: []did.Service{{ID: "recovered", Type: "serviceType", ServiceEndpoint: "https://example.com"}}})
+	require.NoError(t, err)
+	_, err = resolver.Anchor(context.Background(), recoverOp)
+	assert.NoError(t, err)
+
+	result, err := resolver.Resolve(context.Background(), ionDID.ID())
+	assert.NoError(t, err)
+	assert.True(t, result.DocumentMetadata.Method.Published)
+	assert.Len(t, result.Document.Services, 1)
+	assert.Equal(t, "#recovered", result.Document.Services[0].ID)
+
+	_, deactivateOp, err := recoveredDID.Deactivate()
+	require.NoError(t, err)
+	_, err = resolver.Anchor(context.Background(), deactivateOp)
+	assert.NoError(t, err)
+
+	result, err = resolver.Resolve(context.Background(), ionDID.ID())
+	assert.NoError(t, err)
+	assert.True(t, result.DocumentMetadata.Deactivated)
+}
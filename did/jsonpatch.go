@@ -0,0 +1,366 @@
+package did
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/goccy/go-json"
+	"github.com/pkg/errors"
+)
+
+// JSON Patch operations https://www.rfc-editor.org/rfc/rfc6902#section-4
+const (
+	JSONPatchAdd     = "add"
+	JSONPatchRemove  = "remove"
+	JSONPatchReplace = "replace"
+	JSONPatchMove    = "move"
+	JSONPatchCopy    = "copy"
+	JSONPatchTest    = "test"
+)
+
+// JSONPatch is a JSON Patch of a DID Document https://www.rfc-editor.org/rfc/rfc6902
+type JSONPatch []JSONPatchOperation
+
+// JSONPatchOperation is an operation of a JSON Patch, whose paths are JSON Pointers
+// https://www.rfc-editor.org/rfc/rfc6901
+type JSONPatchOperation struct {
+	Op    string `json:"op"`
+	Path  string `json:"path"`
+	From  string `json:"from,omitempty"`
+	Value any    `json:"value,omitempty"`
+}
+
+// Apply applies the operations of the patch in order to a copy of the document. The patch fails as a whole if any
+// operation fails, including a failed test operation.
+func (p JSONPatch) Apply(doc Document) (*Document, error) {
+	value, err := toJSONValue(doc)
+	if err != nil {
+		return nil, err
+	}
+	for i, op := range p {
+		if value, err = applyJSONPatchOperation(value, op); err != nil {
+			return nil, errors.Wrapf(err, "applying operation %d: %s %s", i, op.Op, op.Path)
+		}
+	}
+	valueBytes, err := json.Marshal(value)
+	if err != nil {
+		return nil, errors.Wrap(err, "marshalling patched document")
+	}
+	var patched Document
+	if err = json.Unmarshal(valueBytes, &patched); err != nil {
+		return nil, errors.Wrap(err, "unmarshalling patched document")
+	}
+	return &patched, nil
+}
+
+func applyJSONPatchOperation(root any, op JSONPatchOperation) (any, error) {
+	path, err := parseJSONPointer(op.Path)
+	if err != nil {
+		return nil, err
+	}
+	switch op.Op {
+	case JSONPatchAdd:
+		return addJSONValue(root, path, copyJSONValue(op.Value))
+	case JSONPatchRemove:
+		root, _, err = removeJSONValue(root, path)
+		return root, err
+	case JSONPatchReplace:
+		if _, err = getJSONValue(root, path); err != nil {
+			return nil, err
+		}
+		if root, _, err = removeJSONValue(root, path); err != nil {
+			return nil, err
+		}
+		return addJSONValue(root, path, copyJSONValue(op.Value))
+	case JSONPatchMove, JSONPatchCopy:
+		from, err := parseJSONPointer(op.From)
+		if err != nil {
+			return nil, errors.Wrap(err, "from")
+		}
+		var value any
+		if op.Op == JSONPatchMove {
+			if isJSONPointerPrefix(from, path) && len(from) < len(path) {
+				return nil, errors.New("cannot move a value into one of its children")
+			}
+			root, value, err = removeJSONValue(root, from)
+		} else {
+			value, err = getJSONValue(root, from)
+			value = copyJSONValue(value)
+		}
+		if err != nil {
+			return nil, err
+		}
+		return addJSONValue(root, path, value)
+	case JSONPatchTest:
+		value, err := getJSONValue(root, path)
+		if err != nil {
+			return nil, err
+		}
+		if !jsonEqual(value, op.Value) {
+			return nil, errors.New("test failed")
+		}
+		return root, nil
+	default:
+		return nil, fmt.Errorf("unsupported operation: %s", op.Op)
+	}
+}
+
+// parseJSONPointer parses a JSON Pointer into its unescaped reference tokens
+func parseJSONPointer(pointer string) ([]string, error) {
+	if pointer == "" {
+		return nil, nil
+	}
+	if !strings.HasPrefix(pointer, "/") {
+		return nil, fmt.Errorf("invalid JSON Pointer: %s", pointer)
+	}
+	tokens := strings.Split(pointer[1:], "/")
+	for i, token := range tokens {
+		tokens[i] = strings.ReplaceAll(strings.ReplaceAll(token, "~1", "/"), "~0", "~")
+	}
+	return tokens, nil
+}
+
+func isJSONPointerPrefix(prefix, path []string) bool {
+	if len(prefix) > len(path) {
+		return false
+	}
+	for i := range prefix {
+		if prefix[i] != path[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func getJSONValue(node any, path []string) (any, error) {
+	for _, token := range path {
+		switch n := node.(type) {
+		case map[string]any:
+			child, ok := n[token]
+			if !ok {
+				return nil, fmt.Errorf("member not found: %s", token)
+			}
+			node = child
+		case []any:
+			i, err := arrayIndex(token, len(n))
+			if err != nil {
+				return nil, err
+			}
+			node = n[i]
+		default:
+			return nil, fmt.Errorf("cannot reference %s in a %T", token, node)
+		}
+	}
+	return node, nil
+}
+
+// addJSONValue adds a value at the path, returning the updated node. Arrays are extended in place of the target
+// index, or at their end for the `-` token.
+func addJSONValue(node any, path []string, value any) (any, error) {
+	if len(path) == 0 {
+		return value, nil
+	}
+	token := path[0]
+	switch n := node.(type) {
+	case map[string]any:
+		if len(path) == 1 {
+			n[token] = value
+			return n, nil
+		}
+		child, ok := n[token]
+		if !ok {
+			return nil, fmt.Errorf("member not found: %s", token)
+		}
+		updated, err := addJSONValue(child, path[1:], value)
+		if err != nil {
+			return nil, err
+		}
+		n[token] = updated
+		return n, nil
+	case []any:
+		if len(path) == 1 {
+			if token == "-" {
+				return append(n, value), nil
+			}
+			i, err := arrayIndex(token, len(n)+1)
+			if err != nil {
+				return nil, err
+			}
+			n = append(n, nil)
+			copy(n[i+1:], n[i:])
+			n[i] = value
+			return n, nil
+		}
+		i, err := arrayIndex(token, len(n))
+		if err != nil {
+			return nil, err
+		}
+		updated, err := addJSONValue(n[i], path[1:], value)
+		if err != nil {
+			return nil, err
+		}
+		n[i] = updated
+		return n, nil
+	default:
+		return nil, fmt.Errorf("cannot add %s to a %T", token, node)
+	}
+}
+
+// removeJSONValue removes the value at the path, returning the updated node and the removed value
+func removeJSONValue(node any, path []string) (any, any, error) {
+	if len(path) == 0 {
+		return nil, nil, errors.New("cannot remove the document")
+	}
+	token := path[0]
+	switch n := node.(type) {
+	case map[string]any:
+		child, ok := n[token]
+		if !ok {
+			return nil, nil, fmt.Errorf("member not found: %s", token)
+		}
+		if len(path) == 1 {
+			delete(n, token)
+			return n, child, nil
+		}
+		updated, removed, err := removeJSONValue(child, path[1:])
+		if err != nil {
+			return nil, nil, err
+		}
+		n[token] = updated
+		return n, removed, nil
+	case []any:
+		i, err := arrayIndex(token, len(n))
+		if err != nil {
+			return nil, nil, err
+		}
+		if len(path) == 1 {
+			removed := n[i]
+			return append(n[:i], n[i+1:]...), removed, nil
+		}
+		updated, removed, err := removeJSONValue(n[i], path[1:])
+		if err != nil {
+			return nil, nil, err
+		}
+		n[i] = updated
+		return n, removed, nil
+	default:
+		return nil, nil, fmt.Errorf("cannot remove %s from a %T", token, node)
+	}
+}
+
+// arrayIndex parses an array index, which must be less than the bound
+func arrayIndex(token string, bound int) (int, error) {
+	if token == "" || (len(token) > 1 && token[0] == '0') || strings.TrimLeft(token, "0123456789") != "" {
+		return 0, fmt.Errorf("invalid array index: %s", token)
+	}
+	i, err := strconv.Atoi(token)
+	if err != nil || i >= bound {
+		return 0, fmt.Errorf("array index out of bounds: %s", token)
+	}
+	return i, nil
+}
+
+// diffJSON appends the operations turning one JSON value into another to the patch. Objects are compared member by
+// member, and arrays element by element, with elements added or removed at their end.
+func diffJSON(path string, from, to any, patch *JSONPatch) {
+	fromMap, fromIsMap := from.(map[string]any)
+	toMap, toIsMap := to.(map[string]any)
+	if fromIsMap && toIsMap {
+		for _, key := range sortedKeys(fromMap) {
+			memberPath := path + "/" + escapeJSONPointer(key)
+			if toValue, ok := toMap[key]; ok {
+				diffJSON(memberPath, fromMap[key], toValue, patch)
+			} else {
+				*patch = append(*patch, JSONPatchOperation{Op: JSONPatchRemove, Path: memberPath})
+			}
+		}
+		for _, key := range sortedKeys(toMap) {
+			if _, ok := fromMap[key]; !ok {
+				*patch = append(*patch, JSONPatchOperation{Op: JSONPatchAdd, Path: path + "/" + escapeJSONPointer(key), Value: toMap[key]})
+			}
+		}
+		return
+	}
+
+	fromArray, fromIsArray := from.([]any)
+	toArray, toIsArray := to.([]any)
+	if fromIsArray && toIsArray {
+		common := len(fromArray)
+		if len(toArray) < common {
+			common = len(toArray)
+		}
+		for i := 0; i < common; i++ {
+			diffJSON(fmt.Sprintf("%s/%d", path, i), fromArray[i], toArray[i], patch)
+		}
+		for i := len(fromArray) - 1; i >= common; i-- {
+			*patch = append(*patch, JSONPatchOperation{Op: JSONPatchRemove, Path: fmt.Sprintf("%s/%d", path, i)})
+		}
+		for i := common; i < len(toArray); i++ {
+			*patch = append(*patch, JSONPatchOperation{Op: JSONPatchAdd, Path: path + "/-", Value: toArray[i]})
+		}
+		return
+	}
+
+	if !jsonEqual(from, to) {
+		*patch = append(*patch, JSONPatchOperation{Op: JSONPatchReplace, Path: path, Value: to})
+	}
+}
+
+func sortedKeys(m map[string]any) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func escapeJSONPointer(token string) string {
+	return strings.ReplaceAll(strings.ReplaceAll(token, "~", "~0"), "/", "~1")
+}
+
+// toJSONValue converts a value to its JSON data model of maps, slices, strings, numbers, booleans and nil
+func toJSONValue(v any) (any, error) {
+	valueBytes, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var value any
+	if err = json.Unmarshal(valueBytes, &value); err != nil {
+		return nil, err
+	}
+	return value, nil
+}
+
+// copyJSONValue deep copies a value of the JSON data model, so that patched documents do not share it
+func copyJSONValue(v any) any {
+	copied, err := toJSONValue(v)
+	if err != nil {
+		return v
+	}
+	return copied
+}
+
+// jsonEqual returns true if two values have the same JSON representation, regardless of the order of object members
+func jsonEqual(a, b any) bool {
+	aValue, err := toJSONValue(a)
+	if err != nil {
+		return false
+	}
+	bValue, err := toJSONValue(b)
+	if err != nil {
+		return false
+	}
+	aBytes, err := json.Marshal(aValue)
+	if err != nil {
+		return false
+	}
+	bBytes, err := json.Marshal(bValue)
+	if err != nil {
+		return false
+	}
+	return bytes.Equal(aBytes, bBytes)
+}
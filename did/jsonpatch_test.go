@@ -0,0 +1,82 @@
+package did
+
+import (
+	"testing"
+
+	"github.com/goccy/go-json"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestJSONPatchApply(t *testing.T) {
+	t.Run("operations", func(tt *testing.T) {
+		doc := validDocument()
+		patch := JSONPatch{
+			{Op: JSONPatchTest, Path: "/id", Value: "did:example:123"},
+			{Op: JSONPatchAdd, Path: "/alsoKnownAs", Value: "https://example.com/alice"},
+			{Op: JSONPatchReplace, Path: "/service/0/serviceEndpoint", Value: "https://example.org"},
+			{Op: JSONPatchRemove, Path: "/authentication/2"},
+			{Op: JSONPatchAdd, Path: "/authentication/0", Value: "#key-3"},
+			{Op: JSONPatchCopy, From: "/authentication/1", Path: "/capabilityInvocation"},
+			{Op: JSONPatchMove, From: "/service/1", Path: "/service/0"},
+		}
+		patched, err := patch.Apply(doc)
+		require.NoError(tt, err)
+		assert.Equal(tt, "https://example.com/alice", patched.AlsoKnownAs)
+		assert.Equal(tt, VerificationRelationship{"#key-3", "#key-1", "did:example:123#key-2"}, patched.Authentication)
+		assert.Equal(tt, VerificationRelationship{"#key-1"}, patched.CapabilityInvocation)
+		require.Len(tt, patched.Services, 2)
+		assert.Equal(tt, "#messaging", patched.Services[0].ID)
+		assert.Equal(tt, "https://example.org", patched.Services[1].ServiceEndpoint)
+
+		// the document is not modified
+		assert.Empty(tt, doc.AlsoKnownAs)
+		assert.Len(tt, doc.Authentication, 3)
+	})
+
+	t.Run("escaped pointers", func(tt *testing.T) {
+		doc := validDocument()
+		doc.Services[0].ServiceEndpoint = map[string]any{"a/b": "1", "c~d": "2"}
+		patched, err := JSONPatch{
+			{Op: JSONPatchReplace, Path: "/service/0/serviceEndpoint/a~1b", Value: "3"},
+			{Op: JSONPatchRemove, Path: "/service/0/serviceEndpoint/c~0d"},
+		}.Apply(doc)
+		require.NoError(tt, err)
+		assert.Equal(tt, map[string]any{"a/b": "3"}, patched.Services[0].ServiceEndpoint)
+
+		var patch JSONPatch
+		diffJSON("", map[string]any{"a/b": "1", "c~d": "2"}, map[string]any{"a/b": "3"}, &patch)
+		assert.Equal(tt, JSONPatch{
+			{Op: JSONPatchReplace, Path: "/a~1b", Value: "3"},
+			{Op: JSONPatchRemove, Path: "/c~0d"},
+		}, patch)
+	})
+
+	t.Run("failed operations", func(tt *testing.T) {
+		doc := validDocument()
+		for _, test := range []struct {
+			op  JSONPatchOperation
+			err string
+		}{
+			{op: JSONPatchOperation{Op: JSONPatchTest, Path: "/id", Value: "did:example:456"}, err: "test failed"},
+			{op: JSONPatchOperation{Op: JSONPatchRemove, Path: "/alsoKnownAs"}, err: "member not found: alsoKnownAs"},
+			{op: JSONPatchOperation{Op: JSONPatchReplace, Path: "/service/2", Value: "x"}, err: "array index out of bounds: 2"},
+			{op: JSONPatchOperation{Op: JSONPatchAdd, Path: "/service/01", Value: "x"}, err: "invalid array index: 01"},
+			{op: JSONPatchOperation{Op: JSONPatchMove, From: "/service", Path: "/service/0"}, err: "cannot move a value into one of its children"},
+			{op: JSONPatchOperation{Op: JSONPatchAdd, Path: "id", Value: "x"}, err: "invalid JSON Pointer: id"},
+			{op: JSONPatchOperation{Op: "merge", Path: "/id"}, err: "unsupported operation: merge"},
+		} {
+			_, err := JSONPatch{test.op}.Apply(doc)
+			assert.ErrorContains(tt, err, test.err)
+		}
+	})
+
+	t.Run("unmarshals from JSON", func(tt *testing.T) {
+		var patch JSONPatch
+		require.NoError(tt, json.Unmarshal([]byte(`[{"op":"add","path":"/service/-","value":{"id":"#hub","type":"IdentityHub","serviceEndpoint":"https://hub.example.com"}}]`), &patch))
+		patched, err := patch.Apply(validDocument())
+		require.NoError(tt, err)
+		require.Len(tt, patched.Services, 3)
+		assert.Equal(tt, "#hub", patched.Services[2].ID)
+	})
+}
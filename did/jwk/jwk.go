@@ -22,8 +22,17 @@ type (
 const (
 	// Prefix did:jwk prefix
 	Prefix = "did:jwk"
+
+	// SignatureUse is the JWK use of keys that only sign, which are only placed in signing verification relationships
+	SignatureUse = "sig"
+	// EncryptionUse is the JWK use of keys that only encrypt, which are only placed in keyAgreement
+	EncryptionUse = "enc"
 )
 
+// privateKeyMembers are the JWK members holding private or symmetric key material, which a did:jwk must not contain
+// https://www.rfc-editor.org/rfc/rfc7518#section-6
+var privateKeyMembers = []string{"d", "p", "q", "dp", "dq", "qi", "oth", "k"}
+
 func (d JWK) IsValid() bool {
 	_, err := d.Expand()
 	return err == nil
@@ -61,6 +70,11 @@ func GenerateDIDJWK(kt crypto.KeyType) (gocrypto.PrivateKey, *JWK, error) {
 	if err != nil {
 		return nil, nil, errors.Wrap(err, "converting public key to JWK")
 	}
+	// key agreement keys declare their use, so that they are not placed in signing relationships when expanded by
+	// implementations that do not infer it
+	if encryptionOnly(*pubKeyJWK) {
+		pubKeyJWK.Use = EncryptionUse
+	}
 
 	// 2. Serialize it into a UTF-8 string
 	// 3. Encode string using base64url
@@ -74,12 +88,16 @@ func GenerateDIDJWK(kt crypto.KeyType) (gocrypto.PrivateKey, *JWK, error) {
 
 // CreateDIDJWK creates a did:jwk from a JWK public key by following the steps in the spec:
 // https://github.com/quartzjer/did-jwk/blob/main/spec.md
+// The JWK must be a public key, without private key members, whose use, if any, is supported by the key.
 func CreateDIDJWK(publicKeyJWK jwx.PublicKeyJWK) (*JWK, error) {
 	// 2. Serialize it into a UTF-8 string
 	pubKeyJWKBytes, err := json.Marshal(publicKeyJWK)
 	if err != nil {
 		return nil, errors.Wrap(err, "marshalling public key JWK")
 	}
+	if _, err = parsePublicKeyJWK(pubKeyJWKBytes); err != nil {
+		return nil, errors.Wrap(err, "invalid public key JWK")
+	}
 	pubKeyJWKStr := string(pubKeyJWKBytes)
 
 	// 3. Encode string using base64url
@@ -107,10 +125,14 @@ func (d JWK) Expand() (*did.Document, error) {
 		return nil, errors.Wrap(err, "decoding did:jwk")
 	}
 
-	var pubKeyJWK jwx.PublicKeyJWK
-	if err = json.Unmarshal(decodedPubKeyJWKStr, &pubKeyJWK); err != nil {
+	pubKeyJWK, err := parsePublicKeyJWK(decodedPubKeyJWKStr)
+	if err != nil {
 		return nil, errors.Wrap(err, "unmarshalling did:jwk")
 	}
+	use, err := keyUse(*pubKeyJWK)
+	if err != nil {
+		return nil, err
+	}
 
 	keyReference := "#0"
 	keyID := id + keyReference
@@ -123,7 +145,7 @@ func (d JWK) Expand() (*did.Document, error) {
 				ID:           keyID,
 				Type:         cryptosuite.JSONWebKey2020Type,
 				Controller:   id,
-				PublicKeyJWK: &pubKeyJWK,
+				PublicKeyJWK: pubKeyJWK,
 			},
 		},
 		Authentication:       []did.VerificationMethodSet{keyID},
@@ -135,10 +157,10 @@ func (d JWK) Expand() (*did.Document, error) {
 
 	// If the JWK contains a use property with the value "sig" then the keyAgreement property is not included in the
 	// DID Document. If the use value is "enc" then only the keyAgreement property is included in the DID Document.
-	switch pubKeyJWK.Use {
-	case "sig":
+	switch use {
+	case SignatureUse:
 		doc.KeyAgreement = nil
-	case "enc":
+	case EncryptionUse:
 		doc.Authentication = nil
 		doc.AssertionMethod = nil
 		doc.CapabilityInvocation = nil
@@ -148,6 +170,72 @@ func (d JWK) Expand() (*did.Document, error) {
 	return &doc, nil
 }
 
+// parsePublicKeyJWK parses a JWK, which must be a public key without any private key members
+func parsePublicKeyJWK(jwkBytes []byte) (*jwx.PublicKeyJWK, error) {
+	var members map[string]any
+	if err := json.Unmarshal(jwkBytes, &members); err != nil {
+		return nil, err
+	}
+	for _, member := range privateKeyMembers {
+		if _, ok := members[member]; ok {
+			return nil, fmt.Errorf("JWK must not contain the private key member: %s", member)
+		}
+	}
+	var pubKeyJWK jwx.PublicKeyJWK
+	if err := json.Unmarshal(jwkBytes, &pubKeyJWK); err != nil {
+		return nil, err
+	}
+	if pubKeyJWK.KTY == "" {
+		return nil, errors.New("JWK must have a kty")
+	}
+	if _, err := keyUse(pubKeyJWK); err != nil {
+		return nil, err
+	}
+	return &pubKeyJWK, nil
+}
+
+// keyUse returns the use of the key, which is its use member if any. Without one, the did:jwk spec places the key in
+// every verification relationship. Keys which can only be used for key agreement, such as X25519 keys, are the
+// exception: departing from the spec, they are given an encryption use, as they cannot authenticate or sign.
+func keyUse(publicKeyJWK jwx.PublicKeyJWK) (string, error) {
+	switch publicKeyJWK.Use {
+	case "":
+		if encryptionOnly(publicKeyJWK) {
+			return EncryptionUse, nil
+		}
+		return "", nil
+	case SignatureUse:
+		if encryptionOnly(publicKeyJWK) {
+			return "", fmt.Errorf("%s keys cannot have use %s", publicKeyJWK.CRV, SignatureUse)
+		}
+	case EncryptionUse:
+		if signatureOnly(publicKeyJWK) {
+			return "", fmt.Errorf("%s keys cannot have use %s", keyName(publicKeyJWK), EncryptionUse)
+		}
+	default:
+		return "", fmt.Errorf("unsupported JWK use: %s", publicKeyJWK.Use)
+	}
+	return publicKeyJWK.Use, nil
+}
+
+// encryptionOnly returns true for keys that can only be used for key agreement
+func encryptionOnly(publicKeyJWK jwx.PublicKeyJWK) bool {
+	return publicKeyJWK.KTY == "OKP" && (publicKeyJWK.CRV == "X25519" || publicKeyJWK.CRV == "X448")
+}
+
+// signatureOnly returns true for keys that can only be used for signatures
+func signatureOnly(publicKeyJWK jwx.PublicKeyJWK) bool {
+	return publicKeyJWK.KTY == jwx.DilithiumKTY ||
+		(publicKeyJWK.KTY == "OKP" && (publicKeyJWK.CRV == "Ed25519" || publicKeyJWK.CRV == "Ed448"))
+}
+
+func keyName(publicKeyJWK jwx.PublicKeyJWK) string {
+	if publicKeyJWK.KTY == jwx.DilithiumKTY {
+		return publicKeyJWK.ALG
+	}
+	return publicKeyJWK.CRV
+}
+
 // IsSupportedJWKType returns if a given key type is supported for the did:jwk method
 func IsSupportedJWKType(kt crypto.KeyType) bool {
 	jwkTypes := GetSupportedDIDJWKTypes()
@@ -161,5 +249,6 @@ func IsSupportedJWKType(kt crypto.KeyType) bool {
 
 // GetSupportedDIDJWKTypes returns all supported did:jwk key types
 func GetSupportedDIDJWKTypes() []crypto.KeyType {
-	return []crypto.KeyType{crypto.Ed25519, crypto.X25519, crypto.SECP256k1, crypto.P256, crypto.P384, crypto.P521, crypto.RSA}
+	return []crypto.KeyType{crypto.Ed25519, crypto.X25519, crypto.SECP256k1, crypto.P256, crypto.P384, crypto.P521, crypto.RSA,
+		crypto.Dilithium2, crypto.Dilithium3, crypto.Dilithium5}
 }
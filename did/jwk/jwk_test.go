@@ -2,12 +2,14 @@ package jwk
 
 import (
 	"embed"
+	"encoding/base64"
 	"strings"
 	"testing"
 
 	"github.com/extrimian/ssi-sdk/cryptosuite/jws2020"
 	"github.com/goccy/go-json"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
 	"github.com/extrimian/ssi-sdk/crypto"
 	"github.com/extrimian/ssi-sdk/crypto/jwx"
@@ -115,6 +117,16 @@ func TestGenerateDIDJWK(t *testing.T) {
 			keyType:   crypto.RSA,
 			expectErr: false,
 		},
+		{
+			name:      "Dilithium2",
+			keyType:   crypto.Dilithium2,
+			expectErr: false,
+		},
+		{
+			name:      "Dilithium5",
+			keyType:   crypto.Dilithium5,
+			expectErr: false,
+		},
 		{
 			name:      "Unsupported",
 			keyType:   crypto.KeyType("unsupported"),
@@ -179,6 +191,131 @@ func TestExpandDIDJWK(t *testing.T) {
 	})
 }
 
+func TestExpandDIDJWKUse(t *testing.T) {
+	signing := []did.Relationship{did.Authentication, did.AssertionMethod, did.CapabilityInvocation, did.CapabilityDelegation}
+	all := append(signing, did.KeyAgreement)
+
+	tests := []struct {
+		name          string
+		keyType       crypto.KeyType
+		use           string
+		relationships []did.Relationship
+	}{
+		{name: "X25519 keys are only used for key agreement", keyType: crypto.X25519, relationships: []did.Relationship{did.KeyAgreement}},
+		{name: "Ed25519 keys without a use are in every relationship", keyType: crypto.Ed25519, relationships: all},
+		{name: "Ed25519 keys for signing", keyType: crypto.Ed25519, use: SignatureUse, relationships: signing},
+		{name: "Dilithium keys without a use are in every relationship", keyType: crypto.Dilithium3, relationships: all},
+		{name: "P-256 keys are used for both", keyType: crypto.P256, relationships: all},
+		{name: "P-256 keys for signing", keyType: crypto.P256, use: SignatureUse, relationships: signing},
+		{name: "P-256 keys for encryption", keyType: crypto.P256, use: EncryptionUse, relationships: []did.Relationship{did.KeyAgreement}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(tt *testing.T) {
+			pubKey, _, err := crypto.GenerateKeyByKeyType(test.keyType)
+			require.NoError(tt, err)
+			pubKeyJWK, err := jwx.PublicKeyToPublicKeyJWK("", pubKey)
+			require.NoError(tt, err)
+			pubKeyJWK.Use = test.use
+			didJWK, err := CreateDIDJWK(*pubKeyJWK)
+			require.NoError(tt, err)
+
+			doc, err := didJWK.Expand()
+			require.NoError(tt, err)
+			assert.NoError(tt, doc.Validate())
+			for _, relationship := range all {
+				assert.Equal(tt, contains(test.relationships, relationship), doc.HasRelationship("#0", relationship), relationship)
+			}
+		})
+	}
+
+	t.Run("generated X25519 keys declare their use", func(tt *testing.T) {
+		_, didJWK, err := GenerateDIDJWK(crypto.X25519)
+		require.NoError(tt, err)
+		doc, err := didJWK.Expand()
+		require.NoError(tt, err)
+		assert.Equal(tt, EncryptionUse, doc.VerificationMethod[0].PublicKeyJWK.Use)
+	})
+
+	t.Run("dilithium verification methods", func(tt *testing.T) {
+		privKey, didJWK, err := GenerateDIDJWK(crypto.Dilithium2)
+		require.NoError(tt, err)
+		doc, err := didJWK.Expand()
+		require.NoError(tt, err)
+
+		vm := doc.VerificationMethod[0]
+		alg, err := vm.Algorithm()
+		require.NoError(tt, err)
+		assert.Equal(tt, jwx.DilithiumMode2Alg.String(), alg)
+
+		pubKey, err := vm.PublicKeyJWK.ToPublicKey()
+		require.NoError(tt, err)
+		gotKeyType, err := crypto.GetKeyTypeFromPrivateKey(privKey)
+		require.NoError(tt, err)
+		assert.Equal(tt, crypto.Dilithium2, gotKeyType)
+		assert.NotEmpty(tt, pubKey)
+	})
+
+	t.Run("unsupported uses", func(tt *testing.T) {
+		for _, test := range []struct {
+			keyType crypto.KeyType
+			use     string
+			err     string
+		}{
+			{keyType: crypto.X25519, use: SignatureUse, err: "X25519 keys cannot have use sig"},
+			{keyType: crypto.Ed25519, use: EncryptionUse, err: "Ed25519 keys cannot have use enc"},
+			{keyType: crypto.Dilithium2, use: EncryptionUse, err: "CRYDI2 keys cannot have use enc"},
+			{keyType: crypto.P256, use: "wrap", err: "unsupported JWK use: wrap"},
+		} {
+			pubKey, _, err := crypto.GenerateKeyByKeyType(test.keyType)
+			require.NoError(tt, err)
+			pubKeyJWK, err := jwx.PublicKeyToPublicKeyJWK("", pubKey)
+			require.NoError(tt, err)
+			pubKeyJWK.Use = test.use
+			_, err = CreateDIDJWK(*pubKeyJWK)
+			assert.ErrorContains(tt, err, test.err)
+
+			// DIDs created by other implementations are rejected on expansion
+			jwkBytes, err := json.Marshal(pubKeyJWK)
+			require.NoError(tt, err)
+			_, err = JWK(Prefix + ":" + base64.RawURLEncoding.EncodeToString(jwkBytes)).Expand()
+			assert.ErrorContains(tt, err, test.err)
+		}
+	})
+}
+
+func TestDIDJWKPrivateKeyMembers(t *testing.T) {
+	_, privKey, err := crypto.GenerateEd25519Key()
+	require.NoError(t, err)
+	_, privKeyJWK, err := jwx.PrivateKeyToPrivateKeyJWK("", privKey)
+	require.NoError(t, err)
+	privKeyJWKBytes, err := json.Marshal(privKeyJWK)
+	require.NoError(t, err)
+
+	_, err = JWK(Prefix + ":" + base64.RawURLEncoding.EncodeToString(privKeyJWKBytes)).Expand()
+	assert.ErrorContains(t, err, "JWK must not contain the private key member: d")
+
+	symmetricJWK := []byte(`{"kty":"oct","k":"GawgguFyGrWKav7AX4VKUg"}`)
+	_, err = JWK(Prefix + ":" + base64.RawURLEncoding.EncodeToString(symmetricJWK)).Expand()
+	assert.ErrorContains(t, err, "JWK must not contain the private key member: k")
+
+	// the public key of the private key is accepted
+	didJWK, err := CreateDIDJWK(privKeyJWK.ToPublicKeyJWK())
+	require.NoError(t, err)
+	assert.True(t, didJWK.IsValid())
+
+	_, err = CreateDIDJWK(jwx.PublicKeyJWK{X: privKeyJWK.X})
+	assert.ErrorContains(t, err, "JWK must have a kty")
+}
+
+func contains(relationships []did.Relationship, relationship did.Relationship) bool {
+	for _, r := range relationships {
+		if r == relationship {
+			return true
+		}
+	}
+	return false
+}
+
 func getTestVector(fileName string) (string, error) {
 	b, err := jwkTestVectors.ReadFile("testdata/" + fileName)
 	return string(b), err
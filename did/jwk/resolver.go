@@ -2,6 +2,8 @@ package jwk
 
 import (
 	"context"
+	"strings"
+	"time"
 
 	"github.com/pkg/errors"
 
@@ -13,13 +15,28 @@ type Resolver struct{}
 
 var _ resolution.Resolver = (*Resolver)(nil)
 
-func (Resolver) Resolve(_ context.Context, id string, _ ...resolution.Option) (*resolution.Result, error) {
+func (Resolver) Resolve(_ context.Context, id string, opts ...resolution.Option) (*resolution.Result, error) {
+	start := time.Now()
+	if !strings.HasPrefix(id, Prefix) {
+		return nil, resolution.NewResolutionErrorf(resolution.MethodNotSupportedErrorCode, "not a did:jwk DID: %s", id)
+	}
+	options, err := resolution.ParseOptions(opts...)
+	if err != nil {
+		return nil, err
+	}
+	if err = options.RejectVersioned(did.JWKMethod); err != nil {
+		return nil, err
+	}
 	didJWK := JWK(id)
 	doc, err := didJWK.Expand()
 	if err != nil {
-		return nil, errors.Wrap(err, "expanding did:jwk")
+		return nil, resolution.NewResolutionError(resolution.InvalidDIDErrorCode, errors.Wrap(err, "expanding did:jwk"))
+	}
+	result := resolution.NewResult(*doc, nil, start)
+	if err = resolution.ValidateResult(result); err != nil {
+		return nil, err
 	}
-	return &resolution.Result{Document: *doc}, nil
+	return options.Apply(result), nil
 }
 
 func (Resolver) Methods() []did.Method {
@@ -30,6 +30,10 @@ const (
 	// Prefix did:key prefix
 	Prefix = "did:key"
 
+	// BLS12381G1PublicKeySize and BLS12381G2PublicKeySize are the sizes of compressed BLS12-381 public keys
+	BLS12381G1PublicKeySize = 48
+	BLS12381G2PublicKeySize = 96
+
 	// Expansion options
 	EnableEncryptionKeyDerivationOption = "EnableEncryptionKeyDerivation"
 	PublicKeyFormatOption               = "PublicKeyFormat"
@@ -171,7 +175,7 @@ func (d DIDKey) Decode() ([]byte, crypto.KeyType, error) {
 	if err != nil {
 		return nil, "", err
 	}
-	if n != 2 {
+	if !did.IsValidMultiCodecPrefix(multiCodec, n) {
 		return nil, "", errors.New("error parsing did:key varint")
 	}
 
@@ -189,7 +193,8 @@ func (d DIDKey) Decode() ([]byte, crypto.KeyType, error) {
 //   - EnableEncryptionKeyDerivationOption (default to true)
 //   - PublicKeyFormatOption (defaults to JWK)
 //
-// TODO(gabe) support BLS curves https://github.com/extrimian/ssi-sdk/issues/381
+// BLS12-381 keys have no JWK representation and always expand to Bls12381G2Key2020 and Bls12381G1Key2020
+// verification methods. A bls12_381-g1g2 key expands to one verification method for each of its two keys.
 func (d DIDKey) Expand(opts ...Option) (*did.Document, error) {
 	publicKeyFormat, enableEncryptionDerivation, err := processExpansionOptions(opts...)
 	if err != nil {
@@ -205,6 +210,9 @@ func (d DIDKey) Expand(opts ...Option) (*did.Document, error) {
 	if err != nil {
 		return nil, errors.Wrap(err, "decoding did:key")
 	}
+	if cryptoKeyType == crypto.BLS12381G2 || cryptoKeyType == crypto.BLS12381G1G2 {
+		return expandBLS(id, pubKey, cryptoKeyType)
+	}
 
 	var verificationMethod *did.VerificationMethod
 	keyID := id + "#" + suffix
@@ -219,6 +227,19 @@ func (d DIDKey) Expand(opts ...Option) (*did.Document, error) {
 		if err != nil {
 			return nil, errors.Wrap(err, "converting key type to multikey type")
 		}
+		if multiKeyType == cryptosuite.MultikeyType {
+			// Multikey verification methods carry the multicodec prefixed key, which is the suffix of the did:key
+			if _, err = crypto.BytesToPubKey(pubKey, cryptoKeyType); err != nil {
+				return nil, errors.Wrap(err, "converting bytes to public key")
+			}
+			verificationMethod = &did.VerificationMethod{
+				ID:                 keyID,
+				Type:               multiKeyType,
+				Controller:         id,
+				PublicKeyMultibase: suffix,
+			}
+			break
+		}
 		verificationMethod, err = did.ConstructMultibaseVerificationMethod(keyID, id, pubKey, multiKeyType)
 		if err != nil {
 			return nil, errors.Wrapf(err, "could not construct %s verification method", publicKeyFormat)
@@ -258,6 +279,8 @@ func (d DIDKey) Expand(opts ...Option) (*did.Document, error) {
 			contexts = append(contexts, cryptosuite.BLS12381G2Key2020Context)
 		case crypto.P224, crypto.P256, crypto.P384, crypto.P521:
 			contexts = append(contexts, cryptosuite.Multikey2021Context)
+		case crypto.Dilithium2, crypto.Dilithium3, crypto.Dilithium5:
+			contexts = append(contexts, cryptosuite.MultikeyContext)
 		}
 	}
 	doc.Context = contexts
@@ -277,7 +300,8 @@ func (d DIDKey) Expand(opts ...Option) (*did.Document, error) {
 
 	// https://w3c-ccg.github.io/did-method-key/#derive-encryption-key-algorithm
 	// the only case we have to consider is if the verification method is X25519
-	if enableEncryptionDerivation && !isVerificationMethodX25519Key {
+	// signature only keys, such as Dilithium keys, are never used for key agreement
+	if enableEncryptionDerivation && !isVerificationMethodX25519Key && !isSignatureOnlyKeyType(cryptoKeyType) {
 		keyAgreementVerificationMethod, keyAgreementVerificationMethodSet, err := generateKeyAgreementVerificationMethod(*verificationMethod)
 		if err != nil {
 			return nil, errors.Wrap(err, "generating key agreement verification method")
@@ -291,6 +315,66 @@ func (d DIDKey) Expand(opts ...Option) (*did.Document, error) {
 	return &doc, nil
 }
 
+// expandBLS expands a did:key of a BLS12-381 G2 key, or of concatenated G1 and G2 keys, whose verification
+// methods are identified by the multibase encoding of each key
+// https://w3c-ccg.github.io/did-method-key/#bls-12381
+func expandBLS(id string, pubKey []byte, kt crypto.KeyType) (*did.Document, error) {
+	type blsKey struct {
+		keyType   crypto.KeyType
+		ldKeyType cryptosuite.LDKeyType
+		bytes     []byte
+	}
+	var keys []blsKey
+	switch kt {
+	case crypto.BLS12381G2:
+		keys = []blsKey{{keyType: crypto.BLS12381G2, ldKeyType: cryptosuite.BLS12381G2Key2020, bytes: pubKey}}
+	case crypto.BLS12381G1G2:
+		if len(pubKey) != BLS12381G1PublicKeySize+BLS12381G2PublicKeySize {
+			return nil, fmt.Errorf("invalid bls12_381-g1g2 public key size: %d", len(pubKey))
+		}
+		keys = []blsKey{
+			{keyType: crypto.BLS12381G1, ldKeyType: cryptosuite.BLS12381G1Key2020, bytes: pubKey[:BLS12381G1PublicKeySize]},
+			{keyType: crypto.BLS12381G2, ldKeyType: cryptosuite.BLS12381G2Key2020, bytes: pubKey[BLS12381G1PublicKeySize:]},
+		}
+	default:
+		return nil, fmt.Errorf("not a bls key type: %s", kt)
+	}
+
+	doc := did.Document{
+		Context: []string{did.KnownDIDContext, cryptosuite.BLS12381G2Key2020Context},
+		ID:      id,
+	}
+	for _, k := range keys {
+		// only the G2 key can be checked to be a valid point, as the crypto package has no G1 keys
+		if k.keyType == crypto.BLS12381G2 {
+			if _, err := crypto.BytesToPubKey(k.bytes, k.keyType); err != nil {
+				return nil, errors.Wrap(err, "converting bytes to bls12_381-g2 public key")
+			}
+		} else if len(k.bytes) != BLS12381G1PublicKeySize {
+			return nil, fmt.Errorf("invalid bls12_381-g1 public key size: %d", len(k.bytes))
+		}
+		encoded, err := MultibaseEncodedKey(k.keyType, k.bytes)
+		if err != nil {
+			return nil, errors.Wrap(err, "multibase encoding bls key")
+		}
+		keyID := id + "#" + encoded
+		verificationMethod, err := did.ConstructMultibaseVerificationMethod(keyID, id, k.bytes, k.ldKeyType)
+		if err != nil {
+			return nil, errors.Wrapf(err, "could not construct %s verification method", k.ldKeyType)
+		}
+		doc.VerificationMethod = append(doc.VerificationMethod, *verificationMethod)
+		doc.Authentication = append(doc.Authentication, keyID)
+		doc.AssertionMethod = append(doc.AssertionMethod, keyID)
+		doc.CapabilityDelegation = append(doc.CapabilityDelegation, keyID)
+		doc.CapabilityInvocation = append(doc.CapabilityInvocation, keyID)
+	}
+	return &doc, nil
+}
+
+func isSignatureOnlyKeyType(kt crypto.KeyType) bool {
+	return kt == crypto.Dilithium2 || kt == crypto.Dilithium3 || kt == crypto.Dilithium5
+}
+
 func generateKeyAgreementVerificationMethod(vm did.VerificationMethod) (*did.VerificationMethod, []did.VerificationMethodSet, error) {
 	var verificationMethod *did.VerificationMethod
 	var verificationMethodSet []did.VerificationMethodSet
@@ -374,7 +458,7 @@ func processExpansionOptions(opts ...Option) (cryptosuite.LDKeyType, bool, error
 }
 
 func IsSupportedDIDKeyType(kt crypto.KeyType) bool {
-	keyTypes := GetSupportedDIDKeyTypes()
+	keyTypes := append(GetSupportedDIDKeyTypes(), GetExperimentalDIDKeyTypes()...)
 	for _, t := range keyTypes {
 		if t == kt {
 			return true
@@ -387,3 +471,9 @@ func GetSupportedDIDKeyTypes() []crypto.KeyType {
 	return []crypto.KeyType{crypto.Ed25519, crypto.X25519, crypto.SECP256k1,
 		crypto.P256, crypto.P384, crypto.P521, crypto.RSA}
 }
+
+// GetExperimentalDIDKeyTypes returns the key types whose multicodecs are drafts. They can be used to create and
+// expand did:key DIDs, but BLS12381G1G2 keys cannot be generated.
+func GetExperimentalDIDKeyTypes() []crypto.KeyType {
+	return []crypto.KeyType{crypto.BLS12381G2, crypto.BLS12381G1G2, crypto.Dilithium2, crypto.Dilithium3, crypto.Dilithium5}
+}
@@ -8,10 +8,13 @@ import (
 	"crypto/rand"
 	"crypto/rsa"
 	"crypto/sha256"
+	"encoding/hex"
 	"strings"
 	"testing"
 
+	"github.com/cloudflare/circl/sign/dilithium"
 	secp "github.com/decred/dcrd/dcrec/secp256k1/v4"
+	"github.com/mr-tron/base58"
 	"github.com/multiformats/go-multibase"
 	"github.com/multiformats/go-multicodec"
 
@@ -21,8 +24,10 @@ import (
 	"github.com/extrimian/ssi-sdk/did/resolution"
 
 	"github.com/extrimian/ssi-sdk/crypto"
+	"github.com/extrimian/ssi-sdk/cryptosuite"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestParseDID(t *testing.T) {
@@ -106,6 +111,26 @@ func TestGenerateDIDKey(t *testing.T) {
 			keyType:   crypto.RSA,
 			expectErr: false,
 		},
+		{
+			name:      "BLS12381G2",
+			keyType:   crypto.BLS12381G2,
+			expectErr: false,
+		},
+		{
+			name:      "BLS12381G1G2",
+			keyType:   crypto.BLS12381G1G2,
+			expectErr: true,
+		},
+		{
+			name:      "Dilithium2",
+			keyType:   crypto.Dilithium2,
+			expectErr: false,
+		},
+		{
+			name:      "Dilithium5",
+			keyType:   crypto.Dilithium5,
+			expectErr: false,
+		},
 		{
 			name:      "Unsupported",
 			keyType:   crypto.KeyType("unsupported"),
@@ -139,7 +164,7 @@ func TestGenerateDIDKey(t *testing.T) {
 
 			multiCodec, n, err := varint.FromUvarint(decoded)
 			assert.NoError(t, err)
-			assert.Equal(t, 2, n)
+			assert.True(t, did.IsValidMultiCodecPrefix(multiCodec, n))
 			assert.Equal(t, codec, multicodec.Code(multiCodec))
 		})
 	}
@@ -209,6 +234,112 @@ func TestExpandDIDKey(t *testing.T) {
 	})
 }
 
+func TestExpandExperimentalDIDKey(t *testing.T) {
+	t.Run("BBS+ key", func(tt *testing.T) {
+		pubKey, _, err := crypto.GenerateBBSKeyPair()
+		require.NoError(tt, err)
+		pubKeyBytes, err := pubKey.Marshal()
+		require.NoError(tt, err)
+
+		didKey, err := CreateDIDKey(crypto.BLS12381G2, pubKeyBytes)
+		require.NoError(tt, err)
+		doc, err := didKey.Expand(PublicKeyFormatJSONWebKey2020, EnableEncryptionKeyDerivation)
+		require.NoError(tt, err)
+		assert.NoError(tt, doc.IsValid())
+		assert.Contains(tt, doc.Context, cryptosuite.BLS12381G2Key2020Context)
+		require.Len(tt, doc.VerificationMethod, 1)
+		assert.Equal(tt, cryptosuite.BLS12381G2Key2020, doc.VerificationMethod[0].Type)
+		assert.Equal(tt, base58.Encode(pubKeyBytes), doc.VerificationMethod[0].PublicKeyBase58)
+		assert.Empty(tt, doc.KeyAgreement)
+	})
+
+	t.Run("Invalid BLS keys", func(tt *testing.T) {
+		didKey, err := CreateDIDKey(crypto.BLS12381G2, make([]byte, BLS12381G2PublicKeySize))
+		require.NoError(tt, err)
+		_, err = didKey.Expand()
+		assert.Error(tt, err)
+
+		didKey, err = CreateDIDKey(crypto.BLS12381G1G2, make([]byte, BLS12381G1PublicKeySize))
+		require.NoError(tt, err)
+		_, err = didKey.Expand()
+		assert.ErrorContains(tt, err, "invalid bls12_381-g1g2 public key size")
+	})
+
+	for _, kt := range []crypto.KeyType{crypto.Dilithium2, crypto.Dilithium3, crypto.Dilithium5} {
+		t.Run(string(kt), func(tt *testing.T) {
+			_, didKey, err := GenerateDIDKey(kt)
+			require.NoError(tt, err)
+			suffix, err := didKey.Suffix()
+			require.NoError(tt, err)
+
+			jwkDoc, err := didKey.Expand()
+			require.NoError(tt, err)
+			require.Len(tt, jwkDoc.VerificationMethod, 1)
+			assert.Equal(tt, cryptosuite.JSONWebKey2020Type, jwkDoc.VerificationMethod[0].Type)
+			assert.Equal(tt, "LWE", jwkDoc.VerificationMethod[0].PublicKeyJWK.KTY)
+			assert.Empty(tt, jwkDoc.KeyAgreement)
+
+			multikeyDoc, err := didKey.Expand(PublicKeyFormatMultibase)
+			require.NoError(tt, err)
+			assert.Contains(tt, multikeyDoc.Context, cryptosuite.MultikeyContext)
+			require.Len(tt, multikeyDoc.VerificationMethod, 1)
+			assert.Equal(tt, cryptosuite.MultikeyType, multikeyDoc.VerificationMethod[0].Type)
+			assert.Equal(tt, suffix, multikeyDoc.VerificationMethod[0].PublicKeyMultibase)
+			assert.Empty(tt, multikeyDoc.KeyAgreement)
+		})
+	}
+
+	t.Run("Dilithium test vectors", func(tt *testing.T) {
+		// did:keys of the keys generated from an all zero seed, identified by their prefix and SHA-256 hash
+		vectors := []struct {
+			keyType crypto.KeyType
+			mode    dilithium.Mode
+			prefix  []byte
+			didKey  string
+			sha256  string
+		}{
+			{crypto.Dilithium2, dilithium.Mode2, []byte{0x82, 0x80, 0xc0, 0x01}, "did:key:z2BK7CzfPgdE2eAY", "e401daeef0ca1b72bb41d4c059b7a43a7b9b5ccd3cdf167268e7544c197d7fbf"},
+			{crypto.Dilithium3, dilithium.Mode3, []byte{0x83, 0x80, 0xc0, 0x01}, "did:key:z2J3e9UsxQgmLG64", "4a83d6aa34c16115613ae9126d450320e033b9a3abff716575992793f8cf927b"},
+			{crypto.Dilithium5, dilithium.Mode5, []byte{0x85, 0x80, 0xc0, 0x01}, "did:key:z2S4UdJF9ME7u78m", "e03fbfd42ef6ee1ffbbce9e2422eb920100e7b4e12d52c5ba5faef0de295ecfb"},
+		}
+		for _, v := range vectors {
+			pubKey, _ := v.mode.NewKeyFromSeed(make([]byte, v.mode.SeedSize()))
+			didKey, err := CreateDIDKey(v.keyType, pubKey.Bytes())
+			require.NoError(tt, err)
+			assert.True(tt, strings.HasPrefix(didKey.String(), v.didKey), v.keyType)
+			sum := sha256.Sum256([]byte(didKey.String()))
+			assert.Equal(tt, v.sha256, hex.EncodeToString(sum[:]), v.keyType)
+
+			suffix, err := didKey.Suffix()
+			require.NoError(tt, err)
+			_, decoded, err := multibase.Decode(suffix)
+			require.NoError(tt, err)
+			assert.Equal(tt, v.prefix, decoded[:4], v.keyType)
+
+			decodedKey, keyType, err := didKey.Decode()
+			require.NoError(tt, err)
+			assert.Equal(tt, v.keyType, keyType)
+			assert.Equal(tt, pubKey.Bytes(), decodedKey)
+		}
+	})
+
+	t.Run("Dilithium keys with the ML-DSA codes", func(tt *testing.T) {
+		// 0x1210 is mldsa-44-pub, which does not identify round 3 Dilithium keys
+		pubKey, _ := dilithium.Mode2.NewKeyFromSeed(make([]byte, dilithium.Mode2.SeedSize()))
+		encoded, err := multibase.Encode(multibase.Base58BTC, append(varint.ToUvarint(0x1210), pubKey.Bytes()...))
+		require.NoError(tt, err)
+		_, _, err = DIDKey(Prefix + ":" + encoded).Decode()
+		assert.Error(tt, err)
+	})
+
+	t.Run("Invalid Dilithium key size", func(tt *testing.T) {
+		didKey, err := CreateDIDKey(crypto.Dilithium2, []byte("too short"))
+		require.NoError(tt, err)
+		_, err = didKey.Expand()
+		assert.ErrorContains(tt, err, "public key size")
+	})
+}
+
 func TestGenerateAndDecodeDIDKey(t *testing.T) {
 	for _, kt := range GetSupportedDIDKeyTypes() {
 		privKey, didKey, err := GenerateDIDKey(kt)
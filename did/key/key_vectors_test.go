@@ -22,6 +22,7 @@ const (
 	NISTCurvesTestVector    string = "nist-curves.json"
 	RSATestVector           string = "rsa.json"
 	SECP256k1TestVector     string = "secp256k1.json"
+	BLS12381TestVector      string = "bls12381.json"
 )
 
 // https://github.com/w3c-ccg/did-method-key/tree/main/test-vectors
@@ -50,6 +51,10 @@ func TestKnownTestVectors(t *testing.T) {
 			name:     "secp256k1",
 			testFile: SECP256k1TestVector,
 		},
+		{
+			name:     "BLS12381",
+			testFile: BLS12381TestVector,
+		},
 	}
 	for _, test := range tests {
 		t.Run(test.name, func(tt *testing.T) {
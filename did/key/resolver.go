@@ -2,8 +2,8 @@ package key
 
 import (
 	"context"
-	"fmt"
 	"strings"
+	"time"
 
 	"github.com/pkg/errors"
 
@@ -15,16 +15,28 @@ type Resolver struct{}
 
 var _ resolution.Resolver = (*Resolver)(nil)
 
-func (Resolver) Resolve(_ context.Context, id string, _ ...resolution.Option) (*resolution.Result, error) {
+func (Resolver) Resolve(_ context.Context, id string, opts ...resolution.Option) (*resolution.Result, error) {
+	start := time.Now()
 	if !strings.HasPrefix(id, Prefix) {
-		return nil, fmt.Errorf("not a id:key DID: %s", id)
+		return nil, resolution.NewResolutionErrorf(resolution.MethodNotSupportedErrorCode, "not a did:key DID: %s", id)
+	}
+	options, err := resolution.ParseOptions(opts...)
+	if err != nil {
+		return nil, err
+	}
+	if err = options.RejectVersioned(did.KeyMethod); err != nil {
+		return nil, err
 	}
 	didKey := DIDKey(id)
 	doc, err := didKey.Expand()
 	if err != nil {
-		return nil, errors.Wrapf(err, "could not expand did:key DID: %s", id)
+		return nil, resolution.NewResolutionError(resolution.InvalidDIDErrorCode, errors.Wrapf(err, "could not expand did:key DID: %s", id))
+	}
+	result := resolution.NewResult(*doc, nil, start)
+	if err = resolution.ValidateResult(result); err != nil {
+		return nil, err
 	}
-	return &resolution.Result{Document: *doc}, nil
+	return options.Apply(result), nil
 }
 
 func (Resolver) Methods() []did.Method {
@@ -35,6 +35,29 @@ func TestResolveKeyForDID(t *testing.T) {
 		assert.Contains(tt, err.Error(), "unsupported method: example")
 	})
 
+	t.Run("invalid did:key", func(tt *testing.T) {
+		_, err = r.Resolve(context.Background(), "did:key:invalid")
+		assert.Error(tt, err)
+		assert.True(tt, resolution.IsInvalidDID(err))
+
+		_, err = Resolver{}.Resolve(context.Background(), "did:example:test")
+		assert.Error(tt, err)
+		assert.True(tt, resolution.IsMethodNotSupported(err))
+	})
+
+	t.Run("options are passed to the method resolver", func(tt *testing.T) {
+		_, didKey, err := GenerateDIDKey(crypto.Ed25519)
+		require.NoError(tt, err)
+
+		resolved, err := r.Resolve(context.Background(), didKey.String(), resolution.WithAccept(resolution.DIDJSONContentType))
+		assert.NoError(tt, err)
+		assert.Equal(tt, resolution.DIDJSONContentType, resolved.ContentType)
+
+		_, err = r.Resolve(context.Background(), didKey.String(), resolution.WithVersionID("1"))
+		assert.Error(tt, err)
+		assert.Equal(tt, resolution.InvalidOptionsErrorCode, resolution.GetErrorCode(err))
+	})
+
 	t.Run("unresolveable did", func(tt *testing.T) {
 		_, err = resolution.ResolveKeyForDID(context.Background(), r, "did:example:test", "test-kid")
 		assert.Error(tt, err)
@@ -25,16 +25,16 @@ type Document struct {
 	Context any `json:"@context,omitempty"`
 	// As per https://www.w3.org/TR/did-core/#did-subject intermediate representations of DID Documents do not
 	// require an ID property. The provided test vectors demonstrate IRs. As such, the property is optional.
-	ID                   string                  `json:"id,omitempty"`
-	Controller           string                  `json:"controller,omitempty"`
-	AlsoKnownAs          string                  `json:"alsoKnownAs,omitempty"`
-	VerificationMethod   []VerificationMethod    `json:"verificationMethod,omitempty" validate:"dive"`
-	Authentication       []VerificationMethodSet `json:"authentication,omitempty" validate:"dive"`
-	AssertionMethod      []VerificationMethodSet `json:"assertionMethod,omitempty" validate:"dive"`
-	KeyAgreement         []VerificationMethodSet `json:"keyAgreement,omitempty" validate:"dive"`
-	CapabilityInvocation []VerificationMethodSet `json:"capabilityInvocation,omitempty" validate:"dive"`
-	CapabilityDelegation []VerificationMethodSet `json:"capabilityDelegation,omitempty" validate:"dive"`
-	Services             []Service               `json:"service,omitempty" validate:"dive"`
+	ID                   string                   `json:"id,omitempty"`
+	Controller           string                   `json:"controller,omitempty"`
+	AlsoKnownAs          string                   `json:"alsoKnownAs,omitempty"`
+	VerificationMethod   []VerificationMethod     `json:"verificationMethod,omitempty" validate:"dive"`
+	Authentication       VerificationRelationship `json:"authentication,omitempty" validate:"dive"`
+	AssertionMethod      VerificationRelationship `json:"assertionMethod,omitempty" validate:"dive"`
+	KeyAgreement         VerificationRelationship `json:"keyAgreement,omitempty" validate:"dive"`
+	CapabilityInvocation VerificationRelationship `json:"capabilityInvocation,omitempty" validate:"dive"`
+	CapabilityDelegation VerificationRelationship `json:"capabilityDelegation,omitempty" validate:"dive"`
+	Services             []Service                `json:"service,omitempty" validate:"dive"`
 }
 
 type VerificationMethod struct {
@@ -53,7 +53,7 @@ type VerificationMethod struct {
 // VerificationMethodSet is a union type supporting the `authentication`, `assertionMethod`, `keyAgreement`,
 // `capabilityInvocation`, and `capabilityDelegation` types.
 // A set of one or more verification methods. Each verification method MAY be embedded or referenced.
-// TODO(gabe) consider changing this to a custom unmarshaler https://stackoverflow.com/a/28016508
+// Entries are held by a VerificationRelationship, which has typed accessors for them.
 type VerificationMethodSet any
 
 // Service is a property compliant with the did-core spec https://www.w3.org/TR/did-core/#services
@@ -101,6 +101,8 @@ func KeyTypeToMultikeyLDType(kt crypto.KeyType) (cryptosuite.LDKeyType, error) {
 		return cryptosuite.BLS12381G1Key2020, nil
 	case crypto.BLS12381G2:
 		return cryptosuite.BLS12381G2Key2020, nil
+	case crypto.Dilithium2, crypto.Dilithium3, crypto.Dilithium5:
+		return cryptosuite.MultikeyType, nil
 	default:
 		return "", fmt.Errorf("keyType %+v failed to convert to multikey LDKeyType", kt)
 	}
@@ -1,52 +1,45 @@
 package modena
 
-type Network string
+import "github.com/extrimian/ssi-sdk/did/sidetree"
+
+type (
+	Network          = sidetree.Network
+	PublicKeyPurpose = sidetree.PublicKeyPurpose
+	OperationKeyType = sidetree.OperationKeyType
+	OperationType    = sidetree.OperationType
+	Patch            = sidetree.Patch
+	PatchAction      = sidetree.PatchAction
+)
 
 const (
-	Mainnet Network = "mainnet"
-	Testnet Network = "testnet"
+	Mainnet = sidetree.Mainnet
+	Testnet = sidetree.Testnet
 )
 
-type PublicKeyPurpose string
-
 const (
-	Authentication       PublicKeyPurpose = "authentication"
-	AssertionMethod      PublicKeyPurpose = "assertionMethod"
-	CapabilityInvocation PublicKeyPurpose = "capabilityInvocation"
-	CapabilityDelegation PublicKeyPurpose = "capabilityDelegation"
-	KeyAgreement         PublicKeyPurpose = "keyAgreement"
+	Authentication       = sidetree.Authentication
+	AssertionMethod      = sidetree.AssertionMethod
+	CapabilityInvocation = sidetree.CapabilityInvocation
+	CapabilityDelegation = sidetree.CapabilityDelegation
+	KeyAgreement         = sidetree.KeyAgreement
 )
 
-type OperationKeyType string
-
 const (
-	Public  OperationKeyType = "public"
-	Private OperationKeyType = "private"
+	Public  = sidetree.Public
+	Private = sidetree.Private
 )
 
-type OperationType string
-
 const (
-	Create     OperationType = "create"
-	Update     OperationType = "update"
-	Deactivate OperationType = "deactivate"
-	Recover    OperationType = "recover"
+	Create     = sidetree.Create
+	Update     = sidetree.Update
+	Deactivate = sidetree.Deactivate
+	Recover    = sidetree.Recover
 )
 
-type Patch interface {
-	GetAction() PatchAction
-}
-
-type PatchAction string
-
 const (
-	Replace          PatchAction = "replace"
-	AddPublicKeys    PatchAction = "add-public-keys"
-	RemovePublicKeys PatchAction = "remove-public-keys"
-	AddServices      PatchAction = "add-services"
-	RemoveServices   PatchAction = "remove-services"
+	Replace          = sidetree.Replace
+	AddPublicKeys    = sidetree.AddPublicKeys
+	RemovePublicKeys = sidetree.RemovePublicKeys
+	AddServices      = sidetree.AddServices
+	RemoveServices   = sidetree.RemoveServices
 )
-
-func (p PatchAction) String() string {
-	return string(p)
-}
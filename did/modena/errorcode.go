@@ -1,26 +1,28 @@
 package modena
 
-type ErrorCode string
+import "github.com/extrimian/ssi-sdk/did/sidetree"
+
+type ErrorCode = sidetree.ErrorCode
 
 const (
-	DeltaExceedsMaximumSize                    ErrorCode = "DeltaExceedsMaximumSize"
-	DIDDocumentPublicKeyIDDuplicated           ErrorCode = "DidDocumentPublicKeyIdDuplicated"
-	DIDDocumentPublicKeyMissingOrIncorrectType ErrorCode = "DidDocumentPublicKeyMissingOrIncorrectType"
-	DIDDocumentServiceIDDuplicated             ErrorCode = "DidDocumentServiceIdDuplicated"
-	DIDSuffixIncorrectLength                   ErrorCode = "DidSuffixIncorrectLength" // #nosec
-	EncodedStringIncorrectEncoding             ErrorCode = "EncodedStringIncorrectEncoding"
-	IDNotUsingBase64URLCharacterSet            ErrorCode = "IdNotUsingBase64UrlCharacterSet"
-	IDTooLong                                  ErrorCode = "IdTooLong"
-	JWKES256kMissingOrInvalidCRV               ErrorCode = "JwkEs256kMissingOrInvalidCrv"
-	JWKES256kMissingOrInvalidKTY               ErrorCode = "JwkEs256kMissingOrInvalidKty"
-	JWKES256kHasIncorrectLengthOfX             ErrorCode = "JwkEs256kHasIncorrectLengthOfX"
-	JWKES256kHasIncorrectLengthOfY             ErrorCode = "JwkEs256kHasIncorrectLengthOfY"
-	JWKES256kHasIncorrectLengthOfD             ErrorCode = "JwkEs256kHasIncorrectLengthOfD"
-	MultihashStringNotAMultihash               ErrorCode = "MultihashStringNotAMultihash"
-	MultihashUnsupportedHashAlgorithm          ErrorCode = "MultihashUnsupportedHashAlgorithm"
-	PublicKeyJWKES256kHasUnexpectedProperty    ErrorCode = "PublicKeyJwkEs256kHasUnexpectedProperty"
-	PublicKeyPurposeDuplicated                 ErrorCode = "PublicKeyPurposeDuplicated"
-	ServiceEndpointCannotBeAnArray             ErrorCode = "ServiceEndpointCannotBeAnArray"
-	ServiceEndpointStringNotValidURI           ErrorCode = "ServiceEndpointStringNotValidUri"
-	ServiceTypeTooLong                         ErrorCode = "ServiceTypeTooLong"
+	DeltaExceedsMaximumSize                    = sidetree.DeltaExceedsMaximumSize
+	DIDDocumentPublicKeyIDDuplicated           = sidetree.DIDDocumentPublicKeyIDDuplicated
+	DIDDocumentPublicKeyMissingOrIncorrectType = sidetree.DIDDocumentPublicKeyMissingOrIncorrectType
+	DIDDocumentServiceIDDuplicated             = sidetree.DIDDocumentServiceIDDuplicated
+	DIDSuffixIncorrectLength                   = sidetree.DIDSuffixIncorrectLength
+	EncodedStringIncorrectEncoding             = sidetree.EncodedStringIncorrectEncoding
+	IDNotUsingBase64URLCharacterSet            = sidetree.IDNotUsingBase64URLCharacterSet
+	IDTooLong                                  = sidetree.IDTooLong
+	JWKES256kMissingOrInvalidCRV               = sidetree.JWKES256kMissingOrInvalidCRV
+	JWKES256kMissingOrInvalidKTY               = sidetree.JWKES256kMissingOrInvalidKTY
+	JWKES256kHasIncorrectLengthOfX             = sidetree.JWKES256kHasIncorrectLengthOfX
+	JWKES256kHasIncorrectLengthOfY             = sidetree.JWKES256kHasIncorrectLengthOfY
+	JWKES256kHasIncorrectLengthOfD             = sidetree.JWKES256kHasIncorrectLengthOfD
+	MultihashStringNotAMultihash               = sidetree.MultihashStringNotAMultihash
+	MultihashUnsupportedHashAlgorithm          = sidetree.MultihashUnsupportedHashAlgorithm
+	PublicKeyJWKES256kHasUnexpectedProperty    = sidetree.PublicKeyJWKES256kHasUnexpectedProperty
+	PublicKeyPurposeDuplicated                 = sidetree.PublicKeyPurposeDuplicated
+	ServiceEndpointCannotBeAnArray             = sidetree.ServiceEndpointCannotBeAnArray
+	ServiceEndpointStringNotValidURI           = sidetree.ServiceEndpointStringNotValidURI
+	ServiceTypeTooLong                         = sidetree.ServiceTypeTooLong
 )
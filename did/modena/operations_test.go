@@ -89,6 +89,7 @@ func TestResolver(t *testing.T) {
 		assert.Equal(tt, "did:ion:test", result.Document.ID)
 	})
 
+	// long form DIDs are resolved locally only for the Modena prefixes, such as did:quarkid:zksync, not for did:ion
 	t.Run("resolve a long form DID", func(tt *testing.T) {
 		tt.Run("bad long form DID", func(ttt *testing.T) {
 			gock.New("https://test-ion-resolution.com").
@@ -101,7 +102,7 @@ func TestResolver(t *testing.T) {
 			assert.NoError(ttt, err)
 			assert.NotEmpty(ttt, resolver)
 
-			badLongFormDID := "did:ion:Eia3aiRzeCkV7LOx3SERjjH93EXoIM3UoDyOQbbZAN4oWg:eyJRpb24iOiJyZXBsYWNlIiwiZG9jdW1lbnQiOkZWx0YSI6eyJwYXRjaGVzIjpbeyJhY3nsicHVibGljS2V5cyI6W3siaWQiOiJwdWJsaWNLZXlNb2RlbDFJZCIsInB1YmxpY0tleUp3ayI6eyJjcnYiOiJzZWNwMjU2azEiLCJrdHkiOiJFQyIsIngiOiJ0WFNLQl9ydWJYUzdzQ2pYcXVwVkpFelRjVzNNc2ptRXZxMVlwWG45NlpnIiwieSI6ImRPaWNYcWJqRnhvR0otSzAtR0oxa0hZSnFpY19EX09NdVV3a1E3T2w2bmsifSwicHVycG9zZXMiOlsiYXV0aGVudGljYXRpb24iLCJrZXlBZ3JlZW1lbnQiXSwidHlwZSI6IkVjZHNhU2VjcDI1NmsxVmVyaWZpY2F0aW9uS2V5MjAxOSJ9XSwic2VydmljZXMiOlt7ImlkIjoic2VydmljZTFJZCIsInNlcnZpY2VFbmRwb2ludCI6Imh0dHA6Ly93d3cuc2VydmljZTEuY29tIiwidHlwZSI6InNlcnZpY2UxVHlwZSJ9XX19XSwidXBkYXRlQ29tbWl0bWVudCI6IkVpREtJa3dxTzY5SVBHM3BPbEhrZGI4Nm5ZdDBhTnhTSFp1MnItYmhFem5qZEEifSwic3VmZml4RGF0YSI6eyJkZWx0YUhhc2giOiJFaUNmRFdSbllsY0Q5RUdBM2RfNVoxQUh1LWlZcU1iSjluZmlxZHo1UzhWRGJnIiwicmVjb3ZlcnlDb21taXRtZW50IjoiRWlCZk9aZE10VTZPQnc4UGs4NzlRdFotMkotOUZiYmpTWnlvYUFfYnFENHpoQSJ9fQ"
+			badLongFormDID := "did:quarkid:zksync:Eia3aiRzeCkV7LOx3SERjjH93EXoIM3UoDyOQbbZAN4oWg:eyJRpb24iOiJyZXBsYWNlIiwiZG9jdW1lbnQiOkZWx0YSI6eyJwYXRjaGVzIjpbeyJhY3nsicHVibGljS2V5cyI6W3siaWQiOiJwdWJsaWNLZXlNb2RlbDFJZCIsInB1YmxpY0tleUp3ayI6eyJjcnYiOiJzZWNwMjU2azEiLCJrdHkiOiJFQyIsIngiOiJ0WFNLQl9ydWJYUzdzQ2pYcXVwVkpFelRjVzNNc2ptRXZxMVlwWG45NlpnIiwieSI6ImRPaWNYcWJqRnhvR0otSzAtR0oxa0hZSnFpY19EX09NdVV3a1E3T2w2bmsifSwicHVycG9zZXMiOlsiYXV0aGVudGljYXRpb24iLCJrZXlBZ3JlZW1lbnQiXSwidHlwZSI6IkVjZHNhU2VjcDI1NmsxVmVyaWZpY2F0aW9uS2V5MjAxOSJ9XSwic2VydmljZXMiOlt7ImlkIjoic2VydmljZTFJZCIsInNlcnZpY2VFbmRwb2ludCI6Imh0dHA6Ly93d3cuc2VydmljZTEuY29tIiwidHlwZSI6InNlcnZpY2UxVHlwZSJ9XX19XSwidXBkYXRlQ29tbWl0bWVudCI6IkVpREtJa3dxTzY5SVBHM3BPbEhrZGI4Nm5ZdDBhTnhTSFp1MnItYmhFem5qZEEifSwic3VmZml4RGF0YSI6eyJkZWx0YUhhc2giOiJFaUNmRFdSbllsY0Q5RUdBM2RfNVoxQUh1LWlZcU1iSjluZmlxZHo1UzhWRGJnIiwicmVjb3ZlcnlDb21taXRtZW50IjoiRWlCZk9aZE10VTZPQnc4UGs4NzlRdFotMkotOUZiYmpTWnlvYUFfYnFENHpoQSJ9fQ"
 
 			result, err := resolver.Resolve(context.Background(), badLongFormDID, nil)
 			assert.Empty(ttt, result)
@@ -120,7 +121,7 @@ func TestResolver(t *testing.T) {
 			assert.NoError(ttt, err)
 			assert.NotEmpty(ttt, resolver)
 
-			longFormDID := "did:ion:EiDyOQbbZAa3aiRzeCkV7LOx3SERjjH93EXoIM3UoN4oWg:eyJkZWx0YSI6eyJwYXRjaGVzIjpbeyJhY3Rpb24iOiJyZXBsYWNlIiwiZG9jdW1lbnQiOnsicHVibGljS2V5cyI6W3siaWQiOiJwdWJsaWNLZXlNb2RlbDFJZCIsInB1YmxpY0tleUp3ayI6eyJjcnYiOiJzZWNwMjU2azEiLCJrdHkiOiJFQyIsIngiOiJ0WFNLQl9ydWJYUzdzQ2pYcXVwVkpFelRjVzNNc2ptRXZxMVlwWG45NlpnIiwieSI6ImRPaWNYcWJqRnhvR0otSzAtR0oxa0hZSnFpY19EX09NdVV3a1E3T2w2bmsifSwicHVycG9zZXMiOlsiYXV0aGVudGljYXRpb24iLCJrZXlBZ3JlZW1lbnQiXSwidHlwZSI6IkVjZHNhU2VjcDI1NmsxVmVyaWZpY2F0aW9uS2V5MjAxOSJ9XSwic2VydmljZXMiOlt7ImlkIjoic2VydmljZTFJZCIsInNlcnZpY2VFbmRwb2ludCI6Imh0dHA6Ly93d3cuc2VydmljZTEuY29tIiwidHlwZSI6InNlcnZpY2UxVHlwZSJ9XX19XSwidXBkYXRlQ29tbWl0bWVudCI6IkVpREtJa3dxTzY5SVBHM3BPbEhrZGI4Nm5ZdDBhTnhTSFp1MnItYmhFem5qZEEifSwic3VmZml4RGF0YSI6eyJkZWx0YUhhc2giOiJFaUNmRFdSbllsY0Q5RUdBM2RfNVoxQUh1LWlZcU1iSjluZmlxZHo1UzhWRGJnIiwicmVjb3ZlcnlDb21taXRtZW50IjoiRWlCZk9aZE10VTZPQnc4UGs4NzlRdFotMkotOUZiYmpTWnlvYUFfYnFENHpoQSJ9fQ"
+			longFormDID := "did:quarkid:zksync:EiB3BS5bocRZuJ05VU8vD9MCLUTFV0CVRgXh148hmsDJsw:eyJkZWx0YSI6eyJwYXRjaGVzIjpbeyJhY3Rpb24iOiJyZXBsYWNlIiwiZG9jdW1lbnQiOnsicHVibGljS2V5cyI6W3siaWQiOiJwdWJsaWNLZXlNb2RlbDFJZCIsInB1YmxpY0tleUp3ayI6eyJjcnYiOiJzZWNwMjU2azEiLCJrdHkiOiJFQyIsIngiOiJ0WFNLQl9ydWJYUzdzQ2pYcXVwVkpFelRjVzNNc2ptRXZxMVlwWG45NlpnIiwieSI6ImRPaWNYcWJqRnhvR0otSzAtR0oxa0hZSnFpY19EX09NdVV3a1E3T2w2bmsifSwicHVycG9zZXMiOlsiYXV0aGVudGljYXRpb24iLCJrZXlBZ3JlZW1lbnQiXSwidHlwZSI6IkVjZHNhU2VjcDI1NmsxVmVyaWZpY2F0aW9uS2V5MjAxOSJ9XSwic2VydmljZXMiOlt7ImlkIjoic2VydmljZTFJZCIsInNlcnZpY2VFbmRwb2ludCI6Imh0dHA6Ly93d3cuc2VydmljZTEuY29tIiwidHlwZSI6InNlcnZpY2UxVHlwZSJ9XX19XSwidXBkYXRlQ29tbWl0bWVudCI6WyJFaURLSWt3cU82OUlQRzNwT2xIa2RiODZuWXQwYU54U0hadTJyLWJoRXpuamRBIl19LCJzdWZmaXhEYXRhIjp7ImRlbHRhSGFzaCI6IkVpRFFEaFd5UUFIdjljSEh4NTNOaUgxUW9CS05CVC1CeWkyRlBwTEhrS1JVV0EiLCJyZWNvdmVyeUNvbW1pdG1lbnQiOlsiRWlCZk9aZE10VTZPQnc4UGs4NzlRdFotMkotOUZiYmpTWnlvYUFfYnFENHpoQSJdfX0"
 
 			result, err := resolver.Resolve(context.Background(), longFormDID, nil)
 			assert.NoError(ttt, err)
@@ -201,11 +202,12 @@ func TestRequests(t *testing.T) {
 
 		// check DID object
 		assert.NotEmpty(tt, ionDID.ID())
-		assert.Contains(tt, ionDID.ID(), "did:ion:")
+		// new DIDs have the first Modena method and chain as their prefix, where they used to be did:ion DIDs
+		assert.Contains(tt, ionDID.ID(), "did:quarkid:zksync:")
 		assert.Len(tt, ionDID.Operations(), 1)
-		assert.NotEmpty(tt, ionDID.updatePrivateKey)
-		assert.NotEmpty(tt, ionDID.recoveryPrivateKey)
-		assert.NotEqual(tt, ionDID.updatePrivateKey, ionDID.recoveryPrivateKey)
+		assert.NotEmpty(tt, ionDID.GetUpdatePrivateKey())
+		assert.NotEmpty(tt, ionDID.GetRecoveryPrivateKey())
+		assert.NotEqual(tt, ionDID.GetUpdatePrivateKey(), ionDID.GetRecoveryPrivateKey())
 
 		// try to decode long form DID
 		decoded, initialState, err := DecodeLongFormDID(ionDID.LongForm())
@@ -276,7 +278,7 @@ func TestRequests(t *testing.T) {
 		assert.NotEmpty(tt, updateOp.SignedData)
 
 		// make sure keys are different and op is added
-		assert.NotEqual(tt, ionDID.updatePrivateKey, updatedDID.updatePrivateKey)
+		assert.NotEqual(tt, ionDID.GetUpdatePrivateKey(), updatedDID.GetUpdatePrivateKey())
 		assert.Len(tt, ionDID.Operations(), 1)
 		assert.Len(tt, updatedDID.Operations(), 2)
 	})
@@ -328,8 +330,8 @@ func TestRequests(t *testing.T) {
 		assert.NotEmpty(tt, recoverOp.SignedData)
 
 		// make sure keys are different and op is added
-		assert.NotEqual(tt, ionDID.updatePrivateKey, recoveredDID.updatePrivateKey)
-		assert.NotEqual(tt, ionDID.recoveryPrivateKey, recoveredDID.recoveryPrivateKey)
+		assert.NotEqual(tt, ionDID.GetUpdatePrivateKey(), recoveredDID.GetUpdatePrivateKey())
+		assert.NotEqual(tt, ionDID.GetRecoveryPrivateKey(), recoveredDID.GetRecoveryPrivateKey())
 		assert.Len(tt, ionDID.Operations(), 1)
 		assert.Len(tt, recoveredDID.Operations(), 2)
 	})
@@ -1,185 +1,50 @@
 package modena
 
 import (
-	"bytes"
 	"context"
-	"fmt"
-	"io"
 	"net/http"
-	"net/url"
-	"strings"
 
 	"github.com/extrimian/ssi-sdk/did"
 	"github.com/extrimian/ssi-sdk/did/resolution"
-	"github.com/goccy/go-json"
-	"github.com/pkg/errors"
-	"github.com/sirupsen/logrus"
+	"github.com/extrimian/ssi-sdk/did/sidetree"
 )
 
-// LocalResolver is a resolver that can resolve long form ION DIDs
+// LocalResolver is a resolver that can resolve long form Modena DIDs
 type LocalResolver struct{}
 
-func (LocalResolver) Resolve(_ context.Context, id string, _ ...resolution.Option) (*resolution.Result, error) {
-	if id == "" {
-		return nil, errors.New("id cannot be empty")
-	}
-	if !IsLongFormDID(id) {
-		return nil, errors.New("id is not a long form DID")
-	}
-	shortFormDID, initialState, err := DecodeLongFormDID(id)
-	if err != nil {
-		return nil, errors.Wrap(err, "invalid long form DID")
-	}
-	didDoc, err := PatchesToDIDDocument(shortFormDID, id, initialState.Delta.Patches)
+func (LocalResolver) Resolve(ctx context.Context, id string, opts ...resolution.Option) (*resolution.Result, error) {
+	r, err := sidetree.NewLocalResolver[[]string](Protocol)
 	if err != nil {
-		return nil, errors.Wrap(err, "reconstructing document from long form DID")
+		return nil, err
 	}
-	return &resolution.Result{
-		Context:  "https://w3id.org/did-resolution/v1",
-		Document: *didDoc,
-		DocumentMetadata: &resolution.DocumentMetadata{
-			EquivalentID: []string{shortFormDID},
-			Method: resolution.Method{
-				Published:          false,
-				RecoveryCommitment: initialState.SuffixData.RecoveryCommitment,
-				UpdateCommitment:   initialState.Delta.UpdateCommitment},
-		}}, nil
+	return r.Resolve(ctx, id, opts...)
 }
 
 func (LocalResolver) Methods() []did.Method {
-	return []did.Method{did.IONMethod}
+	return Protocol.Methods()
 }
 
 var _ resolution.Resolver = (*LocalResolver)(nil)
 
-type Resolver struct {
-	client  *http.Client
-	baseURL url.URL
-}
-
-var _ resolution.Resolver = (*Resolver)(nil)
+type Resolver = sidetree.Resolver[[]string]
 
-// NewModenaResolver creates a new resolution for the ION DID method with a common base URL
-// The base URL is the URL of the ION node, for example: https://ion.tbd.network
+// NewModenaResolver creates a new resolution for the Modena DID methods with a common base URL
+// The base URL is the URL of the Modena node, for example: https://node.quarkid.org
 // The resolution will append the DID to the base URL to resolve the DID such as
 //
-//	https://ion.tbd.network/identifiers/did:ion:1234
+//	https://node.quarkid.org/identifiers/did:quarkid:zksync:1234
 //
-// and similarly for submitting anchor operations to the ION node...
+// and similarly for submitting anchor operations to the Modena node...
 //
-//	https://ion.tbd.network/operations
+//	https://node.quarkid.org/operations
 func NewModenaResolver(client *http.Client, baseURL string) (*Resolver, error) {
-	if client == nil {
-		return nil, errors.New("client cannot be nil")
-	}
-	parsedURL, err := url.ParseRequestURI(baseURL)
-	if err != nil {
-		return nil, errors.Wrap(err, "invalid resolution URL")
-	}
-	if parsedURL.Scheme != "https" {
-		return nil, errors.New("invalid resolution URL scheme; must use https")
-	}
-	return &Resolver{
-		client:  client,
-		baseURL: *parsedURL,
-	}, nil
+	return sidetree.NewResolver[[]string](Protocol, client, baseURL)
 }
 
-// Resolve resolves a did:ion DID by appending the DID to the base URL with the identifiers path and making a GET request
-func (i Resolver) Resolve(ctx context.Context, id string, _ ...resolution.Option) (*resolution.Result, error) {
-	// first attempt to decode as a long form DID, if we get an error, continue
-	if id == "" {
-		return nil, errors.New("id cannot be empty")
-	}
-	if IsLongFormDID(id) {
-		shortFormDID, initialState, err := DecodeLongFormDID(id)
-		if err != nil {
-			return nil, errors.Wrap(err, "invalid long form DID")
-		}
-		didDoc, err := PatchesToDIDDocument(shortFormDID, id, initialState.Delta.Patches)
-		if err != nil {
-			return nil, errors.Wrap(err, "reconstructing document from long form DID")
-		}
-		return &resolution.Result{
-			Context:  "https://w3id.org/did-resolution/v1",
-			Document: *didDoc,
-			DocumentMetadata: &resolution.DocumentMetadata{
-				EquivalentID: []string{shortFormDID},
-				Method: resolution.Method{
-					Published:          false,
-					RecoveryCommitment: initialState.SuffixData.RecoveryCommitment,
-					UpdateCommitment:   initialState.Delta.UpdateCommitment},
-			}}, nil
-	}
-
-	if i.baseURL.String() == "" {
-		return nil, errors.New("resolution URL cannot be empty")
-	}
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, strings.Join([]string{i.baseURL.String(), "identifiers", id}, "/"), nil)
-	if err != nil {
-		return nil, errors.Wrap(err, "creating request")
-	}
-	resp, err := i.client.Do(req)
-	if err != nil {
-		return nil, errors.Wrapf(err, "resolving, with URL: %s", i.baseURL.String())
-	}
-
-	defer func() {
-		_ = resp.Body.Close()
-	}()
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, errors.Wrapf(err, "resolving, with response %+v", resp)
-	}
-	if !is2xxStatusCode(resp.StatusCode) {
-		return nil, fmt.Errorf("could not resolve DID: %q", string(body))
-	}
-	resolutionResult, err := resolution.ParseDIDResolution(body)
-	if err != nil {
-		return nil, errors.Wrapf(err, "resolving did:ion DID<%s>", id)
-	}
-	return resolutionResult, nil
-}
-
-// Anchor submits an anchor operation to the ION node by appending the operations path to the base URL
-// and making a POST request
-func (i Resolver) Anchor(ctx context.Context, op AnchorOperation) (*resolution.Result, error) {
-	if i.baseURL.String() == "" {
-		return nil, errors.New("resolution URL cannot be empty")
-	}
-	jsonOpBytes, err := json.Marshal(op)
-	if err != nil {
-		return nil, errors.Wrapf(err, "marshalling anchor operation %+v", op)
-	}
-
-	req, err := http.NewRequestWithContext(ctx, http.MethodPost, strings.Join([]string{i.baseURL.String(), "operations"}, "/"), bytes.NewReader(jsonOpBytes))
-	if err != nil {
-		return nil, errors.Wrap(err, "creating request")
-	}
-	resp, err := i.client.Do(req)
-	if err != nil {
-		return nil, errors.Wrapf(err, "posting anchor operation %+v", op)
-	}
-
-	defer func() {
-		_ = resp.Body.Close()
-	}()
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, errors.Wrapf(err, "could not resolve with response %+v", resp)
-	}
-	if !is2xxStatusCode(resp.StatusCode) {
-		return nil, fmt.Errorf("anchor operation failed: %s", string(body))
-	}
-	logrus.Infof("successfully anchored operation: %s", string(body))
-
-	var resolutionResult resolution.Result
-	if err := json.Unmarshal(body, &resolutionResult); err != nil {
-		return nil, errors.Wrap(err, "unmarshalling anchor response")
-	}
-	return &resolutionResult, nil
-}
+// Node is an in-process Modena node for testing operations and resolution offline
+type Node = sidetree.Node[[]string]
 
-func (Resolver) Methods() []did.Method {
-	return []did.Method{did.IONMethod}
+// NewNode creates a Modena node without any DIDs, to be served with an httptest.Server
+func NewNode() (*Node, error) {
+	return sidetree.NewNode[[]string](Protocol)
 }
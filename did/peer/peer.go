@@ -9,7 +9,7 @@
 // that peer-to-peer relationships in every blockchain ecosystem can benefit by offloading pairwise and n-wise
 // relationships to peer DIDs.
 //
-// Currently only methods 0 and 2 are supported. Method 1 will be supported in a future date.
+// Methods 0, 1, 2 and 4 are supported.
 package peer
 
 import (
@@ -26,7 +26,6 @@ import (
 
 	"github.com/extrimian/ssi-sdk/crypto"
 	"github.com/extrimian/ssi-sdk/did"
-	"github.com/extrimian/ssi-sdk/did/resolution"
 	"github.com/extrimian/ssi-sdk/util"
 )
 
@@ -40,7 +39,7 @@ type (
 const (
 	DIDPeerPrefix               = "did:peer"
 	EncNumBasis                 = did.Base58BTCMultiBase
-	DIDRegex                    = `^did:peer:(([01](z)([1-9a-km-zA-HJ-NP-Z]{46,47}))|(2((\.[AEVID](z)([1-9a-km-zA-HJ-NP-Z]{46,47}))+(\.(S)[0-9a-zA-Z=]*)?)))$`
+	DIDRegex                    = `^did:peer:(([01](z)([1-9a-km-zA-HJ-NP-Z]{46,47}))|(2((\.[AEVID](z)([1-9a-km-zA-HJ-NP-Z]{46,}))+(\.(S)[0-9a-zA-Z_\-=]*)*))|(4(z)([1-9a-km-zA-HJ-NP-Z]{46,})(:(z)([1-9a-km-zA-HJ-NP-Z]+))?))$`
 	KnownContext                = "https://w3id.org/did/v1"
 	DIDCommMessagingAbbr string = "dm"
 	DIDCommMessaging     string = "DIDCommMessaging"
@@ -102,9 +101,11 @@ func (d DIDPeer) Suffix() (string, error) {
 	case "0":
 		index = 1
 	case "1":
-		return "", errors.Wrap(util.NotImplementedError, "parsing method 1")
+		index = 1
 	case "2":
 		index = 2
+	case "4":
+		index = 1
 	}
 	return s[index:], nil
 }
@@ -117,25 +118,6 @@ func buildDIDPeerFromEncoded(method int, encoded string) DIDPeer {
 	return DIDPeer(fmt.Sprintf("%s:%d%s", DIDPeerPrefix, method, encoded))
 }
 
-type byValue struct {
-	Key       string `json:"key"`
-	Signature string `json:"sig"`
-}
-
-// PeerDelta https://identity.foundation/peer-did-method-spec/#backing-storage
-type PeerDelta struct {
-	Change string    `json:"change"` // <base64url encoding of a change fragment>,
-	By     []byValue `json:"by"`     //  [ {"key": <id of key>, "sig": <signature value>} ... ],
-	When   int64     `json:"when"`   // <ISO8601/RFC3339 UTC timestamp with at least second precision>
-}
-
-func (DIDPeer) Delta(_ DIDPeer) (*PeerDelta, error) {
-	return nil, errors.Wrap(util.NotImplementedError, "peer:did delta")
-}
-
-// TODO: CRDT https://github.com/extrimian/ssi-sdk/issues/138
-// https://identity.foundation/peer-did-method-spec/#crdts
-
 // Generates the key by types
 func (DIDPeer) generateKeyByType(kt crypto.KeyType) (gocrypto.PublicKey, gocrypto.PrivateKey, error) {
 	if !IsSupportedDIDPeerType(kt) {
@@ -152,13 +134,6 @@ func (DIDPeer) IsValidPurpose(p PurposeType) bool {
 	return false
 }
 
-func (Method1) resolve(d did.DID, _ resolution.Option) (*resolution.Result, error) {
-	if _, ok := d.(DIDPeer); !ok {
-		return nil, errors.Wrap(util.CastingError, DIDPeerPrefix)
-	}
-	return nil, util.NotImplementedError
-}
-
 func (DIDPeer) buildVerificationMethod(data, id string) (*did.VerificationMethod, error) {
 	_, keyType, err := did.DecodeMultibasePublicKeyWithType([]byte(data))
 	if err != nil {
@@ -176,10 +151,21 @@ func (DIDPeer) buildVerificationMethod(data, id string) (*did.VerificationMethod
 
 // ServiceBlockEncoded Remaps the service block for encoding
 type ServiceBlockEncoded struct {
+	ID              string   `json:"id,omitempty"`
 	ServiceType     string   `json:"t"`
-	ServiceEndpoint string   `json:"s"`
-	RoutingKeys     []string `json:"r"`
-	Accept          []string `json:"a"`
+	ServiceEndpoint any      `json:"s"`
+	RoutingKeys     []string `json:"r,omitempty"`
+	Accept          []string `json:"a,omitempty"`
+}
+
+// serviceAbbreviations are the common strings replaced when encoding a service
+// https://identity.foundation/peer-did-method-spec/#method-2-multiple-inception-key-without-doc
+var serviceAbbreviations = map[string]string{
+	"type":            "t",
+	"serviceEndpoint": "s",
+	"routingKeys":     "r",
+	"accept":          "a",
+	DIDCommMessaging:  DIDCommMessagingAbbr,
 }
 
 // Start with the JSON structure for your service.
@@ -193,8 +179,9 @@ func (DIDPeer) encodeService(p did.Service) (string, error) {
 	}
 
 	serviceBlock := ServiceBlockEncoded{
+		ID:              p.ID,
 		ServiceType:     p.Type,
-		ServiceEndpoint: p.ServiceEndpoint.(string),
+		ServiceEndpoint: abbreviateServiceEndpoint(p.ServiceEndpoint, true),
 		RoutingKeys:     p.RoutingKeys,
 		Accept:          p.Accept,
 	}
@@ -207,12 +194,45 @@ func (DIDPeer) encodeService(p did.Service) (string, error) {
 	if err != nil {
 		return "", err
 	}
-	return b64.RawURLEncoding.EncodeToString([]byte(string(dat))), nil
+	return b64.RawURLEncoding.EncodeToString(dat), nil
+}
+
+// abbreviateServiceEndpoint replaces the keys of map service endpoints, such as the DIDComm v2
+// {"uri", "accept", "routingKeys"} form, with their abbreviations, or expands them again
+func abbreviateServiceEndpoint(endpoint any, abbreviate bool) any {
+	switch e := endpoint.(type) {
+	case map[string]any:
+		mapped := make(map[string]any, len(e))
+		for k, v := range e {
+			mapped[abbreviateServiceKey(k, abbreviate)] = v
+		}
+		return mapped
+	case []any:
+		mapped := make([]any, 0, len(e))
+		for _, v := range e {
+			mapped = append(mapped, abbreviateServiceEndpoint(v, abbreviate))
+		}
+		return mapped
+	default:
+		return endpoint
+	}
+}
+
+func abbreviateServiceKey(key string, abbreviate bool) string {
+	for full, abbr := range serviceAbbreviations {
+		if abbreviate && key == full {
+			return abbr
+		}
+		if !abbreviate && key == abbr {
+			return full
+		}
+	}
+	return key
 }
 
 // Checks if the service block is valid
 func (DIDPeer) checkValidPeerServiceBlock(s string) bool {
-	if s[:2] != "."+string(PurposeCapabilityServiceCode) {
+	if len(s) < 2 || s[:2] != "."+string(PurposeCapabilityServiceCode) {
 		return false
 	}
 	return true
@@ -226,15 +246,8 @@ func (d DIDPeer) decodeServiceBlock(s string) (*did.Service, error) {
 		return nil, errors.New("invalid string provided")
 	}
 
-	s2 := s[2:]
-
-	// Remove the padding if present.
-	padding := b64.NoPadding
-	if s2[len(s2)-1] == '=' {
-		padding = b64.StdPadding
-	}
-	encoder := b64.RawURLEncoding.WithPadding(padding)
-	decoded, err := encoder.DecodeString(s2)
+	// Remove the padding if present, which older implementations added.
+	decoded, err := b64.RawURLEncoding.DecodeString(strings.TrimRight(s[2:], "="))
 	if err != nil {
 		return nil, errors.Wrap(err, "decoding service for did:peer")
 	}
@@ -244,8 +257,9 @@ func (d DIDPeer) decodeServiceBlock(s string) (*did.Service, error) {
 		return nil, errors.Wrap(err, "decoding JSON for peer service block")
 	}
 	serviceBlock := did.Service{
+		ID:              psbe.ID,
 		Type:            psbe.ServiceType,
-		ServiceEndpoint: psbe.ServiceEndpoint,
+		ServiceEndpoint: abbreviateServiceEndpoint(psbe.ServiceEndpoint, false),
 		RoutingKeys:     psbe.RoutingKeys,
 		Accept:          psbe.Accept,
 	}
@@ -274,10 +288,13 @@ func peerMethodAvailable(m string) bool {
 		return true
 	case "1":
 		// Method1
-		return false
+		return true
 	case "2":
 		// Method2
 		return true
+	case "4":
+		// Method4
+		return true
 	default:
 		return false
 	}
@@ -1,17 +1,47 @@
 package peer
 
 import (
+	"context"
+	gocrypto "crypto"
+	"crypto/sha256"
+	b64 "encoding/base64"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/goccy/go-json"
+	"github.com/lestrrat-go/jwx/v2/jws"
+	"github.com/multiformats/go-multibase"
+	"github.com/multiformats/go-multihash"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+
+	"github.com/extrimian/ssi-sdk/crypto/jwx"
 	"github.com/extrimian/ssi-sdk/did"
+	"github.com/extrimian/ssi-sdk/did/resolution"
 	"github.com/extrimian/ssi-sdk/util"
 )
 
 // Method1 Method 1: genesis doc
-type Method1 struct{}
+type Method1 struct {
+	// Genesis is the stored variant of the genesis version of the DID Document. It must not have an id.
+	Genesis did.Document
+}
 
 func (Method1) Method() did.Method {
 	return did.PeerMethod
 }
 
+// GenesisBytes returns the bytes of the stored variant of the genesis document, which the DID is the hash of. These
+// bytes must be kept in a PeerStore for the DID to be resolved.
+func (m Method1) GenesisBytes() ([]byte, error) {
+	if m.Genesis.ID != "" {
+		return nil, errors.New("genesis document for did:peer:1 must not have an id")
+	}
+	return json.Marshal(m.Genesis)
+}
+
 // Generate https://identity.foundation/peer-did-method-spec/#generation-method
 // Creates a genesis version of JSON text of the DID doc for the DID. This inception key is the key that creates the
 // DID and authenticates when exchanging it with the first peer CANNOT include the DID itself This lets the doc be
@@ -21,8 +51,516 @@ func (Method1) Method() did.Method {
 // relative reference rather than an absolute value. For example, each controller property of a verificationMethod
 // that is owned by this DID would say "controller": "#id".). Calculate the SHA256 [RFC4634] hash of the bytes of
 // the stored variant of the genesis version of the DID doc, and make this value the new DID's numeric basis.
-func (Method1) Generate() (*DIDPeer, error) {
-	// Create a Genesis Version
-	// TODO support method 1 https://github.com/extrimian/ssi-sdk/issues/137
-	return nil, util.NotImplementedError
+func (m Method1) Generate() (*DIDPeer, error) {
+	genesis, err := m.GenesisBytes()
+	if err != nil {
+		return nil, errors.Wrap(err, "encoding genesis document")
+	}
+	numericBasis, err := numericBasisForGenesis(genesis)
+	if err != nil {
+		return nil, err
+	}
+	didPeer := buildDIDPeerFromEncoded(1, numericBasis)
+	return &didPeer, nil
+}
+
+func numericBasisForGenesis(genesis []byte) (string, error) {
+	mh, err := multihash.Sum(genesis, multihash.SHA2_256, -1)
+	if err != nil {
+		return "", errors.Wrap(err, "hashing genesis document")
+	}
+	return multibase.Encode(EncNumBasis, mh)
+}
+
+func (Method1) resolve(ctx context.Context, d did.DID, store PeerStore) (*resolution.Result, error) {
+	didPeer, ok := d.(DIDPeer)
+	if !ok {
+		return nil, errors.Wrap(util.CastingError, DIDPeerPrefix)
+	}
+	if store == nil {
+		return nil, resolution.NewResolutionErrorf(resolution.NotFoundErrorCode, "no genesis document known for: %s", didPeer)
+	}
+	genesisBytes, err := store.GetGenesis(ctx, didPeer)
+	if err != nil {
+		return nil, err
+	}
+	var genesis did.Document
+	if err = json.Unmarshal(genesisBytes, &genesis); err != nil {
+		return nil, errors.Wrap(err, "unmarshalling genesis document")
+	}
+	deltas, err := store.GetDeltas(ctx, didPeer)
+	if err != nil {
+		return nil, errors.Wrap(err, "getting deltas")
+	}
+	doc, metadata := applyDeltas(contextualize(genesis, string(didPeer), ""), deltas)
+	return &resolution.Result{Document: doc, DocumentMetadata: metadata}, nil
+}
+
+// PeerChangeAction is the kind of change a PeerChange makes to a DID Document
+type PeerChangeAction string
+
+const (
+	AddVerificationMethodAction    PeerChangeAction = "add-verification-method"
+	RemoveVerificationMethodAction PeerChangeAction = "remove-verification-method"
+	AddServiceAction               PeerChangeAction = "add-service"
+	RemoveServiceAction            PeerChangeAction = "remove-service"
+)
+
+// PeerChange is a change fragment, the content of a PeerDelta
+// https://identity.foundation/peer-did-method-spec/#backing-storage
+type PeerChange struct {
+	Action PeerChangeAction `json:"action"`
+	// VerificationMethod to add, with the purposes deciding the verification relationships it is referenced from
+	VerificationMethod *did.VerificationMethod `json:"verificationMethod,omitempty"`
+	Purposes           []PurposeType           `json:"purposes,omitempty"`
+	// Service to add
+	Service *did.Service `json:"service,omitempty"`
+	// ID of the verification method or service to remove
+	ID string `json:"id,omitempty"`
+}
+
+type byValue struct {
+	Key       string `json:"key"`
+	Signature string `json:"sig"`
+}
+
+// PeerDelta https://identity.foundation/peer-did-method-spec/#backing-storage
+// Each signature is a compact JWS over a deltaPayload binding the change to the time of the delta, so the time a
+// delta is ordered by cannot be changed without invalidating its signatures. When repeats the signed time.
+type PeerDelta struct {
+	Change string    `json:"change"` // <base64url encoding of a change fragment>,
+	By     []byValue `json:"by"`     //  [ {"key": <id of key>, "sig": <signature value>} ... ],
+	When   string    `json:"when"`   // <ISO8601/RFC3339 UTC timestamp with at least second precision>
+}
+
+// deltaPayload is the payload signed by the signers of a delta
+type deltaPayload struct {
+	Change string `json:"change"`
+	When   string `json:"when"`
+}
+
+// Delta creates a delta for the DID from a change, signed by a key authorized in the current DID Document. The
+// signature is a compact JWS over the encoded change fragment and the current time, and the signer's key ID
+// identifies the key.
+func (d DIDPeer) Delta(change PeerChange, signer *jwx.Signer) (*PeerDelta, error) {
+	return d.delta(change, signer, time.Now())
+}
+
+func (d DIDPeer) delta(change PeerChange, signer *jwx.Signer, when time.Time) (*PeerDelta, error) {
+	if signer == nil {
+		return nil, errors.New("signer cannot be empty")
+	}
+	if err := change.validate(); err != nil {
+		return nil, err
+	}
+	changeBytes, err := json.Marshal(change)
+	if err != nil {
+		return nil, errors.Wrap(err, "marshalling change")
+	}
+	payload := deltaPayload{
+		Change: b64.RawURLEncoding.EncodeToString(changeBytes),
+		When:   when.UTC().Format(time.RFC3339Nano),
+	}
+	payloadBytes, err := json.Marshal(payload)
+	if err != nil {
+		return nil, errors.Wrap(err, "marshalling delta payload")
+	}
+	signature, err := signer.SignJWS(payloadBytes)
+	if err != nil {
+		return nil, errors.Wrapf(err, "signing delta for %s", d)
+	}
+	return &PeerDelta{
+		Change: payload.Change,
+		By:     []byValue{{Key: signer.KID, Signature: string(signature)}},
+		When:   payload.When,
+	}, nil
+}
+
+// hash uniquely identifies a delta by its change and signatures, and breaks ties between deltas made at the same
+// time. The time of a delta is part of what is signed, so copies of a delta differing in their When are the same.
+func (pd PeerDelta) hash() string {
+	signatures := make([]string, 0, len(pd.By))
+	for _, by := range pd.By {
+		signatures = append(signatures, by.Signature)
+	}
+	sort.Strings(signatures)
+	h := sha256.New()
+	h.Write([]byte(pd.Change))
+	for _, signature := range signatures {
+		h.Write([]byte{'.'})
+		h.Write([]byte(signature))
+	}
+	return b64.RawURLEncoding.EncodeToString(h.Sum(nil))
+}
+
+// signedPayload returns the payload signed by the first signer of a delta, without verifying the signature. Every
+// signature is checked against this payload when the delta is verified.
+func (pd PeerDelta) signedPayload() (*deltaPayload, time.Time, error) {
+	if len(pd.By) == 0 {
+		return nil, time.Time{}, errors.New("delta is not signed")
+	}
+	msg, err := jws.Parse([]byte(pd.By[0].Signature))
+	if err != nil {
+		return nil, time.Time{}, errors.Wrap(err, "parsing signature")
+	}
+	var payload deltaPayload
+	if err = json.Unmarshal(msg.Payload(), &payload); err != nil {
+		return nil, time.Time{}, errors.Wrap(err, "unmarshalling signed payload")
+	}
+	if payload.Change != pd.Change {
+		return nil, time.Time{}, errors.New("signature is not over the change")
+	}
+	when, err := time.Parse(time.RFC3339Nano, payload.When)
+	if err != nil {
+		return nil, time.Time{}, errors.Wrap(err, "parsing signed time")
+	}
+	return &payload, when, nil
+}
+
+func (c PeerChange) validate() error {
+	switch c.Action {
+	case AddVerificationMethodAction:
+		if c.VerificationMethod == nil || c.VerificationMethod.ID == "" {
+			return errors.New("verification method with an id is required")
+		}
+		for _, purpose := range c.Purposes {
+			if purpose == PurposeCapabilityServiceCode || !DIDPeer("").IsValidPurpose(purpose) {
+				return fmt.Errorf("invalid purpose: %s", purpose)
+			}
+		}
+	case AddServiceAction:
+		if c.Service == nil || !c.Service.IsValid() {
+			return errors.New("valid service is required")
+		}
+	case RemoveVerificationMethodAction, RemoveServiceAction:
+		if c.ID == "" {
+			return errors.New("id is required")
+		}
+	default:
+		return fmt.Errorf("unknown change action: %s", c.Action)
+	}
+	return nil
+}
+
+// applyDeltas replays deltas on top of the genesis document. The deltas form a grow-only set, a CRDT, and are applied
+// in an order derived from the deltas alone: by signed time, with ties broken by hash. Each delta is checked against
+// the document it applies to, so every peer holding the same set of deltas arrives at the same document regardless
+// of the order the deltas were delivered in. Deltas that are not authorized or cannot be applied are skipped.
+func applyDeltas(doc did.Document, deltas []PeerDelta) (did.Document, *resolution.DocumentMetadata) {
+	type orderedDelta struct {
+		PeerDelta
+		payload deltaPayload
+		when    time.Time
+		hash    string
+	}
+	ordered := make([]orderedDelta, 0, len(deltas))
+	seen := make(map[string]bool, len(deltas))
+	for _, delta := range deltas {
+		hash := delta.hash()
+		if seen[hash] {
+			continue
+		}
+		payload, when, err := delta.signedPayload()
+		if err != nil {
+			logrus.WithError(err).Warnf("skipping delta with invalid signed payload for %s", doc.ID)
+			continue
+		}
+		seen[hash] = true
+		ordered = append(ordered, orderedDelta{PeerDelta: delta, payload: *payload, when: when, hash: hash})
+	}
+	sort.Slice(ordered, func(i, j int) bool {
+		if !ordered[i].when.Equal(ordered[j].when) {
+			return ordered[i].when.Before(ordered[j].when)
+		}
+		return ordered[i].hash < ordered[j].hash
+	})
+
+	metadata := new(resolution.DocumentMetadata)
+	for _, delta := range ordered {
+		change, err := verifyDelta(doc, delta.PeerDelta, delta.payload)
+		if err != nil {
+			logrus.WithError(err).Warnf("skipping delta for %s", doc.ID)
+			continue
+		}
+		updated, err := applyChange(doc, *change)
+		if err != nil {
+			logrus.WithError(err).Warnf("skipping delta for %s", doc.ID)
+			continue
+		}
+		doc = updated
+		metadata.Updated = util.AsRFC3339Timestamp(delta.when)
+	}
+	return doc, metadata
+}
+
+// verifyDelta checks that a delta is signed by a key authorized in the document, and decodes its change. Keys
+// referenced by capabilityInvocation are authorized, or those referenced by authentication if there are none. Every
+// signature must be over the given payload.
+func verifyDelta(doc did.Document, delta PeerDelta, payload deltaPayload) (*PeerChange, error) {
+	authorized := doc.CapabilityInvocation
+	if len(authorized) == 0 {
+		authorized = doc.Authentication
+	}
+	var verified bool
+	for _, by := range delta.By {
+		if !isReferenced(doc, authorized, by.Key) {
+			continue
+		}
+		if err := verifyDeltaSignature(doc, by, payload); err != nil {
+			return nil, err
+		}
+		verified = true
+	}
+	if !verified {
+		return nil, errors.New("delta is not signed by an authorized key")
+	}
+
+	changeBytes, err := b64.RawURLEncoding.DecodeString(delta.Change)
+	if err != nil {
+		return nil, errors.Wrap(err, "decoding change")
+	}
+	var change PeerChange
+	if err = json.Unmarshal(changeBytes, &change); err != nil {
+		return nil, errors.Wrap(err, "unmarshalling change")
+	}
+	if err = change.validate(); err != nil {
+		return nil, err
+	}
+	return &change, nil
+}
+
+func verifyDeltaSignature(doc did.Document, by byValue, payload deltaPayload) error {
+	key, err := findVerificationMethodKey(doc, by.Key)
+	if err != nil {
+		return errors.Wrapf(err, "getting key: %s", by.Key)
+	}
+	verifier, err := jwx.NewJWXVerifier(doc.ID, by.Key, key)
+	if err != nil {
+		return errors.Wrap(err, "creating verifier")
+	}
+	if err = verifier.VerifyJWS(by.Signature); err != nil {
+		return errors.Wrapf(err, "verifying signature of key: %s", by.Key)
+	}
+	msg, err := jws.Parse([]byte(by.Signature))
+	if err != nil {
+		return errors.Wrap(err, "parsing signature")
+	}
+	var signed deltaPayload
+	if err = json.Unmarshal(msg.Payload(), &signed); err != nil {
+		return errors.Wrap(err, "unmarshalling signed payload")
+	}
+	if signed != payload {
+		return errors.New("signature is not over the change and time of the delta")
+	}
+	return nil
+}
+
+// findVerificationMethodKey finds the public key of a verification method, which may be embedded in a relationship
+func findVerificationMethodKey(doc did.Document, kid string) (gocrypto.PublicKey, error) {
+	if key, err := did.GetKeyFromVerificationMethod(doc, kid); err == nil {
+		return key, nil
+	}
+	relationships := [][]did.VerificationMethodSet{doc.Authentication, doc.AssertionMethod, doc.KeyAgreement,
+		doc.CapabilityInvocation, doc.CapabilityDelegation}
+	for _, relationship := range relationships {
+		for _, set := range relationship {
+			if vm, ok := set.(did.VerificationMethod); ok && sameID(doc.ID, vm.ID, kid) {
+				return did.GetKeyFromVerificationMethod(did.Document{ID: doc.ID, VerificationMethod: []did.VerificationMethod{vm}}, kid)
+			}
+		}
+	}
+	return nil, fmt.Errorf("no verification method found with id: %s", kid)
+}
+
+// isReferenced returns true if the verification method id appears in the verification relationship
+func isReferenced(doc did.Document, relationship []did.VerificationMethodSet, id string) bool {
+	for _, set := range relationship {
+		switch v := set.(type) {
+		case string:
+			if sameID(doc.ID, v, id) {
+				return true
+			}
+		case did.VerificationMethod:
+			if sameID(doc.ID, v.ID, id) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func sameID(docID, a, b string) bool {
+	return did.FullyQualifiedVerificationMethodID(docID, a) == did.FullyQualifiedVerificationMethodID(docID, b)
+}
+
+func applyChange(doc did.Document, change PeerChange) (did.Document, error) {
+	switch change.Action {
+	case AddVerificationMethodAction:
+		vm := *change.VerificationMethod
+		for _, existing := range doc.VerificationMethod {
+			if sameID(doc.ID, existing.ID, vm.ID) {
+				return doc, fmt.Errorf("verification method already exists: %s", vm.ID)
+			}
+		}
+		if vm.Controller == "" {
+			vm.Controller = doc.ID
+		}
+		doc.VerificationMethod = append(append([]did.VerificationMethod{}, doc.VerificationMethod...), vm)
+		for _, purpose := range change.Purposes {
+			relationship := relationshipForPurpose(&doc, purpose)
+			*relationship = append(append([]did.VerificationMethodSet{}, *relationship...), vm.ID)
+		}
+	case RemoveVerificationMethodAction:
+		var vms []did.VerificationMethod
+		for _, vm := range doc.VerificationMethod {
+			if !sameID(doc.ID, vm.ID, change.ID) {
+				vms = append(vms, vm)
+			}
+		}
+		if len(vms) == len(doc.VerificationMethod) && !isReferencedAnywhere(doc, change.ID) {
+			return doc, fmt.Errorf("no verification method found with id: %s", change.ID)
+		}
+		doc.VerificationMethod = vms
+		for _, purpose := range []PurposeType{PurposeAssertionCode, PurposeEncryptionCode, PurposeVerificationCode,
+			PurposeCapabilityInvocationCode, PurposeCapabilityDelegationCode} {
+			relationship := relationshipForPurpose(&doc, purpose)
+			var remaining []did.VerificationMethodSet
+			for _, set := range *relationship {
+				if !isReferenced(doc, []did.VerificationMethodSet{set}, change.ID) {
+					remaining = append(remaining, set)
+				}
+			}
+			*relationship = remaining
+		}
+	case AddServiceAction:
+		for _, service := range doc.Services {
+			if sameID(doc.ID, service.ID, change.Service.ID) {
+				return doc, fmt.Errorf("service already exists: %s", change.Service.ID)
+			}
+		}
+		doc.Services = append(append([]did.Service{}, doc.Services...), *change.Service)
+	case RemoveServiceAction:
+		var services []did.Service
+		for _, service := range doc.Services {
+			if !sameID(doc.ID, service.ID, change.ID) {
+				services = append(services, service)
+			}
+		}
+		if len(services) == len(doc.Services) {
+			return doc, fmt.Errorf("no service found with id: %s", change.ID)
+		}
+		doc.Services = services
+	}
+	return doc, nil
+}
+
+func isReferencedAnywhere(doc did.Document, id string) bool {
+	for _, relationship := range [][]did.VerificationMethodSet{doc.Authentication, doc.AssertionMethod,
+		doc.KeyAgreement, doc.CapabilityInvocation, doc.CapabilityDelegation} {
+		if isReferenced(doc, relationship, id) {
+			return true
+		}
+	}
+	return false
+}
+
+func relationshipForPurpose(doc *did.Document, purpose PurposeType) *did.VerificationRelationship {
+	switch purpose {
+	case PurposeAssertionCode:
+		return &doc.AssertionMethod
+	case PurposeEncryptionCode:
+		return &doc.KeyAgreement
+	case PurposeCapabilityInvocationCode:
+		return &doc.CapabilityInvocation
+	case PurposeCapabilityDelegationCode:
+		return &doc.CapabilityDelegation
+	default:
+		return &doc.Authentication
+	}
+}
+
+// PeerStore is the backing storage of did:peer:1 DIDs, holding the genesis document of each DID and the set of
+// deltas received for it https://identity.foundation/peer-did-method-spec/#backing-storage
+type PeerStore interface {
+	// PutGenesis stores the genesis document bytes of a DID, which must hash to the DID's numeric basis
+	PutGenesis(ctx context.Context, id DIDPeer, genesis []byte) error
+	// GetGenesis returns the genesis document bytes of a DID, or a notFound resolution error
+	GetGenesis(ctx context.Context, id DIDPeer) ([]byte, error)
+	// AddDeltas merges deltas into the set of deltas of a DID. Adding a delta more than once has no effect.
+	AddDeltas(ctx context.Context, id DIDPeer, deltas ...PeerDelta) error
+	// GetDeltas returns all deltas of a DID
+	GetDeltas(ctx context.Context, id DIDPeer) ([]PeerDelta, error)
+}
+
+// MemoryPeerStore is a PeerStore kept in memory
+type MemoryPeerStore struct {
+	mu      sync.RWMutex
+	genesis map[DIDPeer][]byte
+	deltas  map[DIDPeer]map[string]PeerDelta
+}
+
+var _ PeerStore = (*MemoryPeerStore)(nil)
+
+// NewMemoryPeerStore creates an empty MemoryPeerStore
+func NewMemoryPeerStore() *MemoryPeerStore {
+	return &MemoryPeerStore{
+		genesis: make(map[DIDPeer][]byte),
+		deltas:  make(map[DIDPeer]map[string]PeerDelta),
+	}
+}
+
+func (s *MemoryPeerStore) PutGenesis(_ context.Context, id DIDPeer, genesis []byte) error {
+	suffix, err := id.Suffix()
+	if err != nil {
+		return err
+	}
+	if method, _ := id.GetMethodID(); method != "1" {
+		return fmt.Errorf("not a did:peer:1 DID: %s", id)
+	}
+	numericBasis, err := numericBasisForGenesis(genesis)
+	if err != nil {
+		return err
+	}
+	if numericBasis != suffix {
+		return errors.New("genesis document does not match the DID")
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.genesis[id] = genesis
+	return nil
+}
+
+func (s *MemoryPeerStore) GetGenesis(_ context.Context, id DIDPeer) ([]byte, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	genesis, ok := s.genesis[id]
+	if !ok {
+		return nil, resolution.NewResolutionErrorf(resolution.NotFoundErrorCode, "no genesis document known for: %s", id)
+	}
+	return genesis, nil
+}
+
+func (s *MemoryPeerStore) AddDeltas(_ context.Context, id DIDPeer, deltas ...PeerDelta) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.genesis[id]; !ok {
+		return fmt.Errorf("no genesis document known for: %s", id)
+	}
+	if s.deltas[id] == nil {
+		s.deltas[id] = make(map[string]PeerDelta)
+	}
+	for _, delta := range deltas {
+		s.deltas[id][delta.hash()] = delta
+	}
+	return nil
+}
+
+func (s *MemoryPeerStore) GetDeltas(_ context.Context, id DIDPeer) ([]PeerDelta, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	deltas := make([]PeerDelta, 0, len(s.deltas[id]))
+	for _, delta := range s.deltas[id] {
+		deltas = append(deltas, delta)
+	}
+	return deltas, nil
 }
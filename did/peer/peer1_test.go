@@ -1,14 +1,166 @@
 package peer
 
 import (
+	"context"
+	"crypto/ed25519"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/extrimian/ssi-sdk/crypto"
+	"github.com/extrimian/ssi-sdk/crypto/jwx"
+	"github.com/extrimian/ssi-sdk/did"
+	"github.com/extrimian/ssi-sdk/did/resolution"
+	"github.com/extrimian/ssi-sdk/util"
 )
 
 func TestPeerMethod1(t *testing.T) {
-	var m1 Method1
-	_, err := m1.Generate()
-	assert.Error(t, err)
-	assert.Contains(t, "not implemented", err.Error())
+	pubKey, privKey, err := crypto.GenerateEd25519Key()
+	require.NoError(t, err)
+	vm, err := did.ConstructJWKVerificationMethod("#key-1", "", pubKey, crypto.Ed25519)
+	require.NoError(t, err)
+
+	genesis := did.Document{
+		Context:              did.KnownDIDContext,
+		VerificationMethod:   []did.VerificationMethod{*vm},
+		CapabilityInvocation: []did.VerificationMethodSet{"#key-1"},
+		Services: []did.Service{
+			{ID: "#service", Type: DIDCommMessaging, ServiceEndpoint: "https://example.com/endpoint"},
+		},
+	}
+	m1 := Method1{Genesis: genesis}
+	didPeer, err := m1.Generate()
+	require.NoError(t, err)
+	assert.True(t, didPeer.IsValid())
+
+	genesisBytes, err := m1.GenesisBytes()
+	require.NoError(t, err)
+	store := NewMemoryPeerStore()
+	require.NoError(t, store.PutGenesis(context.Background(), *didPeer, genesisBytes))
+	resolver := Resolver{Store: store}
+
+	signer, err := jwx.NewJWXSigner(didPeer.String(), "#key-1", privKey)
+	require.NoError(t, err)
+
+	t.Run("genesis with an id", func(tt *testing.T) {
+		_, err := Method1{Genesis: did.Document{ID: "did:example:123"}}.Generate()
+		assert.Error(tt, err)
+	})
+
+	t.Run("genesis that does not match the did", func(tt *testing.T) {
+		err := store.PutGenesis(context.Background(), *didPeer, []byte(`{}`))
+		assert.Error(tt, err)
+	})
+
+	t.Run("unknown did", func(tt *testing.T) {
+		_, err := Resolver{}.Resolve(context.Background(), didPeer.String())
+		assert.True(tt, resolution.IsNotFound(err))
+	})
+
+	t.Run("resolve genesis", func(tt *testing.T) {
+		resolved, err := resolver.Resolve(context.Background(), didPeer.String())
+		require.NoError(tt, err)
+		assert.Equal(tt, didPeer.String(), resolved.ID)
+		assert.Equal(tt, didPeer.String(), resolved.VerificationMethod[0].Controller)
+		assert.Len(tt, resolved.Services, 1)
+	})
+
+	t.Run("deltas converge regardless of delivery order", func(tt *testing.T) {
+		newKey, _, err := crypto.GenerateEd25519Key()
+		require.NoError(tt, err)
+		newVM, err := did.ConstructJWKVerificationMethod("#key-2", "", newKey, crypto.Ed25519)
+		require.NoError(tt, err)
+
+		addKey, err := didPeer.Delta(PeerChange{
+			Action:             AddVerificationMethodAction,
+			VerificationMethod: newVM,
+			Purposes:           []PurposeType{PurposeVerificationCode},
+		}, signer)
+		require.NoError(tt, err)
+		removeService, err := didPeer.Delta(PeerChange{Action: RemoveServiceAction, ID: "#service"}, signer)
+		require.NoError(tt, err)
+		addService, err := didPeer.Delta(PeerChange{Action: AddServiceAction, Service: &did.Service{
+			ID: "#files", Type: "LinkedDomains", ServiceEndpoint: "https://example.com/files",
+		}}, signer)
+		require.NoError(tt, err)
+
+		otherStore := NewMemoryPeerStore()
+		require.NoError(tt, otherStore.PutGenesis(context.Background(), *didPeer, genesisBytes))
+
+		require.NoError(tt, store.AddDeltas(context.Background(), *didPeer, *addKey, *removeService, *addService))
+		require.NoError(tt, otherStore.AddDeltas(context.Background(), *didPeer, *addService))
+		require.NoError(tt, otherStore.AddDeltas(context.Background(), *didPeer, *removeService, *addKey, *addService))
+
+		resolved, err := resolver.Resolve(context.Background(), didPeer.String())
+		require.NoError(tt, err)
+		other, err := Resolver{Store: otherStore}.Resolve(context.Background(), didPeer.String())
+		require.NoError(tt, err)
+		assert.Equal(tt, resolved.Document, other.Document)
+
+		assert.Len(tt, resolved.VerificationMethod, 2)
+		assert.Equal(tt, did.VerificationRelationship{"#key-2"}, resolved.Authentication)
+		require.Len(tt, resolved.Services, 1)
+		assert.Equal(tt, "#files", resolved.Services[0].ID)
+		assert.NotEmpty(tt, resolved.DocumentMetadata.Updated)
+	})
+
+	t.Run("unauthorized deltas are skipped", func(tt *testing.T) {
+		_, otherKey, err := ed25519.GenerateKey(nil)
+		require.NoError(tt, err)
+		otherSigner, err := jwx.NewJWXSigner(didPeer.String(), "#key-1", otherKey)
+		require.NoError(tt, err)
+		forged, err := didPeer.Delta(PeerChange{Action: RemoveVerificationMethodAction, ID: "#key-1"}, otherSigner)
+		require.NoError(tt, err)
+		unknownKey, err := jwx.NewJWXSigner(didPeer.String(), "#key-3", otherKey)
+		require.NoError(tt, err)
+		unknown, err := didPeer.Delta(PeerChange{Action: RemoveVerificationMethodAction, ID: "#key-1"}, unknownKey)
+		require.NoError(tt, err)
+		require.NoError(tt, store.AddDeltas(context.Background(), *didPeer, *forged, *unknown))
+
+		resolved, err := resolver.Resolve(context.Background(), didPeer.String())
+		require.NoError(tt, err)
+		assert.Len(tt, resolved.VerificationMethod, 2)
+	})
+
+	t.Run("deltas are applied in time order", func(tt *testing.T) {
+		// a delta made before the key was added cannot remove it
+		removeKey, err := didPeer.delta(PeerChange{Action: RemoveVerificationMethodAction, ID: "#key-2"}, signer, time.Now().Add(-time.Hour))
+		require.NoError(tt, err)
+		require.NoError(tt, store.AddDeltas(context.Background(), *didPeer, *removeKey))
+
+		resolved, err := resolver.Resolve(context.Background(), didPeer.String())
+		require.NoError(tt, err)
+		assert.Len(tt, resolved.VerificationMethod, 2)
+	})
+
+	t.Run("the time of a delta is signed", func(tt *testing.T) {
+		deltas, err := store.GetDeltas(context.Background(), *didPeer)
+		require.NoError(tt, err)
+
+		removeKey, err := didPeer.Delta(PeerChange{Action: RemoveVerificationMethodAction, ID: "#key-2"}, signer)
+		require.NoError(tt, err)
+		// back-dating a copy of the delta neither reorders it nor adds another delta
+		backDated := *removeKey
+		backDated.When = time.Now().Add(-time.Hour).UTC().Format(time.RFC3339Nano)
+		require.NoError(tt, store.AddDeltas(context.Background(), *didPeer, backDated, *removeKey))
+		stored, err := store.GetDeltas(context.Background(), *didPeer)
+		require.NoError(tt, err)
+		assert.Len(tt, stored, len(deltas)+1)
+
+		resolved, err := resolver.Resolve(context.Background(), didPeer.String())
+		require.NoError(tt, err)
+		assert.Len(tt, resolved.VerificationMethod, 1)
+		signedTime, err := time.Parse(time.RFC3339Nano, removeKey.When)
+		require.NoError(tt, err)
+		assert.Equal(tt, util.AsRFC3339Timestamp(signedTime), resolved.DocumentMetadata.Updated)
+	})
+
+	t.Run("invalid change", func(tt *testing.T) {
+		_, err := didPeer.Delta(PeerChange{Action: "replace"}, signer)
+		assert.Error(tt, err)
+		_, err = didPeer.Delta(PeerChange{Action: RemoveServiceAction}, nil)
+		assert.Error(tt, err)
+	})
 }
@@ -55,56 +55,74 @@ func (Method2) resolve(didDoc did.DID, _ resolution.Option) (*resolution.Result,
 		ID:      string(d),
 	}
 
+	var services int
 	for _, entry := range entries {
-		serviceType := PurposeType(entry[0])
-		switch serviceType {
-		case PurposeCapabilityServiceCode:
+		if entry == "" {
+			return nil, errors.New("empty did:peer:2 element")
+		}
+		purpose := PurposeType(entry[0])
+		if purpose == PurposeCapabilityServiceCode {
 			service, err := d.decodeServiceBlock("." + entry)
 			if err != nil {
 				return nil, err
 			}
-			service.ID = string(d) + "#didcommmessaging-0"
+			if service.ID == "" {
+				service.ID = serviceID(services)
+			}
+			services++
 			doc.Services = append(doc.Services, *service)
+			continue
+		}
+		if !d.IsValidPurpose(purpose) {
+			return nil, errors.Wrap(util.UnsupportedError, string(entry[0]))
+		}
+		vm, err := d.buildVerificationMethod(entry[1:], string(d))
+		if err != nil {
+			return nil, errors.Wrapf(err, "building verification method for purpose %s", purpose)
+		}
+		switch purpose {
 		case PurposeEncryptionCode:
-			vm, err := d.buildVerificationMethod(entry[1:], string(d))
-			if err != nil {
-				return nil, errors.Wrap(err, "building encryption code")
-			}
 			doc.KeyAgreement = append(doc.KeyAgreement, *vm)
 		case PurposeVerificationCode:
-			vm, err := d.buildVerificationMethod(entry[1:], string(d))
-			if err != nil {
-				return nil, errors.Wrap(err, "building verification code")
-			}
 			doc.Authentication = append(doc.Authentication, *vm)
+		case PurposeAssertionCode:
+			doc.AssertionMethod = append(doc.AssertionMethod, *vm)
 		case PurposeCapabilityInvocationCode:
-			vm, err := d.buildVerificationMethod(entry[1:], string(d))
-			if err != nil {
-				return nil, errors.Wrap(err, "building capabilities invocation code")
-			}
 			doc.CapabilityInvocation = append(doc.CapabilityInvocation, *vm)
 		case PurposeCapabilityDelegationCode:
-			vm, err := d.buildVerificationMethod(entry[1:], string(d))
-			if err != nil {
-				return nil, errors.Wrap(err, "building capability delegation code")
-			}
 			doc.CapabilityDelegation = append(doc.CapabilityDelegation, *vm)
-		default:
-			return nil, errors.Wrap(util.UnsupportedError, string(entry[0]))
 		}
 	}
 	return &resolution.Result{Document: doc}, nil
 }
 
+// PurposeKey is a public key together with the purpose it serves in a did:peer:2 DID. The purpose decides the
+// verification relationship the key is placed in when the DID is resolved.
+type PurposeKey struct {
+	Purpose PurposeType
+	// KeyType of the public key, defaults to the key type of the Method2
+	KeyType   crypto.KeyType
+	PublicKey gocrypto.PublicKey
+}
+
+// serviceID returns the id of the n-th service of a did:peer:2 DID that does not specify one, as described in
+// https://identity.foundation/peer-did-method-spec/#method-2-multiple-inception-key-without-doc
+func serviceID(n int) string {
+	if n == 0 {
+		return "#service"
+	}
+	return fmt.Sprintf("#service-%d", n)
+}
+
 // Generate If numalgo == 2, the generation mode is similar to Method 0 (and therefore also did:key) with the ability
 // to specify additional keys in the generated DID Document. This method is necessary when both an encryption key
 // and a signing key are required.
-// It determines the purpose implicitly by looking at the type of object:
+// Values may be a PurposeKey, a gocrypto.PublicKey, which is used for key agreement, or a did.Service:
 // 1. Start with the did prefix did:peer:2
 // 2. Construct a multibase encoded, multicodec-encoded form of each public key to be included.
 // 3. Prefix each encoded key with a period character (.) and single character from the purpose codes table below.
 // 4. Append the encoded key to the DID.
-// 5. Encode and append a service type to the end of the peer DID if desired as described below.
+// 5. Encode and append each service to the end of the peer DID if desired as described below.
 func (m Method2) Generate() (*DIDPeer, error) {
 	if len(m.Values) == 0 {
 		// revive:disable-next-line:error-strings We do not want to change to error messages sent to clients.
@@ -113,40 +131,59 @@ func (m Method2) Generate() (*DIDPeer, error) {
 
 	var didPeer DIDPeer
 	var encoded string
-	var err error
+	var services int
 
-	for i, value := range m.Values {
+	for _, value := range m.Values {
 		var enc string
 		var purpose PurposeType
+		var err error
 
-		switch tt := value.(type) {
+		switch v := value.(type) {
 		case did.Service:
 			purpose = PurposeCapabilityServiceCode
-			service := value.(did.Service)
-
-			if i < len(m.Values)-1 {
-				return nil, fmt.Errorf("failed to created did for %s. service must be appended last", "did:peer")
-			}
-
-			if !service.IsValid() {
+			if !v.IsValid() {
 				return nil, errors.New("service purpose provided but invalid service definition given")
 			}
-
-			enc, err = didPeer.encodeService(service)
-
+			// ids implied by the position of the service are left out of the encoding
+			if v.ID == serviceID(services) {
+				v.ID = ""
+			}
+			services++
+			enc, err = didPeer.encodeService(v)
 			if err != nil {
 				return nil, errors.Wrap(err, "encoding service for did:peer")
 			}
-
+		case PurposeKey, *PurposeKey:
+			key, ok := v.(PurposeKey)
+			if !ok {
+				key = *v.(*PurposeKey)
+			}
+			if services > 0 {
+				return nil, fmt.Errorf("failed to created did for %s. services must be appended last", "did:peer")
+			}
+			if key.Purpose == PurposeCapabilityServiceCode || !didPeer.IsValidPurpose(key.Purpose) {
+				return nil, fmt.Errorf("invalid purpose for key: %s", key.Purpose)
+			}
+			kt := key.KeyType
+			if kt == "" {
+				kt = m.KT
+			}
+			purpose = key.Purpose
+			enc, err = encodePublicKeyWithKeyMultiCodecType(kt, key.PublicKey)
+			if err != nil {
+				return nil, errors.Wrap(err, "encoding public key for did:peer")
+			}
 		case gocrypto.PublicKey:
+			if services > 0 {
+				return nil, fmt.Errorf("failed to created did for %s. services must be appended last", "did:peer")
+			}
 			purpose = PurposeEncryptionCode
-			key := value.(gocrypto.PublicKey)
-			enc, err = encodePublicKeyWithKeyMultiCodecType(m.KT, key)
+			enc, err = encodePublicKeyWithKeyMultiCodecType(m.KT, v)
 			if err != nil {
 				return nil, errors.Wrap(err, "encoding public key for did:peer")
 			}
 		default:
-			return nil, errors.Wrap(util.NotImplementedError, fmt.Sprintf("encoding of %s did:peer", tt))
+			return nil, errors.Wrap(util.NotImplementedError, fmt.Sprintf("encoding of %T did:peer", v))
 		}
 
 		encoded += "." + string(purpose) + enc
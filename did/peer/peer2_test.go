@@ -1,9 +1,11 @@
 package peer
 
 import (
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
 	"github.com/extrimian/ssi-sdk/crypto"
 	"github.com/extrimian/ssi-sdk/did"
@@ -76,3 +78,62 @@ func TestPeerResolveMethod2(t *testing.T) {
 	assert.Equal(t, testDoc.Authentication[1].(did.VerificationMethod).Controller, resolved.Authentication[1].(did.VerificationMethod).Controller)
 	assert.Equal(t, testDoc.Authentication[1].(did.VerificationMethod).PublicKeyMultibase, resolved.Authentication[1].(did.VerificationMethod).PublicKeyMultibase)
 }
+
+func TestPeerMethod2Purposes(t *testing.T) {
+	var d DIDPeer
+	signingKey, _, err := d.generateKeyByType(crypto.Ed25519)
+	require.NoError(t, err)
+	agreementKey, _, err := d.generateKeyByType(crypto.X25519)
+	require.NoError(t, err)
+	assertionKey, _, err := d.generateKeyByType(crypto.P256)
+	require.NoError(t, err)
+
+	mediator := did.Service{
+		ID:   "#service",
+		Type: DIDCommMessaging,
+		ServiceEndpoint: map[string]any{
+			"uri":         "https://example.com/endpoint",
+			"accept":      []any{"didcomm/v2"},
+			"routingKeys": []any{"did:example:somemediator#somekey"},
+		},
+	}
+	files := did.Service{ID: "#files", Type: "LinkedDomains", ServiceEndpoint: "https://example.com/files"}
+	other := did.Service{ID: "#service-2", Type: "LinkedDomains", ServiceEndpoint: "https://example.com/other"}
+
+	m2 := Method2{KT: crypto.Ed25519, Values: []any{
+		PurposeKey{Purpose: PurposeVerificationCode, PublicKey: signingKey},
+		&PurposeKey{Purpose: PurposeEncryptionCode, KeyType: crypto.X25519, PublicKey: agreementKey},
+		PurposeKey{Purpose: PurposeAssertionCode, KeyType: crypto.P256, PublicKey: assertionKey},
+		mediator, files, other,
+	}}
+	didPeer, err := m2.Generate()
+	require.NoError(t, err)
+	assert.True(t, didPeer.IsValid())
+	assert.Equal(t, 3, strings.Count(string(*didPeer), ".S"))
+
+	resolved, err := Method2{}.resolve(*didPeer, nil)
+	require.NoError(t, err)
+	assert.Len(t, resolved.Authentication, 1)
+	assert.Len(t, resolved.KeyAgreement, 1)
+	assert.Len(t, resolved.AssertionMethod, 1)
+	assert.EqualValues(t, "X25519KeyAgreementKey2020", resolved.KeyAgreement[0].(did.VerificationMethod).Type)
+
+	require.Len(t, resolved.Services, 3)
+	assert.Equal(t, "#service", resolved.Services[0].ID)
+	assert.Equal(t, DIDCommMessaging, resolved.Services[0].Type)
+	assert.Equal(t, mediator.ServiceEndpoint, resolved.Services[0].ServiceEndpoint)
+	assert.Equal(t, "#files", resolved.Services[1].ID)
+	assert.Equal(t, "#service-2", resolved.Services[2].ID)
+
+	t.Run("invalid purpose", func(tt *testing.T) {
+		_, err := Method2{KT: crypto.Ed25519, Values: []any{
+			PurposeKey{Purpose: PurposeCapabilityServiceCode, PublicKey: signingKey},
+		}}.Generate()
+		assert.Error(tt, err)
+	})
+
+	t.Run("keys after services", func(tt *testing.T) {
+		_, err := Method2{KT: crypto.Ed25519, Values: []any{files, signingKey}}.Generate()
+		assert.Error(tt, err)
+	})
+}
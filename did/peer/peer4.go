@@ -0,0 +1,223 @@
+package peer
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/goccy/go-json"
+	"github.com/multiformats/go-multibase"
+	"github.com/multiformats/go-multicodec"
+	"github.com/multiformats/go-multihash"
+	"github.com/multiformats/go-varint"
+	"github.com/pkg/errors"
+
+	"github.com/extrimian/ssi-sdk/did"
+	"github.com/extrimian/ssi-sdk/did/resolution"
+	"github.com/extrimian/ssi-sdk/util"
+)
+
+// Method4 Method 4: short form and long form
+// https://identity.foundation/peer-did-method-spec/#method-4-short-form-and-long-form
+// The long form DID carries the input document encoded in the DID itself, prefixed by a hash of the encoded document.
+// The short form DID is the hash alone, and can only be resolved after the long form has been seen.
+type Method4 struct {
+	// Document is the input document. It must not have an id, and references to the DID within it should be relative.
+	Document did.Document
+}
+
+func (Method4) Method() did.Method {
+	return did.PeerMethod
+}
+
+// Generate creates the long form did:peer:4 DID of the input document:
+// 1. JSON encode the input document and prefix it with the json multicodec
+// 2. Encode the result with base58btc multibase: this is the encoded document
+// 3. Hash the encoded document with sha2-256, and encode the multihash with base58btc multibase
+// 4. Construct the DID as did:peer:4{hash}:{encoded document}
+func (m Method4) Generate() (*DIDPeer, error) {
+	if m.Document.ID != "" {
+		return nil, errors.New("input document for did:peer:4 must not have an id")
+	}
+	docBytes, err := json.Marshal(m.Document)
+	if err != nil {
+		return nil, errors.Wrap(err, "marshalling input document")
+	}
+	encodedDoc, err := multibase.Encode(EncNumBasis, append(varint.ToUvarint(uint64(multicodec.Json)), docBytes...))
+	if err != nil {
+		return nil, errors.Wrap(err, "encoding input document")
+	}
+	hash, err := hashPeer4Document(encodedDoc)
+	if err != nil {
+		return nil, err
+	}
+	didPeer := DIDPeer(fmt.Sprintf("%s:4%s:%s", DIDPeerPrefix, hash, encodedDoc))
+	return &didPeer, nil
+}
+
+// ShortForm returns the short form of a did:peer:4 DID, which is the DID itself if it is in short form already
+func (d DIDPeer) ShortForm() (DIDPeer, error) {
+	suffix, err := d.Suffix()
+	if err != nil {
+		return "", err
+	}
+	if method, _ := d.GetMethodID(); method != "4" {
+		return "", fmt.Errorf("%s is not a did:peer:4 DID", d)
+	}
+	hash, _, _ := strings.Cut(suffix, ":")
+	return buildDIDPeerFromEncoded(4, hash), nil
+}
+
+// IsLongForm returns true if the DID is a did:peer:4 long form DID
+func (d DIDPeer) IsLongForm() bool {
+	method, err := d.GetMethodID()
+	return err == nil && method == "4" && strings.Count(string(d), ":") == 3
+}
+
+func hashPeer4Document(encodedDoc string) (string, error) {
+	mh, err := multihash.Sum([]byte(encodedDoc), multihash.SHA2_256, -1)
+	if err != nil {
+		return "", errors.Wrap(err, "hashing encoded document")
+	}
+	hash, err := multibase.Encode(EncNumBasis, mh)
+	if err != nil {
+		return "", errors.Wrap(err, "encoding hash")
+	}
+	return hash, nil
+}
+
+// decodeLongForm verifies the hash of a long form DID and decodes its input document
+func decodeLongForm(d DIDPeer) (*did.Document, error) {
+	suffix, err := d.Suffix()
+	if err != nil {
+		return nil, err
+	}
+	hash, encodedDoc, ok := strings.Cut(suffix, ":")
+	if !ok {
+		return nil, errors.New("not a long form did:peer:4 DID")
+	}
+	expectedHash, err := hashPeer4Document(encodedDoc)
+	if err != nil {
+		return nil, err
+	}
+	if hash != expectedHash {
+		return nil, errors.New("hash does not match the encoded document")
+	}
+	_, decoded, err := multibase.Decode(encodedDoc)
+	if err != nil {
+		return nil, errors.Wrap(err, "decoding encoded document")
+	}
+	codec, n, err := varint.FromUvarint(decoded)
+	if err != nil {
+		return nil, errors.Wrap(err, "decoding multicodec")
+	}
+	if multicodec.Code(codec) != multicodec.Json {
+		return nil, fmt.Errorf("unsupported multicodec for encoded document: %s", multicodec.Code(codec))
+	}
+	var doc did.Document
+	if err = json.Unmarshal(decoded[n:], &doc); err != nil {
+		return nil, errors.Wrap(err, "unmarshalling input document")
+	}
+	return &doc, nil
+}
+
+// contextualize turns an input document into the resolved document of the given DID, as described in
+// https://identity.foundation/peer-did-method-spec/#resolving-a-did
+func contextualize(doc did.Document, id, alsoKnownAs string) did.Document {
+	doc.ID = id
+	if alsoKnownAs != "" {
+		doc.AlsoKnownAs = alsoKnownAs
+	}
+	if doc.Context == nil {
+		doc.Context = did.KnownDIDContext
+	}
+	for i := range doc.VerificationMethod {
+		if doc.VerificationMethod[i].Controller == "" {
+			doc.VerificationMethod[i].Controller = id
+		}
+	}
+	return doc
+}
+
+func (Method4) resolve(ctx context.Context, d did.DID, store LongFormStore) (*resolution.Result, error) {
+	didPeer, ok := d.(DIDPeer)
+	if !ok {
+		return nil, errors.Wrap(util.CastingError, DIDPeerPrefix)
+	}
+	shortForm, err := didPeer.ShortForm()
+	if err != nil {
+		return nil, err
+	}
+
+	if didPeer.IsLongForm() {
+		doc, err := decodeLongForm(didPeer)
+		if err != nil {
+			return nil, err
+		}
+		if store != nil {
+			if err = store.PutLongForm(ctx, didPeer); err != nil {
+				return nil, errors.Wrap(err, "storing long form")
+			}
+		}
+		return &resolution.Result{Document: contextualize(*doc, string(didPeer), string(shortForm))}, nil
+	}
+
+	if store == nil {
+		return nil, resolution.NewResolutionErrorf(resolution.NotFoundErrorCode, "no long form known for: %s", didPeer)
+	}
+	longForm, err := store.GetLongForm(ctx, shortForm)
+	if err != nil {
+		return nil, err
+	}
+	doc, err := decodeLongForm(longForm)
+	if err != nil {
+		return nil, err
+	}
+	return &resolution.Result{Document: contextualize(*doc, string(shortForm), string(longForm))}, nil
+}
+
+// LongFormStore keeps the did:peer:4 long form DIDs that have been seen, so that their short forms can be resolved
+type LongFormStore interface {
+	// PutLongForm stores a long form DID under its short form
+	PutLongForm(ctx context.Context, longForm DIDPeer) error
+	// GetLongForm returns the long form of a short form DID, or a notFound resolution error
+	GetLongForm(ctx context.Context, shortForm DIDPeer) (DIDPeer, error)
+}
+
+// MemoryLongFormStore is a LongFormStore kept in memory
+type MemoryLongFormStore struct {
+	mu        sync.RWMutex
+	longForms map[DIDPeer]DIDPeer
+}
+
+var _ LongFormStore = (*MemoryLongFormStore)(nil)
+
+// NewMemoryLongFormStore creates an empty MemoryLongFormStore
+func NewMemoryLongFormStore() *MemoryLongFormStore {
+	return &MemoryLongFormStore{longForms: make(map[DIDPeer]DIDPeer)}
+}
+
+func (s *MemoryLongFormStore) PutLongForm(_ context.Context, longForm DIDPeer) error {
+	if !longForm.IsLongForm() {
+		return fmt.Errorf("not a long form did:peer:4 DID: %s", longForm)
+	}
+	shortForm, err := longForm.ShortForm()
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.longForms[shortForm] = longForm
+	return nil
+}
+
+func (s *MemoryLongFormStore) GetLongForm(_ context.Context, shortForm DIDPeer) (DIDPeer, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	longForm, ok := s.longForms[shortForm]
+	if !ok {
+		return "", resolution.NewResolutionErrorf(resolution.NotFoundErrorCode, "no long form known for: %s", shortForm)
+	}
+	return longForm, nil
+}
@@ -0,0 +1,90 @@
+package peer
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/extrimian/ssi-sdk/crypto"
+	"github.com/extrimian/ssi-sdk/did"
+	"github.com/extrimian/ssi-sdk/did/resolution"
+)
+
+func TestPeerMethod4(t *testing.T) {
+	var d DIDPeer
+	pubKey, _, err := d.generateKeyByType(crypto.Ed25519)
+	require.NoError(t, err)
+	multibaseKey, err := encodePublicKeyWithKeyMultiCodecType(crypto.Ed25519, pubKey)
+	require.NoError(t, err)
+
+	input := did.Document{
+		Context: did.KnownDIDContext,
+		VerificationMethod: []did.VerificationMethod{
+			{ID: "#key-1", Type: "Multikey", PublicKeyMultibase: multibaseKey},
+		},
+		Authentication: []did.VerificationMethodSet{"#key-1"},
+		Services: []did.Service{
+			{ID: "#service", Type: DIDCommMessaging, ServiceEndpoint: "https://example.com/endpoint"},
+		},
+	}
+	longForm, err := Method4{Document: input}.Generate()
+	require.NoError(t, err)
+	assert.True(t, longForm.IsValid())
+	assert.True(t, longForm.IsLongForm())
+
+	shortForm, err := longForm.ShortForm()
+	require.NoError(t, err)
+	assert.True(t, shortForm.IsValid())
+	assert.False(t, shortForm.IsLongForm())
+	assert.True(t, strings.HasPrefix(string(*longForm), string(shortForm)+":"))
+
+	t.Run("input document with an id", func(tt *testing.T) {
+		_, err := Method4{Document: did.Document{ID: "did:example:123"}}.Generate()
+		assert.Error(tt, err)
+	})
+
+	t.Run("short form of another method", func(tt *testing.T) {
+		_, err := DIDPeer("did:peer:0z6MkpTHR8VNsBxYAAWHut2Geadd9jSwuBV8xRoAnwWsdvktH").ShortForm()
+		assert.Error(tt, err)
+	})
+
+	t.Run("resolve long form", func(tt *testing.T) {
+		resolved, err := Resolver{}.Resolve(context.Background(), longForm.String())
+		require.NoError(tt, err)
+		assert.Equal(tt, longForm.String(), resolved.ID)
+		assert.Equal(tt, shortForm.String(), resolved.AlsoKnownAs)
+		assert.Equal(tt, longForm.String(), resolved.VerificationMethod[0].Controller)
+		assert.Equal(tt, "#service", resolved.Services[0].ID)
+	})
+
+	t.Run("resolve tampered long form", func(tt *testing.T) {
+		tampered := strings.Replace(longForm.String(), string(shortForm), "did:peer:4zQmcLyTbrfYBjm6kFJxNZjz2r8kiPqyzHaH8UEb57RBRY5Tm", 1)
+		_, err := Resolver{}.Resolve(context.Background(), tampered)
+		assert.True(tt, resolution.IsInvalidDID(err))
+	})
+
+	t.Run("resolve short form", func(tt *testing.T) {
+		_, err := Resolver{}.Resolve(context.Background(), shortForm.String())
+		assert.True(tt, resolution.IsNotFound(err))
+
+		r := Resolver{LongForms: NewMemoryLongFormStore()}
+		_, err = r.Resolve(context.Background(), shortForm.String())
+		assert.True(tt, resolution.IsNotFound(err))
+
+		_, err = r.Resolve(context.Background(), longForm.String())
+		require.NoError(tt, err)
+		resolved, err := r.Resolve(context.Background(), shortForm.String())
+		require.NoError(tt, err)
+		assert.Equal(tt, shortForm.String(), resolved.ID)
+		assert.Equal(tt, longForm.String(), resolved.AlsoKnownAs)
+		assert.Equal(tt, shortForm.String(), resolved.VerificationMethod[0].Controller)
+	})
+
+	t.Run("store rejects short forms", func(tt *testing.T) {
+		err := NewMemoryLongFormStore().PutLongForm(context.Background(), shortForm)
+		assert.Error(tt, err)
+	})
+}
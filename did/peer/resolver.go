@@ -2,8 +2,8 @@ package peer
 
 import (
 	"context"
-	"fmt"
 	"strings"
+	"time"
 
 	"github.com/pkg/errors"
 
@@ -11,34 +11,67 @@ import (
 	"github.com/extrimian/ssi-sdk/did/resolution"
 )
 
-type Resolver struct{}
+// Resolver resolves did:peer DIDs. did:peer:1 DIDs are resolved from the genesis documents and deltas in Store, and
+// short form did:peer:4 DIDs using the long forms previously seen by LongForms, if set.
+type Resolver struct {
+	Store     PeerStore
+	LongForms LongFormStore
+}
 
 var _ resolution.Resolver = (*Resolver)(nil)
 
-func (Resolver) Resolve(_ context.Context, id string, opts ...resolution.Option) (*resolution.Result, error) {
+func (r Resolver) Resolve(ctx context.Context, id string, opts ...resolution.Option) (*resolution.Result, error) {
+	start := time.Now()
 	if !strings.HasPrefix(id, DIDPeerPrefix) {
-		return nil, fmt.Errorf("not a did:peer DID: %s", id)
+		return nil, resolution.NewResolutionErrorf(resolution.MethodNotSupportedErrorCode, "not a did:peer DID: %s", id)
 	}
 
+	options, err := resolution.ParseOptions(opts...)
+	if err != nil {
+		return nil, err
+	}
+	if err = options.RejectVersioned(did.PeerMethod); err != nil {
+		return nil, err
+	}
+
+	// DID syntax has no padding, so did:peer:2 DIDs with padded base64url services are invalid
+	if !did.IsValidDID(id) {
+		return nil, resolution.NewResolutionErrorf(resolution.InvalidDIDErrorCode, "not a valid DID: %s", id)
+	}
 	didPeer := DIDPeer(id)
 	if len(didPeer) < len(DIDPeerPrefix)+2 {
-		return nil, errors.New("did is too short")
+		return nil, resolution.NewResolutionError(resolution.InvalidDIDErrorCode, errors.New("did is too short"))
 	}
 
 	m := string(didPeer[9])
-	if peerMethodAvailable(m) {
-		switch m {
-		case "0":
-			return Method0{}.resolve(didPeer, opts)
-		case "1":
-			return Method1{}.resolve(didPeer, opts)
-		case "2":
-			return Method2{}.resolve(didPeer, opts)
-		default:
-			return nil, fmt.Errorf("%s method not supported", m)
+	if !peerMethodAvailable(m) {
+		return nil, resolution.NewResolutionErrorf(resolution.MethodNotSupportedErrorCode, "could not resolve peer DID: %s", id)
+	}
+
+	var result *resolution.Result
+	switch m {
+	case "0":
+		result, err = Method0{}.resolve(didPeer, opts)
+	case "1":
+		result, err = Method1{}.resolve(ctx, didPeer, r.Store)
+	case "2":
+		result, err = Method2{}.resolve(didPeer, opts)
+	case "4":
+		result, err = Method4{}.resolve(ctx, didPeer, r.LongForms)
+	default:
+		return nil, resolution.NewResolutionErrorf(resolution.MethodNotSupportedErrorCode, "%s method not supported", m)
+	}
+	if err != nil {
+		if resolution.GetErrorCode(err) == resolution.InternalErrorCode {
+			err = resolution.NewResolutionError(resolution.InvalidDIDErrorCode, err)
 		}
+		return nil, errors.Wrapf(err, "resolving did:peer DID: %s", id)
+	}
+	resolved := resolution.NewResult(result.Document, result.DocumentMetadata, start)
+	if err = resolution.ValidateResult(resolved); err != nil {
+		return nil, err
 	}
-	return nil, fmt.Errorf("could not resolve peer DID: %s", id)
+	return options.Apply(resolved), nil
 }
 
 func (Resolver) Methods() []did.Method {
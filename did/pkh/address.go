@@ -0,0 +1,197 @@
+package pkh
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/mr-tron/base58"
+	"github.com/pkg/errors"
+
+	"github.com/extrimian/ssi-sdk/crypto"
+)
+
+var eip155AddressRegex = regexp.MustCompile(`^0x[0-9a-fA-F]{40}$`)
+
+// tezos address prefixes, and the version bytes of their base58check encodings
+var tezosAddressPrefixes = map[string][]byte{
+	"tz1": {6, 161, 159},
+	"tz2": {6, 161, 161},
+	"tz3": {6, 161, 164},
+	"KT1": {2, 90, 121},
+}
+
+// validateAddress validates an account address for the known namespaces; addresses of other namespaces are accepted
+func validateAddress(namespace, address string) error {
+	switch namespace {
+	case EIP155Namespace:
+		return validateEIP155Address(address)
+	case BIP122Namespace:
+		return validateBIP122Address(address)
+	case SolanaNamespace:
+		return validateSolanaAddress(address)
+	case TezosNamespace:
+		return validateTezosAddress(address)
+	case CosmosNamespace:
+		_, _, err := decodeBech32(address)
+		return err
+	}
+	return nil
+}
+
+// validateEIP155Address checks a hex address, and its EIP-55 checksum if it is mixed case
+func validateEIP155Address(address string) error {
+	if !eip155AddressRegex.MatchString(address) {
+		return fmt.Errorf("expected 0x followed by 40 hex characters: %s", address)
+	}
+	hexAddress := address[2:]
+	if hexAddress == strings.ToLower(hexAddress) || hexAddress == strings.ToUpper(hexAddress) {
+		return nil
+	}
+	checksum := fmt.Sprintf("%x", crypto.Keccak256([]byte(strings.ToLower(hexAddress))))
+	for i, c := range hexAddress {
+		upper := c >= 'A' && c <= 'F'
+		lower := c >= 'a' && c <= 'f'
+		if (upper && checksum[i] < '8') || (lower && checksum[i] >= '8') {
+			return fmt.Errorf("invalid EIP-55 checksum: %s", address)
+		}
+	}
+	return nil
+}
+
+// validateBIP122Address checks a base58check P2PKH or P2SH address, or a bech32 or bech32m segwit address
+func validateBIP122Address(address string) error {
+	if _, _, err := decodeBech32(address); err == nil {
+		return nil
+	}
+	payload, err := decodeBase58Check(address)
+	if err != nil {
+		return err
+	}
+	if len(payload) != 21 {
+		return fmt.Errorf("expected a 21 byte version and hash, got %d bytes", len(payload))
+	}
+	return nil
+}
+
+// validateSolanaAddress checks that the address is a base58 ed25519 public key
+func validateSolanaAddress(address string) error {
+	publicKey, err := base58.Decode(address)
+	if err != nil {
+		return errors.Wrap(err, "decoding base58")
+	}
+	if len(publicKey) != ed25519.PublicKeySize {
+		return fmt.Errorf("expected a %d byte public key, got %d bytes", ed25519.PublicKeySize, len(publicKey))
+	}
+	return nil
+}
+
+// validateTezosAddress checks a base58check tz1, tz2, tz3 or KT1 address
+func validateTezosAddress(address string) error {
+	if len(address) < 3 {
+		return fmt.Errorf("unknown address prefix: %s", address)
+	}
+	version, ok := tezosAddressPrefixes[address[:3]]
+	if !ok {
+		return fmt.Errorf("unknown address prefix: %s", address[:3])
+	}
+	payload, err := decodeBase58Check(address)
+	if err != nil {
+		return err
+	}
+	if len(payload) != len(version)+20 || !bytes.HasPrefix(payload, version) {
+		return fmt.Errorf("expected a %s prefixed 20 byte hash", address[:3])
+	}
+	return nil
+}
+
+// decodeBase58Check decodes a base58 string whose last four bytes are the first four of its double SHA-256
+func decodeBase58Check(s string) ([]byte, error) {
+	decoded, err := base58.Decode(s)
+	if err != nil {
+		return nil, errors.Wrap(err, "decoding base58")
+	}
+	if len(decoded) < 5 {
+		return nil, errors.New("base58check value is too short")
+	}
+	payload, checksum := decoded[:len(decoded)-4], decoded[len(decoded)-4:]
+	first := sha256.Sum256(payload)
+	second := sha256.Sum256(first[:])
+	if !bytes.Equal(second[:4], checksum) {
+		return nil, errors.New("invalid base58check checksum")
+	}
+	return payload, nil
+}
+
+const (
+	bech32Charset        = "qpzry9x8gf2tvdw0s3jn54khce6mua7l"
+	bech32Constant       = 1
+	bech32mConstant      = 0x2bc830a3
+	bech32ChecksumLength = 6
+	bech32MaxLength      = 90
+)
+
+// decodeBech32 decodes a bech32 or bech32m string, returning its human-readable part and 5-bit data
+// https://github.com/bitcoin/bips/blob/master/bip-0173.mediawiki
+// https://github.com/bitcoin/bips/blob/master/bip-0350.mediawiki
+func decodeBech32(s string) (string, []byte, error) {
+	if len(s) > bech32MaxLength {
+		return "", nil, fmt.Errorf("bech32 value is longer than %d characters", bech32MaxLength)
+	}
+	if strings.ToLower(s) != s && strings.ToUpper(s) != s {
+		return "", nil, errors.New("bech32 value is mixed case")
+	}
+	s = strings.ToLower(s)
+	separator := strings.LastIndexByte(s, '1')
+	if separator < 1 || separator+bech32ChecksumLength+1 > len(s) {
+		return "", nil, errors.New("invalid bech32 separator position")
+	}
+	hrp := s[:separator]
+	for _, c := range hrp {
+		if c < 33 || c > 126 {
+			return "", nil, errors.New("invalid bech32 human-readable part")
+		}
+	}
+	data := make([]byte, 0, len(s)-separator-1)
+	for _, c := range s[separator+1:] {
+		i := strings.IndexRune(bech32Charset, c)
+		if i < 0 {
+			return "", nil, fmt.Errorf("invalid bech32 character: %c", c)
+		}
+		data = append(data, byte(i))
+	}
+	if checksum := bech32Polymod(append(bech32ExpandHRP(hrp), data...)); checksum != bech32Constant && checksum != bech32mConstant {
+		return "", nil, errors.New("invalid bech32 checksum")
+	}
+	return hrp, data[:len(data)-bech32ChecksumLength], nil
+}
+
+func bech32ExpandHRP(hrp string) []byte {
+	expanded := make([]byte, 0, len(hrp)*2+1)
+	for _, c := range hrp {
+		expanded = append(expanded, byte(c>>5))
+	}
+	expanded = append(expanded, 0)
+	for _, c := range hrp {
+		expanded = append(expanded, byte(c&31))
+	}
+	return expanded
+}
+
+func bech32Polymod(values []byte) uint32 {
+	generator := [5]uint32{0x3b6a57b2, 0x26508e6d, 0x1ea119fa, 0x3d4233dd, 0x2a1462b3}
+	checksum := uint32(1)
+	for _, v := range values {
+		top := checksum >> 25
+		checksum = (checksum&0x1ffffff)<<5 ^ uint32(v)
+		for i := 0; i < 5; i++ {
+			if (top>>uint(i))&1 == 1 {
+				checksum ^= generator[i]
+			}
+		}
+	}
+	return checksum
+}
@@ -0,0 +1,107 @@
+package pkh
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// CAIP-2 and CAIP-10 syntax
+// https://github.com/ChainAgnostic/CAIPs/blob/main/CAIPs/caip-2.md
+// https://github.com/ChainAgnostic/CAIPs/blob/main/CAIPs/caip-10.md
+var (
+	namespaceRegex      = regexp.MustCompile(`^[-a-z0-9]{3,8}$`)
+	referenceRegex      = regexp.MustCompile(`^[-_a-zA-Z0-9]{1,32}$`)
+	accountAddressRegex = regexp.MustCompile(`^[-.%a-zA-Z0-9]{1,128}$`)
+
+	// eip155 references are decimal chain ids
+	eip155ReferenceRegex = regexp.MustCompile(`^[0-9]{1,32}$`)
+)
+
+// Known CAIP-2 namespaces
+const (
+	BIP122Namespace = "bip122"
+	EIP155Namespace = "eip155"
+	SolanaNamespace = "solana"
+	TezosNamespace  = "tezos"
+	CosmosNamespace = "cosmos"
+)
+
+// ChainID is a CAIP-2 blockchain id: namespace:reference
+type ChainID struct {
+	Namespace string
+	Reference string
+}
+
+// ParseChainID parses a CAIP-2 chain id, such as eip155:1
+func ParseChainID(chainID string) (*ChainID, error) {
+	namespace, reference, found := strings.Cut(chainID, ":")
+	if !found {
+		return nil, fmt.Errorf("invalid chain id, expected namespace:reference: %s", chainID)
+	}
+	c := ChainID{Namespace: namespace, Reference: reference}
+	if err := c.Validate(); err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
+
+// Validate checks the syntax of the chain id
+func (c ChainID) Validate() error {
+	if !namespaceRegex.MatchString(c.Namespace) {
+		return fmt.Errorf("invalid chain id namespace: %s", c.Namespace)
+	}
+	if !referenceRegex.MatchString(c.Reference) {
+		return fmt.Errorf("invalid chain id reference: %s", c.Reference)
+	}
+	if c.Namespace == EIP155Namespace && !eip155ReferenceRegex.MatchString(c.Reference) {
+		return fmt.Errorf("invalid eip155 chain id reference: %s", c.Reference)
+	}
+	return nil
+}
+
+func (c ChainID) String() string {
+	return c.Namespace + ":" + c.Reference
+}
+
+// AccountID is a CAIP-10 account id: chain_id:account_address
+type AccountID struct {
+	ChainID
+	Address string
+}
+
+// ParseAccountID parses a CAIP-10 account id, such as eip155:1:0xb9c5714089478a327f09197987f16f9e5d936e8a, and
+// validates its address for the known namespaces
+func ParseAccountID(accountID string) (*AccountID, error) {
+	split := strings.Split(accountID, ":")
+	if len(split) != 3 {
+		return nil, fmt.Errorf("invalid account id, expected namespace:reference:address: %s", accountID)
+	}
+	a := AccountID{ChainID: ChainID{Namespace: split[0], Reference: split[1]}, Address: split[2]}
+	if err := a.Validate(); err != nil {
+		return nil, err
+	}
+	return &a, nil
+}
+
+// Validate checks the syntax of the account id, and the address for the known namespaces: EIP-55 checksums for
+// eip155, base58check or bech32 for bip122, base58 public keys for solana, base58check for tezos, and bech32 for
+// cosmos
+func (a AccountID) Validate() error {
+	if err := a.ChainID.Validate(); err != nil {
+		return err
+	}
+	if !accountAddressRegex.MatchString(a.Address) {
+		return fmt.Errorf("invalid account address: %s", a.Address)
+	}
+	if err := validateAddress(a.Namespace, a.Address); err != nil {
+		return errors.Wrapf(err, "invalid %s address", a.Namespace)
+	}
+	return nil
+}
+
+func (a AccountID) String() string {
+	return a.ChainID.String() + ":" + a.Address
+}
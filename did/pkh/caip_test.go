@@ -0,0 +1,90 @@
+package pkh
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseChainID(t *testing.T) {
+	chainID, err := ParseChainID("eip155:137")
+	require.NoError(t, err)
+	assert.Equal(t, ChainID{Namespace: EIP155Namespace, Reference: "137"}, *chainID)
+	assert.Equal(t, PolygonNetworkPrefix, chainID.String())
+
+	chainID, err = ParseChainID("cosmos:cosmoshub-4")
+	require.NoError(t, err)
+	assert.Equal(t, "cosmoshub-4", chainID.Reference)
+
+	for _, invalid := range []string{"", "eip155", "eip155:", ":1", "EIP155:1", "ns:1", "eip155:mainnet", "bip122:" + string(make([]byte, 33))} {
+		_, err = ParseChainID(invalid)
+		assert.Error(t, err, invalid)
+	}
+}
+
+func TestParseAccountID(t *testing.T) {
+	tests := []struct {
+		accountID string
+		err       string
+	}{
+		{"eip155:1:0xb9c5714089478a327f09197987f16f9e5d936e8a", ""},
+		{"eip155:1:0xB9C5714089478A327F09197987F16F9E5D936E8A", ""},
+		{"eip155:1:0xCD2a3d9F938E13CD947Ec05AbC7FE734Df8DD826", ""},
+		{"eip155:1:0xCD2a3d9F938E13CD947Ec05AbC7FE734Df8DD827", "invalid EIP-55 checksum"},
+		{"eip155:1:0xb9c5714089478a327f09197987f16f9e5d936e8", "expected 0x followed by 40 hex characters"},
+		{"bip122:000000000019d6689c085ae165831e93:128Lkh3S7CkDTBZ8W7BbpsN3YYizJMp8p6", ""},
+		{"bip122:000000000019d6689c085ae165831e93:128Lkh3S7CkDTBZ8W7BbpsN3YYizJMp8p7", "invalid base58check checksum"},
+		{"bip122:000000000019d6689c085ae165831e93:bc1qar0srrr7xfkvy5l643lydnw9re59gtzzwf5mdq", ""},
+		{"bip122:000000000019d6689c085ae165831e93:bc1p5d7rjq7g6rdk2yhzks9smlaqtedr4dekq08ge8ztwac72sfr9rusxg3297", ""},
+		{"bip122:000000000019d6689c085ae165831e93:bc1qar0srrr7xfkvy5l643lydnw9re59gtzzwf5mdr", "invalid bip122 address"},
+		{"solana:4sGjMW1sUnHzSxGspuhpqLDx6wiyjNtZ:CKg5d12Jhpej1JqtmxLJgaFqqeYjxgPqToJ4LBdvG9Ev", ""},
+		{"solana:4sGjMW1sUnHzSxGspuhpqLDx6wiyjNtZ:CKg5d12Jhpej1Jqtmx", "expected a 32 byte public key"},
+		{"tezos:NetXdQprcVkpaWU:tz1TzrmTBSuiVHV2VfMnGRMYvTEPCP42oSM8", ""},
+		{"tezos:NetXdQprcVkpaWU:tz2BFTyPeYRzxd5aiBchbXN3WCZhx7BqbMBq", ""},
+		{"tezos:NetXdQprcVkpaWU:tz3agP9LGe2cXmKQyYn6T68BHKjjktDbbSWX", ""},
+		{"tezos:NetXdQprcVkpaWU:tz4agP9LGe2cXmKQyYn6T68BHKjjktDbbSWX", "unknown address prefix: tz4"},
+		{"tezos:NetXdQprcVkpaWU:tz1TzrmTBSuiVHV2VfMnGRMYvTEPCP42oSM9", "invalid base58check checksum"},
+		{"cosmos:cosmoshub-3:cosmos1t2uflqwqe0fsj0shcfkrvpukewcw40yjj6hdc0", ""},
+		{"cosmos:cosmoshub-3:cosmos1t2uflqwqe0fsj0shcfkrvpukewcw40yjj6hdcq", "invalid bech32 checksum"},
+		{"cosmos:cosmoshub-3:Cosmos1t2uflqwqe0fsj0shcfkrvpukewcw40yjj6hdc0", "mixed case"},
+		{"other:chain:any-address.1", ""},
+		{"other:chain:bad/address", "invalid account address"},
+		{"eip155:1", "expected namespace:reference:address"},
+	}
+	for _, test := range tests {
+		t.Run(test.accountID, func(tt *testing.T) {
+			accountID, err := ParseAccountID(test.accountID)
+			if test.err != "" {
+				assert.ErrorContains(tt, err, test.err)
+				return
+			}
+			require.NoError(tt, err)
+			assert.Equal(tt, test.accountID, accountID.String())
+		})
+	}
+}
+
+func TestVerificationTypes(t *testing.T) {
+	tests := map[string]string{
+		"did:pkh:eip155:8453:0xb9c5714089478a327f09197987f16f9e5d936e8a":                               ECDSASECP256k1RecoveryMethod2020,
+		"did:pkh:cosmos:cosmoshub-4:cosmos1t2uflqwqe0fsj0shcfkrvpukewcw40yjj6hdc0":                     ECDSASECP256k1RecoveryMethod2020,
+		"did:pkh:solana:4sGjMW1sUnHzSxGspuhpqLDx6wiyjNtZ:CKg5d12Jhpej1JqtmxLJgaFqqeYjxgPqToJ4LBdvG9Ev": Ed25519VerificationKey2018,
+		"did:pkh:tezos:NetXdQprcVkpaWU:tz1TzrmTBSuiVHV2VfMnGRMYvTEPCP42oSM8":                           Ed25519PublicKeyBLAKE2BDigestSize20Base58CheckEncoded2021,
+		"did:pkh:tezos:NetXdQprcVkpaWU:tz2BFTyPeYRzxd5aiBchbXN3WCZhx7BqbMBq":                           ECDSASECP256k1RecoveryMethod2020,
+		"did:pkh:tezos:NetXdQprcVkpaWU:tz3agP9LGe2cXmKQyYn6T68BHKjjktDbbSWX":                           P256PublicKeyBLAKE2BDigestSize20Base58CheckEncoded2021,
+	}
+	for id, verificationType := range tests {
+		t.Run(id, func(tt *testing.T) {
+			doc, err := PKH(id).Expand()
+			require.NoError(tt, err)
+			assert.Equal(tt, verificationType, string(doc.VerificationMethod[0].Type))
+		})
+	}
+
+	_, err := PKH("did:pkh:tezos:NetXdQprcVkpaWU:KT1BEqzn5Wx8uJrZNvuS9DVHmLvG9td3fDLi").Expand()
+	assert.ErrorContains(t, err, "unsupported tezos account")
+
+	_, err = PKH("did:pkh:unknown:chain:address").Expand()
+	assert.ErrorContains(t, err, "unsupported did:pkh namespace: unknown")
+}
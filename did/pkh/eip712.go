@@ -0,0 +1,283 @@
+package pkh
+
+import (
+	"encoding/hex"
+	"fmt"
+	"math/big"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/extrimian/ssi-sdk/crypto"
+)
+
+const (
+	eip712DomainType  = "EIP712Domain"
+	eip712AddressType = "address"
+)
+
+var (
+	eip712ArrayType  = regexp.MustCompile(`^(.+)\[(\d*)]$`)
+	eip712IntType    = regexp.MustCompile(`^(u?)int(\d*)$`)
+	eip712BytesNType = regexp.MustCompile(`^bytes(\d+)$`)
+)
+
+// TypedDataField is a named and typed member of an EIP-712 struct type
+type TypedDataField struct {
+	Name string `json:"name"`
+	Type string `json:"type"`
+}
+
+// TypedData is an EIP-712 typed structured data message, as signed by eth_signTypedData_v4
+// https://eips.ethereum.org/EIPS/eip-712
+type TypedData struct {
+	Types       map[string][]TypedDataField `json:"types"`
+	PrimaryType string                      `json:"primaryType"`
+	Domain      map[string]any              `json:"domain"`
+	Message     map[string]any              `json:"message"`
+}
+
+// Hash returns the EIP-712 signing hash of the typed data:
+// keccak256("\x19\x01" ‖ hashStruct(domain) ‖ hashStruct(message))
+func (td TypedData) Hash() ([]byte, error) {
+	if td.PrimaryType == "" {
+		return nil, errors.New("typed data must have a primary type")
+	}
+	if _, ok := td.Types[eip712DomainType]; !ok {
+		return nil, errors.Errorf("typed data must define the %s type", eip712DomainType)
+	}
+	domainSeparator, err := td.HashStruct(eip712DomainType, td.Domain)
+	if err != nil {
+		return nil, errors.Wrap(err, "hashing domain")
+	}
+	messageHash, err := td.HashStruct(td.PrimaryType, td.Message)
+	if err != nil {
+		return nil, errors.Wrap(err, "hashing message")
+	}
+	return crypto.Keccak256([]byte{0x19, 0x01}, domainSeparator, messageHash), nil
+}
+
+// HashStruct returns keccak256(typeHash ‖ encodeData(data)) of a struct of the named type
+func (td TypedData) HashStruct(typeName string, data map[string]any) ([]byte, error) {
+	encoded, err := td.encodeData(typeName, data)
+	if err != nil {
+		return nil, err
+	}
+	return crypto.Keccak256(encoded), nil
+}
+
+// EncodeType returns the type encoding of the named type, followed by the encodings of the struct types it
+// references, sorted by name, e.g. Mail(Person from,Person to,string contents)Person(string name,address wallet)
+func (td TypedData) EncodeType(typeName string) (string, error) {
+	deps := make(map[string]bool)
+	if err := td.dependencies(typeName, deps); err != nil {
+		return "", err
+	}
+	delete(deps, typeName)
+	sorted := make([]string, 0, len(deps))
+	for dep := range deps {
+		sorted = append(sorted, dep)
+	}
+	sort.Strings(sorted)
+
+	var b strings.Builder
+	for _, t := range append([]string{typeName}, sorted...) {
+		fields := make([]string, 0, len(td.Types[t]))
+		for _, field := range td.Types[t] {
+			fields = append(fields, field.Type+" "+field.Name)
+		}
+		b.WriteString(t + "(" + strings.Join(fields, ",") + ")")
+	}
+	return b.String(), nil
+}
+
+func (td TypedData) dependencies(typeName string, deps map[string]bool) error {
+	if deps[typeName] {
+		return nil
+	}
+	fields, ok := td.Types[typeName]
+	if !ok {
+		return errors.Errorf("unknown type: %s", typeName)
+	}
+	deps[typeName] = true
+	for _, field := range fields {
+		baseType := field.Type
+		for match := eip712ArrayType.FindStringSubmatch(baseType); match != nil; match = eip712ArrayType.FindStringSubmatch(baseType) {
+			baseType = match[1]
+		}
+		if _, ok = td.Types[baseType]; ok {
+			if err := td.dependencies(baseType, deps); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (td TypedData) typeHash(typeName string) ([]byte, error) {
+	encodedType, err := td.EncodeType(typeName)
+	if err != nil {
+		return nil, err
+	}
+	return crypto.Keccak256([]byte(encodedType)), nil
+}
+
+func (td TypedData) encodeData(typeName string, data map[string]any) ([]byte, error) {
+	typeHash, err := td.typeHash(typeName)
+	if err != nil {
+		return nil, err
+	}
+	encoded := typeHash
+	for _, field := range td.Types[typeName] {
+		value, err := td.encodeValue(field.Type, data[field.Name])
+		if err != nil {
+			return nil, errors.Wrapf(err, "encoding %s.%s", typeName, field.Name)
+		}
+		encoded = append(encoded, value...)
+	}
+	return encoded, nil
+}
+
+// encodeValue encodes a value of a type as 32 bytes
+func (td TypedData) encodeValue(typeName string, value any) ([]byte, error) {
+	if _, ok := td.Types[typeName]; ok {
+		data, ok := value.(map[string]any)
+		if !ok {
+			return nil, errors.Errorf("expected an object for %s, got %T", typeName, value)
+		}
+		return td.HashStruct(typeName, data)
+	}
+	if match := eip712ArrayType.FindStringSubmatch(typeName); match != nil {
+		items, ok := value.([]any)
+		if !ok {
+			return nil, errors.Errorf("expected an array for %s, got %T", typeName, value)
+		}
+		if match[2] != "" && strconv.Itoa(len(items)) != match[2] {
+			return nil, errors.Errorf("expected %s items for %s, got %d", match[2], typeName, len(items))
+		}
+		var encoded []byte
+		for _, item := range items {
+			itemValue, err := td.encodeValue(match[1], item)
+			if err != nil {
+				return nil, err
+			}
+			encoded = append(encoded, itemValue...)
+		}
+		return crypto.Keccak256(encoded), nil
+	}
+
+	switch {
+	case typeName == "string":
+		s, ok := value.(string)
+		if !ok {
+			return nil, errors.Errorf("expected a string, got %T", value)
+		}
+		return crypto.Keccak256([]byte(s)), nil
+	case typeName == "bytes":
+		b, err := hexBytes(value)
+		if err != nil {
+			return nil, err
+		}
+		return crypto.Keccak256(b), nil
+	case typeName == "bool":
+		b, ok := value.(bool)
+		if !ok {
+			return nil, errors.Errorf("expected a bool, got %T", value)
+		}
+		if b {
+			return leftPad32(big.NewInt(1).Bytes()), nil
+		}
+		return make([]byte, 32), nil
+	case typeName == eip712AddressType:
+		b, err := hexBytes(value)
+		if err != nil {
+			return nil, err
+		}
+		if len(b) != 20 {
+			return nil, errors.Errorf("address must be 20 bytes, got %d", len(b))
+		}
+		return leftPad32(b), nil
+	case eip712BytesNType.MatchString(typeName):
+		size, _ := strconv.Atoi(eip712BytesNType.FindStringSubmatch(typeName)[1])
+		b, err := hexBytes(value)
+		if err != nil {
+			return nil, err
+		}
+		if size < 1 || size > 32 || len(b) != size {
+			return nil, errors.Errorf("invalid %s value of %d bytes", typeName, len(b))
+		}
+		return append(b, make([]byte, 32-len(b))...), nil
+	case eip712IntType.MatchString(typeName):
+		match := eip712IntType.FindStringSubmatch(typeName)
+		return encodeInt(value, match[1] == "u", match[2])
+	}
+	return nil, errors.Errorf("unsupported type: %s", typeName)
+}
+
+// encodeInt encodes an integer given as a JSON number, or a decimal or hex string, as a 32 byte two's complement
+func encodeInt(value any, unsigned bool, bits string) ([]byte, error) {
+	size := 256
+	if bits != "" {
+		size, _ = strconv.Atoi(bits)
+	}
+	if size < 8 || size > 256 || size%8 != 0 {
+		return nil, errors.Errorf("invalid integer size: %d", size)
+	}
+
+	n := new(big.Int)
+	switch v := value.(type) {
+	case float64:
+		if v != float64(int64(v)) {
+			return nil, errors.Errorf("expected an integer, got %v", v)
+		}
+		n.SetInt64(int64(v))
+	case int:
+		n.SetInt64(int64(v))
+	case int64:
+		n.SetInt64(v)
+	case string:
+		if _, ok := n.SetString(v, 0); !ok {
+			return nil, errors.Errorf("invalid integer: %s", v)
+		}
+	default:
+		return nil, errors.Errorf("expected an integer, got %T", value)
+	}
+
+	limit := new(big.Int).Lsh(big.NewInt(1), uint(size))
+	if unsigned {
+		if n.Sign() < 0 || n.Cmp(limit) >= 0 {
+			return nil, fmt.Errorf("%s out of range for uint%d", n, size)
+		}
+		return leftPad32(n.Bytes()), nil
+	}
+	half := new(big.Int).Rsh(limit, 1)
+	if n.Cmp(half) >= 0 || n.Cmp(new(big.Int).Neg(half)) < 0 {
+		return nil, fmt.Errorf("%s out of range for int%d", n, size)
+	}
+	if n.Sign() < 0 {
+		// two's complement over 256 bits
+		n.Add(n, new(big.Int).Lsh(big.NewInt(1), 256))
+	}
+	return leftPad32(n.Bytes()), nil
+}
+
+func hexBytes(value any) ([]byte, error) {
+	s, ok := value.(string)
+	if !ok {
+		return nil, errors.Errorf("expected a hex string, got %T", value)
+	}
+	b, err := hex.DecodeString(strings.TrimPrefix(s, "0x"))
+	if err != nil {
+		return nil, errors.Wrapf(err, "decoding hex string: %s", s)
+	}
+	return b, nil
+}
+
+func leftPad32(b []byte) []byte {
+	padded := make([]byte, 32)
+	copy(padded[32-len(b):], b)
+	return padded
+}
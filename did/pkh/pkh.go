@@ -3,7 +3,6 @@ package pkh
 import (
 	"embed"
 	"fmt"
-	"regexp"
 	"strings"
 
 	"github.com/extrimian/ssi-sdk/cryptosuite"
@@ -33,16 +32,50 @@ const (
 	Bitcoin  Network = "Bitcoin"
 	Ethereum Network = "Ethereum"
 	Polygon  Network = "Polygon"
+	Solana   Network = "Solana"
+	Tezos    Network = "Tezos"
+	Cosmos   Network = "Cosmos"
+	Arbitrum Network = "Arbitrum"
+	Optimism Network = "Optimism"
+	Base     Network = "Base"
+	ZkSync   Network = "zkSync"
 )
 
 const (
 	BitcoinNetworkPrefix  = "bip122:000000000019d6689c085ae165831e93"
 	EthereumNetworkPrefix = "eip155:1"
 	PolygonNetworkPrefix  = "eip155:137"
-
-	ECDSASECP256k1RecoveryMethod2020 = "EcdsaSecp256k1RecoveryMethod2020"
+	SolanaNetworkPrefix   = "solana:4sGjMW1sUnHzSxGspuhpqLDx6wiyjNtZ"
+	TezosNetworkPrefix    = "tezos:NetXdQprcVkpaWU"
+	CosmosNetworkPrefix   = "cosmos:cosmoshub-4"
+	ArbitrumNetworkPrefix = "eip155:42161"
+	OptimismNetworkPrefix = "eip155:10"
+	BaseNetworkPrefix     = "eip155:8453"
+	ZkSyncNetworkPrefix   = "eip155:324"
+
+	ECDSASECP256k1RecoveryMethod2020                          = "EcdsaSecp256k1RecoveryMethod2020"
+	Ed25519VerificationKey2018                                = "Ed25519VerificationKey2018"
+	Ed25519PublicKeyBLAKE2BDigestSize20Base58CheckEncoded2021 = "Ed25519PublicKeyBLAKE2BDigestSize20Base58CheckEncoded2021"
+	P256PublicKeyBLAKE2BDigestSize20Base58CheckEncoded2021    = "P256PublicKeyBLAKE2BDigestSize20Base58CheckEncoded2021"
 )
 
+// networkPrefixes are the CAIP-2 chain ids of the supported networks, in the order they are listed
+var networkPrefixes = []struct {
+	network Network
+	prefix  string
+}{
+	{Bitcoin, BitcoinNetworkPrefix},
+	{Ethereum, EthereumNetworkPrefix},
+	{Polygon, PolygonNetworkPrefix},
+	{Solana, SolanaNetworkPrefix},
+	{Tezos, TezosNetworkPrefix},
+	{Cosmos, CosmosNetworkPrefix},
+	{Arbitrum, ArbitrumNetworkPrefix},
+	{Optimism, OptimismNetworkPrefix},
+	{Base, BaseNetworkPrefix},
+	{ZkSync, ZkSyncNetworkPrefix},
+}
+
 // GetDIDPKHContext returns a context which should be manually inserted into each did:pkh document. This will likely
 // change over time as new verification methods are supported, and general-purpose methods are specified.
 func GetDIDPKHContext() (string, error) {
@@ -89,62 +122,102 @@ func (d PKH) Suffix() (string, error) {
 	return split[1], nil
 }
 
+// AccountID parses the CAIP-10 account id of the did:pkh
+func (d PKH) AccountID() (*AccountID, error) {
+	suffix, found := strings.CutPrefix(string(d), DIDPKHPrefix+":")
+	if !found {
+		return nil, fmt.Errorf("not a did:pkh DID: %s", d)
+	}
+	return ParseAccountID(suffix)
+}
+
 func (PKH) Method() did.Method {
 	return did.PKHMethod
 }
 
 // GetDIDPKHPrefixForNetwork returns the did:pkh prefix for a given network
 func GetDIDPKHPrefixForNetwork(n Network) (string, error) {
-	switch n {
-	case Bitcoin:
-		return BitcoinNetworkPrefix, nil
-	case Ethereum:
-		return EthereumNetworkPrefix, nil
-	case Polygon:
-		return PolygonNetworkPrefix, nil
+	for _, np := range networkPrefixes {
+		if np.network == n {
+			return np.prefix, nil
+		}
 	}
 	return "", fmt.Errorf("unsupported did:pkh network: %s", n)
 }
 
 // GetDIDPKHNetworkForPrefix returns the did:pkh network for a given prefix
 func GetDIDPKHNetworkForPrefix(p string) (Network, error) {
-	switch p {
-	case BitcoinNetworkPrefix:
-		return Bitcoin, nil
-	case EthereumNetworkPrefix:
-		return Ethereum, nil
-	case PolygonNetworkPrefix:
-		return Polygon, nil
+	for _, np := range networkPrefixes {
+		if np.prefix == p {
+			return np.network, nil
+		}
 	}
 	return "", fmt.Errorf("unsupported did:pkh prefix: %s", p)
 }
 
 // GetDIDPKHNetworkForDID returns the network for a given did:pkh
 func GetDIDPKHNetworkForDID(id string) (Network, error) {
-	prefixes := GetDIDPKHNetworkPrefixes()
-	for _, prefix := range prefixes {
-		if strings.Contains(id, prefix+":") {
-			return GetDIDPKHNetworkForPrefix(prefix)
-		}
+	accountID, err := PKH(id).AccountID()
+	if err != nil {
+		return "", errors.Wrapf(err, "could not find network for did:pkh DID: %s", id)
+	}
+	network, err := GetDIDPKHNetworkForPrefix(accountID.ChainID.String())
+	if err != nil {
+		return "", errors.Wrapf(err, "could not find network for did:pkh DID: %s", id)
 	}
-	return "", fmt.Errorf("could not find network for did:pkh DID: %s", id)
+	return network, nil
 }
 
-// GetVerificationTypeForNetwork returns the verification key type for a given network
+// GetVerificationTypeForNetwork returns the verification key type for a given network. Tezos accounts may also be
+// verified with other types depending on their address; see GetVerificationTypeForAccount.
 func GetVerificationTypeForNetwork(n Network) (string, error) {
 	switch n {
-	case Bitcoin, Ethereum, Polygon:
+	case Bitcoin, Ethereum, Polygon, Cosmos, Arbitrum, Optimism, Base, ZkSync:
 		return ECDSASECP256k1RecoveryMethod2020, nil
+	case Solana:
+		return Ed25519VerificationKey2018, nil
+	case Tezos:
+		return Ed25519PublicKeyBLAKE2BDigestSize20Base58CheckEncoded2021, nil
 	}
 	return "", fmt.Errorf("unsupported did:pkh network: %s", n)
 }
 
+// GetVerificationTypeForAccount returns the verification key type for an account of a known namespace, which for
+// tezos depends on the curve of the account's address: tz1 for ed25519, tz2 for secp256k1 and tz3 for P-256
+func GetVerificationTypeForAccount(accountID AccountID) (string, error) {
+	switch accountID.Namespace {
+	case BIP122Namespace, EIP155Namespace, CosmosNamespace:
+		return ECDSASECP256k1RecoveryMethod2020, nil
+	case SolanaNamespace:
+		return Ed25519VerificationKey2018, nil
+	case TezosNamespace:
+		switch {
+		case strings.HasPrefix(accountID.Address, "tz1"):
+			return Ed25519PublicKeyBLAKE2BDigestSize20Base58CheckEncoded2021, nil
+		case strings.HasPrefix(accountID.Address, "tz2"):
+			return ECDSASECP256k1RecoveryMethod2020, nil
+		case strings.HasPrefix(accountID.Address, "tz3"):
+			return P256PublicKeyBLAKE2BDigestSize20Base58CheckEncoded2021, nil
+		}
+		return "", fmt.Errorf("unsupported tezos account: %s", accountID.Address)
+	}
+	return "", fmt.Errorf("unsupported did:pkh namespace: %s", accountID.Namespace)
+}
+
 func GetSupportedPKHNetworks() []Network {
-	return []Network{Bitcoin, Ethereum, Polygon}
+	networks := make([]Network, 0, len(networkPrefixes))
+	for _, np := range networkPrefixes {
+		networks = append(networks, np.network)
+	}
+	return networks
 }
 
 func GetDIDPKHNetworkPrefixes() []string {
-	return []string{BitcoinNetworkPrefix, EthereumNetworkPrefix, PolygonNetworkPrefix}
+	prefixes := make([]string, 0, len(networkPrefixes))
+	for _, np := range networkPrefixes {
+		prefixes = append(prefixes, np.prefix)
+	}
+	return prefixes
 }
 
 // Expand turns the DID key into a complaint DID Document
@@ -181,67 +254,39 @@ func (d PKH) Expand() (*did.Document, error) {
 }
 
 func constructPKHVerificationMethod(didPKH PKH) (*did.VerificationMethod, error) {
-	if !IsValidPKH(didPKH) {
-		parsed, err := didPKH.Suffix()
-		if err != nil || parsed == "" {
-			return nil, errors.Wrap(err, "PKH DID is not valid")
-		}
-	}
-
-	network, err := GetDIDPKHNetworkForDID(didPKH.String())
+	accountID, err := didPKH.AccountID()
 	if err != nil {
-		return nil, errors.Wrap(err, "finding network")
+		return nil, errors.Wrap(err, "PKH DID is not valid")
 	}
-	verificationType, err := GetVerificationTypeForNetwork(network)
+	verificationType, err := GetVerificationTypeForAccount(*accountID)
 	if err != nil {
 		return nil, errors.Wrap(err, "finding verification type")
 	}
 
-	suffix, err := didPKH.Suffix()
-	if err != nil {
-		return nil, err
-	}
-	return &did.VerificationMethod{
+	verificationMethod := did.VerificationMethod{
 		ID:                  string(didPKH) + "#blockchainAccountId",
 		Type:                cryptosuite.LDKeyType(verificationType),
 		Controller:          string(didPKH),
-		BlockchainAccountID: suffix,
-	}, nil
+		BlockchainAccountID: accountID.String(),
+	}
+	// solana addresses are base58 encoded ed25519 public keys
+	if accountID.Namespace == SolanaNamespace {
+		verificationMethod.PublicKeyBase58 = accountID.Address
+	}
+	return &verificationMethod, nil
 }
 
 // IsValidPKH checks if a pkh did is valid based on the following parameters:
 // pkh-did    = "did:pkh:" address
 // address    = account_id according to [CAIP-10]
 // account_id:        chain_id + ":" + account_address
-// chain_id:          [-a-z0-9]{3,8}:[-a-zA-Z0-9]{1,32}
-// account_address:   [a-zA-Z0-9]{1,64}
+// chain_id:          [-a-z0-9]{3,8}:[-_a-zA-Z0-9]{1,32}
+// account_address:   [-.%a-zA-Z0-9]{1,128}
 // chain_id:    namespace + ":" + reference
 // namespace:   [-a-z0-9]{3,8}
-// reference:   [-a-zA-Z0-9]{1,32}
+// reference:   [-_a-zA-Z0-9]{1,32}
+// Addresses of known namespaces are also validated, see AccountID.Validate.
 func IsValidPKH(id PKH) bool {
-	split := strings.Split(string(id), ":")
-
-	if len(split) != 5 || (split[0]+":"+split[1]) != DIDPKHPrefix {
-		return false
-	}
-
-	// namespace
-	matched, err := regexp.MatchString(`[-a-z0-9]{3,8}`, split[2])
-	if !matched || err != nil {
-		return false
-	}
-
-	// reference
-	matched, err = regexp.MatchString(`[-a-zA-Z0-9]{1,32}`, split[3])
-	if !matched || err != nil {
-		return false
-	}
-
-	// account_address
-	matched, err = regexp.MatchString(`[a-zA-Z0-9]{1,64}`, split[4])
-	if !matched || err != nil {
-		return false
-	}
-
-	return true
+	_, err := id.AccountID()
+	return err == nil
 }
@@ -21,10 +21,24 @@ var (
 	testVectorPKHDIDFS embed.FS
 )
 
+var testAddresses = map[Network]string{
+	Bitcoin:  "128Lkh3S7CkDTBZ8W7BbpsN3YYizJMp8p6",
+	Ethereum: "0xb9c5714089478a327f09197987f16f9e5d936e8a",
+	Polygon:  "0xb9c5714089478a327f09197987f16f9e5d936e8a",
+	Solana:   "CKg5d12Jhpej1JqtmxLJgaFqqeYjxgPqToJ4LBdvG9Ev",
+	Tezos:    "tz1TzrmTBSuiVHV2VfMnGRMYvTEPCP42oSM8",
+	Cosmos:   "cosmos1t2uflqwqe0fsj0shcfkrvpukewcw40yjj6hdc0",
+	Arbitrum: "0xb9c5714089478a327f09197987f16f9e5d936e8a",
+	Optimism: "0xb9c5714089478a327f09197987f16f9e5d936e8a",
+	Base:     "0xb9c5714089478a327f09197987f16f9e5d936e8a",
+	ZkSync:   "0xb9c5714089478a327f09197987f16f9e5d936e8a",
+}
+
 var pkhTestVectors = map[Network][]string{
 	Bitcoin:  {BitcoinNetworkPrefix, "did-pkh-bitcoin-doc.json"},
 	Ethereum: {EthereumNetworkPrefix, "did-pkh-ethereum-doc.json"},
 	Polygon:  {PolygonNetworkPrefix, "did-pkh-polygon-doc.json"},
+	Solana:   {SolanaNetworkPrefix, "did-pkh-solana-doc.json"},
 }
 
 func TestDIDPKHVectors(t *testing.T) {
@@ -127,6 +141,13 @@ func TestIsValidPKH(t *testing.T) {
 	// Solana
 	assert.True(t, IsValidPKH("did:pkh:solana:4sGjMW1sUnHzSxGspuhpqLDx6wiyjNtZ:CKg5d12Jhpej1JqtmxLJgaFqqeYjxgPqToJ4LBdvG9Ev"))
 
+	// Tezos
+	assert.True(t, IsValidPKH("did:pkh:tezos:NetXdQprcVkpaWU:tz1TzrmTBSuiVHV2VfMnGRMYvTEPCP42oSM8"))
+	// Cosmos
+	assert.True(t, IsValidPKH("did:pkh:cosmos:cosmoshub-3:cosmos1t2uflqwqe0fsj0shcfkrvpukewcw40yjj6hdc0"))
+	// Unknown namespaces are only checked for syntax
+	assert.True(t, IsValidPKH("did:pkh:unknown:chain:address"))
+
 	// Invalid DIDs
 	assert.False(t, IsValidPKH(""))
 	assert.False(t, IsValidPKH("notpkh"))
@@ -135,12 +156,19 @@ func TestIsValidPKH(t *testing.T) {
 	assert.False(t, IsValidPKH("did:pkh:eip155:1:"))
 	assert.False(t, IsValidPKH("did:pkh:eip155::0xb9c5714089478a327f09197987f16f9e5d936e8a"))
 	assert.False(t, IsValidPKH("did:pkh:NOCAP:1:0xb9c5714089478a327f09197987f16f9e5d936e8a"))
+	assert.False(t, IsValidPKH("did:pkh:namespacetoolong:1:0xb9c5714089478a327f09197987f16f9e5d936e8a"))
+	assert.False(t, IsValidPKH("did:pkh:eip155:1:0xb9c5714089478a327f09197987f16f9e5d936e8a:extra"))
+	assert.False(t, IsValidPKH("xdid:pkh:eip155:1:0xb9c5714089478a327f09197987f16f9e5d936e8a"))
+	assert.False(t, IsValidPKH("did:pkh:eip155:1:dummyaddress"))
+	assert.False(t, IsValidPKH("did:pkh:eip155:1:0xCD2a3d9F938E13CD947Ec05AbC7FE734Df8DD827"))
+	assert.False(t, IsValidPKH("did:pkh:bip122:000000000019d6689c085ae165831e93:128Lkh3S7CkDTBZ8W7BbpsN3YYizJMp8p7"))
+	assert.False(t, IsValidPKH("did:pkh:solana:4sGjMW1sUnHzSxGspuhpqLDx6wiyjNtZ:dummyaddress"))
 }
 
 func TestGetNetwork(t *testing.T) {
 	t.Run("Test Known Networks", func(tt *testing.T) {
-		for network := range pkhTestVectors {
-			didPKH, err := CreateDIDPKHFromNetwork(network, "dummyaddress")
+		for network, address := range testAddresses {
+			didPKH, err := CreateDIDPKHFromNetwork(network, address)
 			assert.NoError(t, err)
 
 			n, err := GetDIDPKHNetworkForDID(didPKH.String())
@@ -152,7 +180,7 @@ func TestGetNetwork(t *testing.T) {
 
 	// test bad network
 	t.Run("Test Unknown Network", func(tt *testing.T) {
-		_, err := CreateDIDPKHFromNetwork("bad", "dummyaddress")
+		_, err := CreateDIDPKHFromNetwork("bad", testAddresses[Ethereum])
 		assert.Error(tt, err)
 		assert.Contains(tt, err.Error(), "unsupported did:pkh network: bad")
 	})
@@ -166,7 +194,8 @@ func TestGetSupportedNetworks(t *testing.T) {
 		supportedNetworksSet[supportedNetworks[i]] = true
 	}
 
-	for network := range pkhTestVectors {
+	for network := range testAddresses {
 		assert.True(t, supportedNetworksSet[network])
 	}
+	assert.Len(t, supportedNetworks, len(testAddresses))
 }
@@ -2,8 +2,8 @@ package pkh
 
 import (
 	"context"
-	"fmt"
 	"strings"
+	"time"
 
 	"github.com/pkg/errors"
 
@@ -15,16 +15,28 @@ type Resolver struct{}
 
 var _ resolution.Resolver = (*Resolver)(nil)
 
-func (Resolver) Resolve(_ context.Context, id string, _ ...resolution.Option) (*resolution.Result, error) {
+func (Resolver) Resolve(_ context.Context, id string, opts ...resolution.Option) (*resolution.Result, error) {
+	start := time.Now()
 	if !strings.HasPrefix(id, DIDPKHPrefix) {
-		return nil, fmt.Errorf("not a did:pkh DID: %s", id)
+		return nil, resolution.NewResolutionErrorf(resolution.MethodNotSupportedErrorCode, "not a did:pkh DID: %s", id)
+	}
+	options, err := resolution.ParseOptions(opts...)
+	if err != nil {
+		return nil, err
+	}
+	if err = options.RejectVersioned(did.PKHMethod); err != nil {
+		return nil, err
 	}
 	didPKH := PKH(id)
 	doc, err := didPKH.Expand()
 	if err != nil {
-		return nil, errors.Wrapf(err, "could not expand did:pkh DID: %s", id)
+		return nil, resolution.NewResolutionError(resolution.InvalidDIDErrorCode, errors.Wrapf(err, "could not expand did:pkh DID: %s", id))
+	}
+	result := resolution.NewResult(*doc, nil, start)
+	if err = resolution.ValidateResult(result); err != nil {
+		return nil, err
 	}
-	return &resolution.Result{Document: *doc}, nil
+	return options.Apply(result), nil
 }
 
 func (Resolver) Methods() []did.Method {
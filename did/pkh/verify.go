@@ -0,0 +1,105 @@
+package pkh
+
+import (
+	"crypto/ed25519"
+	"fmt"
+
+	"github.com/mr-tron/base58"
+	"github.com/pkg/errors"
+
+	"github.com/extrimian/ssi-sdk/crypto"
+)
+
+// eip191Prefix is prepended to messages signed with Ethereum's personal_sign
+const eip191Prefix = "\x19Ethereum Signed Message:\n"
+
+// Address returns the account address of the did:pkh, the last segment of its CAIP-10 account id
+func (d PKH) Address() (string, error) {
+	accountID, err := d.AccountID()
+	if err != nil {
+		return "", err
+	}
+	return accountID.Address, nil
+}
+
+// VerifyMessage verifies a signature over a message by the account of the did:pkh. Ethereum accounts (eip155)
+// are verified as EIP-191 personal_sign signatures, and Solana accounts as Ed25519 signatures.
+func (d PKH) VerifyMessage(message, signature []byte) error {
+	accountID, err := d.AccountID()
+	if err != nil {
+		return err
+	}
+	switch accountID.Namespace {
+	case EIP155Namespace:
+		return VerifyEIP191(crypto.EthereumAddress(accountID.Address), message, signature)
+	case SolanaNamespace:
+		return VerifySolana(accountID.Address, message, signature)
+	default:
+		return fmt.Errorf("message verification is not supported for did:pkh namespace: %s", accountID.Namespace)
+	}
+}
+
+// VerifyTypedData verifies an EIP-712 signature over typed data by the Ethereum account of the did:pkh
+func (d PKH) VerifyTypedData(typedData TypedData, signature []byte) error {
+	accountID, err := d.AccountID()
+	if err != nil {
+		return err
+	}
+	if accountID.Namespace != EIP155Namespace {
+		return fmt.Errorf("typed data verification is not supported for did:pkh namespace: %s", accountID.Namespace)
+	}
+	return VerifyEIP712(crypto.EthereumAddress(accountID.Address), typedData, signature)
+}
+
+// EIP191Hash returns the hash signed by personal_sign for a message:
+// keccak256("\x19Ethereum Signed Message:\n" ‖ len(message) ‖ message)
+// https://eips.ethereum.org/EIPS/eip-191
+func EIP191Hash(message []byte) []byte {
+	return crypto.Keccak256([]byte(fmt.Sprintf("%s%d", eip191Prefix, len(message))), message)
+}
+
+// VerifyEIP191 verifies a 65 byte R || S || V personal_sign signature over a message by recovering the signer's
+// secp256k1 public key and comparing its address to the given address
+func VerifyEIP191(address crypto.EthereumAddress, message, signature []byte) error {
+	return verifyEthereumSignature(address, EIP191Hash(message), signature)
+}
+
+// VerifyEIP712 verifies a 65 byte R || S || V eth_signTypedData signature over typed data by recovering the signer's
+// secp256k1 public key and comparing its address to the given address
+func VerifyEIP712(address crypto.EthereumAddress, typedData TypedData, signature []byte) error {
+	hash, err := typedData.Hash()
+	if err != nil {
+		return errors.Wrap(err, "hashing typed data")
+	}
+	return verifyEthereumSignature(address, hash, signature)
+}
+
+func verifyEthereumSignature(address crypto.EthereumAddress, hash, signature []byte) error {
+	if !address.IsValid() {
+		return fmt.Errorf("invalid ethereum address: %s", address)
+	}
+	recovered, err := crypto.RecoverSECP256k1PublicKey(hash, signature)
+	if err != nil {
+		return errors.Wrap(err, "recovering signer")
+	}
+	if !address.Matches(*recovered) {
+		return fmt.Errorf("signature was made by %s, not %s", crypto.EthereumAddressFromPublicKey(*recovered), address)
+	}
+	return nil
+}
+
+// VerifySolana verifies an Ed25519 signature over a message by a Solana account, whose base58 address is its
+// public key
+func VerifySolana(address string, message, signature []byte) error {
+	publicKey, err := base58.Decode(address)
+	if err != nil {
+		return errors.Wrapf(err, "decoding solana address: %s", address)
+	}
+	if len(publicKey) != ed25519.PublicKeySize {
+		return fmt.Errorf("solana address must be a %d byte public key, got %d", ed25519.PublicKeySize, len(publicKey))
+	}
+	if !ed25519.Verify(publicKey, message, signature) {
+		return errors.New("invalid solana signature")
+	}
+	return nil
+}
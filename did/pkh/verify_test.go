@@ -0,0 +1,179 @@
+package pkh
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/hex"
+	"testing"
+
+	secp "github.com/decred/dcrd/dcrec/secp256k1/v4"
+	"github.com/goccy/go-json"
+	"github.com/mr-tron/base58"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/extrimian/ssi-sdk/crypto"
+)
+
+// the example from https://eips.ethereum.org/EIPS/eip-712, signed by the account whose private key is keccak256("cow")
+const eip712MailExample = `{
+  "types": {
+    "EIP712Domain": [
+      {"name": "name", "type": "string"},
+      {"name": "version", "type": "string"},
+      {"name": "chainId", "type": "uint256"},
+      {"name": "verifyingContract", "type": "address"}
+    ],
+    "Person": [
+      {"name": "name", "type": "string"},
+      {"name": "wallet", "type": "address"}
+    ],
+    "Mail": [
+      {"name": "from", "type": "Person"},
+      {"name": "to", "type": "Person"},
+      {"name": "contents", "type": "string"}
+    ]
+  },
+  "primaryType": "Mail",
+  "domain": {
+    "name": "Ether Mail",
+    "version": "1",
+    "chainId": 1,
+    "verifyingContract": "0xCcCCccccCCCCcCCCCCCcCcCccCcCCCcCcccccccC"
+  },
+  "message": {
+    "from": {"name": "Cow", "wallet": "0xCD2a3d9F938E13CD947Ec05AbC7FE734Df8DD826"},
+    "to": {"name": "Bob", "wallet": "0xbBbBBBBbbBBBbbbBbbBbbbbBBbBbbbbBbBbbBBbB"},
+    "contents": "Hello, Bob!"
+  }
+}`
+
+const (
+	eip712MailHash      = "be609aee343fb3c4b28e1df9e632fca64fcfaede20f02e86244efddf30957bd2"
+	eip712MailSignature = "4355c47d63924e8a72e509b65029052eb6c299d53a04e167c5775fd466751c9d" +
+		"07299936d304c153f6443dfa05f40ff007d72911b6f72307f996231605b91562" + "1c"
+	cowAddress = "0xCD2a3d9F938E13CD947Ec05AbC7FE734Df8DD826"
+)
+
+func TestEIP712(t *testing.T) {
+	var typedData TypedData
+	require.NoError(t, json.Unmarshal([]byte(eip712MailExample), &typedData))
+
+	t.Run("encode type", func(tt *testing.T) {
+		encoded, err := typedData.EncodeType("Mail")
+		assert.NoError(tt, err)
+		assert.Equal(tt, "Mail(Person from,Person to,string contents)Person(string name,address wallet)", encoded)
+	})
+
+	t.Run("hash", func(tt *testing.T) {
+		hash, err := typedData.Hash()
+		assert.NoError(tt, err)
+		assert.Equal(tt, eip712MailHash, hex.EncodeToString(hash))
+	})
+
+	t.Run("verify", func(tt *testing.T) {
+		signature, err := hex.DecodeString(eip712MailSignature)
+		require.NoError(tt, err)
+		assert.NoError(tt, VerifyEIP712(cowAddress, typedData, signature))
+
+		didPKH, err := CreateDIDPKHFromNetwork(Ethereum, cowAddress)
+		require.NoError(tt, err)
+		assert.NoError(tt, didPKH.VerifyTypedData(typedData, signature))
+
+		tampered := typedData
+		tampered.Message = map[string]any{"from": typedData.Message["from"], "to": typedData.Message["to"], "contents": "Hello, Eve!"}
+		assert.ErrorContains(tt, VerifyEIP712(cowAddress, tampered, signature), "signature was made by")
+	})
+
+	t.Run("atomic types", func(tt *testing.T) {
+		td := TypedData{
+			Types: map[string][]TypedDataField{
+				"EIP712Domain": {{Name: "name", Type: "string"}},
+				"Values": {
+					{Name: "flag", Type: "bool"},
+					{Name: "amount", Type: "int8"},
+					{Name: "id", Type: "bytes4"},
+					{Name: "data", Type: "bytes"},
+					{Name: "tags", Type: "string[]"},
+				},
+			},
+			PrimaryType: "Values",
+			Domain:      map[string]any{"name": "test"},
+			Message: map[string]any{
+				"flag":   true,
+				"amount": float64(-1),
+				"id":     "0x01020304",
+				"data":   "0xff",
+				"tags":   []any{"a", "b"},
+			},
+		}
+		_, err := td.Hash()
+		assert.NoError(tt, err)
+
+		td.Message["amount"] = float64(128)
+		_, err = td.Hash()
+		assert.ErrorContains(tt, err, "out of range for int8")
+
+		td.Message["amount"] = float64(1)
+		td.Message["id"] = "0x01"
+		_, err = td.Hash()
+		assert.ErrorContains(tt, err, "invalid bytes4 value")
+
+		td.PrimaryType = "Unknown"
+		_, err = td.Hash()
+		assert.ErrorContains(tt, err, "unknown type: Unknown")
+	})
+}
+
+func TestEIP191(t *testing.T) {
+	privateKey := secp.PrivKeyFromBytes(crypto.Keccak256([]byte("cow")))
+	message := []byte("I am the owner of this account")
+	signature, err := crypto.SignRecoverableSECP256k1(*privateKey, EIP191Hash(message))
+	require.NoError(t, err)
+	// wallets produce a recovery id of 27 or 28
+	signature[64] += 27
+
+	assert.NoError(t, VerifyEIP191(cowAddress, message, signature))
+	assert.ErrorContains(t, VerifyEIP191(cowAddress, []byte("another message"), signature), "signature was made by")
+	assert.ErrorContains(t, VerifyEIP191("0xb9c5714089478a327f09197987f16f9e5d936e8a", message, signature), "signature was made by")
+	assert.ErrorContains(t, VerifyEIP191("not an address", message, signature), "invalid ethereum address")
+
+	for _, network := range []Network{Ethereum, Polygon} {
+		didPKH, err := CreateDIDPKHFromNetwork(network, cowAddress)
+		require.NoError(t, err)
+		assert.NoError(t, didPKH.VerifyMessage(message, signature))
+	}
+}
+
+func TestVerifySolana(t *testing.T) {
+	publicKey, privateKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	address := base58.Encode(publicKey)
+	message := []byte("I am the owner of this account")
+	signature := ed25519.Sign(privateKey, message)
+
+	assert.NoError(t, VerifySolana(address, message, signature))
+	assert.ErrorContains(t, VerifySolana(address, []byte("another message"), signature), "invalid solana signature")
+	assert.ErrorContains(t, VerifySolana("0OIl", message, signature), "decoding solana address")
+	assert.ErrorContains(t, VerifySolana(base58.Encode([]byte("short")), message, signature), "must be a 32 byte public key")
+
+	didPKH, err := CreateDIDPKHFromNetwork(Solana, address)
+	require.NoError(t, err)
+	assert.NoError(t, didPKH.VerifyMessage(message, signature))
+	assert.ErrorContains(t, didPKH.VerifyTypedData(TypedData{}, signature), "not supported for did:pkh namespace: solana")
+
+	doc, err := didPKH.Expand()
+	require.NoError(t, err)
+	assert.Equal(t, Ed25519VerificationKey2018, string(doc.VerificationMethod[0].Type))
+	assert.Equal(t, address, doc.VerificationMethod[0].PublicKeyBase58)
+}
+
+func TestVerifyMessageUnsupportedNamespace(t *testing.T) {
+	didPKH, err := CreateDIDPKHFromNetwork(Bitcoin, "128Lkh3S7CkDTBZ8W7BbpsN3YYizJMp8p6")
+	require.NoError(t, err)
+	assert.ErrorContains(t, didPKH.VerifyMessage([]byte("message"), nil), "not supported for did:pkh namespace: bip122")
+
+	address, err := didPKH.Address()
+	assert.NoError(t, err)
+	assert.Equal(t, "128Lkh3S7CkDTBZ8W7BbpsN3YYizJMp8p6", address)
+}
@@ -0,0 +1,124 @@
+package registrar
+
+import (
+	"io"
+	"net/http"
+
+	"github.com/goccy/go-json"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+
+	"github.com/extrimian/ssi-sdk/did"
+	"github.com/extrimian/ssi-sdk/util"
+)
+
+// Paths of the operations of a Universal Registrar driver
+// https://github.com/decentralized-identity/universal-registrar/blob/main/openapi/openapi-driver.yaml
+const (
+	CreatePath     = "/1.0/create"
+	UpdatePath     = "/1.0/update"
+	DeactivatePath = "/1.0/deactivate"
+
+	// maxRequestSize is the maximum size of a request body
+	maxRequestSize = 1 << 20
+)
+
+// Handler is an http.Handler exposing a Registrar with the Universal Registrar driver contract. Requests are POSTed
+// as JSON to /1.0/create, with the method given as a query parameter or in the request, /1.0/update and
+// /1.0/deactivate. Responses are the states of the jobs. Requests which cannot be processed respond with a
+// 400 status code and a failed state giving the reason.
+type Handler struct {
+	registrar Registrar
+}
+
+var _ http.Handler = (*Handler)(nil)
+
+// NewHandler creates a new Handler for the given registrar
+func NewHandler(registrar Registrar) (*Handler, error) {
+	if registrar == nil {
+		return nil, errors.New("registrar cannot be nil")
+	}
+	return &Handler{registrar: registrar}, nil
+}
+
+func (h Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch r.URL.Path {
+	case CreatePath, UpdatePath, DeactivatePath:
+	default:
+		http.NotFound(w, r)
+		return
+	}
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxRequestSize))
+	if err != nil {
+		writeFailed(w, "", errors.Wrap(err, "reading request"))
+		return
+	}
+
+	var response *Response
+	var jobID string
+	switch r.URL.Path {
+	case CreatePath:
+		var request CreateRequest
+		if err = json.Unmarshal(body, &request); err != nil {
+			writeFailed(w, "", errors.Wrap(err, "unmarshalling create request"))
+			return
+		}
+		if method := r.URL.Query().Get("method"); method != "" {
+			request.Method = did.Method(method)
+		}
+		jobID = request.JobID
+		response, err = h.registrar.Create(r.Context(), request)
+	case UpdatePath:
+		var request UpdateRequest
+		if err = json.Unmarshal(body, &request); err != nil {
+			writeFailed(w, "", errors.Wrap(err, "unmarshalling update request"))
+			return
+		}
+		jobID = request.JobID
+		response, err = h.registrar.Update(r.Context(), request)
+	case DeactivatePath:
+		var request DeactivateRequest
+		if err = json.Unmarshal(body, &request); err != nil {
+			writeFailed(w, "", errors.Wrap(err, "unmarshalling deactivate request"))
+			return
+		}
+		jobID = request.JobID
+		response, err = h.registrar.Deactivate(r.Context(), request)
+	}
+	if err != nil {
+		writeFailed(w, jobID, err)
+		return
+	}
+
+	// a finished create responds that the DID has been created
+	status := http.StatusOK
+	if r.URL.Path == CreatePath && response.DIDState.State == StateFinished {
+		status = http.StatusCreated
+	}
+	writeResponse(w, status, response)
+}
+
+// writeFailed responds with the failed state of a job
+func writeFailed(w http.ResponseWriter, jobID string, err error) {
+	logrus.WithError(err).Warn("registration failed")
+	writeResponse(w, http.StatusBadRequest, &Response{
+		JobID:    jobID,
+		DIDState: DIDState{State: StateFailed, Reason: err.Error()},
+	})
+}
+
+func writeResponse(w http.ResponseWriter, status int, response *Response) {
+	responseBytes, err := json.Marshal(response)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", util.JSONContentType)
+	w.WriteHeader(status)
+	_, _ = w.Write(responseBytes)
+}
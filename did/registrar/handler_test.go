@@ -0,0 +1,70 @@
+package registrar
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/goccy/go-json"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/extrimian/ssi-sdk/did/key"
+)
+
+func TestHandler(t *testing.T) {
+	r, err := NewRegistrar(KeyRegistrar{})
+	require.NoError(t, err)
+	handler, err := NewHandler(r)
+	require.NoError(t, err)
+
+	serve := func(method, target, body string) (*httptest.ResponseRecorder, Response) {
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest(method, target, strings.NewReader(body)))
+		var response Response
+		if w.Code != http.StatusNotFound && w.Code != http.StatusMethodNotAllowed {
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		}
+		return w, response
+	}
+
+	t.Run("create", func(tt *testing.T) {
+		w, response := serve(http.MethodPost, CreatePath+"?method=key", `{"jobId":"job"}`)
+		assert.Equal(tt, http.StatusCreated, w.Code)
+		assert.Equal(tt, "application/json", w.Header().Get("Content-Type"))
+		assert.Equal(tt, "job", response.JobID)
+		assert.Equal(tt, StateFinished, response.DIDState.State)
+		assert.True(tt, key.DIDKey(response.DIDState.DID).IsValid())
+	})
+
+	t.Run("failed", func(tt *testing.T) {
+		w, response := serve(http.MethodPost, UpdatePath, `{"jobId":"job","did":"did:key:z6MkhaXgBZDvotDkL5257faiztiGiC2QtKLGpbnnEGta2doK"}`)
+		assert.Equal(tt, http.StatusBadRequest, w.Code)
+		assert.Equal(tt, "job", response.JobID)
+		assert.Equal(tt, StateFailed, response.DIDState.State)
+		assert.Contains(tt, response.DIDState.Reason, "did:key DIDs cannot be updated")
+
+		w, response = serve(http.MethodPost, CreatePath+"?method=web", `{}`)
+		assert.Equal(tt, http.StatusBadRequest, w.Code)
+		assert.Contains(tt, response.DIDState.Reason, "unsupported method: web")
+
+		w, response = serve(http.MethodPost, DeactivatePath, `not json`)
+		assert.Equal(tt, http.StatusBadRequest, w.Code)
+		assert.Contains(tt, response.DIDState.Reason, "unmarshalling deactivate request")
+	})
+
+	t.Run("wrong method and path", func(tt *testing.T) {
+		w, _ := serve(http.MethodGet, CreatePath, "")
+		assert.Equal(tt, http.StatusMethodNotAllowed, w.Code)
+		assert.Equal(tt, http.MethodPost, w.Header().Get("Allow"))
+
+		w, _ = serve(http.MethodPost, "/1.0/identifiers", "")
+		assert.Equal(tt, http.StatusNotFound, w.Code)
+	})
+
+	t.Run("nil registrar", func(tt *testing.T) {
+		_, err := NewHandler(nil)
+		assert.ErrorContains(tt, err, "registrar cannot be nil")
+	})
+}
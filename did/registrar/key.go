@@ -0,0 +1,101 @@
+package registrar
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+
+	"github.com/extrimian/ssi-sdk/crypto"
+	"github.com/extrimian/ssi-sdk/did"
+	"github.com/extrimian/ssi-sdk/did/jwk"
+	"github.com/extrimian/ssi-sdk/did/key"
+)
+
+// KeyRegistrar creates did:key DIDs from generated keys. Their documents are derived from their keys, so they
+// cannot be updated or deactivated.
+type KeyRegistrar struct{}
+
+var _ Registrar = (*KeyRegistrar)(nil)
+
+// Create generates a key of the options' key type, defaulting to Ed25519, and returns its private key in the secret
+func (KeyRegistrar) Create(_ context.Context, request CreateRequest) (*Response, error) {
+	if request.DIDDocument != nil {
+		return nil, errors.New("did:key DID Documents are derived from their key and cannot be given")
+	}
+	privateKey, didKey, err := key.GenerateDIDKey(keyTypeOrDefault(request.Options, crypto.Ed25519))
+	if err != nil {
+		return nil, errors.Wrap(err, "generating did:key")
+	}
+	doc, err := didKey.Expand()
+	if err != nil {
+		return nil, errors.Wrap(err, "expanding did:key")
+	}
+	vm, err := newSecretVerificationMethod(doc.VerificationMethod[0], privateKey)
+	if err != nil {
+		return nil, err
+	}
+	return finished(request.JobID, didKey.String(), doc, &Secret{VerificationMethod: []SecretVerificationMethod{*vm}}), nil
+}
+
+func (KeyRegistrar) Update(context.Context, UpdateRequest) (*Response, error) {
+	return nil, unsupportedOperation(did.KeyMethod, "updated")
+}
+
+func (KeyRegistrar) Deactivate(context.Context, DeactivateRequest) (*Response, error) {
+	return nil, unsupportedOperation(did.KeyMethod, "deactivated")
+}
+
+func (KeyRegistrar) Methods() []did.Method {
+	return []did.Method{did.KeyMethod}
+}
+
+// JWKRegistrar creates did:jwk DIDs from generated keys, or from a public key held by the client. Their documents
+// are derived from their keys, so they cannot be updated or deactivated.
+type JWKRegistrar struct{}
+
+var _ Registrar = (*JWKRegistrar)(nil)
+
+// Create creates a did:jwk for the public key in the secret of the request, if given, or otherwise generates a key of
+// the options' key type, defaulting to Ed25519, and returns its private key in the secret
+func (JWKRegistrar) Create(_ context.Context, request CreateRequest) (*Response, error) {
+	if request.DIDDocument != nil {
+		return nil, errors.New("did:jwk DID Documents are derived from their key and cannot be given")
+	}
+	if publicKeyJWK := clientPublicKey(request.Secret); publicKeyJWK != nil {
+		didJWK, err := jwk.CreateDIDJWK(*publicKeyJWK)
+		if err != nil {
+			return nil, errors.Wrap(err, "creating did:jwk")
+		}
+		doc, err := didJWK.Expand()
+		if err != nil {
+			return nil, errors.Wrap(err, "expanding did:jwk")
+		}
+		return finished(request.JobID, didJWK.String(), doc, nil), nil
+	}
+
+	privateKey, didJWK, err := jwk.GenerateDIDJWK(keyTypeOrDefault(request.Options, crypto.Ed25519))
+	if err != nil {
+		return nil, errors.Wrap(err, "generating did:jwk")
+	}
+	doc, err := didJWK.Expand()
+	if err != nil {
+		return nil, errors.Wrap(err, "expanding did:jwk")
+	}
+	vm, err := newSecretVerificationMethod(doc.VerificationMethod[0], privateKey)
+	if err != nil {
+		return nil, err
+	}
+	return finished(request.JobID, didJWK.String(), doc, &Secret{VerificationMethod: []SecretVerificationMethod{*vm}}), nil
+}
+
+func (JWKRegistrar) Update(context.Context, UpdateRequest) (*Response, error) {
+	return nil, unsupportedOperation(did.JWKMethod, "updated")
+}
+
+func (JWKRegistrar) Deactivate(context.Context, DeactivateRequest) (*Response, error) {
+	return nil, unsupportedOperation(did.JWKMethod, "deactivated")
+}
+
+func (JWKRegistrar) Methods() []did.Method {
+	return []did.Method{did.JWKMethod}
+}
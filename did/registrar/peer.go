@@ -0,0 +1,92 @@
+package registrar
+
+import (
+	"context"
+	gocrypto "crypto"
+
+	"github.com/pkg/errors"
+
+	"github.com/extrimian/ssi-sdk/crypto"
+	"github.com/extrimian/ssi-sdk/did"
+	"github.com/extrimian/ssi-sdk/did/peer"
+)
+
+// PeerRegistrar creates did:peer:2 DIDs with a generated authentication key and X25519 key agreement key, and the
+// services of the request's DID Document. Their documents are encoded in the DID, so they cannot be updated or
+// deactivated.
+type PeerRegistrar struct{}
+
+var _ Registrar = (*PeerRegistrar)(nil)
+
+// Create generates an authentication key of the options' key type, defaulting to Ed25519, and a key agreement key,
+// returning their private keys in the secret
+func (PeerRegistrar) Create(ctx context.Context, request CreateRequest) (*Response, error) {
+	var services []did.Service
+	if request.DIDDocument != nil {
+		if len(request.DIDDocument.VerificationMethod) > 0 {
+			return nil, errors.New("did:peer verification methods are generated and cannot be given")
+		}
+		services = request.DIDDocument.Services
+	}
+
+	kt := keyTypeOrDefault(request.Options, crypto.Ed25519)
+	if !peer.IsSupportedDIDPeerType(kt) {
+		return nil, errors.Errorf("unsupported did:peer type: %s", kt)
+	}
+	signingPublicKey, signingPrivateKey, err := crypto.GenerateKeyByKeyType(kt)
+	if err != nil {
+		return nil, errors.Wrap(err, "generating authentication key")
+	}
+	agreementPublicKey, agreementPrivateKey, err := crypto.GenerateX25519Key()
+	if err != nil {
+		return nil, errors.Wrap(err, "generating key agreement key")
+	}
+	values := []any{
+		peer.PurposeKey{Purpose: peer.PurposeVerificationCode, PublicKey: signingPublicKey},
+		peer.PurposeKey{Purpose: peer.PurposeEncryptionCode, KeyType: crypto.X25519, PublicKey: agreementPublicKey},
+	}
+	for _, service := range services {
+		values = append(values, service)
+	}
+	didPeer, err := peer.Method2{KT: kt, Values: values}.Generate()
+	if err != nil {
+		return nil, errors.Wrap(err, "generating did:peer")
+	}
+
+	resolved, err := peer.Resolver{}.Resolve(ctx, didPeer.String())
+	if err != nil {
+		return nil, errors.Wrap(err, "resolving did:peer")
+	}
+	doc := resolved.Document
+	var secret Secret
+	for _, key := range []struct {
+		relationship did.Relationship
+		privateKey   gocrypto.PrivateKey
+	}{
+		{relationship: did.Authentication, privateKey: signingPrivateKey},
+		{relationship: did.KeyAgreement, privateKey: agreementPrivateKey},
+	} {
+		vm, err := doc.SelectVerificationMethod(key.relationship, "")
+		if err != nil {
+			return nil, err
+		}
+		secretVM, err := newSecretVerificationMethod(*vm, key.privateKey)
+		if err != nil {
+			return nil, err
+		}
+		secret.VerificationMethod = append(secret.VerificationMethod, *secretVM)
+	}
+	return finished(request.JobID, didPeer.String(), &doc, &secret), nil
+}
+
+func (PeerRegistrar) Update(context.Context, UpdateRequest) (*Response, error) {
+	return nil, unsupportedOperation(did.PeerMethod, "updated")
+}
+
+func (PeerRegistrar) Deactivate(context.Context, DeactivateRequest) (*Response, error) {
+	return nil, unsupportedOperation(did.PeerMethod, "deactivated")
+}
+
+func (PeerRegistrar) Methods() []did.Method {
+	return []did.Method{did.PeerMethod}
+}
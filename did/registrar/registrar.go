@@ -0,0 +1,269 @@
+// Package registrar creates, updates and deactivates DIDs across methods following the DIF DID Registration
+// specification https://identity.foundation/did-registration/
+//
+// Each operation returns the state of a registration job. A job is finished once the DID is registered, while a job
+// in the action state needs the client to act, such as signing a payload with a key it holds or publishing a
+// did:web DID Document, and a job in the wait state is waiting on the method, such as a Sidetree node anchoring an
+// operation. Jobs are continued by repeating the request with the job's id.
+//
+// Keys generated by a registrar are returned to the client in the secret of the DID state once their job has
+// finished, and are not kept afterwards. Job ids are generated by the registrar, and unfinished jobs expire. Keys held
+// by the client are passed in the secret of a request, either as private keys or, for clients keeping their private
+// keys to themselves, as public keys for which the registrar asks for signatures.
+package registrar
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/pkg/errors"
+
+	"github.com/extrimian/ssi-sdk/crypto"
+	"github.com/extrimian/ssi-sdk/crypto/jwx"
+	"github.com/extrimian/ssi-sdk/did"
+	"github.com/extrimian/ssi-sdk/did/resolution"
+)
+
+// Registrar provides an interface for registering DIDs as per the spec https://identity.foundation/did-registration/
+type Registrar interface {
+	// Create creates a DID of the request's method
+	Create(ctx context.Context, request CreateRequest) (*Response, error)
+	// Update updates the DID Document of a DID
+	Update(ctx context.Context, request UpdateRequest) (*Response, error)
+	// Deactivate deactivates a DID
+	Deactivate(ctx context.Context, request DeactivateRequest) (*Response, error)
+	// Methods returns all methods that can be registered by this registrar
+	Methods() []did.Method
+}
+
+// State is the state of a registration job https://identity.foundation/did-registration/#didstatestate
+type State string
+
+const (
+	// StateFinished means the operation has completed
+	StateFinished State = "finished"
+	// StateFailed means the operation has failed, for the reason given in the DID state
+	StateFailed State = "failed"
+	// StateAction means the client has to perform the action given in the DID state before continuing the job
+	StateAction State = "action"
+	// StateWait means the client has to wait before continuing the job
+	StateWait State = "wait"
+)
+
+// Actions a client is asked to perform https://identity.foundation/did-registration/#didstateaction
+const (
+	// ActionSignPayload asks the client to sign the payloads of the signing requests in the DID state, and to return
+	// the signatures in the signing response of the request's secret
+	ActionSignPayload = "signPayload"
+	// ActionPublishDocument asks the client to publish the DID Document in the DID state at the URL given by the
+	// didDocumentUrl registration metadata, for methods whose documents are hosted by their controller
+	ActionPublishDocument = "publishDidDocument"
+	// ActionRemoveDocument asks the client to remove the DID Document at the URL given by the didDocumentUrl
+	// registration metadata
+	ActionRemoveDocument = "removeDidDocument"
+)
+
+// DocumentOperation is an operation of an update request
+// https://identity.foundation/did-registration/#diddocumentoperation
+type DocumentOperation string
+
+const (
+	// SetDocument replaces the DID Document
+	SetDocument DocumentOperation = "setDidDocument"
+	// AddToDocument adds the verification methods and services of a DID Document
+	AddToDocument DocumentOperation = "addToDidDocument"
+	// RemoveFromDocument removes the verification methods and services with the ids of a DID Document
+	RemoveFromDocument DocumentOperation = "removeFromDidDocument"
+)
+
+// Purposes of the keys of a secret which are not verification relationships of the DID Document
+const (
+	// PurposeUpdate is the purpose of a Sidetree update key
+	PurposeUpdate = "update"
+	// PurposeNextUpdate is the purpose of the public key a Sidetree update commits to, when the client holds its
+	// update keys
+	PurposeNextUpdate = "nextUpdate"
+	// PurposeRecovery is the purpose of a Sidetree recovery key
+	PurposeRecovery = "recovery"
+)
+
+// Options are the options of a request https://identity.foundation/did-registration/#options
+type Options struct {
+	// KeyType is the type of the keys generated for the DID, defaulting to a type the method supports
+	KeyType crypto.KeyType `json:"keyType,omitempty"`
+}
+
+// Secret holds the keys of a DID https://identity.foundation/did-registration/#secret
+// In requests, it holds the client's keys, or the signatures of the payloads a registrar asked the client to sign.
+// In the DID state, it holds the keys generated by the registrar.
+type Secret struct {
+	VerificationMethod []SecretVerificationMethod `json:"verificationMethod,omitempty"`
+	SigningResponse    map[string]SigningResponse `json:"signingResponse,omitempty"`
+}
+
+// Find returns the first verification method of the secret with the purpose
+func (s *Secret) Find(purpose string) *SecretVerificationMethod {
+	if s == nil {
+		return nil
+	}
+	for i, vm := range s.VerificationMethod {
+		for _, p := range vm.Purpose {
+			if p == purpose {
+				return &s.VerificationMethod[i]
+			}
+		}
+	}
+	return nil
+}
+
+// SecretVerificationMethod is a key of a secret, with its private key when held by the client or generated by a
+// registrar, or its public key alone when the client signs with it
+type SecretVerificationMethod struct {
+	ID            string             `json:"id,omitempty"`
+	Type          string             `json:"type,omitempty"`
+	Controller    string             `json:"controller,omitempty"`
+	PublicKeyJWK  *jwx.PublicKeyJWK  `json:"publicKeyJwk,omitempty"`
+	PrivateKeyJWK *jwx.PrivateKeyJWK `json:"privateKeyJwk,omitempty"`
+	Purpose       []string           `json:"purpose,omitempty"`
+}
+
+// SigningRequest asks the client to sign a payload https://identity.foundation/did-registration/#signing-request-set
+type SigningRequest struct {
+	// SerializedPayload is the base64url encoded bytes to sign
+	SerializedPayload string `json:"serializedPayload"`
+	// KID is the id of the key to sign with, if it has one
+	KID string `json:"kid,omitempty"`
+	// Alg is the JWA algorithm of the signature
+	Alg string `json:"alg"`
+	// Purpose is the purpose of the key to sign with
+	Purpose string `json:"purpose,omitempty"`
+}
+
+// SigningResponse holds the base64url encoded signature of a signing request
+// https://identity.foundation/did-registration/#signing-response-set
+type SigningResponse struct {
+	Signature string `json:"signature"`
+}
+
+// CreateRequest https://identity.foundation/did-registration/#create
+type CreateRequest struct {
+	JobID       string        `json:"jobId,omitempty"`
+	Method      did.Method    `json:"method,omitempty"`
+	Options     Options       `json:"options,omitempty"`
+	Secret      *Secret       `json:"secret,omitempty"`
+	DIDDocument *did.Document `json:"didDocument,omitempty"`
+}
+
+// UpdateRequest https://identity.foundation/did-registration/#update
+// Each operation applies to the DID Document at the same index.
+type UpdateRequest struct {
+	JobID                string              `json:"jobId,omitempty"`
+	DID                  string              `json:"did"`
+	Options              Options             `json:"options,omitempty"`
+	Secret               *Secret             `json:"secret,omitempty"`
+	DIDDocumentOperation []DocumentOperation `json:"didDocumentOperation,omitempty"`
+	DIDDocument          []did.Document      `json:"didDocument,omitempty"`
+}
+
+// DeactivateRequest https://identity.foundation/did-registration/#deactivate
+type DeactivateRequest struct {
+	JobID   string  `json:"jobId,omitempty"`
+	DID     string  `json:"did"`
+	Options Options `json:"options,omitempty"`
+	Secret  *Secret `json:"secret,omitempty"`
+}
+
+// Response is the state of a registration job https://identity.foundation/did-registration/#didstate
+type Response struct {
+	JobID                string                       `json:"jobId,omitempty"`
+	DIDState             DIDState                     `json:"didState"`
+	RegistrationMetadata map[string]any               `json:"didRegistrationMetadata,omitempty"`
+	DocumentMetadata     *resolution.DocumentMetadata `json:"didDocumentMetadata,omitempty"`
+}
+
+// DIDState https://identity.foundation/did-registration/#didstate
+type DIDState struct {
+	State          State                     `json:"state"`
+	DID            string                    `json:"did,omitempty"`
+	Secret         *Secret                   `json:"secret,omitempty"`
+	DIDDocument    *did.Document             `json:"didDocument,omitempty"`
+	Action         string                    `json:"action,omitempty"`
+	SigningRequest map[string]SigningRequest `json:"signingRequest,omitempty"`
+	Wait           string                    `json:"wait,omitempty"`
+	WaitTime       int64                     `json:"waitTime,omitempty"`
+	Reason         string                    `json:"reason,omitempty"`
+}
+
+// MultiMethodRegistrar registers DIDs with the registrar of their method
+type MultiMethodRegistrar struct {
+	registrars map[did.Method]Registrar
+	methods    []did.Method
+}
+
+var _ Registrar = (*MultiMethodRegistrar)(nil)
+
+// NewRegistrar creates a registrar for the methods of the given registrars
+func NewRegistrar(registrars ...Registrar) (*MultiMethodRegistrar, error) {
+	r := make(map[did.Method]Registrar)
+	var methods []did.Method
+	for _, registrar := range registrars {
+		for _, m := range registrar.Methods() {
+			if _, ok := r[m]; ok {
+				return nil, fmt.Errorf("duplicate registrar for method: %s", m)
+			}
+			r[m] = registrar
+			methods = append(methods, m)
+		}
+	}
+	return &MultiMethodRegistrar{registrars: r, methods: methods}, nil
+}
+
+// Create creates a DID with the registrar of the request's method
+func (mr MultiMethodRegistrar) Create(ctx context.Context, request CreateRequest) (*Response, error) {
+	registrar, err := mr.registrar(request.Method)
+	if err != nil {
+		return nil, err
+	}
+	return registrar.Create(ctx, request)
+}
+
+// Update updates a DID with the registrar of its method
+func (mr MultiMethodRegistrar) Update(ctx context.Context, request UpdateRequest) (*Response, error) {
+	method, err := resolution.GetMethodForDID(request.DID)
+	if err != nil {
+		return nil, errors.Wrap(err, "getting method for DID before updating")
+	}
+	registrar, err := mr.registrar(method)
+	if err != nil {
+		return nil, err
+	}
+	return registrar.Update(ctx, request)
+}
+
+// Deactivate deactivates a DID with the registrar of its method
+func (mr MultiMethodRegistrar) Deactivate(ctx context.Context, request DeactivateRequest) (*Response, error) {
+	method, err := resolution.GetMethodForDID(request.DID)
+	if err != nil {
+		return nil, errors.Wrap(err, "getting method for DID before deactivating")
+	}
+	registrar, err := mr.registrar(method)
+	if err != nil {
+		return nil, err
+	}
+	return registrar.Deactivate(ctx, request)
+}
+
+func (mr MultiMethodRegistrar) Methods() []did.Method {
+	return mr.methods
+}
+
+func (mr MultiMethodRegistrar) registrar(method did.Method) (Registrar, error) {
+	if method == "" {
+		return nil, errors.New("method cannot be empty")
+	}
+	registrar, ok := mr.registrars[method]
+	if !ok {
+		return nil, fmt.Errorf("unsupported method: %s", method)
+	}
+	return registrar, nil
+}
@@ -0,0 +1,190 @@
+package registrar
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/extrimian/ssi-sdk/crypto"
+	"github.com/extrimian/ssi-sdk/crypto/jwx"
+	"github.com/extrimian/ssi-sdk/did"
+	"github.com/extrimian/ssi-sdk/did/jwk"
+	"github.com/extrimian/ssi-sdk/did/key"
+	"github.com/extrimian/ssi-sdk/did/peer"
+)
+
+func TestNewRegistrar(t *testing.T) {
+	t.Run("methods", func(tt *testing.T) {
+		r, err := NewRegistrar(KeyRegistrar{}, JWKRegistrar{}, PeerRegistrar{})
+		require.NoError(tt, err)
+		assert.ElementsMatch(tt, []did.Method{did.KeyMethod, did.JWKMethod, did.PeerMethod}, r.Methods())
+	})
+
+	t.Run("duplicate method", func(tt *testing.T) {
+		_, err := NewRegistrar(KeyRegistrar{}, KeyRegistrar{})
+		assert.ErrorContains(tt, err, "duplicate registrar for method: key")
+	})
+
+	t.Run("unsupported method", func(tt *testing.T) {
+		r, err := NewRegistrar(KeyRegistrar{})
+		require.NoError(tt, err)
+		_, err = r.Create(context.Background(), CreateRequest{Method: did.WebMethod})
+		assert.ErrorContains(tt, err, "unsupported method: web")
+		_, err = r.Create(context.Background(), CreateRequest{})
+		assert.ErrorContains(tt, err, "method cannot be empty")
+		_, err = r.Update(context.Background(), UpdateRequest{DID: "did:web:example.com"})
+		assert.ErrorContains(tt, err, "unsupported method: web")
+		_, err = r.Deactivate(context.Background(), DeactivateRequest{DID: "not-a-did"})
+		assert.ErrorContains(tt, err, "getting method for DID")
+	})
+
+	t.Run("routes by method", func(tt *testing.T) {
+		r, err := NewRegistrar(KeyRegistrar{}, JWKRegistrar{})
+		require.NoError(tt, err)
+		response, err := r.Create(context.Background(), CreateRequest{Method: did.JWKMethod})
+		require.NoError(tt, err)
+		assert.True(tt, jwk.JWK(response.DIDState.DID).IsValid())
+
+		_, err = r.Update(context.Background(), UpdateRequest{DID: response.DIDState.DID})
+		assert.ErrorContains(tt, err, "did:jwk DIDs cannot be updated")
+	})
+}
+
+func TestKeyRegistrar(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("create", func(tt *testing.T) {
+		response, err := KeyRegistrar{}.Create(ctx, CreateRequest{JobID: "job", Options: Options{KeyType: crypto.SECP256k1}})
+		require.NoError(tt, err)
+		assert.Equal(tt, "job", response.JobID)
+		assert.Equal(tt, StateFinished, response.DIDState.State)
+		assert.True(tt, key.DIDKey(response.DIDState.DID).IsValid())
+		require.NotNil(tt, response.DIDState.DIDDocument)
+		assert.Equal(tt, response.DIDState.DID, response.DIDState.DIDDocument.ID)
+
+		// the secret holds the private key of the document's verification method
+		require.NotNil(tt, response.DIDState.Secret)
+		require.Len(tt, response.DIDState.Secret.VerificationMethod, 1)
+		secretVM := response.DIDState.Secret.VerificationMethod[0]
+		assert.Equal(tt, response.DIDState.DIDDocument.VerificationMethod[0].ID, secretVM.ID)
+		assertPrivateKeyOf(tt, response.DIDState.DIDDocument.VerificationMethod[0], secretVM)
+	})
+
+	t.Run("generates a job id", func(tt *testing.T) {
+		response, err := KeyRegistrar{}.Create(ctx, CreateRequest{})
+		require.NoError(tt, err)
+		assert.NotEmpty(tt, response.JobID)
+	})
+
+	t.Run("unsupported key type", func(tt *testing.T) {
+		_, err := KeyRegistrar{}.Create(ctx, CreateRequest{Options: Options{KeyType: "unknown"}})
+		assert.ErrorContains(tt, err, "unsupported did:key type")
+	})
+
+	t.Run("document cannot be given", func(tt *testing.T) {
+		_, err := KeyRegistrar{}.Create(ctx, CreateRequest{DIDDocument: &did.Document{}})
+		assert.ErrorContains(tt, err, "derived from their key")
+	})
+
+	t.Run("update and deactivate", func(tt *testing.T) {
+		_, err := KeyRegistrar{}.Update(ctx, UpdateRequest{})
+		assert.ErrorContains(tt, err, "did:key DIDs cannot be updated")
+		_, err = KeyRegistrar{}.Deactivate(ctx, DeactivateRequest{})
+		assert.ErrorContains(tt, err, "did:key DIDs cannot be deactivated")
+	})
+}
+
+func TestJWKRegistrar(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("create", func(tt *testing.T) {
+		response, err := JWKRegistrar{}.Create(ctx, CreateRequest{})
+		require.NoError(tt, err)
+		assert.Equal(tt, StateFinished, response.DIDState.State)
+		assert.True(tt, jwk.JWK(response.DIDState.DID).IsValid())
+		require.NotNil(tt, response.DIDState.Secret)
+		assertPrivateKeyOf(tt, response.DIDState.DIDDocument.VerificationMethod[0], response.DIDState.Secret.VerificationMethod[0])
+	})
+
+	t.Run("create with the client's public key", func(tt *testing.T) {
+		_, privateKey, err := crypto.GenerateP256Key()
+		require.NoError(tt, err)
+		publicKeyJWK, _, err := jwx.PrivateKeyToPrivateKeyJWK("", privateKey)
+		require.NoError(tt, err)
+
+		response, err := JWKRegistrar{}.Create(ctx, CreateRequest{
+			Secret: &Secret{VerificationMethod: []SecretVerificationMethod{{PublicKeyJWK: publicKeyJWK}}},
+		})
+		require.NoError(tt, err)
+		assert.Equal(tt, StateFinished, response.DIDState.State)
+		assert.Nil(tt, response.DIDState.Secret)
+		expected, err := jwk.CreateDIDJWK(*publicKeyJWK)
+		require.NoError(tt, err)
+		assert.Equal(tt, expected.String(), response.DIDState.DID)
+	})
+
+	t.Run("update and deactivate", func(tt *testing.T) {
+		_, err := JWKRegistrar{}.Deactivate(ctx, DeactivateRequest{})
+		assert.ErrorContains(tt, err, "did:jwk DIDs cannot be deactivated")
+	})
+}
+
+func TestPeerRegistrar(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("create", func(tt *testing.T) {
+		service := did.Service{ID: "#service", Type: "LinkedDomains", ServiceEndpoint: "https://example.com"}
+		response, err := PeerRegistrar{}.Create(ctx, CreateRequest{DIDDocument: &did.Document{Services: []did.Service{service}}})
+		require.NoError(tt, err)
+		assert.Equal(tt, StateFinished, response.DIDState.State)
+		assert.True(tt, peer.DIDPeer(response.DIDState.DID).IsValid())
+
+		doc := response.DIDState.DIDDocument
+		require.NotNil(tt, doc)
+		require.Len(tt, doc.Services, 1)
+		assert.Equal(tt, service.ServiceEndpoint, doc.Services[0].ServiceEndpoint)
+
+		// the secret holds the authentication and key agreement keys
+		require.NotNil(tt, response.DIDState.Secret)
+		require.Len(tt, response.DIDState.Secret.VerificationMethod, 2)
+		authentication, err := doc.SelectVerificationMethod(did.Authentication, "")
+		require.NoError(tt, err)
+		keyAgreement, err := doc.SelectVerificationMethod(did.KeyAgreement, "")
+		require.NoError(tt, err)
+		assert.Equal(tt, authentication.ID, response.DIDState.Secret.VerificationMethod[0].ID)
+		assertPrivateKeyOf(tt, *authentication, response.DIDState.Secret.VerificationMethod[0])
+		assert.Equal(tt, keyAgreement.ID, response.DIDState.Secret.VerificationMethod[1].ID)
+		assertPrivateKeyOf(tt, *keyAgreement, response.DIDState.Secret.VerificationMethod[1])
+	})
+
+	t.Run("verification methods cannot be given", func(tt *testing.T) {
+		_, err := PeerRegistrar{}.Create(ctx, CreateRequest{DIDDocument: &did.Document{
+			VerificationMethod: []did.VerificationMethod{{ID: "#key-1"}},
+		}})
+		assert.ErrorContains(tt, err, "verification methods are generated")
+	})
+
+	t.Run("update and deactivate", func(tt *testing.T) {
+		_, err := PeerRegistrar{}.Update(ctx, UpdateRequest{})
+		assert.ErrorContains(tt, err, "did:peer DIDs cannot be updated")
+	})
+}
+
+// assertPrivateKeyOf asserts that the secret verification method holds the private key of the verification method
+func assertPrivateKeyOf(t *testing.T, vm did.VerificationMethod, secretVM SecretVerificationMethod) {
+	require.NotNil(t, secretVM.PrivateKeyJWK)
+	doc := did.Document{ID: vm.Controller, VerificationMethod: []did.VerificationMethod{vm}}
+	expected, err := did.GetKeyFromVerificationMethod(doc, vm.ID)
+	require.NoError(t, err)
+
+	publicKeyJWK := secretVM.PrivateKeyJWK.ToPublicKeyJWK()
+	publicKey, err := publicKeyJWK.ToPublicKey()
+	require.NoError(t, err)
+	expectedBytes, err := crypto.PubKeyToBytes(expected)
+	require.NoError(t, err)
+	publicKeyBytes, err := crypto.PubKeyToBytes(publicKey)
+	require.NoError(t, err)
+	assert.Equal(t, expectedBytes, publicKeyBytes)
+}
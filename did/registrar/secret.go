@@ -0,0 +1,72 @@
+package registrar
+
+import (
+	gocrypto "crypto"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+
+	"github.com/extrimian/ssi-sdk/crypto"
+	"github.com/extrimian/ssi-sdk/crypto/jwx"
+	"github.com/extrimian/ssi-sdk/did"
+)
+
+// newSecretVerificationMethod returns the private key of a verification method as a secret verification method
+func newSecretVerificationMethod(vm did.VerificationMethod, privateKey gocrypto.PrivateKey, purposes ...string) (*SecretVerificationMethod, error) {
+	publicKeyJWK, privateKeyJWK, err := jwx.PrivateKeyToPrivateKeyJWK(vm.ID, privateKey)
+	if err != nil {
+		return nil, errors.Wrapf(err, "converting private key of %s to JWK", vm.ID)
+	}
+	return &SecretVerificationMethod{
+		ID:            vm.ID,
+		Type:          string(vm.Type),
+		Controller:    vm.Controller,
+		PublicKeyJWK:  publicKeyJWK,
+		PrivateKeyJWK: privateKeyJWK,
+		Purpose:       purposes,
+	}, nil
+}
+
+// clientPublicKey returns the first public key of the secret without a private key, which a client holding its own
+// keys passes to be registered
+func clientPublicKey(secret *Secret) *jwx.PublicKeyJWK {
+	if secret == nil {
+		return nil
+	}
+	for _, vm := range secret.VerificationMethod {
+		if vm.PublicKeyJWK != nil && vm.PrivateKeyJWK == nil {
+			return vm.PublicKeyJWK
+		}
+	}
+	return nil
+}
+
+// keyTypeOrDefault returns the key type of the options, or the default if none is set
+func keyTypeOrDefault(options Options, defaultKeyType crypto.KeyType) crypto.KeyType {
+	if options.KeyType == "" {
+		return defaultKeyType
+	}
+	return options.KeyType
+}
+
+// jobIDOrNew returns the job id of a request, or a new job id for requests without one
+func jobIDOrNew(jobID string) string {
+	if jobID == "" {
+		return uuid.NewString()
+	}
+	return jobID
+}
+
+// finished returns the response of a finished job
+func finished(jobID, id string, doc *did.Document, secret *Secret) *Response {
+	return &Response{
+		JobID:    jobIDOrNew(jobID),
+		DIDState: DIDState{State: StateFinished, DID: id, DIDDocument: doc, Secret: secret},
+	}
+}
+
+// unsupportedOperation is the error of an operation a method does not support
+func unsupportedOperation(method did.Method, operation string) error {
+	return fmt.Errorf("did:%s DIDs cannot be %s", method, operation)
+}
@@ -0,0 +1,584 @@
+package registrar
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+
+	"github.com/extrimian/ssi-sdk/crypto"
+	"github.com/extrimian/ssi-sdk/crypto/jwx"
+	"github.com/extrimian/ssi-sdk/did"
+	"github.com/extrimian/ssi-sdk/did/ion"
+	"github.com/extrimian/ssi-sdk/did/modena"
+	"github.com/extrimian/ssi-sdk/did/resolution"
+	"github.com/extrimian/ssi-sdk/did/sidetree"
+)
+
+const (
+	// longFormDIDMetadata is the registration metadata holding the long form of a created Sidetree DID, which resolves
+	// before its create operation is anchored
+	longFormDIDMetadata = "longFormDid"
+
+	// sidetreeSigningAlg is the algorithm of the signatures of Sidetree operations
+	sidetreeSigningAlg = "ES256K"
+
+	// anchorWait is how long clients are asked to wait before checking whether an operation has been anchored
+	anchorWait = time.Minute
+
+	// jobExpiry is how long a job is kept after the client last continued it, after which it is evicted along with
+	// its secret
+	jobExpiry = 24 * time.Hour
+)
+
+// Operations of registrar jobs, which can only be continued by a request for the same operation
+const (
+	createOperation     = "create"
+	updateOperation     = "update"
+	deactivateOperation = "deactivate"
+)
+
+// Anchorer anchors Sidetree operations on a node and resolves their DIDs, such as sidetree.Resolver
+type Anchorer interface {
+	resolution.Resolver
+	Anchor(ctx context.Context, op sidetree.AnchorOperation) (*resolution.Result, error)
+}
+
+// SidetreeRegistrar creates, updates and deactivates the DIDs of a Sidetree method, such as did:ion, by anchoring
+// operations on a node.
+//
+// Update and recovery keys are generated unless the request's secret holds public keys with the update and recovery
+// purposes, and are returned in the secret. Updates are signed with the update key and deactivations with the
+// recovery key of the secret. When the secret holds their public keys alone, the client is asked to sign the
+// operation, and updates commit to the public key of the secret with the nextUpdate purpose. Jobs wait until their
+// operations have been anchored, and the generated keys are returned once their job has finished. Job ids are
+// generated by the registrar, and jobs which are not continued within a day are evicted.
+type SidetreeRegistrar[C sidetree.Commitment] struct {
+	protocol sidetree.Protocol
+	anchorer Anchorer
+
+	mu   sync.Mutex
+	jobs map[string]*sidetreeJob
+}
+
+var _ Registrar = (*SidetreeRegistrar[string])(nil)
+
+// sidetreeJob is an operation waiting on the client's signature or on being anchored
+type sidetreeJob struct {
+	operation string
+	did       string
+	secret    *Secret
+	metadata  map[string]any
+	// expires is when the job is evicted unless it is continued, guarded by the registrar's mutex
+	expires time.Time
+	// signingRequestID is the id of the signing request the job's operation is assembled with
+	signingRequestID string
+	// assemble completes the operation with the client's signature, until it has been signed
+	assemble func(signature []byte) (sidetree.AnchorOperation, error)
+	// anchored reports whether the operation has been anchored, from the document metadata of the DID
+	anchored func(metadata *resolution.DocumentMetadata) bool
+
+	// mu serializes the continuations of the job, guarding assemble and done
+	mu sync.Mutex
+	// done is set once the job has finished, after which it can no longer be continued
+	done bool
+}
+
+// NewSidetreeRegistrar creates a registrar for the DIDs of the protocol, anchoring their operations with the anchorer
+func NewSidetreeRegistrar[C sidetree.Commitment](p sidetree.Protocol, anchorer Anchorer) (*SidetreeRegistrar[C], error) {
+	if err := p.Validate(); err != nil {
+		return nil, errors.Wrap(err, "invalid protocol")
+	}
+	if anchorer == nil {
+		return nil, errors.New("anchorer cannot be nil")
+	}
+	return &SidetreeRegistrar[C]{protocol: p, anchorer: anchorer, jobs: make(map[string]*sidetreeJob)}, nil
+}
+
+// NewIONRegistrar creates a registrar for did:ion DIDs
+func NewIONRegistrar(anchorer Anchorer) (*SidetreeRegistrar[string], error) {
+	return NewSidetreeRegistrar[string](ion.Protocol, anchorer)
+}
+
+// NewModenaRegistrar creates a registrar for the DIDs of Modena nodes
+func NewModenaRegistrar(anchorer Anchorer) (*SidetreeRegistrar[[]string], error) {
+	return NewSidetreeRegistrar[[]string](modena.Protocol, anchorer)
+}
+
+// Create anchors a create operation for the verification methods and services of the request's DID Document. A
+// document without verification methods is given one for a generated key of the options' key type, defaulting to
+// secp256k1, with the authentication and assertionMethod purposes.
+func (r *SidetreeRegistrar[C]) Create(ctx context.Context, request CreateRequest) (*Response, error) {
+	if request.JobID != "" {
+		return r.continueJob(ctx, request.JobID, createOperation, "", request.Secret)
+	}
+
+	var requestDoc did.Document
+	if request.DIDDocument != nil {
+		requestDoc = *request.DIDDocument
+	}
+	doc, err := toSidetreeDocument(requestDoc)
+	if err != nil {
+		return nil, err
+	}
+	secret := new(Secret)
+	if len(doc.PublicKeys) == 0 {
+		publicKey, secretVM, err := generateSidetreeKey(keyTypeOrDefault(request.Options, crypto.SECP256k1))
+		if err != nil {
+			return nil, err
+		}
+		doc.PublicKeys = append(doc.PublicKeys, *publicKey)
+		secret.VerificationMethod = append(secret.VerificationMethod, *secretVM)
+	}
+
+	var created *sidetree.DID[C]
+	var createRequest *sidetree.CreateRequest[C]
+	updateVM, recoveryVM := request.Secret.Find(PurposeUpdate), request.Secret.Find(PurposeRecovery)
+	switch {
+	case updateVM == nil && recoveryVM == nil:
+		created, createRequest, err = sidetree.NewDID[C](r.protocol, *doc)
+		if err != nil {
+			return nil, errors.Wrap(err, "creating DID")
+		}
+		updateKey, updatePrivateKey := created.GetUpdateKey(), created.GetUpdatePrivateKey()
+		recoveryKey, recoveryPrivateKey := created.GetRecoveryKey(), created.GetRecoveryPrivateKey()
+		secret.VerificationMethod = append(secret.VerificationMethod,
+			SecretVerificationMethod{PublicKeyJWK: &updateKey, PrivateKeyJWK: &updatePrivateKey, Purpose: []string{PurposeUpdate}},
+			SecretVerificationMethod{PublicKeyJWK: &recoveryKey, PrivateKeyJWK: &recoveryPrivateKey, Purpose: []string{PurposeRecovery}})
+	case updateVM != nil && updateVM.PublicKeyJWK != nil && recoveryVM != nil && recoveryVM.PublicKeyJWK != nil:
+		created, createRequest, err = sidetree.NewDIDFromKeys[C](r.protocol, *recoveryVM.PublicKeyJWK, *updateVM.PublicKeyJWK, *doc)
+		if err != nil {
+			return nil, errors.Wrap(err, "creating DID")
+		}
+	default:
+		return nil, errors.New("the secret must hold the public keys of both the update and recovery keys, or neither")
+	}
+	if len(secret.VerificationMethod) == 0 {
+		secret = nil
+	}
+
+	job := &sidetreeJob{
+		operation: createOperation,
+		did:       created.ID(),
+		secret:    secret,
+		metadata:  map[string]any{longFormDIDMetadata: created.LongForm()},
+		anchored: func(metadata *resolution.DocumentMetadata) bool {
+			return metadata != nil && metadata.Method.Published
+		},
+	}
+	return r.submit(ctx, uuid.NewString(), job, createRequest)
+}
+
+// Update anchors an update operation adding or removing the verification methods and services of the request's DID
+// Documents. Replacing the DID Document with setDidDocument is not supported, as it requires a recover operation.
+func (r *SidetreeRegistrar[C]) Update(ctx context.Context, request UpdateRequest) (*Response, error) {
+	if request.JobID != "" {
+		return r.continueJob(ctx, request.JobID, updateOperation, request.DID, request.Secret)
+	}
+
+	suffix, err := r.protocol.Suffix(request.DID)
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid DID: %s", request.DID)
+	}
+	stateChange, err := toStateChange(request.DIDDocumentOperation, request.DIDDocument)
+	if err != nil {
+		return nil, err
+	}
+	updateVM := request.Secret.Find(PurposeUpdate)
+	if updateVM == nil {
+		return nil, errors.New("the secret must hold the update key")
+	}
+	updateKey, err := secretPublicKey(*updateVM)
+	if err != nil {
+		return nil, err
+	}
+
+	var nextUpdateKey jwx.PublicKeyJWK
+	var secret *Secret
+	if updateVM.PrivateKeyJWK != nil {
+		nextKey, nextPrivateKey, err := generateOperationKey()
+		if err != nil {
+			return nil, err
+		}
+		nextUpdateKey = *nextKey
+		secret = &Secret{VerificationMethod: []SecretVerificationMethod{
+			{PublicKeyJWK: nextKey, PrivateKeyJWK: nextPrivateKey, Purpose: []string{PurposeUpdate}},
+		}}
+	} else {
+		nextUpdateVM := request.Secret.Find(PurposeNextUpdate)
+		if nextUpdateVM == nil || nextUpdateVM.PublicKeyJWK == nil {
+			return nil, errors.New("the secret must hold the public key of the next update key when it holds the update public key alone")
+		}
+		nextUpdateKey = *nextUpdateVM.PublicKeyJWK
+	}
+	_, nextCommitment, err := r.protocol.Commit(nextUpdateKey)
+	if err != nil {
+		return nil, errors.Wrap(err, "committing to the next update key")
+	}
+
+	unsigned, err := sidetree.PrepareUpdateRequest[C](r.protocol, suffix, updateKey, nextUpdateKey, *stateChange)
+	if err != nil {
+		return nil, errors.Wrap(err, "preparing update request")
+	}
+	job := &sidetreeJob{
+		operation: updateOperation,
+		did:       request.DID,
+		secret:    secret,
+		anchored: func(metadata *resolution.DocumentMetadata) bool {
+			return metadata != nil && hasCommitment(metadata.Method.UpdateCommitment, nextCommitment)
+		},
+	}
+	jobID := uuid.NewString()
+	if updateVM.PrivateKeyJWK == nil {
+		job.assemble = func(signature []byte) (sidetree.AnchorOperation, error) {
+			return unsigned.Assemble(signature)
+		}
+		return r.requestSignature(jobID, job, unsigned.SigningInput, *updateVM, PurposeUpdate)
+	}
+	signer, err := sidetree.NewBTCSignerVerifier(*updateVM.PrivateKeyJWK)
+	if err != nil {
+		return nil, errors.Wrap(err, "creating signer")
+	}
+	updateRequest, err := unsigned.Sign(signer)
+	if err != nil {
+		return nil, err
+	}
+	return r.submit(ctx, jobID, job, updateRequest)
+}
+
+// Deactivate anchors a deactivate operation signed with the recovery key of the secret
+func (r *SidetreeRegistrar[C]) Deactivate(ctx context.Context, request DeactivateRequest) (*Response, error) {
+	if request.JobID != "" {
+		return r.continueJob(ctx, request.JobID, deactivateOperation, request.DID, request.Secret)
+	}
+
+	suffix, err := r.protocol.Suffix(request.DID)
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid DID: %s", request.DID)
+	}
+	recoveryVM := request.Secret.Find(PurposeRecovery)
+	if recoveryVM == nil {
+		return nil, errors.New("the secret must hold the recovery key")
+	}
+	recoveryKey, err := secretPublicKey(*recoveryVM)
+	if err != nil {
+		return nil, err
+	}
+	unsigned, err := sidetree.PrepareDeactivateRequest(r.protocol, suffix, recoveryKey)
+	if err != nil {
+		return nil, errors.Wrap(err, "preparing deactivate request")
+	}
+	job := &sidetreeJob{
+		operation: deactivateOperation,
+		did:       request.DID,
+		anchored: func(metadata *resolution.DocumentMetadata) bool {
+			return metadata != nil && metadata.Deactivated
+		},
+	}
+	jobID := uuid.NewString()
+	if recoveryVM.PrivateKeyJWK == nil {
+		job.assemble = func(signature []byte) (sidetree.AnchorOperation, error) {
+			return unsigned.Assemble(signature)
+		}
+		return r.requestSignature(jobID, job, unsigned.SigningInput, *recoveryVM, PurposeRecovery)
+	}
+	signer, err := sidetree.NewBTCSignerVerifier(*recoveryVM.PrivateKeyJWK)
+	if err != nil {
+		return nil, errors.Wrap(err, "creating signer")
+	}
+	deactivateRequest, err := unsigned.Sign(signer)
+	if err != nil {
+		return nil, err
+	}
+	return r.submit(ctx, jobID, job, deactivateRequest)
+}
+
+func (r *SidetreeRegistrar[C]) Methods() []did.Method {
+	return r.protocol.Methods()
+}
+
+// job returns the job with the id, if there is one which has not expired
+func (r *SidetreeRegistrar[C]) job(jobID string) (*sidetreeJob, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	job, ok := r.jobs[jobID]
+	if !ok || time.Now().After(job.expires) {
+		return nil, false
+	}
+	return job, true
+}
+
+// store keeps a job until it is continued or expires, evicting the jobs which have expired
+func (r *SidetreeRegistrar[C]) store(jobID string, job *sidetreeJob) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	now := time.Now()
+	for id, stored := range r.jobs {
+		if now.After(stored.expires) {
+			delete(r.jobs, id)
+		}
+	}
+	job.expires = now.Add(jobExpiry)
+	r.jobs[jobID] = job
+}
+
+// continueJob assembles the operation of a job waiting on a signature with the signing response of the secret, or
+// checks whether the operation of a job waiting on anchoring has been anchored. The job must be for the operation,
+// and for the DID when one is given.
+func (r *SidetreeRegistrar[C]) continueJob(ctx context.Context, jobID, operation, id string, secret *Secret) (*Response, error) {
+	job, ok := r.job(jobID)
+	if !ok {
+		return nil, errors.Errorf("unknown job: %s", jobID)
+	}
+	job.mu.Lock()
+	defer job.mu.Unlock()
+	if job.done {
+		return nil, errors.Errorf("unknown job: %s", jobID)
+	}
+	if job.operation != operation {
+		return nil, errors.Errorf("job %s is for the %s operation, not %s", jobID, job.operation, operation)
+	}
+	if id != "" && id != job.did {
+		return nil, errors.Errorf("job %s is for %s, not %s", jobID, job.did, id)
+	}
+
+	if job.assemble != nil {
+		var signingResponse SigningResponse
+		if secret != nil {
+			signingResponse = secret.SigningResponse[job.signingRequestID]
+		}
+		if signingResponse.Signature == "" {
+			return nil, errors.Errorf("the secret must hold the signing response of %s", job.signingRequestID)
+		}
+		signature, err := sidetree.Decode(signingResponse.Signature)
+		if err != nil {
+			return nil, errors.Wrap(err, "decoding signature")
+		}
+		op, err := job.assemble(signature)
+		if err != nil {
+			return nil, err
+		}
+		return r.submit(ctx, jobID, job, op)
+	}
+
+	result, err := r.anchorer.Resolve(ctx, job.did)
+	if err != nil {
+		if resolution.IsNotFound(err) {
+			return r.state(jobID, job, nil), nil
+		}
+		return nil, errors.Wrapf(err, "resolving %s", job.did)
+	}
+	return r.state(jobID, job, result), nil
+}
+
+// requestSignature stores a job until the client returns the signature of the signing input of its operation
+func (r *SidetreeRegistrar[C]) requestSignature(jobID string, job *sidetreeJob, signingInput sidetree.SigningInput, vm SecretVerificationMethod, purpose string) (*Response, error) {
+	hash, err := signingInput.Hash()
+	if err != nil {
+		return nil, errors.Wrap(err, "hashing signing input")
+	}
+	job.signingRequestID = purpose
+	r.store(jobID, job)
+	return &Response{
+		JobID: jobID,
+		DIDState: DIDState{
+			State:  StateAction,
+			DID:    job.did,
+			Action: ActionSignPayload,
+			SigningRequest: map[string]SigningRequest{job.signingRequestID: {
+				SerializedPayload: sidetree.Encode(hash),
+				KID:               vm.ID,
+				Alg:               sidetreeSigningAlg,
+				Purpose:           purpose,
+			}},
+		},
+	}, nil
+}
+
+// submit anchors the operation of a job, which is either new or locked by its continuation
+func (r *SidetreeRegistrar[C]) submit(ctx context.Context, jobID string, job *sidetreeJob, op sidetree.AnchorOperation) (*Response, error) {
+	result, err := r.anchorer.Anchor(ctx, op)
+	if err != nil {
+		return nil, errors.Wrap(err, "anchoring operation")
+	}
+	job.assemble = nil
+	return r.state(jobID, job, result), nil
+}
+
+// state returns the finished state of a job whose operation has been anchored, with the job's secret, and otherwise
+// keeps the job and returns its wait state. The job is either new or locked by its continuation.
+func (r *SidetreeRegistrar[C]) state(jobID string, job *sidetreeJob, result *resolution.Result) *Response {
+	if result == nil || !job.anchored(result.DocumentMetadata) {
+		r.store(jobID, job)
+		return &Response{
+			JobID: jobID,
+			DIDState: DIDState{
+				State:    StateWait,
+				DID:      job.did,
+				Wait:     "waiting for the operation to be anchored",
+				WaitTime: anchorWait.Milliseconds(),
+			},
+			RegistrationMetadata: job.metadata,
+		}
+	}
+
+	// the secret is returned once, and dropped along with the job
+	job.done = true
+	r.mu.Lock()
+	delete(r.jobs, jobID)
+	r.mu.Unlock()
+	response := finished(jobID, job.did, nil, job.secret)
+	job.secret = nil
+	if !result.DocumentMetadata.Deactivated {
+		response.DIDState.DIDDocument = &result.Document
+	}
+	response.RegistrationMetadata = job.metadata
+	response.DocumentMetadata = result.DocumentMetadata
+	return response
+}
+
+// toSidetreeDocument converts the verification methods and services of a DID Document to a Sidetree document, with
+// the purposes of each public key given by the verification relationships referencing it
+func toSidetreeDocument(doc did.Document) (*sidetree.Document, error) {
+	var sidetreeDoc sidetree.Document
+	for _, vm := range doc.VerificationMethod {
+		if vm.PublicKeyJWK == nil {
+			return nil, errors.Errorf("verification method %s must have a publicKeyJwk", vm.ID)
+		}
+		var purposes []sidetree.PublicKeyPurpose
+		for _, relationship := range []struct {
+			purpose      sidetree.PublicKeyPurpose
+			relationship did.Relationship
+		}{
+			{purpose: sidetree.Authentication, relationship: did.Authentication},
+			{purpose: sidetree.AssertionMethod, relationship: did.AssertionMethod},
+			{purpose: sidetree.KeyAgreement, relationship: did.KeyAgreement},
+			{purpose: sidetree.CapabilityInvocation, relationship: did.CapabilityInvocation},
+			{purpose: sidetree.CapabilityDelegation, relationship: did.CapabilityDelegation},
+		} {
+			if doc.HasRelationship(vm.ID, relationship.relationship) {
+				purposes = append(purposes, relationship.purpose)
+			}
+		}
+		sidetreeDoc.PublicKeys = append(sidetreeDoc.PublicKeys, sidetree.PublicKey{
+			ID:           fragment(vm.ID),
+			Type:         string(vm.Type),
+			PublicKeyJWK: *vm.PublicKeyJWK,
+			Purposes:     purposes,
+		})
+	}
+	for _, service := range doc.Services {
+		service.ID = fragment(service.ID)
+		sidetreeDoc.Services = append(sidetreeDoc.Services, service)
+	}
+	return &sidetreeDoc, nil
+}
+
+// toStateChange converts the operations of an update request to the state change of a Sidetree update
+func toStateChange(operations []DocumentOperation, docs []did.Document) (*sidetree.StateChange, error) {
+	if len(operations) != len(docs) {
+		return nil, errors.New("each operation must have a DID Document")
+	}
+	var stateChange sidetree.StateChange
+	for i, operation := range operations {
+		switch operation {
+		case AddToDocument:
+			added, err := toSidetreeDocument(docs[i])
+			if err != nil {
+				return nil, err
+			}
+			stateChange.PublicKeysToAdd = append(stateChange.PublicKeysToAdd, added.PublicKeys...)
+			stateChange.ServicesToAdd = append(stateChange.ServicesToAdd, added.Services...)
+		case RemoveFromDocument:
+			for _, vm := range docs[i].VerificationMethod {
+				stateChange.PublicKeyIDsToRemove = append(stateChange.PublicKeyIDsToRemove, fragment(vm.ID))
+			}
+			for _, service := range docs[i].Services {
+				stateChange.ServiceIDsToRemove = append(stateChange.ServiceIDsToRemove, fragment(service.ID))
+			}
+		default:
+			return nil, errors.Errorf("unsupported operation for Sidetree DIDs: %s", operation)
+		}
+	}
+	return &stateChange, nil
+}
+
+// generateSidetreeKey generates a key for the DID Document, with the authentication and assertionMethod purposes
+func generateSidetreeKey(kt crypto.KeyType) (*sidetree.PublicKey, *SecretVerificationMethod, error) {
+	_, privateKey, err := crypto.GenerateKeyByKeyType(kt)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "generating key")
+	}
+	const id = "key-1"
+	publicKeyJWK, privateKeyJWK, err := jwx.PrivateKeyToPrivateKeyJWK(id, privateKey)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "converting key to JWK")
+	}
+	publicKey := sidetree.PublicKey{
+		ID:           id,
+		Type:         "JsonWebKey2020",
+		PublicKeyJWK: *publicKeyJWK,
+		Purposes:     []sidetree.PublicKeyPurpose{sidetree.Authentication, sidetree.AssertionMethod},
+	}
+	secretVM := SecretVerificationMethod{
+		ID:            "#" + id,
+		Type:          publicKey.Type,
+		PublicKeyJWK:  publicKeyJWK,
+		PrivateKeyJWK: privateKeyJWK,
+		Purpose:       []string{string(sidetree.Authentication), string(sidetree.AssertionMethod)},
+	}
+	return &publicKey, &secretVM, nil
+}
+
+// generateOperationKey generates a secp256k1 update or recovery key
+func generateOperationKey() (*jwx.PublicKeyJWK, *jwx.PrivateKeyJWK, error) {
+	_, privateKey, err := crypto.GenerateSECP256k1Key()
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "generating secp256k1 key")
+	}
+	publicKeyJWK, privateKeyJWK, err := jwx.PrivateKeyToPrivateKeyJWK(uuid.NewString(), privateKey)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "converting key to JWK")
+	}
+	return publicKeyJWK, privateKeyJWK, nil
+}
+
+// secretPublicKey returns the public key of an update or recovery key, which must be exactly the key committed to
+func secretPublicKey(vm SecretVerificationMethod) (jwx.PublicKeyJWK, error) {
+	if vm.PublicKeyJWK == nil {
+		return jwx.PublicKeyJWK{}, errors.Errorf("the %s key of the secret must have its public key", strings.Join(vm.Purpose, ", "))
+	}
+	return *vm.PublicKeyJWK, nil
+}
+
+// hasCommitment checks the commitment of resolved document metadata, which is a string or a list of strings
+func hasCommitment(commitments any, commitment string) bool {
+	switch c := commitments.(type) {
+	case string:
+		return c == commitment
+	case []string:
+		for _, s := range c {
+			if s == commitment {
+				return true
+			}
+		}
+	case []any:
+		for _, s := range c {
+			if s == commitment {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// fragment returns the fragment of a verification method or service id, or the id itself if it has none
+func fragment(id string) string {
+	if i := strings.LastIndex(id, "#"); i >= 0 {
+		return id[i+1:]
+	}
+	return id
+}
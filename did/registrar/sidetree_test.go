@@ -0,0 +1,332 @@
+package registrar
+
+import (
+	"context"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/extrimian/ssi-sdk/crypto"
+	"github.com/extrimian/ssi-sdk/crypto/jwx"
+	"github.com/extrimian/ssi-sdk/did"
+	"github.com/extrimian/ssi-sdk/did/ion"
+	"github.com/extrimian/ssi-sdk/did/modena"
+	"github.com/extrimian/ssi-sdk/did/resolution"
+	"github.com/extrimian/ssi-sdk/did/sidetree"
+)
+
+// pendingAnchorer anchors operations on a node, reporting them as unpublished until the DID is resolved
+type pendingAnchorer[C sidetree.Commitment] struct {
+	*sidetree.Node[C]
+}
+
+func (a pendingAnchorer[C]) Anchor(_ context.Context, op sidetree.AnchorOperation) (*resolution.Result, error) {
+	result, err := a.Submit(op)
+	if err != nil {
+		return nil, err
+	}
+	result.DocumentMetadata = &resolution.DocumentMetadata{Method: resolution.Method{Published: false}}
+	return result, nil
+}
+
+func TestSidetreeRegistrar(t *testing.T) {
+	ctx := context.Background()
+	node, err := sidetree.NewNode[string](ion.Protocol)
+	require.NoError(t, err)
+	server := httptest.NewTLSServer(node)
+	defer server.Close()
+	resolver, err := ion.NewIONResolver(server.Client(), server.URL)
+	require.NoError(t, err)
+	r, err := NewIONRegistrar(resolver)
+	require.NoError(t, err)
+	assert.Equal(t, []did.Method{did.IONMethod}, r.Methods())
+
+	service := did.Service{ID: "#linked", Type: "LinkedDomains", ServiceEndpoint: "https://example.com"}
+
+	t.Run("create, update and deactivate with generated keys", func(tt *testing.T) {
+		created, err := r.Create(ctx, CreateRequest{Method: did.IONMethod})
+		require.NoError(tt, err)
+		assert.Equal(tt, StateFinished, created.DIDState.State)
+		assert.True(tt, ion.Protocol.IsValid(created.DIDState.DID))
+		assert.NotEmpty(tt, created.RegistrationMetadata[longFormDIDMetadata])
+		require.NotNil(tt, created.DIDState.DIDDocument)
+		require.Len(tt, created.DIDState.DIDDocument.VerificationMethod, 1)
+		assert.Len(tt, created.DIDState.DIDDocument.Authentication, 1)
+
+		// the secret holds the generated document, update and recovery keys
+		secret := created.DIDState.Secret
+		require.NotNil(tt, secret)
+		require.Len(tt, secret.VerificationMethod, 3)
+		assert.NotNil(tt, secret.Find(string(sidetree.Authentication)).PrivateKeyJWK)
+		assert.NotNil(tt, secret.Find(PurposeUpdate).PrivateKeyJWK)
+		assert.NotNil(tt, secret.Find(PurposeRecovery).PrivateKeyJWK)
+
+		updated, err := r.Update(ctx, UpdateRequest{
+			DID:                  created.DIDState.DID,
+			Secret:               secret,
+			DIDDocumentOperation: []DocumentOperation{AddToDocument},
+			DIDDocument:          []did.Document{{Services: []did.Service{service}}},
+		})
+		require.NoError(tt, err)
+		assert.Equal(tt, StateFinished, updated.DIDState.State)
+		require.Len(tt, updated.DIDState.DIDDocument.Services, 1)
+		assert.Equal(tt, service.ServiceEndpoint, updated.DIDState.DIDDocument.Services[0].ServiceEndpoint)
+
+		// the secret holds the next update key, and the previous one can no longer be used
+		nextUpdateVM := updated.DIDState.Secret.Find(PurposeUpdate)
+		require.NotNil(tt, nextUpdateVM)
+		_, err = r.Update(ctx, UpdateRequest{
+			DID:                  created.DIDState.DID,
+			Secret:               secret,
+			DIDDocumentOperation: []DocumentOperation{RemoveFromDocument},
+			DIDDocument:          []did.Document{{Services: []did.Service{service}}},
+		})
+		assert.ErrorContains(tt, err, "anchoring operation")
+
+		updated, err = r.Update(ctx, UpdateRequest{
+			DID:                  created.DIDState.DID,
+			Secret:               &Secret{VerificationMethod: []SecretVerificationMethod{*nextUpdateVM}},
+			DIDDocumentOperation: []DocumentOperation{RemoveFromDocument},
+			DIDDocument:          []did.Document{{Services: []did.Service{service}}},
+		})
+		require.NoError(tt, err)
+		assert.Equal(tt, StateFinished, updated.DIDState.State)
+		assert.Empty(tt, updated.DIDState.DIDDocument.Services)
+
+		deactivated, err := r.Deactivate(ctx, DeactivateRequest{DID: created.DIDState.DID, Secret: secret})
+		require.NoError(tt, err)
+		assert.Equal(tt, StateFinished, deactivated.DIDState.State)
+		assert.Nil(tt, deactivated.DIDState.DIDDocument)
+		require.NotNil(tt, deactivated.DocumentMetadata)
+		assert.True(tt, deactivated.DocumentMetadata.Deactivated)
+	})
+
+	t.Run("create, update and deactivate with the client's keys", func(tt *testing.T) {
+		updateKey, updateSigner := generateClientKey(tt)
+		recoveryKey, recoverySigner := generateClientKey(tt)
+		documentKey, _ := generateClientKey(tt)
+		doc := did.Document{
+			VerificationMethod: []did.VerificationMethod{{ID: "#signing", Type: "JsonWebKey2020", PublicKeyJWK: &documentKey}},
+			AssertionMethod:    []did.VerificationMethodSet{"#signing"},
+			Services:           []did.Service{service},
+		}
+		created, err := r.Create(ctx, CreateRequest{
+			Method: did.IONMethod,
+			Secret: &Secret{VerificationMethod: []SecretVerificationMethod{
+				{PublicKeyJWK: &updateKey, Purpose: []string{PurposeUpdate}},
+				{PublicKeyJWK: &recoveryKey, Purpose: []string{PurposeRecovery}},
+			}},
+			DIDDocument: &doc,
+		})
+		require.NoError(tt, err)
+		assert.Equal(tt, StateFinished, created.DIDState.State)
+		assert.Nil(tt, created.DIDState.Secret)
+		require.Len(tt, created.DIDState.DIDDocument.VerificationMethod, 1)
+		assert.Len(tt, created.DIDState.DIDDocument.AssertionMethod, 1)
+		assert.Empty(tt, created.DIDState.DIDDocument.Authentication)
+		assert.Len(tt, created.DIDState.DIDDocument.Services, 1)
+
+		// the client is asked to sign the update with its update key
+		nextUpdateKey, _ := generateClientKey(tt)
+		action, err := r.Update(ctx, UpdateRequest{
+			DID: created.DIDState.DID,
+			Secret: &Secret{VerificationMethod: []SecretVerificationMethod{
+				{ID: "update-key", PublicKeyJWK: &updateKey, Purpose: []string{PurposeUpdate}},
+				{PublicKeyJWK: &nextUpdateKey, Purpose: []string{PurposeNextUpdate}},
+			}},
+			DIDDocumentOperation: []DocumentOperation{RemoveFromDocument},
+			DIDDocument:          []did.Document{{VerificationMethod: []did.VerificationMethod{{ID: "#signing"}}}},
+		})
+		require.NoError(tt, err)
+		assert.Equal(tt, StateAction, action.DIDState.State)
+		assert.Equal(tt, ActionSignPayload, action.DIDState.Action)
+		signingRequest, ok := action.DIDState.SigningRequest[PurposeUpdate]
+		require.True(tt, ok)
+		assert.Equal(tt, "update-key", signingRequest.KID)
+		assert.Equal(tt, "ES256K", signingRequest.Alg)
+
+		// a signature by another key is rejected, and the job can be continued
+		_, err = r.Update(ctx, UpdateRequest{JobID: action.JobID, Secret: signingResponse(tt, recoverySigner, signingRequest)})
+		assert.ErrorContains(tt, err, "not signed by the update key")
+		_, err = r.Update(ctx, UpdateRequest{JobID: action.JobID})
+		assert.ErrorContains(tt, err, "must hold the signing response")
+
+		updated, err := r.Update(ctx, UpdateRequest{JobID: action.JobID, Secret: signingResponse(tt, updateSigner, signingRequest)})
+		require.NoError(tt, err)
+		assert.Equal(tt, action.JobID, updated.JobID)
+		assert.Equal(tt, StateFinished, updated.DIDState.State)
+		assert.Empty(tt, updated.DIDState.DIDDocument.VerificationMethod)
+
+		// the client is asked to sign the deactivation with its recovery key
+		action, err = r.Deactivate(ctx, DeactivateRequest{
+			DID:    created.DIDState.DID,
+			Secret: &Secret{VerificationMethod: []SecretVerificationMethod{{PublicKeyJWK: &recoveryKey, Purpose: []string{PurposeRecovery}}}},
+		})
+		require.NoError(tt, err)
+		assert.Equal(tt, StateAction, action.DIDState.State)
+		signingRequest = action.DIDState.SigningRequest[PurposeRecovery]
+		deactivated, err := r.Deactivate(ctx, DeactivateRequest{JobID: action.JobID, Secret: signingResponse(tt, recoverySigner, signingRequest)})
+		require.NoError(tt, err)
+		assert.Equal(tt, StateFinished, deactivated.DIDState.State)
+		assert.True(tt, deactivated.DocumentMetadata.Deactivated)
+	})
+
+	t.Run("invalid requests", func(tt *testing.T) {
+		updateKey, _ := generateClientKey(tt)
+		_, err := r.Create(ctx, CreateRequest{Secret: &Secret{VerificationMethod: []SecretVerificationMethod{
+			{PublicKeyJWK: &updateKey, Purpose: []string{PurposeUpdate}},
+		}}})
+		assert.ErrorContains(tt, err, "both the update and recovery keys, or neither")
+
+		_, err = r.Create(ctx, CreateRequest{DIDDocument: &did.Document{
+			VerificationMethod: []did.VerificationMethod{{ID: "#key", PublicKeyBase58: "abc"}},
+		}})
+		assert.ErrorContains(tt, err, "must have a publicKeyJwk")
+
+		_, err = r.Update(ctx, UpdateRequest{DID: "did:web:example.com"})
+		assert.ErrorContains(tt, err, "invalid DID")
+
+		_, err = r.Update(ctx, UpdateRequest{
+			DID:                  "did:ion:EiDyOQbbZAa3aiRzeCkV7LOx3SERjjH93EXoIM3UoN4oWg",
+			DIDDocumentOperation: []DocumentOperation{SetDocument},
+			DIDDocument:          []did.Document{{}},
+		})
+		assert.ErrorContains(tt, err, "unsupported operation for Sidetree DIDs: setDidDocument")
+
+		_, err = r.Update(ctx, UpdateRequest{
+			DID:                  "did:ion:EiDyOQbbZAa3aiRzeCkV7LOx3SERjjH93EXoIM3UoN4oWg",
+			DIDDocumentOperation: []DocumentOperation{AddToDocument},
+			DIDDocument:          []did.Document{{Services: []did.Service{service}}},
+		})
+		assert.ErrorContains(tt, err, "the secret must hold the update key")
+
+		_, err = r.Update(ctx, UpdateRequest{
+			DID: "did:ion:EiDyOQbbZAa3aiRzeCkV7LOx3SERjjH93EXoIM3UoN4oWg",
+			Secret: &Secret{VerificationMethod: []SecretVerificationMethod{
+				{PublicKeyJWK: &updateKey, Purpose: []string{PurposeUpdate}},
+			}},
+			DIDDocumentOperation: []DocumentOperation{AddToDocument},
+			DIDDocument:          []did.Document{{Services: []did.Service{service}}},
+		})
+		assert.ErrorContains(tt, err, "next update key")
+
+		_, err = r.Deactivate(ctx, DeactivateRequest{DID: "did:ion:EiDyOQbbZAa3aiRzeCkV7LOx3SERjjH93EXoIM3UoN4oWg"})
+		assert.ErrorContains(tt, err, "the secret must hold the recovery key")
+	})
+
+	t.Run("nil anchorer", func(tt *testing.T) {
+		_, err := NewIONRegistrar(nil)
+		assert.ErrorContains(tt, err, "anchorer cannot be nil")
+	})
+}
+
+func TestSidetreeRegistrarWait(t *testing.T) {
+	ctx := context.Background()
+	node, err := sidetree.NewNode[[]string](modena.Protocol)
+	require.NoError(t, err)
+	r, err := NewModenaRegistrar(pendingAnchorer[[]string]{Node: node})
+	require.NoError(t, err)
+
+	// the job waits until the node has anchored the operation, returning the generated keys once it has finished
+	waiting, err := r.Create(ctx, CreateRequest{})
+	require.NoError(t, err)
+	assert.Equal(t, StateWait, waiting.DIDState.State)
+	assert.Positive(t, waiting.DIDState.WaitTime)
+	assert.Nil(t, waiting.DIDState.Secret)
+	assert.NotEmpty(t, waiting.RegistrationMetadata[longFormDIDMetadata])
+
+	// jobs are continued by requests for the same operation
+	_, err = r.Update(ctx, UpdateRequest{JobID: waiting.JobID})
+	assert.ErrorContains(t, err, "is for the create operation, not update")
+
+	finishedCreate, err := r.Create(ctx, CreateRequest{JobID: waiting.JobID})
+	require.NoError(t, err)
+	assert.Equal(t, waiting.JobID, finishedCreate.JobID)
+	assert.Equal(t, StateFinished, finishedCreate.DIDState.State)
+	assert.Equal(t, waiting.DIDState.DID, finishedCreate.DIDState.DID)
+	require.NotNil(t, finishedCreate.DIDState.DIDDocument)
+	require.NotNil(t, finishedCreate.DIDState.Secret)
+	assert.NotNil(t, finishedCreate.DIDState.Secret.Find(PurposeUpdate))
+
+	// the secret is returned once, as the finished job is dropped
+	_, err = r.Create(ctx, CreateRequest{JobID: waiting.JobID})
+	assert.ErrorContains(t, err, "unknown job")
+
+	// updates are anchored once the DID's update commitment is the commitment to the next update key
+	waiting, err = r.Update(ctx, UpdateRequest{
+		DID:                  finishedCreate.DIDState.DID,
+		Secret:               finishedCreate.DIDState.Secret,
+		DIDDocumentOperation: []DocumentOperation{AddToDocument},
+		DIDDocument:          []did.Document{{Services: []did.Service{{ID: "#linked", Type: "LinkedDomains", ServiceEndpoint: "https://example.com"}}}},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, StateWait, waiting.DIDState.State)
+	_, err = r.Update(ctx, UpdateRequest{JobID: waiting.JobID, DID: "did:ion:EiDyOQbbZAa3aiRzeCkV7LOx3SERjjH93EXoIM3UoN4oWg"})
+	assert.ErrorContains(t, err, "job "+waiting.JobID+" is for "+finishedCreate.DIDState.DID)
+	finishedUpdate, err := r.Update(ctx, UpdateRequest{JobID: waiting.JobID})
+	require.NoError(t, err)
+	assert.Equal(t, StateFinished, finishedUpdate.DIDState.State)
+	assert.Len(t, finishedUpdate.DIDState.DIDDocument.Services, 1)
+
+	// the job is done, and job ids are generated by the registrar
+	_, err = r.Update(ctx, UpdateRequest{JobID: waiting.JobID})
+	assert.ErrorContains(t, err, "unknown job")
+	_, err = r.Create(ctx, CreateRequest{JobID: "client-job"})
+	assert.ErrorContains(t, err, "unknown job: client-job")
+
+	// concurrent continuations of a job finish it once
+	waiting, err = r.Create(ctx, CreateRequest{})
+	require.NoError(t, err)
+	var wg sync.WaitGroup
+	var finishedCount atomic.Int32
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if response, err := r.Create(ctx, CreateRequest{JobID: waiting.JobID}); err == nil && response.DIDState.Secret != nil {
+				finishedCount.Add(1)
+			}
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, int32(1), finishedCount.Load())
+
+	// jobs which are not continued are evicted once they expire
+	waiting, err = r.Create(ctx, CreateRequest{})
+	require.NoError(t, err)
+	r.mu.Lock()
+	r.jobs[waiting.JobID].expires = time.Now().Add(-time.Second)
+	r.mu.Unlock()
+	_, err = r.Create(ctx, CreateRequest{JobID: waiting.JobID})
+	assert.ErrorContains(t, err, "unknown job")
+	_, err = r.Create(ctx, CreateRequest{})
+	require.NoError(t, err)
+	r.mu.Lock()
+	assert.NotContains(t, r.jobs, waiting.JobID)
+	r.mu.Unlock()
+}
+
+// generateClientKey generates a secp256k1 key held by the client, returning its public key and a signer
+func generateClientKey(t *testing.T) (jwx.PublicKeyJWK, sidetree.Signer) {
+	_, privateKey, err := crypto.GenerateSECP256k1Key()
+	require.NoError(t, err)
+	publicKeyJWK, privateKeyJWK, err := jwx.PrivateKeyToPrivateKeyJWK("", privateKey)
+	require.NoError(t, err)
+	signer, err := sidetree.NewBTCSignerVerifier(*privateKeyJWK)
+	require.NoError(t, err)
+	return *publicKeyJWK, signer
+}
+
+// signingResponse signs the payload of a signing request, returning the secret of the request continuing the job
+func signingResponse(t *testing.T, signer sidetree.Signer, request SigningRequest) *Secret {
+	payload, err := sidetree.Decode(request.SerializedPayload)
+	require.NoError(t, err)
+	signature, err := signer.Sign(payload)
+	require.NoError(t, err)
+	return &Secret{SigningResponse: map[string]SigningResponse{request.Purpose: {Signature: sidetree.Encode(signature)}}}
+}
@@ -0,0 +1,516 @@
+package registrar
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"sync"
+	"time"
+
+	"github.com/goccy/go-json"
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+
+	"github.com/extrimian/ssi-sdk/crypto"
+	"github.com/extrimian/ssi-sdk/crypto/jwx"
+	"github.com/extrimian/ssi-sdk/did"
+	"github.com/extrimian/ssi-sdk/did/resolution"
+	"github.com/extrimian/ssi-sdk/did/web"
+)
+
+// documentURLMetadata is the registration metadata holding the URL a did:web DID Document is hosted at
+const documentURLMetadata = "didDocumentUrl"
+
+// WebRegistrar creates, updates and deactivates did:web DIDs, whose documents are hosted by their controller. With a
+// host, documents are published to and removed from it and jobs finish right away. Without one, the client is asked
+// to publish or remove the document at its URL, and updates are made to the document resolved from that URL. The job
+// finishes, returning any generated keys, once the client continues it after the document has been published at or
+// removed from its URL.
+//
+// Updates and deactivations must be signed by a capabilityInvocation key of the current document. The registrar
+// signs them when the secret holds the private key with the capabilityInvocation purpose, and otherwise asks the
+// client to sign them with the key of the secret, or the first capabilityInvocation key of the document.
+type WebRegistrar struct {
+	host *web.Handler
+
+	mu   sync.Mutex
+	jobs map[string]*webJob
+}
+
+var _ Registrar = (*WebRegistrar)(nil)
+
+// webJob is an operation waiting on the client's signature, or on the client publishing or removing the document at
+// its URL
+type webJob struct {
+	operation string
+	did       string
+	// current is the document the operation was signed for, which must not change before the job is continued
+	current did.Document
+	// doc is the document to publish, or nil for a deactivation
+	doc *did.Document
+	// secret holds the generated keys, which are returned once the job has finished
+	secret *Secret
+	// signingInput is the JWS signing input the client signs, whose payload identifies the job and its operation
+	signingInput string
+	// verifier verifies the client's signature, until the operation has been signed
+	verifier *jwx.Verifier
+	// expires is when the job is evicted unless it is continued, guarded by the registrar's mutex
+	expires time.Time
+
+	// mu serializes the continuations of the job, guarding verifier, secret and done
+	mu sync.Mutex
+	// done is set once the job has finished, after which it can no longer be continued
+	done bool
+}
+
+// webSigningPayload is the payload of the JWS signing an update or deactivation
+type webSigningPayload struct {
+	JobID       string        `json:"jobId"`
+	DID         string        `json:"did"`
+	Operation   string        `json:"operation"`
+	DIDDocument *did.Document `json:"didDocument,omitempty"`
+}
+
+// NewWebRegistrar creates a registrar publishing documents to the given host, which may be nil
+func NewWebRegistrar(host *web.Handler) *WebRegistrar {
+	return &WebRegistrar{host: host, jobs: make(map[string]*webJob)}
+}
+
+// Create creates a did:web DID with the id of the request's DID Document, which did:web controllers choose. Documents
+// without verification methods are given one for a generated key of the options' key type, defaulting to Ed25519,
+// whose private key is returned in the secret once the job has finished, and which is the capabilityInvocation key
+// updates are signed with. DIDs whose document already exists are not created again.
+func (r *WebRegistrar) Create(ctx context.Context, request CreateRequest) (*Response, error) {
+	if request.JobID != "" {
+		return r.continueJob(ctx, request.JobID, createOperation, "", request.Secret)
+	}
+	if request.DIDDocument == nil || request.DIDDocument.ID == "" {
+		return nil, errors.New("did:web DIDs are chosen by their controller; the DID Document must have an id")
+	}
+	id := web.DIDWeb(request.DIDDocument.ID)
+	if _, err := id.GetDocURL(); err != nil {
+		return nil, errors.Wrap(err, "invalid did:web DID")
+	}
+	exists, err := r.exists(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if exists {
+		return nil, errors.Errorf("the DID Document of %s already exists", id)
+	}
+
+	doc := *request.DIDDocument
+	var secret *Secret
+	if len(doc.VerificationMethod) == 0 {
+		kt := keyTypeOrDefault(request.Options, crypto.Ed25519)
+		publicKey, privateKey, err := crypto.GenerateKeyByKeyType(kt)
+		if err != nil {
+			return nil, errors.Wrap(err, "generating key")
+		}
+		publicKeyBytes, err := crypto.PubKeyToBytes(publicKey, crypto.ECDSAMarshalCompressed)
+		if err != nil {
+			return nil, errors.Wrap(err, "converting public key to bytes")
+		}
+		created, err := id.CreateDoc(kt, publicKeyBytes)
+		if err != nil {
+			return nil, errors.Wrap(err, "creating did:web document")
+		}
+		created.Services = doc.Services
+		created.CapabilityInvocation = created.Authentication
+		doc = *created
+		vm, err := newSecretVerificationMethod(doc.VerificationMethod[0], privateKey, string(did.CapabilityInvocation))
+		if err != nil {
+			return nil, err
+		}
+		secret = &Secret{VerificationMethod: []SecretVerificationMethod{*vm}}
+	}
+	if doc.Context == nil {
+		doc.Context = did.KnownDIDContext
+	}
+
+	job := &webJob{operation: createOperation, did: id.String(), doc: &doc, secret: secret}
+	return r.complete(uuid.NewString(), job)
+}
+
+// Update applies the operations of the request to the DID's current document and publishes the result, once signed by
+// a capabilityInvocation key of the current document
+func (r *WebRegistrar) Update(ctx context.Context, request UpdateRequest) (*Response, error) {
+	if request.JobID != "" {
+		return r.continueJob(ctx, request.JobID, updateOperation, request.DID, request.Secret)
+	}
+	if len(request.DIDDocumentOperation) != len(request.DIDDocument) {
+		return nil, errors.New("each operation must have a DID Document")
+	}
+	id := web.DIDWeb(request.DID)
+	current, err := r.current(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	doc := *current
+	for i, operation := range request.DIDDocumentOperation {
+		updated, err := applyOperation(doc, operation, request.DIDDocument[i])
+		if err != nil {
+			return nil, errors.Wrapf(err, "applying %s", operation)
+		}
+		doc = *updated
+	}
+	job := &webJob{operation: updateOperation, did: id.String(), current: *current, doc: &doc}
+	return r.authorize(ctx, job, request.Secret)
+}
+
+// Deactivate removes the DID's document, after which the DID no longer resolves, once signed by a
+// capabilityInvocation key of the current document
+func (r *WebRegistrar) Deactivate(ctx context.Context, request DeactivateRequest) (*Response, error) {
+	if request.JobID != "" {
+		return r.continueJob(ctx, request.JobID, deactivateOperation, request.DID, request.Secret)
+	}
+	id := web.DIDWeb(request.DID)
+	if _, err := id.GetDocURL(); err != nil {
+		return nil, errors.Wrap(err, "invalid did:web DID")
+	}
+	current, err := r.current(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	job := &webJob{operation: deactivateOperation, did: id.String(), current: *current}
+	return r.authorize(ctx, job, request.Secret)
+}
+
+func (*WebRegistrar) Methods() []did.Method {
+	return []did.Method{did.WebMethod}
+}
+
+// authorize signs the operation of a job with the capabilityInvocation key of the secret and completes it, or stores
+// the job and asks the client to sign it
+func (r *WebRegistrar) authorize(ctx context.Context, job *webJob, secret *Secret) (*Response, error) {
+	secretVM := secret.Find(string(did.CapabilityInvocation))
+	var kid string
+	if secretVM != nil {
+		kid = secretVM.ID
+	}
+	vm, err := capabilityInvocationMethod(job.current, kid)
+	if err != nil {
+		return nil, err
+	}
+	key, err := did.GetKeyFromVerificationMethod(did.Document{ID: job.did, VerificationMethod: []did.VerificationMethod{*vm}}, vm.ID)
+	if err != nil {
+		return nil, errors.Wrapf(err, "getting key of %s", vm.ID)
+	}
+	job.verifier, err = jwx.NewJWXVerifier(job.did, vm.ID, key)
+	if err != nil {
+		return nil, errors.Wrapf(err, "creating verifier for %s", vm.ID)
+	}
+
+	jobID := uuid.NewString()
+	header, err := json.Marshal(map[string]string{"alg": job.verifier.ALG, "kid": vm.ID})
+	if err != nil {
+		return nil, errors.Wrap(err, "marshalling JWS header")
+	}
+	payload, err := json.Marshal(webSigningPayload{JobID: jobID, DID: job.did, Operation: job.operation, DIDDocument: job.doc})
+	if err != nil {
+		return nil, errors.Wrap(err, "marshalling signing payload")
+	}
+	job.signingInput = base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(payload)
+
+	if secretVM != nil && secretVM.PrivateKeyJWK != nil {
+		signer, err := jwx.NewJWXSignerFromJWK(job.did, *secretVM.PrivateKeyJWK)
+		if err != nil {
+			return nil, errors.Wrap(err, "creating signer")
+		}
+		token, err := signer.SignJWS(payload)
+		if err != nil {
+			return nil, errors.Wrap(err, "signing operation")
+		}
+		if err = job.verifier.VerifyJWS(string(token)); err != nil {
+			return nil, errors.Errorf("the capabilityInvocation key of the secret is not %s", vm.ID)
+		}
+		job.verifier = nil
+		return r.complete(jobID, job)
+	}
+
+	r.store(jobID, job)
+	return &Response{
+		JobID: jobID,
+		DIDState: DIDState{
+			State:  StateAction,
+			DID:    job.did,
+			Action: ActionSignPayload,
+			SigningRequest: map[string]SigningRequest{string(did.CapabilityInvocation): {
+				SerializedPayload: base64.RawURLEncoding.EncodeToString([]byte(job.signingInput)),
+				KID:               vm.ID,
+				Alg:               job.verifier.ALG,
+				Purpose:           string(did.CapabilityInvocation),
+			}},
+		},
+	}, nil
+}
+
+// job returns the job with the id, if there is one which has not expired
+func (r *WebRegistrar) job(jobID string) (*webJob, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	job, ok := r.jobs[jobID]
+	if !ok || time.Now().After(job.expires) {
+		return nil, false
+	}
+	return job, true
+}
+
+// store keeps a job until it is continued or expires, evicting the jobs which have expired
+func (r *WebRegistrar) store(jobID string, job *webJob) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	now := time.Now()
+	for id, stored := range r.jobs {
+		if now.After(stored.expires) {
+			delete(r.jobs, id)
+		}
+	}
+	job.expires = now.Add(jobExpiry)
+	r.jobs[jobID] = job
+}
+
+// continueJob completes the operation of a job with the client's signature of its signing input, as long as the
+// current document has not changed since the job started, or finishes a job once the client has published or removed
+// the document at its URL. The job must be for the operation, and for the DID when one is given.
+func (r *WebRegistrar) continueJob(ctx context.Context, jobID, operation, id string, secret *Secret) (*Response, error) {
+	job, ok := r.job(jobID)
+	if !ok {
+		return nil, errors.Errorf("unknown job: %s", jobID)
+	}
+	job.mu.Lock()
+	defer job.mu.Unlock()
+	if job.done {
+		return nil, errors.Errorf("unknown job: %s", jobID)
+	}
+	if job.operation != operation {
+		return nil, errors.Errorf("job %s is for the %s operation, not %s", jobID, job.operation, operation)
+	}
+	if id != "" && id != job.did {
+		return nil, errors.Errorf("job %s is for %s, not %s", jobID, job.did, id)
+	}
+
+	if job.verifier == nil {
+		done, err := r.clientDone(ctx, job)
+		if err != nil {
+			return nil, err
+		}
+		if !done {
+			return r.awaitClient(jobID, job)
+		}
+		return r.finish(jobID, job)
+	}
+
+	var signingResponse SigningResponse
+	if secret != nil {
+		signingResponse = secret.SigningResponse[string(did.CapabilityInvocation)]
+	}
+	if signingResponse.Signature == "" {
+		return nil, errors.Errorf("the secret must hold the signing response of %s", did.CapabilityInvocation)
+	}
+	if err := job.verifier.VerifyJWS(job.signingInput + "." + signingResponse.Signature); err != nil {
+		return nil, errors.Errorf("the operation is not signed by %s", job.verifier.KID)
+	}
+
+	current, err := r.current(ctx, web.DIDWeb(job.did))
+	if err != nil {
+		return nil, err
+	}
+	if !sameDocument(*current, job.current) {
+		return nil, errors.Errorf("the DID Document of %s has changed since job %s started", job.did, jobID)
+	}
+	job.verifier = nil
+	return r.complete(jobID, job)
+}
+
+// complete publishes the document of a job to the host, or removes it for a deactivation, and finishes the job.
+// Without a host, the client is asked to publish or remove the document at its URL.
+func (r *WebRegistrar) complete(jobID string, job *webJob) (*Response, error) {
+	if r.host == nil {
+		return r.awaitClient(jobID, job)
+	}
+	if job.doc != nil {
+		if err := r.host.Put(*job.doc); err != nil {
+			return nil, errors.Wrap(err, "publishing document")
+		}
+	} else if err := r.host.Remove(web.DIDWeb(job.did)); err != nil {
+		return nil, errors.Wrap(err, "removing document")
+	}
+	return r.finish(jobID, job)
+}
+
+// awaitClient stores a job and asks the client to publish its document at its URL, or to remove the document for a
+// deactivation
+func (r *WebRegistrar) awaitClient(jobID string, job *webJob) (*Response, error) {
+	docURL, err := web.DIDWeb(job.did).GetDocURL()
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid did:web DID")
+	}
+	action := ActionPublishDocument
+	if job.doc == nil {
+		action = ActionRemoveDocument
+	}
+	r.store(jobID, job)
+	return &Response{
+		JobID:                jobID,
+		DIDState:             DIDState{State: StateAction, DID: job.did, DIDDocument: job.doc, Action: action},
+		RegistrationMetadata: map[string]any{documentURLMetadata: docURL},
+	}, nil
+}
+
+// clientDone reports whether the document of a job has been published at its URL, or removed for a deactivation
+func (r *WebRegistrar) clientDone(ctx context.Context, job *webJob) (bool, error) {
+	id := web.DIDWeb(job.did)
+	published, err := id.Resolve(ctx)
+	if err != nil {
+		if resolution.IsNotFound(err) {
+			return job.doc == nil, nil
+		}
+		return false, errors.Wrapf(err, "resolving %s", id)
+	}
+	return job.doc != nil && sameDocument(*published, *job.doc), nil
+}
+
+// finish returns the finished state of a job, with the job's secret, and drops the job along with its secret
+func (r *WebRegistrar) finish(jobID string, job *webJob) (*Response, error) {
+	docURL, err := web.DIDWeb(job.did).GetDocURL()
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid did:web DID")
+	}
+	job.done = true
+	r.mu.Lock()
+	delete(r.jobs, jobID)
+	r.mu.Unlock()
+	response := finished(jobID, job.did, job.doc, job.secret)
+	job.secret = nil
+	response.RegistrationMetadata = map[string]any{documentURLMetadata: docURL}
+	return response, nil
+}
+
+// sameDocument returns true if the documents have the same JSON representation
+func sameDocument(a, b did.Document) bool {
+	aBytes, err := json.Marshal(a)
+	if err != nil {
+		return false
+	}
+	bBytes, err := json.Marshal(b)
+	return err == nil && bytes.Equal(aBytes, bBytes)
+}
+
+// exists reports whether the DID has a document on the host, or at its URL without one. Failing to fetch the
+// document for any reason but it not being found is an error, as it may exist.
+func (r *WebRegistrar) exists(ctx context.Context, id web.DIDWeb) (bool, error) {
+	if r.host != nil {
+		_, err := r.host.Get(id)
+		return err == nil, nil
+	}
+	if _, err := id.Resolve(ctx); err != nil {
+		if resolution.IsNotFound(err) {
+			return false, nil
+		}
+		return false, errors.Wrapf(err, "checking whether the DID Document of %s exists", id)
+	}
+	return true, nil
+}
+
+// current returns the document of the DID from the host, or resolved from its URL without one
+func (r *WebRegistrar) current(ctx context.Context, id web.DIDWeb) (*did.Document, error) {
+	if r.host != nil {
+		return r.host.Get(id)
+	}
+	doc, err := id.Resolve(ctx)
+	if err != nil {
+		return nil, errors.Wrapf(err, "resolving %s", id)
+	}
+	return doc, nil
+}
+
+// capabilityInvocationMethod returns the capabilityInvocation verification method of a document with the id, or its
+// first one without an id
+func capabilityInvocationMethod(doc did.Document, kid string) (*did.VerificationMethod, error) {
+	vms, err := doc.VerificationMethods(did.CapabilityInvocation)
+	if err != nil {
+		return nil, errors.Wrap(err, "getting capabilityInvocation verification methods")
+	}
+	if len(vms) == 0 {
+		return nil, errors.Errorf("%s has no capabilityInvocation verification method to sign the operation with", doc.ID)
+	}
+	if kid == "" {
+		vm := vms[0]
+		vm.ID = did.FullyQualifiedVerificationMethodID(doc.ID, vm.ID)
+		return &vm, nil
+	}
+	for _, vm := range vms {
+		if did.FullyQualifiedVerificationMethodID(doc.ID, vm.ID) == did.FullyQualifiedVerificationMethodID(doc.ID, kid) {
+			vm.ID = did.FullyQualifiedVerificationMethodID(doc.ID, vm.ID)
+			return &vm, nil
+		}
+	}
+	return nil, errors.Errorf("%s is not a capabilityInvocation verification method of %s", kid, doc.ID)
+}
+
+// applyOperation applies an update operation with its DID Document to a document
+func applyOperation(doc did.Document, operation DocumentOperation, operand did.Document) (*did.Document, error) {
+	switch operation {
+	case SetDocument:
+		if operand.ID != doc.ID {
+			return nil, errors.Errorf("document id %s does not match %s", operand.ID, doc.ID)
+		}
+		return &operand, nil
+	case AddToDocument:
+		return addToDocument(doc, operand)
+	case RemoveFromDocument:
+		manager, err := web.NewDocumentManagerFromDocument(doc)
+		if err != nil {
+			return nil, err
+		}
+		for _, vm := range operand.VerificationMethod {
+			if err = manager.RemoveVerificationMethod(vm.ID); err != nil {
+				return nil, err
+			}
+		}
+		for _, service := range operand.Services {
+			if err = manager.RemoveService(service.ID); err != nil {
+				return nil, err
+			}
+		}
+		removed := manager.Document()
+		return &removed, nil
+	default:
+		return nil, errors.Errorf("unsupported operation: %s", operation)
+	}
+}
+
+// addToDocument adds the verification methods, verification relationships and services of the operand to a document
+func addToDocument(doc did.Document, operand did.Document) (*did.Document, error) {
+	manager, err := web.NewDocumentManagerFromDocument(doc)
+	if err != nil {
+		return nil, err
+	}
+	added := manager.Document()
+	for _, vm := range operand.VerificationMethod {
+		id := did.FullyQualifiedVerificationMethodID(doc.ID, vm.ID)
+		if _, err = did.GetVerificationMethodForKID(added, id); err == nil {
+			return nil, errors.Errorf("verification method already exists: %s", id)
+		}
+		vm.ID = id
+		if vm.Controller == "" {
+			vm.Controller = doc.ID
+		}
+		added.VerificationMethod = append(added.VerificationMethod, vm)
+	}
+	added.Authentication = append(added.Authentication, operand.Authentication...)
+	added.AssertionMethod = append(added.AssertionMethod, operand.AssertionMethod...)
+	added.KeyAgreement = append(added.KeyAgreement, operand.KeyAgreement...)
+	added.CapabilityInvocation = append(added.CapabilityInvocation, operand.CapabilityInvocation...)
+	added.CapabilityDelegation = append(added.CapabilityDelegation, operand.CapabilityDelegation...)
+	for _, service := range operand.Services {
+		for _, existing := range added.Services {
+			if did.FullyQualifiedVerificationMethodID(doc.ID, existing.ID) == did.FullyQualifiedVerificationMethodID(doc.ID, service.ID) {
+				return nil, errors.Errorf("service already exists: %s", service.ID)
+			}
+		}
+		added.Services = append(added.Services, service)
+	}
+	return &added, nil
+}
@@ -0,0 +1,219 @@
+package registrar
+
+import (
+	"context"
+	"crypto/ed25519"
+	"encoding/base64"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/h2non/gock.v1"
+
+	"github.com/extrimian/ssi-sdk/crypto"
+	"github.com/extrimian/ssi-sdk/did"
+	"github.com/extrimian/ssi-sdk/did/web"
+)
+
+func TestWebRegistrar(t *testing.T) {
+	ctx := context.Background()
+	id := "did:web:example.com"
+	service := did.Service{ID: "#linked", Type: "LinkedDomains", ServiceEndpoint: "https://example.com"}
+
+	t.Run("create, update and deactivate with a host", func(tt *testing.T) {
+		host, err := web.NewHandler()
+		require.NoError(tt, err)
+		r := NewWebRegistrar(host)
+
+		created, err := r.Create(ctx, CreateRequest{DIDDocument: &did.Document{ID: id}})
+		require.NoError(tt, err)
+		assert.Equal(tt, StateFinished, created.DIDState.State)
+		assert.Equal(tt, id, created.DIDState.DID)
+		assert.Equal(tt, "https://example.com/.well-known/did.json", created.RegistrationMetadata[documentURLMetadata])
+		require.NotNil(tt, created.DIDState.Secret)
+		assertPrivateKeyOf(tt, created.DIDState.DIDDocument.VerificationMethod[0], created.DIDState.Secret.VerificationMethod[0])
+		assert.True(tt, created.DIDState.DIDDocument.HasRelationship(created.DIDState.DIDDocument.VerificationMethod[0].ID, did.CapabilityInvocation))
+
+		hosted, err := host.Get(web.DIDWeb(id))
+		require.NoError(tt, err)
+		assert.Equal(tt, created.DIDState.DIDDocument.VerificationMethod, hosted.VerificationMethod)
+
+		_, err = r.Create(ctx, CreateRequest{DIDDocument: &did.Document{ID: id}})
+		assert.ErrorContains(tt, err, "the DID Document of did:web:example.com already exists")
+
+		// the registrar signs with the capabilityInvocation key of the secret
+		secret := created.DIDState.Secret
+		updated, err := r.Update(ctx, UpdateRequest{
+			DID:                  id,
+			Secret:               secret,
+			DIDDocumentOperation: []DocumentOperation{AddToDocument},
+			DIDDocument:          []did.Document{{Services: []did.Service{service}}},
+		})
+		require.NoError(tt, err)
+		assert.Equal(tt, StateFinished, updated.DIDState.State)
+		hosted, err = host.Get(web.DIDWeb(id))
+		require.NoError(tt, err)
+		require.Len(tt, hosted.Services, 1)
+
+		_, err = r.Update(ctx, UpdateRequest{
+			DID:                  id,
+			Secret:               secret,
+			DIDDocumentOperation: []DocumentOperation{AddToDocument},
+			DIDDocument:          []did.Document{{Services: []did.Service{service}}},
+		})
+		assert.ErrorContains(tt, err, "service already exists: #linked")
+
+		// without the private key, the client is asked to sign the update
+		action, err := r.Update(ctx, UpdateRequest{
+			DID:                  id,
+			DIDDocumentOperation: []DocumentOperation{RemoveFromDocument},
+			DIDDocument:          []did.Document{{Services: []did.Service{service}}},
+		})
+		require.NoError(tt, err)
+		assert.Equal(tt, StateAction, action.DIDState.State)
+		assert.Equal(tt, ActionSignPayload, action.DIDState.Action)
+		signingRequest, ok := action.DIDState.SigningRequest[string(did.CapabilityInvocation)]
+		require.True(tt, ok)
+		assert.Equal(tt, created.DIDState.DIDDocument.VerificationMethod[0].ID, signingRequest.KID)
+		assert.Equal(tt, "EdDSA", signingRequest.Alg)
+
+		_, otherKey, err := crypto.GenerateEd25519Key()
+		require.NoError(tt, err)
+		_, err = r.Update(ctx, UpdateRequest{JobID: action.JobID, Secret: signEd25519(tt, otherKey, signingRequest)})
+		assert.ErrorContains(tt, err, "the operation is not signed by")
+		_, err = r.Deactivate(ctx, DeactivateRequest{JobID: action.JobID})
+		assert.ErrorContains(tt, err, "is for the update operation, not deactivate")
+
+		privateKey, err := secret.VerificationMethod[0].PrivateKeyJWK.ToPrivateKey()
+		require.NoError(tt, err)
+		updated, err = r.Update(ctx, UpdateRequest{JobID: action.JobID, Secret: signEd25519(tt, privateKey, signingRequest)})
+		require.NoError(tt, err)
+		assert.Equal(tt, StateFinished, updated.DIDState.State)
+		assert.Equal(tt, action.JobID, updated.JobID)
+		assert.Empty(tt, updated.DIDState.DIDDocument.Services)
+		_, err = r.Update(ctx, UpdateRequest{JobID: action.JobID, Secret: signEd25519(tt, privateKey, signingRequest)})
+		assert.ErrorContains(tt, err, "unknown job")
+
+		// a job is signed for the current document, and cannot be continued once it has changed
+		action, err = r.Deactivate(ctx, DeactivateRequest{DID: id})
+		require.NoError(tt, err)
+		assert.Equal(tt, ActionSignPayload, action.DIDState.Action)
+		_, err = r.Update(ctx, UpdateRequest{
+			DID:                  id,
+			Secret:               secret,
+			DIDDocumentOperation: []DocumentOperation{AddToDocument},
+			DIDDocument:          []did.Document{{Services: []did.Service{service}}},
+		})
+		require.NoError(tt, err)
+		signingRequest = action.DIDState.SigningRequest[string(did.CapabilityInvocation)]
+		_, err = r.Deactivate(ctx, DeactivateRequest{JobID: action.JobID, Secret: signEd25519(tt, privateKey, signingRequest)})
+		assert.ErrorContains(tt, err, "has changed since job")
+
+		// a key which is not a capabilityInvocation key of the document cannot sign
+		otherSecret := Secret{VerificationMethod: []SecretVerificationMethod{{ID: "#other", Purpose: []string{string(did.CapabilityInvocation)}}}}
+		_, err = r.Deactivate(ctx, DeactivateRequest{DID: id, Secret: &otherSecret})
+		assert.ErrorContains(tt, err, "#other is not a capabilityInvocation verification method")
+
+		deactivated, err := r.Deactivate(ctx, DeactivateRequest{DID: id, Secret: secret})
+		require.NoError(tt, err)
+		assert.Equal(tt, StateFinished, deactivated.DIDState.State)
+		_, err = host.Get(web.DIDWeb(id))
+		assert.ErrorContains(tt, err, "no document hosted")
+	})
+
+	t.Run("create and deactivate without a host", func(tt *testing.T) {
+		defer gock.Off()
+		r := NewWebRegistrar(nil)
+		docURL := "https://example.com/.well-known/did.json"
+
+		// the client is asked to publish the document, and the generated keys are returned once it has
+		gock.New("https://example.com").Get("/.well-known/did.json").Reply(404)
+		action, err := r.Create(ctx, CreateRequest{DIDDocument: &did.Document{ID: id}})
+		require.NoError(tt, err)
+		assert.Equal(tt, StateAction, action.DIDState.State)
+		assert.Equal(tt, ActionPublishDocument, action.DIDState.Action)
+		assert.Equal(tt, docURL, action.RegistrationMetadata[documentURLMetadata])
+		require.NotNil(tt, action.DIDState.DIDDocument)
+		assert.Nil(tt, action.DIDState.Secret)
+		require.NotEmpty(tt, action.JobID)
+
+		gock.New("https://example.com").Get("/.well-known/did.json").Reply(404)
+		waiting, err := r.Create(ctx, CreateRequest{JobID: action.JobID})
+		require.NoError(tt, err)
+		assert.Equal(tt, action.JobID, waiting.JobID)
+		assert.Equal(tt, ActionPublishDocument, waiting.DIDState.Action)
+		assert.Nil(tt, waiting.DIDState.Secret)
+
+		gock.New("https://example.com").Get("/.well-known/did.json").Reply(200).JSON(action.DIDState.DIDDocument)
+		created, err := r.Create(ctx, CreateRequest{JobID: action.JobID})
+		require.NoError(tt, err)
+		assert.Equal(tt, action.JobID, created.JobID)
+		assert.Equal(tt, StateFinished, created.DIDState.State)
+		require.NotNil(tt, created.DIDState.Secret)
+		assertPrivateKeyOf(tt, action.DIDState.DIDDocument.VerificationMethod[0], created.DIDState.Secret.VerificationMethod[0])
+
+		_, err = r.Create(ctx, CreateRequest{JobID: action.JobID})
+		assert.ErrorContains(tt, err, "unknown job")
+
+		// a document which cannot be fetched may exist, so it is not created
+		gock.New("https://example.com").Get("/.well-known/did.json").Reply(503)
+		_, err = r.Create(ctx, CreateRequest{DIDDocument: &did.Document{ID: id}})
+		assert.ErrorContains(tt, err, "checking whether the DID Document of did:web:example.com exists")
+
+		// once the client has published the document, it is not created again
+		gock.New("https://example.com").Get("/.well-known/did.json").Reply(200).JSON(action.DIDState.DIDDocument)
+		_, err = r.Create(ctx, CreateRequest{DIDDocument: &did.Document{ID: id}})
+		assert.ErrorContains(tt, err, "already exists")
+
+		// the client is asked to remove the document, and the job finishes once it has
+		gock.New("https://example.com").Get("/.well-known/did.json").Reply(200).JSON(action.DIDState.DIDDocument)
+		action, err = r.Deactivate(ctx, DeactivateRequest{DID: id, Secret: created.DIDState.Secret})
+		require.NoError(tt, err)
+		assert.Equal(tt, StateAction, action.DIDState.State)
+		assert.Equal(tt, ActionRemoveDocument, action.DIDState.Action)
+		assert.Equal(tt, docURL, action.RegistrationMetadata[documentURLMetadata])
+
+		gock.New("https://example.com").Get("/.well-known/did.json").Reply(200).JSON(created.DIDState.DIDDocument)
+		waiting, err = r.Deactivate(ctx, DeactivateRequest{JobID: action.JobID})
+		require.NoError(tt, err)
+		assert.Equal(tt, ActionRemoveDocument, waiting.DIDState.Action)
+
+		gock.New("https://example.com").Get("/.well-known/did.json").Reply(404)
+		deactivated, err := r.Deactivate(ctx, DeactivateRequest{JobID: action.JobID})
+		require.NoError(tt, err)
+		assert.Equal(tt, action.JobID, deactivated.JobID)
+		assert.Equal(tt, StateFinished, deactivated.DIDState.State)
+		_, err = r.Deactivate(ctx, DeactivateRequest{JobID: action.JobID})
+		assert.ErrorContains(tt, err, "unknown job")
+		assert.True(tt, gock.IsDone())
+	})
+
+	t.Run("invalid requests", func(tt *testing.T) {
+		r := NewWebRegistrar(nil)
+		_, err := r.Create(ctx, CreateRequest{})
+		assert.ErrorContains(tt, err, "must have an id")
+		_, err = r.Create(ctx, CreateRequest{DIDDocument: &did.Document{ID: "did:key:abc"}})
+		assert.ErrorContains(tt, err, "invalid did:web DID")
+		_, err = r.Update(ctx, UpdateRequest{DID: id, DIDDocumentOperation: []DocumentOperation{SetDocument}})
+		assert.ErrorContains(tt, err, "each operation must have a DID Document")
+		_, err = r.Update(ctx, UpdateRequest{JobID: "client-job"})
+		assert.ErrorContains(tt, err, "unknown job: client-job")
+
+		host, err := web.NewHandler()
+		require.NoError(tt, err)
+		require.NoError(tt, host.Put(did.Document{ID: id}))
+		_, err = NewWebRegistrar(host).Deactivate(ctx, DeactivateRequest{DID: id})
+		assert.ErrorContains(tt, err, "has no capabilityInvocation verification method")
+	})
+}
+
+// signEd25519 signs the payload of a signing request with an Ed25519 key, returning the secret of the request
+// continuing the job
+func signEd25519(t *testing.T, privateKey any, request SigningRequest) *Secret {
+	key, ok := privateKey.(ed25519.PrivateKey)
+	require.True(t, ok)
+	payload, err := base64.RawURLEncoding.DecodeString(request.SerializedPayload)
+	require.NoError(t, err)
+	signature := base64.RawURLEncoding.EncodeToString(ed25519.Sign(key, payload))
+	return &Secret{SigningResponse: map[string]SigningResponse{request.Purpose: {Signature: signature}}}
+}
@@ -0,0 +1,330 @@
+package did
+
+import (
+	gocrypto "crypto"
+	"fmt"
+
+	"github.com/goccy/go-json"
+	bbsg2 "github.com/hyperledger/aries-framework-go/pkg/crypto/primitive/bbs12381g2pub"
+	"github.com/pkg/errors"
+
+	"github.com/extrimian/ssi-sdk/crypto"
+	"github.com/extrimian/ssi-sdk/crypto/jwx"
+	"github.com/extrimian/ssi-sdk/cryptosuite"
+	"github.com/extrimian/ssi-sdk/cryptosuite/bbs"
+	"github.com/extrimian/ssi-sdk/cryptosuite/jws2020"
+)
+
+// Relationship is a verification relationship https://www.w3.org/TR/did-core/#verification-relationships
+type Relationship string
+
+const (
+	Authentication       Relationship = "authentication"
+	AssertionMethod      Relationship = "assertionMethod"
+	KeyAgreement         Relationship = "keyAgreement"
+	CapabilityInvocation Relationship = "capabilityInvocation"
+	CapabilityDelegation Relationship = "capabilityDelegation"
+)
+
+// Relationships returns all verification relationships, in the order they appear in a DID Document
+func Relationships() []Relationship {
+	return []Relationship{Authentication, AssertionMethod, KeyAgreement, CapabilityInvocation, CapabilityDelegation}
+}
+
+// VerificationRelationship is the set of verification methods of a relationship, such as `authentication`. Each
+// entry is either a reference to a verification method as a string, or an embedded VerificationMethod. Sets of
+// references as a []string are accepted as entries too, as made by did:key and did:peer expansions.
+// When unmarshalled, embedded verification methods are VerificationMethod values rather than maps, so that entries
+// are always one of the types above.
+type VerificationRelationship []VerificationMethodSet
+
+// UnmarshalJSON accepts a set of references and embedded verification methods, or a single one of them
+func (r *VerificationRelationship) UnmarshalJSON(data []byte) error {
+	var entries []json.RawMessage
+	if err := json.Unmarshal(data, &entries); err != nil {
+		entries = []json.RawMessage{data}
+	}
+	relationship := make(VerificationRelationship, 0, len(entries))
+	for i, entry := range entries {
+		set, err := unmarshalVerificationMethodSet(entry)
+		if err != nil {
+			return errors.Wrapf(err, "verification relationship entry %d", i)
+		}
+		relationship = append(relationship, set)
+	}
+	*r = relationship
+	return nil
+}
+
+// MarshalJSON marshals references as strings and embedded verification methods as objects
+func (r VerificationRelationship) MarshalJSON() ([]byte, error) {
+	entries := make([]any, 0, len(r))
+	for i, set := range r {
+		entry, err := marshalableVerificationMethodSet(set)
+		if err != nil {
+			return nil, errors.Wrapf(err, "verification relationship entry %d", i)
+		}
+		entries = append(entries, entry)
+	}
+	return json.Marshal(entries)
+}
+
+func unmarshalVerificationMethodSet(data []byte) (VerificationMethodSet, error) {
+	var reference string
+	if err := json.Unmarshal(data, &reference); err == nil {
+		return reference, nil
+	}
+	var references []string
+	if err := json.Unmarshal(data, &references); err == nil {
+		return references, nil
+	}
+	var vm VerificationMethod
+	if err := json.Unmarshal(data, &vm); err != nil {
+		return nil, errors.New("must be a reference or an embedded verification method")
+	}
+	return vm, nil
+}
+
+// marshalableVerificationMethodSet checks an entry is a reference, a set of references or an embedded verification
+// method, dereferencing pointers to embedded verification methods
+func marshalableVerificationMethodSet(set VerificationMethodSet) (any, error) {
+	switch v := set.(type) {
+	case string, []string, VerificationMethod, map[string]any:
+		return v, nil
+	case *VerificationMethod:
+		if v == nil {
+			return nil, errors.New("embedded verification method cannot be nil")
+		}
+		return *v, nil
+	case []any:
+		for _, reference := range v {
+			if _, ok := reference.(string); !ok {
+				return nil, fmt.Errorf("sets of references must only hold strings, not %T", reference)
+			}
+		}
+		return v, nil
+	default:
+		return nil, fmt.Errorf("unsupported verification method set: %T", set)
+	}
+}
+
+// References returns the references of the relationship as they appear in it, including the ones in sets of
+// references. Embedded verification methods are not included.
+func (r VerificationRelationship) References() []string {
+	var references []string
+	for _, set := range r {
+		switch v := set.(type) {
+		case string:
+			references = append(references, v)
+		case []string:
+			references = append(references, v...)
+		case []any:
+			for _, reference := range v {
+				if s, ok := reference.(string); ok {
+					references = append(references, s)
+				}
+			}
+		}
+	}
+	return references
+}
+
+// Embedded returns the verification methods embedded in the relationship
+func (r VerificationRelationship) Embedded() []VerificationMethod {
+	var embedded []VerificationMethod
+	for _, set := range r {
+		if vm, err := embeddedVerificationMethod(set); err == nil {
+			embedded = append(embedded, *vm)
+		}
+	}
+	return embedded
+}
+
+// Relationship returns the verification methods of a relationship of the document
+func (d *Document) Relationship(relationship Relationship) VerificationRelationship {
+	switch relationship {
+	case Authentication:
+		return d.Authentication
+	case AssertionMethod:
+		return d.AssertionMethod
+	case KeyAgreement:
+		return d.KeyAgreement
+	case CapabilityInvocation:
+		return d.CapabilityInvocation
+	case CapabilityDelegation:
+		return d.CapabilityDelegation
+	default:
+		return nil
+	}
+}
+
+// VerificationMethodIDs returns the fully qualified ids of the verification methods of a relationship, both
+// referenced and embedded, in the order they appear in it
+func (d *Document) VerificationMethodIDs(relationship Relationship) []string {
+	var ids []string
+	for _, set := range d.Relationship(relationship) {
+		if vm, err := embeddedVerificationMethod(set); err == nil {
+			ids = append(ids, FullyQualifiedVerificationMethodID(d.ID, vm.ID))
+			continue
+		}
+		for _, reference := range (VerificationRelationship{set}).References() {
+			ids = append(ids, FullyQualifiedVerificationMethodID(d.ID, reference))
+		}
+	}
+	return ids
+}
+
+// HasRelationship returns true if the verification method with the id, which may be relative, is referenced or
+// embedded by the relationship
+func (d *Document) HasRelationship(id string, relationship Relationship) bool {
+	id = FullyQualifiedVerificationMethodID(d.ID, id)
+	for _, vmID := range d.VerificationMethodIDs(relationship) {
+		if vmID == id {
+			return true
+		}
+	}
+	return false
+}
+
+// VerificationMethods returns the verification methods of a relationship, in the order they appear in it. Referenced
+// verification methods are resolved to the document's verification methods, and references to verification methods
+// that are not in the document, such as ones of another DID, are an error.
+func (d *Document) VerificationMethods(relationship Relationship) ([]VerificationMethod, error) {
+	var vms []VerificationMethod
+	for _, set := range d.Relationship(relationship) {
+		if vm, err := embeddedVerificationMethod(set); err == nil {
+			vms = append(vms, *vm)
+			continue
+		}
+		references := VerificationRelationship{set}.References()
+		if len(references) == 0 {
+			return nil, fmt.Errorf("unsupported %s verification method set: %T", relationship, set)
+		}
+		for _, reference := range references {
+			vm, err := d.verificationMethod(reference)
+			if err != nil {
+				return nil, errors.Wrapf(err, "resolving %s reference", relationship)
+			}
+			vms = append(vms, *vm)
+		}
+	}
+	return vms, nil
+}
+
+// verificationMethod finds the verification method with the id in the document, including embedded ones
+func (d *Document) verificationMethod(id string) (*VerificationMethod, error) {
+	id = FullyQualifiedVerificationMethodID(d.ID, id)
+	for _, vm := range d.VerificationMethod {
+		if FullyQualifiedVerificationMethodID(d.ID, vm.ID) == id {
+			return &vm, nil
+		}
+	}
+	for _, relationship := range Relationships() {
+		for _, vm := range d.Relationship(relationship).Embedded() {
+			if FullyQualifiedVerificationMethodID(d.ID, vm.ID) == id {
+				return &vm, nil
+			}
+		}
+	}
+	return nil, fmt.Errorf("no verification method found with id: %s", id)
+}
+
+// SelectVerificationMethod returns the first verification method of a relationship whose key is used with the JSON
+// Web Algorithm, e.g. EdDSA or ES256K. Any verification method of the relationship is selected when alg is empty.
+func (d *Document) SelectVerificationMethod(relationship Relationship, alg string) (*VerificationMethod, error) {
+	vms, err := d.VerificationMethods(relationship)
+	if err != nil {
+		return nil, err
+	}
+	for _, vm := range vms {
+		if alg == "" {
+			return &vm, nil
+		}
+		if vmAlg, err := vm.Algorithm(); err == nil && vmAlg == alg {
+			return &vm, nil
+		}
+	}
+	if alg == "" {
+		return nil, fmt.Errorf("did<%s> has no %s verification methods", d.ID, relationship)
+	}
+	return nil, fmt.Errorf("did<%s> has no %s verification methods for %s", d.ID, relationship, alg)
+}
+
+// PublicKey returns the public key of the verification method
+func (vm VerificationMethod) PublicKey() (gocrypto.PublicKey, error) {
+	if vm.PublicKeyMultibase != "" {
+		// multicodec prefixed keys carry their key type, which the verification method type may not, e.g. Multikey
+		if keyBytes, _, kt, err := DecodeMultibaseEncodedKey(vm.PublicKeyMultibase); err == nil {
+			return crypto.BytesToPubKey(keyBytes, kt, crypto.ECDSAUnmarshalCompressed)
+		}
+	}
+	return extractKeyFromVerificationMethod(vm)
+}
+
+// JWK returns the public key of the verification method as a JWK, converting keys in other representations. The
+// kid of converted keys is the id of the verification method.
+func (vm VerificationMethod) JWK() (*jwx.PublicKeyJWK, error) {
+	if vm.PublicKeyJWK != nil {
+		publicKeyJWK := *vm.PublicKeyJWK
+		return &publicKeyJWK, nil
+	}
+	publicKey, err := vm.PublicKey()
+	if err != nil {
+		return nil, errors.Wrapf(err, "getting public key of %s", vm.ID)
+	}
+	publicKeyJWK, err := jwx.PublicKeyToPublicKeyJWK(vm.ID, publicKey)
+	if err != nil {
+		return nil, errors.Wrapf(err, "converting public key of %s to JWK", vm.ID)
+	}
+	return publicKeyJWK, nil
+}
+
+// Algorithm returns the JSON Web Algorithm the key of the verification method is used with, which is the JWK's alg
+// when it has one
+func (vm VerificationMethod) Algorithm() (string, error) {
+	publicKeyJWK, err := vm.JWK()
+	if err != nil {
+		return "", err
+	}
+	if publicKeyJWK.ALG != "" {
+		return publicKeyJWK.ALG, nil
+	}
+	return jwx.AlgFromKeyAndCurve(publicKeyJWK.KTY, publicKeyJWK.CRV)
+}
+
+// JWXVerifier returns a verifier of JWTs and JWSs signed by the key of the verification method, identified by the
+// verification method's id
+func (vm VerificationMethod) JWXVerifier() (*jwx.Verifier, error) {
+	publicKeyJWK, err := vm.JWK()
+	if err != nil {
+		return nil, err
+	}
+	if publicKeyJWK.KID == "" {
+		publicKeyJWK.KID = vm.ID
+	}
+	return jwx.NewJWXVerifierFromJWK(vm.ID, *publicKeyJWK)
+}
+
+// Verifier returns a verifier of Data Integrity proofs made with the key of the verification method. BLS12-381 G2
+// keys verify BBS+ signatures, and all other keys JSON Web Signature 2020 signatures. Verifiers are identified by the
+// verification method's id.
+func (vm VerificationMethod) Verifier() (cryptosuite.Verifier, error) {
+	if vm.Type == cryptosuite.BLS12381G2Key2020 {
+		publicKey, err := vm.PublicKey()
+		if err != nil {
+			return nil, errors.Wrapf(err, "getting public key of %s", vm.ID)
+		}
+		bbsKey, ok := publicKey.(*bbsg2.PublicKey)
+		if !ok {
+			return nil, fmt.Errorf("%s is not a BLS12-381 G2 key", vm.ID)
+		}
+		return bbs.NewBBSPlusVerifier(vm.ID, bbsKey), nil
+	}
+	publicKeyJWK, err := vm.JWK()
+	if err != nil {
+		return nil, err
+	}
+	// proofs reference the verification method, not the kid of its key
+	publicKeyJWK.KID = vm.ID
+	return jws2020.NewJSONWebKeyVerifier(vm.ID, *publicKeyJWK)
+}
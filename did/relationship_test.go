@@ -0,0 +1,161 @@
+package did
+
+import (
+	"testing"
+
+	"github.com/goccy/go-json"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/extrimian/ssi-sdk/crypto"
+	"github.com/extrimian/ssi-sdk/crypto/jwx"
+	"github.com/extrimian/ssi-sdk/cryptosuite"
+	"github.com/extrimian/ssi-sdk/cryptosuite/jws2020"
+)
+
+func TestVerificationRelationshipJSON(t *testing.T) {
+	t.Run("round trips references and embedded verification methods", func(tt *testing.T) {
+		doc := validDocument()
+		docBytes, err := json.Marshal(doc)
+		require.NoError(tt, err)
+
+		var unmarshalled Document
+		require.NoError(tt, json.Unmarshal(docBytes, &unmarshalled))
+		assert.Equal(tt, doc.Authentication, unmarshalled.Authentication)
+		assert.Equal(tt, doc.AssertionMethod, unmarshalled.AssertionMethod)
+		assert.Equal(tt, doc.KeyAgreement, unmarshalled.KeyAgreement)
+		assert.Empty(tt, unmarshalled.CapabilityInvocation)
+	})
+
+	t.Run("embedded verification methods unmarshal to verification methods", func(tt *testing.T) {
+		var relationship VerificationRelationship
+		require.NoError(tt, json.Unmarshal([]byte(`["#key-1",{"id":"#key-2","type":"Multikey","controller":"did:example:123","publicKeyMultibase":"z6MkmM42vxfqZQsv4ehtTjFFxQ4sQKS2w6WR7emozFAn5cxu"}]`), &relationship))
+		require.Len(tt, relationship, 2)
+		assert.Equal(tt, "#key-1", relationship[0])
+		embedded, ok := relationship[1].(VerificationMethod)
+		require.True(tt, ok)
+		assert.Equal(tt, "#key-2", embedded.ID)
+		assert.Equal(tt, []string{"#key-1"}, relationship.References())
+		assert.Equal(tt, []VerificationMethod{embedded}, relationship.Embedded())
+	})
+
+	t.Run("single values", func(tt *testing.T) {
+		var relationship VerificationRelationship
+		require.NoError(tt, json.Unmarshal([]byte(`"#key-1"`), &relationship))
+		assert.Equal(tt, VerificationRelationship{"#key-1"}, relationship)
+	})
+
+	t.Run("invalid entries", func(tt *testing.T) {
+		var relationship VerificationRelationship
+		assert.ErrorContains(tt, json.Unmarshal([]byte(`["#key-1",42]`), &relationship), "verification relationship entry 1")
+
+		_, err := json.Marshal(VerificationRelationship{42})
+		assert.ErrorContains(tt, err, "unsupported verification method set: int")
+	})
+
+	t.Run("pointers to embedded verification methods marshal as objects", func(tt *testing.T) {
+		vm := validDocument().VerificationMethod[0]
+		relationshipBytes, err := json.Marshal(VerificationRelationship{&vm})
+		require.NoError(tt, err)
+
+		var relationship VerificationRelationship
+		require.NoError(tt, json.Unmarshal(relationshipBytes, &relationship))
+		assert.Equal(tt, VerificationRelationship{vm}, relationship)
+	})
+}
+
+func TestDocumentRelationships(t *testing.T) {
+	t.Run("verification method ids", func(tt *testing.T) {
+		doc := validDocument()
+		assert.Equal(tt, []string{"did:example:123#key-1", "did:example:123#key-2", "did:example:other#key-1"},
+			doc.VerificationMethodIDs(Authentication))
+		assert.Equal(tt, []string{"did:example:123#key-1", "did:example:123#key-3"}, doc.VerificationMethodIDs(AssertionMethod))
+		assert.Empty(tt, doc.VerificationMethodIDs(CapabilityDelegation))
+
+		assert.True(tt, doc.HasRelationship("#key-3", KeyAgreement))
+		assert.True(tt, doc.HasRelationship("did:example:123#key-1", AssertionMethod))
+		assert.False(tt, doc.HasRelationship("#key-1", KeyAgreement))
+	})
+
+	t.Run("verification methods", func(tt *testing.T) {
+		doc := validDocument()
+		vms, err := doc.VerificationMethods(AssertionMethod)
+		require.NoError(tt, err)
+		require.Len(tt, vms, 2)
+		assert.Equal(tt, doc.VerificationMethod[0], vms[0])
+		assert.Equal(tt, doc.KeyAgreement[0], vms[1])
+
+		// references to other DIDs cannot be resolved from the document
+		_, err = doc.VerificationMethods(Authentication)
+		assert.ErrorContains(tt, err, "no verification method found with id: did:example:other#key-1")
+	})
+
+	t.Run("select verification method", func(tt *testing.T) {
+		doc := validDocument()
+		doc.Authentication = doc.Authentication[:2]
+
+		vm, err := doc.SelectVerificationMethod(Authentication, "")
+		require.NoError(tt, err)
+		assert.Equal(tt, "#key-1", vm.ID)
+
+		vm, err = doc.SelectVerificationMethod(Authentication, string(crypto.EdDSA))
+		require.NoError(tt, err)
+		assert.Equal(tt, "#key-1", vm.ID)
+
+		_, err = doc.SelectVerificationMethod(Authentication, string(crypto.ES256K))
+		assert.ErrorContains(tt, err, "has no authentication verification methods for ES256K")
+
+		_, err = doc.SelectVerificationMethod(CapabilityInvocation, "")
+		assert.ErrorContains(tt, err, "has no capabilityInvocation verification methods")
+	})
+}
+
+func TestVerificationMethodKeys(t *testing.T) {
+	pubKey, privKey, err := crypto.GenerateSECP256k1Key()
+	require.NoError(t, err)
+	vm, err := ConstructJWKVerificationMethod("did:example:123#key-1", "did:example:123", pubKey.SerializeCompressed(), crypto.SECP256k1)
+	require.NoError(t, err)
+
+	t.Run("jwk and algorithm", func(tt *testing.T) {
+		publicKeyJWK, err := vm.JWK()
+		require.NoError(tt, err)
+		assert.Equal(tt, "secp256k1", publicKeyJWK.CRV)
+
+		alg, err := vm.Algorithm()
+		require.NoError(tt, err)
+		assert.Equal(tt, string(crypto.ES256K), alg)
+
+		// multibase keys are converted
+		multibaseVM := validDocument().VerificationMethod[0]
+		publicKeyJWK, err = multibaseVM.JWK()
+		require.NoError(tt, err)
+		assert.Equal(tt, "Ed25519", publicKeyJWK.CRV)
+		assert.Equal(tt, multibaseVM.ID, publicKeyJWK.KID)
+	})
+
+	t.Run("jwx verifier", func(tt *testing.T) {
+		signer, err := jwx.NewJWXSigner("did:example:123", vm.ID, privKey.ToECDSA())
+		require.NoError(tt, err)
+		token, err := signer.SignJWS([]byte("hello"))
+		require.NoError(tt, err)
+
+		verifier, err := vm.JWXVerifier()
+		require.NoError(tt, err)
+		assert.NoError(tt, verifier.VerifyJWS(string(token)))
+	})
+
+	t.Run("data integrity verifier", func(tt *testing.T) {
+		_, privateKeyJWK, err := jwx.PrivateKeyToPrivateKeyJWK(vm.ID, privKey.ToECDSA())
+		require.NoError(tt, err)
+		signer, err := jws2020.NewJSONWebKeySigner(vm.ID, *privateKeyJWK, cryptosuite.AssertionMethod)
+		require.NoError(tt, err)
+		signature, err := signer.Sign([]byte("hello"))
+		require.NoError(tt, err)
+
+		verifier, err := vm.Verifier()
+		require.NoError(tt, err)
+		assert.Equal(tt, vm.ID, verifier.GetKeyID())
+		assert.NoError(tt, verifier.Verify([]byte("hello"), signature))
+		assert.Error(tt, verifier.Verify([]byte("goodbye"), signature))
+	})
+}
@@ -0,0 +1,287 @@
+package did
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	"mime"
+	"reflect"
+	"sync"
+
+	"github.com/fxamacker/cbor/v2"
+	"github.com/goccy/go-json"
+	"github.com/piprate/json-gold/ld"
+	"github.com/pkg/errors"
+
+	"github.com/extrimian/ssi-sdk/util"
+)
+
+// Media types of the representations of a DID Document https://www.w3.org/TR/did-spec-registries/#representation-specific-entries
+const (
+	// JSONMediaType is the media type of the JSON representation, which has no JSON-LD context
+	// https://www.w3.org/TR/did-core/#json
+	JSONMediaType = "application/did+json"
+	// JSONLDMediaType is the media type of the JSON-LD representation https://www.w3.org/TR/did-core/#json-ld
+	JSONLDMediaType = "application/did+ld+json"
+	// CBORMediaType is the media type of the CBOR representation, the deterministic CBOR encoding of the document's
+	// JSON data model https://www.w3.org/TR/did-spec-registries/#application-did-cbor
+	CBORMediaType = "application/did+cbor"
+)
+
+var (
+	//go:embed context
+	knownContexts embed.FS
+
+	// knownContextFiles maps the JSON-LD contexts commonly found in DID Documents to their embedded copy, so that
+	// consuming JSON-LD representations does not require fetching them
+	knownContextFiles = map[string]string{
+		KnownDIDContext: "did-v1.jsonld",
+		"https://w3id.org/security/suites/jws-2020/v1":     "jws-2020-v1.jsonld",
+		"https://w3id.org/security/suites/ed25519-2020/v1": "ed25519-2020-v1.jsonld",
+	}
+
+	documentLoader     ld.DocumentLoader
+	documentLoaderErr  error
+	documentLoaderOnce sync.Once
+)
+
+// Produce produces the representation of the document with the given media type, following the production rules of
+// https://www.w3.org/TR/did-core/#representations:
+//   - application/did+json omits the JSON-LD context, which is specific to the JSON-LD representation
+//   - application/did+ld+json includes a JSON-LD context, whose first value is https://www.w3.org/ns/did/v1
+//   - application/did+cbor encodes the document, including any JSON-LD context, as deterministic CBOR as defined by
+//     https://www.rfc-editor.org/rfc/rfc8949#section-4.2.1
+func (d *Document) Produce(mediaType string) ([]byte, error) {
+	if d == nil {
+		return nil, errors.New("document cannot be empty")
+	}
+	doc := *d
+	switch mediaType {
+	case JSONMediaType:
+		doc.Context = nil
+		return json.Marshal(doc)
+	case JSONLDMediaType:
+		doc.Context = withDIDContext(doc.Context)
+		return json.Marshal(doc)
+	case CBORMediaType:
+		return MarshalCBOR(doc)
+	default:
+		return nil, fmt.Errorf("unsupported representation: %s", mediaType)
+	}
+}
+
+// ConsumeDocument consumes a representation of a DID Document with the given media type, following the consumption
+// rules of https://www.w3.org/TR/did-core/#representations. JSON-LD representations must have a JSON-LD context
+// whose first value is https://www.w3.org/ns/did/v1, and must expand without dropping any of their properties. The
+// contexts of the DID and the JSON Web Key 2020 and Ed25519 2020 suites are known, and other contexts are fetched.
+func ConsumeDocument(mediaType string, data []byte) (*Document, error) {
+	parsedMediaType, _, err := mime.ParseMediaType(mediaType)
+	if err != nil {
+		return nil, errors.Wrapf(err, "parsing media type: %s", mediaType)
+	}
+	var doc Document
+	switch parsedMediaType {
+	case JSONMediaType:
+		if err = json.Unmarshal(data, &doc); err != nil {
+			return nil, errors.Wrap(err, "unmarshalling JSON representation")
+		}
+	case JSONLDMediaType:
+		if err = json.Unmarshal(data, &doc); err != nil {
+			return nil, errors.Wrap(err, "unmarshalling JSON-LD representation")
+		}
+		if err = validateJSONLD(data); err != nil {
+			return nil, errors.Wrap(err, "invalid JSON-LD representation")
+		}
+	case CBORMediaType:
+		if err = UnmarshalCBOR(data, &doc); err != nil {
+			return nil, errors.Wrap(err, "unmarshalling CBOR representation")
+		}
+	default:
+		return nil, fmt.Errorf("unsupported representation: %s", mediaType)
+	}
+	return &doc, nil
+}
+
+// MarshalCBOR encodes the JSON data model of v as deterministic CBOR. Integers are encoded as CBOR integers and other
+// numbers as floating point values in their shortest form.
+func MarshalCBOR(v any) ([]byte, error) {
+	jsonBytes, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	decoder := json.NewDecoder(bytes.NewReader(jsonBytes))
+	decoder.UseNumber()
+	var value any
+	if err = decoder.Decode(&value); err != nil {
+		return nil, err
+	}
+	encMode, err := cbor.CoreDetEncOptions().EncMode()
+	if err != nil {
+		return nil, err
+	}
+	return encMode.Marshal(cborValue(value))
+}
+
+// UnmarshalCBOR decodes CBOR made of the JSON data model into v, which is populated as it would be from JSON. Maps
+// must have text string keys, which must not be duplicated.
+func UnmarshalCBOR(data []byte, v any) error {
+	decMode, err := cbor.DecOptions{
+		DupMapKey:      cbor.DupMapKeyEnforcedAPF,
+		DefaultMapType: reflect.TypeOf(map[string]any{}),
+		IndefLength:    cbor.IndefLengthForbidden,
+	}.DecMode()
+	if err != nil {
+		return err
+	}
+	var value any
+	if err = decMode.Unmarshal(data, &value); err != nil {
+		return err
+	}
+	if err = checkJSONDataModel(value); err != nil {
+		return err
+	}
+	jsonBytes, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(jsonBytes, v)
+}
+
+// cborValue converts JSON numbers to the integer or floating point values they hold, recursively
+func cborValue(value any) any {
+	switch v := value.(type) {
+	case json.Number:
+		if i, err := v.Int64(); err == nil {
+			return i
+		}
+		if f, err := v.Float64(); err == nil {
+			return f
+		}
+		return v.String()
+	case map[string]any:
+		for key, nested := range v {
+			v[key] = cborValue(nested)
+		}
+		return v
+	case []any:
+		for i, nested := range v {
+			v[i] = cborValue(nested)
+		}
+		return v
+	default:
+		return v
+	}
+}
+
+// checkJSONDataModel checks that a decoded CBOR value only holds the types of the JSON data model
+func checkJSONDataModel(value any) error {
+	switch v := value.(type) {
+	case nil, bool, string, int64, uint64, float64:
+		return nil
+	case map[string]any:
+		for key, nested := range v {
+			if err := checkJSONDataModel(nested); err != nil {
+				return errors.Wrapf(err, "%s", key)
+			}
+		}
+		return nil
+	case []any:
+		for i, nested := range v {
+			if err := checkJSONDataModel(nested); err != nil {
+				return errors.Wrapf(err, "[%d]", i)
+			}
+		}
+		return nil
+	default:
+		return fmt.Errorf("unsupported CBOR value: %T", value)
+	}
+}
+
+// withDIDContext returns the JSON-LD context with https://www.w3.org/ns/did/v1 as its first value, adding it when
+// it is missing and moving it first when it is not
+func withDIDContext(context any) any {
+	var contexts []any
+	switch c := context.(type) {
+	case nil:
+		return KnownDIDContext
+	case string:
+		if c == KnownDIDContext {
+			return c
+		}
+		contexts = []any{c}
+	case []string:
+		for _, s := range c {
+			contexts = append(contexts, s)
+		}
+	case []any:
+		contexts = c
+	default:
+		contexts = []any{c}
+	}
+	if len(contexts) > 0 && contexts[0] == KnownDIDContext {
+		return context
+	}
+	withDID := []any{KnownDIDContext}
+	for _, c := range contexts {
+		if c != KnownDIDContext {
+			withDID = append(withDID, c)
+		}
+	}
+	return withDID
+}
+
+// validateJSONLD checks the JSON-LD context of a JSON-LD representation, then expands it in safe mode, which fails
+// on properties that are not defined by the context instead of dropping them
+func validateJSONLD(data []byte) error {
+	var docMap map[string]any
+	if err := json.Unmarshal(data, &docMap); err != nil {
+		return err
+	}
+	switch context := docMap["@context"].(type) {
+	case nil:
+		return errors.New("@context is required")
+	case string:
+		if context != KnownDIDContext {
+			return fmt.Errorf("@context must be %s, not %s", KnownDIDContext, context)
+		}
+	case []any:
+		if len(context) == 0 || context[0] != KnownDIDContext {
+			return fmt.Errorf("the first value of @context must be %s", KnownDIDContext)
+		}
+	default:
+		return fmt.Errorf("@context must be %s or a list starting with it", KnownDIDContext)
+	}
+
+	loader, err := getDocumentLoader()
+	if err != nil {
+		return errors.Wrap(err, "loading known contexts")
+	}
+	processor := util.NewLDProcessor()
+	processor.DocumentLoader = loader
+	if _, err = processor.ExpandStrict(docMap); err != nil {
+		return errors.Wrap(err, "expanding document")
+	}
+	return nil
+}
+
+// getDocumentLoader returns a caching document loader, shared by all consumers, with the known contexts preloaded
+func getDocumentLoader() (ld.DocumentLoader, error) {
+	documentLoaderOnce.Do(func() {
+		loader := ld.NewCachingDocumentLoader(ld.NewRFC7324CachingDocumentLoader(nil))
+		for url, fileName := range knownContextFiles {
+			contextBytes, err := knownContexts.ReadFile("context/" + fileName)
+			if err != nil {
+				documentLoaderErr = errors.Wrapf(err, "reading context: %s", fileName)
+				return
+			}
+			context, err := ld.DocumentFromReader(bytes.NewReader(contextBytes))
+			if err != nil {
+				documentLoaderErr = errors.Wrapf(err, "parsing context: %s", fileName)
+				return
+			}
+			loader.AddDocument(url, context)
+		}
+		documentLoader = loader
+	})
+	return documentLoader, documentLoaderErr
+}
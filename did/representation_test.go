@@ -0,0 +1,156 @@
+package did
+
+import (
+	"testing"
+
+	"github.com/fxamacker/cbor/v2"
+	"github.com/goccy/go-json"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProduce(t *testing.T) {
+	t.Run("json omits the context", func(tt *testing.T) {
+		doc := validDocument()
+		docBytes, err := doc.Produce(JSONMediaType)
+		require.NoError(tt, err)
+		assert.NotContains(tt, string(docBytes), "@context")
+
+		consumed, err := ConsumeDocument(JSONMediaType, docBytes)
+		require.NoError(tt, err)
+		assert.Nil(tt, consumed.Context)
+		assert.Equal(tt, doc.ID, consumed.ID)
+	})
+
+	t.Run("json-ld starts with the did context", func(tt *testing.T) {
+		for _, test := range []struct {
+			context  any
+			expected any
+		}{
+			{context: nil, expected: KnownDIDContext},
+			{context: KnownDIDContext, expected: KnownDIDContext},
+			{context: "https://example.com/v1", expected: []any{KnownDIDContext, "https://example.com/v1"}},
+			{context: []string{"https://example.com/v1", KnownDIDContext}, expected: []any{KnownDIDContext, "https://example.com/v1"}},
+			{context: []any{KnownDIDContext, "https://example.com/v1"}, expected: []any{KnownDIDContext, "https://example.com/v1"}},
+		} {
+			doc := validDocument()
+			doc.Context = test.context
+			docBytes, err := doc.Produce(JSONLDMediaType)
+			require.NoError(tt, err)
+
+			var docMap map[string]any
+			require.NoError(tt, json.Unmarshal(docBytes, &docMap))
+			expected, err := json.Marshal(test.expected)
+			require.NoError(tt, err)
+			actual, err := json.Marshal(docMap["@context"])
+			require.NoError(tt, err)
+			assert.JSONEq(tt, string(expected), string(actual))
+		}
+	})
+
+	t.Run("cbor is deterministic", func(tt *testing.T) {
+		doc := validDocument()
+		doc.Services[1].ServiceEndpoint = []any{map[string]any{"uri": "https://example.com/didcomm", "priority": 1, "weight": 0.5}}
+		docBytes, err := doc.Produce(CBORMediaType)
+		require.NoError(tt, err)
+		again, err := doc.Produce(CBORMediaType)
+		require.NoError(tt, err)
+		assert.Equal(tt, docBytes, again)
+
+		// the encoding is the deterministic encoding of the document's JSON data model
+		var value any
+		require.NoError(tt, cbor.Unmarshal(docBytes, &value))
+		deterministic, err := cbor.CoreDetEncOptions().EncMode()
+		require.NoError(tt, err)
+		reencoded, err := deterministic.Marshal(value)
+		require.NoError(tt, err)
+		assert.Equal(tt, docBytes, reencoded)
+
+		consumed, err := ConsumeDocument(CBORMediaType, docBytes)
+		require.NoError(tt, err)
+		expected, err := json.Marshal(doc)
+		require.NoError(tt, err)
+		actual, err := json.Marshal(consumed)
+		require.NoError(tt, err)
+		assert.JSONEq(tt, string(expected), string(actual))
+	})
+
+	t.Run("unsupported representation", func(tt *testing.T) {
+		doc := validDocument()
+		_, err := doc.Produce("application/xml")
+		assert.ErrorContains(tt, err, "unsupported representation: application/xml")
+
+		var nilDoc *Document
+		_, err = nilDoc.Produce(JSONMediaType)
+		assert.Error(tt, err)
+	})
+}
+
+func TestConsumeDocument(t *testing.T) {
+	t.Run("json-ld test vector", func(tt *testing.T) {
+		// the test vector's contexts are known, so they are not fetched
+		gotTestVector, err := getTestVector(TestVector1)
+		require.NoError(tt, err)
+
+		doc, err := ConsumeDocument(JSONLDMediaType, []byte(gotTestVector))
+		require.NoError(tt, err)
+		assert.Equal(tt, "did:example:123", doc.ID)
+		assert.Len(tt, doc.Authentication, 1)
+	})
+
+	t.Run("json-ld with media type parameters", func(tt *testing.T) {
+		doc := Document{Context: KnownDIDContext, ID: "did:example:123"}
+		docBytes, err := doc.Produce(JSONLDMediaType)
+		require.NoError(tt, err)
+		_, err = ConsumeDocument(JSONLDMediaType+"; charset=utf-8", docBytes)
+		assert.NoError(tt, err)
+	})
+
+	t.Run("json-ld requires the did context first", func(tt *testing.T) {
+		for _, docJSON := range []string{
+			`{"id":"did:example:123"}`,
+			`{"@context":"https://example.com/v1","id":"did:example:123"}`,
+			`{"@context":["https://example.com/v1","https://www.w3.org/ns/did/v1"],"id":"did:example:123"}`,
+			`{"@context":{"id":"@id"},"id":"did:example:123"}`,
+		} {
+			_, err := ConsumeDocument(JSONLDMediaType, []byte(docJSON))
+			assert.ErrorContains(tt, err, "@context", docJSON)
+		}
+	})
+
+	t.Run("json-ld with properties its context does not define", func(tt *testing.T) {
+		// publicKeyJwk is defined by the JSON Web Key 2020 context, which is missing
+		docJSON := `{"@context":"https://www.w3.org/ns/did/v1","id":"did:example:123","verificationMethod":[{"id":"#key-1","type":"JsonWebKey2020","controller":"did:example:123","publicKeyJwk":{"kty":"OKP","crv":"Ed25519","x":"VCpo2LMLhn6iWku8MKvSLg2ZAoC-nlOyPVQaO3FxVeQ"}}]}`
+		_, err := ConsumeDocument(JSONLDMediaType, []byte(docJSON))
+		assert.ErrorContains(tt, err, "expanding document")
+
+		docJSON = `{"@context":["https://www.w3.org/ns/did/v1","https://w3id.org/security/suites/jws-2020/v1"],"id":"did:example:123","verificationMethod":[{"id":"#key-1","type":"JsonWebKey2020","controller":"did:example:123","publicKeyJwk":{"kty":"OKP","crv":"Ed25519","x":"VCpo2LMLhn6iWku8MKvSLg2ZAoC-nlOyPVQaO3FxVeQ"}}]}`
+		doc, err := ConsumeDocument(JSONLDMediaType, []byte(docJSON))
+		require.NoError(tt, err)
+		require.Len(tt, doc.VerificationMethod, 1)
+		assert.Equal(tt, "Ed25519", doc.VerificationMethod[0].PublicKeyJWK.CRV)
+	})
+
+	t.Run("cbor outside of the json data model", func(tt *testing.T) {
+		for name, value := range map[string]any{
+			"byte strings":       map[string]any{"id": []byte("did:example:123")},
+			"non text keys":      map[any]any{1: "did:example:123"},
+			"tags":               map[string]any{"id": cbor.Tag{Number: 32, Content: "did:example:123"}},
+			"not a document map": []any{"did:example:123"},
+		} {
+			data, err := cbor.Marshal(value)
+			require.NoError(tt, err)
+			_, err = ConsumeDocument(CBORMediaType, data)
+			assert.Error(tt, err, name)
+		}
+
+		// a map with the id key twice
+		_, err := ConsumeDocument(CBORMediaType, []byte{0xa2, 0x62, 'i', 'd', 0x61, 'a', 0x62, 'i', 'd', 0x61, 'b'})
+		assert.ErrorContains(tt, err, "duplicate map key")
+	})
+
+	t.Run("unsupported representation", func(tt *testing.T) {
+		_, err := ConsumeDocument("application/xml", []byte("<did/>"))
+		assert.ErrorContains(tt, err, "unsupported representation: application/xml")
+	})
+}
@@ -0,0 +1,357 @@
+package resolution
+
+import (
+	"container/list"
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/extrimian/ssi-sdk/did"
+	"github.com/extrimian/ssi-sdk/util"
+)
+
+const (
+	// DefaultCacheTTL is the time a successfully resolved DID is kept when no TTL is configured
+	DefaultCacheTTL = 15 * time.Minute
+	// DefaultNegativeCacheTTL is the time a DID that could not be found is kept when no TTL is configured
+	DefaultNegativeCacheTTL = 1 * time.Minute
+	// DefaultCacheMaxEntries is the maximum number of cached results kept when no limit is configured
+	DefaultCacheMaxEntries = 1000
+)
+
+// CacheConfig configures the behavior of a CachingResolver
+type CacheConfig struct {
+	// TTL is the maximum amount of time a resolved DID is cached for. The TTL may be shortened by a hint in the
+	// resolved document's metadata, such as `nextUpdate`.
+	TTL time.Duration
+	// NegativeTTL is the amount of time a DID that was not found is cached for. A negative value disables
+	// negative caching.
+	NegativeTTL time.Duration
+	// MaxEntries is the maximum number of entries, positive and negative, kept in the cache. When the limit is
+	// reached the least recently used entry is evicted.
+	MaxEntries int
+	// Clock is used to determine the current time. Defaults to time.Now.
+	Clock func() time.Time
+}
+
+// CacheStats exposes counters which can be used to tune a CachingResolver
+type CacheStats struct {
+	// Hits is the number of resolutions served from a cached result
+	Hits uint64 `json:"hits"`
+	// NegativeHits is the number of resolutions served from a cached not found result
+	NegativeHits uint64 `json:"negativeHits"`
+	// Misses is the number of resolutions that were passed to the underlying resolver
+	Misses uint64 `json:"misses"`
+	// Coalesced is the number of resolutions that waited on an identical in-flight resolution
+	Coalesced uint64 `json:"coalesced"`
+	// Evictions is the number of entries removed to stay within the configured maximum number of entries
+	Evictions uint64 `json:"evictions"`
+	// Expirations is the number of entries removed because their TTL elapsed
+	Expirations uint64 `json:"expirations"`
+	// Entries is the number of entries currently in the cache
+	Entries int `json:"entries"`
+}
+
+// CachingResolver wraps a Resolver with an LRU cache that expires entries after a TTL. Concurrent resolutions of the
+// same DID are coalesced into a single call to the underlying resolver. DIDs that could not be found are cached
+// separately, with their own TTL.
+type CachingResolver struct {
+	resolver Resolver
+	config   CacheConfig
+
+	mu       sync.Mutex
+	entries  map[string]*list.Element
+	lru      *list.List
+	inFlight map[string]*inFlightResolution
+	stats    CacheStats
+	// generation is incremented by every invalidation, so that resolutions started before it are not cached
+	generation uint64
+}
+
+var _ Resolver = (*CachingResolver)(nil)
+
+type cacheEntry struct {
+	key       string
+	id        string
+	result    *Result
+	err       error
+	notFound  bool
+	expiresAt time.Time
+}
+
+type inFlightResolution struct {
+	done   chan struct{}
+	result *Result
+	err    error
+}
+
+// NewCachingResolver creates a CachingResolver wrapping the given resolver. Zero values in the config are replaced
+// with DefaultCacheTTL, DefaultNegativeCacheTTL and DefaultCacheMaxEntries respectively.
+func NewCachingResolver(resolver Resolver, config CacheConfig) (*CachingResolver, error) {
+	if resolver == nil {
+		return nil, errors.New("resolver cannot be nil")
+	}
+	if config.TTL < 0 {
+		return nil, fmt.Errorf("invalid cache ttl: %s", config.TTL)
+	}
+	if config.MaxEntries < 0 {
+		return nil, fmt.Errorf("invalid cache max entries: %d", config.MaxEntries)
+	}
+	if config.TTL == 0 {
+		config.TTL = DefaultCacheTTL
+	}
+	if config.NegativeTTL == 0 {
+		config.NegativeTTL = DefaultNegativeCacheTTL
+	}
+	if config.MaxEntries == 0 {
+		config.MaxEntries = DefaultCacheMaxEntries
+	}
+	if config.Clock == nil {
+		config.Clock = time.Now
+	}
+	return &CachingResolver{
+		resolver: resolver,
+		config:   config,
+		entries:  make(map[string]*list.Element),
+		lru:      list.New(),
+		inFlight: make(map[string]*inFlightResolution),
+	}, nil
+}
+
+// Resolve returns a cached resolution result for the DID if one exists and has not expired. Otherwise, it resolves
+// the DID with the underlying resolver, sharing the result with any concurrent callers resolving the same DID.
+// Results are cached per DID and set of resolution options. A NoCacheOption skips the cache and replaces the cached
+// result with a fresh one. Every caller receives its own copy of the result, so it can be modified freely.
+//
+// A shared resolution is not canceled when the caller that started it is; each caller stops waiting for it when its
+// own context is done.
+func (cr *CachingResolver) Resolve(ctx context.Context, id string, opts ...Option) (*Result, error) {
+	key, noCache := cacheKey(id, opts)
+	if noCache {
+		cr.mu.Lock()
+		generation := cr.generation
+		cr.mu.Unlock()
+		result, err := cr.resolver.Resolve(ctx, id, opts...)
+		cr.mu.Lock()
+		cr.stats.Misses++
+		if cr.generation == generation {
+			cr.store(key, id, result, err)
+		}
+		cr.mu.Unlock()
+		return result, err
+	}
+
+	cr.mu.Lock()
+	if entry, ok := cr.lookup(key); ok {
+		if entry.notFound {
+			cr.stats.NegativeHits++
+		} else {
+			cr.stats.Hits++
+		}
+		cr.mu.Unlock()
+		return copyResult(entry.result, entry.err)
+	}
+	call, ok := cr.inFlight[key]
+	if ok {
+		cr.stats.Coalesced++
+	} else {
+		call = &inFlightResolution{done: make(chan struct{})}
+		cr.inFlight[key] = call
+		cr.stats.Misses++
+		go cr.resolve(context.WithoutCancel(ctx), key, id, opts, call, cr.generation)
+	}
+	cr.mu.Unlock()
+
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case <-call.done:
+		return copyResult(call.result, call.err)
+	}
+}
+
+// resolve resolves the DID for every caller waiting on the call, recovering from a panic of the underlying resolver
+// so that the callers are always released. The result is not cached if the cache has been invalidated since the
+// generation the call started in.
+func (cr *CachingResolver) resolve(ctx context.Context, key, id string, opts []Option, call *inFlightResolution, generation uint64) {
+	defer func() {
+		if r := recover(); r != nil {
+			call.result, call.err = nil, fmt.Errorf("resolving %s: resolver panicked: %v", id, r)
+		}
+		cr.mu.Lock()
+		if cr.inFlight[key] == call {
+			delete(cr.inFlight, key)
+		}
+		if cr.generation == generation {
+			cr.store(key, id, call.result, call.err)
+		}
+		cr.mu.Unlock()
+		close(call.done)
+	}()
+	call.result, call.err = cr.resolver.Resolve(ctx, id, opts...)
+}
+
+// Methods returns the methods supported by the underlying resolver
+func (cr *CachingResolver) Methods() []did.Method {
+	return cr.resolver.Methods()
+}
+
+// Invalidate removes all cached results for the given DID, regardless of the options they were resolved with.
+// Resolutions in flight are not cached once they finish, and later resolutions of the DID do not wait on them.
+func (cr *CachingResolver) Invalidate(id string) {
+	cr.mu.Lock()
+	defer cr.mu.Unlock()
+	cr.generation++
+	for _, element := range cr.entries {
+		if element.Value.(*cacheEntry).id == id {
+			cr.remove(element)
+		}
+	}
+	for key := range cr.inFlight {
+		if key == id || strings.HasPrefix(key, id+"|") {
+			delete(cr.inFlight, key)
+		}
+	}
+}
+
+// InvalidateAll removes all cached results. Resolutions in flight are not cached once they finish, and later
+// resolutions do not wait on them.
+func (cr *CachingResolver) InvalidateAll() {
+	cr.mu.Lock()
+	defer cr.mu.Unlock()
+	cr.generation++
+	cr.entries = make(map[string]*list.Element)
+	cr.lru.Init()
+	cr.inFlight = make(map[string]*inFlightResolution)
+}
+
+// Stats returns a snapshot of the cache's counters
+func (cr *CachingResolver) Stats() CacheStats {
+	cr.mu.Lock()
+	defer cr.mu.Unlock()
+	stats := cr.stats
+	stats.Entries = cr.lru.Len()
+	return stats
+}
+
+// lookup returns an unexpired entry for the key, removing it if it has expired. Must be called with the lock held.
+func (cr *CachingResolver) lookup(key string) (*cacheEntry, bool) {
+	element, ok := cr.entries[key]
+	if !ok {
+		return nil, false
+	}
+	entry := element.Value.(*cacheEntry)
+	if !cr.config.Clock().Before(entry.expiresAt) {
+		cr.remove(element)
+		cr.stats.Expirations++
+		return nil, false
+	}
+	cr.lru.MoveToFront(element)
+	return entry, true
+}
+
+// store caches a copy of a resolution outcome if it is cacheable. Must be called with the lock held.
+func (cr *CachingResolver) store(key, id string, result *Result, err error) {
+	result, copyErr := copyResult(result, nil)
+	if copyErr != nil {
+		return
+	}
+	now := cr.config.Clock()
+	entry := cacheEntry{key: key, id: id, result: result, err: err}
+	switch {
+	case isNotFound(result, err):
+		if cr.config.NegativeTTL < 0 {
+			return
+		}
+		entry.notFound = true
+		entry.expiresAt = now.Add(cr.config.NegativeTTL)
+	case err != nil || result == nil || result.Metadata.Error != nil:
+		// transient failures and other errors are never cached
+		return
+	default:
+		entry.expiresAt = now.Add(cr.ttlFor(result, now))
+	}
+	if !now.Before(entry.expiresAt) {
+		return
+	}
+
+	if element, ok := cr.entries[key]; ok {
+		cr.remove(element)
+	}
+	cr.entries[key] = cr.lru.PushFront(&entry)
+	for cr.lru.Len() > cr.config.MaxEntries {
+		cr.remove(cr.lru.Back())
+		cr.stats.Evictions++
+	}
+}
+
+// ttlFor returns the configured TTL, shortened by the `nextUpdate` hint in the document metadata if present
+func (cr *CachingResolver) ttlFor(result *Result, now time.Time) time.Duration {
+	ttl := cr.config.TTL
+	if result.DocumentMetadata == nil || result.DocumentMetadata.NextUpdate == "" {
+		return ttl
+	}
+	nextUpdate, err := time.Parse(time.RFC3339, result.DocumentMetadata.NextUpdate)
+	if err != nil {
+		return ttl
+	}
+	if untilUpdate := nextUpdate.Sub(now); untilUpdate < ttl {
+		return untilUpdate
+	}
+	return ttl
+}
+
+// remove drops an element from the cache. Must be called with the lock held.
+func (cr *CachingResolver) remove(element *list.Element) {
+	delete(cr.entries, element.Value.(*cacheEntry).key)
+	cr.lru.Remove(element)
+}
+
+// copyResult returns a deep copy of the result, so that callers sharing a result cannot see each other's changes
+func copyResult(result *Result, err error) (*Result, error) {
+	if result == nil {
+		return nil, err
+	}
+	var copied Result
+	if copyErr := util.Copy(result, &copied); copyErr != nil {
+		return nil, errors.Wrap(copyErr, "copying resolution result")
+	}
+	return &copied, err
+}
+
+// cacheKey builds a key unique to a DID and the options it is resolved with, and reports whether the cache should
+// be bypassed
+func cacheKey(id string, opts []Option) (string, bool) {
+	var noCache bool
+	var sb strings.Builder
+	sb.WriteString(id)
+	for _, opt := range opts {
+		switch o := opt.(type) {
+		case nil:
+			continue
+		case NoCacheOption:
+			noCache = noCache || bool(o)
+			continue
+		case VersionTimeOption:
+			sb.WriteString(fmt.Sprintf("|%T=%s", o, time.Time(o).UTC().Format(time.RFC3339Nano)))
+		default:
+			sb.WriteString(fmt.Sprintf("|%T=%+v", o, o))
+		}
+	}
+	return sb.String(), noCache
+}
+
+// isNotFound returns true if the resolution outcome indicates the DID does not exist
+func isNotFound(result *Result, err error) bool {
+	if IsNotFound(err) {
+		return true
+	}
+	if result == nil || result.Metadata.Error == nil {
+		return false
+	}
+	return result.Metadata.Error.NotFound || result.Metadata.Error.Code == NotFoundErrorCode
+}
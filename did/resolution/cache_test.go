@@ -0,0 +1,377 @@
+package resolution
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/extrimian/ssi-sdk/did"
+)
+
+type countingResolver struct {
+	calls   atomic.Int32
+	release chan struct{}
+	resolve func(id string) (*Result, error)
+}
+
+func (r *countingResolver) Resolve(_ context.Context, id string, _ ...Option) (*Result, error) {
+	r.calls.Add(1)
+	if r.release != nil {
+		<-r.release
+	}
+	return r.resolve(id)
+}
+
+func (*countingResolver) Methods() []did.Method {
+	return []did.Method{"example"}
+}
+
+func newCountingResolver() *countingResolver {
+	return &countingResolver{resolve: func(id string) (*Result, error) {
+		switch id {
+		case "did:example:missing":
+			return nil, NewResolutionErrorf(NotFoundErrorCode, "did not found: %s", id)
+		case "did:example:broken":
+			return nil, errors.New("connection refused")
+		default:
+			return &Result{Document: did.Document{ID: id}}, nil
+		}
+	}}
+}
+
+type testClock struct {
+	now time.Time
+}
+
+func (c *testClock) Now() time.Time {
+	return c.now
+}
+
+func TestNewCachingResolver(t *testing.T) {
+	t.Run("nil resolver", func(tt *testing.T) {
+		_, err := NewCachingResolver(nil, CacheConfig{})
+		assert.Error(tt, err)
+		assert.Contains(tt, err.Error(), "resolver cannot be nil")
+	})
+
+	t.Run("bad config", func(tt *testing.T) {
+		_, err := NewCachingResolver(newCountingResolver(), CacheConfig{TTL: -1})
+		assert.Error(tt, err)
+
+		_, err = NewCachingResolver(newCountingResolver(), CacheConfig{MaxEntries: -1})
+		assert.Error(tt, err)
+	})
+
+	t.Run("methods are passed through", func(tt *testing.T) {
+		cr, err := NewCachingResolver(newCountingResolver(), CacheConfig{})
+		assert.NoError(tt, err)
+		assert.Equal(tt, []did.Method{"example"}, cr.Methods())
+	})
+}
+
+func TestCachingResolver(t *testing.T) {
+	t.Run("caches until the ttl elapses", func(tt *testing.T) {
+		clock := &testClock{now: time.Now()}
+		r := newCountingResolver()
+		cr, err := NewCachingResolver(r, CacheConfig{TTL: time.Minute, Clock: clock.Now})
+		require.NoError(tt, err)
+
+		for i := 0; i < 3; i++ {
+			result, err := cr.Resolve(context.Background(), "did:example:123")
+			assert.NoError(tt, err)
+			assert.Equal(tt, "did:example:123", result.Document.ID)
+		}
+		assert.EqualValues(tt, 1, r.calls.Load())
+
+		clock.now = clock.now.Add(time.Minute)
+		_, err = cr.Resolve(context.Background(), "did:example:123")
+		assert.NoError(tt, err)
+		assert.EqualValues(tt, 2, r.calls.Load())
+
+		stats := cr.Stats()
+		assert.EqualValues(tt, 2, stats.Hits)
+		assert.EqualValues(tt, 2, stats.Misses)
+		assert.EqualValues(tt, 1, stats.Expirations)
+		assert.Equal(tt, 1, stats.Entries)
+	})
+
+	t.Run("respects the next update hint", func(tt *testing.T) {
+		clock := &testClock{now: time.Now().UTC()}
+		r := newCountingResolver()
+		r.resolve = func(id string) (*Result, error) {
+			return &Result{
+				Document:         did.Document{ID: id},
+				DocumentMetadata: &DocumentMetadata{NextUpdate: clock.now.Add(10 * time.Second).Format(time.RFC3339)},
+			}, nil
+		}
+		cr, err := NewCachingResolver(r, CacheConfig{TTL: time.Hour, Clock: clock.Now})
+		require.NoError(tt, err)
+
+		_, err = cr.Resolve(context.Background(), "did:example:123")
+		assert.NoError(tt, err)
+		clock.now = clock.now.Add(5 * time.Second)
+		_, err = cr.Resolve(context.Background(), "did:example:123")
+		assert.NoError(tt, err)
+		assert.EqualValues(tt, 1, r.calls.Load())
+
+		clock.now = clock.now.Add(10 * time.Second)
+		_, err = cr.Resolve(context.Background(), "did:example:123")
+		assert.NoError(tt, err)
+		assert.EqualValues(tt, 2, r.calls.Load())
+	})
+
+	t.Run("negative caching uses its own ttl", func(tt *testing.T) {
+		clock := &testClock{now: time.Now()}
+		r := newCountingResolver()
+		cr, err := NewCachingResolver(r, CacheConfig{TTL: time.Hour, NegativeTTL: time.Second, Clock: clock.Now})
+		require.NoError(tt, err)
+
+		_, err = cr.Resolve(context.Background(), "did:example:missing")
+		assert.Error(tt, err)
+		_, err = cr.Resolve(context.Background(), "did:example:missing")
+		assert.True(tt, IsNotFound(err))
+		assert.EqualValues(tt, 1, r.calls.Load())
+		assert.EqualValues(tt, 1, cr.Stats().NegativeHits)
+
+		clock.now = clock.now.Add(time.Second)
+		_, err = cr.Resolve(context.Background(), "did:example:missing")
+		assert.Error(tt, err)
+		assert.EqualValues(tt, 2, r.calls.Load())
+	})
+
+	t.Run("negative caching can be disabled", func(tt *testing.T) {
+		r := newCountingResolver()
+		cr, err := NewCachingResolver(r, CacheConfig{NegativeTTL: -1})
+		require.NoError(tt, err)
+
+		_, _ = cr.Resolve(context.Background(), "did:example:missing")
+		_, _ = cr.Resolve(context.Background(), "did:example:missing")
+		assert.EqualValues(tt, 2, r.calls.Load())
+	})
+
+	t.Run("other errors are not cached", func(tt *testing.T) {
+		r := newCountingResolver()
+		cr, err := NewCachingResolver(r, CacheConfig{})
+		require.NoError(tt, err)
+
+		_, err = cr.Resolve(context.Background(), "did:example:broken")
+		assert.Error(tt, err)
+		_, err = cr.Resolve(context.Background(), "did:example:broken")
+		assert.Error(tt, err)
+		assert.EqualValues(tt, 2, r.calls.Load())
+		assert.Equal(tt, 0, cr.Stats().Entries)
+	})
+
+	t.Run("evicts the least recently used entry", func(tt *testing.T) {
+		r := newCountingResolver()
+		cr, err := NewCachingResolver(r, CacheConfig{MaxEntries: 2})
+		require.NoError(tt, err)
+
+		_, _ = cr.Resolve(context.Background(), "did:example:1")
+		_, _ = cr.Resolve(context.Background(), "did:example:2")
+		_, _ = cr.Resolve(context.Background(), "did:example:1")
+		_, _ = cr.Resolve(context.Background(), "did:example:3")
+		assert.EqualValues(tt, 3, r.calls.Load())
+		assert.EqualValues(tt, 1, cr.Stats().Evictions)
+
+		// did:example:2 was evicted, did:example:1 was not
+		_, _ = cr.Resolve(context.Background(), "did:example:1")
+		assert.EqualValues(tt, 3, r.calls.Load())
+		_, _ = cr.Resolve(context.Background(), "did:example:2")
+		assert.EqualValues(tt, 4, r.calls.Load())
+	})
+
+	t.Run("explicit invalidation", func(tt *testing.T) {
+		r := newCountingResolver()
+		cr, err := NewCachingResolver(r, CacheConfig{})
+		require.NoError(tt, err)
+
+		_, _ = cr.Resolve(context.Background(), "did:example:1")
+		_, _ = cr.Resolve(context.Background(), "did:example:2")
+		cr.Invalidate("did:example:1")
+		assert.Equal(tt, 1, cr.Stats().Entries)
+
+		_, _ = cr.Resolve(context.Background(), "did:example:1")
+		assert.EqualValues(tt, 3, r.calls.Load())
+
+		cr.InvalidateAll()
+		assert.Equal(tt, 0, cr.Stats().Entries)
+	})
+
+	t.Run("resolutions in flight during an invalidation are not cached", func(tt *testing.T) {
+		r := newCountingResolver()
+		r.release = make(chan struct{})
+		cr, err := NewCachingResolver(r, CacheConfig{})
+		require.NoError(tt, err)
+
+		for _, invalidate := range []func(){func() { cr.Invalidate("did:example:123") }, cr.InvalidateAll} {
+			done := make(chan struct{})
+			go func() {
+				defer close(done)
+				_, err := cr.Resolve(context.Background(), "did:example:123")
+				assert.NoError(tt, err)
+			}()
+			assert.Eventually(tt, func() bool { return r.calls.Load() > 0 }, time.Second, time.Millisecond)
+
+			// a resolution after the invalidation does not wait on the one in flight
+			invalidate()
+			after := make(chan struct{})
+			go func() {
+				defer close(after)
+				_, err := cr.Resolve(context.Background(), "did:example:123")
+				assert.NoError(tt, err)
+			}()
+			assert.Eventually(tt, func() bool { return r.calls.Load() == 2 }, time.Second, time.Millisecond)
+
+			r.release <- struct{}{}
+			<-done
+			assert.Equal(tt, 0, cr.Stats().Entries)
+
+			// the resolution started after the invalidation is cached
+			r.release <- struct{}{}
+			<-after
+			assert.Equal(tt, 1, cr.Stats().Entries)
+			cr.InvalidateAll()
+			r.calls.Store(0)
+		}
+	})
+
+	t.Run("concurrent resolutions are coalesced", func(tt *testing.T) {
+		r := newCountingResolver()
+		r.release = make(chan struct{})
+		cr, err := NewCachingResolver(r, CacheConfig{})
+		require.NoError(tt, err)
+
+		const callers = 10
+		var wg sync.WaitGroup
+		wg.Add(callers)
+		for i := 0; i < callers; i++ {
+			go func() {
+				defer wg.Done()
+				result, err := cr.Resolve(context.Background(), "did:example:123")
+				assert.NoError(tt, err)
+				assert.Equal(tt, "did:example:123", result.Document.ID)
+			}()
+		}
+
+		// wait until every caller is either resolving or waiting on the in-flight resolution
+		assert.Eventually(tt, func() bool {
+			stats := cr.Stats()
+			return stats.Misses == 1 && stats.Coalesced == callers-1
+		}, time.Second, time.Millisecond)
+		close(r.release)
+		wg.Wait()
+
+		assert.EqualValues(tt, 1, r.calls.Load())
+	})
+
+	t.Run("waiters stop waiting when their context is done", func(tt *testing.T) {
+		r := newCountingResolver()
+		r.release = make(chan struct{})
+		cr, err := NewCachingResolver(r, CacheConfig{})
+		require.NoError(tt, err)
+
+		// the caller starting the resolution gives up, which does not cancel the resolution of the other callers
+		first, cancelFirst := context.WithCancel(context.Background())
+		firstDone := make(chan error)
+		go func() {
+			_, err := cr.Resolve(first, "did:example:123")
+			firstDone <- err
+		}()
+		assert.Eventually(tt, func() bool { return cr.Stats().Misses == 1 }, time.Second, time.Millisecond)
+
+		waiter, cancelWaiter := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancelWaiter()
+		_, err = cr.Resolve(waiter, "did:example:123")
+		assert.ErrorIs(tt, err, context.DeadlineExceeded)
+
+		cancelFirst()
+		assert.ErrorIs(tt, <-firstDone, context.Canceled)
+
+		close(r.release)
+		result, err := cr.Resolve(context.Background(), "did:example:123")
+		assert.NoError(tt, err)
+		assert.Equal(tt, "did:example:123", result.Document.ID)
+		assert.EqualValues(tt, 1, r.calls.Load())
+	})
+
+	t.Run("a panicking resolver releases the waiters", func(tt *testing.T) {
+		r := newCountingResolver()
+		r.release = make(chan struct{})
+		r.resolve = func(string) (*Result, error) {
+			panic("boom")
+		}
+		cr, err := NewCachingResolver(r, CacheConfig{})
+		require.NoError(tt, err)
+
+		const callers = 3
+		errs := make(chan error, callers)
+		for i := 0; i < callers; i++ {
+			go func() {
+				_, err := cr.Resolve(context.Background(), "did:example:123")
+				errs <- err
+			}()
+		}
+		assert.Eventually(tt, func() bool {
+			stats := cr.Stats()
+			return stats.Misses == 1 && stats.Coalesced == callers-1
+		}, time.Second, time.Millisecond)
+		close(r.release)
+		for i := 0; i < callers; i++ {
+			assert.ErrorContains(tt, <-errs, "resolver panicked: boom")
+		}
+	})
+
+	t.Run("callers receive their own copy of the result", func(tt *testing.T) {
+		r := newCountingResolver()
+		cr, err := NewCachingResolver(r, CacheConfig{})
+		require.NoError(tt, err)
+
+		result, err := cr.Resolve(context.Background(), "did:example:123")
+		require.NoError(tt, err)
+		result.Document.ID = "did:example:456"
+		result.Document.AlsoKnownAs = "https://example.com"
+
+		cached, err := cr.Resolve(context.Background(), "did:example:123")
+		require.NoError(tt, err)
+		assert.Equal(tt, "did:example:123", cached.Document.ID)
+		assert.Empty(tt, cached.Document.AlsoKnownAs)
+		assert.EqualValues(tt, 1, r.calls.Load())
+	})
+}
+
+func TestCachingResolverOptions(t *testing.T) {
+	t.Run("no cache refreshes the cached result", func(tt *testing.T) {
+		r := newCountingResolver()
+		cr, err := NewCachingResolver(r, CacheConfig{})
+		require.NoError(tt, err)
+
+		_, _ = cr.Resolve(context.Background(), "did:example:123")
+		_, _ = cr.Resolve(context.Background(), "did:example:123", WithNoCache())
+		assert.EqualValues(tt, 2, r.calls.Load())
+
+		_, _ = cr.Resolve(context.Background(), "did:example:123")
+		assert.EqualValues(tt, 2, r.calls.Load())
+		assert.Equal(tt, 1, cr.Stats().Entries)
+	})
+
+	t.Run("results are cached per version", func(tt *testing.T) {
+		r := newCountingResolver()
+		cr, err := NewCachingResolver(r, CacheConfig{})
+		require.NoError(tt, err)
+
+		_, _ = cr.Resolve(context.Background(), "did:example:123", WithVersionID("1"))
+		_, _ = cr.Resolve(context.Background(), "did:example:123", WithVersionID("2"))
+		_, _ = cr.Resolve(context.Background(), "did:example:123", WithVersionID("1"))
+		assert.EqualValues(tt, 2, r.calls.Load())
+
+		cr.Invalidate("did:example:123")
+		assert.Equal(tt, 0, cr.Stats().Entries)
+	})
+}
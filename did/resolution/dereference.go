@@ -0,0 +1,212 @@
+package resolution
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/extrimian/ssi-sdk/did"
+)
+
+// Dereferencer dereferences DID URLs to the resource they identify, as described in
+// https://w3c-ccg.github.io/did-resolution/#dereferencing-algorithm
+type Dereferencer struct {
+	resolver Resolver
+}
+
+// NewDereferencer creates a new Dereferencer that resolves DIDs using the provided resolver
+func NewDereferencer(resolver Resolver) (*Dereferencer, error) {
+	if resolver == nil {
+		return nil, errors.New("resolver cannot be nil")
+	}
+	return &Dereferencer{resolver: resolver}, nil
+}
+
+// Dereference dereferences a DID URL into a DID Document, a verification method, a service or a service endpoint URL.
+// The `versionId` and `versionTime` DID parameters select the version of the DID Document the resource is
+// dereferenced from. On failure, both an error and a result with populated dereferencing metadata are returned.
+func (d Dereferencer) Dereference(ctx context.Context, didURL string, opts ...Option) (*DereferencingResult, error) {
+	// 1. validate the DID URL
+	parsed, err := did.ParseURL(didURL)
+	if err != nil {
+		return dereferencingError(InvalidDIDURLErrorCode, errors.Wrap(err, "parsing did url"))
+	}
+	params, err := parsed.Params()
+	if err != nil {
+		return dereferencingError(InvalidDIDURLErrorCode, err)
+	}
+	versionOpts, err := versionOptions(*parsed)
+	if err != nil {
+		return dereferencingError(InvalidDIDURLErrorCode, err)
+	}
+
+	// 2. resolve the DID, at the version of the DID URL if any
+	resolveOpts := append(append([]Option{}, opts...), versionOpts...)
+	resolved, err := d.resolver.Resolve(ctx, parsed.DID(), resolveOpts...)
+	if err != nil {
+		return dereferencingError(GetErrorCode(err), errors.Wrapf(err, "resolving did: %s", parsed.DID()))
+	}
+	doc := resolved.Document
+
+	// 3. dereference the primary resource
+	if service := params.Get(did.ServiceParameter); service != "" {
+		endpoint, err := selectServiceEndpoint(doc, service, parsed.Path, params.Get(did.RelativeRefParameter))
+		if err != nil {
+			return dereferencingError(NotFoundErrorCode, err)
+		}
+		// a fragment is appended to the endpoint URL, as described in
+		// https://w3c-ccg.github.io/did-resolution/#dereferencing-algorithm-secondary
+		if parsed.HasFragment() {
+			endpoint += "#" + parsed.Fragment
+		}
+		return &DereferencingResult{
+			DereferencingMetadata: DereferencingMetadata{ContentType: URIListContentType},
+			ContentStream:         endpoint,
+			ContentMetadata:       resolved.DocumentMetadata,
+		}, nil
+	}
+	if parsed.Path != "" {
+		return dereferencingError(NotFoundErrorCode, fmt.Errorf("no resource at path: %s", parsed.Path))
+	}
+
+	// the resolved content type is the requested representation, if any
+	contentType := resolved.Metadata.ContentType
+	if contentType == "" {
+		contentType = DocumentContentType(doc)
+	}
+	if !parsed.HasFragment() {
+		return &DereferencingResult{
+			DereferencingMetadata: DereferencingMetadata{ContentType: contentType},
+			ContentStream:         &doc,
+			ContentMetadata:       resolved.DocumentMetadata,
+		}, nil
+	}
+
+	// 4. dereference the secondary resource identified by the fragment
+	resource, err := selectFragment(doc, parsed.DID()+"#"+parsed.Fragment)
+	if err != nil {
+		return dereferencingError(NotFoundErrorCode, err)
+	}
+	return &DereferencingResult{
+		DereferencingMetadata: DereferencingMetadata{ContentType: contentType},
+		ContentStream:         resource,
+		ContentMetadata:       resolved.DocumentMetadata,
+	}, nil
+}
+
+// versionOptions turns the `versionId` and `versionTime` DID parameters of a DID URL into resolution options. The
+// `hl` DID parameter is rejected, as the hash links of DID Documents are not checked.
+func versionOptions(didURL did.URL) ([]Option, error) {
+	if didURL.HashLink() != "" {
+		return nil, errors.Errorf("the %s DID parameter is not supported", did.HashLinkParameter)
+	}
+	var opts []Option
+	if versionID := didURL.VersionID(); versionID != "" {
+		opts = append(opts, WithVersionID(versionID))
+	}
+	if versionTime := didURL.VersionTime(); versionTime != "" {
+		t, err := time.Parse(time.RFC3339, versionTime)
+		if err != nil {
+			return nil, errors.Errorf("invalid versionTime: %s", versionTime)
+		}
+		opts = append(opts, WithVersionTime(t))
+	}
+	return opts, nil
+}
+
+// selectFragment finds the verification method or service with the given fully qualified id. Verification methods
+// embedded in verification relationships are considered as well.
+func selectFragment(doc did.Document, id string) (any, error) {
+	for i := range doc.VerificationMethod {
+		if did.FullyQualifiedVerificationMethodID(doc.ID, doc.VerificationMethod[i].ID) == id {
+			return &doc.VerificationMethod[i], nil
+		}
+	}
+	for _, relationship := range did.Relationships() {
+		for _, vm := range doc.Relationship(relationship).Embedded() {
+			if did.FullyQualifiedVerificationMethodID(doc.ID, vm.ID) == id {
+				return &vm, nil
+			}
+		}
+	}
+	for i := range doc.Services {
+		if did.FullyQualifiedVerificationMethodID(doc.ID, doc.Services[i].ID) == id {
+			return &doc.Services[i], nil
+		}
+	}
+	return nil, fmt.Errorf("no verification method or service found with id: %s", id)
+}
+
+// selectServiceEndpoint finds the service with the given id and constructs an endpoint URL from it as described in
+// https://w3c-ccg.github.io/did-resolution/#service-endpoint-construction
+func selectServiceEndpoint(doc did.Document, serviceID, path, relativeRef string) (string, error) {
+	id := did.FullyQualifiedVerificationMethodID(doc.ID, serviceID)
+	for _, service := range doc.Services {
+		if did.FullyQualifiedVerificationMethodID(doc.ID, service.ID) != id {
+			continue
+		}
+		endpoint, err := firstServiceEndpointURL(service.ServiceEndpoint)
+		if err != nil {
+			return "", errors.Wrapf(err, "service: %s", service.ID)
+		}
+		endpointURL, err := url.Parse(endpoint)
+		if err != nil {
+			return "", errors.Wrapf(err, "parsing service endpoint: %s", endpoint)
+		}
+		if path != "" {
+			endpointURL.Path = strings.TrimSuffix(endpointURL.Path, "/") + path
+		}
+		if relativeRef != "" {
+			ref, err := url.Parse(relativeRef)
+			if err != nil {
+				return "", errors.Wrapf(err, "parsing relative reference: %s", relativeRef)
+			}
+			if ref.IsAbs() {
+				return "", fmt.Errorf("relative reference must not be absolute: %s", relativeRef)
+			}
+			endpointURL.Path = strings.TrimSuffix(endpointURL.Path, "/") + "/" + strings.TrimPrefix(ref.Path, "/")
+			if ref.RawQuery != "" {
+				endpointURL.RawQuery = ref.RawQuery
+			}
+			if ref.Fragment != "" {
+				endpointURL.Fragment = ref.Fragment
+			}
+		}
+		return endpointURL.String(), nil
+	}
+	return "", fmt.Errorf("no service found with id: %s", serviceID)
+}
+
+// firstServiceEndpointURL returns the first URL from a service endpoint, which may be a string, a map or a set
+func firstServiceEndpointURL(endpoint any) (string, error) {
+	switch e := endpoint.(type) {
+	case string:
+		return e, nil
+	case []string:
+		if len(e) > 0 {
+			return e[0], nil
+		}
+	case []any:
+		for _, v := range e {
+			if s, err := firstServiceEndpointURL(v); err == nil {
+				return s, nil
+			}
+		}
+	case map[string]any:
+		if uri, ok := e["uri"].(string); ok {
+			return uri, nil
+		}
+	}
+	return "", errors.New("service endpoint does not contain a URL")
+}
+
+func dereferencingError(code string, err error) (*DereferencingResult, error) {
+	resolutionErr := NewResolutionError(code, err)
+	return &DereferencingResult{
+		DereferencingMetadata: DereferencingMetadata{Error: resolutionErr.Metadata()},
+	}, resolutionErr
+}
@@ -0,0 +1,178 @@
+package resolution
+
+import (
+	"context"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/extrimian/ssi-sdk/did"
+)
+
+type staticResolver struct {
+	docs map[string]did.Document
+}
+
+func (r staticResolver) Resolve(_ context.Context, id string, _ ...Option) (*Result, error) {
+	doc, ok := r.docs[id]
+	if !ok {
+		return nil, NewResolutionErrorf(NotFoundErrorCode, "did not found: %s", id)
+	}
+	return &Result{Document: doc}, nil
+}
+
+func (staticResolver) Methods() []did.Method {
+	return []did.Method{"example"}
+}
+
+// versionedResolver resolves the versions of a DID Document by their versionId, the last one being current
+type versionedResolver struct {
+	versions []did.Document
+}
+
+func (r versionedResolver) Resolve(_ context.Context, id string, opts ...Option) (*Result, error) {
+	options, err := ParseOptions(opts...)
+	if err != nil {
+		return nil, err
+	}
+	for i, doc := range r.versions {
+		versionID := strconv.Itoa(i + 1)
+		if doc.ID == id && (options.VersionID == versionID || (!options.IsVersioned() && i == len(r.versions)-1)) {
+			return &Result{Document: doc, DocumentMetadata: &DocumentMetadata{VersionID: versionID}}, nil
+		}
+	}
+	return nil, NewResolutionErrorf(NotFoundErrorCode, "did not found: %s", id)
+}
+
+func (versionedResolver) Methods() []did.Method {
+	return []did.Method{"example"}
+}
+
+func TestDereferencerVersions(t *testing.T) {
+	versions := []did.Document{
+		{ID: "did:example:123", VerificationMethod: []did.VerificationMethod{{ID: "#key-1", Type: "JsonWebKey2020", Controller: "did:example:old"}}},
+		{ID: "did:example:123", VerificationMethod: []did.VerificationMethod{{ID: "#key-1", Type: "JsonWebKey2020", Controller: "did:example:123"}}},
+	}
+	dereferencer, err := NewDereferencer(versionedResolver{versions: versions})
+	require.NoError(t, err)
+
+	t.Run("the fragment is dereferenced from the requested version", func(tt *testing.T) {
+		result, err := dereferencer.Dereference(context.Background(), "did:example:123?versionId=1#key-1")
+		require.NoError(tt, err)
+		vm, ok := result.ContentStream.(*did.VerificationMethod)
+		require.True(tt, ok)
+		assert.Equal(tt, "did:example:old", vm.Controller)
+		assert.Equal(tt, "1", result.ContentMetadata.VersionID)
+
+		result, err = dereferencer.Dereference(context.Background(), "did:example:123#key-1")
+		require.NoError(tt, err)
+		assert.Equal(tt, "did:example:123", result.ContentStream.(*did.VerificationMethod).Controller)
+	})
+
+	t.Run("unknown version", func(tt *testing.T) {
+		result, err := dereferencer.Dereference(context.Background(), "did:example:123?versionId=3#key-1")
+		assert.Error(tt, err)
+		assert.Equal(tt, NotFoundErrorCode, result.Error.Code)
+	})
+
+	t.Run("invalid versionTime", func(tt *testing.T) {
+		result, err := dereferencer.Dereference(context.Background(), "did:example:123?versionTime=yesterday#key-1")
+		assert.ErrorContains(tt, err, "invalid versionTime: yesterday")
+		assert.Equal(tt, InvalidDIDURLErrorCode, result.Error.Code)
+	})
+
+	t.Run("hash links are not supported", func(tt *testing.T) {
+		result, err := dereferencer.Dereference(context.Background(), "did:example:123?hl=zQmWvQxTqbG2Z9HPJgG57jjwR154cKhbtJenbyYTWkjgF3e#key-1")
+		assert.ErrorContains(tt, err, "the hl DID parameter is not supported")
+		assert.Equal(tt, InvalidDIDURLErrorCode, result.Error.Code)
+	})
+}
+
+func TestDereferencer(t *testing.T) {
+	doc := did.Document{
+		Context: did.KnownDIDContext,
+		ID:      "did:example:123",
+		VerificationMethod: []did.VerificationMethod{
+			{ID: "#key-1", Type: "JsonWebKey2020", Controller: "did:example:123"},
+		},
+		KeyAgreement: []did.VerificationMethodSet{
+			did.VerificationMethod{ID: "did:example:123#key-2", Type: "X25519KeyAgreementKey2020", Controller: "did:example:123"},
+		},
+		Services: []did.Service{
+			{ID: "did:example:123#files", Type: "LinkedDomains", ServiceEndpoint: "https://example.com/files/"},
+			{ID: "#messages", Type: "DIDCommMessaging", ServiceEndpoint: []any{map[string]any{"uri": "https://example.com/dm"}}},
+		},
+	}
+	dereferencer, err := NewDereferencer(staticResolver{docs: map[string]did.Document{doc.ID: doc}})
+	require.NoError(t, err)
+
+	t.Run("nil resolver", func(tt *testing.T) {
+		_, err := NewDereferencer(nil)
+		assert.Error(tt, err)
+	})
+
+	t.Run("did document", func(tt *testing.T) {
+		result, err := dereferencer.Dereference(context.Background(), "did:example:123")
+		require.NoError(tt, err)
+		assert.Equal(tt, DIDJSONLDContentType, result.ContentType)
+		assert.Equal(tt, doc.ID, result.ContentStream.(*did.Document).ID)
+	})
+
+	t.Run("verification method by relative id", func(tt *testing.T) {
+		result, err := dereferencer.Dereference(context.Background(), "did:example:123#key-1")
+		require.NoError(tt, err)
+		assert.Equal(tt, "#key-1", result.ContentStream.(*did.VerificationMethod).ID)
+	})
+
+	t.Run("embedded verification method", func(tt *testing.T) {
+		result, err := dereferencer.Dereference(context.Background(), "did:example:123#key-2")
+		require.NoError(tt, err)
+		assert.Equal(tt, "did:example:123#key-2", result.ContentStream.(*did.VerificationMethod).ID)
+	})
+
+	t.Run("service by fragment", func(tt *testing.T) {
+		result, err := dereferencer.Dereference(context.Background(), "did:example:123#files")
+		require.NoError(tt, err)
+		assert.Equal(tt, "LinkedDomains", result.ContentStream.(*did.Service).Type)
+	})
+
+	t.Run("service endpoint with relative ref", func(tt *testing.T) {
+		result, err := dereferencer.Dereference(context.Background(), "did:example:123?service=files&relativeRef=%2Fresume.pdf")
+		require.NoError(tt, err)
+		assert.Equal(tt, URIListContentType, result.ContentType)
+		assert.Equal(tt, "https://example.com/files/resume.pdf", result.ContentStream)
+	})
+
+	t.Run("service endpoint from a map with fragment", func(tt *testing.T) {
+		result, err := dereferencer.Dereference(context.Background(), "did:example:123?service=messages#inbox")
+		require.NoError(tt, err)
+		assert.Equal(tt, "https://example.com/dm#inbox", result.ContentStream)
+	})
+
+	t.Run("invalid did url", func(tt *testing.T) {
+		result, err := dereferencer.Dereference(context.Background(), "did:example")
+		assert.Error(tt, err)
+		assert.Equal(tt, InvalidDIDURLErrorCode, result.Error.Code)
+	})
+
+	t.Run("unknown did", func(tt *testing.T) {
+		result, err := dereferencer.Dereference(context.Background(), "did:example:456#key-1")
+		assert.Error(tt, err)
+		assert.Equal(tt, NotFoundErrorCode, result.Error.Code)
+	})
+
+	t.Run("unknown fragment", func(tt *testing.T) {
+		result, err := dereferencer.Dereference(context.Background(), "did:example:123#key-3")
+		assert.Error(tt, err)
+		assert.Equal(tt, NotFoundErrorCode, result.Error.Code)
+		assert.True(tt, result.Error.NotFound)
+	})
+
+	t.Run("unknown service", func(tt *testing.T) {
+		result, err := dereferencer.Dereference(context.Background(), "did:example:123?service=unknown")
+		assert.Error(tt, err)
+		assert.Equal(tt, NotFoundErrorCode, result.Error.Code)
+	})
+}
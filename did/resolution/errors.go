@@ -0,0 +1,85 @@
+package resolution
+
+import (
+	"fmt"
+
+	"github.com/pkg/errors"
+)
+
+// ResolutionError is returned by resolvers when a DID cannot be resolved. It carries one of the error codes defined
+// in https://www.w3.org/TR/did-spec-registries/#error so that callers can tell failure cases apart.
+type ResolutionError struct {
+	Code string
+	Err  error
+}
+
+// NewResolutionError wraps an error with a DID Resolution error code
+func NewResolutionError(code string, err error) *ResolutionError {
+	return &ResolutionError{Code: code, Err: err}
+}
+
+// NewResolutionErrorf creates an error with a DID Resolution error code from a formatted message
+func NewResolutionErrorf(code string, msg string, a ...any) *ResolutionError {
+	return &ResolutionError{Code: code, Err: errors.Errorf(msg, a...)}
+}
+
+func (e *ResolutionError) Error() string {
+	if e.Err == nil {
+		return e.Code
+	}
+	return fmt.Sprintf("%s: %s", e.Code, e.Err.Error())
+}
+
+func (e *ResolutionError) Unwrap() error {
+	return e.Err
+}
+
+// Metadata returns the error as it is represented in DID resolution metadata
+func (e *ResolutionError) Metadata() *Error {
+	return &Error{
+		Code:                       e.Code,
+		InvalidDID:                 e.Code == InvalidDIDErrorCode || e.Code == InvalidDIDURLErrorCode,
+		NotFound:                   e.Code == NotFoundErrorCode,
+		RepresentationNotSupported: e.Code == RepresentationNotSupportedErrorCode,
+	}
+}
+
+// GetErrorCode returns the DID Resolution error code carried by err. Errors that do not carry a code are reported
+// as internal errors. A nil error has no code.
+func GetErrorCode(err error) string {
+	if err == nil {
+		return ""
+	}
+	var resolutionErr *ResolutionError
+	if errors.As(err, &resolutionErr) {
+		return resolutionErr.Code
+	}
+	return InternalErrorCode
+}
+
+// ErrorMetadata returns the DID resolution metadata error for err, or nil if err is nil
+func ErrorMetadata(err error) *Error {
+	if err == nil {
+		return nil
+	}
+	var resolutionErr *ResolutionError
+	if errors.As(err, &resolutionErr) {
+		return resolutionErr.Metadata()
+	}
+	return NewResolutionError(InternalErrorCode, err).Metadata()
+}
+
+// IsNotFound returns true if err indicates the DID does not exist
+func IsNotFound(err error) bool {
+	return GetErrorCode(err) == NotFoundErrorCode
+}
+
+// IsInvalidDID returns true if err indicates the DID is not valid
+func IsInvalidDID(err error) bool {
+	return GetErrorCode(err) == InvalidDIDErrorCode
+}
+
+// IsMethodNotSupported returns true if err indicates the resolver does not support the DID's method
+func IsMethodNotSupported(err error) bool {
+	return GetErrorCode(err) == MethodNotSupportedErrorCode
+}
@@ -0,0 +1,266 @@
+package resolution
+
+import (
+	"mime"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/goccy/go-json"
+	"github.com/sirupsen/logrus"
+
+	"github.com/extrimian/ssi-sdk/did"
+)
+
+const (
+	// IdentifiersPath is the path under which a Universal Resolver driver resolves DIDs
+	// https://github.com/decentralized-identity/universal-resolver/blob/main/openapi/openapi.yaml
+	IdentifiersPath = "/1.0/identifiers/"
+
+	// ResultContentType is the media type of a DID Resolution Result
+	// https://w3c-ccg.github.io/did-resolution/#did-resolution-result
+	ResultContentType = `application/ld+json;profile="https://w3id.org/did-resolution"`
+
+	resultProfile = "https://w3id.org/did-resolution"
+)
+
+// Handler is an http.Handler exposing a Resolver with the Universal Resolver driver contract. DIDs are resolved with
+// GET /1.0/identifiers/{did}. Depending on the Accept header, the response is either the DID Resolution Result or
+// the DID Document alone. DID URLs with a path, fragment or service parameter are dereferenced.
+type Handler struct {
+	resolver     Resolver
+	dereferencer *Dereferencer
+}
+
+var _ http.Handler = (*Handler)(nil)
+
+// NewHandler creates a new Handler for the given resolver
+func NewHandler(resolver Resolver) (*Handler, error) {
+	dereferencer, err := NewDereferencer(resolver)
+	if err != nil {
+		return nil, err
+	}
+	return &Handler{resolver: resolver, dereferencer: dereferencer}, nil
+}
+
+func (h Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.Header().Set("Allow", http.MethodGet)
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+	escaped, ok := strings.CutPrefix(r.URL.EscapedPath(), IdentifiersPath)
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+	identifier, err := url.PathUnescape(escaped)
+	if err != nil {
+		h.writeError(w, ResultContentType, NewResolutionError(InvalidDIDErrorCode, err))
+		return
+	}
+	// the query may be sent unescaped, in which case it is part of the request's query rather than its path
+	if r.URL.RawQuery != "" {
+		identifier += "?" + r.URL.RawQuery
+	}
+
+	accept, err := negotiateContentType(r.Header.Get("Accept"))
+	if err != nil {
+		h.writeError(w, ResultContentType, err)
+		return
+	}
+	var opts []Option
+	if accept != ResultContentType {
+		opts = append(opts, WithAccept(accept))
+	}
+	if strings.Contains(r.Header.Get("Cache-Control"), "no-cache") {
+		opts = append(opts, WithNoCache())
+	}
+
+	didURL, err := did.ParseURL(identifier)
+	if err != nil {
+		h.writeError(w, accept, NewResolutionError(InvalidDIDErrorCode, err))
+		return
+	}
+	if didURL.IsDID() || isVersionedDID(*didURL) {
+		versionOpts, err := versionOptions(*didURL)
+		if err != nil {
+			h.writeError(w, accept, NewResolutionError(InvalidDIDURLErrorCode, err))
+			return
+		}
+		h.resolve(w, r, didURL.DID(), accept, append(opts, versionOpts...))
+		return
+	}
+	h.dereference(w, r, identifier, accept, opts)
+}
+
+func (h Handler) resolve(w http.ResponseWriter, r *http.Request, id, accept string, opts []Option) {
+	result, err := h.resolver.Resolve(r.Context(), id, opts...)
+	if err != nil {
+		h.writeError(w, accept, err)
+		return
+	}
+	status := http.StatusOK
+	if result.DocumentMetadata != nil && result.DocumentMetadata.Deactivated {
+		status = http.StatusGone
+	}
+	if accept == ResultContentType {
+		writeJSON(w, ResultContentType, status, result)
+		return
+	}
+	writeRepresentation(w, accept, status, result.Document)
+}
+
+func (h Handler) dereference(w http.ResponseWriter, r *http.Request, didURL, accept string, opts []Option) {
+	result, err := h.dereferencer.Dereference(r.Context(), didURL, opts...)
+	if err != nil {
+		h.writeError(w, accept, err)
+		return
+	}
+	if accept == ResultContentType {
+		writeJSON(w, ResultContentType, http.StatusOK, result)
+		return
+	}
+	if endpoint, ok := result.ContentStream.(string); ok {
+		http.Redirect(w, r, endpoint, http.StatusSeeOther)
+		return
+	}
+	writeRepresentation(w, accept, http.StatusOK, result.ContentStream)
+}
+
+func (Handler) writeError(w http.ResponseWriter, accept string, err error) {
+	code := GetErrorCode(err)
+	status := StatusCodeForError(code)
+	if status >= http.StatusInternalServerError {
+		logrus.WithError(err).Error("resolving DID")
+	}
+	if accept != ResultContentType {
+		http.Error(w, code, status)
+		return
+	}
+	writeJSON(w, ResultContentType, status, Result{
+		Context:  ResultContext,
+		Metadata: Metadata{Error: ErrorMetadata(err)},
+	})
+}
+
+// StatusCodeForError maps a DID Resolution error code to the HTTP status code a Universal Resolver driver responds
+// with https://w3c-ccg.github.io/did-resolution/#bindings-https
+func StatusCodeForError(code string) int {
+	switch code {
+	case "":
+		return http.StatusOK
+	case InvalidDIDErrorCode, InvalidDIDURLErrorCode, InvalidOptionsErrorCode:
+		return http.StatusBadRequest
+	case NotFoundErrorCode:
+		return http.StatusNotFound
+	case RepresentationNotSupportedErrorCode:
+		return http.StatusNotAcceptable
+	case MethodNotSupportedErrorCode:
+		return http.StatusNotImplemented
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// negotiateContentType picks the response media type from an Accept header, preferring the supported media types
+// with the highest quality value. Among media types of equal quality the first listed wins. The DID Resolution
+// Result is returned when no specific representation of the DID Document is preferred.
+func negotiateContentType(accept string) (string, error) {
+	if strings.TrimSpace(accept) == "" {
+		return ResultContentType, nil
+	}
+	var contentType string
+	bestQuality := 0.0
+	for _, part := range strings.Split(accept, ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+		quality := 1.0
+		if q, ok := params["q"]; ok {
+			if quality, err = strconv.ParseFloat(q, 64); err != nil || quality < 0 || quality > 1 {
+				continue
+			}
+		}
+		if quality <= bestQuality {
+			continue
+		}
+		var candidate string
+		switch mediaType {
+		case "application/ld+json":
+			if params["profile"] == resultProfile {
+				candidate = ResultContentType
+			}
+		case DIDJSONLDContentType, DIDJSONContentType, DIDCBORContentType:
+			candidate = mediaType
+		case "application/json", "*/*", "application/*":
+			candidate = ResultContentType
+		}
+		if candidate != "" {
+			contentType, bestQuality = candidate, quality
+		}
+	}
+	if contentType == "" {
+		return "", NewResolutionErrorf(RepresentationNotSupportedErrorCode, "unsupported representation: %s", accept)
+	}
+	return contentType, nil
+}
+
+// isVersionedDID reports whether a DID URL identifies a version of a DID Document rather than a resource to
+// dereference, having no path or fragment and only the `versionId` and `versionTime` DID parameters
+func isVersionedDID(didURL did.URL) bool {
+	if didURL.Path != "" || didURL.HasFragment() || !didURL.HasQuery() {
+		return false
+	}
+	params, err := didURL.Params()
+	if err != nil {
+		return false
+	}
+	for name := range params {
+		if name != did.VersionIDParameter && name != did.VersionTimeParameter {
+			return false
+		}
+	}
+	return true
+}
+
+// writeRepresentation writes a DID Document, or a resource dereferenced from one, in the representation of the
+// content type. Verification methods and services are encoded as the JSON of the representation, or as CBOR.
+func writeRepresentation(w http.ResponseWriter, contentType string, status int, content any) {
+	var bytes []byte
+	var err error
+	switch c := content.(type) {
+	case did.Document:
+		bytes, err = c.Produce(contentType)
+	case *did.Document:
+		bytes, err = c.Produce(contentType)
+	default:
+		if contentType == DIDCBORContentType {
+			bytes, err = did.MarshalCBOR(content)
+		} else {
+			bytes, err = json.Marshal(content)
+		}
+	}
+	if err != nil {
+		logrus.WithError(err).Error("producing DID Document representation")
+		http.Error(w, InternalErrorCode, http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", contentType)
+	w.WriteHeader(status)
+	_, _ = w.Write(bytes)
+}
+
+func writeJSON(w http.ResponseWriter, contentType string, status int, body any) {
+	bytes, err := json.Marshal(body)
+	if err != nil {
+		logrus.WithError(err).Error("marshalling resolution response")
+		http.Error(w, InternalErrorCode, http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", contentType)
+	w.WriteHeader(status)
+	_, _ = w.Write(bytes)
+}
@@ -0,0 +1,188 @@
+package resolution
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/goccy/go-json"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/extrimian/ssi-sdk/did"
+)
+
+func TestHandler(t *testing.T) {
+	doc := did.Document{
+		Context: did.KnownDIDContext,
+		ID:      "did:example:123",
+		Services: []did.Service{
+			{ID: "#files", Type: "LinkedDomains", ServiceEndpoint: "https://example.com/files/"},
+		},
+	}
+	handler, err := NewHandler(staticResolver{docs: map[string]did.Document{doc.ID: doc}})
+	require.NoError(t, err)
+
+	serve := func(path, accept string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		if accept != "" {
+			req.Header.Set("Accept", accept)
+		}
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("nil resolver", func(tt *testing.T) {
+		_, err := NewHandler(nil)
+		assert.Error(tt, err)
+	})
+
+	t.Run("resolution result by default", func(tt *testing.T) {
+		w := serve("/1.0/identifiers/did:example:123", "")
+		assert.Equal(tt, http.StatusOK, w.Code)
+		assert.Equal(tt, ResultContentType, w.Header().Get("Content-Type"))
+
+		result, err := ParseDIDResolution(w.Body.Bytes())
+		require.NoError(tt, err)
+		assert.Equal(tt, doc.ID, result.Document.ID)
+	})
+
+	t.Run("did document representation", func(tt *testing.T) {
+		w := serve("/1.0/identifiers/did%3Aexample%3A123", DIDJSONLDContentType)
+		assert.Equal(tt, http.StatusOK, w.Code)
+		assert.Equal(tt, DIDJSONLDContentType, w.Header().Get("Content-Type"))
+
+		var resolved did.Document
+		require.NoError(tt, json.Unmarshal(w.Body.Bytes(), &resolved))
+		assert.Equal(tt, doc.ID, resolved.ID)
+	})
+
+	t.Run("did document representation without context", func(tt *testing.T) {
+		w := serve("/1.0/identifiers/did:example:123", DIDJSONContentType)
+		assert.Equal(tt, http.StatusOK, w.Code)
+		assert.Equal(tt, DIDJSONContentType, w.Header().Get("Content-Type"))
+		assert.NotContains(tt, w.Body.String(), "@context")
+	})
+
+	t.Run("cbor did document representation", func(tt *testing.T) {
+		w := serve("/1.0/identifiers/did:example:123", DIDCBORContentType)
+		assert.Equal(tt, http.StatusOK, w.Code)
+		assert.Equal(tt, DIDCBORContentType, w.Header().Get("Content-Type"))
+
+		resolved, err := did.ConsumeDocument(DIDCBORContentType, w.Body.Bytes())
+		require.NoError(tt, err)
+		assert.Equal(tt, doc, *resolved)
+
+		// dereferenced resources are encoded as CBOR too
+		w = serve("/1.0/identifiers/did:example:123%23files", DIDCBORContentType)
+		assert.Equal(tt, http.StatusOK, w.Code)
+		var service did.Service
+		require.NoError(tt, did.UnmarshalCBOR(w.Body.Bytes(), &service))
+		assert.Equal(tt, doc.Services[0], service)
+	})
+
+	t.Run("representation with the highest quality", func(tt *testing.T) {
+		w := serve("/1.0/identifiers/did:example:123", ResultContentType+";q=0.5, "+DIDJSONContentType+";q=0.9, application/xml")
+		assert.Equal(tt, http.StatusOK, w.Code)
+		assert.Equal(tt, DIDJSONContentType, w.Header().Get("Content-Type"))
+
+		// representations with a quality of zero are not acceptable
+		w = serve("/1.0/identifiers/did:example:123", DIDCBORContentType+";q=0, "+DIDJSONLDContentType+";q=0.1")
+		assert.Equal(tt, DIDJSONLDContentType, w.Header().Get("Content-Type"))
+
+		w = serve("/1.0/identifiers/did:example:123", DIDJSONContentType+";q=0")
+		assert.Equal(tt, http.StatusNotAcceptable, w.Code)
+	})
+
+	t.Run("unsupported representation", func(tt *testing.T) {
+		w := serve("/1.0/identifiers/did:example:123", "application/xml")
+		assert.Equal(tt, http.StatusNotAcceptable, w.Code)
+	})
+
+	t.Run("invalid did", func(tt *testing.T) {
+		w := serve("/1.0/identifiers/did:example", "")
+		assert.Equal(tt, http.StatusBadRequest, w.Code)
+
+		result, err := ParseDIDResolution(w.Body.Bytes())
+		require.NoError(tt, err)
+		assert.Equal(tt, InvalidDIDErrorCode, result.Error.Code)
+	})
+
+	t.Run("not found", func(tt *testing.T) {
+		w := serve("/1.0/identifiers/did:example:456", "")
+		assert.Equal(tt, http.StatusNotFound, w.Code)
+
+		result, err := ParseDIDResolution(w.Body.Bytes())
+		require.NoError(tt, err)
+		assert.True(tt, result.Error.NotFound)
+	})
+
+	t.Run("not found without the resolution result", func(tt *testing.T) {
+		w := serve("/1.0/identifiers/did:example:456", DIDJSONContentType)
+		assert.Equal(tt, http.StatusNotFound, w.Code)
+	})
+
+	t.Run("dereferences a service", func(tt *testing.T) {
+		w := serve("/1.0/identifiers/did:example:123%3Fservice%3Dfiles", DIDJSONLDContentType)
+		assert.Equal(tt, http.StatusSeeOther, w.Code)
+		assert.Equal(tt, "https://example.com/files/", w.Header().Get("Location"))
+	})
+
+	t.Run("wrong path and method", func(tt *testing.T) {
+		assert.Equal(tt, http.StatusNotFound, serve("/identifiers/did:example:123", "").Code)
+
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/1.0/identifiers/did:example:123", nil))
+		assert.Equal(tt, http.StatusMethodNotAllowed, w.Code)
+	})
+}
+
+func TestHandlerVersions(t *testing.T) {
+	versions := []did.Document{
+		{ID: "did:example:123", VerificationMethod: []did.VerificationMethod{{ID: "#key-1", Type: "JsonWebKey2020", Controller: "did:example:old"}}},
+		{ID: "did:example:123", VerificationMethod: []did.VerificationMethod{{ID: "#key-1", Type: "JsonWebKey2020", Controller: "did:example:123"}}},
+	}
+	handler, err := NewHandler(versionedResolver{versions: versions})
+	require.NoError(t, err)
+
+	serve := func(path, accept string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		if accept != "" {
+			req.Header.Set("Accept", accept)
+		}
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("a versioned DID resolves the version", func(tt *testing.T) {
+		w := serve("/1.0/identifiers/did:example:123%3FversionId%3D1", "")
+		assert.Equal(tt, http.StatusOK, w.Code)
+		result, err := ParseDIDResolution(w.Body.Bytes())
+		require.NoError(tt, err)
+		assert.Equal(tt, "did:example:old", result.Document.VerificationMethod[0].Controller)
+	})
+
+	t.Run("a fragment is dereferenced from the version", func(tt *testing.T) {
+		w := serve("/1.0/identifiers/did:example:123%3FversionId%3D1%23key-1", DIDJSONContentType)
+		assert.Equal(tt, http.StatusOK, w.Code)
+		var vm did.VerificationMethod
+		require.NoError(tt, json.Unmarshal(w.Body.Bytes(), &vm))
+		assert.Equal(tt, "did:example:old", vm.Controller)
+	})
+
+	t.Run("invalid versionTime", func(tt *testing.T) {
+		w := serve("/1.0/identifiers/did:example:123%3FversionTime%3Dyesterday", "")
+		assert.Equal(tt, http.StatusBadRequest, w.Code)
+	})
+}
+
+func TestStatusCodeForError(t *testing.T) {
+	assert.Equal(t, http.StatusOK, StatusCodeForError(""))
+	assert.Equal(t, http.StatusBadRequest, StatusCodeForError(InvalidDIDErrorCode))
+	assert.Equal(t, http.StatusNotFound, StatusCodeForError(NotFoundErrorCode))
+	assert.Equal(t, http.StatusNotAcceptable, StatusCodeForError(RepresentationNotSupportedErrorCode))
+	assert.Equal(t, http.StatusNotImplemented, StatusCodeForError(MethodNotSupportedErrorCode))
+	assert.Equal(t, http.StatusInternalServerError, StatusCodeForError(InternalErrorCode))
+}
@@ -2,11 +2,32 @@ package resolution
 
 import (
 	"reflect"
+	"time"
+
+	"github.com/goccy/go-json"
+	"github.com/pkg/errors"
 
 	"github.com/extrimian/ssi-sdk/did"
 	"github.com/extrimian/ssi-sdk/util"
 )
 
+const (
+	// ResultContext is the JSON-LD context of a DID Resolution Result
+	ResultContext = "https://w3id.org/did-resolution/v1"
+
+	// DIDJSONContentType is the media type of a DID Document without JSON-LD context
+	// https://www.w3.org/TR/did-spec-registries/#application-did-json
+	DIDJSONContentType = did.JSONMediaType
+	// DIDJSONLDContentType is the media type of a DID Document with JSON-LD context
+	// https://www.w3.org/TR/did-spec-registries/#application-did-ld-json
+	DIDJSONLDContentType = did.JSONLDMediaType
+	// DIDCBORContentType is the media type of a DID Document encoded as deterministic CBOR
+	// https://www.w3.org/TR/did-spec-registries/#application-did-cbor
+	DIDCBORContentType = did.CBORMediaType
+	// URIListContentType is the media type of a dereferenced service endpoint URL
+	URIListContentType = "text/uri-list"
+)
+
 // Result encapsulates the tuple of a DID resolution https://www.w3.org/TR/did-core/#did-resolution
 type Result struct {
 	Context           string `json:"@context,omitempty"`
@@ -15,6 +36,47 @@ type Result struct {
 	*DocumentMetadata `json:"didDocumentMetadata,omitempty"`
 }
 
+// NewResult builds the Result of a successful resolution which began at the given start time, populating the
+// resolution metadata. A nil document metadata is replaced by empty metadata.
+func NewResult(doc did.Document, docMetadata *DocumentMetadata, start time.Time) *Result {
+	if docMetadata == nil {
+		docMetadata = new(DocumentMetadata)
+	}
+	now := time.Now()
+	return &Result{
+		Context: ResultContext,
+		Metadata: Metadata{
+			ContentType: DocumentContentType(doc),
+			Duration:    now.Sub(start).Milliseconds(),
+			Retrieved:   util.AsRFC3339Timestamp(now),
+		},
+		Document:         doc,
+		DocumentMetadata: docMetadata,
+	}
+}
+
+// DocumentContentType returns the media type of a DID Document's JSON representation, which depends on whether the
+// document has a JSON-LD context
+func DocumentContentType(doc did.Document) string {
+	if doc.Context != nil {
+		return DIDJSONLDContentType
+	}
+	return DIDJSONContentType
+}
+
+// DocumentStream produces the DID Document in the representation of the result's content type, which is the one
+// requested with the accept option, as the `didDocumentStream` of https://w3c-ccg.github.io/did-resolution/#resolving
+func (r *Result) DocumentStream() ([]byte, error) {
+	if r == nil {
+		return nil, errors.New("result cannot be empty")
+	}
+	contentType := r.Metadata.ContentType
+	if contentType == "" {
+		contentType = DocumentContentType(r.Document)
+	}
+	return r.Document.Produce(contentType)
+}
+
 func (r *Result) IsEmpty() bool {
 	if r == nil {
 		return true
@@ -68,6 +130,18 @@ func (s *DocumentMetadata) IsValid() bool {
 	return util.NewValidator().Struct(s) == nil
 }
 
+// Error codes for resolution and dereferencing https://www.w3.org/TR/did-spec-registries/#error
+const (
+	InvalidDIDErrorCode                 = "invalidDid"
+	InvalidDIDURLErrorCode              = "invalidDidUrl"
+	InvalidDIDDocumentErrorCode         = "invalidDidDocument"
+	NotFoundErrorCode                   = "notFound"
+	RepresentationNotSupportedErrorCode = "representationNotSupported"
+	MethodNotSupportedErrorCode         = "methodNotSupported"
+	InvalidOptionsErrorCode             = "invalidOptions"
+	InternalErrorCode                   = "internalError"
+)
+
 // Error https://www.w3.org/TR/did-core/#did-resolution-metadata
 type Error struct {
 	Code                       string `json:"code"`
@@ -76,8 +150,45 @@ type Error struct {
 	RepresentationNotSupported bool   `json:"representationNotSupported"`
 }
 
+// UnmarshalJSON accepts both the object form above and the plain error code string returned by Universal Resolver
+// drivers, e.g. "error": "notFound"
+func (e *Error) UnmarshalJSON(data []byte) error {
+	var code string
+	if err := json.Unmarshal(data, &code); err == nil {
+		*e = *NewResolutionError(code, nil).Metadata()
+		return nil
+	}
+	type errorAlias Error
+	var alias errorAlias
+	if err := json.Unmarshal(data, &alias); err != nil {
+		return err
+	}
+	*e = Error(alias)
+	return nil
+}
+
 // Metadata https://www.w3.org/TR/did-core/#did-resolution-metadata
 type Metadata struct {
 	ContentType string `json:"contentType,omitempty"`
 	Error       *Error `json:"error,omitempty"`
+	// Duration is the time, in milliseconds, the resolution took
+	Duration int64 `json:"duration,omitempty"`
+	// Retrieved is the RFC3339 timestamp of when the resolution completed
+	Retrieved string `json:"retrieved,omitempty"`
+}
+
+// DereferencingResult encapsulates the tuple of a DID URL dereferencing
+// https://w3c-ccg.github.io/did-resolution/#did-url-dereferencing
+type DereferencingResult struct {
+	Context               string `json:"@context,omitempty"`
+	DereferencingMetadata `json:"dereferencingMetadata"`
+	// ContentStream is one of a *did.Document, *did.VerificationMethod, *did.Service or a service endpoint URL string
+	ContentStream   any               `json:"contentStream,omitempty"`
+	ContentMetadata *DocumentMetadata `json:"contentMetadata,omitempty"`
+}
+
+// DereferencingMetadata https://www.w3.org/TR/did-core/#did-url-dereferencing-metadata
+type DereferencingMetadata struct {
+	ContentType string `json:"contentType,omitempty"`
+	Error       *Error `json:"error,omitempty"`
 }
@@ -0,0 +1,151 @@
+package resolution
+
+import (
+	"time"
+
+	"github.com/extrimian/ssi-sdk/did"
+)
+
+// The following types are the resolution options defined in
+// https://www.w3.org/TR/did-spec-registries/#did-resolution-options and the versioning DID parameters defined in
+// https://www.w3.org/TR/did-core/#did-parameters. Each may be passed as an Option to Resolver.Resolve.
+type (
+	// AcceptOption is the media type of the preferred representation of the DID Document
+	AcceptOption string
+	// VersionIDOption identifies a specific version of the DID Document to resolve
+	VersionIDOption string
+	// VersionTimeOption identifies the version of the DID Document that was valid at the given time
+	VersionTimeOption time.Time
+	// NoCacheOption requests that cached results are not used, as described in
+	// https://w3c-ccg.github.io/did-resolution/#caching
+	NoCacheOption bool
+)
+
+// WithAccept requests a representation of the DID Document, e.g. application/did+ld+json
+func WithAccept(contentType string) Option {
+	return AcceptOption(contentType)
+}
+
+// WithVersionID requests a specific version of the DID Document
+func WithVersionID(versionID string) Option {
+	return VersionIDOption(versionID)
+}
+
+// WithVersionTime requests the version of the DID Document that was valid at the given time
+func WithVersionTime(versionTime time.Time) Option {
+	return VersionTimeOption(versionTime)
+}
+
+// WithNoCache requests a fresh resolution, bypassing any caches
+func WithNoCache() Option {
+	return NoCacheOption(true)
+}
+
+// Options are the typed resolution options collected from a list of Option values
+type Options struct {
+	Accept      string
+	VersionID   string
+	VersionTime *time.Time
+	NoCache     bool
+}
+
+// supportedRepresentations are the media types resolvers are able to produce
+var supportedRepresentations = map[string]bool{
+	DIDJSONContentType:   true,
+	DIDJSONLDContentType: true,
+	DIDCBORContentType:   true,
+}
+
+// ParseOptions collects resolution options into an Options value. Nil options are ignored. Unknown options result in
+// an invalidOptions error, and a request for an unsupported representation results in a representationNotSupported
+// error.
+func ParseOptions(opts ...Option) (*Options, error) {
+	var options Options
+	for _, opt := range opts {
+		switch o := opt.(type) {
+		case nil:
+			continue
+		case AcceptOption:
+			if !supportedRepresentations[string(o)] {
+				return nil, NewResolutionErrorf(RepresentationNotSupportedErrorCode, "unsupported representation: %s", o)
+			}
+			options.Accept = string(o)
+		case VersionIDOption:
+			if o == "" {
+				return nil, NewResolutionErrorf(InvalidOptionsErrorCode, "versionId cannot be empty")
+			}
+			options.VersionID = string(o)
+		case VersionTimeOption:
+			t := time.Time(o)
+			if t.IsZero() {
+				return nil, NewResolutionErrorf(InvalidOptionsErrorCode, "versionTime cannot be empty")
+			}
+			options.VersionTime = &t
+		case NoCacheOption:
+			options.NoCache = bool(o)
+		default:
+			return nil, NewResolutionErrorf(InvalidOptionsErrorCode, "unknown resolution option: %T", opt)
+		}
+	}
+	if options.VersionID != "" && options.VersionTime != nil {
+		return nil, NewResolutionErrorf(InvalidOptionsErrorCode, "versionId and versionTime cannot both be set")
+	}
+	return &options, nil
+}
+
+// IsVersioned returns true if a specific version of the DID Document was requested
+func (o Options) IsVersioned() bool {
+	return o.VersionID != "" || o.VersionTime != nil
+}
+
+// RejectVersioned returns an invalidOptions error if a specific version was requested. It is meant for methods
+// whose DID Documents are derived from the DID itself and therefore have no history.
+func (o Options) RejectVersioned(method did.Method) error {
+	if o.IsVersioned() {
+		return NewResolutionErrorf(InvalidOptionsErrorCode, "did:%s does not support versionId or versionTime", method)
+	}
+	return nil
+}
+
+// FormatVersionTime formats the requested version time as it appears in the `versionTime` DID parameter
+func (o Options) FormatVersionTime() string {
+	if o.VersionTime == nil {
+		return ""
+	}
+	return o.VersionTime.UTC().Format(time.RFC3339)
+}
+
+// CheckVersion returns a notFound error unless the DID Document Metadata confirms that the document is the requested
+// version: its `versionId` must equal the requested one, and its `updated` time, or `created` time if it was never
+// updated, must be at or before the requested `versionTime`. Unversioned requests are always satisfied.
+func (o Options) CheckVersion(metadata *DocumentMetadata) error {
+	if !o.IsVersioned() {
+		return nil
+	}
+	if metadata == nil {
+		metadata = new(DocumentMetadata)
+	}
+	if o.VersionID != "" {
+		if metadata.VersionID != o.VersionID {
+			return NewResolutionErrorf(NotFoundErrorCode, "version<%s> not found", o.VersionID)
+		}
+		return nil
+	}
+	versionTime := metadata.Updated
+	if versionTime == "" {
+		versionTime = metadata.Created
+	}
+	t, err := time.Parse(time.RFC3339, versionTime)
+	if err != nil || t.After(*o.VersionTime) {
+		return NewResolutionErrorf(NotFoundErrorCode, "version at time<%s> not found", o.FormatVersionTime())
+	}
+	return nil
+}
+
+// Apply sets the content type of the result to the requested representation, if any, and returns the result
+func (o Options) Apply(result *Result) *Result {
+	if result != nil && o.Accept != "" {
+		result.Metadata.ContentType = o.Accept
+	}
+	return result
+}
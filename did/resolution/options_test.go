@@ -0,0 +1,113 @@
+package resolution
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/extrimian/ssi-sdk/did"
+)
+
+func TestParseOptions(t *testing.T) {
+	t.Run("no options", func(tt *testing.T) {
+		options, err := ParseOptions()
+		require.NoError(tt, err)
+		assert.Equal(tt, Options{}, *options)
+
+		options, err = ParseOptions(nil)
+		require.NoError(tt, err)
+		assert.False(tt, options.IsVersioned())
+	})
+
+	t.Run("all options", func(tt *testing.T) {
+		versionTime := time.Date(2023, 1, 2, 3, 4, 5, 0, time.UTC)
+		options, err := ParseOptions(WithAccept(DIDJSONContentType), WithVersionTime(versionTime), WithNoCache())
+		require.NoError(tt, err)
+		assert.Equal(tt, DIDJSONContentType, options.Accept)
+		assert.Equal(tt, "2023-01-02T03:04:05Z", options.FormatVersionTime())
+		assert.True(tt, options.NoCache)
+		assert.True(tt, options.IsVersioned())
+
+		err = options.RejectVersioned("key")
+		assert.Error(tt, err)
+		assert.Equal(tt, InvalidOptionsErrorCode, GetErrorCode(err))
+	})
+
+	t.Run("unsupported representation", func(tt *testing.T) {
+		_, err := ParseOptions(WithAccept("application/xml"))
+		assert.Error(tt, err)
+		assert.Equal(tt, RepresentationNotSupportedErrorCode, GetErrorCode(err))
+	})
+
+	t.Run("invalid options", func(tt *testing.T) {
+		for _, opts := range [][]Option{
+			{"unknown"},
+			{WithVersionID("")},
+			{WithVersionTime(time.Time{})},
+			{WithVersionID("1"), WithVersionTime(time.Now())},
+		} {
+			_, err := ParseOptions(opts...)
+			assert.Error(tt, err)
+			assert.Equal(tt, InvalidOptionsErrorCode, GetErrorCode(err))
+		}
+	})
+
+	t.Run("document stream in the requested representation", func(tt *testing.T) {
+		options, err := ParseOptions(WithAccept(DIDCBORContentType))
+		require.NoError(tt, err)
+		doc := did.Document{Context: did.KnownDIDContext, ID: "did:example:123"}
+		result := options.Apply(&Result{Metadata: Metadata{ContentType: DIDJSONLDContentType}, Document: doc})
+
+		stream, err := result.DocumentStream()
+		require.NoError(tt, err)
+		expected, err := doc.Produce(DIDCBORContentType)
+		require.NoError(tt, err)
+		assert.Equal(tt, expected, stream)
+	})
+
+	t.Run("apply sets the content type", func(tt *testing.T) {
+		result := &Result{Metadata: Metadata{ContentType: DIDJSONLDContentType}}
+		Options{Accept: DIDJSONContentType}.Apply(result)
+		assert.Equal(tt, DIDJSONContentType, result.ContentType)
+
+		Options{}.Apply(result)
+		assert.Equal(tt, DIDJSONContentType, result.ContentType)
+	})
+}
+
+func TestCheckVersion(t *testing.T) {
+	versionTime := time.Date(2023, 1, 2, 0, 0, 0, 0, time.UTC)
+
+	t.Run("unversioned requests", func(tt *testing.T) {
+		assert.NoError(tt, Options{}.CheckVersion(nil))
+	})
+
+	t.Run("version id", func(tt *testing.T) {
+		options := Options{VersionID: "2"}
+		assert.NoError(tt, options.CheckVersion(&DocumentMetadata{VersionID: "2"}))
+		for _, metadata := range []*DocumentMetadata{nil, {}, {VersionID: "3"}} {
+			err := options.CheckVersion(metadata)
+			assert.Error(tt, err)
+			assert.True(tt, IsNotFound(err))
+		}
+	})
+
+	t.Run("version time", func(tt *testing.T) {
+		options := Options{VersionTime: &versionTime}
+		assert.NoError(tt, options.CheckVersion(&DocumentMetadata{Created: "2023-01-02T00:00:00Z"}))
+		assert.NoError(tt, options.CheckVersion(&DocumentMetadata{Created: "2022-01-01T00:00:00Z", Updated: "2023-01-01T00:00:00Z"}))
+		for _, metadata := range []*DocumentMetadata{
+			nil,
+			{},
+			{Created: "2023-01-03T00:00:00Z"},
+			{Created: "2022-01-01T00:00:00Z", Updated: "2023-01-03T00:00:00Z"},
+			{Created: "not a time"},
+		} {
+			err := options.CheckVersion(metadata)
+			assert.Error(tt, err)
+			assert.True(tt, IsNotFound(err))
+		}
+	})
+}
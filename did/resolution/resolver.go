@@ -4,7 +4,6 @@ import (
 	"context"
 	gocrypto "crypto"
 	"fmt"
-	"strings"
 
 	"github.com/goccy/go-json"
 	"github.com/pkg/errors"
@@ -53,12 +52,12 @@ func NewResolver(resolvers ...Resolver) (*MultiMethodResolver, error) {
 func (dr MultiMethodResolver) Resolve(ctx context.Context, id string, opts ...Option) (*Result, error) {
 	method, err := GetMethodForDID(id)
 	if err != nil {
-		return nil, errors.Wrap(err, "getting method for DID before resolving")
+		return nil, NewResolutionError(InvalidDIDErrorCode, errors.Wrap(err, "getting method for DID before resolving"))
 	}
 	if resolver, ok := dr.resolvers[method]; ok {
-		return resolver.Resolve(ctx, id, opts)
+		return resolver.Resolve(ctx, id, opts...)
 	}
-	return nil, fmt.Errorf("unsupported method: %s", method)
+	return nil, NewResolutionErrorf(MethodNotSupportedErrorCode, "unsupported method: %s", method)
 }
 
 func (dr MultiMethodResolver) Methods() []did.Method {
@@ -67,11 +66,27 @@ func (dr MultiMethodResolver) Methods() []did.Method {
 
 // GetMethodForDID provides the method for the given did string
 func GetMethodForDID(id string) (did.Method, error) {
-	split := strings.Split(id, ":")
-	if len(split) < 3 {
-		return "", fmt.Errorf("not a valid did: %s", id)
+	didURL, err := did.ParseURL(id)
+	if err != nil {
+		return "", errors.Wrapf(err, "not a valid did: %s", id)
+	}
+	return didURL.Method, nil
+}
+
+// ValidateResult validates the DID Document of a resolution result against https://www.w3.org/TR/did-core, as
+// resolvers do before returning it. The documents of deactivated DIDs may be empty, in which case they are not
+// validated. Invalid documents are reported with the invalidDidDocument error code, wrapping the did.ValidationErrors.
+func ValidateResult(result *Result) error {
+	if result == nil {
+		return errors.New("resolution result cannot be empty")
+	}
+	if result.DocumentMetadata != nil && result.DocumentMetadata.Deactivated && result.Document.IsEmpty() {
+		return nil
+	}
+	if err := result.Document.Validate(); err != nil {
+		return NewResolutionError(InvalidDIDDocumentErrorCode, err)
 	}
-	return did.Method(split[1]), nil
+	return nil
 }
 
 // ParseDIDResolution attempts to parse a DID Resolution Result or a DID Document
@@ -80,13 +95,17 @@ func ParseDIDResolution(resolvedDID []byte) (*Result, error) {
 		return nil, errors.New("cannot parse empty resolved DID")
 	}
 
-	// first try to parse as a DID Resolver Result
-	var result Result
-	if err := json.Unmarshal(resolvedDID, &result); err == nil {
-		if result.IsEmpty() {
-			return nil, errors.New("empty DID Resolution Result")
+	// first try to parse as a DID Resolver Result, which is recognized by its members since a DID Document may have
+	// an @context as well
+	var members map[string]json.RawMessage
+	if err := json.Unmarshal(resolvedDID, &members); err == nil && isResolutionResult(members) {
+		var result Result
+		if err = json.Unmarshal(resolvedDID, &result); err == nil {
+			if result.IsEmpty() {
+				return nil, errors.New("empty DID Resolution Result")
+			}
+			return &result, nil
 		}
-		return &result, err
 	}
 
 	// next try to parse as a DID Document
@@ -102,12 +121,21 @@ func ParseDIDResolution(resolvedDID []byte) (*Result, error) {
 	return nil, errors.New("could not parse DID Resolution Result or DID Document")
 }
 
+func isResolutionResult(members map[string]json.RawMessage) bool {
+	for _, member := range []string{"didDocument", "didResolutionMetadata", "didDocumentMetadata"} {
+		if _, ok := members[member]; ok {
+			return true
+		}
+	}
+	return false
+}
+
 // ResolveKeyForDID resolves a public key from a DID for a given KID.
 func ResolveKeyForDID(ctx context.Context, resolver Resolver, id, kid string) (gocrypto.PublicKey, error) {
 	if resolver == nil {
 		return nil, errors.New("resolution cannot be empty")
 	}
-	resolved, err := resolver.Resolve(ctx, id, nil)
+	resolved, err := resolver.Resolve(ctx, id)
 	if err != nil {
 		return nil, errors.Wrapf(err, "resolving DID: %s", id)
 	}
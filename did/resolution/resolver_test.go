@@ -1,10 +1,16 @@
 package resolution
 
 import (
+	"context"
 	"testing"
 	"time"
 
+	"github.com/pkg/errors"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/extrimian/ssi-sdk/did"
+	"github.com/extrimian/ssi-sdk/util"
 )
 
 func TestDIDDocumentMetadata_IsValid(t *testing.T) {
@@ -51,4 +57,101 @@ func TestParseDIDResolution(t *testing.T) {
 		assert.False(tt, resolutionResult.Document.IsEmpty())
 		assert.Equal(tt, "did:ion:test", resolutionResult.Document.ID)
 	})
+
+	t.Run("did document with a context", func(tt *testing.T) {
+		resolutionResult, err := ParseDIDResolution([]byte(`{"@context": "https://www.w3.org/ns/did/v1", "id": "did:web:example.com"}`))
+		assert.NoError(tt, err)
+		assert.Equal(tt, "did:web:example.com", resolutionResult.Document.ID)
+	})
+}
+
+func TestMultiMethodResolver(t *testing.T) {
+	r, err := NewResolver(staticResolver{docs: map[string]did.Document{"did:example:123": {ID: "did:example:123"}}})
+	require.NoError(t, err)
+
+	t.Run("resolves a known did", func(tt *testing.T) {
+		result, err := r.Resolve(context.Background(), "did:example:123")
+		assert.NoError(tt, err)
+		assert.Equal(tt, "did:example:123", result.Document.ID)
+	})
+
+	t.Run("invalid did", func(tt *testing.T) {
+		_, err := r.Resolve(context.Background(), "did:example")
+		assert.Error(tt, err)
+		assert.True(tt, IsInvalidDID(err))
+		assert.Equal(tt, &Error{Code: InvalidDIDErrorCode, InvalidDID: true}, ErrorMetadata(err))
+	})
+
+	t.Run("unsupported method", func(tt *testing.T) {
+		_, err := r.Resolve(context.Background(), "did:other:123")
+		assert.Error(tt, err)
+		assert.True(tt, IsMethodNotSupported(err))
+	})
+
+	t.Run("not found", func(tt *testing.T) {
+		_, err := r.Resolve(context.Background(), "did:example:456")
+		assert.Error(tt, err)
+		assert.True(tt, IsNotFound(err))
+		assert.Equal(tt, &Error{Code: NotFoundErrorCode, NotFound: true}, ErrorMetadata(err))
+	})
+}
+
+func TestResolutionError(t *testing.T) {
+	t.Run("codes survive wrapping", func(tt *testing.T) {
+		err := errors.Wrap(NewResolutionErrorf(NotFoundErrorCode, "missing %s", "did:example:123"), "resolving")
+		assert.Equal(tt, NotFoundErrorCode, GetErrorCode(err))
+		assert.Equal(tt, "resolving: notFound: missing did:example:123", err.Error())
+	})
+
+	t.Run("errors without a code are internal errors", func(tt *testing.T) {
+		assert.Equal(tt, InternalErrorCode, GetErrorCode(errors.New("boom")))
+		assert.Equal(tt, &Error{Code: InternalErrorCode}, ErrorMetadata(errors.New("boom")))
+	})
+
+	t.Run("nil error", func(tt *testing.T) {
+		assert.Empty(tt, GetErrorCode(nil))
+		assert.Nil(tt, ErrorMetadata(nil))
+	})
+}
+
+func TestNewResult(t *testing.T) {
+	start := time.Now().Add(-time.Second)
+	result := NewResult(did.Document{ID: "did:example:123"}, nil, start)
+	assert.Equal(t, DIDJSONContentType, result.ContentType)
+	assert.GreaterOrEqual(t, result.Duration, int64(1000))
+	assert.True(t, util.IsRFC3339Timestamp(result.Retrieved))
+	assert.NotNil(t, result.DocumentMetadata)
+
+	result = NewResult(did.Document{Context: did.KnownDIDContext, ID: "did:example:123"}, &DocumentMetadata{Deactivated: true}, start)
+	assert.Equal(t, DIDJSONLDContentType, result.ContentType)
+	assert.True(t, result.DocumentMetadata.Deactivated)
+}
+
+func TestValidateResult(t *testing.T) {
+	t.Run("valid document", func(tt *testing.T) {
+		result := NewResult(did.Document{ID: "did:example:123"}, nil, time.Now())
+		assert.NoError(tt, ValidateResult(result))
+	})
+
+	t.Run("invalid document", func(tt *testing.T) {
+		result := NewResult(did.Document{ID: "did:example:123", Authentication: []did.VerificationMethodSet{"#key-1"}}, nil, time.Now())
+		err := ValidateResult(result)
+		assert.Equal(tt, InvalidDIDDocumentErrorCode, GetErrorCode(err))
+		var validationErrors did.ValidationErrors
+		require.True(tt, errors.As(err, &validationErrors))
+		require.Len(tt, validationErrors, 1)
+		assert.Equal(tt, "$.authentication[0]", validationErrors[0].Path)
+	})
+
+	t.Run("deactivated DIDs may have empty documents", func(tt *testing.T) {
+		result := NewResult(did.Document{}, &DocumentMetadata{Deactivated: true}, time.Now())
+		assert.NoError(tt, ValidateResult(result))
+
+		result = NewResult(did.Document{}, nil, time.Now())
+		assert.Equal(tt, InvalidDIDDocumentErrorCode, GetErrorCode(ValidateResult(result)))
+	})
+
+	t.Run("empty result", func(tt *testing.T) {
+		assert.ErrorContains(tt, ValidateResult(nil), "resolution result cannot be empty")
+	})
 }
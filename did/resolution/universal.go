@@ -0,0 +1,169 @@
+package resolution
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/extrimian/ssi-sdk/did"
+)
+
+// maxResultSize is the largest response body read from a Universal Resolver
+const maxResultSize = 1 << 20
+
+// UniversalResolver is a Resolver backed by a remote Universal Resolver, or by any service implementing the
+// Universal Resolver driver contract such as a Handler https://github.com/decentralized-identity/universal-resolver
+// Requests are routed to the base URL configured for the DID's method, falling back to the default base URL.
+type UniversalResolver struct {
+	client  *http.Client
+	baseURL string
+	routes  map[did.Method]string
+	methods []did.Method
+}
+
+var _ Resolver = (*UniversalResolver)(nil)
+
+// NewUniversalResolver creates a new resolver for the given methods which sends requests to the Universal Resolver
+// at baseURL, for example: https://dev.uniresolver.io. The base URL may be empty if every method is routed with
+// Route.
+func NewUniversalResolver(client *http.Client, baseURL string, methods ...did.Method) (*UniversalResolver, error) {
+	if client == nil {
+		return nil, errors.New("client cannot be empty")
+	}
+	if baseURL != "" {
+		if _, err := url.ParseRequestURI(baseURL); err != nil {
+			return nil, errors.Wrap(err, "invalid base url")
+		}
+	}
+	return &UniversalResolver{
+		client:  client,
+		baseURL: strings.TrimSuffix(baseURL, "/"),
+		routes:  make(map[did.Method]string),
+		methods: methods,
+	}, nil
+}
+
+// Route sends requests for DIDs of the given method to a different base URL, such as a single method driver. The
+// method is added to the list of supported methods if needed.
+func (ur *UniversalResolver) Route(method did.Method, baseURL string) error {
+	if _, err := url.ParseRequestURI(baseURL); err != nil {
+		return errors.Wrapf(err, "invalid base url for method: %s", method)
+	}
+	if !ur.supports(method) {
+		ur.methods = append(ur.methods, method)
+	}
+	ur.routes[method] = strings.TrimSuffix(baseURL, "/")
+	return nil
+}
+
+// Resolve resolves a DID through the Universal Resolver. Error responses are mapped back to typed errors.
+func (ur *UniversalResolver) Resolve(ctx context.Context, id string, opts ...Option) (*Result, error) {
+	method, err := GetMethodForDID(id)
+	if err != nil {
+		return nil, NewResolutionError(InvalidDIDErrorCode, err)
+	}
+	if !ur.supports(method) {
+		return nil, NewResolutionErrorf(MethodNotSupportedErrorCode, "unsupported method: %s", method)
+	}
+	baseURL, ok := ur.routes[method]
+	if !ok {
+		baseURL = ur.baseURL
+	}
+	if baseURL == "" {
+		return nil, NewResolutionErrorf(MethodNotSupportedErrorCode, "no route for method: %s", method)
+	}
+	options, err := ParseOptions(opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	query := url.Values{}
+	if options.VersionID != "" {
+		query.Set(did.VersionIDParameter, options.VersionID)
+	}
+	if options.VersionTime != nil {
+		query.Set(did.VersionTimeParameter, options.FormatVersionTime())
+	}
+	identifier := id
+	if len(query) > 0 {
+		identifier += "?" + query.Encode()
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, baseURL+IdentifiersPath+url.PathEscape(identifier), nil)
+	if err != nil {
+		return nil, errors.Wrap(err, "creating request")
+	}
+	req.Header.Set("Accept", ResultContentType)
+	if options.NoCache {
+		req.Header.Set("Cache-Control", "no-cache")
+	}
+
+	resp, err := ur.client.Do(req)
+	if err != nil {
+		return nil, errors.Wrapf(err, "resolving did: %s", id)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxResultSize+1))
+	if err != nil {
+		return nil, errors.Wrap(err, "reading response body")
+	}
+	if len(body) > maxResultSize {
+		return nil, fmt.Errorf("resolving did: %s: response exceeds the limit of %d bytes", id, maxResultSize)
+	}
+
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusGone {
+		return nil, errorFromResponse(resp.StatusCode, body)
+	}
+	result, err := ParseDIDResolution(body)
+	if err != nil {
+		return nil, errors.Wrap(err, "parsing resolution result")
+	}
+	if resp.StatusCode == http.StatusGone {
+		if result.DocumentMetadata == nil {
+			result.DocumentMetadata = new(DocumentMetadata)
+		}
+		result.DocumentMetadata.Deactivated = true
+	}
+	if err = ValidateResult(result); err != nil {
+		return nil, err
+	}
+	return options.Apply(result), nil
+}
+
+func (ur *UniversalResolver) Methods() []did.Method {
+	return ur.methods
+}
+
+func (ur *UniversalResolver) supports(method did.Method) bool {
+	for _, m := range ur.methods {
+		if m == method {
+			return true
+		}
+	}
+	return false
+}
+
+// errorFromResponse creates a typed error from an error response, preferring the code in the body's resolution
+// metadata over the one implied by the status code
+func errorFromResponse(status int, body []byte) error {
+	err := fmt.Errorf("universal resolver responded with status %d", status)
+	if result, parseErr := ParseDIDResolution(body); parseErr == nil && result.Error != nil && result.Error.Code != "" {
+		return NewResolutionError(result.Error.Code, err)
+	}
+	switch status {
+	case http.StatusBadRequest:
+		return NewResolutionError(InvalidDIDErrorCode, err)
+	case http.StatusNotFound:
+		return NewResolutionError(NotFoundErrorCode, err)
+	case http.StatusNotAcceptable:
+		return NewResolutionError(RepresentationNotSupportedErrorCode, err)
+	case http.StatusNotImplemented:
+		return NewResolutionError(MethodNotSupportedErrorCode, err)
+	default:
+		return NewResolutionError(InternalErrorCode, err)
+	}
+}
@@ -0,0 +1,120 @@
+package resolution
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/extrimian/ssi-sdk/did"
+)
+
+func TestUniversalResolver(t *testing.T) {
+	doc := did.Document{Context: did.KnownDIDContext, ID: "did:example:123"}
+	handler, err := NewHandler(staticResolver{docs: map[string]did.Document{doc.ID: doc}})
+	require.NoError(t, err)
+	server := httptest.NewServer(handler)
+	defer server.Close()
+
+	t.Run("bad arguments", func(tt *testing.T) {
+		_, err := NewUniversalResolver(nil, server.URL)
+		assert.Error(tt, err)
+
+		_, err = NewUniversalResolver(http.DefaultClient, "not a url")
+		assert.Error(tt, err)
+	})
+
+	resolver, err := NewUniversalResolver(server.Client(), server.URL, "example")
+	require.NoError(t, err)
+
+	t.Run("resolves through the handler", func(tt *testing.T) {
+		result, err := resolver.Resolve(context.Background(), doc.ID)
+		require.NoError(tt, err)
+		assert.Equal(tt, doc.ID, result.Document.ID)
+	})
+
+	t.Run("maps errors back to typed errors", func(tt *testing.T) {
+		_, err := resolver.Resolve(context.Background(), "did:example:456")
+		assert.True(tt, IsNotFound(err))
+
+		_, err = resolver.Resolve(context.Background(), "did:other:123")
+		assert.True(tt, IsMethodNotSupported(err))
+
+		_, err = resolver.Resolve(context.Background(), "not-a-did")
+		assert.True(tt, IsInvalidDID(err))
+	})
+
+	t.Run("rejects invalid documents", func(tt *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", ResultContentType)
+			_, _ = w.Write([]byte(`{"didDocument": {"id": "did:example:123", "service": [{"id": "#linked", "type": "LinkedDomains"}]}}`))
+		}))
+		defer server.Close()
+		resolver, err := NewUniversalResolver(server.Client(), server.URL, "example")
+		require.NoError(tt, err)
+
+		_, err = resolver.Resolve(context.Background(), "did:example:123")
+		assert.Equal(tt, InvalidDIDDocumentErrorCode, GetErrorCode(err))
+		assert.ErrorContains(tt, err, "$.service[0].serviceEndpoint: serviceEndpoint is required")
+	})
+
+	t.Run("rejects oversized responses", func(tt *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", ResultContentType)
+			_, _ = w.Write([]byte(`{"didDocument": {"id": "did:example:123"}, "padding": "` + strings.Repeat("a", maxResultSize) + `"}`))
+		}))
+		defer server.Close()
+		resolver, err := NewUniversalResolver(server.Client(), server.URL, "example")
+		require.NoError(tt, err)
+
+		_, err = resolver.Resolve(context.Background(), "did:example:123")
+		assert.ErrorContains(tt, err, "response exceeds the limit")
+	})
+
+	t.Run("sends options", func(tt *testing.T) {
+		var got *http.Request
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			got = r
+			w.Header().Set("Content-Type", ResultContentType)
+			w.WriteHeader(http.StatusGone)
+			_, _ = w.Write([]byte(`{"didDocument": {"id": "did:example:123"}, "didResolutionMetadata": {}}`))
+		}))
+		defer server.Close()
+
+		resolver, err := NewUniversalResolver(server.Client(), server.URL, "example")
+		require.NoError(tt, err)
+		result, err := resolver.Resolve(context.Background(), doc.ID,
+			WithVersionTime(time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)), WithNoCache())
+		require.NoError(tt, err)
+		assert.True(tt, result.Deactivated)
+		identifier, err := url.PathUnescape(strings.TrimPrefix(got.URL.EscapedPath(), IdentifiersPath))
+		require.NoError(tt, err)
+		assert.Equal(tt, "did:example:123?versionTime=2023-01-01T00%3A00%3A00Z", identifier)
+		assert.Equal(tt, ResultContentType, got.Header.Get("Accept"))
+		assert.Equal(tt, "no-cache", got.Header.Get("Cache-Control"))
+	})
+
+	t.Run("routes methods", func(tt *testing.T) {
+		driver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"didResolutionMetadata": {"error": "notFound"}}`))
+		}))
+		defer driver.Close()
+
+		routed, err := NewUniversalResolver(http.DefaultClient, "")
+		require.NoError(tt, err)
+		_, err = routed.Resolve(context.Background(), doc.ID)
+		assert.True(tt, IsMethodNotSupported(err))
+
+		require.NoError(tt, routed.Route("example", driver.URL))
+		assert.Equal(tt, []did.Method{"example"}, routed.Methods())
+		_, err = routed.Resolve(context.Background(), doc.ID)
+		assert.True(tt, IsNotFound(err))
+	})
+}
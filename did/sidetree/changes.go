@@ -0,0 +1,141 @@
+package sidetree
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/extrimian/ssi-sdk/did"
+)
+
+// NewStateChange converts the changes to a DID Document into the state change of an update request. Sidetree patches
+// can only add or remove public keys and services, so a changed public key, or a public key whose verification
+// relationships changed, is added again with its new purposes, which replaces it. Public keys must be JWKs declared in
+// the document's verificationMethod, and changes to the controller or alsoKnownAs of the document cannot be expressed.
+func NewStateChange(from did.Document, changes did.ChangeSet) (*StateChange, error) {
+	for _, property := range changes.Properties {
+		// the context is set by the resolver, not the state of the DID
+		if property.Name != did.ContextProperty {
+			return nil, fmt.Errorf("cannot change %s of a sidetree DID", property.Name)
+		}
+	}
+	target, err := changes.Apply(from)
+	if err != nil {
+		return nil, errors.Wrap(err, "applying changes")
+	}
+
+	var stateChange StateChange
+	removed := make(map[string]bool, len(changes.VerificationMethodsRemoved))
+	for _, vm := range changes.VerificationMethodsRemoved {
+		id, err := fragment(from.ID, vm.ID)
+		if err != nil {
+			return nil, err
+		}
+		removed[id] = true
+		stateChange.PublicKeyIDsToRemove = append(stateChange.PublicKeyIDsToRemove, id)
+	}
+
+	var updated []string
+	update := func(id string) error {
+		fragmentID, err := fragment(from.ID, id)
+		if err != nil {
+			return err
+		}
+		if !removed[fragmentID] && !contains(updated, fragmentID) {
+			updated = append(updated, fragmentID)
+		}
+		return nil
+	}
+	for _, vm := range changes.VerificationMethodsAdded {
+		if err = update(vm.ID); err != nil {
+			return nil, err
+		}
+	}
+	for _, change := range changes.VerificationMethodsChanged {
+		if err = update(change.New.ID); err != nil {
+			return nil, err
+		}
+	}
+	for _, relationshipChanges := range [][]did.RelationshipChange{changes.RelationshipsAdded, changes.RelationshipsRemoved} {
+		for _, change := range relationshipChanges {
+			if err = update(change.ID); err != nil {
+				return nil, err
+			}
+		}
+	}
+	for _, id := range updated {
+		publicKey, err := toPublicKey(*target, id)
+		if err != nil {
+			return nil, err
+		}
+		stateChange.PublicKeysToAdd = append(stateChange.PublicKeysToAdd, *publicKey)
+	}
+
+	for _, service := range changes.ServicesRemoved {
+		id, err := fragment(from.ID, service.ID)
+		if err != nil {
+			return nil, err
+		}
+		stateChange.ServiceIDsToRemove = append(stateChange.ServiceIDsToRemove, id)
+	}
+	services := append([]did.Service(nil), changes.ServicesAdded...)
+	for _, change := range changes.ServicesChanged {
+		services = append(services, change.New)
+	}
+	for _, service := range services {
+		if service.ID, err = fragment(from.ID, service.ID); err != nil {
+			return nil, err
+		}
+		stateChange.ServicesToAdd = append(stateChange.ServicesToAdd, service)
+	}
+	return &stateChange, nil
+}
+
+// toPublicKey converts the verification method of the document with the fragment id into a public key, whose
+// purposes are the verification relationships the document lists it in
+func toPublicKey(doc did.Document, id string) (*PublicKey, error) {
+	for _, vm := range doc.VerificationMethod {
+		if vmID, err := fragment(doc.ID, vm.ID); err != nil || vmID != id {
+			continue
+		}
+		if vm.PublicKeyJWK == nil {
+			return nil, fmt.Errorf("verification method %s must have a publicKeyJwk", vm.ID)
+		}
+		publicKey := PublicKey{
+			ID:           id,
+			Type:         string(vm.Type),
+			PublicKeyJWK: *vm.PublicKeyJWK,
+		}
+		for _, relationship := range did.Relationships() {
+			if doc.HasRelationship("#"+id, relationship) {
+				publicKey.Purposes = append(publicKey.Purposes, PublicKeyPurpose(relationship))
+			}
+		}
+		return &publicKey, nil
+	}
+	return nil, fmt.Errorf("verification method #%s must be declared in the document's verificationMethod", id)
+}
+
+// fragment returns the fragment of an id of the DID's resources, which is how sidetree identifies them
+func fragment(didID, id string) (string, error) {
+	switch {
+	case strings.HasPrefix(id, "#"):
+		return strings.TrimPrefix(id, "#"), nil
+	case didID != "" && strings.HasPrefix(id, didID+"#"):
+		return strings.TrimPrefix(id, didID+"#"), nil
+	case !strings.Contains(id, "#"):
+		return id, nil
+	default:
+		return "", fmt.Errorf("cannot reference %s, which is not a resource of %s", id, didID)
+	}
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,128 @@
+package sidetree
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/extrimian/ssi-sdk/crypto/jwx"
+	"github.com/extrimian/ssi-sdk/did"
+)
+
+func TestApplyPatches(t *testing.T) {
+	doc := sidetreeDocument()
+	patched, err := ApplyPatches(doc, []Patch{
+		AddPublicKeysAction{Action: AddPublicKeys, PublicKeys: []PublicKey{{
+			ID:           "key-1",
+			Type:         "JsonWebKey2020",
+			PublicKeyJWK: jwx.PublicKeyJWK{KTY: "OKP", CRV: "Ed25519", X: "VCpo2LMLhn6iWku8MKvSLg2ZAoC-nlOyPVQaO3FxVeQ"},
+			Purposes:     []PublicKeyPurpose{KeyAgreement},
+		}}},
+		AddServicesAction{Action: AddServices, Services: []did.Service{
+			{ID: "linked", Type: "LinkedDomains", ServiceEndpoint: "https://example.org"},
+		}},
+	})
+	require.NoError(t, err)
+
+	// adding a public key or service with an existing id replaces it
+	require.Len(t, patched.VerificationMethod, 2)
+	assert.Equal(t, "#key-1", patched.VerificationMethod[1].ID)
+	assert.Equal(t, "VCpo2LMLhn6iWku8MKvSLg2ZAoC-nlOyPVQaO3FxVeQ", patched.VerificationMethod[1].PublicKeyJWK.X)
+	assert.Empty(t, patched.Authentication)
+	assert.Equal(t, did.VerificationRelationship{"#key-2"}, patched.AssertionMethod)
+	assert.Equal(t, did.VerificationRelationship{"#key-1"}, patched.KeyAgreement)
+	require.Len(t, patched.Services, 1)
+	assert.Equal(t, "https://example.org", patched.Services[0].ServiceEndpoint)
+
+	// the document is not modified
+	assert.Equal(t, sidetreeDocument(), doc)
+}
+
+func TestNewStateChange(t *testing.T) {
+	t.Run("patches of the state change result in the new document", func(tt *testing.T) {
+		from := sidetreeDocument()
+		to := sidetreeDocument()
+		to.VerificationMethod = []did.VerificationMethod{to.VerificationMethod[0], {
+			ID:           "#key-3",
+			Type:         "JsonWebKey2020",
+			Controller:   to.ID,
+			PublicKeyJWK: &jwx.PublicKeyJWK{KTY: "OKP", CRV: "Ed25519", X: "VCpo2LMLhn6iWku8MKvSLg2ZAoC-nlOyPVQaO3FxVeQ"},
+		}}
+		to.Authentication = did.VerificationRelationship{"#key-1", "#key-3"}
+		to.AssertionMethod = nil
+		to.CapabilityInvocation = did.VerificationRelationship{to.ID + "#key-1"}
+		to.Services = []did.Service{
+			{ID: "#hub", Type: "IdentityHub", ServiceEndpoint: "https://hub.example.com"},
+		}
+
+		stateChange, err := NewStateChange(from, did.Diff(from, to))
+		require.NoError(tt, err)
+		assert.Equal(tt, []string{"key-2"}, stateChange.PublicKeyIDsToRemove)
+		require.Len(tt, stateChange.PublicKeysToAdd, 2)
+		assert.Equal(tt, "key-3", stateChange.PublicKeysToAdd[0].ID)
+		assert.Equal(tt, []PublicKeyPurpose{Authentication}, stateChange.PublicKeysToAdd[0].Purposes)
+		assert.Equal(tt, "key-1", stateChange.PublicKeysToAdd[1].ID)
+		assert.Equal(tt, []PublicKeyPurpose{Authentication, CapabilityInvocation}, stateChange.PublicKeysToAdd[1].Purposes)
+		assert.Equal(tt, []string{"linked"}, stateChange.ServiceIDsToRemove)
+		require.Len(tt, stateChange.ServicesToAdd, 1)
+		assert.Equal(tt, "hub", stateChange.ServicesToAdd[0].ID)
+
+		patched, err := ApplyPatches(from, stateChange.Patches())
+		require.NoError(tt, err)
+		assert.True(tt, did.Diff(*patched, to).IsEmpty())
+	})
+
+	t.Run("changes sidetree cannot express", func(tt *testing.T) {
+		from := sidetreeDocument()
+		for name, test := range map[string]struct {
+			changes did.ChangeSet
+			err     string
+		}{
+			"controller": {
+				changes: did.ChangeSet{Properties: []did.PropertyChange{{Name: did.ControllerProperty, New: "did:example:123"}}},
+				err:     "cannot change controller of a sidetree DID",
+			},
+			"key of another DID": {
+				changes: did.ChangeSet{RelationshipsAdded: []did.RelationshipChange{{Relationship: did.Authentication, ID: "did:example:123#key-1"}}},
+				err:     "cannot reference did:example:123#key-1, which is not a resource of did:test:EiAbc",
+			},
+			"key without a JWK": {
+				changes: did.ChangeSet{VerificationMethodsAdded: []did.VerificationMethod{{
+					ID:                 "#key-3",
+					Type:               "Ed25519VerificationKey2020",
+					PublicKeyMultibase: "z6MkmM42vxfqZQsv4ehtTjFFxQ4sQKS2w6WR7emozFAn5cxu",
+				}}},
+				err: "verification method #key-3 must have a publicKeyJwk",
+			},
+		} {
+			_, err := NewStateChange(from, test.changes)
+			assert.ErrorContains(tt, err, test.err, name)
+		}
+	})
+}
+
+func sidetreeDocument() did.Document {
+	return did.Document{
+		ID: "did:test:EiAbc",
+		VerificationMethod: []did.VerificationMethod{
+			{
+				ID:           "#key-1",
+				Type:         "JsonWebKey2020",
+				Controller:   "did:test:EiAbc",
+				PublicKeyJWK: &jwx.PublicKeyJWK{KTY: "EC", CRV: "secp256k1", X: "nIqlRCx0eyBSXcQnqDpReSv4zuWhwCRWssoc9L_nj6A", Y: "iG29VK6l2U5sKBZUSJePvyFusXgSlK2dDFlWaCM8F7k"},
+			},
+			{
+				ID:           "#key-2",
+				Type:         "JsonWebKey2020",
+				Controller:   "did:test:EiAbc",
+				PublicKeyJWK: &jwx.PublicKeyJWK{KTY: "EC", CRV: "P-256", X: "igrFmi0whuihKnj9R3Om1SoMph72wUGeFaBbzG2vzns", Y: "efsX5b10x8yjyrj4ny3pGfLcY7Xby1KzgqOdqnsrJIM"},
+			},
+		},
+		Authentication:  did.VerificationRelationship{"#key-1"},
+		AssertionMethod: did.VerificationRelationship{"#key-2"},
+		Services: []did.Service{
+			{ID: "#linked", Type: "LinkedDomains", ServiceEndpoint: "https://example.com"},
+		},
+	}
+}
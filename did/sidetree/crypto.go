@@ -0,0 +1,286 @@
+package sidetree
+
+import (
+	gocrypto "crypto"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"strings"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/btcsuite/btcd/btcec/v2/ecdsa"
+	"github.com/decred/dcrd/dcrec/secp256k1/v4"
+	"github.com/goccy/go-json"
+	"github.com/gowebpki/jcs"
+	"github.com/pkg/errors"
+
+	"github.com/extrimian/ssi-sdk/crypto"
+	sdkcrypto "github.com/extrimian/ssi-sdk/crypto/jwx"
+)
+
+// Hash hashes given data with sha2-256, which is used by the ES256K signatures of operations; not multihashed
+func Hash(data []byte) []byte {
+	hashed := sha256.Sum256(data)
+	return hashed[:]
+}
+
+// Encode encodes according to the encoding scheme of the sidetree spec
+func Encode(data []byte) string {
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// EncodeString encodes a string according to the encoding scheme of the sidetree spec
+func EncodeString(data string) string {
+	return Encode([]byte(data))
+}
+
+// EncodeAny encodes any according to the encoding scheme of the sidetree spec
+func EncodeAny(data any) (string, error) {
+	anyBytes, err := json.Marshal(data)
+	if err != nil {
+		return "", err
+	}
+	return Encode(anyBytes), nil
+}
+
+// Decode decodes according to the encoding scheme of the sidetree spec
+func Decode(data string) ([]byte, error) {
+	return base64.RawURLEncoding.DecodeString(data)
+}
+
+// Canonicalize transforms JSON according to the protocol's JSON Canonicalization Scheme
+// https://identity.foundation/sidetree/spec/#json-canonicalization-scheme
+func Canonicalize(data []byte) ([]byte, error) {
+	return jcs.Transform(data)
+}
+
+// CanonicalizeAny transforms JSON according to the protocol's JSON Canonicalization Scheme
+// https://identity.foundation/sidetree/spec/#json-canonicalization-scheme
+func CanonicalizeAny(data any) ([]byte, error) {
+	anyBytes, err := json.Marshal(data)
+	if err != nil {
+		return nil, err
+	}
+	return Canonicalize(anyBytes)
+}
+
+// Signer signs the signed data of operations with an update or recovery key, which may be held outside the
+// application such as in a KMS. Sign receives the sha2-256 hash of the JWS signing input and returns the ES256K
+// signature as the 64 bytes of R || S.
+type Signer interface {
+	Sign(dataHash []byte) ([]byte, error)
+}
+
+// KeyManagerSigner is a Signer of a secp256k1 update or recovery key held by a key manager
+type KeyManagerSigner struct {
+	*crypto.RemoteSigner
+	publicKey sdkcrypto.PublicKeyJWK
+}
+
+// NewKeyManagerSigner creates a signer of the secp256k1 key with the id in the key manager
+func NewKeyManagerSigner(keyManager crypto.KeyManager, keyID string) (*KeyManagerSigner, error) {
+	remoteSigner, err := crypto.NewRemoteSigner(keyManager, keyID)
+	if err != nil {
+		return nil, errors.Wrap(err, "creating remote signer")
+	}
+	publicKey, err := sdkcrypto.PublicKeyToPublicKeyJWK("", remoteSigner.PublicKey())
+	if err != nil {
+		return nil, errors.Wrap(err, "converting public key to JWK")
+	}
+	if publicKey.KTY != "EC" || publicKey.CRV != "secp256k1" {
+		return nil, fmt.Errorf("unsupported key for sidetree signer: %s/%s", publicKey.KTY, publicKey.CRV)
+	}
+	return &KeyManagerSigner{RemoteSigner: remoteSigner, publicKey: *publicKey}, nil
+}
+
+var _ Signer = (*KeyManagerSigner)(nil)
+
+// Sign signs the hash with the key of the key manager, returning the 64 bytes of R || S
+func (s *KeyManagerSigner) Sign(dataHash []byte) ([]byte, error) {
+	signature, err := s.RemoteSigner.Sign(rand.Reader, dataHash, gocrypto.SHA256)
+	if err != nil {
+		return nil, err
+	}
+	return crypto.SECP256k1SignatureToRS(signature)
+}
+
+// PublicKeyJWK returns the public key of the signer, to commit to or reveal as an update or recovery key
+func (s *KeyManagerSigner) PublicKeyJWK() sdkcrypto.PublicKeyJWK {
+	return s.publicKey
+}
+
+// SigningInput is the header and payload of the compact JWS of an operation's signed data
+// https://identity.foundation/sidetree/spec/#signed-data-compact-jws
+type SigningInput struct {
+	Header  map[string]any
+	Payload any
+}
+
+// NewSigningInput creates the signing input of an ES256K compact JWS of the payload
+func NewSigningInput(payload any) SigningInput {
+	return SigningInput{Header: map[string]any{"alg": "ES256K"}, Payload: payload}
+}
+
+// String returns the encoded header and payload, joined with a period
+func (si SigningInput) String() (string, error) {
+	encodedHeader, err := EncodeAny(si.Header)
+	if err != nil {
+		return "", errors.Wrap(err, "encoding JWS header")
+	}
+	encodedPayload, err := EncodeAny(si.Payload)
+	if err != nil {
+		return "", errors.Wrap(err, "encoding JWS payload")
+	}
+	return encodedHeader + "." + encodedPayload, nil
+}
+
+// Hash returns the sha2-256 hash of the signing input, which is what a Signer signs
+func (si SigningInput) Hash() ([]byte, error) {
+	signingContent, err := si.String()
+	if err != nil {
+		return nil, err
+	}
+	return Hash([]byte(signingContent)), nil
+}
+
+// Assemble creates the compact JWS from the signing input and its 64 byte R || S signature
+func (si SigningInput) Assemble(signature []byte) (string, error) {
+	if len(signature) != 64 {
+		return "", fmt.Errorf("invalid signature length: %d; expected 64 bytes of R || S", len(signature))
+	}
+	signingContent, err := si.String()
+	if err != nil {
+		return "", err
+	}
+	return signingContent + "." + Encode(signature), nil
+}
+
+// Sign signs the signing input with the signer, creating the compact JWS
+func (si SigningInput) Sign(signer Signer) (string, error) {
+	if signer == nil {
+		return "", errors.New("signer cannot be nil")
+	}
+	contentHash, err := si.Hash()
+	if err != nil {
+		return "", err
+	}
+	signature, err := signer.Sign(contentHash)
+	if err != nil {
+		return "", errors.Wrap(err, "signing JWS")
+	}
+	return si.Assemble(signature)
+}
+
+type BTCSignerVerifier struct {
+	publicKey  *btcec.PublicKey
+	privateKey *btcec.PrivateKey
+}
+
+// NewBTCSignerVerifier creates a new signer/verifier for signatures suited for the BTC blockchain
+func NewBTCSignerVerifier(privateKey sdkcrypto.PrivateKeyJWK) (*BTCSignerVerifier, error) {
+	privateKeyBytes, err := Decode(privateKey.D)
+	if err != nil {
+		return nil, errors.Wrap(err, "constructing BTC signer/verifier")
+	}
+	privKey, pubKey := btcec.PrivKeyFromBytes(privateKeyBytes)
+	return &BTCSignerVerifier{
+		publicKey:  pubKey,
+		privateKey: privKey,
+	}, nil
+}
+
+// NewBTCVerifier creates a new verifier for signatures suited for the BTC blockchain from a secp256k1 public key,
+// such as the update or recovery key revealed by an operation
+func NewBTCVerifier(publicKey sdkcrypto.PublicKeyJWK) (*BTCSignerVerifier, error) {
+	if publicKey.KTY != "EC" || publicKey.CRV != "secp256k1" {
+		return nil, fmt.Errorf("unsupported key for BTC verifier: %s/%s", publicKey.KTY, publicKey.CRV)
+	}
+	x, err := Decode(publicKey.X)
+	if err != nil {
+		return nil, errors.Wrap(err, "decoding x coordinate")
+	}
+	y, err := Decode(publicKey.Y)
+	if err != nil {
+		return nil, errors.Wrap(err, "decoding y coordinate")
+	}
+	if len(x) != 32 || len(y) != 32 {
+		return nil, errors.New("invalid secp256k1 public key coordinates")
+	}
+	pubKey, err := btcec.ParsePubKey(append(append([]byte{0x04}, x...), y...))
+	if err != nil {
+		return nil, errors.Wrap(err, "parsing secp256k1 public key")
+	}
+	return &BTCSignerVerifier{publicKey: pubKey}, nil
+}
+
+var _ Signer = (*BTCSignerVerifier)(nil)
+
+// GetJWSHeader returns the default JWS header for the BTC signer
+func (*BTCSignerVerifier) GetJWSHeader() map[string]any {
+	return map[string]any{
+		"alg": "ES256K",
+	}
+}
+
+// Sign signs the given data according to Bitcoin's signing process
+func (sv *BTCSignerVerifier) Sign(dataHash []byte) ([]byte, error) {
+	if sv.privateKey == nil {
+		return nil, errors.New("signing requires a private key")
+	}
+	signature, err := ecdsa.SignCompact(sv.privateKey, dataHash, false)
+	if err != nil {
+		return nil, err
+	}
+
+	rBytes := signature[1:33]
+	sBytes := signature[33:65]
+
+	// Convert the signature from DER format to 64-byte hexadecimal format
+	r := fmt.Sprintf("%064s", hex.EncodeToString(rBytes))
+	s := fmt.Sprintf("%064s", hex.EncodeToString(sBytes))
+
+	// convert to bytes and return
+	return hex.DecodeString(r + s)
+}
+
+// Verify verifies the given data according to Bitcoin's verification process
+func (sv *BTCSignerVerifier) Verify(data, signature []byte) (bool, error) {
+	if len(signature) != 64 {
+		return false, fmt.Errorf("invalid signature length: %d", len(signature))
+	}
+	r := new(secp256k1.ModNScalar)
+	r.SetBytes((*[32]byte)(signature[:32]))
+	s := new(secp256k1.ModNScalar)
+	s.SetBytes((*[32]byte)(signature[32:]))
+
+	// Reconstruct the signature once we have the R and S values
+	reconstructedSignature := ecdsa.NewSignature(r, s)
+
+	return reconstructedSignature.Verify(data, sv.publicKey), nil
+}
+
+// SignJWT signs the given data according to the protocol's JWT signing process,
+// creating a compact JWS in a JWT
+func (sv *BTCSignerVerifier) SignJWT(data any) (string, error) {
+	return SigningInput{Header: sv.GetJWSHeader(), Payload: data}.Sign(sv)
+}
+
+// VerifyJWS verifies the given data according to the protocol's JWS verification process
+func (sv *BTCSignerVerifier) VerifyJWS(jws string) (bool, error) {
+	jwsParts := strings.Split(jws, ".")
+	if len(jwsParts) != 3 {
+		return false, errors.Errorf("invalid JWS: %s", jws)
+	}
+
+	signingContent := jwsParts[0] + "." + jwsParts[1]
+	contentHash := Hash([]byte(signingContent))
+
+	decodedSignature, err := Decode(jwsParts[2])
+	if err != nil {
+		return false, errors.Wrap(err, "decoding signature")
+	}
+
+	return sv.Verify(contentHash, decodedSignature)
+}
@@ -0,0 +1,302 @@
+package sidetree
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/extrimian/ssi-sdk/crypto/jwx"
+	"github.com/extrimian/ssi-sdk/cryptosuite"
+	"github.com/goccy/go-json"
+
+	"github.com/extrimian/ssi-sdk/did"
+	"github.com/pkg/errors"
+)
+
+// InitialState is the initial state of a DID Document as defined in the spec
+// https://identity.foundation/sidetree/spec/#long-form-did-uris
+type InitialState[C Commitment] struct {
+	SuffixData SuffixData[C] `json:"suffixData,omitempty"`
+	Delta      Delta[C]      `json:"delta,omitempty"`
+}
+
+// ToDIDStrings returns the short and long form DIDs of the initial state
+func (is InitialState[C]) ToDIDStrings(p Protocol) (shortFormDID string, longFormDID string, err error) {
+	shortFormDID, err = p.CreateShortFormDID(is.SuffixData)
+	if err != nil {
+		return shortFormDID, longFormDID, err
+	}
+	initialStateBytesCanonical, err := CanonicalizeAny(is)
+	if err != nil {
+		err = errors.Wrap(err, "canonicalizing long form DID suffix data")
+		return shortFormDID, longFormDID, err
+	}
+	encoded := Encode(initialStateBytesCanonical)
+	longFormDID = strings.Join([]string{shortFormDID, encoded}, ":")
+	return shortFormDID, longFormDID, nil
+}
+
+// CreateLongFormDID generates a long form DID URI representation from a document, recovery, and update keys,
+// intended to be the initial state of a DID Document. The method follows the guidelines in the spec:
+// https://identity.foundation/sidetree/spec/#long-form-did-uris
+func CreateLongFormDID[C Commitment](p Protocol, recoveryKey, updateKey jwx.PublicKeyJWK, document Document) (string, error) {
+	createRequest, err := NewCreateRequest[C](p, recoveryKey, updateKey, document)
+	if err != nil {
+		return "", err
+	}
+	is := InitialState[C]{
+		Delta:      createRequest.Delta,
+		SuffixData: createRequest.SuffixData,
+	}
+	_, longFormDID, err := is.ToDIDStrings(p)
+	return longFormDID, err
+}
+
+// DecodeLongFormDID decodes a long form DID into a short form DID and
+// its create operation suffix data
+func DecodeLongFormDID[C Commitment](p Protocol, longFormDID string) (string, *InitialState[C], error) {
+	prefix, ok := p.Prefix(longFormDID)
+	if !ok {
+		return "", nil, fmt.Errorf("not a valid %s DID", p.method())
+	}
+	split := strings.Split(strings.TrimPrefix(longFormDID, prefix+":"), ":")
+	if len(split) != 2 {
+		return "", nil, errors.New("invalid long form URI")
+	}
+	decoded, err := Decode(split[1])
+	if err != nil {
+		return "", nil, errors.Wrap(err, "decoding long form URI")
+	}
+	var initialState InitialState[C]
+	if err = json.Unmarshal(decoded, &initialState); err != nil {
+		return "", nil, errors.Wrap(err, "unmarshalling long form URI")
+	}
+	return prefix + ":" + split[0], &initialState, nil
+}
+
+// CreateShortFormDID follows the process on did uri composition from the spec:
+// https://identity.foundation/sidetree/spec/#did-uri-composition, used to generate a short form DID URI,
+// which is most frequently used in the protocol and when sharing out DIDs. The DID has the first prefix
+// of the protocol.
+func (p Protocol) CreateShortFormDID(suffixData any) (string, error) {
+	if len(p.Prefixes) == 0 {
+		return "", errors.New("protocol must have at least one prefix")
+	}
+	createOpSuffixDataCanonical, err := CanonicalizeAny(suffixData)
+	if err != nil {
+		return "", errors.Wrap(err, "canonicalizing suffix data")
+	}
+	hash, err := p.HashEncode(createOpSuffixDataCanonical)
+	if err != nil {
+		return "", errors.Wrap(err, "generating multihash for DID URI")
+	}
+	return strings.Join([]string{p.Prefixes[0], hash}, ":"), nil
+}
+
+// LongToShortFormDID returns the short form DID from a long form DID
+func LongToShortFormDID[C Commitment](p Protocol, longFormDID string) (string, error) {
+	shortFormDID, _, err := DecodeLongFormDID[C](p, longFormDID)
+	if err != nil {
+		return "", errors.Wrap(err, "decoding long form DID")
+	}
+	return shortFormDID, nil
+}
+
+// PatchesToDIDDocument applies a list of sidetree state patches in order resulting in a DID Document.
+func PatchesToDIDDocument(shortFormDID, longFormDID string, patches []Patch) (*did.Document, error) {
+	if len(patches) == 0 {
+		return nil, errors.New("no patches to apply")
+	}
+	if shortFormDID == "" {
+		return nil, errors.New("short form DID is required")
+	}
+	doc := did.Document{
+		Context: []any{"https://www.w3.org/ns/did/v1", map[string]any{
+			"@base": longFormDID,
+		}},
+		ID: longFormDID,
+	}
+	return ApplyPatches(doc, patches)
+}
+
+// ApplyPatches applies a list of sidetree state patches in order to a DID Document. As in the reference
+// implementation, adding a public key or service replaces any existing one with the same id.
+func ApplyPatches(doc did.Document, patches []Patch) (*did.Document, error) {
+	// patches modify the document's lists, which must not be shared with the caller's document
+	doc.VerificationMethod = append([]did.VerificationMethod(nil), doc.VerificationMethod...)
+	doc.Authentication = append(did.VerificationRelationship(nil), doc.Authentication...)
+	doc.AssertionMethod = append(did.VerificationRelationship(nil), doc.AssertionMethod...)
+	doc.KeyAgreement = append(did.VerificationRelationship(nil), doc.KeyAgreement...)
+	doc.CapabilityInvocation = append(did.VerificationRelationship(nil), doc.CapabilityInvocation...)
+	doc.CapabilityDelegation = append(did.VerificationRelationship(nil), doc.CapabilityDelegation...)
+	doc.Services = append([]did.Service(nil), doc.Services...)
+	for _, patch := range patches {
+		switch patch.GetAction() {
+		case AddServices:
+			addServicePatch := patch.(AddServicesAction)
+			for _, s := range addServicePatch.Services {
+				s := s
+				s.ID = canonicalID(s.ID)
+				doc.Services = removeService(doc.Services, s.ID)
+				doc.Services = append(doc.Services, s)
+			}
+		case RemoveServices:
+			removeServicePatch := patch.(RemoveServicesAction)
+			for _, id := range removeServicePatch.IDs {
+				doc.Services = removeService(doc.Services, canonicalID(id))
+			}
+		case AddPublicKeys:
+			addKeyPatch := patch.(AddPublicKeysAction)
+			gotDoc, err := addPublicKeysPatch(doc, addKeyPatch)
+			if err != nil {
+				return nil, err
+			}
+			doc = *gotDoc
+		case RemovePublicKeys:
+			removeKeyPatch := patch.(RemovePublicKeysAction)
+			gotDoc, err := removePublicKeysPatch(doc, removeKeyPatch)
+			if err != nil {
+				return nil, err
+			}
+			doc = *gotDoc
+		case Replace:
+			replacePatch := patch.(ReplaceAction)
+			gotDoc, err := replaceActionPatch(doc, replacePatch)
+			if err != nil {
+				return nil, err
+			}
+			doc = *gotDoc
+		default:
+			return nil, fmt.Errorf("unknown patch type: %T", patch)
+		}
+	}
+	return &doc, nil
+}
+
+// removeService removes the service with the id, if any
+func removeService(services []did.Service, id string) []did.Service {
+	kept := make([]did.Service, 0, len(services))
+	for _, service := range services {
+		if service.ID != id {
+			kept = append(kept, service)
+		}
+	}
+	return kept
+}
+
+func replaceActionPatch(doc did.Document, patch ReplaceAction) (*did.Document, error) {
+	// first zero out all public keys and services
+	doc.VerificationMethod = nil
+	doc.Authentication = nil
+	doc.AssertionMethod = nil
+	doc.KeyAgreement = nil
+	doc.CapabilityInvocation = nil
+	doc.CapabilityDelegation = nil
+	doc.Services = nil
+
+	// now add back what the patch includes
+	gotDoc, err := addPublicKeysPatch(doc, AddPublicKeysAction{PublicKeys: patch.Document.PublicKeys})
+	if err != nil {
+		return nil, err
+	}
+	doc = *gotDoc
+	for _, service := range patch.Document.Services {
+		s := service
+		s.ID = canonicalID(s.ID)
+		doc.Services = append(doc.Services, s)
+	}
+	return &doc, nil
+}
+
+func addPublicKeysPatch(doc did.Document, patch AddPublicKeysAction) (*did.Document, error) {
+	for _, key := range patch.PublicKeys {
+		currKey := key
+		currKey.ID = canonicalID(currKey.ID)
+		for _, vm := range doc.VerificationMethod {
+			if vm.ID != currKey.ID {
+				continue
+			}
+			gotDoc, err := removePublicKeysPatch(doc, RemovePublicKeysAction{IDs: []string{currKey.ID}})
+			if err != nil {
+				return nil, err
+			}
+			doc = *gotDoc
+			break
+		}
+		doc.VerificationMethod = append(doc.VerificationMethod, did.VerificationMethod{
+			ID:           currKey.ID,
+			Type:         cryptosuite.LDKeyType(currKey.Type),
+			Controller:   doc.ID,
+			PublicKeyJWK: &currKey.PublicKeyJWK,
+		})
+		for _, purpose := range currKey.Purposes {
+			switch purpose {
+			case Authentication:
+				doc.Authentication = append(doc.Authentication, currKey.ID)
+			case AssertionMethod:
+				doc.AssertionMethod = append(doc.AssertionMethod, currKey.ID)
+			case KeyAgreement:
+				doc.KeyAgreement = append(doc.KeyAgreement, currKey.ID)
+			case CapabilityInvocation:
+				doc.CapabilityInvocation = append(doc.CapabilityInvocation, currKey.ID)
+			case CapabilityDelegation:
+				doc.CapabilityDelegation = append(doc.CapabilityDelegation, currKey.ID)
+			default:
+				return nil, fmt.Errorf("unknown key purpose: %s:%s", currKey.ID, purpose)
+			}
+		}
+	}
+	return &doc, nil
+}
+
+func canonicalID(id string) string {
+	if strings.Contains(id, "#") {
+		return id
+	}
+	return "#" + id
+}
+
+func removePublicKeysPatch(doc did.Document, patch RemovePublicKeysAction) (*did.Document, error) {
+	for _, id := range patch.IDs {
+		id := canonicalID(id)
+		removed := false
+		for i, key := range doc.VerificationMethod {
+			if key.ID != id {
+				continue
+			}
+			doc.VerificationMethod = append(doc.VerificationMethod[:i], doc.VerificationMethod[i+1:]...)
+			removed = true
+
+			// TODO(gabe): in the future handle the case where the value is not a simple ID
+			// remove from all other key lists
+			for j, a := range doc.Authentication {
+				if a == id {
+					doc.Authentication = append(doc.Authentication[:j], doc.Authentication[j+1:]...)
+				}
+			}
+			for j, am := range doc.AssertionMethod {
+				if am == id {
+					doc.AssertionMethod = append(doc.AssertionMethod[:j], doc.AssertionMethod[j+1:]...)
+				}
+			}
+			for j, ka := range doc.KeyAgreement {
+				if ka == id {
+					doc.KeyAgreement = append(doc.KeyAgreement[:j], doc.KeyAgreement[j+1:]...)
+				}
+			}
+			for j, ci := range doc.CapabilityInvocation {
+				if ci == id {
+					doc.CapabilityInvocation = append(doc.CapabilityInvocation[:j], doc.CapabilityInvocation[j+1:]...)
+				}
+			}
+			for j, cd := range doc.CapabilityDelegation {
+				if cd == id {
+					doc.CapabilityDelegation = append(doc.CapabilityDelegation[:j], doc.CapabilityDelegation[j+1:]...)
+				}
+			}
+		}
+		if !removed {
+			return nil, fmt.Errorf("could not find key with id %s", id)
+		}
+	}
+	return &doc, nil
+}
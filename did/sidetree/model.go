@@ -0,0 +1,257 @@
+package sidetree
+
+import (
+	"fmt"
+
+	"github.com/extrimian/ssi-sdk/crypto/jwx"
+	"github.com/extrimian/ssi-sdk/did"
+	"github.com/goccy/go-json"
+	"github.com/pkg/errors"
+)
+
+// object models
+
+type Document struct {
+	PublicKeys []PublicKey   `json:"publicKeys,omitempty"`
+	Services   []did.Service `json:"services,omitempty"`
+}
+
+func (d Document) IsEmpty() bool {
+	return len(d.PublicKeys) == 0 && len(d.Services) == 0
+}
+
+type PublicKey struct {
+	ID           string             `json:"id,omitempty"`
+	Type         string             `json:"type,omitempty"`
+	PublicKeyJWK jwx.PublicKeyJWK   `json:"publicKeyJwk,omitempty"`
+	Purposes     []PublicKeyPurpose `json:"purposes,omitempty"`
+}
+
+// action models
+
+// AddServicesAction https://identity.foundation/sidetree/spec/#add-services
+type AddServicesAction struct {
+	Action   PatchAction   `json:"action,omitempty"`
+	Services []did.Service `json:"services,omitempty"`
+}
+
+func (a AddServicesAction) GetAction() PatchAction {
+	return a.Action
+}
+
+// RemoveServicesAction https://identity.foundation/sidetree/spec/#remove-services
+type RemoveServicesAction struct {
+	Action PatchAction `json:"action,omitempty"`
+	IDs    []string    `json:"ids,omitempty"`
+}
+
+func (a RemoveServicesAction) GetAction() PatchAction {
+	return a.Action
+}
+
+// AddPublicKeysAction https://identity.foundation/sidetree/spec/#add-public-keys
+type AddPublicKeysAction struct {
+	Action     PatchAction `json:"action,omitempty"`
+	PublicKeys []PublicKey `json:"publicKeys,omitempty"`
+}
+
+func (a AddPublicKeysAction) GetAction() PatchAction {
+	return a.Action
+}
+
+// RemovePublicKeysAction https://identity.foundation/sidetree/spec/#add-public-keys
+type RemovePublicKeysAction struct {
+	Action PatchAction `json:"action,omitempty"`
+	IDs    []string    `json:"ids,omitempty"`
+}
+
+func (a RemovePublicKeysAction) GetAction() PatchAction {
+	return a.Action
+}
+
+// ReplaceAction https://identity.foundation/sidetree/spec/#replace
+type ReplaceAction struct {
+	Action   PatchAction `json:"action,omitempty"`
+	Document Document    `json:"document,omitempty"`
+}
+
+func (a ReplaceAction) GetAction() PatchAction {
+	return a.Action
+}
+
+// request models
+
+type AnchorOperation interface {
+	GetType() OperationType
+}
+
+type CreateRequest[C Commitment] struct {
+	Type       OperationType `json:"type,omitempty"`
+	SuffixData SuffixData[C] `json:"suffixData,omitempty"`
+	Delta      Delta[C]      `json:"delta,omitempty"`
+}
+
+func (CreateRequest[C]) GetType() OperationType {
+	return Create
+}
+
+type SuffixData[C Commitment] struct {
+	DeltaHash          string `json:"deltaHash,omitempty"`
+	RecoveryCommitment C      `json:"recoveryCommitment,omitempty"`
+}
+
+type UpdateRequest[C Commitment] struct {
+	Type        OperationType `json:"type,omitempty"`
+	DIDSuffix   string        `json:"didSuffix,omitempty"`
+	RevealValue string        `json:"revealValue,omitempty"`
+	Delta       Delta[C]      `json:"delta,omitempty"`
+	SignedData  string        `json:"signedData,omitempty"`
+}
+
+func (UpdateRequest[C]) GetType() OperationType {
+	return Update
+}
+
+// UpdateSignedDataObject https://identity.foundation/sidetree/spec/#update-signed-data-object
+type UpdateSignedDataObject struct {
+	UpdateKey jwx.PublicKeyJWK `json:"updateKey,omitempty"`
+	DeltaHash string           `json:"deltaHash,omitempty"`
+}
+
+type Delta[C Commitment] struct {
+	Patches          []Patch `json:"patches,omitempty"` //revive:disable-line
+	UpdateCommitment C       `json:"updateCommitment,omitempty"`
+}
+
+func (d *Delta[C]) UnmarshalJSON(data []byte) error {
+	var delta struct {
+		Patches          []json.RawMessage `json:"patches"`
+		UpdateCommitment C                 `json:"updateCommitment"`
+	}
+	if err := json.Unmarshal(data, &delta); err != nil {
+		return errors.Wrap(err, "unmarshalling delta")
+	}
+	if len(delta.UpdateCommitment) == 0 {
+		return fmt.Errorf("no updateCommitment found in delta")
+	}
+	d.UpdateCommitment = delta.UpdateCommitment
+	if delta.Patches == nil {
+		return fmt.Errorf("no patches found in delta")
+	}
+	var patches []Patch
+	for _, patch := range delta.Patches {
+		var action struct {
+			Action PatchAction `json:"action"`
+		}
+		if err := json.Unmarshal(patch, &action); err != nil {
+			return errors.Wrap(err, "patch is not a map")
+		}
+		if action.Action == "" {
+			return fmt.Errorf("patch has no action")
+		}
+		switch action.Action {
+		case Replace:
+			var ra ReplaceAction
+			if err := json.Unmarshal(patch, &ra); err != nil {
+				return errors.Wrap(err, "unmarshalling replace action")
+			}
+			patches = append(patches, ra)
+		case AddPublicKeys:
+			var apa AddPublicKeysAction
+			if err := json.Unmarshal(patch, &apa); err != nil {
+				return errors.Wrap(err, "unmarshalling add public keys action")
+			}
+			patches = append(patches, apa)
+		case RemovePublicKeys:
+			var rpa RemovePublicKeysAction
+			if err := json.Unmarshal(patch, &rpa); err != nil {
+				return errors.Wrap(err, "unmarshalling remove public keys action")
+			}
+			patches = append(patches, rpa)
+		case AddServices:
+			var asa AddServicesAction
+			if err := json.Unmarshal(patch, &asa); err != nil {
+				return errors.Wrap(err, "unmarshalling add services action")
+			}
+			patches = append(patches, asa)
+		case RemoveServices:
+			var rsa RemoveServicesAction
+			if err := json.Unmarshal(patch, &rsa); err != nil {
+				return errors.Wrap(err, "unmarshalling remove services action")
+			}
+			patches = append(patches, rsa)
+		default:
+			return fmt.Errorf("unknown patch action: %s", action.Action)
+		}
+	}
+	d.Patches = patches
+	return nil
+}
+
+func NewDelta[C Commitment](updateCommitment C) Delta[C] {
+	return Delta[C]{
+		Patches:          make([]Patch, 0),
+		UpdateCommitment: updateCommitment,
+	}
+}
+
+func (d *Delta[C]) GetPatches() []Patch {
+	return d.Patches
+}
+
+func (d *Delta[C]) AddAddServicesAction(patch AddServicesAction) {
+	d.Patches = append(d.Patches, patch)
+}
+
+func (d *Delta[C]) AddRemoveServicesAction(patch RemoveServicesAction) {
+	d.Patches = append(d.Patches, patch)
+}
+
+func (d *Delta[C]) AddAddPublicKeysAction(patch AddPublicKeysAction) {
+	d.Patches = append(d.Patches, patch)
+}
+
+func (d *Delta[C]) AddRemovePublicKeysAction(patch RemovePublicKeysAction) {
+	d.Patches = append(d.Patches, patch)
+}
+
+func (d *Delta[C]) AddReplaceAction(patch ReplaceAction) {
+	d.Patches = append(d.Patches, patch)
+}
+
+type DeactivateRequest struct {
+	Type        OperationType `json:"type,omitempty"`
+	DIDSuffix   string        `json:"didSuffix,omitempty"`
+	RevealValue string        `json:"revealValue,omitempty"`
+	SignedData  string        `json:"signedData,omitempty"`
+}
+
+func (DeactivateRequest) GetType() OperationType {
+	return Deactivate
+}
+
+// DeactivateSignedDataObject https://identity.foundation/sidetree/spec/#deactivate-signed-data-object
+type DeactivateSignedDataObject struct {
+	DIDSuffix   string           `json:"didSuffix,omitempty"`
+	RecoveryKey jwx.PublicKeyJWK `json:"recoveryKey,omitempty"`
+}
+
+type RecoverRequest[C Commitment] struct {
+	Type        OperationType `json:"type,omitempty"`
+	DIDSuffix   string        `json:"didSuffix,omitempty"`
+	RevealValue string        `json:"revealValue,omitempty"`
+	Delta       Delta[C]      `json:"delta,omitempty"`
+	SignedData  string        `json:"signedData,omitempty"`
+}
+
+func (RecoverRequest[C]) GetType() OperationType {
+	return Recover
+}
+
+// RecoverySignedDataObject https://identity.foundation/sidetree/spec/#recovery-signed-data-object
+type RecoverySignedDataObject[C Commitment] struct {
+	RecoveryCommitment C                `json:"recoveryCommitment,omitempty"`
+	RecoveryKey        jwx.PublicKeyJWK `json:"recoveryKey,omitempty"`
+	DeltaHash          string           `json:"deltaHash,omitempty"`
+	AnchorOrigin       string           `json:"anchorOrigin,omitempty"`
+}
@@ -0,0 +1,532 @@
+package sidetree
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/goccy/go-json"
+	"github.com/pkg/errors"
+
+	"github.com/extrimian/ssi-sdk/crypto/jwx"
+	"github.com/extrimian/ssi-sdk/did"
+	"github.com/extrimian/ssi-sdk/did/resolution"
+	"github.com/extrimian/ssi-sdk/util"
+)
+
+// maxOperationSize bounds the operations a Node reads from a request body
+const maxOperationSize = 1 << 20
+
+// Node is an in-process Sidetree node of a protocol for local testing, such as of DID.Update, Recover and Deactivate
+// against Resolver.Anchor without a live ION or Modena node. It is an http.Handler accepting operations with a POST to
+// the protocol's operations path and resolving DIDs with a GET to the protocol's identifiers path. Operations are
+// validated as a node would, checking delta hashes, reveal values against the DID's commitments and the signatures of
+// the signed data, and are applied immediately; every version of each DID is kept, so DIDs resolve with the versionId
+// and versionTime parameters.
+type Node[C Commitment] struct {
+	protocol Protocol
+
+	mu   sync.RWMutex
+	dids map[string]*nodeDID[C]
+}
+
+// nodeDID is the history of a DID on a Node
+type nodeDID[C Commitment] struct {
+	operations []AnchorOperation
+	versions   []nodeState[C]
+}
+
+// nodeState is the state of a DID after an operation
+type nodeState[C Commitment] struct {
+	patches            []Patch
+	updateCommitment   C
+	recoveryCommitment C
+	deactivated        bool
+	time               time.Time
+}
+
+var (
+	_ http.Handler        = (*Node[string])(nil)
+	_ resolution.Resolver = (*Node[string])(nil)
+)
+
+// NewNode creates a Node of the protocol without any DIDs
+func NewNode[C Commitment](p Protocol) (*Node[C], error) {
+	if err := p.Validate(); err != nil {
+		return nil, errors.Wrap(err, "invalid protocol")
+	}
+	return &Node[C]{protocol: p, dids: make(map[string]*nodeDID[C])}, nil
+}
+
+// Submit validates an operation and applies it to the state of its DID, returning the resolution result of the DID
+// after the operation
+func (n *Node[C]) Submit(op AnchorOperation) (*resolution.Result, error) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	var suffix string
+	var err error
+	switch o := op.(type) {
+	case CreateRequest[C]:
+		suffix, err = n.create(o)
+	case *CreateRequest[C]:
+		suffix, err = n.create(*o)
+	case UpdateRequest[C]:
+		suffix, err = o.DIDSuffix, n.update(o)
+	case *UpdateRequest[C]:
+		suffix, err = o.DIDSuffix, n.update(*o)
+	case RecoverRequest[C]:
+		suffix, err = o.DIDSuffix, n.recover(o)
+	case *RecoverRequest[C]:
+		suffix, err = o.DIDSuffix, n.recover(*o)
+	case DeactivateRequest:
+		suffix, err = o.DIDSuffix, n.deactivate(o)
+	case *DeactivateRequest:
+		suffix, err = o.DIDSuffix, n.deactivate(*o)
+	default:
+		return nil, errors.Errorf("unsupported operation: %T", op)
+	}
+	if err != nil {
+		return nil, err
+	}
+	shortFormDID := n.shortFormDID(suffix)
+	return n.result(shortFormDID, shortFormDID, n.dids[suffix].versions, time.Now())
+}
+
+// Operations returns the operations applied to a DID, in order, starting with its create operation
+func (n *Node[C]) Operations(id string) ([]AnchorOperation, error) {
+	suffix, err := n.suffix(id)
+	if err != nil {
+		return nil, err
+	}
+	n.mu.RLock()
+	defer n.mu.RUnlock()
+	state, ok := n.dids[suffix]
+	if !ok {
+		return nil, errors.Errorf("DID<%s> not found", id)
+	}
+	return append([]AnchorOperation(nil), state.operations...), nil
+}
+
+// Resolve resolves a DID from the state of the node. Unpublished long form DIDs are resolved from their initial state.
+func (n *Node[C]) Resolve(_ context.Context, id string, opts ...resolution.Option) (*resolution.Result, error) {
+	start := time.Now()
+	options, err := resolution.ParseOptions(opts...)
+	if err != nil {
+		return nil, err
+	}
+	suffix, err := n.suffix(id)
+	if err != nil {
+		return nil, resolution.NewResolutionError(resolution.InvalidDIDErrorCode, err)
+	}
+	n.mu.RLock()
+	defer n.mu.RUnlock()
+	state, ok := n.dids[suffix]
+	if !ok {
+		if n.protocol.IsLongFormDID(id) && !options.IsVersioned() {
+			result, err := resolveLongFormDID[C](n.protocol, id, start)
+			if err != nil {
+				return nil, err
+			}
+			return options.Apply(result), nil
+		}
+		return nil, resolution.NewResolutionErrorf(resolution.NotFoundErrorCode, "DID<%s> not found", id)
+	}
+	versions := state.versions
+	switch {
+	case options.VersionID != "":
+		version, err := strconv.Atoi(options.VersionID)
+		if err != nil || version < 1 || version > len(versions) {
+			return nil, resolution.NewResolutionErrorf(resolution.NotFoundErrorCode, "version<%s> of DID<%s> not found", options.VersionID, id)
+		}
+		versions = versions[:version]
+	case options.VersionTime != nil:
+		for len(versions) > 0 && versions[len(versions)-1].time.After(*options.VersionTime) {
+			versions = versions[:len(versions)-1]
+		}
+		if len(versions) == 0 {
+			return nil, resolution.NewResolutionErrorf(resolution.NotFoundErrorCode, "DID<%s> did not exist at %s", id, options.FormatVersionTime())
+		}
+	}
+	prefix, _ := n.protocol.Prefix(id)
+	shortFormDID := prefix + ":" + suffix
+	result, err := n.result(id, shortFormDID, versions, start)
+	if err != nil {
+		return nil, err
+	}
+	if err = resolution.ValidateResult(result); err != nil {
+		return nil, err
+	}
+	return options.Apply(result), nil
+}
+
+func (n *Node[C]) Methods() []did.Method {
+	return n.protocol.Methods()
+}
+
+func (n *Node[C]) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	path := strings.TrimPrefix(r.URL.Path, "/")
+	switch {
+	case path == n.protocol.operationsPath():
+		if r.Method != http.MethodPost {
+			w.Header().Set("Allow", "POST")
+			http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+			return
+		}
+		n.serveOperation(w, r)
+	case strings.HasPrefix(path, n.protocol.identifiersPath()+"/"):
+		if r.Method != http.MethodGet {
+			w.Header().Set("Allow", "GET")
+			http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+			return
+		}
+		n.serveIdentifier(w, r, strings.TrimPrefix(path, n.protocol.identifiersPath()+"/"))
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func (n *Node[C]) serveOperation(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxOperationSize))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	op, err := n.parseOperation(body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	result, err := n.Submit(op)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	writeResult(w, http.StatusOK, result)
+}
+
+func (n *Node[C]) serveIdentifier(w http.ResponseWriter, r *http.Request, id string) {
+	var opts []resolution.Option
+	query := r.URL.Query()
+	if versionID := query.Get(did.VersionIDParameter); versionID != "" {
+		opts = append(opts, resolution.WithVersionID(versionID))
+	}
+	if versionTime := query.Get(did.VersionTimeParameter); versionTime != "" {
+		t, err := time.Parse(time.RFC3339, versionTime)
+		if err != nil {
+			http.Error(w, "invalid versionTime", http.StatusBadRequest)
+			return
+		}
+		opts = append(opts, resolution.WithVersionTime(t))
+	}
+	result, err := n.Resolve(r.Context(), id, opts...)
+	switch {
+	case resolution.IsNotFound(err):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case err != nil:
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case result.DocumentMetadata != nil && result.DocumentMetadata.Deactivated:
+		writeResult(w, http.StatusGone, result)
+	default:
+		writeResult(w, http.StatusOK, result)
+	}
+}
+
+func writeResult(w http.ResponseWriter, status int, result *resolution.Result) {
+	resultBytes, err := json.Marshal(result)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", util.JSONContentType)
+	w.WriteHeader(status)
+	_, _ = w.Write(resultBytes)
+}
+
+// parseOperation unmarshalls an operation according to its type
+func (*Node[C]) parseOperation(body []byte) (AnchorOperation, error) {
+	var typed struct {
+		Type OperationType `json:"type"`
+	}
+	if err := json.Unmarshal(body, &typed); err != nil {
+		return nil, errors.Wrap(err, "unmarshalling operation")
+	}
+	var op AnchorOperation
+	var err error
+	switch typed.Type {
+	case Create:
+		var create CreateRequest[C]
+		err = json.Unmarshal(body, &create)
+		op = create
+	case Update:
+		var update UpdateRequest[C]
+		err = json.Unmarshal(body, &update)
+		op = update
+	case Recover:
+		var recover RecoverRequest[C]
+		err = json.Unmarshal(body, &recover)
+		op = recover
+	case Deactivate:
+		var deactivate DeactivateRequest
+		err = json.Unmarshal(body, &deactivate)
+		op = deactivate
+	default:
+		return nil, errors.Errorf("unknown operation type: %q", typed.Type)
+	}
+	if err != nil {
+		return nil, errors.Wrapf(err, "unmarshalling %s operation", typed.Type)
+	}
+	return op, nil
+}
+
+func (n *Node[C]) create(op CreateRequest[C]) (string, error) {
+	if err := n.checkDeltaHash(op.Delta, op.SuffixData.DeltaHash); err != nil {
+		return "", err
+	}
+	if len(commitments(op.SuffixData.RecoveryCommitment)) == 0 {
+		return "", errors.New("no recoveryCommitment found in suffix data")
+	}
+	suffixDataCanonical, err := CanonicalizeAny(op.SuffixData)
+	if err != nil {
+		return "", errors.Wrap(err, "canonicalizing suffix data")
+	}
+	suffix, err := n.protocol.HashEncode(suffixDataCanonical)
+	if err != nil {
+		return "", errors.Wrap(err, "generating DID suffix")
+	}
+	if _, ok := n.dids[suffix]; ok {
+		return "", errors.Errorf("DID with suffix<%s> already exists", suffix)
+	}
+	if err = n.checkPatches(suffix, op.Delta.Patches); err != nil {
+		return "", err
+	}
+	n.dids[suffix] = &nodeDID[C]{
+		operations: []AnchorOperation{op},
+		versions: []nodeState[C]{{
+			patches:            op.Delta.Patches,
+			updateCommitment:   op.Delta.UpdateCommitment,
+			recoveryCommitment: op.SuffixData.RecoveryCommitment,
+			time:               time.Now(),
+		}},
+	}
+	return suffix, nil
+}
+
+func (n *Node[C]) update(op UpdateRequest[C]) error {
+	state, current, err := n.current(op.DIDSuffix)
+	if err != nil {
+		return err
+	}
+	var signedData UpdateSignedDataObject
+	if err = n.checkSignedData(op.SignedData, &signedData, &signedData.UpdateKey); err != nil {
+		return err
+	}
+	if err = n.checkReveal(op.RevealValue, signedData.UpdateKey, current.updateCommitment); err != nil {
+		return errors.Wrap(err, "invalid update key")
+	}
+	if err = n.checkDeltaHash(op.Delta, signedData.DeltaHash); err != nil {
+		return err
+	}
+	next := current
+	next.patches = append(append([]Patch(nil), current.patches...), op.Delta.Patches...)
+	if err = n.checkPatches(op.DIDSuffix, next.patches); err != nil {
+		return err
+	}
+	next.updateCommitment = op.Delta.UpdateCommitment
+	next.time = time.Now()
+	state.operations = append(state.operations, op)
+	state.versions = append(state.versions, next)
+	return nil
+}
+
+func (n *Node[C]) recover(op RecoverRequest[C]) error {
+	state, current, err := n.current(op.DIDSuffix)
+	if err != nil {
+		return err
+	}
+	var signedData RecoverySignedDataObject[C]
+	if err = n.checkSignedData(op.SignedData, &signedData, &signedData.RecoveryKey); err != nil {
+		return err
+	}
+	if err = n.checkReveal(op.RevealValue, signedData.RecoveryKey, current.recoveryCommitment); err != nil {
+		return errors.Wrap(err, "invalid recovery key")
+	}
+	if err = n.checkDeltaHash(op.Delta, signedData.DeltaHash); err != nil {
+		return err
+	}
+	if err = n.checkPatches(op.DIDSuffix, op.Delta.Patches); err != nil {
+		return err
+	}
+	state.operations = append(state.operations, op)
+	state.versions = append(state.versions, nodeState[C]{
+		patches:            op.Delta.Patches,
+		updateCommitment:   op.Delta.UpdateCommitment,
+		recoveryCommitment: signedData.RecoveryCommitment,
+		time:               time.Now(),
+	})
+	return nil
+}
+
+func (n *Node[C]) deactivate(op DeactivateRequest) error {
+	state, current, err := n.current(op.DIDSuffix)
+	if err != nil {
+		return err
+	}
+	var signedData DeactivateSignedDataObject
+	if err = n.checkSignedData(op.SignedData, &signedData, &signedData.RecoveryKey); err != nil {
+		return err
+	}
+	if signedData.DIDSuffix != op.DIDSuffix {
+		return errors.New("signed DID suffix does not match the operation")
+	}
+	if err = n.checkReveal(op.RevealValue, signedData.RecoveryKey, current.recoveryCommitment); err != nil {
+		return errors.Wrap(err, "invalid recovery key")
+	}
+	state.operations = append(state.operations, op)
+	state.versions = append(state.versions, nodeState[C]{deactivated: true, time: time.Now()})
+	return nil
+}
+
+// current returns the history and the current state of a DID that can be operated on
+func (n *Node[C]) current(suffix string) (*nodeDID[C], nodeState[C], error) {
+	state, ok := n.dids[suffix]
+	if !ok {
+		return nil, nodeState[C]{}, errors.Errorf("DID with suffix<%s> not found", suffix)
+	}
+	current := state.versions[len(state.versions)-1]
+	if current.deactivated {
+		return nil, nodeState[C]{}, errors.Errorf("DID with suffix<%s> is deactivated", suffix)
+	}
+	return state, current, nil
+}
+
+// checkSignedData verifies the compact JWS of an operation with the key it reveals, unmarshalling its payload
+func (*Node[C]) checkSignedData(jws string, signedData any, key *jwx.PublicKeyJWK) error {
+	parts := strings.Split(jws, ".")
+	if len(parts) != 3 {
+		return errors.New("signed data is not a compact JWS")
+	}
+	payload, err := Decode(parts[1])
+	if err != nil {
+		return errors.Wrap(err, "decoding signed data")
+	}
+	if err = json.Unmarshal(payload, signedData); err != nil {
+		return errors.Wrap(err, "unmarshalling signed data")
+	}
+	verifier, err := NewBTCVerifier(*key)
+	if err != nil {
+		return errors.Wrap(err, "creating verifier for signed data")
+	}
+	verified, err := verifier.VerifyJWS(jws)
+	if err != nil {
+		return errors.Wrap(err, "verifying signed data")
+	}
+	if !verified {
+		return errors.New("invalid signature on signed data")
+	}
+	return nil
+}
+
+// checkReveal checks that the reveal value is that of the key, and that the key is committed to
+func (n *Node[C]) checkReveal(revealValue string, key jwx.PublicKeyJWK, commitment C) error {
+	reveal, keyCommitment, err := n.protocol.Commit(key)
+	if err != nil {
+		return errors.Wrap(err, "generating commitment")
+	}
+	if reveal != revealValue {
+		return errors.New("reveal value does not match the key")
+	}
+	for _, c := range commitments(commitment) {
+		if c == keyCommitment {
+			return nil
+		}
+	}
+	return errors.New("key does not match the commitment")
+}
+
+func (n *Node[C]) checkDeltaHash(delta Delta[C], deltaHash string) error {
+	if len(commitments(delta.UpdateCommitment)) == 0 {
+		return errors.New("no updateCommitment found in delta")
+	}
+	deltaCanonical, err := CanonicalizeAny(delta)
+	if err != nil {
+		return errors.Wrap(err, "canonicalizing delta")
+	}
+	hash, err := n.protocol.HashEncode(deltaCanonical)
+	if err != nil {
+		return errors.Wrap(err, "hash-encoding delta")
+	}
+	if hash != deltaHash {
+		return errors.New("delta hash does not match the delta")
+	}
+	return nil
+}
+
+// checkPatches checks that the patches of a state of a DID apply to an empty document
+func (n *Node[C]) checkPatches(suffix string, patches []Patch) error {
+	shortFormDID := n.shortFormDID(suffix)
+	if _, err := PatchesToDIDDocument(shortFormDID, shortFormDID, patches); err != nil {
+		return errors.Wrap(err, "applying patches")
+	}
+	return nil
+}
+
+// shortFormDID returns the short form DID of a suffix with the first prefix of the protocol
+func (n *Node[C]) shortFormDID(suffix string) string {
+	return n.protocol.Prefixes[0] + ":" + suffix
+}
+
+// suffix returns the unique suffix of a short or long form DID of the protocol
+func (n *Node[C]) suffix(id string) (string, error) {
+	suffix, err := n.protocol.Suffix(id)
+	if err != nil {
+		return "", err
+	}
+	suffix, _, _ = strings.Cut(suffix, ":")
+	return suffix, nil
+}
+
+// result builds the resolution result of the last of the versions of a DID
+func (n *Node[C]) result(id, shortFormDID string, versions []nodeState[C], start time.Time) (*resolution.Result, error) {
+	current := versions[len(versions)-1]
+	metadata := resolution.DocumentMetadata{
+		Created:   versions[0].time.UTC().Format(time.RFC3339),
+		VersionID: strconv.Itoa(len(versions)),
+		Method:    resolution.Method{Published: true},
+	}
+	if len(versions) > 1 {
+		metadata.Updated = current.time.UTC().Format(time.RFC3339)
+	}
+	if id != shortFormDID {
+		metadata.CanonicalID = shortFormDID
+	}
+	doc := did.Document{Context: []any{did.KnownDIDContext}, ID: id}
+	if current.deactivated {
+		metadata.Deactivated = true
+		return resolution.NewResult(doc, &metadata, start), nil
+	}
+	metadata.Method.UpdateCommitment = current.updateCommitment
+	metadata.Method.RecoveryCommitment = current.recoveryCommitment
+	patched, err := PatchesToDIDDocument(shortFormDID, id, current.patches)
+	if err != nil {
+		return nil, errors.Wrapf(err, "applying patches of DID<%s>", id)
+	}
+	return resolution.NewResult(*patched, &metadata, start), nil
+}
+
+// commitments returns the commitments of the representation of a method
+func commitments[C Commitment](c C) []string {
+	switch v := any(c).(type) {
+	case []string:
+		return v
+	case string:
+		if v == "" {
+			return nil
+		}
+		return []string{v}
+	}
+	return nil
+}
@@ -0,0 +1,376 @@
+package sidetree
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/extrimian/ssi-sdk/crypto"
+	"github.com/extrimian/ssi-sdk/crypto/jwx"
+	"github.com/extrimian/ssi-sdk/did"
+	"github.com/extrimian/ssi-sdk/did/resolution"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNode(t *testing.T) {
+	node, err := NewNode[[]string](testProtocol)
+	require.NoError(t, err)
+	server := httptest.NewTLSServer(node)
+	defer server.Close()
+	resolver, err := NewResolver[[]string](testProtocol, server.Client(), server.URL)
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	d, createRequest, err := NewDID[[]string](testProtocol, testDocument())
+	require.NoError(t, err)
+
+	t.Run("unpublished long form DID", func(tt *testing.T) {
+		result, err := node.Resolve(ctx, d.LongForm())
+		assert.NoError(tt, err)
+		assert.False(tt, result.DocumentMetadata.Method.Published)
+
+		_, err = resolver.Resolve(ctx, d.ID())
+		assert.ErrorContains(tt, err, "could not resolve DID")
+	})
+
+	t.Run("create", func(tt *testing.T) {
+		result, err := resolver.Anchor(ctx, createRequest)
+		assert.NoError(tt, err)
+		assert.Equal(tt, d.ID(), result.Document.ID)
+
+		_, err = resolver.Anchor(ctx, createRequest)
+		assert.ErrorContains(tt, err, "already exists")
+
+		result, err = resolver.Resolve(ctx, d.ID())
+		assert.NoError(tt, err)
+		assert.Equal(tt, d.ID(), result.Document.ID)
+		assert.Len(tt, result.Document.VerificationMethod, 1)
+		assert.Len(tt, result.Document.Services, 1)
+		assert.Equal(tt, "1", result.DocumentMetadata.VersionID)
+		assert.True(tt, result.DocumentMetadata.Method.Published)
+		assert.ElementsMatch(tt, createRequest.Delta.UpdateCommitment, result.DocumentMetadata.Method.UpdateCommitment)
+		assert.ElementsMatch(tt, createRequest.SuffixData.RecoveryCommitment, result.DocumentMetadata.Method.RecoveryCommitment)
+	})
+
+	t.Run("update", func(tt *testing.T) {
+		updatedDID, updateRequest, err := d.Update(StateChange{ServiceIDsToRemove: []string{"service1"}})
+		require.NoError(tt, err)
+
+		tampered := *updateRequest
+		tampered.Delta = NewDelta(updateRequest.Delta.UpdateCommitment)
+		tampered.Delta.AddRemovePublicKeysAction(RemovePublicKeysAction{Action: RemovePublicKeys, IDs: []string{"key1"}})
+		_, err = resolver.Anchor(ctx, tampered)
+		assert.ErrorContains(tt, err, "delta hash does not match the delta")
+
+		// updates whose patches cannot be applied are rejected
+		nextUpdateKey, _, err := generateKeyPair()
+		require.NoError(tt, err)
+		invalidKey := testDocument().PublicKeys[0]
+		invalidKey.ID, invalidKey.Purposes = "key2", []PublicKeyPurpose{"unknown"}
+		unsigned, err := PrepareUpdateRequest[[]string](testProtocol, d.suffix, d.updateKey, *nextUpdateKey, StateChange{PublicKeysToAdd: []PublicKey{invalidKey}})
+		require.NoError(tt, err)
+		signer, err := NewBTCSignerVerifier(d.updatePrivateKey)
+		require.NoError(tt, err)
+		invalidUpdate, err := unsigned.Sign(signer)
+		require.NoError(tt, err)
+		_, err = resolver.Anchor(ctx, invalidUpdate)
+		assert.ErrorContains(tt, err, "unknown key purpose")
+
+		result, err := resolver.Anchor(ctx, updateRequest)
+		assert.NoError(tt, err)
+		assert.Empty(tt, result.Document.Services)
+		assert.ElementsMatch(tt, updateRequest.Delta.UpdateCommitment, result.DocumentMetadata.Method.UpdateCommitment)
+
+		// the update key has been revealed, so it cannot be used again
+		_, err = resolver.Anchor(ctx, updateRequest)
+		assert.ErrorContains(tt, err, "key does not match the commitment")
+
+		d = updatedDID
+	})
+
+	t.Run("recover", func(tt *testing.T) {
+		// the update key cannot recover
+		_, badRecoverRequest, err := DID[[]string]{
+			protocol:           d.protocol,
+			id:                 d.id,
+			suffix:             d.suffix,
+			longFormDID:        d.longFormDID,
+			updateKey:          d.updateKey,
+			recoveryKey:        d.updateKey,
+			updatePrivateKey:   d.updatePrivateKey,
+			recoveryPrivateKey: d.updatePrivateKey,
+		}.Recover(testDocument())
+		require.NoError(tt, err)
+		_, err = resolver.Anchor(ctx, badRecoverRequest)
+		assert.ErrorContains(tt, err, "invalid recovery key")
+
+		// recoveries whose patches cannot be applied are rejected
+		invalidDocument := testDocument()
+		invalidDocument.PublicKeys[0].Purposes = []PublicKeyPurpose{"unknown"}
+		_, invalidRecoverRequest, err := d.Recover(invalidDocument)
+		require.NoError(tt, err)
+		_, err = resolver.Anchor(ctx, invalidRecoverRequest)
+		assert.ErrorContains(tt, err, "unknown key purpose")
+
+		recoveredDID, recoverRequest, err := d.Recover(Document{PublicKeys: testDocument().PublicKeys})
+		require.NoError(tt, err)
+		result, err := resolver.Anchor(ctx, recoverRequest)
+		assert.NoError(tt, err)
+		assert.Empty(tt, result.Document.Services)
+		assert.Len(tt, result.Document.VerificationMethod, 1)
+		assert.Equal(tt, "3", result.DocumentMetadata.VersionID)
+
+		d = recoveredDID
+	})
+
+	t.Run("history", func(tt *testing.T) {
+		result, err := resolver.Resolve(ctx, d.ID(), resolution.WithVersionID("1"))
+		assert.NoError(tt, err)
+		assert.Len(tt, result.Document.Services, 1)
+
+		_, err = resolver.Resolve(ctx, d.ID(), resolution.WithVersionID("4"))
+		assert.ErrorContains(tt, err, "could not resolve DID")
+
+		operations, err := node.Operations(d.ID())
+		assert.NoError(tt, err)
+		require.Len(tt, operations, 3)
+		assert.Equal(tt, Create, operations[0].GetType())
+		assert.Equal(tt, Update, operations[1].GetType())
+		assert.Equal(tt, Recover, operations[2].GetType())
+	})
+
+	t.Run("deactivate", func(tt *testing.T) {
+		deactivatedDID, deactivateRequest, err := d.Deactivate()
+		require.NoError(tt, err)
+		_, err = resolver.Anchor(ctx, deactivateRequest)
+		assert.NoError(tt, err)
+
+		result, err := resolver.Resolve(ctx, d.ID())
+		assert.NoError(tt, err)
+		assert.True(tt, result.DocumentMetadata.Deactivated)
+		assert.Empty(tt, result.Document.VerificationMethod)
+		assert.Nil(tt, result.DocumentMetadata.Method.UpdateCommitment)
+
+		_, updateRequest, err := deactivatedDID.Update(StateChange{ServiceIDsToRemove: []string{"service1"}})
+		require.NoError(tt, err)
+		_, err = resolver.Anchor(ctx, updateRequest)
+		assert.ErrorContains(tt, err, "is deactivated")
+	})
+
+	t.Run("published long form DID", func(tt *testing.T) {
+		result, err := node.Resolve(ctx, d.LongForm())
+		assert.NoError(tt, err)
+		assert.True(tt, result.DocumentMetadata.Method.Published)
+		assert.Equal(tt, d.ID(), result.DocumentMetadata.CanonicalID)
+	})
+}
+
+func TestNodeHandler(t *testing.T) {
+	node, err := NewNode[string](Protocol{Prefixes: []string{"did:test"}, OperationsPath: "1.0/operations"})
+	require.NoError(t, err)
+	assert.Equal(t, []did.Method{"test"}, node.Methods())
+
+	tests := []struct {
+		method string
+		path   string
+		body   string
+		status int
+	}{
+		{http.MethodGet, "/1.0/operations", "", http.StatusMethodNotAllowed},
+		{http.MethodPost, "/operations", "{}", http.StatusNotFound},
+		{http.MethodPost, "/1.0/operations", `{"type":"unknown"}`, http.StatusBadRequest},
+		{http.MethodPost, "/1.0/operations", `{"type":"deactivate","didSuffix":"EiAbc"}`, http.StatusBadRequest},
+		{http.MethodPost, "/identifiers/did:test:EiAbc", "", http.StatusMethodNotAllowed},
+		{http.MethodGet, "/identifiers/did:test:EiAbc", "", http.StatusNotFound},
+		{http.MethodGet, "/identifiers/did:ion:EiAbc", "", http.StatusBadRequest},
+		{http.MethodGet, "/identifiers/did:test:EiAbc?versionTime=yesterday", "", http.StatusBadRequest},
+	}
+	for _, test := range tests {
+		t.Run(test.method+" "+test.path, func(tt *testing.T) {
+			recorder := httptest.NewRecorder()
+			node.ServeHTTP(recorder, httptest.NewRequest(test.method, test.path, strings.NewReader(test.body)))
+			assert.Equal(tt, test.status, recorder.Code)
+		})
+	}
+
+	_, err = NewNode[string](Protocol{})
+	assert.ErrorContains(t, err, "invalid protocol")
+}
+
+func TestNodeExternalSigner(t *testing.T) {
+	node, err := NewNode[string](testProtocol)
+	require.NoError(t, err)
+	server := httptest.NewTLSServer(node)
+	defer server.Close()
+	resolver, err := NewResolver[string](testProtocol, server.Client(), server.URL)
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	// the keys are held by the signers, as they would be by a KMS
+	recoveryKey, recoverySigner := generateSigner(t)
+	updateKey, updateSigner := generateSigner(t)
+
+	d, createRequest, err := NewDIDFromKeys[string](testProtocol, recoveryKey, updateKey, testDocument())
+	require.NoError(t, err)
+	assert.Empty(t, d.GetUpdatePrivateKey())
+	assert.Empty(t, d.GetRecoveryPrivateKey())
+	_, err = resolver.Anchor(ctx, createRequest)
+	require.NoError(t, err)
+
+	t.Run("private key operations need a signer", func(tt *testing.T) {
+		_, _, err := d.Update(StateChange{ServiceIDsToRemove: []string{"service1"}})
+		assert.ErrorContains(tt, err, "use UpdateWithSigner")
+		_, _, err = d.Recover(testDocument())
+		assert.ErrorContains(tt, err, "use RecoverWithSigner")
+		_, _, err = d.Deactivate()
+		assert.ErrorContains(tt, err, "use DeactivateWithSigner")
+	})
+
+	t.Run("prepare, sign and assemble an update", func(tt *testing.T) {
+		nextUpdateKey, nextUpdateSigner := generateSigner(tt)
+		unsigned, err := d.PrepareUpdate(nextUpdateKey, StateChange{ServiceIDsToRemove: []string{"service1"}})
+		require.NoError(tt, err)
+		hash, err := unsigned.SigningInput.Hash()
+		require.NoError(tt, err)
+
+		wrongSignature, err := recoverySigner.Sign(hash)
+		require.NoError(tt, err)
+		_, _, err = d.AssembleUpdate(*unsigned, wrongSignature)
+		assert.ErrorContains(tt, err, "update request is not signed by the update key")
+
+		_, _, err = d.AssembleUpdate(*unsigned, wrongSignature[:32])
+		assert.ErrorContains(tt, err, "invalid signature length: 32")
+
+		signature, err := updateSigner.Sign(hash)
+		require.NoError(tt, err)
+
+		// a delta changed after the signing input was prepared is not the signed one
+		tampered := *unsigned
+		tampered.Request.Delta = NewDelta(unsigned.Request.Delta.UpdateCommitment)
+		tampered.Request.Delta.AddRemovePublicKeysAction(RemovePublicKeysAction{Action: RemovePublicKeys, IDs: []string{"key1"}})
+		_, _, err = d.AssembleUpdate(tampered, signature)
+		assert.ErrorContains(tt, err, "delta hash of the signed data does not match the delta")
+
+		updatedDID, updateRequest, err := d.AssembleUpdate(*unsigned, signature)
+		require.NoError(tt, err)
+		assert.Equal(tt, nextUpdateKey, updatedDID.GetUpdateKey())
+		assert.Len(tt, updatedDID.Operations(), 2)
+		assert.Len(tt, d.Operations(), 1)
+
+		result, err := resolver.Anchor(ctx, updateRequest)
+		assert.NoError(tt, err)
+		assert.Empty(tt, result.Document.Services)
+
+		d, updateSigner = updatedDID, nextUpdateSigner
+	})
+
+	t.Run("recover with a signer", func(tt *testing.T) {
+		nextRecoveryKey, nextRecoverySigner := generateSigner(tt)
+		nextUpdateKey, nextUpdateSigner := generateSigner(tt)
+
+		unsigned, err := d.PrepareRecover(nextRecoveryKey, nextUpdateKey, testDocument())
+		require.NoError(tt, err)
+		hash, err := unsigned.SigningInput.Hash()
+		require.NoError(tt, err)
+		signature, err := recoverySigner.Sign(hash)
+		require.NoError(tt, err)
+		tampered := *unsigned
+		tampered.Request.Delta = NewDelta(unsigned.Request.Delta.UpdateCommitment)
+		tampered.Request.Delta.AddReplaceAction(ReplaceAction{Action: Replace, Document: Document{}})
+		_, _, err = d.AssembleRecover(tampered, signature)
+		assert.ErrorContains(tt, err, "delta hash of the signed data does not match the delta")
+
+		_, _, err = d.RecoverWithSigner(updateSigner, nextRecoveryKey, nextUpdateKey, testDocument())
+		assert.ErrorContains(tt, err, "recover request is not signed by the recovery key")
+
+		recoveredDID, recoverRequest, err := d.RecoverWithSigner(recoverySigner, nextRecoveryKey, nextUpdateKey, testDocument())
+		require.NoError(tt, err)
+		assert.Equal(tt, nextRecoveryKey, recoveredDID.GetRecoveryKey())
+		assert.Equal(tt, nextUpdateKey, recoveredDID.GetUpdateKey())
+		result, err := resolver.Anchor(ctx, recoverRequest)
+		assert.NoError(tt, err)
+		assert.Len(tt, result.Document.Services, 1)
+
+		d, recoverySigner, updateSigner = recoveredDID, nextRecoverySigner, nextUpdateSigner
+	})
+
+	t.Run("assemble for another DID", func(tt *testing.T) {
+		other, _, err := NewDIDFromKeys[string](testProtocol, generateKey(tt), generateKey(tt), testDocument())
+		require.NoError(tt, err)
+		unsigned, err := other.PrepareDeactivate()
+		require.NoError(tt, err)
+		hash, err := unsigned.SigningInput.Hash()
+		require.NoError(tt, err)
+		signature, err := recoverySigner.Sign(hash)
+		require.NoError(tt, err)
+		_, _, err = d.AssembleDeactivate(*unsigned, signature)
+		assert.Error(tt, err)
+	})
+
+	t.Run("deactivate with a signer", func(tt *testing.T) {
+		_, deactivateRequest, err := d.DeactivateWithSigner(recoverySigner)
+		require.NoError(tt, err)
+		_, err = resolver.Anchor(ctx, deactivateRequest)
+		assert.NoError(tt, err)
+
+		result, err := resolver.Resolve(ctx, d.ID())
+		assert.NoError(tt, err)
+		assert.True(tt, result.DocumentMetadata.Deactivated)
+	})
+}
+
+func TestNodeKeyManagerSigner(t *testing.T) {
+	node, err := NewNode[string](testProtocol)
+	require.NoError(t, err)
+	server := httptest.NewTLSServer(node)
+	defer server.Close()
+	resolver, err := NewResolver[string](testProtocol, server.Client(), server.URL)
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	keyManager := crypto.NewInMemoryKeyManager()
+	signer := func(keyID string) *KeyManagerSigner {
+		_, err := keyManager.GenerateKey(keyID, crypto.SECP256k1)
+		require.NoError(t, err)
+		kmSigner, err := NewKeyManagerSigner(keyManager, keyID)
+		require.NoError(t, err)
+		return kmSigner
+	}
+	recoverySigner, updateSigner, nextUpdateSigner := signer("recovery"), signer("update"), signer("next-update")
+
+	d, createRequest, err := NewDIDFromKeys[string](testProtocol, recoverySigner.PublicKeyJWK(), updateSigner.PublicKeyJWK(), testDocument())
+	require.NoError(t, err)
+	_, err = resolver.Anchor(ctx, createRequest)
+	require.NoError(t, err)
+
+	d, updateRequest, err := d.UpdateWithSigner(updateSigner, nextUpdateSigner.PublicKeyJWK(), StateChange{ServiceIDsToRemove: []string{"service1"}})
+	require.NoError(t, err)
+	result, err := resolver.Anchor(ctx, updateRequest)
+	require.NoError(t, err)
+	assert.Empty(t, result.Document.Services)
+
+	_, deactivateRequest, err := d.DeactivateWithSigner(recoverySigner)
+	require.NoError(t, err)
+	_, err = resolver.Anchor(ctx, deactivateRequest)
+	assert.NoError(t, err)
+
+	// sidetree operations are signed with secp256k1 keys
+	_, err = keyManager.GenerateKey("p256", crypto.P256)
+	require.NoError(t, err)
+	_, err = NewKeyManagerSigner(keyManager, "p256")
+	assert.ErrorContains(t, err, "unsupported key for sidetree signer: EC/P-256")
+}
+
+func generateSigner(t *testing.T) (jwx.PublicKeyJWK, Signer) {
+	t.Helper()
+	_, privateKey, err := crypto.GenerateSECP256k1Key()
+	require.NoError(t, err)
+	publicKeyJWK, privateKeyJWK, err := jwx.PrivateKeyToPrivateKeyJWK("", privateKey)
+	require.NoError(t, err)
+	signer, err := NewBTCSignerVerifier(*privateKeyJWK)
+	require.NoError(t, err)
+	return *publicKeyJWK, signer
+}
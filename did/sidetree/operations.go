@@ -0,0 +1,391 @@
+package sidetree
+
+import (
+	"reflect"
+
+	"github.com/extrimian/ssi-sdk/crypto"
+	"github.com/extrimian/ssi-sdk/crypto/jwx"
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+)
+
+// DID is a representation of a Sidetree DID and should be used to maintain the state of its
+// DID Document. It contains the DID suffix, the long form DID, the operations of the DID, and the
+// current update and recovery public keys. DIDs created with NewDID also hold the update and recovery
+// private keys, while DIDs created with NewDIDFromKeys leave them to the caller, such as in a KMS, and
+// are operated on with a Signer or by preparing and assembling operations. All receiver methods are side
+// effect free, and return new instances of DID with the updated state.
+type DID[C Commitment] struct {
+	protocol           Protocol
+	id                 string
+	suffix             string
+	longFormDID        string
+	operations         []any
+	updateKey          jwx.PublicKeyJWK
+	recoveryKey        jwx.PublicKeyJWK
+	updatePrivateKey   jwx.PrivateKeyJWK
+	recoveryPrivateKey jwx.PrivateKeyJWK
+}
+
+func (d DID[C]) IsEmpty() bool {
+	return reflect.DeepEqual(d, DID[C]{})
+}
+
+func (d DID[C]) ID() string {
+	return d.id
+}
+
+func (d DID[C]) Suffix() string {
+	return d.suffix
+}
+
+func (d DID[C]) LongForm() string {
+	return d.longFormDID
+}
+
+func (d DID[C]) Operations() []any {
+	return d.operations
+}
+
+func (d DID[C]) Operation(index int) any {
+	return d.operations[index]
+}
+
+// GetUpdateKey returns the public key the next update must be signed with
+func (d DID[C]) GetUpdateKey() jwx.PublicKeyJWK {
+	return d.updateKey
+}
+
+// GetRecoveryKey returns the public key the next recovery or deactivation must be signed with
+func (d DID[C]) GetRecoveryKey() jwx.PublicKeyJWK {
+	return d.recoveryKey
+}
+
+// GetUpdatePrivateKey returns the update private key, which is empty unless generated by the DID
+func (d DID[C]) GetUpdatePrivateKey() jwx.PrivateKeyJWK {
+	return d.updatePrivateKey
+}
+
+// GetRecoveryPrivateKey returns the recovery private key, which is empty unless generated by the DID
+func (d DID[C]) GetRecoveryPrivateKey() jwx.PrivateKeyJWK {
+	return d.recoveryPrivateKey
+}
+
+// NewDID creates a new DID of the protocol with a new recovery and update key pairs, of type secp256k1, in addition
+// to any content passed into in the document parameter. The result is a DID object that contains the long form DID,
+// and operations to be submitted to an anchor service.
+func NewDID[C Commitment](p Protocol, doc Document) (*DID[C], *CreateRequest[C], error) {
+	// generate update key pair
+	updatePubKeyJWK, updatePrivKeyJWK, err := generateKeyPair()
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "generating update keypair")
+	}
+
+	// generate recovery key pair
+	recoveryPubKeyJWK, recoveryPrivKeyJWK, err := generateKeyPair()
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "generating recovery keypair")
+	}
+
+	d, createRequest, err := NewDIDFromKeys[C](p, *recoveryPubKeyJWK, *updatePubKeyJWK, doc)
+	if err != nil {
+		return nil, nil, err
+	}
+	d.updatePrivateKey = *updatePrivKeyJWK
+	d.recoveryPrivateKey = *recoveryPrivKeyJWK
+	return d, createRequest, nil
+}
+
+// NewDIDFromKeys creates a new DID of the protocol with the caller's secp256k1 recovery and update public keys, in
+// addition to any content passed into in the document parameter. The private keys are never needed by the DID; its
+// operations are signed with a Signer, or prepared and assembled with signatures made elsewhere.
+func NewDIDFromKeys[C Commitment](p Protocol, recoveryKey, updateKey jwx.PublicKeyJWK, doc Document) (*DID[C], *CreateRequest[C], error) {
+	if err := p.Validate(); err != nil {
+		return nil, nil, errors.Wrap(err, "invalid protocol")
+	}
+	if doc.IsEmpty() {
+		return nil, nil, errors.New("document cannot be empty")
+	}
+
+	createRequest, err := NewCreateRequest[C](p, recoveryKey, updateKey, doc)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "generating create request")
+	}
+
+	longFormDID, err := CreateLongFormDID[C](p, recoveryKey, updateKey, doc)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "generating long form DID")
+	}
+	shortFormDID, err := LongToShortFormDID[C](p, longFormDID)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "generating short form DID")
+	}
+	suffix, err := p.Suffix(shortFormDID)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "generating DID suffix")
+	}
+
+	return &DID[C]{
+		protocol:    p,
+		id:          shortFormDID,
+		suffix:      suffix,
+		longFormDID: longFormDID,
+		operations:  []any{createRequest},
+		updateKey:   updateKey,
+		recoveryKey: recoveryKey,
+	}, createRequest, nil
+}
+
+// Update updates the DID object's state with a provided state change object. The result is a new DID object
+// with the update key pair and an update operation to be submitted to an anchor service.
+func (d DID[C]) Update(stateChange StateChange) (*DID[C], *UpdateRequest[C], error) {
+	if d.IsEmpty() {
+		return nil, nil, errors.New("DID cannot be empty")
+	}
+	if d.updatePrivateKey.D == "" {
+		return nil, nil, errors.New("DID does not hold its update private key; use UpdateWithSigner")
+	}
+
+	// generate next update key pair
+	nextUpdatePubKeyJWK, nextUpdatePrivKeyJWK, err := generateKeyPair()
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "generating next update keypair")
+	}
+
+	// create a signer with the current update key
+	signer, err := NewBTCSignerVerifier(d.updatePrivateKey)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "creating signer")
+	}
+
+	updatedDID, updateRequest, err := d.UpdateWithSigner(signer, *nextUpdatePubKeyJWK, stateChange)
+	if err != nil {
+		return nil, nil, err
+	}
+	updatedDID.updatePrivateKey = *nextUpdatePrivKeyJWK
+	return updatedDID, updateRequest, nil
+}
+
+// UpdateWithSigner updates the DID object's state with a provided state change object, signing with the signer of the
+// current update key and committing to the next update key. The result is a new DID object and an update operation
+// to be submitted to an anchor service.
+func (d DID[C]) UpdateWithSigner(signer Signer, nextUpdateKey jwx.PublicKeyJWK, stateChange StateChange) (*DID[C], *UpdateRequest[C], error) {
+	unsigned, err := d.PrepareUpdate(nextUpdateKey, stateChange)
+	if err != nil {
+		return nil, nil, err
+	}
+	updateRequest, err := unsigned.Sign(signer)
+	if err != nil {
+		return nil, nil, err
+	}
+	return d.withUpdate(*unsigned, updateRequest)
+}
+
+// PrepareUpdate prepares an update operation committing to the next update key, whose signed data is to be signed
+// with the current update key and assembled with AssembleUpdate
+func (d DID[C]) PrepareUpdate(nextUpdateKey jwx.PublicKeyJWK, stateChange StateChange) (*UnsignedUpdateRequest[C], error) {
+	if d.IsEmpty() {
+		return nil, errors.New("DID cannot be empty")
+	}
+	if err := stateChange.IsValid(); err != nil {
+		return nil, errors.Wrap(err, "invalid state change")
+	}
+	unsigned, err := PrepareUpdateRequest[C](d.protocol, d.suffix, d.updateKey, nextUpdateKey, stateChange)
+	if err != nil {
+		return nil, errors.Wrap(err, "generating update request")
+	}
+	return unsigned, nil
+}
+
+// AssembleUpdate completes a prepared update operation with the signature of the current update key over the hash
+// of its signing input, returning a new DID object and the update operation to be submitted to an anchor service.
+func (d DID[C]) AssembleUpdate(unsigned UnsignedUpdateRequest[C], signature []byte) (*DID[C], *UpdateRequest[C], error) {
+	updateRequest, err := unsigned.Assemble(signature)
+	if err != nil {
+		return nil, nil, err
+	}
+	return d.withUpdate(unsigned, updateRequest)
+}
+
+func (d DID[C]) withUpdate(unsigned UnsignedUpdateRequest[C], updateRequest *UpdateRequest[C]) (*DID[C], *UpdateRequest[C], error) {
+	if unsigned.Request.DIDSuffix != d.suffix || !reflect.DeepEqual(unsigned.UpdateKey, d.updateKey) {
+		return nil, nil, errors.New("update request was not prepared for the DID")
+	}
+	updatedDID := d
+	updatedDID.operations = append(append([]any(nil), d.operations...), updateRequest)
+	updatedDID.updateKey = unsigned.NextUpdateKey
+	updatedDID.updatePrivateKey = jwx.PrivateKeyJWK{}
+	return &updatedDID, updateRequest, nil
+}
+
+// Recover recovers the DID object's state with a provided document object, returning a new DID object and
+// recover operation to be submitted to an anchor service.
+func (d DID[C]) Recover(doc Document) (*DID[C], *RecoverRequest[C], error) {
+	if d.IsEmpty() {
+		return nil, nil, errors.New("DID cannot be empty")
+	}
+	if d.recoveryPrivateKey.D == "" {
+		return nil, nil, errors.New("DID does not hold its recovery private key; use RecoverWithSigner")
+	}
+
+	// generate next recovery key pair
+	nextRecoveryPubKeyJWK, nextRecoveryPrivKeyJWK, err := generateKeyPair()
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "generating next recovery keypair")
+	}
+
+	// generate next update key pair
+	nextUpdatePubKeyJWK, nextUpdatePrivKeyJWK, err := generateKeyPair()
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "generating next update keypair")
+	}
+
+	// create a signer with the current recovery key
+	signer, err := NewBTCSignerVerifier(d.recoveryPrivateKey)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "creating signer")
+	}
+
+	recoveredDID, recoverRequest, err := d.RecoverWithSigner(signer, *nextRecoveryPubKeyJWK, *nextUpdatePubKeyJWK, doc)
+	if err != nil {
+		return nil, nil, err
+	}
+	recoveredDID.updatePrivateKey = *nextUpdatePrivKeyJWK
+	recoveredDID.recoveryPrivateKey = *nextRecoveryPrivKeyJWK
+	return recoveredDID, recoverRequest, nil
+}
+
+// RecoverWithSigner recovers the DID object's state with a provided document object, signing with the signer of the
+// current recovery key and committing to the next recovery and update keys. The result is a new DID object and a
+// recover operation to be submitted to an anchor service.
+func (d DID[C]) RecoverWithSigner(signer Signer, nextRecoveryKey, nextUpdateKey jwx.PublicKeyJWK, doc Document) (*DID[C], *RecoverRequest[C], error) {
+	unsigned, err := d.PrepareRecover(nextRecoveryKey, nextUpdateKey, doc)
+	if err != nil {
+		return nil, nil, err
+	}
+	recoverRequest, err := unsigned.Sign(signer)
+	if err != nil {
+		return nil, nil, err
+	}
+	return d.withRecover(*unsigned, recoverRequest)
+}
+
+// PrepareRecover prepares a recover operation committing to the next recovery and update keys, whose signed data is
+// to be signed with the current recovery key and assembled with AssembleRecover
+func (d DID[C]) PrepareRecover(nextRecoveryKey, nextUpdateKey jwx.PublicKeyJWK, doc Document) (*UnsignedRecoverRequest[C], error) {
+	if d.IsEmpty() {
+		return nil, errors.New("DID cannot be empty")
+	}
+	if doc.IsEmpty() {
+		return nil, errors.New("document cannot be empty")
+	}
+	unsigned, err := PrepareRecoverRequest[C](d.protocol, d.suffix, d.recoveryKey, nextRecoveryKey, nextUpdateKey, doc)
+	if err != nil {
+		return nil, errors.Wrap(err, "generating recover request")
+	}
+	return unsigned, nil
+}
+
+// AssembleRecover completes a prepared recover operation with the signature of the current recovery key over the
+// hash of its signing input, returning a new DID object and the recover operation to be submitted to an anchor service.
+func (d DID[C]) AssembleRecover(unsigned UnsignedRecoverRequest[C], signature []byte) (*DID[C], *RecoverRequest[C], error) {
+	recoverRequest, err := unsigned.Assemble(signature)
+	if err != nil {
+		return nil, nil, err
+	}
+	return d.withRecover(unsigned, recoverRequest)
+}
+
+func (d DID[C]) withRecover(unsigned UnsignedRecoverRequest[C], recoverRequest *RecoverRequest[C]) (*DID[C], *RecoverRequest[C], error) {
+	if unsigned.Request.DIDSuffix != d.suffix || !reflect.DeepEqual(unsigned.RecoveryKey, d.recoveryKey) {
+		return nil, nil, errors.New("recover request was not prepared for the DID")
+	}
+	recoveredDID := d
+	recoveredDID.operations = append(append([]any(nil), d.operations...), recoverRequest)
+	recoveredDID.updateKey = unsigned.NextUpdateKey
+	recoveredDID.recoveryKey = unsigned.NextRecoveryKey
+	recoveredDID.updatePrivateKey = jwx.PrivateKeyJWK{}
+	recoveredDID.recoveryPrivateKey = jwx.PrivateKeyJWK{}
+	return &recoveredDID, recoverRequest, nil
+}
+
+// Deactivate creates a terminal state DID and the corresponding anchor operation to submit to the anchor service.
+func (d DID[C]) Deactivate() (*DID[C], *DeactivateRequest, error) {
+	if d.IsEmpty() {
+		return nil, nil, errors.New("DID cannot be empty")
+	}
+	if d.recoveryPrivateKey.D == "" {
+		return nil, nil, errors.New("DID does not hold its recovery private key; use DeactivateWithSigner")
+	}
+
+	// create a signer with the current recovery key
+	signer, err := NewBTCSignerVerifier(d.recoveryPrivateKey)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "creating signer")
+	}
+	return d.DeactivateWithSigner(signer)
+}
+
+// DeactivateWithSigner creates a terminal state DID and the corresponding anchor operation to submit to the anchor
+// service, signing with the signer of the current recovery key.
+func (d DID[C]) DeactivateWithSigner(signer Signer) (*DID[C], *DeactivateRequest, error) {
+	unsigned, err := d.PrepareDeactivate()
+	if err != nil {
+		return nil, nil, err
+	}
+	deactivateRequest, err := unsigned.Sign(signer)
+	if err != nil {
+		return nil, nil, err
+	}
+	return d.withDeactivate(*unsigned, deactivateRequest)
+}
+
+// PrepareDeactivate prepares a deactivate operation, whose signed data is to be signed with the current recovery key
+// and assembled with AssembleDeactivate
+func (d DID[C]) PrepareDeactivate() (*UnsignedDeactivateRequest, error) {
+	if d.IsEmpty() {
+		return nil, errors.New("DID cannot be empty")
+	}
+	unsigned, err := PrepareDeactivateRequest(d.protocol, d.suffix, d.recoveryKey)
+	if err != nil {
+		return nil, errors.Wrap(err, "generating deactivate request")
+	}
+	return unsigned, nil
+}
+
+// AssembleDeactivate completes a prepared deactivate operation with the signature of the current recovery key over
+// the hash of its signing input, returning the terminal state DID and the deactivate operation to be submitted to an
+// anchor service.
+func (d DID[C]) AssembleDeactivate(unsigned UnsignedDeactivateRequest, signature []byte) (*DID[C], *DeactivateRequest, error) {
+	deactivateRequest, err := unsigned.Assemble(signature)
+	if err != nil {
+		return nil, nil, err
+	}
+	return d.withDeactivate(unsigned, deactivateRequest)
+}
+
+func (d DID[C]) withDeactivate(unsigned UnsignedDeactivateRequest, deactivateRequest *DeactivateRequest) (*DID[C], *DeactivateRequest, error) {
+	if unsigned.Request.DIDSuffix != d.suffix || !reflect.DeepEqual(unsigned.RecoveryKey, d.recoveryKey) {
+		return nil, nil, errors.New("deactivate request was not prepared for the DID")
+	}
+	deactivatedDID := d
+	deactivatedDID.operations = append(append([]any(nil), d.operations...), deactivateRequest)
+	return &deactivatedDID, deactivateRequest, nil
+}
+
+// generateKeyPair generates a secp256k1 update or recovery key pair
+func generateKeyPair() (*jwx.PublicKeyJWK, *jwx.PrivateKeyJWK, error) {
+	_, privateKey, err := crypto.GenerateSECP256k1Key()
+	if err != nil {
+		return nil, nil, err
+	}
+	publicKeyJWK, privateKeyJWK, err := jwx.PrivateKeyToPrivateKeyJWK(uuid.NewString(), privateKey)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "converting key pair to JWK")
+	}
+	return publicKeyJWK, privateKeyJWK, nil
+}
+
+func is2xxStatusCode(statusCode int) bool {
+	return statusCode >= 200 && statusCode < 300
+}
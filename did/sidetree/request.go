@@ -0,0 +1,491 @@
+package sidetree
+
+import (
+	"strings"
+
+	"github.com/extrimian/ssi-sdk/crypto/jwx"
+	"github.com/extrimian/ssi-sdk/did"
+	"github.com/goccy/go-json"
+	"github.com/multiformats/go-multihash"
+	"github.com/pkg/errors"
+)
+
+const (
+	maxIDLength          = 50
+	maxServiceTypeLength = 30
+)
+
+// NewCreateRequest creates a new create request https://identity.foundation/sidetree/spec/#create
+func NewCreateRequest[C Commitment](p Protocol, recoveryKey, updateKey jwx.PublicKeyJWK, document Document) (*CreateRequest[C], error) {
+	// prepare delta
+	replacePatch := ReplaceAction{
+		Action:   Replace,
+		Document: document,
+	}
+	_, updateCommitment, err := p.Commit(updateKey)
+	if err != nil {
+		return nil, err
+	}
+	delta := NewDelta(newCommitment[C](updateCommitment))
+	delta.AddReplaceAction(replacePatch)
+
+	// prepare suffix data
+	deltaCanonical, err := CanonicalizeAny(delta)
+	if err != nil {
+		return nil, err
+	}
+	deltaHash, err := p.HashEncode(deltaCanonical)
+	if err != nil {
+		return nil, err
+	}
+	_, recoveryCommitment, err := p.Commit(recoveryKey)
+	if err != nil {
+		return nil, err
+	}
+	suffixData := SuffixData[C]{
+		DeltaHash:          deltaHash,
+		RecoveryCommitment: newCommitment[C](recoveryCommitment),
+	}
+
+	return &CreateRequest[C]{
+		Type:       Create,
+		SuffixData: suffixData,
+		Delta:      delta,
+	}, nil
+}
+
+// NewUpdateRequest creates a new update request https://identity.foundation/sidetree/spec/#update signed by the
+// signer with the update key
+func NewUpdateRequest[C Commitment](p Protocol, didSuffix string, updateKey, nextUpdateKey jwx.PublicKeyJWK, signer Signer, stateChange StateChange) (*UpdateRequest[C], error) { //revive:disable-line:argument-limit
+	unsigned, err := PrepareUpdateRequest[C](p, didSuffix, updateKey, nextUpdateKey, stateChange)
+	if err != nil {
+		return nil, err
+	}
+	return unsigned.Sign(signer)
+}
+
+// PrepareUpdateRequest prepares an update request https://identity.foundation/sidetree/spec/#update whose signed
+// data is to be signed with the update key, such as by a KMS, and assembled
+func PrepareUpdateRequest[C Commitment](p Protocol, didSuffix string, updateKey, nextUpdateKey jwx.PublicKeyJWK, stateChange StateChange) (*UnsignedUpdateRequest[C], error) {
+	if err := stateChange.IsValid(); err != nil {
+		return nil, errors.Wrap(err, "invalid state change")
+	}
+
+	// prepare reveal value
+	revealValue, _, err := p.Commit(updateKey)
+	if err != nil {
+		return nil, errors.Wrap(err, "generating commitment for update key")
+	}
+
+	// prepare delta
+	_, nextUpdateCommitment, err := p.Commit(nextUpdateKey)
+	if err != nil {
+		return nil, errors.Wrap(err, "generating commitment for next update key")
+	}
+
+	delta := NewDelta(newCommitment[C](nextUpdateCommitment))
+
+	delta.Patches = append(delta.Patches, stateChange.Patches()...)
+
+	deltaCanonical, err := CanonicalizeAny(delta)
+	if err != nil {
+		return nil, errors.Wrap(err, "canonicalizing delta")
+	}
+	deltaHash, err := p.HashEncode(deltaCanonical)
+	if err != nil {
+		return nil, errors.Wrap(err, "hash-encoding delta")
+	}
+
+	// prepare signed data
+	toBeSigned := UpdateSignedDataObject{
+		UpdateKey: updateKey,
+		DeltaHash: deltaHash,
+	}
+	return &UnsignedUpdateRequest[C]{
+		Request: UpdateRequest[C]{
+			Type:        Update,
+			DIDSuffix:   didSuffix,
+			RevealValue: revealValue,
+			Delta:       delta,
+		},
+		SigningInput:  NewSigningInput(toBeSigned),
+		UpdateKey:     updateKey,
+		NextUpdateKey: nextUpdateKey,
+	}, nil
+}
+
+// NewRecoverRequest creates a new recover request https://identity.foundation/sidetree/spec/#recover
+func NewRecoverRequest[C Commitment](p Protocol, didSuffix string, recoveryKey, nextRecoveryKey, nextUpdateKey jwx.PublicKeyJWK, document Document, signer Signer) (*RecoverRequest[C], error) { //revive:disable-line:argument-limit
+	unsigned, err := PrepareRecoverRequest[C](p, didSuffix, recoveryKey, nextRecoveryKey, nextUpdateKey, document)
+	if err != nil {
+		return nil, err
+	}
+	return unsigned.Sign(signer)
+}
+
+// PrepareRecoverRequest prepares a recover request https://identity.foundation/sidetree/spec/#recover whose signed
+// data is to be signed with the recovery key, such as by a KMS, and assembled
+func PrepareRecoverRequest[C Commitment](p Protocol, didSuffix string, recoveryKey, nextRecoveryKey, nextUpdateKey jwx.PublicKeyJWK, document Document) (*UnsignedRecoverRequest[C], error) { //revive:disable-line:argument-limit
+	// prepare reveal value
+	revealValue, _, err := p.Commit(recoveryKey)
+	if err != nil {
+		return nil, err
+	}
+
+	// prepare delta
+	replaceAction := ReplaceAction{
+		Action:   Replace,
+		Document: document,
+	}
+
+	_, updateCommitment, err := p.Commit(nextUpdateKey)
+	if err != nil {
+		return nil, err
+	}
+
+	delta := NewDelta(newCommitment[C](updateCommitment))
+	delta.AddReplaceAction(replaceAction)
+
+	// prepare signed data
+	deltaCanonical, err := CanonicalizeAny(delta)
+	if err != nil {
+		return nil, err
+	}
+	deltaHash, err := p.HashEncode(deltaCanonical)
+	if err != nil {
+		return nil, err
+	}
+	_, recoveryCommitment, err := p.Commit(nextRecoveryKey)
+	if err != nil {
+		return nil, err
+	}
+
+	toBeSigned := RecoverySignedDataObject[C]{
+		RecoveryCommitment: newCommitment[C](recoveryCommitment),
+		RecoveryKey:        recoveryKey,
+		DeltaHash:          deltaHash,
+	}
+	return &UnsignedRecoverRequest[C]{
+		Request: RecoverRequest[C]{
+			Type:        Recover,
+			DIDSuffix:   didSuffix,
+			RevealValue: revealValue,
+			Delta:       delta,
+		},
+		SigningInput:    NewSigningInput(toBeSigned),
+		RecoveryKey:     recoveryKey,
+		NextRecoveryKey: nextRecoveryKey,
+		NextUpdateKey:   nextUpdateKey,
+	}, nil
+}
+
+// NewDeactivateRequest creates a new deactivate request https://identity.foundation/sidetree/spec/#deactivate signed
+// by the signer with the recovery key
+func NewDeactivateRequest(p Protocol, didSuffix string, recoveryKey jwx.PublicKeyJWK, signer Signer) (*DeactivateRequest, error) {
+	unsigned, err := PrepareDeactivateRequest(p, didSuffix, recoveryKey)
+	if err != nil {
+		return nil, err
+	}
+	return unsigned.Sign(signer)
+}
+
+// PrepareDeactivateRequest prepares a deactivate request https://identity.foundation/sidetree/spec/#deactivate whose
+// signed data is to be signed with the recovery key, such as by a KMS, and assembled
+func PrepareDeactivateRequest(p Protocol, didSuffix string, recoveryKey jwx.PublicKeyJWK) (*UnsignedDeactivateRequest, error) {
+	// prepare reveal value
+	revealValue, _, err := p.Commit(recoveryKey)
+	if err != nil {
+		return nil, err
+	}
+
+	// prepare signed data
+	toBeSigned := DeactivateSignedDataObject{
+		DIDSuffix:   didSuffix,
+		RecoveryKey: recoveryKey,
+	}
+	return &UnsignedDeactivateRequest{
+		Request: DeactivateRequest{
+			Type:        Deactivate,
+			DIDSuffix:   didSuffix,
+			RevealValue: revealValue,
+		},
+		SigningInput: NewSigningInput(toBeSigned),
+		RecoveryKey:  recoveryKey,
+	}, nil
+}
+
+// UnsignedUpdateRequest is an update request whose signed data is yet to be signed with the update key
+type UnsignedUpdateRequest[C Commitment] struct {
+	Request       UpdateRequest[C]
+	SigningInput  SigningInput
+	UpdateKey     jwx.PublicKeyJWK
+	NextUpdateKey jwx.PublicKeyJWK
+}
+
+// Sign signs the request with the signer of the update key
+func (u UnsignedUpdateRequest[C]) Sign(signer Signer) (*UpdateRequest[C], error) {
+	signedData, err := u.SigningInput.Sign(signer)
+	if err != nil {
+		return nil, errors.Wrap(err, "signing update request")
+	}
+	return u.assemble(signedData)
+}
+
+// Assemble completes the request with the signature of the update key over the hash of the signing input
+func (u UnsignedUpdateRequest[C]) Assemble(signature []byte) (*UpdateRequest[C], error) {
+	signedData, err := u.SigningInput.Assemble(signature)
+	if err != nil {
+		return nil, errors.Wrap(err, "assembling update request")
+	}
+	return u.assemble(signedData)
+}
+
+func (u UnsignedUpdateRequest[C]) assemble(signedData string) (*UpdateRequest[C], error) {
+	if err := verifySignedData(signedData, u.UpdateKey); err != nil {
+		return nil, errors.Wrap(err, "update request is not signed by the update key")
+	}
+	if err := checkSignedDeltaHash(signedData, u.Request.Delta); err != nil {
+		return nil, errors.Wrap(err, "assembling update request")
+	}
+	request := u.Request
+	request.SignedData = signedData
+	return &request, nil
+}
+
+// UnsignedRecoverRequest is a recover request whose signed data is yet to be signed with the recovery key
+type UnsignedRecoverRequest[C Commitment] struct {
+	Request         RecoverRequest[C]
+	SigningInput    SigningInput
+	RecoveryKey     jwx.PublicKeyJWK
+	NextRecoveryKey jwx.PublicKeyJWK
+	NextUpdateKey   jwx.PublicKeyJWK
+}
+
+// Sign signs the request with the signer of the recovery key
+func (u UnsignedRecoverRequest[C]) Sign(signer Signer) (*RecoverRequest[C], error) {
+	signedData, err := u.SigningInput.Sign(signer)
+	if err != nil {
+		return nil, errors.Wrap(err, "signing recover request")
+	}
+	return u.assemble(signedData)
+}
+
+// Assemble completes the request with the signature of the recovery key over the hash of the signing input
+func (u UnsignedRecoverRequest[C]) Assemble(signature []byte) (*RecoverRequest[C], error) {
+	signedData, err := u.SigningInput.Assemble(signature)
+	if err != nil {
+		return nil, errors.Wrap(err, "assembling recover request")
+	}
+	return u.assemble(signedData)
+}
+
+func (u UnsignedRecoverRequest[C]) assemble(signedData string) (*RecoverRequest[C], error) {
+	if err := verifySignedData(signedData, u.RecoveryKey); err != nil {
+		return nil, errors.Wrap(err, "recover request is not signed by the recovery key")
+	}
+	if err := checkSignedDeltaHash(signedData, u.Request.Delta); err != nil {
+		return nil, errors.Wrap(err, "assembling recover request")
+	}
+	request := u.Request
+	request.SignedData = signedData
+	return &request, nil
+}
+
+// UnsignedDeactivateRequest is a deactivate request whose signed data is yet to be signed with the recovery key
+type UnsignedDeactivateRequest struct {
+	Request      DeactivateRequest
+	SigningInput SigningInput
+	RecoveryKey  jwx.PublicKeyJWK
+}
+
+// Sign signs the request with the signer of the recovery key
+func (u UnsignedDeactivateRequest) Sign(signer Signer) (*DeactivateRequest, error) {
+	signedData, err := u.SigningInput.Sign(signer)
+	if err != nil {
+		return nil, errors.Wrap(err, "signing deactivate request")
+	}
+	return u.assemble(signedData)
+}
+
+// Assemble completes the request with the signature of the recovery key over the hash of the signing input
+func (u UnsignedDeactivateRequest) Assemble(signature []byte) (*DeactivateRequest, error) {
+	signedData, err := u.SigningInput.Assemble(signature)
+	if err != nil {
+		return nil, errors.Wrap(err, "assembling deactivate request")
+	}
+	return u.assemble(signedData)
+}
+
+func (u UnsignedDeactivateRequest) assemble(signedData string) (*DeactivateRequest, error) {
+	if err := verifySignedData(signedData, u.RecoveryKey); err != nil {
+		return nil, errors.Wrap(err, "deactivate request is not signed by the recovery key")
+	}
+	request := u.Request
+	request.SignedData = signedData
+	return &request, nil
+}
+
+// verifySignedData checks the signature of a compact JWS with the key it was to be signed with, catching signers
+// using the wrong key or signature encoding before the operation is anchored
+func verifySignedData(signedData string, key jwx.PublicKeyJWK) error {
+	verifier, err := NewBTCVerifier(key)
+	if err != nil {
+		return err
+	}
+	verified, err := verifier.VerifyJWS(signedData)
+	if err != nil {
+		return err
+	}
+	if !verified {
+		return errors.New("invalid signature")
+	}
+	return nil
+}
+
+// checkSignedDeltaHash recomputes the hash of the delta with the hash algorithm of the delta hash in the signed data,
+// catching deltas changed after their signing input was prepared
+func checkSignedDeltaHash(signedData string, delta any) error {
+	parts := strings.Split(signedData, ".")
+	if len(parts) != 3 {
+		return errors.New("signed data is not a compact JWS")
+	}
+	payload, err := Decode(parts[1])
+	if err != nil {
+		return errors.Wrap(err, "decoding signed data")
+	}
+	var signed struct {
+		DeltaHash string `json:"deltaHash"`
+	}
+	if err = json.Unmarshal(payload, &signed); err != nil {
+		return errors.Wrap(err, "unmarshalling signed data")
+	}
+	decodedHash, err := Decode(signed.DeltaHash)
+	if err != nil {
+		return errors.Wrap(err, "decoding delta hash")
+	}
+	decodedMultihash, err := multihash.Decode(decodedHash)
+	if err != nil {
+		return errors.Wrap(err, "decoding delta hash multihash")
+	}
+	deltaCanonical, err := CanonicalizeAny(delta)
+	if err != nil {
+		return errors.Wrap(err, "canonicalizing delta")
+	}
+	deltaHash, err := Protocol{HashAlgorithm: decodedMultihash.Code}.HashEncode(deltaCanonical)
+	if err != nil {
+		return errors.Wrap(err, "hash-encoding delta")
+	}
+	if deltaHash != signed.DeltaHash {
+		return errors.New("delta hash of the signed data does not match the delta")
+	}
+	return nil
+}
+
+type StateChange struct {
+	ServicesToAdd        []did.Service
+	ServiceIDsToRemove   []string
+	PublicKeysToAdd      []PublicKey
+	PublicKeyIDsToRemove []string
+}
+
+// Patches returns the patches of the state change, in the order they are applied: services to add, services to
+// remove, public keys to add and public keys to remove
+func (s StateChange) Patches() []Patch {
+	var patches []Patch
+	if len(s.ServicesToAdd) > 0 {
+		patches = append(patches, AddServicesAction{
+			Action:   AddServices,
+			Services: s.ServicesToAdd,
+		})
+	}
+	if len(s.ServiceIDsToRemove) > 0 {
+		patches = append(patches, RemoveServicesAction{
+			Action: RemoveServices,
+			IDs:    s.ServiceIDsToRemove,
+		})
+	}
+	if len(s.PublicKeysToAdd) > 0 {
+		patches = append(patches, AddPublicKeysAction{
+			Action:     AddPublicKeys,
+			PublicKeys: s.PublicKeysToAdd,
+		})
+	}
+	if len(s.PublicKeyIDsToRemove) > 0 {
+		patches = append(patches, RemovePublicKeysAction{
+			Action: RemovePublicKeys,
+			IDs:    s.PublicKeyIDsToRemove,
+		})
+	}
+	return patches
+}
+
+func (s StateChange) IsEmpty() bool {
+	return len(s.ServicesToAdd) == 0 &&
+		len(s.ServiceIDsToRemove) == 0 &&
+		len(s.PublicKeysToAdd) == 0 &&
+		len(s.PublicKeyIDsToRemove) == 0
+}
+
+func (s StateChange) IsValid() error {
+	if s.IsEmpty() {
+		return errors.New("state change cannot be empty")
+	}
+
+	// check if services are valid
+	// build index of services to make sure IDs are unique
+	services := make(map[string]did.Service, len(s.ServicesToAdd))
+	for _, service := range s.ServicesToAdd {
+		if _, ok := services[service.ID]; ok {
+			return errors.Errorf("service %s duplicated", service.ID)
+		}
+
+		if len(service.ID) > maxIDLength {
+			return errors.Errorf("service<%s> id is too long", service.ID)
+		}
+
+		// make sure service is valid if it's not a dupe
+		if len(service.Type) > maxServiceTypeLength {
+			return errors.Errorf("service<%s> type %s is too long", service.ID, service.Type)
+		}
+
+		services[service.ID] = service
+	}
+
+	// check if public keys are valid
+	// build index of public keys to add
+	publicKeys := make(map[string]PublicKey, len(s.PublicKeysToAdd))
+	for _, publicKey := range s.PublicKeysToAdd {
+		if _, ok := publicKeys[publicKey.ID]; ok {
+			return errors.Errorf("public key<%s> is duplicated", publicKey.ID)
+		}
+
+		if len(publicKey.ID) > maxIDLength {
+			return errors.Errorf("public key<%s> id is too long", publicKey.ID)
+		}
+
+		publicKeys[publicKey.ID] = publicKey
+	}
+
+	// check if services to remove are valid
+	for _, serviceID := range s.ServiceIDsToRemove {
+		if _, ok := services[serviceID]; ok {
+			return errors.Errorf("service<%s> added and removed in same request", serviceID)
+		}
+
+		if len(serviceID) > maxIDLength {
+			return errors.Errorf("service<%s> id is too long", serviceID)
+		}
+	}
+
+	// check if public keys to remove are valid
+	for _, publicKeyID := range s.PublicKeyIDsToRemove {
+		if _, ok := publicKeys[publicKeyID]; ok {
+			return errors.Errorf("public key<%s> added and removed in same request", publicKeyID)
+		}
+
+		if len(publicKeyID) > maxIDLength {
+			return errors.Errorf("public key<%s> id is too long", publicKeyID)
+		}
+	}
+	return nil
+}
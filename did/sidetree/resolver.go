@@ -0,0 +1,252 @@
+package sidetree
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/extrimian/ssi-sdk/did"
+	"github.com/extrimian/ssi-sdk/did/resolution"
+	"github.com/goccy/go-json"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+// LocalResolver is a resolver that can resolve long form DIDs of a protocol without a node
+type LocalResolver[C Commitment] struct {
+	protocol Protocol
+}
+
+// NewLocalResolver creates a resolver of the long form DIDs of the protocol
+func NewLocalResolver[C Commitment](p Protocol) (*LocalResolver[C], error) {
+	if err := p.Validate(); err != nil {
+		return nil, errors.Wrap(err, "invalid protocol")
+	}
+	return &LocalResolver[C]{protocol: p}, nil
+}
+
+func (r LocalResolver[C]) Resolve(_ context.Context, id string, opts ...resolution.Option) (*resolution.Result, error) {
+	start := time.Now()
+	if id == "" {
+		return nil, resolution.NewResolutionError(resolution.InvalidDIDErrorCode, errors.New("id cannot be empty"))
+	}
+	options, err := resolution.ParseOptions(opts...)
+	if err != nil {
+		return nil, err
+	}
+	if options.IsVersioned() {
+		return nil, resolution.NewResolutionErrorf(resolution.InvalidOptionsErrorCode, "historical resolution requires a node")
+	}
+	if !r.protocol.IsLongFormDID(id) {
+		return nil, resolution.NewResolutionError(resolution.NotFoundErrorCode, errors.New("id is not a long form DID"))
+	}
+	result, err := resolveLongFormDID[C](r.protocol, id, start)
+	if err != nil {
+		return nil, err
+	}
+	return options.Apply(result), nil
+}
+
+// resolveLongFormDID reconstructs the document of an unpublished DID from its long form
+func resolveLongFormDID[C Commitment](p Protocol, id string, start time.Time) (*resolution.Result, error) {
+	shortFormDID, initialState, err := DecodeLongFormDID[C](p, id)
+	if err != nil {
+		return nil, resolution.NewResolutionError(resolution.InvalidDIDErrorCode, errors.Wrap(err, "invalid long form DID"))
+	}
+	didDoc, err := PatchesToDIDDocument(shortFormDID, id, initialState.Delta.Patches)
+	if err != nil {
+		return nil, resolution.NewResolutionError(resolution.InvalidDIDErrorCode, errors.Wrap(err, "reconstructing document from long form DID"))
+	}
+	result := resolution.NewResult(*didDoc, &resolution.DocumentMetadata{
+		EquivalentID: []string{shortFormDID},
+		Method: resolution.Method{
+			Published:          false,
+			RecoveryCommitment: initialState.SuffixData.RecoveryCommitment,
+			UpdateCommitment:   initialState.Delta.UpdateCommitment},
+	}, start)
+	if err = resolution.ValidateResult(result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func (r LocalResolver[C]) Methods() []did.Method {
+	return r.protocol.Methods()
+}
+
+var _ resolution.Resolver = (*LocalResolver[string])(nil)
+
+// Resolver resolves DIDs of a protocol with a Sidetree node and anchors operations on it
+type Resolver[C Commitment] struct {
+	protocol Protocol
+	client   *http.Client
+	baseURL  url.URL
+}
+
+var _ resolution.Resolver = (*Resolver[string])(nil)
+
+// NewResolver creates a new resolver for the DID methods of the protocol with a common base URL
+// The base URL is the URL of the Sidetree node, for example: https://ion.tbd.network
+// The resolver will append the protocol's identifiers path and the DID to the base URL to resolve the DID such as
+//
+//	https://ion.tbd.network/identifiers/did:ion:1234
+//
+// and similarly the operations path for submitting anchor operations to the node...
+//
+//	https://ion.tbd.network/operations
+func NewResolver[C Commitment](p Protocol, client *http.Client, baseURL string) (*Resolver[C], error) {
+	if err := p.Validate(); err != nil {
+		return nil, errors.Wrap(err, "invalid protocol")
+	}
+	if client == nil {
+		return nil, errors.New("client cannot be nil")
+	}
+	parsedURL, err := url.ParseRequestURI(baseURL)
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid resolution URL")
+	}
+	if parsedURL.Scheme != "https" {
+		return nil, errors.New("invalid resolution URL scheme; must use https")
+	}
+	return &Resolver[C]{
+		protocol: p,
+		client:   client,
+		baseURL:  *parsedURL,
+	}, nil
+}
+
+// Resolve resolves a DID of the protocol by appending the DID to the base URL with the identifiers path and making a GET request
+//
+// A specific version of the DID Document is requested from the node by passing the versionId or versionTime DID
+// parameters along with the DID. Long form DIDs are resolved locally unless a specific version is requested.
+func (i Resolver[C]) Resolve(ctx context.Context, id string, opts ...resolution.Option) (*resolution.Result, error) {
+	start := time.Now()
+	if id == "" {
+		return nil, resolution.NewResolutionError(resolution.InvalidDIDErrorCode, errors.New("id cannot be empty"))
+	}
+	options, err := resolution.ParseOptions(opts...)
+	if err != nil {
+		return nil, err
+	}
+	// first attempt to decode as a long form DID, if we get an error, continue
+	if i.protocol.IsLongFormDID(id) && !options.IsVersioned() {
+		result, err := resolveLongFormDID[C](i.protocol, id, start)
+		if err != nil {
+			return nil, err
+		}
+		return options.Apply(result), nil
+	}
+
+	if i.baseURL.String() == "" {
+		return nil, errors.New("resolution URL cannot be empty")
+	}
+	identifierURL := strings.Join([]string{i.baseURL.String(), i.protocol.identifiersPath(), id}, "/")
+	if options.IsVersioned() {
+		query := url.Values{}
+		if options.VersionID != "" {
+			query.Set(did.VersionIDParameter, options.VersionID)
+		}
+		if options.VersionTime != nil {
+			query.Set(did.VersionTimeParameter, options.FormatVersionTime())
+		}
+		identifierURL += "?" + query.Encode()
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, identifierURL, nil)
+	if err != nil {
+		return nil, errors.Wrap(err, "creating request")
+	}
+	if options.NoCache {
+		req.Header.Set("Cache-Control", "no-cache")
+	}
+	resp, err := i.client.Do(req)
+	if err != nil {
+		return nil, errors.Wrapf(err, "resolving, with URL: %s", i.baseURL.String())
+	}
+
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, errors.Wrapf(err, "resolving, with response %+v", resp)
+	}
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		return nil, resolution.NewResolutionErrorf(resolution.NotFoundErrorCode, "could not resolve DID: %q", string(body))
+	case resp.StatusCode == http.StatusBadRequest:
+		return nil, resolution.NewResolutionErrorf(resolution.InvalidDIDErrorCode, "could not resolve DID: %q", string(body))
+	case resp.StatusCode == http.StatusGone:
+		// sidetree nodes respond to deactivated DIDs with a resolution result and a 410 status code
+	case !is2xxStatusCode(resp.StatusCode):
+		return nil, fmt.Errorf("could not resolve DID: %q", string(body))
+	}
+	resolutionResult, err := resolution.ParseDIDResolution(body)
+	if err != nil {
+		return nil, errors.Wrapf(err, "resolving did:%s DID<%s>", i.Methods()[0], id)
+	}
+	if resp.StatusCode == http.StatusGone {
+		if resolutionResult.DocumentMetadata == nil {
+			resolutionResult.DocumentMetadata = new(resolution.DocumentMetadata)
+		}
+		resolutionResult.DocumentMetadata.Deactivated = true
+	}
+	if err = options.CheckVersion(resolutionResult.DocumentMetadata); err != nil {
+		return nil, errors.Wrapf(err, "resolving DID<%s>", id)
+	}
+	result := resolution.NewResult(resolutionResult.Document, resolutionResult.DocumentMetadata, start)
+	if resolutionResult.Metadata.ContentType != "" {
+		result.Metadata.ContentType = resolutionResult.Metadata.ContentType
+	}
+	if err = resolution.ValidateResult(result); err != nil {
+		return nil, err
+	}
+	return options.Apply(result), nil
+}
+
+// Anchor submits an anchor operation to the node by appending the operations path to the base URL
+// and making a POST request
+func (i Resolver[C]) Anchor(ctx context.Context, op AnchorOperation) (*resolution.Result, error) {
+	if i.baseURL.String() == "" {
+		return nil, errors.New("resolution URL cannot be empty")
+	}
+	jsonOpBytes, err := json.Marshal(op)
+	if err != nil {
+		return nil, errors.Wrapf(err, "marshalling anchor operation %+v", op)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, strings.Join([]string{i.baseURL.String(), i.protocol.operationsPath()}, "/"), bytes.NewReader(jsonOpBytes))
+	if err != nil {
+		return nil, errors.Wrap(err, "creating request")
+	}
+	resp, err := i.client.Do(req)
+	if err != nil {
+		return nil, errors.Wrapf(err, "posting anchor operation %+v", op)
+	}
+
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, errors.Wrapf(err, "could not resolve with response %+v", resp)
+	}
+	if !is2xxStatusCode(resp.StatusCode) {
+		return nil, fmt.Errorf("anchor operation failed: %s", string(body))
+	}
+	logrus.Infof("successfully anchored operation: %s", string(body))
+
+	var resolutionResult resolution.Result
+	if err := json.Unmarshal(body, &resolutionResult); err != nil {
+		return nil, errors.Wrap(err, "unmarshalling anchor response")
+	}
+	return &resolutionResult, nil
+}
+
+func (i Resolver[C]) Methods() []did.Method {
+	return i.protocol.Methods()
+}
@@ -0,0 +1,210 @@
+// Package sidetree implements the Sidetree protocol https://identity.foundation/sidetree/spec/ shared by DID methods
+// such as ion and modena. A method is configured by a Protocol holding its DID prefixes, hash algorithm and the paths
+// of its node API. ION commits to a single key per operation while Modena commits to a list of keys, so the operation
+// models are parameterized by the Commitment of the method.
+package sidetree
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/extrimian/ssi-sdk/crypto/jwx"
+	"github.com/extrimian/ssi-sdk/did"
+	"github.com/multiformats/go-multihash"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	DefaultIdentifiersPath = "identifiers"
+	DefaultOperationsPath  = "operations"
+)
+
+// Commitment is the representation of commitments in the operations of a method: a single commitment, or a list of
+// commitments
+type Commitment interface {
+	string | []string
+}
+
+// Protocol configures the Sidetree engine for a DID method
+type Protocol struct {
+	// Prefixes are the prefixes of the method's DIDs, such as did:ion, including any network or chain segments,
+	// such as did:modena:matic. New DIDs are created with the first prefix.
+	Prefixes []string
+	// HashAlgorithm is the multihash code of the hash algorithm https://identity.foundation/sidetree/spec/#hash-algorithm
+	// which defaults to sha2-256
+	HashAlgorithm uint64
+	// IdentifiersPath is the path of the resolution endpoint of the method's nodes, defaulting to identifiers
+	IdentifiersPath string
+	// OperationsPath is the path of the operations endpoint of the method's nodes, defaulting to operations
+	OperationsPath string
+}
+
+// Validate checks that the protocol has well-formed prefixes and a known hash algorithm
+func (p Protocol) Validate() error {
+	if len(p.Prefixes) == 0 {
+		return errors.New("protocol must have at least one prefix")
+	}
+	for _, prefix := range p.Prefixes {
+		segments := strings.Split(prefix, ":")
+		if len(segments) < 2 || segments[0] != "did" {
+			return fmt.Errorf("invalid prefix: %s", prefix)
+		}
+		for _, segment := range segments {
+			if segment == "" {
+				return fmt.Errorf("invalid prefix: %s", prefix)
+			}
+		}
+	}
+	if _, err := multihash.Sum(nil, p.hashAlgorithm(), -1); err != nil {
+		return errors.Wrapf(err, "unsupported hash algorithm: %d", p.HashAlgorithm)
+	}
+	return nil
+}
+
+// Methods returns the DID methods of the protocol's prefixes
+func (p Protocol) Methods() []did.Method {
+	var methods []did.Method
+	seen := make(map[did.Method]bool)
+	for _, prefix := range p.Prefixes {
+		segments := strings.Split(prefix, ":")
+		if len(segments) < 2 {
+			continue
+		}
+		method := did.Method(segments[1])
+		if !seen[method] {
+			seen[method] = true
+			methods = append(methods, method)
+		}
+	}
+	return methods
+}
+
+// method returns the name of the protocol's first method for use in messages
+func (p Protocol) method() string {
+	if methods := p.Methods(); len(methods) > 0 {
+		return methods[0].String()
+	}
+	return "sidetree"
+}
+
+// Prefix returns the longest prefix of the protocol the DID starts with
+func (p Protocol) Prefix(id string) (string, bool) {
+	var match string
+	for _, prefix := range p.Prefixes {
+		if strings.HasPrefix(id, prefix+":") && len(prefix) > len(match) {
+			match = prefix
+		}
+	}
+	return match, match != ""
+}
+
+// IsValid checks if the DID has one of the protocol's prefixes; full validation is impossible without resolution
+func (p Protocol) IsValid(id string) bool {
+	suffix, err := p.Suffix(id)
+	return err == nil && suffix != ""
+}
+
+// Suffix returns the value following the prefix of the DID, which includes the initial state of long form DIDs
+func (p Protocol) Suffix(id string) (string, error) {
+	prefix, ok := p.Prefix(id)
+	if !ok {
+		return "", fmt.Errorf("not a valid %s DID: %s", p.method(), id)
+	}
+	return strings.TrimPrefix(id, prefix+":"), nil
+}
+
+// IsLongFormDID checks if a string is a long form DID URI of the protocol, a short form DID followed by the encoded
+// initial state
+func (p Protocol) IsLongFormDID(maybeLongFormDID string) bool {
+	suffix, err := p.Suffix(maybeLongFormDID)
+	return err == nil && strings.Count(suffix, ":") == 1
+}
+
+// hashAlgorithm returns the multihash code of the protocol's hash algorithm
+func (p Protocol) hashAlgorithm() uint64 {
+	if p.HashAlgorithm == 0 {
+		return multihash.SHA2_256
+	}
+	return p.HashAlgorithm
+}
+
+// identifiersPath returns the path of the resolution endpoint
+func (p Protocol) identifiersPath() string {
+	if p.IdentifiersPath == "" {
+		return DefaultIdentifiersPath
+	}
+	return strings.Trim(p.IdentifiersPath, "/")
+}
+
+// operationsPath returns the path of the operations endpoint
+func (p Protocol) operationsPath() string {
+	if p.OperationsPath == "" {
+		return DefaultOperationsPath
+	}
+	return strings.Trim(p.OperationsPath, "/")
+}
+
+// Multihash hashes data with the protocol's hash algorithm as a multihash https://multiformats.io/multihash/
+func (p Protocol) Multihash(data []byte) ([]byte, error) {
+	multiHashed, err := multihash.Sum(data, p.hashAlgorithm(), -1)
+	if err != nil {
+		logrus.WithError(err).Error("could not multi-hash the given data")
+		return nil, err
+	}
+	return multiHashed, nil
+}
+
+// HashEncode hashes given data according to the protocol's hashing process
+// https://identity.foundation/sidetree/spec/#hashing-process
+func (p Protocol) HashEncode(data []byte) (string, error) {
+	hashed, err := p.Multihash(data)
+	if err != nil {
+		return "", err
+	}
+	return Encode(hashed), nil
+}
+
+// Commit creates a public key commitment according to the steps defined in the protocol
+// https://identity.foundation/sidetree/spec/#public-key-commitment-scheme
+func (p Protocol) Commit(key jwx.PublicKeyJWK) (reveal, commitment string, err error) {
+	// 1. Encode the public key into the form of a valid JWK.
+	// 2. Canonicalize the JWK encoded public key using the implementation’s JSON_CANONICALIZATION_SCHEME.
+	canonicalKey, err := CanonicalizeAny(key)
+	if err != nil {
+		logrus.WithError(err).Error("could not canonicalize JWK")
+		return "", "", err
+	}
+
+	// 3. Use the implementation’s HASH_PROTOCOL to Multihash the canonicalized public key to generate the REVEAL_VALUE,
+	// then Multihash the resulting Multihash value again using the implementation’s HASH_PROTOCOL to produce
+	// the public key commitment.
+	reveal, err = p.HashEncode(canonicalKey)
+	if err != nil {
+		return "", "", errors.Wrap(err, "generating reveal value")
+	}
+	multiHashed, err := p.Multihash(canonicalKey)
+	if err != nil {
+		return "", "", errors.Wrap(err, "hashing canonical key")
+	}
+	decoded, err := multihash.Decode(multiHashed)
+	if err != nil {
+		return "", "", errors.Wrap(err, "decoding multihash")
+	}
+	commitment, err = p.HashEncode(decoded.Digest)
+	if err != nil {
+		return "", "", errors.Wrap(err, "generating commitment value")
+	}
+	return reveal, commitment, nil
+}
+
+// newCommitment wraps a single commitment in the representation of the method
+func newCommitment[C Commitment](commitment string) C {
+	var c C
+	switch any(c).(type) {
+	case []string:
+		return any([]string{commitment}).(C)
+	default:
+		return any(commitment).(C)
+	}
+}
@@ -0,0 +1,242 @@
+package sidetree
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/extrimian/ssi-sdk/crypto"
+	"github.com/extrimian/ssi-sdk/crypto/jwx"
+	"github.com/extrimian/ssi-sdk/did"
+	"github.com/extrimian/ssi-sdk/did/resolution"
+	"github.com/multiformats/go-multihash"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/h2non/gock.v1"
+)
+
+var testProtocol = Protocol{
+	Prefixes: []string{"did:test:main", "did:test", "did:other:net"},
+}
+
+func TestProtocol(t *testing.T) {
+	t.Run("validate", func(tt *testing.T) {
+		assert.NoError(tt, testProtocol.Validate())
+		assert.ErrorContains(tt, Protocol{}.Validate(), "protocol must have at least one prefix")
+		assert.ErrorContains(tt, Protocol{Prefixes: []string{"test"}}.Validate(), "invalid prefix: test")
+		assert.ErrorContains(tt, Protocol{Prefixes: []string{"did::main"}}.Validate(), "invalid prefix: did::main")
+		assert.ErrorContains(tt, Protocol{Prefixes: []string{"did:test"}, HashAlgorithm: 0xffff}.Validate(), "unsupported hash algorithm")
+	})
+
+	t.Run("methods", func(tt *testing.T) {
+		assert.Equal(tt, []did.Method{"test", "other"}, testProtocol.Methods())
+	})
+
+	t.Run("prefixes with network segments", func(tt *testing.T) {
+		prefix, ok := testProtocol.Prefix("did:test:main:EiAbc")
+		assert.True(tt, ok)
+		assert.Equal(tt, "did:test:main", prefix)
+
+		suffix, err := testProtocol.Suffix("did:test:main:EiAbc")
+		assert.NoError(tt, err)
+		assert.Equal(tt, "EiAbc", suffix)
+
+		suffix, err = testProtocol.Suffix("did:test:EiAbc")
+		assert.NoError(tt, err)
+		assert.Equal(tt, "EiAbc", suffix)
+
+		_, err = testProtocol.Suffix("did:ion:EiAbc")
+		assert.ErrorContains(tt, err, "not a valid test DID")
+
+		assert.True(tt, testProtocol.IsValid("did:other:net:EiAbc"))
+		assert.False(tt, testProtocol.IsValid("did:other:EiAbc"))
+
+		assert.True(tt, testProtocol.IsLongFormDID("did:test:main:EiAbc:eyJ"))
+		assert.True(tt, testProtocol.IsLongFormDID("did:test:EiAbc:eyJ"))
+		assert.False(tt, testProtocol.IsLongFormDID("did:test:main:EiAbc"))
+		assert.False(tt, testProtocol.IsLongFormDID("did:ion:EiAbc:eyJ"))
+	})
+
+	t.Run("hash algorithm", func(tt *testing.T) {
+		sha3 := Protocol{Prefixes: []string{"did:test"}, HashAlgorithm: multihash.SHA3_256}
+
+		hashed, err := sha3.Multihash([]byte("data"))
+		assert.NoError(tt, err)
+		decoded, err := multihash.Decode(hashed)
+		assert.NoError(tt, err)
+		assert.Equal(tt, uint64(multihash.SHA3_256), decoded.Code)
+
+		hashed, err = testProtocol.Multihash([]byte("data"))
+		assert.NoError(tt, err)
+		decoded, err = multihash.Decode(hashed)
+		assert.NoError(tt, err)
+		assert.Equal(tt, uint64(multihash.SHA2_256), decoded.Code)
+
+		key := generateKey(tt)
+		_, sha2Commitment, err := testProtocol.Commit(key)
+		assert.NoError(tt, err)
+		_, sha3Commitment, err := sha3.Commit(key)
+		assert.NoError(tt, err)
+		assert.NotEqual(tt, sha2Commitment, sha3Commitment)
+	})
+}
+
+func TestLongFormDID(t *testing.T) {
+	t.Run("single commitment", func(tt *testing.T) {
+		longFormDID, err := CreateLongFormDID[string](testProtocol, generateKey(tt), generateKey(tt), testDocument())
+		assert.NoError(tt, err)
+		assert.True(tt, testProtocol.IsLongFormDID(longFormDID))
+
+		shortFormDID, initialState, err := DecodeLongFormDID[string](testProtocol, longFormDID)
+		assert.NoError(tt, err)
+		assert.Contains(tt, shortFormDID, "did:test:main:")
+		assert.NotEmpty(tt, initialState.SuffixData.RecoveryCommitment)
+		assert.NotEmpty(tt, initialState.Delta.UpdateCommitment)
+
+		gotShortFormDID, gotLongFormDID, err := initialState.ToDIDStrings(testProtocol)
+		assert.NoError(tt, err)
+		assert.Equal(tt, shortFormDID, gotShortFormDID)
+		assert.Equal(tt, longFormDID, gotLongFormDID)
+	})
+
+	t.Run("list of commitments", func(tt *testing.T) {
+		longFormDID, err := CreateLongFormDID[[]string](testProtocol, generateKey(tt), generateKey(tt), testDocument())
+		assert.NoError(tt, err)
+
+		shortFormDID, initialState, err := DecodeLongFormDID[[]string](testProtocol, longFormDID)
+		assert.NoError(tt, err)
+		assert.Contains(tt, shortFormDID, "did:test:main:")
+		assert.Len(tt, initialState.SuffixData.RecoveryCommitment, 1)
+		assert.Len(tt, initialState.Delta.UpdateCommitment, 1)
+	})
+
+	t.Run("other protocol", func(tt *testing.T) {
+		longFormDID, err := CreateLongFormDID[string](testProtocol, generateKey(tt), generateKey(tt), testDocument())
+		assert.NoError(tt, err)
+
+		_, _, err = DecodeLongFormDID[string](Protocol{Prefixes: []string{"did:ion"}}, longFormDID)
+		assert.ErrorContains(tt, err, "not a valid ion DID")
+	})
+}
+
+func TestResolver(t *testing.T) {
+	p := Protocol{
+		Prefixes:        []string{"did:test:main"},
+		IdentifiersPath: "/1.0/identifiers/",
+		OperationsPath:  "create",
+	}
+
+	t.Run("invalid protocol", func(tt *testing.T) {
+		_, err := NewResolver[string](Protocol{}, http.DefaultClient, "https://example.com")
+		assert.ErrorContains(tt, err, "invalid protocol")
+	})
+
+	t.Run("custom identifiers path", func(tt *testing.T) {
+		gock.New("https://test-node.com").
+			Get("/1.0/identifiers/did:test:main:EiAbc").
+			Reply(200).
+			BodyString(`{"didDocument": {"id": "did:test:main:EiAbc"}}`)
+		defer gock.Off()
+
+		resolver, err := NewResolver[string](p, http.DefaultClient, "https://test-node.com")
+		require.NoError(tt, err)
+		assert.Equal(tt, []did.Method{"test"}, resolver.Methods())
+
+		result, err := resolver.Resolve(context.Background(), "did:test:main:EiAbc")
+		assert.NoError(tt, err)
+		assert.Equal(tt, "did:test:main:EiAbc", result.Document.ID)
+	})
+
+	t.Run("unconfirmed version", func(tt *testing.T) {
+		gock.New("https://test-node.com").
+			Get("/1.0/identifiers/did:test:main:EiAbc").
+			MatchParam("versionId", "2").
+			Reply(200).
+			BodyString(`{"didDocument": {"id": "did:test:main:EiAbc"}}`)
+		defer gock.Off()
+
+		resolver, err := NewResolver[string](p, http.DefaultClient, "https://test-node.com")
+		require.NoError(tt, err)
+
+		_, err = resolver.Resolve(context.Background(), "did:test:main:EiAbc", resolution.WithVersionID("2"))
+		assert.Error(tt, err)
+		assert.True(tt, resolution.IsNotFound(err))
+	})
+
+	t.Run("custom operations path", func(tt *testing.T) {
+		gock.New("https://test-node.com").
+			Post("/create").
+			Reply(200).
+			BodyString(`{"didDocument": {"id": "did:test:main:EiAbc"}}`)
+		defer gock.Off()
+
+		resolver, err := NewResolver[string](p, http.DefaultClient, "https://test-node.com")
+		require.NoError(tt, err)
+
+		createRequest, err := NewCreateRequest[string](p, generateKey(tt), generateKey(tt), testDocument())
+		require.NoError(tt, err)
+		_, err = resolver.Anchor(context.Background(), createRequest)
+		assert.NoError(tt, err)
+	})
+
+	t.Run("long form DID", func(tt *testing.T) {
+		resolver, err := NewLocalResolver[[]string](p)
+		require.NoError(tt, err)
+
+		longFormDID, err := CreateLongFormDID[[]string](p, generateKey(tt), generateKey(tt), testDocument())
+		require.NoError(tt, err)
+
+		result, err := resolver.Resolve(context.Background(), longFormDID)
+		assert.NoError(tt, err)
+		assert.Equal(tt, longFormDID, result.Document.ID)
+		assert.Len(tt, result.Document.VerificationMethod, 1)
+		assert.False(tt, result.DocumentMetadata.Method.Published)
+		assert.IsType(tt, []string{}, result.DocumentMetadata.Method.RecoveryCommitment)
+	})
+}
+
+func TestDID(t *testing.T) {
+	d, createRequest, err := NewDID[[]string](testProtocol, testDocument())
+	require.NoError(t, err)
+	assert.Contains(t, d.ID(), "did:test:main:")
+	assert.Len(t, createRequest.SuffixData.RecoveryCommitment, 1)
+
+	updatedDID, updateRequest, err := d.Update(StateChange{ServiceIDsToRemove: []string{"service1"}})
+	assert.NoError(t, err)
+	assert.Equal(t, d.ID(), updatedDID.ID())
+	assert.Equal(t, d.ID(), "did:test:main:"+updateRequest.DIDSuffix)
+	assert.Len(t, updatedDID.Operations(), 2)
+
+	_, _, err = NewDID[string](Protocol{}, testDocument())
+	assert.ErrorContains(t, err, "invalid protocol")
+}
+
+func generateKey(t *testing.T) jwx.PublicKeyJWK {
+	t.Helper()
+	publicKey, _, err := crypto.GenerateSECP256k1Key()
+	require.NoError(t, err)
+	publicKeyJWK, err := jwx.PublicKeyToPublicKeyJWK("", publicKey)
+	require.NoError(t, err)
+	return *publicKeyJWK
+}
+
+func testDocument() Document {
+	return Document{
+		PublicKeys: []PublicKey{{
+			ID:   "key1",
+			Type: "EcdsaSecp256k1VerificationKey2019",
+			PublicKeyJWK: jwx.PublicKeyJWK{
+				KTY: "EC",
+				CRV: "secp256k1",
+				X:   "wfwQCJ3ORqVdnHXkT8P-Lg_GtxBEhX3ty9NUnwnHrmw",
+				Y:   "uie8qL_VuAnRDduphZuxLO6qT9kPp3KRGEIRlTpWrfU",
+			},
+			Purposes: []PublicKeyPurpose{Authentication},
+		}},
+		Services: []did.Service{{
+			ID:              "service1",
+			Type:            "LinkedDomains",
+			ServiceEndpoint: "https://example.com",
+		}},
+	}
+}
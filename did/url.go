@@ -0,0 +1,232 @@
+package did
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	// Prefix is the scheme all DIDs begin with https://www.w3.org/TR/did-core/#did-syntax
+	Prefix = "did:"
+
+	// DID parameters https://www.w3.org/TR/did-core/#did-parameters
+
+	ServiceParameter     = "service"
+	RelativeRefParameter = "relativeRef"
+	VersionIDParameter   = "versionId"
+	VersionTimeParameter = "versionTime"
+	HashLinkParameter    = "hl"
+)
+
+// URL is a parsed DID URL as per https://www.w3.org/TR/did-core/#did-url-syntax
+//
+//	did-url = did path-abempty [ "?" query ] [ "#" fragment ]
+type URL struct {
+	// Method is the DID method name, e.g. `web` in `did:web:example.com`
+	Method Method
+	// ID is the method-specific identifier, e.g. `example.com` in `did:web:example.com`
+	ID string
+	// Path is the path component including the leading '/', if present
+	Path string
+	// Query is the raw query component without the leading '?'
+	Query string
+	// Fragment is the fragment component without the leading '#'
+	Fragment string
+
+	hasQuery    bool
+	hasFragment bool
+}
+
+// ParseURL parses a DID or DID URL, validating it against the ABNF in https://www.w3.org/TR/did-core/#did-syntax
+func ParseURL(didURL string) (*URL, error) {
+	if !strings.HasPrefix(didURL, Prefix) {
+		return nil, fmt.Errorf("did url must begin with %q: %s", Prefix, didURL)
+	}
+
+	var u URL
+	rest := didURL
+	if i := strings.Index(rest, "#"); i >= 0 {
+		u.Fragment, u.hasFragment = rest[i+1:], true
+		rest = rest[:i]
+		if !isValidURLComponent(u.Fragment, "/?") {
+			return nil, fmt.Errorf("invalid fragment in did url: %s", didURL)
+		}
+	}
+	if i := strings.Index(rest, "?"); i >= 0 {
+		u.Query, u.hasQuery = rest[i+1:], true
+		rest = rest[:i]
+		if !isValidURLComponent(u.Query, "/?") {
+			return nil, fmt.Errorf("invalid query in did url: %s", didURL)
+		}
+	}
+	if i := strings.Index(rest, "/"); i >= 0 {
+		u.Path = rest[i:]
+		rest = rest[:i]
+		if !isValidURLComponent(u.Path, "/") {
+			return nil, fmt.Errorf("invalid path in did url: %s", didURL)
+		}
+	}
+
+	method, id, found := strings.Cut(strings.TrimPrefix(rest, Prefix), ":")
+	if !found {
+		return nil, fmt.Errorf("did is missing a method-specific id: %s", didURL)
+	}
+	if !isValidMethodName(method) {
+		return nil, fmt.Errorf("invalid method name in did: %s", didURL)
+	}
+	if !isValidMethodSpecificID(id) {
+		return nil, fmt.Errorf("invalid method-specific id in did: %s", didURL)
+	}
+	u.Method = Method(method)
+	u.ID = id
+	return &u, nil
+}
+
+// IsValidDID returns true if the input is a DID with no path, query or fragment
+func IsValidDID(id string) bool {
+	u, err := ParseURL(id)
+	return err == nil && u.IsDID()
+}
+
+// DID returns the DID portion of the URL, e.g. `did:example:123` for `did:example:123/path?query#fragment`
+func (u URL) DID() string {
+	return Prefix + string(u.Method) + ":" + u.ID
+}
+
+// IsDID returns true if the URL has no path, query or fragment
+func (u URL) IsDID() bool {
+	return u.Path == "" && !u.hasQuery && !u.hasFragment
+}
+
+// HasQuery returns true if the URL has a query component, even if empty
+func (u URL) HasQuery() bool {
+	return u.hasQuery
+}
+
+// HasFragment returns true if the URL has a fragment component, even if empty
+func (u URL) HasFragment() bool {
+	return u.hasFragment
+}
+
+// Params returns the parsed query parameters of the URL
+func (u URL) Params() (url.Values, error) {
+	values, err := url.ParseQuery(u.Query)
+	if err != nil {
+		return nil, errors.Wrap(err, "parsing did url query")
+	}
+	return values, nil
+}
+
+// Param returns the first value of the given query parameter, or an empty string if it is not present
+func (u URL) Param(name string) string {
+	values, err := u.Params()
+	if err != nil {
+		return ""
+	}
+	return values.Get(name)
+}
+
+// Service returns the value of the `service` DID parameter
+func (u URL) Service() string {
+	return u.Param(ServiceParameter)
+}
+
+// RelativeRef returns the value of the `relativeRef` DID parameter
+func (u URL) RelativeRef() string {
+	return u.Param(RelativeRefParameter)
+}
+
+// VersionID returns the value of the `versionId` DID parameter
+func (u URL) VersionID() string {
+	return u.Param(VersionIDParameter)
+}
+
+// VersionTime returns the value of the `versionTime` DID parameter
+func (u URL) VersionTime() string {
+	return u.Param(VersionTimeParameter)
+}
+
+// HashLink returns the value of the `hl` DID parameter
+func (u URL) HashLink() string {
+	return u.Param(HashLinkParameter)
+}
+
+// String reassembles the URL into its string form
+func (u URL) String() string {
+	var sb strings.Builder
+	sb.WriteString(u.DID())
+	sb.WriteString(u.Path)
+	if u.hasQuery {
+		sb.WriteString("?" + u.Query)
+	}
+	if u.hasFragment {
+		sb.WriteString("#" + u.Fragment)
+	}
+	return sb.String()
+}
+
+// isValidMethodName checks method-name = 1*method-char; method-char = %x61-7A / DIGIT
+func isValidMethodName(method string) bool {
+	if method == "" {
+		return false
+	}
+	for _, c := range method {
+		if !(c >= 'a' && c <= 'z') && !(c >= '0' && c <= '9') {
+			return false
+		}
+	}
+	return true
+}
+
+// isValidMethodSpecificID checks method-specific-id = *( *idchar ":" ) 1*idchar
+// where idchar = ALPHA / DIGIT / "." / "-" / "_" / pct-encoded
+func isValidMethodSpecificID(id string) bool {
+	if id == "" || strings.HasSuffix(id, ":") {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		c := id[i]
+		switch {
+		case isUnreservedIDChar(c), c == ':':
+		case c == '%':
+			if i+2 >= len(id) || !isHexDigit(id[i+1]) || !isHexDigit(id[i+2]) {
+				return false
+			}
+			i += 2
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// isValidURLComponent checks the RFC 3986 pchar set, plus any additional allowed characters, for a path, query or
+// fragment component https://www.rfc-editor.org/rfc/rfc3986#section-3.3
+func isValidURLComponent(component, allowed string) bool {
+	for i := 0; i < len(component); i++ {
+		c := component[i]
+		switch {
+		case isUnreservedIDChar(c), c == '~', strings.IndexByte("!$&'()*+,;=:@", c) >= 0,
+			strings.IndexByte(allowed, c) >= 0:
+		case c == '%':
+			if i+2 >= len(component) || !isHexDigit(component[i+1]) || !isHexDigit(component[i+2]) {
+				return false
+			}
+			i += 2
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+func isUnreservedIDChar(c byte) bool {
+	return (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9') || c == '.' || c == '-' || c == '_'
+}
+
+func isHexDigit(c byte) bool {
+	return (c >= '0' && c <= '9') || (c >= 'a' && c <= 'f') || (c >= 'A' && c <= 'F')
+}
@@ -0,0 +1,81 @@
+package did
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseURL(t *testing.T) {
+	t.Run("simple did", func(tt *testing.T) {
+		u, err := ParseURL("did:example:123456789abcdefghi")
+		require.NoError(tt, err)
+		assert.Equal(tt, Method("example"), u.Method)
+		assert.Equal(tt, "123456789abcdefghi", u.ID)
+		assert.Equal(tt, "did:example:123456789abcdefghi", u.DID())
+		assert.True(tt, u.IsDID())
+		assert.Equal(tt, "did:example:123456789abcdefghi", u.String())
+	})
+
+	t.Run("did with colons and percent encoding", func(tt *testing.T) {
+		u, err := ParseURL("did:web:localhost%3A8443:user:alice")
+		require.NoError(tt, err)
+		assert.Equal(tt, WebMethod, u.Method)
+		assert.Equal(tt, "localhost%3A8443:user:alice", u.ID)
+		assert.True(tt, u.IsDID())
+	})
+
+	t.Run("did url with path, query and fragment", func(tt *testing.T) {
+		input := "did:example:123/path/to/resource?service=files&relativeRef=%2Fresume.pdf&versionId=1&versionTime=2021-05-10T17:00:00Z&hl=zQm#key-1"
+		u, err := ParseURL(input)
+		require.NoError(tt, err)
+		assert.False(tt, u.IsDID())
+		assert.Equal(tt, "did:example:123", u.DID())
+		assert.Equal(tt, "/path/to/resource", u.Path)
+		assert.Equal(tt, "key-1", u.Fragment)
+		assert.True(tt, u.HasQuery())
+		assert.True(tt, u.HasFragment())
+		assert.Equal(tt, "files", u.Service())
+		assert.Equal(tt, "/resume.pdf", u.RelativeRef())
+		assert.Equal(tt, "1", u.VersionID())
+		assert.Equal(tt, "2021-05-10T17:00:00Z", u.VersionTime())
+		assert.Equal(tt, "zQm", u.HashLink())
+		assert.Equal(tt, input, u.String())
+	})
+
+	t.Run("empty fragment is preserved", func(tt *testing.T) {
+		u, err := ParseURL("did:example:123#")
+		require.NoError(tt, err)
+		assert.True(tt, u.HasFragment())
+		assert.Empty(tt, u.Fragment)
+		assert.Equal(tt, "did:example:123#", u.String())
+	})
+
+	t.Run("invalid dids", func(tt *testing.T) {
+		for _, input := range []string{
+			"",
+			"example:123",
+			"did:",
+			"did:example",
+			"did:example:",
+			"did:Example:123",
+			"did:ex_ample:123",
+			"did:example:123:",
+			"did:example:12 3",
+			"did:example:%4",
+			"did:example:%zz",
+			"did:example:123#frag#ment",
+			"did:example:123?query#frag ment",
+		} {
+			_, err := ParseURL(input)
+			assert.Error(tt, err, input)
+		}
+	})
+
+	t.Run("is valid did", func(tt *testing.T) {
+		assert.True(tt, IsValidDID("did:key:z6MkhaXgBZDvotDkL5257faiztiGiC2QtKLGpbnnEGta2doK"))
+		assert.False(tt, IsValidDID("did:key:z6MkhaXgBZDvotDkL5257faiztiGiC2QtKLGpbnnEGta2doK#key-1"))
+		assert.False(tt, IsValidDID("not-a-did"))
+	})
+}
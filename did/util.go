@@ -30,14 +30,48 @@ const (
 	P521MultiCodec      = multicodec.P521Pub
 	RSAMultiCodec       = multicodec.RsaPub
 	SHA256MultiCodec    = multicodec.Sha2_256
+
+	BLS12381G1MultiCodec   = multicodec.Bls12_381G1Pub
+	BLS12381G2MultiCodec   = multicodec.Bls12_381G2Pub
+	BLS12381G1G2MultiCodec = multicodec.Bls12_381G1g2Pub
+
+	// EXPERIMENTAL codes for the round 3 Dilithium keys of the crypto package, taken from the private use range of
+	// the multicodec table. Dilithium keys are not ML-DSA keys, so the registered mldsa-*-pub codes are not used, and
+	// these codes are not interoperable with other implementations. They may change once Dilithium is replaced by
+	// ML-DSA.
+
+	Dilithium2MultiCodec multicodec.Code = 0x300002
+	Dilithium3MultiCodec multicodec.Code = 0x300003
+	Dilithium5MultiCodec multicodec.Code = 0x300005
 )
 
+// IsValidMultiCodecPrefix returns true if a multicodec varint prefix of n bytes is expected for the code: two bytes
+// for the registered key codes, and four bytes for the experimental codes in the private use range
+func IsValidMultiCodecPrefix(code uint64, n int) bool {
+	switch multicodec.Code(code) {
+	case Dilithium2MultiCodec, Dilithium3MultiCodec, Dilithium5MultiCodec:
+		return n == 4
+	default:
+		return n == 2
+	}
+}
+
 // GetKeyFromVerificationMethod resolves a DID and provides a kid and public key needed for data verification
 // it is possible that a DID has multiple verification methods, in which case a kid must be provided, otherwise
 // resolution will fail.
 // A KID can be fully qualified (e.g. did:example:123#key-1) or just the fragment (e.g. key-1, #key-1)
 // Some DIDs, like did:key, use the entire DID as the KID, so we need to handle all three cases.
 func GetKeyFromVerificationMethod(did Document, kid string) (gocrypto.PublicKey, error) {
+	method, err := GetVerificationMethodForKID(did, kid)
+	if err != nil {
+		return nil, err
+	}
+	return extractKeyFromVerificationMethod(*method)
+}
+
+// GetVerificationMethodForKID returns the verification method of the did document identified by the kid, which
+// may be the method's fragment or fully qualified id
+func GetVerificationMethodForKID(did Document, kid string) (*VerificationMethod, error) {
 	if did.IsEmpty() {
 		return nil, errors.New("did doc cannot be empty")
 	}
@@ -50,34 +84,32 @@ func GetKeyFromVerificationMethod(did Document, kid string) (gocrypto.PublicKey,
 		return nil, errors.Errorf("did<%s> has no verification methods", did.ID)
 	}
 
-	for _, method := range verificationMethods {
+	for i, method := range verificationMethods {
 		// make sure the kid matches the verification method
 		if matchesKIDConstruction(did.ID, kid, method.ID) {
-			return extractKeyFromVerificationMethod(method)
+			return &verificationMethods[i], nil
 		}
 	}
 
 	return nil, errors.Errorf("did<%s> has no verification methods with kid: %s", did.ID, kid)
 }
 
-// matchesKIDConstruction checks if the targetID matches possible combinations of the did and kid
+// matchesKIDConstruction checks if the kid references the verification method with the targetID in the document of
+// the did, by comparing their fully qualified DID URLs
 func matchesKIDConstruction(did, kid, targetID string) bool {
-	maybeKID1 := kid                                // the kid == the kid
-	maybeKID2 := fmt.Sprintf("#%s", kid)            // the kid == the fragment with a #
-	maybeKID3 := fmt.Sprintf("%s#%s", did, kid)     // the kid == the DID ID + the fragment with a #
-	maybeKID4 := fmt.Sprintf("%s%s", did, kid)      // the kid == the DID ID + the fragment without a #
-	maybeKID5, found := strings.CutPrefix(kid, did) // the kid == the did
-
-	var maybeKID6 string // the kid == the did with a fragment, but the doc only references the fragment without a #
-	var maybeKID7 string // the kid == the did with a fragment, but the doc only references the fragment with a #
-	fullyQualifiedKIDIndex := strings.LastIndex(kid, "#")
-	if fullyQualifiedKIDIndex > 0 {
-		maybeKID6 = kid[fullyQualifiedKIDIndex:]   // the kid == the fragment with a #
-		maybeKID7 = kid[fullyQualifiedKIDIndex+1:] // the kid == the fragment without a #
-	}
+	return qualifiedVerificationMethodID(did, kid) == qualifiedVerificationMethodID(did, targetID)
+}
 
-	return targetID == maybeKID1 || targetID == maybeKID2 || targetID == maybeKID3 || targetID == maybeKID4 ||
-		(found && targetID == maybeKID5) || targetID == maybeKID6 || targetID == maybeKID7
+// qualifiedVerificationMethodID resolves the id of a verification method, or a kid referencing one, against the did
+// of its document. A DID URL is used as it is, while a fragment, with or without its '#', is relative to the did.
+func qualifiedVerificationMethodID(did, id string) string {
+	if u, err := ParseURL(id); err == nil {
+		return u.String()
+	}
+	if strings.HasPrefix(id, did+"#") {
+		return id
+	}
+	return did + "#" + strings.TrimPrefix(id, "#")
 }
 
 func extractKeyFromVerificationMethod(method VerificationMethod) (gocrypto.PublicKey, error) {
@@ -127,11 +159,11 @@ func MultiBaseToPubKeyBytes(mb string) ([]byte, error) {
 	}
 
 	// n = # bytes for the int, which we expect to be two from our multicodec
-	_, n, err := varint.FromUvarint(decoded)
+	multiCodec, n, err := varint.FromUvarint(decoded)
 	if err != nil {
 		return nil, errors.Wrap(err, "parsing multibase varint")
 	}
-	if n != 2 {
+	if !IsValidMultiCodecPrefix(multiCodec, n) {
 		return nil, errors.New("error parsing multibase varint")
 	}
 	pubKeyBytes := decoded[n:]
@@ -154,6 +186,18 @@ func KeyTypeToMultiCodec(kt crypto.KeyType) (multicodec.Code, error) {
 		return P521MultiCodec, nil
 	case crypto.RSA:
 		return RSAMultiCodec, nil
+	case crypto.BLS12381G1:
+		return BLS12381G1MultiCodec, nil
+	case crypto.BLS12381G2:
+		return BLS12381G2MultiCodec, nil
+	case crypto.BLS12381G1G2:
+		return BLS12381G1G2MultiCodec, nil
+	case crypto.Dilithium2:
+		return Dilithium2MultiCodec, nil
+	case crypto.Dilithium3:
+		return Dilithium3MultiCodec, nil
+	case crypto.Dilithium5:
+		return Dilithium5MultiCodec, nil
 	}
 	return 0, fmt.Errorf("unknown multicodec for key type: %s", kt)
 }
@@ -175,12 +219,28 @@ func MultiCodecToKeyType(codec multicodec.Code) (crypto.KeyType, error) {
 		kt = crypto.P521
 	case RSAMultiCodec:
 		kt = crypto.RSA
+	case BLS12381G1MultiCodec:
+		kt = crypto.BLS12381G1
+	case BLS12381G2MultiCodec:
+		kt = crypto.BLS12381G2
+	case BLS12381G1G2MultiCodec:
+		kt = crypto.BLS12381G1G2
+	case Dilithium2MultiCodec:
+		kt = crypto.Dilithium2
+	case Dilithium3MultiCodec:
+		kt = crypto.Dilithium3
+	case Dilithium5MultiCodec:
+		kt = crypto.Dilithium5
 	default:
 		return kt, errors.Errorf("codec conversion not found for %d", codec)
 	}
 	return kt, nil
 }
 
+// errBLS12381G1G2LDKeyType is returned for a bls12_381-g1g2 key, which is a G1 and a G2 key concatenated, each with
+// its own LD key type
+var errBLS12381G1G2LDKeyType = errors.New("a bls12_381-g1g2 key has no single LD key type, its G1 and G2 keys must be used separately")
+
 // MultiCodecToLDKeyType goes from a multicodec to LD key type
 func MultiCodecToLDKeyType(codec multicodec.Code) (cryptosuite.LDKeyType, error) {
 	switch codec {
@@ -192,6 +252,14 @@ func MultiCodecToLDKeyType(codec multicodec.Code) (cryptosuite.LDKeyType, error)
 		return cryptosuite.ECDSASECP256k1VerificationKey2019, nil
 	case P256MultiCodec, P384MultiCodec, P521MultiCodec, RSAMultiCodec:
 		return cryptosuite.JSONWebKey2020Type, nil
+	case BLS12381G1MultiCodec:
+		return cryptosuite.BLS12381G1Key2020, nil
+	case BLS12381G2MultiCodec:
+		return cryptosuite.BLS12381G2Key2020, nil
+	case BLS12381G1G2MultiCodec:
+		return "", errBLS12381G1G2LDKeyType
+	case Dilithium2MultiCodec, Dilithium3MultiCodec, Dilithium5MultiCodec:
+		return cryptosuite.MultikeyType, nil
 	default:
 		return "", fmt.Errorf("unknown multicodec for did:key: %d", codec)
 	}
@@ -213,7 +281,7 @@ func DecodeMultibaseEncodedKey(d string) ([]byte, cryptosuite.LDKeyType, crypto.
 	if err != nil {
 		return nil, "", "", err
 	}
-	if n != 2 {
+	if !IsValidMultiCodecPrefix(multiCodec, n) {
 		return nil, "", "", errors.New("error parsing did:key varint")
 	}
 
@@ -262,6 +330,14 @@ func DecodeMultibasePublicKeyWithType(data []byte) ([]byte, cryptosuite.LDKeyTyp
 		return pubKeyBytes, cryptosuite.ECDSASECP256k1VerificationKey2019, nil
 	case P256MultiCodec, P384MultiCodec, P521MultiCodec, RSAMultiCodec:
 		return pubKeyBytes, cryptosuite.JSONWebKey2020Type, nil
+	case BLS12381G1MultiCodec:
+		return pubKeyBytes, cryptosuite.BLS12381G1Key2020, nil
+	case BLS12381G2MultiCodec:
+		return pubKeyBytes, cryptosuite.BLS12381G2Key2020, nil
+	case BLS12381G1G2MultiCodec:
+		return nil, "", errBLS12381G1G2LDKeyType
+	case Dilithium2MultiCodec, Dilithium3MultiCodec, Dilithium5MultiCodec:
+		return pubKeyBytes, cryptosuite.MultikeyType, nil
 	default:
 		return nil, "", fmt.Errorf("unknown multicodec for did:peer: %d", multiCodecValue)
 	}
@@ -300,7 +376,7 @@ func ConstructMultibaseVerificationMethod(id, controller string, pubKey []byte,
 
 // FullyQualifiedVerificationMethodID returns a fully qualified URL for a verification method.
 func FullyQualifiedVerificationMethodID(did, verificationMethodID string) string {
-	if strings.HasPrefix(verificationMethodID, "did:") {
+	if strings.HasPrefix(verificationMethodID, Prefix) {
 		return verificationMethodID
 	}
 	if strings.HasPrefix(verificationMethodID, "#") {
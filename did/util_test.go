@@ -4,10 +4,15 @@ import (
 	"testing"
 
 	"github.com/mr-tron/base58"
+	"github.com/multiformats/go-multibase"
+	"github.com/multiformats/go-multicodec"
+	"github.com/multiformats/go-varint"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
 	"github.com/extrimian/ssi-sdk/crypto"
 	"github.com/extrimian/ssi-sdk/crypto/jwx"
+	"github.com/extrimian/ssi-sdk/cryptosuite"
 )
 
 func TestGetKeyFromVerificationInformation(t *testing.T) {
@@ -284,6 +289,31 @@ func TestGetKeyFromVerificationInformation(t *testing.T) {
 		assert.NoError(t, err)
 		assert.NotEmpty(t, key)
 	})
+
+	t.Run("kid of another did", func(t *testing.T) {
+		doc := Document{
+			ID: "did:example:123",
+			VerificationMethod: []VerificationMethod{
+				{
+					ID:   "#test-kid",
+					Type: "JsonWebKey2020",
+					PublicKeyJWK: &jwx.PublicKeyJWK{
+						KTY: "OKP",
+						CRV: "Ed25519",
+						X:   "VCpo2LMLhn6iWku8MKvSLg2ZAoC-nlOyPVQaO3FxVeQ",
+					},
+				},
+			},
+		}
+		_, err := GetKeyFromVerificationMethod(doc, "did:example:456#test-kid")
+		assert.ErrorContains(t, err, "has no verification methods with kid: did:example:456#test-kid")
+
+		_, err = GetKeyFromVerificationMethod(doc, "did:example:123#other-kid")
+		assert.Error(t, err)
+
+		_, err = GetKeyFromVerificationMethod(doc, "did:example:123/path#test-kid")
+		assert.Error(t, err)
+	})
 }
 
 func TestFullyQualifiedVerificationMethodID(t *testing.T) {
@@ -327,3 +357,34 @@ func TestFullyQualifiedVerificationMethodID(t *testing.T) {
 		})
 	}
 }
+
+func TestBLS12381MultibaseKeys(t *testing.T) {
+	encode := func(tt *testing.T, codec multicodec.Code, size int) string {
+		encoded, err := multibase.Encode(Base58BTCMultiBase, append(varint.ToUvarint(uint64(codec)), make([]byte, size)...))
+		require.NoError(tt, err)
+		return encoded
+	}
+
+	t.Run("G1 and G2 keys", func(tt *testing.T) {
+		_, ldKeyType, keyType, err := DecodeMultibaseEncodedKey(encode(tt, BLS12381G1MultiCodec, 48))
+		assert.NoError(tt, err)
+		assert.Equal(tt, cryptosuite.BLS12381G1Key2020, ldKeyType)
+		assert.Equal(tt, crypto.BLS12381G1, keyType)
+
+		pubKey, ldKeyType, err := DecodeMultibasePublicKeyWithType([]byte(encode(tt, BLS12381G2MultiCodec, 96)))
+		assert.NoError(tt, err)
+		assert.Len(tt, pubKey, 96)
+		assert.Equal(tt, cryptosuite.BLS12381G2Key2020, ldKeyType)
+	})
+
+	t.Run("G1G2 keys have no single LD key type", func(tt *testing.T) {
+		_, err := MultiCodecToLDKeyType(BLS12381G1G2MultiCodec)
+		assert.ErrorContains(tt, err, "a bls12_381-g1g2 key has no single LD key type")
+
+		encoded := encode(tt, BLS12381G1G2MultiCodec, 144)
+		_, _, _, err = DecodeMultibaseEncodedKey(encoded)
+		assert.ErrorContains(tt, err, "a bls12_381-g1g2 key has no single LD key type")
+		_, _, err = DecodeMultibasePublicKeyWithType([]byte(encoded))
+		assert.ErrorContains(tt, err, "a bls12_381-g1g2 key has no single LD key type")
+	})
+}
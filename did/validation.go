@@ -0,0 +1,290 @@
+package did
+
+import (
+	"fmt"
+	"net/url"
+	"reflect"
+	"strings"
+
+	"github.com/goccy/go-json"
+	"github.com/multiformats/go-multibase"
+
+	"github.com/extrimian/ssi-sdk/util"
+)
+
+// ValidationError is a violation of https://www.w3.org/TR/did-core by a member of a DID Document, addressed by its
+// JSONPath, e.g. `$.verificationMethod[0].controller`
+type ValidationError struct {
+	Path    string
+	Message string
+}
+
+func (e ValidationError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Path, e.Message)
+}
+
+// ValidationErrors are all the violations found validating a DID Document
+type ValidationErrors []ValidationError
+
+func (e ValidationErrors) Error() string {
+	messages := make([]string, 0, len(e))
+	for _, err := range e {
+		messages = append(messages, err.Error())
+	}
+	return fmt.Sprintf("invalid DID Document: %s", strings.Join(messages, "; "))
+}
+
+// Validate validates the document against https://www.w3.org/TR/did-core, unlike IsValid which only checks that
+// required properties are present. It checks that:
+//   - the id is a DID, and controllers and verification method controllers are DIDs
+//   - verification method and service ids are DID URLs or relative references, and are unique in the document
+//   - verification methods have exactly one public key representation, which is well-formed
+//   - verification relationships reference verification methods of the document, or embed valid ones
+//   - service endpoints are URIs, maps, or sets of them
+//
+// All violations are returned as ValidationErrors, each addressed by the JSONPath of the offending member.
+func (d *Document) Validate() error {
+	if d == nil {
+		return ValidationErrors{{Path: "$", Message: "document cannot be empty"}}
+	}
+	v := documentValidator{doc: d, ids: make(map[string]string)}
+	v.validate()
+	if len(v.errs) == 0 {
+		return nil
+	}
+	return v.errs
+}
+
+type documentValidator struct {
+	doc  *Document
+	errs ValidationErrors
+	// ids maps the absolute ids of verification methods and services to the path of the member they identify
+	ids map[string]string
+}
+
+func (v *documentValidator) errorf(path, msg string, a ...any) {
+	v.errs = append(v.errs, ValidationError{Path: path, Message: fmt.Sprintf(msg, a...)})
+}
+
+func (v *documentValidator) validate() {
+	switch {
+	case v.doc.ID == "":
+		v.errorf("$.id", "id is required")
+	case !IsValidDID(v.doc.ID):
+		v.errorf("$.id", "not a DID: %s", v.doc.ID)
+	}
+	if v.doc.Controller != "" && !IsValidDID(v.doc.Controller) {
+		v.errorf("$.controller", "not a DID: %s", v.doc.Controller)
+	}
+	if v.doc.AlsoKnownAs != "" && !isURI(v.doc.AlsoKnownAs) {
+		v.errorf("$.alsoKnownAs", "not a URI: %s", v.doc.AlsoKnownAs)
+	}
+
+	for i, vm := range v.doc.VerificationMethod {
+		v.validateVerificationMethod(fmt.Sprintf("$.verificationMethod[%d]", i), vm)
+	}
+	// embedded verification methods and services are validated before references, which may refer to them
+	var references []func()
+	for _, relationship := range Relationships() {
+		for i, entry := range v.doc.Relationship(relationship) {
+			v.validateRelationshipEntry(fmt.Sprintf("$.%s[%d]", relationship, i), entry, &references)
+		}
+	}
+	for i, service := range v.doc.Services {
+		v.validateService(fmt.Sprintf("$.service[%d]", i), service)
+	}
+	for _, validateReference := range references {
+		validateReference()
+	}
+}
+
+func (v *documentValidator) validateVerificationMethod(path string, vm VerificationMethod) {
+	v.validateID(path, vm.ID)
+	if vm.Type == "" {
+		v.errorf(path+".type", "type is required")
+	}
+	switch {
+	case vm.Controller == "":
+		v.errorf(path+".controller", "controller is required")
+	case !IsValidDID(vm.Controller):
+		v.errorf(path+".controller", "not a DID: %s", vm.Controller)
+	}
+
+	// blockchain accounts may stand in for a public key, or be given alongside one
+	var representations []string
+	if vm.PublicKeyJWK != nil {
+		representations = append(representations, "publicKeyJwk")
+		if err := util.NewValidator().Struct(vm.PublicKeyJWK); err != nil {
+			v.errorf(path+".publicKeyJwk", "invalid JWK: %s", err.Error())
+		}
+	}
+	if vm.PublicKeyMultibase != "" {
+		representations = append(representations, "publicKeyMultibase")
+		if _, _, err := multibase.Decode(vm.PublicKeyMultibase); err != nil {
+			v.errorf(path+".publicKeyMultibase", "invalid multibase value: %s", err.Error())
+		}
+	}
+	if vm.PublicKeyBase58 != "" {
+		representations = append(representations, "publicKeyBase58")
+	}
+	switch {
+	case len(representations) > 1:
+		v.errorf(path, "must have exactly one public key representation, has %s", strings.Join(representations, ", "))
+	case len(representations) == 0 && vm.BlockchainAccountID == "":
+		v.errorf(path, "must have a public key representation, or a blockchainAccountId")
+	}
+}
+
+// validateRelationshipEntry validates an entry of a verification relationship, which is a reference, an embedded
+// verification method, or a set of them. References are deferred until all embedded verification methods are known.
+func (v *documentValidator) validateRelationshipEntry(path string, entry VerificationMethodSet, references *[]func()) {
+	switch e := entry.(type) {
+	case string:
+		*references = append(*references, func() { v.validateReference(path, e) })
+		return
+	case []string:
+		for i, reference := range e {
+			v.validateRelationshipEntry(fmt.Sprintf("%s[%d]", path, i), reference, references)
+		}
+		return
+	case []any:
+		for i, nested := range e {
+			v.validateRelationshipEntry(fmt.Sprintf("%s[%d]", path, i), nested, references)
+		}
+		return
+	case []VerificationMethodSet:
+		for i, nested := range e {
+			v.validateRelationshipEntry(fmt.Sprintf("%s[%d]", path, i), nested, references)
+		}
+		return
+	}
+	vm, err := embeddedVerificationMethod(entry)
+	if err != nil {
+		v.errorf(path, "must be a verification method or a reference to one: %s", err.Error())
+		return
+	}
+	v.validateVerificationMethod(path, *vm)
+}
+
+func (v *documentValidator) validateService(path string, service Service) {
+	v.validateID(path, service.ID)
+	if service.Type == "" {
+		v.errorf(path+".type", "type is required")
+	}
+	endpointPath := path + ".serviceEndpoint"
+	endpoint := reflect.ValueOf(service.ServiceEndpoint)
+	if endpoint.Kind() != reflect.Slice && endpoint.Kind() != reflect.Array {
+		v.validateServiceEndpoint(endpointPath, service.ServiceEndpoint)
+		return
+	}
+	if endpoint.Len() == 0 {
+		v.errorf(endpointPath, "serviceEndpoint cannot be an empty set")
+	}
+	for i := 0; i < endpoint.Len(); i++ {
+		v.validateServiceEndpoint(fmt.Sprintf("%s[%d]", endpointPath, i), endpoint.Index(i).Interface())
+	}
+}
+
+// validateServiceEndpoint validates a single service endpoint, which is either a URI or a map
+func (v *documentValidator) validateServiceEndpoint(path string, endpoint any) {
+	if endpoint == nil {
+		v.errorf(path, "serviceEndpoint is required")
+		return
+	}
+	value := reflect.Indirect(reflect.ValueOf(endpoint))
+	switch value.Kind() {
+	case reflect.String:
+		if !isURI(value.String()) {
+			v.errorf(path, "not a URI: %s", value.String())
+		}
+	case reflect.Map, reflect.Struct:
+	default:
+		v.errorf(path, "must be a URI or a map, not %T", endpoint)
+	}
+}
+
+// validateID validates the id of a verification method or service, which must be unique in the document
+func (v *documentValidator) validateID(path, id string) {
+	idPath := path + ".id"
+	if id == "" {
+		v.errorf(idPath, "id is required")
+		return
+	}
+	absolute, ok := v.absolute(idPath, id)
+	if !ok {
+		return
+	}
+	if existing, ok := v.ids[absolute]; ok {
+		v.errorf(idPath, "duplicate id %s, also used by %s", id, existing)
+		return
+	}
+	v.ids[absolute] = path
+}
+
+// validateReference validates a reference to a verification method, which must be in the document when the
+// reference is to one of the document's verification methods
+func (v *documentValidator) validateReference(path, reference string) {
+	absolute, ok := v.absolute(path, reference)
+	if !ok {
+		return
+	}
+	if !strings.HasPrefix(absolute, v.doc.ID+"#") {
+		return
+	}
+	referenced, ok := v.ids[absolute]
+	switch {
+	case !ok:
+		v.errorf(path, "references a verification method not in the document: %s", reference)
+	case strings.HasPrefix(referenced, "$.service"):
+		v.errorf(path, "references a service, not a verification method: %s", reference)
+	}
+}
+
+// absolute resolves an id, which is either a DID URL or a relative reference, against the document's id
+func (v *documentValidator) absolute(path, id string) (string, bool) {
+	absolute := id
+	if strings.HasPrefix(id, "#") {
+		// relative references cannot be resolved against a missing or invalid id, which is reported on its own
+		if !IsValidDID(v.doc.ID) {
+			return "", false
+		}
+		absolute = v.doc.ID + id
+	}
+	if _, err := ParseURL(absolute); err != nil {
+		v.errorf(path, "not a DID URL or relative reference: %s", id)
+		return "", false
+	}
+	return absolute, true
+}
+
+// embeddedVerificationMethod returns the verification method embedded in a verification relationship, which is
+// either a VerificationMethod or its JSON representation
+func embeddedVerificationMethod(entry VerificationMethodSet) (*VerificationMethod, error) {
+	switch vm := entry.(type) {
+	case VerificationMethod:
+		return &vm, nil
+	case *VerificationMethod:
+		if vm == nil {
+			return nil, fmt.Errorf("verification method cannot be empty")
+		}
+		return vm, nil
+	case map[string]any:
+		vmBytes, err := json.Marshal(vm)
+		if err != nil {
+			return nil, err
+		}
+		var embedded VerificationMethod
+		if err = json.Unmarshal(vmBytes, &embedded); err != nil {
+			return nil, err
+		}
+		return &embedded, nil
+	default:
+		return nil, fmt.Errorf("unexpected type %T", entry)
+	}
+}
+
+// isURI returns true if s is an absolute URI
+func isURI(s string) bool {
+	u, err := url.Parse(s)
+	return err == nil && u.Scheme != ""
+}
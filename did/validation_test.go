@@ -0,0 +1,168 @@
+package did
+
+import (
+	"testing"
+
+	"github.com/goccy/go-json"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/extrimian/ssi-sdk/crypto/jwx"
+)
+
+func validDocument() Document {
+	return Document{
+		Context: KnownDIDContext,
+		ID:      "did:example:123",
+		VerificationMethod: []VerificationMethod{
+			{
+				ID:                 "#key-1",
+				Type:               "Ed25519VerificationKey2020",
+				Controller:         "did:example:123",
+				PublicKeyMultibase: "z6MkmM42vxfqZQsv4ehtTjFFxQ4sQKS2w6WR7emozFAn5cxu",
+			},
+			{
+				ID:           "did:example:123#key-2",
+				Type:         "JsonWebKey2020",
+				Controller:   "did:example:123",
+				PublicKeyJWK: &jwx.PublicKeyJWK{KTY: "OKP", CRV: "Ed25519", X: "VCpo2LMLhn6iWku8MKvSLg2ZAoC-nlOyPVQaO3FxVeQ"},
+			},
+		},
+		Authentication: []VerificationMethodSet{"#key-1", "did:example:123#key-2", "did:example:other#key-1"},
+		KeyAgreement: []VerificationMethodSet{VerificationMethod{
+			ID:                 "#key-3",
+			Type:               "X25519KeyAgreementKey2020",
+			Controller:         "did:example:123",
+			PublicKeyMultibase: "z6LSbysY2xFMRpGMhb7tFTLMpeuPRaqaWM1yECx2AtzE3KCc",
+		}},
+		AssertionMethod: []VerificationMethodSet{[]string{"#key-1", "#key-3"}},
+		Services: []Service{
+			{ID: "#linked", Type: "LinkedDomains", ServiceEndpoint: "https://example.com"},
+			{ID: "#messaging", Type: "DIDCommMessaging", ServiceEndpoint: []any{map[string]any{"uri": "https://example.com/didcomm"}}},
+		},
+	}
+}
+
+func TestDocumentValidate(t *testing.T) {
+	t.Run("valid document", func(tt *testing.T) {
+		doc := validDocument()
+		assert.NoError(tt, doc.Validate())
+	})
+
+	t.Run("valid document from JSON", func(tt *testing.T) {
+		docBytes, err := json.Marshal(validDocument())
+		require.NoError(tt, err)
+		var doc Document
+		require.NoError(tt, json.Unmarshal(docBytes, &doc))
+		assert.NoError(tt, doc.Validate())
+	})
+
+	t.Run("empty document", func(tt *testing.T) {
+		var doc *Document
+		assert.ErrorContains(tt, doc.Validate(), "$: document cannot be empty")
+		assertValidationErrors(tt, &Document{}, map[string]string{"$.id": "id is required"})
+	})
+
+	t.Run("invalid ids", func(tt *testing.T) {
+		doc := validDocument()
+		doc.ID = "did:example:123#fragment"
+		doc.Controller = "https://example.com"
+		doc.AlsoKnownAs = "not a uri"
+		assertValidationErrors(tt, &doc, map[string]string{
+			"$.id":          "not a DID: did:example:123#fragment",
+			"$.controller":  "not a DID: https://example.com",
+			"$.alsoKnownAs": "not a URI: not a uri",
+		})
+	})
+
+	t.Run("invalid verification methods", func(tt *testing.T) {
+		doc := validDocument()
+		doc.VerificationMethod[0].Controller = ""
+		doc.VerificationMethod[0].PublicKeyBase58 = "6MkmM42vxfqZQsv4ehtTjFFxQ4sQKS2w6WR7emozFAn5cxu"
+		doc.VerificationMethod[1].ID = "key 2"
+		doc.VerificationMethod[1].Type = ""
+		doc.VerificationMethod[1].PublicKeyJWK = &jwx.PublicKeyJWK{X: "abc"}
+		doc.KeyAgreement[0] = map[string]any{"id": "#key-3", "type": "X25519KeyAgreementKey2020", "controller": "did:example:123", "publicKeyMultibase": "!"}
+		assertValidationErrors(tt, &doc, map[string]string{
+			"$.verificationMethod[0].controller":   "controller is required",
+			"$.verificationMethod[0]":              "must have exactly one public key representation, has publicKeyMultibase, publicKeyBase58",
+			"$.verificationMethod[1].id":           "not a DID URL or relative reference: key 2",
+			"$.verificationMethod[1].type":         "type is required",
+			"$.verificationMethod[1].publicKeyJwk": "invalid JWK",
+			"$.keyAgreement[0].publicKeyMultibase": "invalid multibase value",
+			"$.authentication[1]":                  "references a verification method not in the document: did:example:123#key-2",
+		})
+	})
+
+	t.Run("missing public key", func(tt *testing.T) {
+		doc := validDocument()
+		doc.VerificationMethod[1].PublicKeyJWK = nil
+		assertValidationErrors(tt, &doc, map[string]string{
+			"$.verificationMethod[1]": "must have a public key representation, or a blockchainAccountId",
+		})
+
+		// blockchain accounts stand in for public keys
+		doc.VerificationMethod[1].BlockchainAccountID = "eip155:1:0xb9c5714089478a327f09197987f16f9e5d936e8a"
+		assert.NoError(tt, doc.Validate())
+	})
+
+	t.Run("duplicate ids", func(tt *testing.T) {
+		doc := validDocument()
+		doc.VerificationMethod[1].ID = "did:example:123#key-1"
+		doc.Services[0].ID = "#key-3"
+		assertValidationErrors(tt, &doc, map[string]string{
+			"$.verificationMethod[1].id": "duplicate id did:example:123#key-1, also used by $.verificationMethod[0]",
+			"$.service[0].id":            "duplicate id #key-3, also used by $.keyAgreement[0]",
+			"$.authentication[1]":        "references a verification method not in the document: did:example:123#key-2",
+		})
+	})
+
+	t.Run("invalid references", func(tt *testing.T) {
+		doc := validDocument()
+		doc.Authentication = []VerificationMethodSet{"#key-4", "#linked", "key 1", 42}
+		doc.AssertionMethod = []VerificationMethodSet{[]any{"#key-1", "#key-5"}}
+		assertValidationErrors(tt, &doc, map[string]string{
+			"$.authentication[0]":     "references a verification method not in the document: #key-4",
+			"$.authentication[1]":     "references a service, not a verification method: #linked",
+			"$.authentication[2]":     "not a DID URL or relative reference: key 1",
+			"$.authentication[3]":     "must be a verification method or a reference to one",
+			"$.assertionMethod[0][1]": "references a verification method not in the document: #key-5",
+		})
+	})
+
+	t.Run("invalid services", func(tt *testing.T) {
+		doc := validDocument()
+		doc.Services = []Service{
+			{ID: "#no-endpoint", Type: "LinkedDomains"},
+			{ID: "#relative", Type: "LinkedDomains", ServiceEndpoint: "example.com"},
+			{ID: "#empty", Type: "LinkedDomains", ServiceEndpoint: []string{}},
+			{ID: "#set", ServiceEndpoint: []any{"https://example.com", 42}},
+		}
+		assertValidationErrors(tt, &doc, map[string]string{
+			"$.service[0].serviceEndpoint":    "serviceEndpoint is required",
+			"$.service[1].serviceEndpoint":    "not a URI: example.com",
+			"$.service[2].serviceEndpoint":    "serviceEndpoint cannot be an empty set",
+			"$.service[3].type":               "type is required",
+			"$.service[3].serviceEndpoint[1]": "must be a URI or a map, not int",
+		})
+	})
+}
+
+// assertValidationErrors asserts that the document has exactly the expected errors, given by path with the start of
+// their message
+func assertValidationErrors(t *testing.T, doc *Document, expected map[string]string) {
+	err := doc.Validate()
+	require.Error(t, err)
+	var validationErrors ValidationErrors
+	require.True(t, errors.As(err, &validationErrors))
+	actual := make(map[string]string)
+	for _, validationErr := range validationErrors {
+		actual[validationErr.Path] = validationErr.Message
+	}
+	require.Len(t, actual, len(expected), err.Error())
+	for path, message := range expected {
+		assert.Contains(t, actual, path, err.Error())
+		assert.Contains(t, actual[path], message)
+	}
+}
@@ -0,0 +1,213 @@
+package web
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/goccy/go-json"
+	"github.com/pkg/errors"
+
+	"github.com/extrimian/ssi-sdk/crypto/jwx"
+	"github.com/extrimian/ssi-sdk/did"
+	"github.com/extrimian/ssi-sdk/did/resolution"
+)
+
+// DIF Well Known DID Configuration https://identity.foundation/.well-known/resources/did-configuration/
+// A domain links itself to a DID by hosting a DID Configuration with a Domain Linkage Credential issued by the DID,
+// which lets verifiers confirm that the controller of a did:web DID also controls its domain.
+// Only JWT Domain Linkage Credentials are supported.
+
+const (
+	DIDConfigurationPath        = WellKnownURLPath + "did-configuration.json"
+	DIDConfigurationContext     = "https://identity.foundation/.well-known/did-configuration/v1"
+	DomainLinkageCredentialType = "DomainLinkageCredential"
+
+	verifiableCredentialContext = "https://www.w3.org/2018/credentials/v1"
+	verifiableCredentialType    = "VerifiableCredential"
+)
+
+// DIDConfiguration is the resource hosted at /.well-known/did-configuration.json
+// https://identity.foundation/.well-known/resources/did-configuration/#did-configuration-resource
+type DIDConfiguration struct {
+	Context any `json:"@context"`
+	// LinkedDIDs are Domain Linkage Credentials, either as JWTs or as JSON-LD credentials
+	LinkedDIDs []any `json:"linked_dids"`
+}
+
+// NewDIDConfiguration creates a DID Configuration holding the given JWT Domain Linkage Credentials
+func NewDIDConfiguration(linkedDIDs ...string) DIDConfiguration {
+	config := DIDConfiguration{Context: DIDConfigurationContext}
+	for _, linkedDID := range linkedDIDs {
+		config.LinkedDIDs = append(config.LinkedDIDs, linkedDID)
+	}
+	return config
+}
+
+// domainLinkageVC is the part of the vc claim of a JWT Domain Linkage Credential that is verified
+type domainLinkageVC struct {
+	Type              any `json:"type"`
+	CredentialSubject struct {
+		ID     string `json:"id"`
+		Origin string `json:"origin"`
+	} `json:"credentialSubject"`
+}
+
+// Origin returns the origin of the DID's domain, which is the origin of its DID Configuration
+func (d DIDWeb) Origin() (string, error) {
+	docURL, err := d.GetDocURL()
+	if err != nil {
+		return "", err
+	}
+	parsed, err := url.Parse(docURL)
+	if err != nil {
+		return "", errors.Wrapf(err, "parsing doc url %s", docURL)
+	}
+	return parsed.Scheme + "://" + parsed.Host, nil
+}
+
+// CreateDomainLinkageCredential signs a JWT Domain Linkage Credential linking the DID to its domain, valid until
+// the expiration time. The signer's KID must reference a verification method of the DID.
+// https://identity.foundation/.well-known/resources/did-configuration/#json-web-token-proof-format
+func (d DIDWeb) CreateDomainLinkageCredential(signer jwx.Signer, expiration time.Time) (string, error) {
+	origin, err := d.Origin()
+	if err != nil {
+		return "", err
+	}
+	if signer.ID != d.String() {
+		return "", fmt.Errorf("signer<%s> is not the DID<%s>", signer.ID, d)
+	}
+	now := time.Now()
+	token, err := signer.SignWithDefaults(map[string]any{
+		"sub": d.String(),
+		"nbf": now.Unix(),
+		"exp": expiration.Unix(),
+		"vc": map[string]any{
+			"@context":       []string{verifiableCredentialContext, DIDConfigurationContext},
+			"issuer":         d.String(),
+			"issuanceDate":   now.UTC().Format(time.RFC3339),
+			"expirationDate": expiration.UTC().Format(time.RFC3339),
+			"type":           []string{verifiableCredentialType, DomainLinkageCredentialType},
+			"credentialSubject": map[string]any{
+				"id":     d.String(),
+				"origin": origin,
+			},
+		},
+	})
+	if err != nil {
+		return "", errors.Wrap(err, "signing domain linkage credential")
+	}
+	return string(token), nil
+}
+
+// VerifyDomainLinkage resolves the DID and verifies that the DID Configuration of its domain holds a valid Domain
+// Linkage Credential for it, signed by one of its verification methods. The given client is used for all requests,
+// or DefaultHTTPClient if nil.
+func (d DIDWeb) VerifyDomainLinkage(ctx context.Context, client *http.Client) error {
+	resolved, err := d.resolve(ctx, client, resolution.Options{})
+	if err != nil {
+		return errors.Wrapf(err, "resolving did:web DID<%s>", d)
+	}
+	return d.verifyDomainLinkage(ctx, client, resolved.Document)
+}
+
+func (d DIDWeb) verifyDomainLinkage(ctx context.Context, client *http.Client, doc did.Document) error {
+	origin, err := d.Origin()
+	if err != nil {
+		return err
+	}
+	configURL := origin + "/" + DIDConfigurationPath
+	configBytes, _, err := fetchJSON(ctx, client, configURL, nil)
+	if err != nil {
+		return errors.Wrap(err, "fetching did configuration")
+	}
+	var config DIDConfiguration
+	if err = json.Unmarshal(configBytes, &config); err != nil {
+		return errors.Wrap(err, "unmarshalling did configuration")
+	}
+
+	var errs []string
+	for _, linkedDID := range config.LinkedDIDs {
+		token, ok := linkedDID.(string)
+		if !ok {
+			// JSON-LD credentials are not supported
+			continue
+		}
+		if err = d.verifyDomainLinkageJWT(token, origin, doc); err == nil {
+			return nil
+		}
+		errs = append(errs, err.Error())
+	}
+	if len(errs) == 0 {
+		return fmt.Errorf("did configuration at %s has no domain linkage credentials", configURL)
+	}
+	return fmt.Errorf("did configuration at %s has no valid domain linkage credential for %s: %s", configURL, d,
+		strings.Join(errs, "; "))
+}
+
+// verifyDomainLinkageJWT verifies a JWT Domain Linkage Credential against the DID Document of the DID
+// https://identity.foundation/.well-known/resources/did-configuration/#did-configuration-jwt-domain-linkage-validation
+func (d DIDWeb) verifyDomainLinkageJWT(token, origin string, doc did.Document) error {
+	headers, err := jwx.GetJWSHeaders([]byte(token))
+	if err != nil {
+		return errors.Wrap(err, "getting domain linkage credential headers")
+	}
+	kid := headers.KeyID()
+	if kidDID, _, _ := strings.Cut(did.FullyQualifiedVerificationMethodID(d.String(), kid), "#"); kidDID != d.String() {
+		return fmt.Errorf("kid<%s> does not reference a verification method of %s", kid, d)
+	}
+	pubKey, err := did.GetKeyFromVerificationMethod(doc, kid)
+	if err != nil {
+		return errors.Wrap(err, "getting key to verify domain linkage credential")
+	}
+	verifier, err := jwx.NewJWXVerifier(d.String(), kid, pubKey)
+	if err != nil {
+		return errors.Wrap(err, "constructing verifier")
+	}
+	_, parsed, err := verifier.VerifyAndParse(token)
+	if err != nil {
+		return errors.Wrap(err, "verifying domain linkage credential")
+	}
+	if parsed.Issuer() != d.String() || parsed.Subject() != d.String() {
+		return fmt.Errorf("domain linkage credential iss<%s> and sub<%s> must be %s", parsed.Issuer(), parsed.Subject(), d)
+	}
+	vcClaim, ok := parsed.Get("vc")
+	if !ok {
+		return errors.New("domain linkage credential has no vc claim")
+	}
+	vcBytes, err := json.Marshal(vcClaim)
+	if err != nil {
+		return errors.Wrap(err, "marshalling vc claim")
+	}
+	var vc domainLinkageVC
+	if err = json.Unmarshal(vcBytes, &vc); err != nil {
+		return errors.Wrap(err, "unmarshalling vc claim")
+	}
+	if !hasType(vc.Type, DomainLinkageCredentialType) {
+		return fmt.Errorf("credential is not a %s", DomainLinkageCredentialType)
+	}
+	if vc.CredentialSubject.ID != d.String() {
+		return fmt.Errorf("credentialSubject.id<%s> must be %s", vc.CredentialSubject.ID, d)
+	}
+	if strings.TrimSuffix(vc.CredentialSubject.Origin, "/") != origin {
+		return fmt.Errorf("credentialSubject.origin<%s> must be %s", vc.CredentialSubject.Origin, origin)
+	}
+	return nil
+}
+
+func hasType(types any, want string) bool {
+	switch t := types.(type) {
+	case string:
+		return t == want
+	case []any:
+		for _, v := range t {
+			if v == want {
+				return true
+			}
+		}
+	}
+	return false
+}
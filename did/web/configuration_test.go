@@ -0,0 +1,204 @@
+package web
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/extrimian/ssi-sdk/crypto"
+	"github.com/extrimian/ssi-sdk/crypto/jwx"
+)
+
+// newTLSDIDWeb starts a TLS server hosting a did:web DID for the server's host, along with a signer for its key
+func newTLSDIDWeb(t *testing.T) (*httptest.Server, *Handler, DIDWeb, *jwx.Signer) {
+	handler, err := NewHandler()
+	require.NoError(t, err)
+	server := httptest.NewTLSServer(handler)
+	t.Cleanup(server.Close)
+
+	host := strings.TrimPrefix(server.URL, "https://")
+	didWeb := DIDWeb(Prefix + ":" + url.QueryEscape(host))
+	pk, sk, err := crypto.GenerateEd25519Key()
+	require.NoError(t, err)
+	doc, err := didWeb.CreateDoc(crypto.Ed25519, pk)
+	require.NoError(t, err)
+	require.NoError(t, handler.Put(*doc))
+
+	signer, err := jwx.NewJWXSigner(didWeb.String(), doc.VerificationMethod[0].ID, sk)
+	require.NoError(t, err)
+	return server, handler, didWeb, signer
+}
+
+func TestDomainLinkage(t *testing.T) {
+	server, handler, didWeb, signer := newTLSDIDWeb(t)
+	client := server.Client()
+
+	t.Run("Origin", func(tt *testing.T) {
+		origin, err := didWeb.Origin()
+		require.NoError(tt, err)
+		assert.Equal(tt, server.URL, origin)
+	})
+
+	t.Run("No DID Configuration", func(tt *testing.T) {
+		err := didWeb.VerifyDomainLinkage(context.Background(), client)
+		assert.ErrorContains(tt, err, "fetching did configuration")
+	})
+
+	t.Run("Valid Domain Linkage Credential", func(tt *testing.T) {
+		credential, err := didWeb.CreateDomainLinkageCredential(*signer, time.Now().Add(time.Hour))
+		require.NoError(tt, err)
+		require.NoError(tt, handler.PutDIDConfiguration(NewDIDConfiguration(credential)))
+
+		assert.NoError(tt, didWeb.VerifyDomainLinkage(context.Background(), client))
+
+		resolver, err := NewResolver(WithHTTPClient(client), WithDomainLinkage())
+		require.NoError(tt, err)
+		result, err := resolver.Resolve(context.Background(), didWeb.String())
+		require.NoError(tt, err)
+		assert.Equal(tt, didWeb.String(), result.ID)
+	})
+
+	t.Run("Expired Domain Linkage Credential", func(tt *testing.T) {
+		credential, err := didWeb.CreateDomainLinkageCredential(*signer, time.Now().Add(-time.Hour))
+		require.NoError(tt, err)
+		require.NoError(tt, handler.PutDIDConfiguration(NewDIDConfiguration(credential)))
+
+		err = didWeb.VerifyDomainLinkage(context.Background(), client)
+		assert.ErrorContains(tt, err, "no valid domain linkage credential")
+	})
+
+	t.Run("Domain Linkage Credential Signed By Another Key", func(tt *testing.T) {
+		_, sk, err := crypto.GenerateEd25519Key()
+		require.NoError(tt, err)
+		otherSigner, err := jwx.NewJWXSigner(didWeb.String(), signer.KID, sk)
+		require.NoError(tt, err)
+		credential, err := didWeb.CreateDomainLinkageCredential(*otherSigner, time.Now().Add(time.Hour))
+		require.NoError(tt, err)
+		require.NoError(tt, handler.PutDIDConfiguration(NewDIDConfiguration(credential)))
+
+		err = didWeb.VerifyDomainLinkage(context.Background(), client)
+		assert.ErrorContains(tt, err, "verifying domain linkage credential")
+
+		resolver, err := NewResolver(WithHTTPClient(client), WithDomainLinkage())
+		require.NoError(tt, err)
+		_, err = resolver.Resolve(context.Background(), didWeb.String())
+		assert.ErrorContains(tt, err, "verifying domain linkage")
+	})
+
+	t.Run("Domain Linkage Credential For Another DID", func(tt *testing.T) {
+		_, _, otherDID, otherSigner := newTLSDIDWeb(tt)
+		credential, err := otherDID.CreateDomainLinkageCredential(*otherSigner, time.Now().Add(time.Hour))
+		require.NoError(tt, err)
+		require.NoError(tt, handler.PutDIDConfiguration(NewDIDConfiguration(credential)))
+
+		err = didWeb.VerifyDomainLinkage(context.Background(), client)
+		assert.ErrorContains(tt, err, "does not reference a verification method")
+	})
+
+	t.Run("Signer Is Not The DID", func(tt *testing.T) {
+		_, err := DIDWeb("did:web:example.com").CreateDomainLinkageCredential(*signer, time.Now().Add(time.Hour))
+		assert.Error(tt, err)
+	})
+}
+
+func TestResolverHTTPClient(t *testing.T) {
+	server, _, didWeb, _ := newTLSDIDWeb(t)
+
+	t.Run("Injected Client", func(tt *testing.T) {
+		resolver, err := NewResolver(WithHTTPClient(server.Client()))
+		require.NoError(tt, err)
+		result, err := resolver.Resolve(context.Background(), didWeb.String())
+		require.NoError(tt, err)
+		assert.Equal(tt, didWeb.String(), result.ID)
+	})
+
+	t.Run("Default Client Does Not Trust The Test Server", func(tt *testing.T) {
+		_, err := Resolver{}.Resolve(context.Background(), didWeb.String())
+		assert.Error(tt, err)
+	})
+}
+
+func TestFetchJSON(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/json", func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/did+ld+json; charset=utf-8")
+		_, _ = w.Write([]byte(`{}`))
+	})
+	mux.HandleFunc("/html", func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		_, _ = w.Write([]byte(`<html></html>`))
+	})
+	mux.HandleFunc("/large", func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		// no Content-Length is sent for a streamed body, so the limit applies while reading
+		for i := 0; i <= MaxDocumentSize/1024; i++ {
+			_, _ = w.Write([]byte(strings.Repeat(" ", 1024)))
+			w.(http.Flusher).Flush()
+		}
+	})
+	mux.HandleFunc("/redirect", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/json", http.StatusFound)
+	})
+	mux.HandleFunc("/redirect-loop", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/redirect-loop", http.StatusFound)
+	})
+	mux.HandleFunc("/redirect-http", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "http://"+r.Host+"/json", http.StatusFound)
+	})
+	server := httptest.NewTLSServer(mux)
+	defer server.Close()
+	// the redirect policy is enforced on custom clients too
+	client := server.Client()
+
+	fetch := func(path string) error {
+		_, _, err := fetchJSON(context.Background(), client, server.URL+path, nil)
+		return err
+	}
+
+	t.Run("JSON Content Type With Parameters", func(tt *testing.T) {
+		assert.NoError(tt, fetch("/json"))
+	})
+
+	t.Run("Non JSON Content Type", func(tt *testing.T) {
+		assert.ErrorContains(tt, fetch("/html"), "unsupported Content-Type")
+	})
+
+	t.Run("Body Too Large", func(tt *testing.T) {
+		assert.ErrorContains(tt, fetch("/large"), "exceeds the limit")
+	})
+
+	t.Run("Redirect To Same Host", func(tt *testing.T) {
+		assert.NoError(tt, fetch("/redirect"))
+	})
+
+	t.Run("Too Many Redirects", func(tt *testing.T) {
+		assert.ErrorContains(tt, fetch("/redirect-loop"), "stopped after")
+	})
+
+	t.Run("Redirect To HTTP", func(tt *testing.T) {
+		assert.ErrorContains(tt, fetch("/redirect-http"), "non-https")
+	})
+
+	t.Run("Client Redirect Policy", func(tt *testing.T) {
+		custom := server.Client()
+		custom.CheckRedirect = func(*http.Request, []*http.Request) error {
+			return errors.New("no redirects")
+		}
+		_, _, err := fetchJSON(context.Background(), custom, server.URL+"/redirect", nil)
+		assert.ErrorContains(tt, err, "no redirects")
+		_, _, err = fetchJSON(context.Background(), custom, server.URL+"/redirect-http", nil)
+		assert.ErrorContains(tt, err, "non-https")
+	})
+
+	t.Run("Not Found", func(tt *testing.T) {
+		assert.ErrorContains(tt, fetch("/missing"), "nothing found")
+	})
+}
@@ -0,0 +1,109 @@
+package web
+
+import (
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/goccy/go-json"
+	"github.com/pkg/errors"
+
+	"github.com/extrimian/ssi-sdk/did"
+	"github.com/extrimian/ssi-sdk/util"
+)
+
+// Handler is an http.Handler hosting did:web DID Documents at the paths given by GetDocPath, for local hosting and
+// testing. Documents are matched by path alone, so the handler serves them regardless of the requested host.
+type Handler struct {
+	mu   sync.RWMutex
+	docs map[string][]byte
+}
+
+var _ http.Handler = (*Handler)(nil)
+
+// NewHandler creates a Handler hosting the given documents
+func NewHandler(docs ...did.Document) (*Handler, error) {
+	h := &Handler{docs: make(map[string][]byte)}
+	for _, doc := range docs {
+		if err := h.Put(doc); err != nil {
+			return nil, err
+		}
+	}
+	return h, nil
+}
+
+// Put hosts a document, replacing any document previously hosted for the same DID
+func (h *Handler) Put(doc did.Document) error {
+	docPath, err := DIDWeb(doc.ID).GetDocPath()
+	if err != nil {
+		return errors.Wrapf(err, "getting doc path for %s", doc.ID)
+	}
+	docBytes, err := json.Marshal(doc)
+	if err != nil {
+		return errors.Wrapf(err, "marshalling document %s", doc.ID)
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.docs[docPath] = docBytes
+	return nil
+}
+
+// Get returns the document hosted for a DID
+func (h *Handler) Get(id DIDWeb) (*did.Document, error) {
+	docPath, err := id.GetDocPath()
+	if err != nil {
+		return nil, errors.Wrapf(err, "getting doc path for %s", id)
+	}
+	h.mu.RLock()
+	docBytes, ok := h.docs[docPath]
+	h.mu.RUnlock()
+	if !ok {
+		return nil, errors.Errorf("no document hosted for %s", id)
+	}
+	var doc did.Document
+	if err = json.Unmarshal(docBytes, &doc); err != nil {
+		return nil, errors.Wrapf(err, "unmarshalling document %s", id)
+	}
+	return &doc, nil
+}
+
+// Remove stops hosting the document of a DID, after which it resolves as not found
+func (h *Handler) Remove(id DIDWeb) error {
+	docPath, err := id.GetDocPath()
+	if err != nil {
+		return errors.Wrapf(err, "getting doc path for %s", id)
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	delete(h.docs, docPath)
+	return nil
+}
+
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		w.Header().Set("Allow", "GET, HEAD")
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+	h.mu.RLock()
+	docBytes, ok := h.docs[strings.TrimPrefix(r.URL.Path, "/")]
+	h.mu.RUnlock()
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+	w.Header().Set("Content-Type", util.JSONContentType)
+	_, _ = w.Write(docBytes)
+}
+
+// PutDIDConfiguration hosts a DID Configuration at /.well-known/did-configuration.json
+func (h *Handler) PutDIDConfiguration(config DIDConfiguration) error {
+	configBytes, err := json.Marshal(config)
+	if err != nil {
+		return errors.Wrap(err, "marshalling did configuration")
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.docs[DIDConfigurationPath] = configBytes
+	return nil
+}
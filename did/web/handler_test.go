@@ -0,0 +1,76 @@
+package web
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/extrimian/ssi-sdk/crypto"
+	"github.com/extrimian/ssi-sdk/did/resolution"
+	"github.com/extrimian/ssi-sdk/util"
+)
+
+func TestHandler(t *testing.T) {
+	pk, _, err := crypto.GenerateEd25519Key()
+	require.NoError(t, err)
+	basic, err := didWebBasic.CreateDoc(crypto.Ed25519, pk)
+	require.NoError(t, err)
+	withPath, err := didWebOptionalPath.CreateDoc(crypto.Ed25519, pk)
+	require.NoError(t, err)
+
+	handler, err := NewHandler(*basic, *withPath)
+	require.NoError(t, err)
+
+	get := func(path string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+		return w
+	}
+
+	t.Run("Well Known Path", func(tt *testing.T) {
+		w := get("/.well-known/did.json")
+		assert.Equal(tt, http.StatusOK, w.Code)
+		assert.Equal(tt, util.JSONContentType, w.Header().Get("Content-Type"))
+		result, err := resolution.ParseDIDResolution(w.Body.Bytes())
+		require.NoError(tt, err)
+		assert.Equal(tt, basic.ID, result.ID)
+	})
+
+	t.Run("Optional Path", func(tt *testing.T) {
+		w := get("/user/alice/did.json")
+		assert.Equal(tt, http.StatusOK, w.Code)
+		result, err := resolution.ParseDIDResolution(w.Body.Bytes())
+		require.NoError(tt, err)
+		assert.Equal(tt, withPath.ID, result.ID)
+	})
+
+	t.Run("Get Document", func(tt *testing.T) {
+		doc, err := handler.Get(didWebBasic)
+		require.NoError(tt, err)
+		assert.Equal(tt, basic.ID, doc.ID)
+		assert.Equal(tt, basic.VerificationMethod, doc.VerificationMethod)
+	})
+
+	t.Run("Removed Document", func(tt *testing.T) {
+		require.NoError(tt, handler.Remove(didWebOptionalPath))
+		assert.Equal(tt, http.StatusNotFound, get("/user/alice/did.json").Code)
+		_, err := handler.Get(didWebOptionalPath)
+		assert.ErrorContains(tt, err, "no document hosted")
+	})
+
+	t.Run("Wrong Method", func(tt *testing.T) {
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/.well-known/did.json", nil))
+		assert.Equal(tt, http.StatusMethodNotAllowed, w.Code)
+	})
+
+	t.Run("Invalid DID", func(tt *testing.T) {
+		invalid := *basic
+		invalid.ID = didWebNotADomain.String()
+		_, err := NewHandler(invalid)
+		assert.Error(tt, err)
+	})
+}
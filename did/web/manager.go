@@ -0,0 +1,284 @@
+package web
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/goccy/go-json"
+	"github.com/pkg/errors"
+
+	"github.com/extrimian/ssi-sdk/crypto"
+	"github.com/extrimian/ssi-sdk/did"
+)
+
+// Relationship is a verification relationship https://www.w3.org/TR/did-core/#verification-relationships
+type Relationship = did.Relationship
+
+const (
+	Authentication       = did.Authentication
+	AssertionMethod      = did.AssertionMethod
+	KeyAgreement         = did.KeyAgreement
+	CapabilityInvocation = did.CapabilityInvocation
+	CapabilityDelegation = did.CapabilityDelegation
+)
+
+// DocumentManager maintains the DID Document of a did:web DID, which is hosted by its controller rather than
+// registered anywhere: https://w3c-ccg.github.io/did-method-web/#update
+// Verification methods are added with the relationships they are used for, and can be rotated or removed later.
+// Once updated, the document is written to the path given by GetDocPath, or served with a Handler.
+type DocumentManager struct {
+	doc did.Document
+}
+
+// NewDocumentManager creates a manager for a new, empty DID Document
+func NewDocumentManager(id DIDWeb) (*DocumentManager, error) {
+	if _, err := id.GetDocURL(); err != nil {
+		return nil, errors.Wrap(err, "invalid did:web DID")
+	}
+	return &DocumentManager{doc: did.Document{Context: did.KnownDIDContext, ID: id.String()}}, nil
+}
+
+// NewDocumentManagerFromDocument creates a manager for an existing DID Document, such as one made by CreateDoc
+func NewDocumentManagerFromDocument(doc did.Document) (*DocumentManager, error) {
+	if _, err := DIDWeb(doc.ID).GetDocURL(); err != nil {
+		return nil, errors.Wrap(err, "invalid did:web DID")
+	}
+	return &DocumentManager{doc: copyDocument(doc)}, nil
+}
+
+// Document returns a copy of the managed DID Document
+func (m *DocumentManager) Document() did.Document {
+	return copyDocument(m.doc)
+}
+
+// AddVerificationMethod adds a verification method for a public key, identified by the fragment and referenced from
+// each of the given relationships. Key agreement keys, such as X25519 keys, can only be used for keyAgreement.
+func (m *DocumentManager) AddVerificationMethod(fragment string, kt crypto.KeyType, publicKey []byte, relationships ...Relationship) error {
+	id := m.verificationMethodID(fragment)
+	if m.indexOfVerificationMethod(id) >= 0 {
+		return fmt.Errorf("verification method already exists: %s", id)
+	}
+	if err := checkRelationships(kt, relationships); err != nil {
+		return err
+	}
+	vm, err := did.ConstructJWKVerificationMethod(id, m.doc.ID, publicKey, kt)
+	if err != nil {
+		return errors.Wrapf(err, "constructing verification method: %s", id)
+	}
+	m.doc.VerificationMethod = append(m.doc.VerificationMethod, *vm)
+	for _, relationship := range relationships {
+		set := m.relationship(relationship)
+		*set = append(*set, id)
+	}
+	return nil
+}
+
+// RotateVerificationMethod replaces the key of an existing verification method, keeping its id and relationships
+func (m *DocumentManager) RotateVerificationMethod(fragment string, kt crypto.KeyType, publicKey []byte) error {
+	id := m.verificationMethodID(fragment)
+	i := m.indexOfVerificationMethod(id)
+	if i < 0 {
+		return fmt.Errorf("verification method not found: %s", id)
+	}
+	var relationships []Relationship
+	for _, relationship := range did.Relationships() {
+		if referencesID(*m.relationship(relationship), id) {
+			relationships = append(relationships, relationship)
+		}
+	}
+	if err := checkRelationships(kt, relationships); err != nil {
+		return err
+	}
+	vm, err := did.ConstructJWKVerificationMethod(id, m.doc.ID, publicKey, kt)
+	if err != nil {
+		return errors.Wrapf(err, "constructing verification method: %s", id)
+	}
+	m.doc.VerificationMethod[i] = *vm
+	return nil
+}
+
+// RemoveVerificationMethod removes a verification method and all references to it
+func (m *DocumentManager) RemoveVerificationMethod(fragment string) error {
+	id := m.verificationMethodID(fragment)
+	i := m.indexOfVerificationMethod(id)
+	if i < 0 {
+		return fmt.Errorf("verification method not found: %s", id)
+	}
+	m.doc.VerificationMethod = append(m.doc.VerificationMethod[:i], m.doc.VerificationMethod[i+1:]...)
+	for _, relationship := range did.Relationships() {
+		set := m.relationship(relationship)
+		var remaining []did.VerificationMethodSet
+		for _, ref := range *set {
+			if refs, ok := ref.([]string); ok {
+				var kept []string
+				for _, r := range refs {
+					if r != id {
+						kept = append(kept, r)
+					}
+				}
+				if len(kept) > 0 {
+					remaining = append(remaining, kept)
+				}
+				continue
+			}
+			if !referencesID(did.VerificationRelationship{ref}, id) {
+				remaining = append(remaining, ref)
+			}
+		}
+		*set = remaining
+	}
+	return nil
+}
+
+// AddService adds a service. A relative service id is qualified with the DID.
+func (m *DocumentManager) AddService(service did.Service) error {
+	service.ID = did.FullyQualifiedVerificationMethodID(m.doc.ID, service.ID)
+	if !service.IsValid() {
+		return errors.New("invalid service")
+	}
+	if m.indexOfService(service.ID) >= 0 {
+		return fmt.Errorf("service already exists: %s", service.ID)
+	}
+	m.doc.Services = append(m.doc.Services, service)
+	return nil
+}
+
+// RemoveService removes the service with the given id or fragment
+func (m *DocumentManager) RemoveService(id string) error {
+	i := m.indexOfService(did.FullyQualifiedVerificationMethodID(m.doc.ID, id))
+	if i < 0 {
+		return fmt.Errorf("service not found: %s", id)
+	}
+	m.doc.Services = append(m.doc.Services[:i], m.doc.Services[i+1:]...)
+	return nil
+}
+
+// DocumentBytes returns the contents of the did.json file
+func (m *DocumentManager) DocumentBytes() ([]byte, error) {
+	return json.MarshalIndent(m.doc, "", "  ")
+}
+
+// WriteFile writes the did.json file into the directory tree rooted at root, which is served at the root of the
+// DID's domain. The written file path is returned.
+func (m *DocumentManager) WriteFile(root string) (string, error) {
+	docPath, err := DIDWeb(m.doc.ID).GetDocPath()
+	if err != nil {
+		return "", err
+	}
+	docBytes, err := m.DocumentBytes()
+	if err != nil {
+		return "", errors.Wrap(err, "marshalling document")
+	}
+	filePath := filepath.Clean(filepath.Join(root, filepath.FromSlash(docPath)))
+	if rel, err := filepath.Rel(filepath.Clean(root), filePath); err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("document path %s escapes the root %s", docPath, root)
+	}
+	if err = os.MkdirAll(filepath.Dir(filePath), 0o755); err != nil {
+		return "", errors.Wrap(err, "creating document directory")
+	}
+	if err = os.WriteFile(filePath, docBytes, 0o644); err != nil {
+		return "", errors.Wrap(err, "writing document")
+	}
+	return filePath, nil
+}
+
+// GetDocPath returns the path of the DID Document relative to the root of the domain, either
+// .well-known/did.json or the optional path followed by did.json
+func (d DIDWeb) GetDocPath() (string, error) {
+	docURL, err := d.GetDocURL()
+	if err != nil {
+		return "", err
+	}
+	parsed, err := url.Parse(docURL)
+	if err != nil {
+		return "", errors.Wrapf(err, "parsing doc url %s", docURL)
+	}
+	return strings.TrimPrefix(parsed.Path, "/"), nil
+}
+
+func (m *DocumentManager) verificationMethodID(fragment string) string {
+	return did.FullyQualifiedVerificationMethodID(m.doc.ID, fragment)
+}
+
+func (m *DocumentManager) indexOfVerificationMethod(id string) int {
+	for i, vm := range m.doc.VerificationMethod {
+		if did.FullyQualifiedVerificationMethodID(m.doc.ID, vm.ID) == id {
+			return i
+		}
+	}
+	return -1
+}
+
+func (m *DocumentManager) indexOfService(id string) int {
+	for i, service := range m.doc.Services {
+		if did.FullyQualifiedVerificationMethodID(m.doc.ID, service.ID) == id {
+			return i
+		}
+	}
+	return -1
+}
+
+func (m *DocumentManager) relationship(relationship Relationship) *did.VerificationRelationship {
+	switch relationship {
+	case AssertionMethod:
+		return &m.doc.AssertionMethod
+	case KeyAgreement:
+		return &m.doc.KeyAgreement
+	case CapabilityInvocation:
+		return &m.doc.CapabilityInvocation
+	case CapabilityDelegation:
+		return &m.doc.CapabilityDelegation
+	default:
+		return &m.doc.Authentication
+	}
+}
+
+// referencesID returns true if a verification relationship references the verification method with the given id.
+// References may be strings, string sets such as the ones made by CreateDoc, or embedded verification methods.
+func referencesID(relationship did.VerificationRelationship, id string) bool {
+	for _, ref := range relationship.References() {
+		if ref == id {
+			return true
+		}
+	}
+	for _, vm := range relationship.Embedded() {
+		if vm.ID == id {
+			return true
+		}
+	}
+	return false
+}
+
+// checkRelationships makes sure key agreement keys are only used for key agreement, and signing keys that cannot
+// be used for key agreement are not
+func checkRelationships(kt crypto.KeyType, relationships []Relationship) error {
+	for _, relationship := range relationships {
+		switch relationship {
+		case Authentication, AssertionMethod, CapabilityInvocation, CapabilityDelegation:
+			if kt == crypto.X25519 {
+				return fmt.Errorf("%s keys cannot be used for %s", kt, relationship)
+			}
+		case KeyAgreement:
+			if kt == crypto.Ed25519 {
+				return fmt.Errorf("%s keys cannot be used for %s", kt, relationship)
+			}
+		default:
+			return fmt.Errorf("unknown verification relationship: %s", relationship)
+		}
+	}
+	return nil
+}
+
+func copyDocument(doc did.Document) did.Document {
+	doc.VerificationMethod = append([]did.VerificationMethod(nil), doc.VerificationMethod...)
+	doc.Authentication = append([]did.VerificationMethodSet(nil), doc.Authentication...)
+	doc.AssertionMethod = append([]did.VerificationMethodSet(nil), doc.AssertionMethod...)
+	doc.KeyAgreement = append([]did.VerificationMethodSet(nil), doc.KeyAgreement...)
+	doc.CapabilityInvocation = append([]did.VerificationMethodSet(nil), doc.CapabilityInvocation...)
+	doc.CapabilityDelegation = append([]did.VerificationMethodSet(nil), doc.CapabilityDelegation...)
+	doc.Services = append([]did.Service(nil), doc.Services...)
+	return doc
+}
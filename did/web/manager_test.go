@@ -0,0 +1,152 @@
+package web
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/goccy/go-json"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/extrimian/ssi-sdk/crypto"
+	"github.com/extrimian/ssi-sdk/did"
+)
+
+func TestDocumentManager(t *testing.T) {
+	t.Run("Invalid DID", func(tt *testing.T) {
+		_, err := NewDocumentManager(didWebNotADomain)
+		assert.Error(tt, err)
+	})
+
+	t.Run("Multiple Keys And Relationships", func(tt *testing.T) {
+		m, err := NewDocumentManager(didWebBasic)
+		require.NoError(tt, err)
+
+		signingKey, _, err := crypto.GenerateEd25519Key()
+		require.NoError(tt, err)
+		agreementKey, _, err := crypto.GenerateX25519Key()
+		require.NoError(tt, err)
+
+		require.NoError(tt, m.AddVerificationMethod("key-1", crypto.Ed25519, signingKey, Authentication, AssertionMethod, CapabilityInvocation))
+		require.NoError(tt, m.AddVerificationMethod("#key-2", crypto.X25519, agreementKey, KeyAgreement))
+		assert.Error(tt, m.AddVerificationMethod("key-1", crypto.Ed25519, signingKey, Authentication))
+		assert.Error(tt, m.AddVerificationMethod("key-3", crypto.X25519, agreementKey, Authentication))
+		assert.Error(tt, m.AddVerificationMethod("key-3", crypto.Ed25519, signingKey, KeyAgreement))
+
+		doc := m.Document()
+		assert.Len(tt, doc.VerificationMethod, 2)
+		assert.Equal(tt, did.VerificationRelationship{"did:web:example.com#key-1"}, doc.Authentication)
+		assert.Equal(tt, did.VerificationRelationship{"did:web:example.com#key-1"}, doc.CapabilityInvocation)
+		assert.Equal(tt, did.VerificationRelationship{"did:web:example.com#key-2"}, doc.KeyAgreement)
+		assert.NoError(tt, doc.IsValid())
+
+		key, err := did.GetKeyFromVerificationMethod(doc, "key-2")
+		require.NoError(tt, err)
+		assert.NotNil(tt, key)
+	})
+
+	t.Run("Rotate And Remove", func(tt *testing.T) {
+		pk, _, err := crypto.GenerateEd25519Key()
+		require.NoError(tt, err)
+		created, err := didWebBasic.CreateDoc(crypto.Ed25519, pk)
+		require.NoError(tt, err)
+		m, err := NewDocumentManagerFromDocument(*created)
+		require.NoError(tt, err)
+
+		rotated, _, err := crypto.GenerateEd25519Key()
+		require.NoError(tt, err)
+		require.NoError(tt, m.RotateVerificationMethod("owner", crypto.Ed25519, rotated))
+		assert.Error(tt, m.RotateVerificationMethod("unknown", crypto.Ed25519, rotated))
+		assert.NotEqual(tt, created.VerificationMethod[0].PublicKeyJWK.X, m.Document().VerificationMethod[0].PublicKeyJWK.X)
+		assert.Equal(tt, created.Authentication, m.Document().Authentication)
+
+		agreementKey, _, err := crypto.GenerateX25519Key()
+		require.NoError(tt, err)
+		assert.Error(tt, m.RotateVerificationMethod("owner", crypto.X25519, agreementKey))
+
+		require.NoError(tt, m.RemoveVerificationMethod("#owner"))
+		assert.Error(tt, m.RemoveVerificationMethod("#owner"))
+		doc := m.Document()
+		assert.Empty(tt, doc.VerificationMethod)
+		assert.Empty(tt, doc.Authentication)
+		assert.Empty(tt, doc.AssertionMethod)
+	})
+
+	t.Run("Services", func(tt *testing.T) {
+		m, err := NewDocumentManager(didWebBasic)
+		require.NoError(tt, err)
+		service := did.Service{ID: "#hub", Type: "LinkedDomains", ServiceEndpoint: "https://example.com"}
+		require.NoError(tt, m.AddService(service))
+		assert.Error(tt, m.AddService(service))
+		assert.Error(tt, m.AddService(did.Service{ID: "#bad"}))
+		assert.Equal(tt, "did:web:example.com#hub", m.Document().Services[0].ID)
+
+		require.NoError(tt, m.RemoveService("hub"))
+		assert.Error(tt, m.RemoveService("hub"))
+		assert.Empty(tt, m.Document().Services)
+	})
+
+	t.Run("Write File", func(tt *testing.T) {
+		pk, _, err := crypto.GenerateEd25519Key()
+		require.NoError(tt, err)
+		m, err := NewDocumentManager(didWebOptionalPath)
+		require.NoError(tt, err)
+		require.NoError(tt, m.AddVerificationMethod("key-1", crypto.Ed25519, pk, Authentication))
+
+		root := tt.TempDir()
+		path, err := m.WriteFile(root)
+		require.NoError(tt, err)
+		assert.Equal(tt, filepath.Join(root, "user", "alice", "did.json"), path)
+
+		docBytes, err := os.ReadFile(path)
+		require.NoError(tt, err)
+		var doc did.Document
+		require.NoError(tt, json.Unmarshal(docBytes, &doc))
+		assert.Equal(tt, didWebOptionalPath.String(), doc.ID)
+	})
+
+	t.Run("Path Traversal", func(tt *testing.T) {
+		root := filepath.Join(tt.TempDir(), "root")
+		for _, id := range []DIDWeb{
+			"did:web:example.com:..:..:etc",
+			"did:web:example.com:%2E%2E:%2E%2E:etc",
+			"did:web:example.com:user%2F..%2F..:etc",
+			"did:web:example.com:user%5C..:etc",
+			"did:web:example.com::etc",
+			"did:web:example.com%2F..%2F..",
+		} {
+			_, err := NewDocumentManager(id)
+			assert.Error(tt, err, id)
+
+			m := &DocumentManager{doc: did.Document{ID: id.String()}}
+			_, err = m.WriteFile(root)
+			assert.Error(tt, err, id)
+		}
+		_, err := os.Stat(filepath.Dir(root))
+		require.NoError(tt, err)
+		entries, err := os.ReadDir(filepath.Dir(root))
+		require.NoError(tt, err)
+		assert.Empty(tt, entries)
+	})
+}
+
+func TestDIDWebGetDocPath(t *testing.T) {
+	path, err := didWebBasic.GetDocPath()
+	assert.NoError(t, err)
+	assert.Equal(t, ".well-known/did.json", path)
+
+	path, err = didWebOptionalPath.GetDocPath()
+	assert.NoError(t, err)
+	assert.Equal(t, "user/alice/did.json", path)
+
+	_, err = didWebNotADomain.GetDocPath()
+	assert.Error(t, err)
+}
+
+func TestDIDWebGetDocPathTraversal(t *testing.T) {
+	for _, id := range []DIDWeb{"did:web:example.com:.", "did:web:example.com:%2e%2e", "did:web:example.com:a%2Fb"} {
+		_, err := id.GetDocPath()
+		assert.ErrorContains(t, err, "invalid path segment", id)
+	}
+}
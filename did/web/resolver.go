@@ -2,8 +2,9 @@ package web
 
 import (
 	"context"
-	"fmt"
+	"net/http"
 	"strings"
+	"time"
 
 	"github.com/pkg/errors"
 
@@ -11,24 +12,80 @@ import (
 	"github.com/extrimian/ssi-sdk/did/resolution"
 )
 
-type Resolver struct{}
+// Resolver resolves did:web DIDs. The zero value uses DefaultHTTPClient and does not verify domain linkage.
+type Resolver struct {
+	client              *http.Client
+	verifyDomainLinkage bool
+}
 
 var _ resolution.Resolver = (*Resolver)(nil)
 
+// ResolverOption configures a Resolver
+type ResolverOption func(r *Resolver)
+
+// WithHTTPClient sets the client used to fetch DID Documents and DID Configurations. Redirects are limited by
+// CheckRedirect in addition to the client's own redirect policy.
+func WithHTTPClient(client *http.Client) ResolverOption {
+	return func(r *Resolver) {
+		r.client = client
+	}
+}
+
+// WithDomainLinkage makes resolution fail unless the DID's domain links to it with a Domain Linkage Credential in its
+// DID Configuration, see VerifyDomainLinkage
+func WithDomainLinkage() ResolverOption {
+	return func(r *Resolver) {
+		r.verifyDomainLinkage = true
+	}
+}
+
+// NewResolver creates a Resolver configured with the given options
+func NewResolver(opts ...ResolverOption) (*Resolver, error) {
+	r := new(Resolver)
+	for _, opt := range opts {
+		opt(r)
+	}
+	return r, nil
+}
+
 func (Resolver) Methods() []did.Method {
 	return []did.Method{did.WebMethod}
 }
 
 // Resolve fetches and returns the Document from the expected URL
 // specification: https://w3c-ccg.github.io/did-method-web/#read-resolve
-func (Resolver) Resolve(ctx context.Context, id string, _ ...resolution.Option) (*resolution.Result, error) {
+func (r Resolver) Resolve(ctx context.Context, id string, opts ...resolution.Option) (*resolution.Result, error) {
+	start := time.Now()
 	if !strings.HasPrefix(id, Prefix) {
-		return nil, fmt.Errorf("not a did:web DID: %s", id)
+		return nil, resolution.NewResolutionErrorf(resolution.MethodNotSupportedErrorCode, "not a did:web DID: %s", id)
+	}
+	options, err := resolution.ParseOptions(opts...)
+	if err != nil {
+		return nil, err
 	}
 	didWeb := DIDWeb(id)
-	doc, err := didWeb.Resolve(ctx)
+	resolved, err := didWeb.resolve(ctx, r.client, *options)
 	if err != nil {
 		return nil, errors.Wrapf(err, "resolving did:web DID: %s", id)
 	}
-	return &resolution.Result{Document: *doc}, nil
+	if r.verifyDomainLinkage {
+		if err = didWeb.verifyDomainLinkage(ctx, r.client, resolved.Document); err != nil {
+			return nil, errors.Wrapf(err, "verifying domain linkage of did:web DID: %s", id)
+		}
+	}
+	result := resolution.NewResult(resolved.Document, resolved.DocumentMetadata, start)
+	if err = resolution.ValidateResult(result); err != nil {
+		return nil, err
+	}
+	return options.Apply(result), nil
+}
+
+// VerifyDomainLinkage resolves the DID and verifies that its domain links to it, see DIDWeb.VerifyDomainLinkage
+func (r Resolver) VerifyDomainLinkage(ctx context.Context, id string) error {
+	didWeb := DIDWeb(id)
+	resolved, err := didWeb.resolve(ctx, r.client, resolution.Options{})
+	if err != nil {
+		return errors.Wrapf(err, "resolving did:web DID: %s", id)
+	}
+	return didWeb.verifyDomainLinkage(ctx, r.client, resolved.Document)
 }
@@ -4,9 +4,11 @@ import (
 	"context"
 	"fmt"
 	"io"
+	"mime"
 	"net/http"
 	"net/url"
 	"strings"
+	"time"
 
 	"github.com/goccy/go-json"
 	"github.com/pkg/errors"
@@ -28,16 +30,73 @@ const (
 	WellKnownURLPath = ".well-known/"
 	DIDDocFilename   = "did.json"
 	Prefix           = "did:web"
+
+	// MaxDocumentSize is the largest response body read when fetching a DID Document or DID Configuration
+	MaxDocumentSize = 1 << 20
+	// MaxRedirects is the number of redirects followed when fetching a DID Document or DID Configuration
+	MaxRedirects = 3
+	// DefaultTimeout bounds each request made by the default HTTP client
+	DefaultTimeout = 30 * time.Second
 )
 
+// jsonContentTypes are the media types accepted for DID Documents and DID Configurations
+var jsonContentTypes = map[string]bool{
+	util.JSONContentType:      true,
+	"application/did+json":    true,
+	"application/did+ld+json": true,
+	"application/ld+json":     true,
+}
+
+// DefaultHTTPClient is used when no client is given. Redirects are limited by CheckRedirect.
+var DefaultHTTPClient = &http.Client{
+	Timeout:       DefaultTimeout,
+	CheckRedirect: CheckRedirect,
+}
+
+// CheckRedirect is the redirect policy of DefaultHTTPClient, which can be reused by custom clients. At most
+// MaxRedirects redirects are followed, and only to HTTPS URLs on the host of the original request, so that the domain
+// named by the DID remains in control of the document.
+func CheckRedirect(req *http.Request, via []*http.Request) error {
+	if len(via) >= MaxRedirects {
+		return fmt.Errorf("stopped after %d redirects", MaxRedirects)
+	}
+	if req.URL.Scheme != "https" {
+		return fmt.Errorf("refusing to follow redirect to non-https url: %s", req.URL)
+	}
+	if req.URL.Host != via[0].URL.Host {
+		return fmt.Errorf("refusing to follow redirect to another host: %s", req.URL.Host)
+	}
+	return nil
+}
+
+// WithRedirectPolicy returns a client enforcing CheckRedirect, or DefaultHTTPClient if client is nil. A custom client
+// is copied, and its own redirect policy, if any, is checked after CheckRedirect.
+func WithRedirectPolicy(client *http.Client) *http.Client {
+	if client == nil {
+		return DefaultHTTPClient
+	}
+	checkRedirect := client.CheckRedirect
+	policyClient := *client
+	policyClient.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+		if err := CheckRedirect(req, via); err != nil {
+			return err
+		}
+		if checkRedirect != nil {
+			return checkRedirect(req, via)
+		}
+		return nil
+	}
+	return &policyClient
+}
+
 // Validate return nil if DID is valid, otherwise the validation error.
 func (d DIDWeb) Validate(ctx context.Context) error {
-	docBytes, header, err := d.resolveDocBytes(ctx)
+	docBytes, header, err := d.resolveDocBytes(ctx, nil, resolution.Options{})
 	if err != nil {
 		return errors.Wrap(err, "resolving doc bytes")
 	}
 	contentType := header.Get("Content-Type")
-	if contentType != util.JSONContentType {
+	if !isJSONContentType(contentType) {
 		return errors.Errorf("header Content-Type received was `%s` but expected `%s`", contentType, "application/json")
 	}
 	var doc did.Document
@@ -118,6 +177,9 @@ func (d DIDWeb) GetDocURL() (string, error) {
 	if err != nil {
 		return "", errors.Wrapf(err, "url.QueryUnescape failed for subStr %s", subStrs[2])
 	}
+	if strings.ContainsAny(decodedDomain, `/\?#@`) {
+		return "", fmt.Errorf("did:web DID %+v has an invalid domain", d)
+	}
 
 	// 3. Generate an HTTPS URL to the expected location of the DID document by prepending https://.
 	if numSubStrs == 3 {
@@ -138,6 +200,9 @@ func (d DIDWeb) GetDocURL() (string, error) {
 		if err != nil {
 			return "", errors.Wrapf(err, "url.QueryUnescape failed for subStr %s", subStrs[i])
 		}
+		if !isValidPathSegment(str) {
+			return "", fmt.Errorf("did:web DID %+v has an invalid path segment %q", d, subStrs[i])
+		}
 		if _, err = sb.WriteString(str + "/"); err != nil {
 			return "", err
 		}
@@ -148,8 +213,26 @@ func (d DIDWeb) GetDocURL() (string, error) {
 	return sb.String(), nil
 }
 
+// isValidPathSegment returns true if a decoded path segment names a single directory, so the path of the DID Document
+// cannot escape the root of the domain
+func isValidPathSegment(segment string) bool {
+	return segment != "" && segment != "." && segment != ".." && !strings.ContainsAny(segment, `/\`)
+}
+
 func (d DIDWeb) Resolve(ctx context.Context) (*did.Document, error) {
-	docBytes, _, err := d.resolveDocBytes(ctx)
+	result, err := d.resolve(ctx, nil, resolution.Options{})
+	if err != nil {
+		return nil, err
+	}
+	return &result.Document, nil
+}
+
+// resolve fetches the DID Document, honoring the resolution options. A specific version of the document is requested
+// by adding the `versionId` or `versionTime` DID parameters to the document's URL, as is done for versioned
+// documents hosted by did:web servers. The server must respond with DID Document Metadata confirming the requested
+// version, otherwise the version is not found.
+func (d DIDWeb) resolve(ctx context.Context, client *http.Client, options resolution.Options) (*resolution.Result, error) {
+	docBytes, _, err := d.resolveDocBytes(ctx, client, options)
 	if err != nil {
 		return nil, errors.Wrapf(err, "resolving did:web DID<%s>", d)
 	}
@@ -160,33 +243,87 @@ func (d DIDWeb) Resolve(ctx context.Context) (*did.Document, error) {
 	if resolutionResult.ID != d.String() {
 		return nil, fmt.Errorf("doc.id<%s> does not match did:web value<%s>", resolutionResult.ID, d)
 	}
-	return &resolutionResult.Document, nil
+	if err = options.CheckVersion(resolutionResult.DocumentMetadata); err != nil {
+		return nil, errors.Wrapf(err, "resolving did:web DID<%s>", d)
+	}
+	return resolutionResult, nil
 }
 
-// resolveDocBytes simply performs a http.Get on the expected URL of the DID Document from GetDocURL
-// and returns the bytes of the fetched file
-func (d DIDWeb) resolveDocBytes(ctx context.Context) ([]byte, http.Header, error) {
+// resolveDocBytes performs a http.Get on the expected URL of the DID Document from GetDocURL using the given client,
+// or DefaultHTTPClient if nil, and returns the bytes of the fetched file
+func (d DIDWeb) resolveDocBytes(ctx context.Context, client *http.Client, options resolution.Options) ([]byte, http.Header, error) {
 	docURL, err := d.GetDocURL()
 	if err != nil {
-		return nil, nil, errors.Wrapf(err, "getting doc url %+v", d)
+		return nil, nil, resolution.NewResolutionError(resolution.InvalidDIDErrorCode, errors.Wrapf(err, "getting doc url %+v", d))
+	}
+	if options.IsVersioned() {
+		query := url.Values{}
+		if options.VersionID != "" {
+			query.Set(did.VersionIDParameter, options.VersionID)
+		}
+		if options.VersionTime != nil {
+			query.Set(did.VersionTimeParameter, options.FormatVersionTime())
+		}
+		docURL += "?" + query.Encode()
 	}
 	// Specification https://w3c-ccg.github.io/did-method-web/#read-resolve
 	// 6. Perform an HTTP GET request to the URL using an agent that can successfully negotiate a secure HTTPS
 	// connection, which enforces the security requirements as described in 2.5 Security and privacy considerations.
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, docURL, nil)
+	header := http.Header{}
+	if options.NoCache {
+		header.Set("Cache-Control", "no-cache")
+	}
+	return fetchJSON(ctx, client, docURL, header)
+}
+
+// fetchJSON gets a JSON resource, reading at most MaxDocumentSize bytes of its body. Redirects are followed according
+// to CheckRedirect, whichever client is used. Responses declaring a content type other than a JSON one are rejected.
+// Not found and gone responses result in a notFound resolution error.
+func fetchJSON(ctx context.Context, client *http.Client, resourceURL string, header http.Header) ([]byte, http.Header, error) {
+	client = WithRedirectPolicy(client)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, resourceURL, nil)
 	if err != nil {
-		return nil, nil, errors.Wrapf(err, "constructing doc request %+v", docURL)
+		return nil, nil, errors.Wrapf(err, "constructing request %+v", resourceURL)
+	}
+	for k, v := range header {
+		req.Header[k] = v
 	}
-	resp, err := http.DefaultClient.Do(req)
+	req.Header.Set("Accept", util.JSONContentType)
+	resp, err := client.Do(req)
 	if err != nil {
-		return nil, nil, errors.Wrapf(err, "getting doc %+v", docURL)
+		return nil, nil, errors.Wrapf(err, "getting %+v", resourceURL)
 	}
 	defer func() {
 		_ = resp.Body.Close()
 	}()
-	body, err := io.ReadAll(resp.Body)
+	switch {
+	case resp.StatusCode == http.StatusNotFound || resp.StatusCode == http.StatusGone:
+		return nil, nil, resolution.NewResolutionErrorf(resolution.NotFoundErrorCode, "nothing found at %s", resourceURL)
+	case resp.StatusCode < 200 || resp.StatusCode >= 300:
+		return nil, nil, fmt.Errorf("getting %s: unexpected status code %d", resourceURL, resp.StatusCode)
+	}
+	// a missing content type is tolerated, since plenty of static hosts do not set one for .json files
+	if contentType := resp.Header.Get("Content-Type"); contentType != "" && !isJSONContentType(contentType) {
+		return nil, nil, fmt.Errorf("getting %s: unsupported Content-Type `%s`", resourceURL, contentType)
+	}
+	if resp.ContentLength > MaxDocumentSize {
+		return nil, nil, fmt.Errorf("getting %s: response of %d bytes exceeds the limit of %d", resourceURL, resp.ContentLength, MaxDocumentSize)
+	}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, MaxDocumentSize+1))
 	if err != nil {
-		return nil, nil, errors.Wrapf(err, "reading response %+v", resp)
+		return nil, nil, errors.Wrapf(err, "reading response %+v", resourceURL)
+	}
+	if len(body) > MaxDocumentSize {
+		return nil, nil, fmt.Errorf("getting %s: response exceeds the limit of %d bytes", resourceURL, MaxDocumentSize)
 	}
 	return body, resp.Header, nil
 }
+
+// isJSONContentType returns true for JSON media types, ignoring any parameters such as the charset
+func isJSONContentType(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	return jsonContentTypes[mediaType]
+}
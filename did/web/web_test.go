@@ -3,12 +3,14 @@ package web
 import (
 	"context"
 	"testing"
+	"time"
 
 	"gopkg.in/h2non/gock.v1"
 
 	"github.com/stretchr/testify/assert"
 
 	"github.com/extrimian/ssi-sdk/crypto"
+	"github.com/extrimian/ssi-sdk/did/resolution"
 )
 
 const (
@@ -56,13 +58,13 @@ func TestDIDWebResolveDocBytes(t *testing.T) {
 			BodyString(`{"didDocument": {"id": "did:web:demo.ssi-sdk.com"}}`)
 		defer gock.Off()
 
-		docBytes, _, err := didWebToBeResolved.resolveDocBytes(context.Background())
+		docBytes, _, err := didWebToBeResolved.resolveDocBytes(context.Background(), nil, resolution.Options{})
 		assert.NoError(tt, err)
 		assert.Contains(tt, string(docBytes), "did:web:demo.ssi-sdk.com")
 	})
 
 	t.Run("Unresolvable Path", func(tt *testing.T) {
-		_, _, err := didWebNotADomain.resolveDocBytes(context.Background())
+		_, _, err := didWebNotADomain.resolveDocBytes(context.Background(), nil, resolution.Options{})
 		assert.Error(tt, err)
 		assert.Contains(tt, err.Error(), "did:web: is missing the required domain")
 	})
@@ -93,6 +95,80 @@ func TestDIDWebResolve(t *testing.T) {
 		assert.Contains(tt, err.Error(), "doc.id<did:web:demo.ssi-sdk.com> does not match did:web value<did:web:doesnotexist.com>")
 	})
 
+	t.Run("Happy Path - Resolve Version", func(tt *testing.T) {
+		gock.New("https://demo.ssi-sdk.com").
+			Get("/.well-known/did.json").
+			MatchParam("versionId", "2").
+			Reply(200).
+			BodyString(`{"didDocument": {"id": "did:web:demo.ssi-sdk.com"}, "didDocumentMetadata": {"versionId": "2"}}`)
+		defer gock.Off()
+
+		result, err := Resolver{}.Resolve(context.Background(), didWebToBeResolved.String(), resolution.WithVersionID("2"))
+		assert.NoError(tt, err)
+		assert.Equal(tt, "2", result.DocumentMetadata.VersionID)
+	})
+
+	t.Run("Unhappy Path - Version Mismatch", func(tt *testing.T) {
+		gock.New("https://demo.ssi-sdk.com").
+			Get("/.well-known/did.json").
+			MatchParam("versionId", "2").
+			Reply(200).
+			BodyString(`{"didDocument": {"id": "did:web:demo.ssi-sdk.com"}, "didDocumentMetadata": {"versionId": "3"}}`)
+		defer gock.Off()
+
+		_, err := Resolver{}.Resolve(context.Background(), didWebToBeResolved.String(), resolution.WithVersionID("2"))
+		assert.Error(tt, err)
+		assert.True(tt, resolution.IsNotFound(err))
+	})
+
+	t.Run("Unhappy Path - Version Not Confirmed", func(tt *testing.T) {
+		gock.New("https://demo.ssi-sdk.com").
+			Get("/.well-known/did.json").
+			MatchParam("versionId", "2").
+			Reply(200).
+			BodyString(`{"didDocument": {"id": "did:web:demo.ssi-sdk.com"}}`)
+		defer gock.Off()
+
+		_, err := Resolver{}.Resolve(context.Background(), didWebToBeResolved.String(), resolution.WithVersionID("2"))
+		assert.Error(tt, err)
+		assert.True(tt, resolution.IsNotFound(err))
+	})
+
+	t.Run("Version Time", func(tt *testing.T) {
+		versionTime := time.Date(2023, 1, 2, 0, 0, 0, 0, time.UTC)
+		gock.New("https://demo.ssi-sdk.com").
+			Get("/.well-known/did.json").
+			MatchParam("versionTime", "2023-01-02T00:00:00Z").
+			Reply(200).
+			BodyString(`{"didDocument": {"id": "did:web:demo.ssi-sdk.com"}, "didDocumentMetadata": {"created": "2023-01-01T00:00:00Z"}}`)
+		gock.New("https://demo.ssi-sdk.com").
+			Get("/.well-known/did.json").
+			MatchParam("versionTime", "2023-01-02T00:00:00Z").
+			Reply(200).
+			BodyString(`{"didDocument": {"id": "did:web:demo.ssi-sdk.com"}, "didDocumentMetadata": {"created": "2023-01-01T00:00:00Z", "updated": "2023-01-03T00:00:00Z"}}`)
+		defer gock.Off()
+
+		result, err := Resolver{}.Resolve(context.Background(), didWebToBeResolved.String(), resolution.WithVersionTime(versionTime))
+		assert.NoError(tt, err)
+		assert.Equal(tt, "2023-01-01T00:00:00Z", result.DocumentMetadata.Created)
+
+		// a document updated after the requested time is not the requested version
+		_, err = Resolver{}.Resolve(context.Background(), didWebToBeResolved.String(), resolution.WithVersionTime(versionTime))
+		assert.Error(tt, err)
+		assert.True(tt, resolution.IsNotFound(err))
+	})
+
+	t.Run("Unhappy Path - Document Not Found", func(tt *testing.T) {
+		gock.New("https://doesnotexist.com").
+			Get("/.well-known/did.json").
+			Reply(404)
+		defer gock.Off()
+
+		_, err := Resolver{}.Resolve(context.Background(), didWebCannotBeResolved.String())
+		assert.Error(tt, err)
+		assert.True(tt, resolution.IsNotFound(err))
+	})
+
 	t.Run("Unhappy Path - Unknown DID", func(t *testing.T) {
 		_, err := didWebCannotBeResolved.Resolve(context.Background())
 		assert.Error(t, err)
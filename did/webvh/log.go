@@ -0,0 +1,526 @@
+package webvh
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/goccy/go-json"
+	"github.com/pkg/errors"
+
+	"github.com/extrimian/ssi-sdk/did"
+	"github.com/extrimian/ssi-sdk/did/web"
+)
+
+// Parameters configure the processing of a DID Log. A log entry only holds the parameters that change with it, and
+// the parameters in effect are the accumulation of those of all entries so far.
+// https://identity.foundation/didwebvh/v1.0/#didwebvh-did-method-parameters
+type Parameters struct {
+	Method string `json:"method,omitempty"`
+	SCID   string `json:"scid,omitempty"`
+	// UpdateKeys are the multikeys authorized to sign the next log entries. A nil slice leaves them unchanged.
+	UpdateKeys []string `json:"updateKeys,omitempty"`
+	// NextKeyHashes enable pre-rotation: the update keys of the next entry must hash to one of them, see NextKeyHash.
+	// A nil slice leaves them unchanged, and an empty one disables pre-rotation.
+	NextKeyHashes []string `json:"nextKeyHashes,omitempty"`
+	Witness       *Witness `json:"witness,omitempty"`
+	// Portable allows the DID to move to another domain, and can only be set in the first entry
+	Portable    bool `json:"portable,omitempty"`
+	Deactivated bool `json:"deactivated,omitempty"`
+	TTL         int  `json:"ttl,omitempty"`
+}
+
+// MarshalJSON keeps empty update keys and next key hashes, which differ from absent ones
+func (p Parameters) MarshalJSON() ([]byte, error) {
+	type parameters Parameters
+	jsonBytes, err := json.Marshal(parameters(p))
+	if err != nil {
+		return nil, err
+	}
+	if (p.UpdateKeys == nil || len(p.UpdateKeys) > 0) && (p.NextKeyHashes == nil || len(p.NextKeyHashes) > 0) {
+		return jsonBytes, nil
+	}
+	var m map[string]any
+	if err = json.Unmarshal(jsonBytes, &m); err != nil {
+		return nil, err
+	}
+	if p.UpdateKeys != nil && len(p.UpdateKeys) == 0 {
+		m["updateKeys"] = []string{}
+	}
+	if p.NextKeyHashes != nil && len(p.NextKeyHashes) == 0 {
+		m["nextKeyHashes"] = []string{}
+	}
+	return json.Marshal(m)
+}
+
+// merge returns the parameters in effect after an entry with the given parameters
+func (p Parameters) merge(changes Parameters) Parameters {
+	if changes.Method != "" {
+		p.Method = changes.Method
+	}
+	if changes.SCID != "" {
+		p.SCID = changes.SCID
+	}
+	if changes.UpdateKeys != nil {
+		p.UpdateKeys = changes.UpdateKeys
+	}
+	if changes.NextKeyHashes != nil {
+		p.NextKeyHashes = changes.NextKeyHashes
+	}
+	if changes.Witness != nil {
+		p.Witness = changes.Witness
+	}
+	if changes.Portable {
+		p.Portable = true
+	}
+	if changes.Deactivated {
+		p.Deactivated = true
+	}
+	if changes.TTL != 0 {
+		p.TTL = changes.TTL
+	}
+	return p
+}
+
+// LogEntry is a line of the DID Log, holding a version of the DID Document
+// https://identity.foundation/didwebvh/v1.0/#the-did-log-file
+type LogEntry struct {
+	// VersionID is the version number and the entry hash, e.g. 1-QmQq6Kg4ZZ1p49znzxnWmes4LkkWgMWLrnrfPre8UD56bz
+	VersionID   string               `json:"versionId"`
+	VersionTime string               `json:"versionTime"`
+	Parameters  Parameters           `json:"parameters"`
+	State       did.Document         `json:"state"`
+	Proof       []DataIntegrityProof `json:"proof,omitempty"`
+
+	// raw is the line the entry was parsed from, which is what its hashes and proofs are checked against
+	raw []byte
+}
+
+// VersionNumber returns the number preceding the entry hash in the version id
+func (e LogEntry) VersionNumber() (int, error) {
+	return versionNumber(e.VersionID)
+}
+
+func versionNumber(versionID string) (int, error) {
+	number, _, ok := strings.Cut(versionID, "-")
+	if !ok {
+		return 0, fmt.Errorf("malformed versionId<%s>", versionID)
+	}
+	n, err := strconv.Atoi(number)
+	if err != nil || n < 1 {
+		return 0, fmt.Errorf("malformed versionId<%s>", versionID)
+	}
+	return n, nil
+}
+
+// document returns the entry as a generic JSON object without its proof
+func (e LogEntry) document() (map[string]any, error) {
+	raw := e.raw
+	if raw == nil {
+		var err error
+		if raw, err = json.Marshal(e); err != nil {
+			return nil, errors.Wrap(err, "marshalling log entry")
+		}
+	}
+	var m map[string]any
+	if err := json.Unmarshal(raw, &m); err != nil {
+		return nil, errors.Wrap(err, "unmarshalling log entry")
+	}
+	delete(m, "proof")
+	return m, nil
+}
+
+// entryHash computes the hash of the entry with its versionId replaced by the previous one
+// https://identity.foundation/didwebvh/v1.0/#entry-hash-generation-and-verification
+func (e LogEntry) entryHash(previousVersionID string) (string, error) {
+	m, err := e.document()
+	if err != nil {
+		return "", err
+	}
+	m["versionId"] = previousVersionID
+	return hashJSON(m)
+}
+
+// Log is a DID Log, with the entries in order
+type Log []LogEntry
+
+// ParseLog parses a DID Log in JSON Lines format
+func ParseLog(data []byte) (Log, error) {
+	var log Log
+	for i, line := range bytes.Split(data, []byte("\n")) {
+		line = bytes.TrimSpace(line)
+		if len(line) == 0 {
+			continue
+		}
+		var entry LogEntry
+		if err := json.Unmarshal(line, &entry); err != nil {
+			return nil, errors.Wrapf(err, "unmarshalling log entry on line %d", i+1)
+		}
+		entry.raw = append([]byte(nil), line...)
+		log = append(log, entry)
+	}
+	if len(log) == 0 {
+		return nil, errors.New("empty did log")
+	}
+	return log, nil
+}
+
+// Bytes returns the DID Log in JSON Lines format, as it is hosted in did.jsonl
+func (l Log) Bytes() ([]byte, error) {
+	var buf bytes.Buffer
+	for _, entry := range l {
+		line := entry.raw
+		if line == nil {
+			var err error
+			if line, err = json.Marshal(entry); err != nil {
+				return nil, errors.Wrapf(err, "marshalling log entry %s", entry.VersionID)
+			}
+		}
+		buf.Write(line)
+		buf.WriteByte('\n')
+	}
+	return buf.Bytes(), nil
+}
+
+// DID returns the DID the log is for
+func (l Log) DID() (DIDWebVH, error) {
+	if len(l) == 0 {
+		return "", errors.New("empty did log")
+	}
+	return DIDWebVH(l[0].State.ID), nil
+}
+
+// Parameters returns the parameters in effect after the last entry
+func (l Log) Parameters() Parameters {
+	var params Parameters
+	for _, entry := range l {
+		params = params.merge(entry.Parameters)
+	}
+	return params
+}
+
+// Create creates a new did:webvh DID and the first entry of its log. The domain is given as for did:web, optionally
+// followed by a path, e.g. example.com, example.com%3A8443 or example.com:dids:alice. The id of the document is set
+// to the new DID, and any occurrence of SCIDPlaceholder in the document is replaced by the SCID. The update keys
+// default to the multikey of the given update key, which signs the entry.
+// https://identity.foundation/didwebvh/v1.0/#create-register
+func Create(domain string, doc did.Document, params Parameters, updateKey ed25519.PrivateKey) (DIDWebVH, Log, error) {
+	if _, err := web.DIDWeb(web.Prefix + ":" + domain).GetDocURL(); err != nil {
+		return "", nil, errors.Wrap(err, "invalid domain")
+	}
+	if params.Deactivated {
+		return "", nil, errors.New("cannot create a deactivated DID")
+	}
+	if params.UpdateKeys == nil {
+		multikey, err := Multikey(updateKey.Public().(ed25519.PublicKey))
+		if err != nil {
+			return "", nil, errors.Wrap(err, "encoding update key")
+		}
+		params.UpdateKeys = []string{multikey}
+	}
+	params.Method = MethodVersion
+	params.SCID = SCIDPlaceholder
+
+	doc.ID = fmt.Sprintf("%s:%s:%s", Prefix, SCIDPlaceholder, domain)
+	if doc.Context == nil {
+		doc.Context = did.KnownDIDContext
+	}
+	preliminary := LogEntry{
+		VersionID:   SCIDPlaceholder,
+		VersionTime: now(),
+		Parameters:  params,
+		State:       doc,
+	}
+	preliminaryBytes, err := json.Marshal(preliminary)
+	if err != nil {
+		return "", nil, errors.Wrap(err, "marshalling preliminary log entry")
+	}
+	scid, err := hashJSONBytes(preliminaryBytes)
+	if err != nil {
+		return "", nil, errors.Wrap(err, "generating scid")
+	}
+	var entry LogEntry
+	if err = json.Unmarshal(bytes.ReplaceAll(preliminaryBytes, []byte(SCIDPlaceholder), []byte(scid)), &entry); err != nil {
+		return "", nil, errors.Wrap(err, "unmarshalling log entry")
+	}
+
+	log, err := Log(nil).appendEntry(entry, scid, updateKey)
+	if err != nil {
+		return "", nil, err
+	}
+	return DIDWebVH(entry.State.ID), log, nil
+}
+
+// Update returns the log with a new entry holding the updated document and the parameters that change. The update key
+// must be authorized: it must be one of the current update keys or, with pre-rotation, one of the new update keys,
+// each of which must match one of the current next key hashes.
+// https://identity.foundation/didwebvh/v1.0/#update-rotate
+func (l Log) Update(doc did.Document, params Parameters, updateKey ed25519.PrivateKey) (Log, error) {
+	if len(l) == 0 {
+		return nil, errors.New("empty did log")
+	}
+	if params.Method != "" || params.SCID != "" || params.Portable {
+		return nil, errors.New("method, scid and portable can only be set when creating a DID")
+	}
+	current := l.Parameters()
+	if current.Deactivated {
+		return nil, errors.New("cannot update a deactivated DID")
+	}
+	id, err := l.DID()
+	if err != nil {
+		return nil, err
+	}
+	if doc.ID == "" {
+		doc.ID = id.String()
+	}
+	if doc.ID != id.String() && !current.Portable {
+		return nil, fmt.Errorf("document id<%s> must be %s", doc.ID, id)
+	}
+	last := l[len(l)-1]
+	entry := LogEntry{
+		VersionID:   last.VersionID,
+		VersionTime: now(),
+		Parameters:  params,
+		State:       doc,
+	}
+	return l.appendEntry(entry, last.VersionID, updateKey)
+}
+
+// Deactivate returns the log with a final entry deactivating the DID, which removes all update keys. When
+// pre-rotation is enabled, it must be disabled by an update first.
+// https://identity.foundation/didwebvh/v1.0/#deactivate-revoke
+func (l Log) Deactivate(updateKey ed25519.PrivateKey) (Log, error) {
+	if len(l) == 0 {
+		return nil, errors.New("empty did log")
+	}
+	if len(l.Parameters().NextKeyHashes) > 0 {
+		return nil, errors.New("pre-rotation must be disabled before deactivating")
+	}
+	return l.Update(l[len(l)-1].State, Parameters{Deactivated: true, UpdateKeys: []string{}}, updateKey)
+}
+
+// appendEntry sets the version id of the entry from its hash, signs it, and appends it to a copy of the log
+func (l Log) appendEntry(entry LogEntry, previousVersionID string, updateKey ed25519.PrivateKey) (Log, error) {
+	multikey, err := Multikey(updateKey.Public().(ed25519.PublicKey))
+	if err != nil {
+		return nil, errors.Wrap(err, "encoding update key")
+	}
+	authorized, err := authorizedKeys(l.Parameters(), entry.Parameters, len(l) == 0)
+	if err != nil {
+		return nil, err
+	}
+	if !contains(authorized, multikey) {
+		return nil, fmt.Errorf("update key<%s> is not authorized", multikey)
+	}
+	entryHash, err := entry.entryHash(previousVersionID)
+	if err != nil {
+		return nil, errors.Wrap(err, "generating entry hash")
+	}
+	entry.VersionID = fmt.Sprintf("%d-%s", len(l)+1, entryHash)
+	versionTime, err := time.Parse(time.RFC3339, entry.VersionTime)
+	if err != nil {
+		return nil, errors.Wrap(err, "parsing versionTime")
+	}
+	document, err := entry.document()
+	if err != nil {
+		return nil, err
+	}
+	proof, err := signDataIntegrity(document, updateKey, versionTime)
+	if err != nil {
+		return nil, errors.Wrap(err, "signing log entry")
+	}
+	entry.Proof = []DataIntegrityProof{*proof}
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return nil, errors.Wrap(err, "marshalling log entry")
+	}
+	entry.raw = line
+	return append(append(Log(nil), l...), entry), nil
+}
+
+// authorizedKeys returns the update keys that may sign an entry with the given parameters. The first entry is signed
+// by its own update keys. Later entries are signed by the update keys in effect before them or, with pre-rotation,
+// by their new update keys, which must have been committed to.
+// https://identity.foundation/didwebvh/v1.0/#authorized-keys
+func authorizedKeys(current, changes Parameters, first bool) ([]string, error) {
+	if first {
+		return changes.UpdateKeys, nil
+	}
+	if len(current.NextKeyHashes) == 0 {
+		return current.UpdateKeys, nil
+	}
+	if changes.UpdateKeys == nil {
+		return nil, errors.New("pre-rotation requires new update keys")
+	}
+	for _, updateKey := range changes.UpdateKeys {
+		hash, err := NextKeyHash(updateKey)
+		if err != nil {
+			return nil, err
+		}
+		if !contains(current.NextKeyHashes, hash) {
+			return nil, fmt.Errorf("update key<%s> does not match any of the next key hashes", updateKey)
+		}
+	}
+	return changes.UpdateKeys, nil
+}
+
+// VerifiedEntry is a log entry that has been verified, along with the parameters in effect after it
+type VerifiedEntry struct {
+	LogEntry
+	Active Parameters
+}
+
+// Verify processes the DID Log, checking the SCID, the chain of entry hashes, the proofs by authorized update keys,
+// and the approval of the witnesses, and returns the verified entries
+// https://identity.foundation/didwebvh/v1.0/#read-resolve
+func (l Log) Verify(witnessProofs []WitnessProof) ([]VerifiedEntry, error) {
+	if len(l) == 0 {
+		return nil, errors.New("empty did log")
+	}
+	approvals := l.witnessApprovals(witnessProofs)
+
+	var (
+		active            Parameters
+		previousVersionID string
+		previousTime      time.Time
+		verified          []VerifiedEntry
+	)
+	id := l[0].State.ID
+	for i, entry := range l {
+		first := i == 0
+		if active.Deactivated {
+			return nil, fmt.Errorf("entry %s follows the deactivation of the DID", entry.VersionID)
+		}
+		if first {
+			if err := verifySCID(entry); err != nil {
+				return nil, err
+			}
+			previousVersionID = entry.Parameters.SCID
+		} else if entry.Parameters.Method != "" || entry.Parameters.SCID != "" || entry.Parameters.Portable {
+			return nil, fmt.Errorf("entry %s changes method, scid or portable", entry.VersionID)
+		}
+
+		number, err := entry.VersionNumber()
+		if err != nil {
+			return nil, err
+		}
+		if number != i+1 {
+			return nil, fmt.Errorf("entry %s should have version number %d", entry.VersionID, i+1)
+		}
+		entryHash, err := entry.entryHash(previousVersionID)
+		if err != nil {
+			return nil, err
+		}
+		if entry.VersionID != fmt.Sprintf("%d-%s", number, entryHash) {
+			return nil, fmt.Errorf("entry %s does not match its entry hash %s", entry.VersionID, entryHash)
+		}
+
+		versionTime, err := time.Parse(time.RFC3339, entry.VersionTime)
+		if err != nil {
+			return nil, errors.Wrapf(err, "parsing versionTime of entry %s", entry.VersionID)
+		}
+		if versionTime.Before(previousTime) || versionTime.After(time.Now()) {
+			return nil, fmt.Errorf("versionTime of entry %s is out of order or in the future", entry.VersionID)
+		}
+
+		if entry.State.ID != id && !active.Portable {
+			return nil, fmt.Errorf("entry %s changes the id of a DID that is not portable", entry.VersionID)
+		}
+
+		authorized, err := authorizedKeys(active, entry.Parameters, first)
+		if err != nil {
+			return nil, errors.Wrapf(err, "entry %s", entry.VersionID)
+		}
+		if err = verifyEntryProofs(entry, authorized); err != nil {
+			return nil, err
+		}
+
+		witness := active.Witness
+		if first {
+			witness = entry.Parameters.Witness
+		}
+		if err = witness.approved(approvals, number); err != nil {
+			return nil, errors.Wrapf(err, "entry %s", entry.VersionID)
+		}
+
+		active = active.merge(entry.Parameters)
+		previousVersionID = entry.VersionID
+		previousTime = versionTime
+		verified = append(verified, VerifiedEntry{LogEntry: entry, Active: active})
+	}
+	return verified, nil
+}
+
+// verifySCID checks the SCID of the first entry, which is the hash of the entry with the SCID replaced by the
+// placeholder
+// https://identity.foundation/didwebvh/v1.0/#scid-generation-and-verification
+func verifySCID(entry LogEntry) error {
+	if entry.Parameters.Method != MethodVersion {
+		return fmt.Errorf("unsupported method version<%s>", entry.Parameters.Method)
+	}
+	scid := entry.Parameters.SCID
+	if scid == "" {
+		return errors.New("first entry has no scid")
+	}
+	if entryDID, err := DIDWebVH(entry.State.ID).SCID(); err != nil || entryDID != scid {
+		return fmt.Errorf("document id<%s> does not contain the scid<%s>", entry.State.ID, scid)
+	}
+	m, err := entry.document()
+	if err != nil {
+		return err
+	}
+	m["versionId"] = SCIDPlaceholder
+	jsonBytes, err := json.Marshal(m)
+	if err != nil {
+		return errors.Wrap(err, "marshalling first entry")
+	}
+	hash, err := hashJSONBytes(bytes.ReplaceAll(jsonBytes, []byte(scid), []byte(SCIDPlaceholder)))
+	if err != nil {
+		return err
+	}
+	if hash != scid {
+		return fmt.Errorf("scid<%s> does not match the first entry", scid)
+	}
+	return nil
+}
+
+// verifyEntryProofs checks that all proofs on the entry are valid and that at least one was made by an authorized key
+func verifyEntryProofs(entry LogEntry, authorized []string) error {
+	if len(entry.Proof) == 0 {
+		return fmt.Errorf("entry %s has no proof", entry.VersionID)
+	}
+	document, err := entry.document()
+	if err != nil {
+		return err
+	}
+	var authorizedProof bool
+	for _, proof := range entry.Proof {
+		multikey, err := verifyDataIntegrity(document, proof)
+		if err != nil {
+			return errors.Wrapf(err, "verifying proof of entry %s", entry.VersionID)
+		}
+		if contains(authorized, multikey) {
+			authorizedProof = true
+		}
+	}
+	if !authorizedProof {
+		return fmt.Errorf("entry %s is not signed by an authorized update key", entry.VersionID)
+	}
+	return nil
+}
+
+func now() string {
+	return time.Now().UTC().Format(time.RFC3339)
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,112 @@
+package webvh
+
+import (
+	"crypto/ed25519"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/multiformats/go-multibase"
+	"github.com/pkg/errors"
+
+	"github.com/extrimian/ssi-sdk/crypto"
+	"github.com/extrimian/ssi-sdk/did"
+	"github.com/extrimian/ssi-sdk/did/key"
+)
+
+const (
+	DataIntegrityProofType = "DataIntegrityProof"
+	// EdDSAJCS2022 is the cryptosuite of the proofs on log entries and witness approvals
+	// https://www.w3.org/TR/vc-di-eddsa/#eddsa-jcs-2022
+	EdDSAJCS2022           = "eddsa-jcs-2022"
+	AssertionMethodPurpose = "assertionMethod"
+)
+
+// DataIntegrityProof is a Data Integrity proof using the eddsa-jcs-2022 cryptosuite. The verification method is the
+// did:key DID of an Ed25519 key, with the multikey as its fragment.
+type DataIntegrityProof struct {
+	Type               string `json:"type"`
+	Cryptosuite        string `json:"cryptosuite"`
+	VerificationMethod string `json:"verificationMethod"`
+	Created            string `json:"created,omitempty"`
+	ProofPurpose       string `json:"proofPurpose"`
+	ProofValue         string `json:"proofValue,omitempty"`
+}
+
+// Multikey returns the multikey encoding of an Ed25519 public key, the form update keys and witnesses take
+func Multikey(publicKey ed25519.PublicKey) (string, error) {
+	return key.MultibaseEncodedKey(crypto.Ed25519, publicKey)
+}
+
+// signDataIntegrity creates an eddsa-jcs-2022 proof over a JSON document without a proof
+// https://www.w3.org/TR/vc-di-eddsa/#create-proof-eddsa-jcs-2022
+func signDataIntegrity(document any, privateKey ed25519.PrivateKey, created time.Time) (*DataIntegrityProof, error) {
+	multikey, err := Multikey(privateKey.Public().(ed25519.PublicKey))
+	if err != nil {
+		return nil, errors.Wrap(err, "encoding public key")
+	}
+	proof := DataIntegrityProof{
+		Type:               DataIntegrityProofType,
+		Cryptosuite:        EdDSAJCS2022,
+		VerificationMethod: key.Prefix + ":" + multikey + "#" + multikey,
+		Created:            created.UTC().Format(time.RFC3339),
+		ProofPurpose:       AssertionMethodPurpose,
+	}
+	hashData, err := dataIntegrityHashData(document, proof)
+	if err != nil {
+		return nil, err
+	}
+	proofValue, err := multibase.Encode(did.Base58BTCMultiBase, ed25519.Sign(privateKey, hashData))
+	if err != nil {
+		return nil, errors.Wrap(err, "encoding proof value")
+	}
+	proof.ProofValue = proofValue
+	return &proof, nil
+}
+
+// verifyDataIntegrity verifies an eddsa-jcs-2022 proof over a JSON document without a proof, and returns the
+// multikey of the key that made it
+// https://www.w3.org/TR/vc-di-eddsa/#verify-proof-eddsa-jcs-2022
+func verifyDataIntegrity(document any, proof DataIntegrityProof) (string, error) {
+	if proof.Type != DataIntegrityProofType || proof.Cryptosuite != EdDSAJCS2022 {
+		return "", fmt.Errorf("unsupported proof type<%s> and cryptosuite<%s>", proof.Type, proof.Cryptosuite)
+	}
+	didKey, multikey, ok := strings.Cut(proof.VerificationMethod, "#")
+	if !ok || didKey != key.Prefix+":"+multikey {
+		return "", fmt.Errorf("verification method<%s> is not a did:key key", proof.VerificationMethod)
+	}
+	publicKey, kt, err := key.DIDKey(didKey).Decode()
+	if err != nil {
+		return "", errors.Wrap(err, "decoding verification method")
+	}
+	if kt != crypto.Ed25519 {
+		return "", fmt.Errorf("verification method<%s> is not an Ed25519 key", proof.VerificationMethod)
+	}
+	_, signature, err := multibase.Decode(proof.ProofValue)
+	if err != nil {
+		return "", errors.Wrap(err, "decoding proof value")
+	}
+	proofValue := proof.ProofValue
+	proof.ProofValue = ""
+	hashData, err := dataIntegrityHashData(document, proof)
+	if err != nil {
+		return "", err
+	}
+	if !ed25519.Verify(publicKey, hashData, signature) {
+		return "", fmt.Errorf("invalid proof value<%s>", proofValue)
+	}
+	return multikey, nil
+}
+
+// dataIntegrityHashData hashes the proof configuration and the document, which are concatenated to be signed
+func dataIntegrityHashData(document any, proofConfig DataIntegrityProof) ([]byte, error) {
+	proofHash, err := jcsSHA256(proofConfig)
+	if err != nil {
+		return nil, errors.Wrap(err, "hashing proof configuration")
+	}
+	docHash, err := jcsSHA256(document)
+	if err != nil {
+		return nil, errors.Wrap(err, "hashing document")
+	}
+	return append(proofHash, docHash...), nil
+}
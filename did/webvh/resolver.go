@@ -0,0 +1,182 @@
+package webvh
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/extrimian/ssi-sdk/did"
+	"github.com/extrimian/ssi-sdk/did/resolution"
+	"github.com/extrimian/ssi-sdk/did/web"
+)
+
+// MaxLogSize is the largest DID Log or witness proofs file read during resolution
+const MaxLogSize = 16 * web.MaxDocumentSize
+
+// Resolver resolves did:webvh DIDs by fetching and verifying their DID Log. The zero value uses
+// web.DefaultHTTPClient.
+type Resolver struct {
+	client *http.Client
+}
+
+var _ resolution.Resolver = (*Resolver)(nil)
+
+// NewResolver creates a Resolver fetching DID Logs with the given client, or web.DefaultHTTPClient if nil
+func NewResolver(client *http.Client) (*Resolver, error) {
+	return &Resolver{client: client}, nil
+}
+
+func (Resolver) Methods() []did.Method {
+	return []did.Method{did.WebVHMethod}
+}
+
+// Resolve fetches and verifies the DID Log, and returns the latest version of the DID Document, or the version
+// requested with the versionId or versionTime options
+// https://identity.foundation/didwebvh/v1.0/#read-resolve
+func (r Resolver) Resolve(ctx context.Context, id string, opts ...resolution.Option) (*resolution.Result, error) {
+	start := time.Now()
+	if !strings.HasPrefix(id, Prefix+":") {
+		return nil, resolution.NewResolutionErrorf(resolution.MethodNotSupportedErrorCode, "not a did:webvh DID: %s", id)
+	}
+	options, err := resolution.ParseOptions(opts...)
+	if err != nil {
+		return nil, err
+	}
+	didWebVH := DIDWebVH(id)
+	logURL, err := didWebVH.GetLogURL()
+	if err != nil {
+		return nil, resolution.NewResolutionError(resolution.InvalidDIDErrorCode, err)
+	}
+	logBytes, err := r.fetch(ctx, logURL, options.NoCache)
+	if err != nil {
+		return nil, errors.Wrapf(err, "fetching did log of %s", id)
+	}
+	log, err := ParseLog(logBytes)
+	if err != nil {
+		return nil, errors.Wrapf(err, "parsing did log of %s", id)
+	}
+	logDID, err := log.DID()
+	if err != nil {
+		return nil, err
+	}
+	if logDID != didWebVH {
+		return nil, fmt.Errorf("did log at %s is for %s", logURL, logDID)
+	}
+
+	var witnessProofs []WitnessProof
+	if log.requiresWitnesses() {
+		witnessURL, err := didWebVH.GetWitnessURL()
+		if err != nil {
+			return nil, err
+		}
+		witnessBytes, err := r.fetch(ctx, witnessURL, options.NoCache)
+		if err != nil && !resolution.IsNotFound(err) {
+			return nil, errors.Wrapf(err, "fetching witness proofs of %s", id)
+		}
+		if err == nil {
+			if witnessProofs, err = ParseWitnessProofs(witnessBytes); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	verified, err := log.Verify(witnessProofs)
+	if err != nil {
+		return nil, errors.Wrapf(err, "verifying did log of %s", id)
+	}
+	i, err := selectVersion(verified, *options)
+	if err != nil {
+		return nil, err
+	}
+	entry := verified[i]
+	metadata := resolution.DocumentMetadata{
+		Created:     verified[0].VersionTime,
+		Updated:     entry.VersionTime,
+		VersionID:   entry.VersionID,
+		Deactivated: entry.Active.Deactivated,
+	}
+	if i+1 < len(verified) {
+		metadata.NextVersionID = verified[i+1].VersionID
+	}
+	result := resolution.NewResult(entry.State, &metadata, start)
+	if err = resolution.ValidateResult(result); err != nil {
+		return nil, err
+	}
+	return options.Apply(result), nil
+}
+
+// selectVersion returns the index of the entry with the requested versionId, of the last entry at the requested
+// versionTime, or of the last entry
+func selectVersion(verified []VerifiedEntry, options resolution.Options) (int, error) {
+	switch {
+	case options.VersionID != "":
+		for i, entry := range verified {
+			if entry.VersionID == options.VersionID {
+				return i, nil
+			}
+		}
+		return 0, resolution.NewResolutionErrorf(resolution.NotFoundErrorCode, "version<%s> not found", options.VersionID)
+	case options.VersionTime != nil:
+		selected := -1
+		for i, entry := range verified {
+			versionTime, err := time.Parse(time.RFC3339, entry.VersionTime)
+			if err != nil || versionTime.After(*options.VersionTime) {
+				break
+			}
+			selected = i
+		}
+		if selected < 0 {
+			return 0, resolution.NewResolutionErrorf(resolution.NotFoundErrorCode, "no version at %s", options.FormatVersionTime())
+		}
+		return selected, nil
+	default:
+		return len(verified) - 1, nil
+	}
+}
+
+// requiresWitnesses returns true if any entry of the log configures witnesses
+func (l Log) requiresWitnesses() bool {
+	for _, entry := range l {
+		if entry.Parameters.Witness != nil && entry.Parameters.Witness.Threshold > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+func (r Resolver) fetch(ctx context.Context, fileURL string, noCache bool) ([]byte, error) {
+	client := web.WithRedirectPolicy(r.client)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, fileURL, nil)
+	if err != nil {
+		return nil, errors.Wrapf(err, "constructing request %s", fileURL)
+	}
+	if noCache {
+		req.Header.Set("Cache-Control", "no-cache")
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, errors.Wrapf(err, "getting %s", fileURL)
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+	switch {
+	case resp.StatusCode == http.StatusNotFound || resp.StatusCode == http.StatusGone:
+		return nil, resolution.NewResolutionErrorf(resolution.NotFoundErrorCode, "nothing found at %s", fileURL)
+	case resp.StatusCode < 200 || resp.StatusCode >= 300:
+		return nil, fmt.Errorf("getting %s: unexpected status code %d", fileURL, resp.StatusCode)
+	}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, MaxLogSize+1))
+	if err != nil {
+		return nil, errors.Wrapf(err, "reading %s", fileURL)
+	}
+	if len(body) > MaxLogSize {
+		return nil, fmt.Errorf("getting %s: response exceeds the limit of %d bytes", fileURL, MaxLogSize)
+	}
+	return body, nil
+}
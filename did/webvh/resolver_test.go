@@ -0,0 +1,148 @@
+package webvh
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/goccy/go-json"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/extrimian/ssi-sdk/crypto"
+	"github.com/extrimian/ssi-sdk/did/resolution"
+)
+
+func TestResolver(t *testing.T) {
+	var logBytes, witnessBytes []byte
+	mux := http.NewServeMux()
+	mux.HandleFunc("/dids/alice/"+LogFilename, func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "text/jsonl")
+		_, _ = w.Write(logBytes)
+	})
+	mux.HandleFunc("/dids/alice/"+WitnessFilename, func(w http.ResponseWriter, r *http.Request) {
+		if witnessBytes == nil {
+			http.NotFound(w, r)
+			return
+		}
+		_, _ = w.Write(witnessBytes)
+	})
+	server := httptest.NewTLSServer(mux)
+	defer server.Close()
+	domain := url.QueryEscape(strings.TrimPrefix(server.URL, "https://")) + ":dids:alice"
+
+	updateKey, _ := generateKey(t)
+	didWebVH, log, err := Create(domain, testDocument(t, domain), Parameters{}, updateKey)
+	require.NoError(t, err)
+	first := log[0]
+	doc := first.State
+	doc.AlsoKnownAs = "https://example.com/alice"
+	log, err = log.Update(doc, Parameters{}, updateKey)
+	require.NoError(t, err)
+	logBytes, err = log.Bytes()
+	require.NoError(t, err)
+
+	resolver, err := NewResolver(server.Client())
+	require.NoError(t, err)
+	r, err := resolution.NewResolver(resolver)
+	require.NoError(t, err)
+
+	t.Run("Latest Version", func(tt *testing.T) {
+		result, err := r.Resolve(context.Background(), didWebVH.String())
+		require.NoError(tt, err)
+		assert.Equal(tt, didWebVH.String(), result.ID)
+		assert.Equal(tt, "https://example.com/alice", result.AlsoKnownAs)
+		assert.Equal(tt, log[1].VersionID, result.DocumentMetadata.VersionID)
+		assert.Equal(tt, first.VersionTime, result.DocumentMetadata.Created)
+		assert.Empty(tt, result.DocumentMetadata.NextVersionID)
+	})
+
+	t.Run("By Version ID", func(tt *testing.T) {
+		result, err := r.Resolve(context.Background(), didWebVH.String(), resolution.WithVersionID(first.VersionID))
+		require.NoError(tt, err)
+		assert.Empty(tt, result.AlsoKnownAs)
+		assert.Equal(tt, log[1].VersionID, result.DocumentMetadata.NextVersionID)
+
+		_, err = r.Resolve(context.Background(), didWebVH.String(), resolution.WithVersionID("3-QmUnknown"))
+		assert.True(tt, resolution.IsNotFound(err))
+	})
+
+	t.Run("By Version Time", func(tt *testing.T) {
+		result, err := r.Resolve(context.Background(), didWebVH.String(), resolution.WithVersionTime(time.Now()))
+		require.NoError(tt, err)
+		assert.Equal(tt, log[1].VersionID, result.DocumentMetadata.VersionID)
+
+		_, err = r.Resolve(context.Background(), didWebVH.String(), resolution.WithVersionTime(time.Now().Add(-time.Hour)))
+		assert.True(tt, resolution.IsNotFound(err))
+	})
+
+	t.Run("Another DID At The Same Location", func(tt *testing.T) {
+		scid, err := didWebVH.SCID()
+		require.NoError(tt, err)
+		other := strings.Replace(didWebVH.String(), scid, "QmOther", 1)
+		_, err = r.Resolve(context.Background(), other)
+		assert.ErrorContains(tt, err, "is for "+didWebVH.String())
+	})
+
+	t.Run("Tampered Log", func(tt *testing.T) {
+		original := logBytes
+		defer func() { logBytes = original }()
+		logBytes = []byte(strings.Replace(string(logBytes), "https://example.com/alice", "https://example.com/mallory", 1))
+		_, err := r.Resolve(context.Background(), didWebVH.String())
+		assert.ErrorContains(tt, err, "verifying did log")
+	})
+
+	t.Run("Witnessed Log", func(tt *testing.T) {
+		originalLog, originalWitness := logBytes, witnessBytes
+		defer func() { logBytes, witnessBytes = originalLog, originalWitness }()
+
+		pk, witnessKey, err := crypto.GenerateEd25519Key()
+		require.NoError(tt, err)
+		member, err := NewWitnessMember(pk)
+		require.NoError(tt, err)
+		witnessed, witnessedLog, err := Create(domain, testDocument(tt, domain),
+			Parameters{Witness: &Witness{Threshold: 1, Witnesses: []WitnessMember{*member}}}, updateKey)
+		require.NoError(tt, err)
+		logBytes, err = witnessedLog.Bytes()
+		require.NoError(tt, err)
+
+		_, err = r.Resolve(context.Background(), witnessed.String())
+		assert.ErrorContains(tt, err, "required witnesses")
+
+		proof, err := SignWitnessProof(witnessedLog[0].VersionID, witnessKey)
+		require.NoError(tt, err)
+		witnessBytes, err = json.Marshal([]WitnessProof{*proof})
+		require.NoError(tt, err)
+		result, err := r.Resolve(context.Background(), witnessed.String())
+		require.NoError(tt, err)
+		assert.Equal(tt, witnessed.String(), result.ID)
+	})
+
+	t.Run("Deactivated", func(tt *testing.T) {
+		original := logBytes
+		defer func() { logBytes = original }()
+		deactivated, err := log.Deactivate(updateKey)
+		require.NoError(tt, err)
+		logBytes, err = deactivated.Bytes()
+		require.NoError(tt, err)
+
+		result, err := r.Resolve(context.Background(), didWebVH.String())
+		require.NoError(tt, err)
+		assert.True(tt, result.DocumentMetadata.Deactivated)
+	})
+
+	t.Run("Not Found", func(tt *testing.T) {
+		notFound := strings.Replace(didWebVH.String(), ":alice", ":bob", 1)
+		_, err := r.Resolve(context.Background(), notFound)
+		assert.True(tt, resolution.IsNotFound(err))
+	})
+
+	t.Run("Invalid DID", func(tt *testing.T) {
+		_, err := resolver.Resolve(context.Background(), "did:webvh:QmScid")
+		assert.True(tt, resolution.IsInvalidDID(err))
+	})
+}
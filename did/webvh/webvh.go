@@ -0,0 +1,161 @@
+// Package webvh implements the did:webvh (did:web + Verifiable History) method
+// https://identity.foundation/didwebvh/v1.0/
+// A did:webvh DID is hosted like a did:web DID, but instead of a single DID Document its domain hosts a log of all
+// versions of the document, did.jsonl. Each log entry is chained to the previous one by its entry hash and signed by
+// an authorized update key, and the DID itself embeds a self-certifying identifier (SCID) derived from the first
+// entry. Update keys may be pre-rotated by committing to the hashes of the next keys, and updates may require the
+// approval of witnesses.
+package webvh
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"strings"
+
+	"github.com/goccy/go-json"
+	"github.com/gowebpki/jcs"
+	"github.com/mr-tron/base58"
+	"github.com/multiformats/go-multihash"
+	"github.com/pkg/errors"
+
+	"github.com/extrimian/ssi-sdk/did"
+	"github.com/extrimian/ssi-sdk/did/web"
+	"github.com/extrimian/ssi-sdk/util"
+)
+
+type (
+	DIDWebVH string
+)
+
+const (
+	Prefix = "did:webvh"
+	// MethodVersion is the version of the did:webvh specification implemented, given in the `method` parameter
+	MethodVersion = "did:webvh:1.0"
+	// SCIDPlaceholder stands in for the SCID while it is being generated
+	SCIDPlaceholder = "{SCID}"
+
+	LogFilename     = "did.jsonl"
+	WitnessFilename = "did-witness.json"
+)
+
+// IsValid checks that the DID has a SCID and a domain that can be turned into a URL
+func (d DIDWebVH) IsValid() bool {
+	_, err := d.GetLogURL()
+	return err == nil
+}
+
+func (d DIDWebVH) String() string {
+	return string(d)
+}
+
+func (d DIDWebVH) Suffix() (string, error) {
+	suffix, ok := strings.CutPrefix(d.String(), Prefix+":")
+	if !ok || suffix == "" {
+		return "", errors.Wrap(util.InvalidFormatError, "did is malformed")
+	}
+	return suffix, nil
+}
+
+func (DIDWebVH) Method() did.Method {
+	return did.WebVHMethod
+}
+
+// SCID returns the self-certifying identifier of the DID
+func (d DIDWebVH) SCID() (string, error) {
+	suffix, err := d.Suffix()
+	if err != nil {
+		return "", err
+	}
+	scid, _, ok := strings.Cut(suffix, ":")
+	if !ok || scid == "" {
+		return "", fmt.Errorf("did:webvh DID %s is missing the scid or the domain", d)
+	}
+	return scid, nil
+}
+
+// DIDWeb returns the did:web DID hosted at the same location, which is the DID without its SCID
+// https://identity.foundation/didwebvh/v1.0/#publishing-a-parallel-didweb-did
+func (d DIDWebVH) DIDWeb() (web.DIDWeb, error) {
+	suffix, err := d.Suffix()
+	if err != nil {
+		return "", err
+	}
+	_, domain, ok := strings.Cut(suffix, ":")
+	if !ok || domain == "" {
+		return "", fmt.Errorf("did:webvh DID %s is missing the scid or the domain", d)
+	}
+	return web.DIDWeb(web.Prefix + ":" + domain), nil
+}
+
+// GetLogURL returns the URL of the DID Log, which is the URL the DID Document of the corresponding did:web DID would
+// have with did.json replaced by did.jsonl
+// https://identity.foundation/didwebvh/v1.0/#the-did-to-https-transformation
+func (d DIDWebVH) GetLogURL() (string, error) {
+	return d.fileURL(LogFilename)
+}
+
+// GetWitnessURL returns the URL of the witness proofs, which are hosted next to the DID Log
+func (d DIDWebVH) GetWitnessURL() (string, error) {
+	return d.fileURL(WitnessFilename)
+}
+
+func (d DIDWebVH) fileURL(filename string) (string, error) {
+	if _, err := d.SCID(); err != nil {
+		return "", err
+	}
+	didWeb, err := d.DIDWeb()
+	if err != nil {
+		return "", err
+	}
+	docURL, err := didWeb.GetDocURL()
+	if err != nil {
+		return "", errors.Wrapf(err, "getting url of %s", d)
+	}
+	return strings.TrimSuffix(docURL, web.DIDDocFilename) + filename, nil
+}
+
+// hashJSON returns the base58btc encoded sha2-256 multihash of the JCS canonicalized JSON value, as used for SCIDs,
+// entry hashes and next key hashes
+func hashJSON(v any) (string, error) {
+	jsonBytes, err := json.Marshal(v)
+	if err != nil {
+		return "", errors.Wrap(err, "marshalling value to hash")
+	}
+	return hashJSONBytes(jsonBytes)
+}
+
+func hashJSONBytes(jsonBytes []byte) (string, error) {
+	canonical, err := jcs.Transform(jsonBytes)
+	if err != nil {
+		return "", errors.Wrap(err, "canonicalizing value to hash")
+	}
+	return hashBytes(canonical)
+}
+
+func hashBytes(data []byte) (string, error) {
+	mh, err := multihash.Sum(data, multihash.SHA2_256, -1)
+	if err != nil {
+		return "", errors.Wrap(err, "hashing")
+	}
+	return base58.Encode(mh), nil
+}
+
+// jcsSHA256 returns the sha256 hash of the JCS canonicalized JSON value
+func jcsSHA256(v any) ([]byte, error) {
+	jsonBytes, err := json.Marshal(v)
+	if err != nil {
+		return nil, errors.Wrap(err, "marshalling value to hash")
+	}
+	canonical, err := jcs.Transform(jsonBytes)
+	if err != nil {
+		return nil, errors.Wrap(err, "canonicalizing value to hash")
+	}
+	hash := sha256.Sum256(canonical)
+	return hash[:], nil
+}
+
+// NextKeyHash returns the hash committing to a future update key for pre-rotation, given as a multikey
+// https://identity.foundation/didwebvh/v1.0/#pre-rotation-key-hash-generation-and-verification
+func NextKeyHash(multikey string) (string, error) {
+	return hashBytes([]byte(multikey))
+}
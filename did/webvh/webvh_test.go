@@ -0,0 +1,267 @@
+package webvh
+
+import (
+	"crypto/ed25519"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/goccy/go-json"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/extrimian/ssi-sdk/crypto"
+	"github.com/extrimian/ssi-sdk/did"
+)
+
+func generateKey(t *testing.T) (ed25519.PrivateKey, string) {
+	pk, sk, err := crypto.GenerateEd25519Key()
+	require.NoError(t, err)
+	multikey, err := Multikey(pk)
+	require.NoError(t, err)
+	return sk, multikey
+}
+
+// testDocument returns a document to create a DID on the domain with, whose controller refers to the DID by the SCID
+// placeholder
+func testDocument(t *testing.T, domain string) did.Document {
+	pk, _, err := crypto.GenerateEd25519Key()
+	require.NoError(t, err)
+	vm, err := did.ConstructJWKVerificationMethod("#key-1", fmt.Sprintf("%s:%s:%s", Prefix, SCIDPlaceholder, domain), pk, crypto.Ed25519)
+	require.NoError(t, err)
+	return did.Document{
+		VerificationMethod: []did.VerificationMethod{*vm},
+		AssertionMethod:    []did.VerificationMethodSet{"#key-1"},
+	}
+}
+
+func TestDIDWebVH(t *testing.T) {
+	t.Run("URLs", func(tt *testing.T) {
+		d := DIDWebVH("did:webvh:QmScid:example.com")
+		assert.True(tt, d.IsValid())
+		scid, err := d.SCID()
+		require.NoError(tt, err)
+		assert.Equal(tt, "QmScid", scid)
+		logURL, err := d.GetLogURL()
+		require.NoError(tt, err)
+		assert.Equal(tt, "https://example.com/.well-known/did.jsonl", logURL)
+		witnessURL, err := d.GetWitnessURL()
+		require.NoError(tt, err)
+		assert.Equal(tt, "https://example.com/.well-known/did-witness.json", witnessURL)
+
+		withPath := DIDWebVH("did:webvh:QmScid:example.com%3A8443:dids:alice")
+		logURL, err = withPath.GetLogURL()
+		require.NoError(tt, err)
+		assert.Equal(tt, "https://example.com:8443/dids/alice/did.jsonl", logURL)
+		didWeb, err := withPath.DIDWeb()
+		require.NoError(tt, err)
+		assert.Equal(tt, "did:web:example.com%3A8443:dids:alice", didWeb.String())
+	})
+
+	t.Run("Invalid", func(tt *testing.T) {
+		assert.False(tt, DIDWebVH("did:webvh:QmScid").IsValid())
+		assert.False(tt, DIDWebVH("did:web:example.com").IsValid())
+	})
+}
+
+func TestLog(t *testing.T) {
+	key1, multikey1 := generateKey(t)
+
+	didWebVH, log, err := Create("example.com", testDocument(t, "example.com"), Parameters{}, key1)
+	require.NoError(t, err)
+	assert.True(t, didWebVH.IsValid())
+	scid, err := didWebVH.SCID()
+	require.NoError(t, err)
+	assert.Equal(t, scid, log[0].Parameters.SCID)
+	assert.Equal(t, []string{multikey1}, log[0].Parameters.UpdateKeys)
+	assert.True(t, strings.HasPrefix(log[0].VersionID, "1-"))
+	assert.NotContains(t, log[0].State.VerificationMethod[0].ID, SCIDPlaceholder)
+
+	t.Run("Create Verifies", func(tt *testing.T) {
+		verified, err := log.Verify(nil)
+		require.NoError(tt, err)
+		assert.Len(tt, verified, 1)
+	})
+
+	t.Run("Round Trip", func(tt *testing.T) {
+		logBytes, err := log.Bytes()
+		require.NoError(tt, err)
+		parsed, err := ParseLog(logBytes)
+		require.NoError(tt, err)
+		_, err = parsed.Verify(nil)
+		assert.NoError(tt, err)
+	})
+
+	t.Run("Update", func(tt *testing.T) {
+		doc := log[0].State
+		doc.Services = []did.Service{{ID: "#files", Type: "LinkedDomains", ServiceEndpoint: "https://example.com"}}
+		updated, err := log.Update(doc, Parameters{}, key1)
+		require.NoError(tt, err)
+		verified, err := updated.Verify(nil)
+		require.NoError(tt, err)
+		require.Len(tt, verified, 2)
+		assert.True(tt, strings.HasPrefix(verified[1].VersionID, "2-"))
+		assert.Len(tt, verified[1].State.Services, 1)
+	})
+
+	t.Run("Unauthorized Update", func(tt *testing.T) {
+		otherKey, _ := generateKey(tt)
+		_, err := log.Update(log[0].State, Parameters{}, otherKey)
+		assert.ErrorContains(tt, err, "not authorized")
+	})
+
+	t.Run("Tampered Entries", func(tt *testing.T) {
+		updated, err := log.Update(log[0].State, Parameters{}, key1)
+		require.NoError(tt, err)
+		logBytes, err := updated.Bytes()
+		require.NoError(tt, err)
+
+		tampered, err := ParseLog([]byte(strings.Replace(string(logBytes), "#key-1", "#key-2", 1)))
+		require.NoError(tt, err)
+		_, err = tampered.Verify(nil)
+		assert.Error(tt, err)
+
+		// dropping the first entry breaks the chain
+		_, err = updated[1:].Verify(nil)
+		assert.Error(tt, err)
+	})
+
+	t.Run("Key Rotation", func(tt *testing.T) {
+		key2, multikey2 := generateKey(tt)
+		rotated, err := log.Update(log[0].State, Parameters{UpdateKeys: []string{multikey2}}, key1)
+		require.NoError(tt, err)
+		_, err = rotated.Update(log[0].State, Parameters{}, key1)
+		assert.ErrorContains(tt, err, "not authorized")
+		rotated, err = rotated.Update(log[0].State, Parameters{}, key2)
+		require.NoError(tt, err)
+		_, err = rotated.Verify(nil)
+		assert.NoError(tt, err)
+	})
+
+	t.Run("Deactivate", func(tt *testing.T) {
+		deactivated, err := log.Deactivate(key1)
+		require.NoError(tt, err)
+		verified, err := deactivated.Verify(nil)
+		require.NoError(tt, err)
+		assert.True(tt, verified[1].Active.Deactivated)
+		assert.Empty(tt, verified[1].Active.UpdateKeys)
+
+		_, err = deactivated.Update(log[0].State, Parameters{}, key1)
+		assert.ErrorContains(tt, err, "deactivated")
+	})
+
+	t.Run("Document ID Cannot Change", func(tt *testing.T) {
+		doc := log[0].State
+		doc.ID = "did:webvh:" + scid + ":example.org"
+		_, err := log.Update(doc, Parameters{}, key1)
+		assert.Error(tt, err)
+	})
+}
+
+func TestPreRotation(t *testing.T) {
+	key1, _ := generateKey(t)
+	key2, multikey2 := generateKey(t)
+	key3, multikey3 := generateKey(t)
+	hash2, err := NextKeyHash(multikey2)
+	require.NoError(t, err)
+	hash3, err := NextKeyHash(multikey3)
+	require.NoError(t, err)
+
+	_, log, err := Create("example.com", testDocument(t, "example.com"), Parameters{NextKeyHashes: []string{hash2}}, key1)
+	require.NoError(t, err)
+	doc := log[0].State
+
+	t.Run("Current Key Cannot Sign", func(tt *testing.T) {
+		_, err := log.Update(doc, Parameters{}, key1)
+		assert.ErrorContains(tt, err, "pre-rotation requires new update keys")
+	})
+
+	t.Run("Uncommitted Key", func(tt *testing.T) {
+		_, err := log.Update(doc, Parameters{UpdateKeys: []string{multikey3}}, key3)
+		assert.ErrorContains(tt, err, "does not match any of the next key hashes")
+	})
+
+	t.Run("Committed Key", func(tt *testing.T) {
+		rotated, err := log.Update(doc, Parameters{UpdateKeys: []string{multikey2}, NextKeyHashes: []string{hash3}}, key2)
+		require.NoError(tt, err)
+		_, err = rotated.Verify(nil)
+		require.NoError(tt, err)
+
+		_, err = rotated.Deactivate(key3)
+		assert.ErrorContains(tt, err, "pre-rotation must be disabled")
+
+		// disabling pre-rotation lets the current keys sign again
+		disabled, err := rotated.Update(doc, Parameters{UpdateKeys: []string{multikey3}, NextKeyHashes: []string{}}, key3)
+		require.NoError(tt, err)
+		assert.Contains(tt, string(disabled[2].raw), `"nextKeyHashes":[]`)
+		deactivated, err := disabled.Deactivate(key3)
+		require.NoError(tt, err)
+		_, err = deactivated.Verify(nil)
+		assert.NoError(tt, err)
+	})
+}
+
+func TestWitnesses(t *testing.T) {
+	key1, _ := generateKey(t)
+	witnessKeys := make([]ed25519.PrivateKey, 3)
+	witness := Witness{Threshold: 2}
+	for i := range witnessKeys {
+		var pk ed25519.PublicKey
+		var err error
+		pk, witnessKeys[i], err = crypto.GenerateEd25519Key()
+		require.NoError(t, err)
+		member, err := NewWitnessMember(pk)
+		require.NoError(t, err)
+		witness.Witnesses = append(witness.Witnesses, *member)
+	}
+
+	_, log, err := Create("example.com", testDocument(t, "example.com"), Parameters{Witness: &witness}, key1)
+	require.NoError(t, err)
+	log, err = log.Update(log[0].State, Parameters{}, key1)
+	require.NoError(t, err)
+
+	approve := func(tt *testing.T, versionID string, keys ...ed25519.PrivateKey) []WitnessProof {
+		var proofs []WitnessProof
+		for _, k := range keys {
+			proof, err := SignWitnessProof(versionID, k)
+			require.NoError(tt, err)
+			proofs = append(proofs, *proof)
+		}
+		return proofs
+	}
+
+	t.Run("No Approvals", func(tt *testing.T) {
+		_, err := log.Verify(nil)
+		assert.ErrorContains(tt, err, "approved by 0 of the 2 required witnesses")
+	})
+
+	t.Run("Below Threshold", func(tt *testing.T) {
+		_, err := log.Verify(approve(tt, log[1].VersionID, witnessKeys[0]))
+		assert.ErrorContains(tt, err, "approved by 1 of the 2 required witnesses")
+	})
+
+	t.Run("Approving The Latest Version Approves Earlier Ones", func(tt *testing.T) {
+		_, err := log.Verify(approve(tt, log[1].VersionID, witnessKeys[0], witnessKeys[2]))
+		assert.NoError(tt, err)
+	})
+
+	t.Run("Approvals Of The First Version Only", func(tt *testing.T) {
+		_, err := log.Verify(approve(tt, log[0].VersionID, witnessKeys[0], witnessKeys[1]))
+		assert.ErrorContains(tt, err, log[1].VersionID)
+	})
+
+	t.Run("Approvals By Non Witnesses", func(tt *testing.T) {
+		other, _ := generateKey(tt)
+		_, err := log.Verify(approve(tt, log[1].VersionID, witnessKeys[0], other))
+		assert.Error(tt, err)
+	})
+
+	t.Run("Witness Proofs File", func(tt *testing.T) {
+		proofsBytes, err := json.Marshal(approve(tt, log[1].VersionID, witnessKeys[1], witnessKeys[2]))
+		require.NoError(tt, err)
+		proofs, err := ParseWitnessProofs(proofsBytes)
+		require.NoError(tt, err)
+		_, err = log.Verify(proofs)
+		assert.NoError(tt, err)
+	})
+}
@@ -0,0 +1,108 @@
+package webvh
+
+import (
+	"crypto/ed25519"
+	"fmt"
+	"time"
+
+	"github.com/goccy/go-json"
+	"github.com/pkg/errors"
+
+	"github.com/extrimian/ssi-sdk/did/key"
+)
+
+// Witness configures the witnesses that must approve log entries before they are published. Witnesses are
+// identified by did:key DIDs of Ed25519 keys.
+// https://identity.foundation/didwebvh/v1.0/#did-witnesses
+type Witness struct {
+	// Threshold is the number of witnesses that must approve each entry. Zero disables witnessing.
+	Threshold int             `json:"threshold"`
+	Witnesses []WitnessMember `json:"witnesses"`
+}
+
+// WitnessMember is a witness of a DID
+type WitnessMember struct {
+	ID string `json:"id"`
+}
+
+// WitnessProof holds witness approvals of a version of the DID, as found in did-witness.json. Approving a version
+// also approves all earlier versions.
+// https://identity.foundation/didwebvh/v1.0/#the-witness-proofs-file
+type WitnessProof struct {
+	VersionID string               `json:"versionId"`
+	Proof     []DataIntegrityProof `json:"proof"`
+}
+
+// NewWitnessMember returns the witness identified by the did:key DID of an Ed25519 key
+func NewWitnessMember(publicKey ed25519.PublicKey) (*WitnessMember, error) {
+	multikey, err := Multikey(publicKey)
+	if err != nil {
+		return nil, errors.Wrap(err, "encoding witness key")
+	}
+	return &WitnessMember{ID: key.Prefix + ":" + multikey}, nil
+}
+
+// SignWitnessProof approves a version of the DID with a witness key
+func SignWitnessProof(versionID string, witnessKey ed25519.PrivateKey) (*WitnessProof, error) {
+	if _, err := versionNumber(versionID); err != nil {
+		return nil, err
+	}
+	proof, err := signDataIntegrity(witnessDocument(versionID), witnessKey, time.Now())
+	if err != nil {
+		return nil, errors.Wrap(err, "signing witness proof")
+	}
+	return &WitnessProof{VersionID: versionID, Proof: []DataIntegrityProof{*proof}}, nil
+}
+
+// ParseWitnessProofs parses the contents of did-witness.json
+func ParseWitnessProofs(data []byte) ([]WitnessProof, error) {
+	var proofs []WitnessProof
+	if err := json.Unmarshal(data, &proofs); err != nil {
+		return nil, errors.Wrap(err, "unmarshalling witness proofs")
+	}
+	return proofs, nil
+}
+
+func witnessDocument(versionID string) map[string]any {
+	return map[string]any{"versionId": versionID}
+}
+
+// witnessApprovals returns, for each witness, the highest version number of the log it has validly approved.
+// Proofs for versions that are not in the log are ignored.
+func (l Log) witnessApprovals(witnessProofs []WitnessProof) map[string]int {
+	approvals := make(map[string]int)
+	for _, witnessProof := range witnessProofs {
+		number, err := versionNumber(witnessProof.VersionID)
+		if err != nil || number > len(l) || l[number-1].VersionID != witnessProof.VersionID {
+			continue
+		}
+		for _, proof := range witnessProof.Proof {
+			multikey, err := verifyDataIntegrity(witnessDocument(witnessProof.VersionID), proof)
+			if err != nil {
+				continue
+			}
+			witnessID := key.Prefix + ":" + multikey
+			if approvals[witnessID] < number {
+				approvals[witnessID] = number
+			}
+		}
+	}
+	return approvals
+}
+
+// approved checks that enough witnesses approved the version, or a later one
+func (w *Witness) approved(approvals map[string]int, number int) error {
+	if w == nil || w.Threshold == 0 {
+		return nil
+	}
+	var count int
+	for _, witness := range w.Witnesses {
+		if approvals[witness.ID] >= number {
+			count++
+		}
+	}
+	if count < w.Threshold {
+		return fmt.Errorf("approved by %d of the %d required witnesses", count, w.Threshold)
+	}
+	return nil
+}
@@ -68,6 +68,26 @@ func (l LDProcessor) GetContextFromMap(dataMap map[string]any) (*ld.Context, err
 	return activeCtx, nil
 }
 
+// ExpandStrict runs https://www.w3.org/TR/json-ld-api/#expansion-algorithms in safe mode, which fails on properties
+// that do not expand to an IRI or keyword instead of silently dropping them
+func (l LDProcessor) ExpandStrict(document any) ([]any, error) {
+	// the options are not copied by the processor, which would drop safe mode
+	options := l.JsonLdOptions.Copy()
+	options.SafeMode = true
+	expanded, err := ld.NewJsonLdApi().Expand(ld.NewContext(nil, options), "", document, options, false, nil)
+	if err != nil {
+		return nil, err
+	}
+	switch e := expanded.(type) {
+	case nil:
+		return []any{}, nil
+	case []any:
+		return e, nil
+	default:
+		return []any{e}, nil
+	}
+}
+
 func LDNormalize(document any) (any, error) {
 	processor := NewLDProcessor()
 	return processor.Normalize(document, processor.GetOptions())
@@ -93,6 +93,18 @@ func TestMergeUniqueValues(t *testing.T) {
 	})
 }
 
+func TestExpandStrict(t *testing.T) {
+	context := map[string]any{"name": "http://schema.org/name"}
+
+	expanded, err := NewLDProcessor().ExpandStrict(map[string]any{"@context": context, "name": "Alice"})
+	assert.NoError(t, err)
+	assert.Len(t, expanded, 1)
+
+	// properties the context does not define fail rather than being dropped
+	_, err = NewLDProcessor().ExpandStrict(map[string]any{"@context": context, "name": "Alice", "nickname": "Al"})
+	assert.ErrorContains(t, err, "invalid property")
+}
+
 func TestLDProcessor(t *testing.T) {
 	testJSONDLContextURLStr := "http://schema.org/"
 	ldProcessor := NewLDProcessor()